@@ -1,14 +1,27 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"net/http"
+	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/anakosmos/backend/src/api"
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/baseline"
+	"github.com/anakosmos/backend/src/grants"
+	"github.com/anakosmos/backend/src/ha"
 	"github.com/anakosmos/backend/src/helm"
+	"github.com/anakosmos/backend/src/i18n"
 	"github.com/anakosmos/backend/src/k8s"
+	"github.com/anakosmos/backend/src/maintenance"
+	"github.com/anakosmos/backend/src/notes"
+	"github.com/anakosmos/backend/src/settings"
+	"github.com/anakosmos/backend/src/store"
 
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -24,8 +37,56 @@ func main() {
 	}
 	port := flag.String("port", "8080", "Port to listen on")
 	devProxy := flag.String("dev-proxy", "", "Dev URL to reverse proxy to (e.g. http://localhost:5173)")
+	storageType := flag.String("storage", "memory", "Storage backend for server-side state: memory, bolt or kubernetes")
+	storagePath := flag.String("storage-path", "anakosmos.db", "Database file for the bolt storage backend")
+	storageNamespace := flag.String("storage-namespace", os.Getenv("POD_NAMESPACE"), "Namespace holding StoreRecords for the kubernetes storage backend")
+	crdConfig := flag.Bool("crd-config", false, "Reconcile ClusterConnection/LinkRule/AlertRule/SavedView/DisplayField/HealthRule CRDs into the runtime config")
+	configNamespace := flag.String("config-namespace", os.Getenv("POD_NAMESPACE"), "Namespace watched for anakosmos configuration CRDs")
+	haMode := flag.Bool("ha", false, "Enable leader election so background subsystems run on a single replica")
+	haLease := flag.String("ha-lease", "anakosmos-leader", "Name of the Lease used for leader election")
+	tenancyConfig := flag.String("tenancy-config", "", "YAML file mapping users/groups to namespaces; enables per-namespace access scoping")
+	policyRules := flag.String("policy-rules", "", "YAML file of allow/deny rules evaluated before every mutating operation")
+	policyOPAURL := flag.String("policy-opa-url", "", "OPA decision URL (e.g. http://localhost:8181/v1/data/anakosmos/allow) consulted before every mutating operation")
+	slowRequest := flag.Duration("slow-request", 2*time.Second, "Flag API requests slower than this in the request log (0 disables)")
+	traceSlow := flag.Bool("trace-slow-requests", false, "Capture span timelines of slow requests, served to admins at /api/debug/slow-requests")
+	publicStatus := flag.Bool("public-status", false, "Serve an unauthenticated, aggregate-only health summary at /api/public/status")
+	publicStatusTTL := flag.Duration("public-status-ttl", time.Minute, "How long the public status summary is cached")
+	trustedProxies := flag.String("trusted-proxies", "", "Comma-separated IPs or CIDRs of reverse proxies whose X-Forwarded-For identifies clients of the public status rate limit; empty limits by connection address")
+	healthMetrics := flag.Bool("health-metrics", false, "Export computed resource health as Prometheus gauges at /metrics")
+	healthMetricsInterval := flag.Duration("health-metrics-interval", 30*time.Second, "How often the exported resource health is rebuilt when the informer cache is off")
+	healthMetricsToken := flag.String("health-metrics-token", os.Getenv("HEALTH_METRICS_TOKEN"), "Bearer token required at /metrics to export per-resource health; without one only per-namespace counts are exported")
+	trafficPrometheus := flag.String("traffic-prometheus-url", "", "Prometheus URL scraping Istio or Linkerd; enables the observed traffic layer at /api/traffic")
+	trafficInterval := flag.Duration("traffic-interval", 30*time.Second, "How often observed mesh traffic is re-queried from Prometheus")
+	restartThreshold := flag.Int("restart-threshold", 3, "Mark pods as flapping after more than this many restarts within --restart-window (0 disables)")
+	restartWindow := flag.Duration("restart-window", 10*time.Minute, "Sliding window for pod restart trend detection")
+	baselineInterval := flag.Duration("baseline-interval", 5*time.Minute, "How often clusters with a baseline are compared against it (0 disables)")
+	cleanupInterval := flag.Duration("cleanup-interval", 0, "Periodically delete old finished Jobs, succeeded Pods and scaled-down ReplicaSets (0 disables)")
+	cleanupDays := flag.Int("cleanup-days", 7, "Age in days after which finished Jobs and succeeded Pods are cleaned up")
+	systemNamespaces := flag.String("system-namespaces", strings.Join(k8s.DefaultSystemNamespaces, ","), "Comma-separated namespaces (globs allowed) hidden from init and watch unless ?includeSystem=true; empty hides nothing")
+	informerCache := flag.Bool("informer-cache", true, "Serve /api/cluster/init from shared informer caches instead of listing the cluster on every call, keeping the full graph precomputed in the background (?refresh=true bypasses them)")
+	namespaced := flag.Bool("namespaced", false, "Run with namespace-scoped RBAC: list and watch only namespaces the service account may read (found via SelfSubjectRulesReview), omitting cluster-scoped kinds")
+	namespacedCandidates := flag.String("namespaced-namespaces", "", "Comma-separated namespaces checked in --namespaced mode when Namespaces can't be listed (the pod's own namespace is always checked)")
+	appGroupLabels := flag.String("app-group-labels", strings.Join(k8s.DefaultAppGroupLabels, ","), "Comma-separated labels grouping resources into ApplicationGroup nodes, first found wins; empty disables grouping")
+	cleanupKeepRevisions := flag.Int("cleanup-keep-revisions", 3, "Scaled-down ReplicaSets kept per Deployment by the cleanup (at least 1)")
 	flag.Parse()
 
+	k8s.ConfigureRestartTracking(*restartThreshold, *restartWindow)
+	k8s.ConfigureInformerCache(*informerCache)
+	var systemPatterns []string
+	for _, ns := range strings.Split(*systemNamespaces, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			systemPatterns = append(systemPatterns, ns)
+		}
+	}
+	k8s.ConfigureSystemNamespaces(systemPatterns)
+	var groupLabels []string
+	for _, key := range strings.Split(*appGroupLabels, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			groupLabels = append(groupLabels, key)
+		}
+	}
+	k8s.ConfigureAppGroupLabels(groupLabels)
+
 	// Try to build config from flags
 	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
 	if err != nil {
@@ -37,9 +98,254 @@ func main() {
 		}
 	}
 
+	if *namespaced && config != nil {
+		var candidates []string
+		for _, ns := range strings.Split(*namespacedCandidates, ",") {
+			if ns = strings.TrimSpace(ns); ns != "" {
+				candidates = append(candidates, ns)
+			}
+		}
+		k8s.ConfigureNamespacedMode(config, candidates)
+		log.Println("Namespaced mode: cluster-scoped kinds are not listed")
+	}
+	if config != nil {
+		// Build the local graph ahead of the first /api/cluster/init
+		k8s.WarmInitCache(config)
+	}
+
+	// Server-side persistence
+	appStore, err := store.New(store.Options{
+		Type:      *storageType,
+		Path:      *storagePath,
+		Namespace: *storageNamespace,
+		Config:    config,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize %s storage: %v", *storageType, err)
+	}
+	defer appStore.Close()
+	log.Printf("Using %s storage backend\n", *storageType)
+
+	// Multi-tenant namespace scoping
+	var tenancy *auth.TenancyConfig
+	if *tenancyConfig != "" {
+		tenancy, err = auth.LoadTenancyConfig(*tenancyConfig)
+		if err != nil {
+			log.Fatalf("Failed to load tenancy config: %v", err)
+		}
+		log.Printf("Tenancy mode enabled with %d tenants\n", len(tenancy.Tenants))
+	}
+
+	// Authorization policy evaluated before mutating operations
+	switch {
+	case *policyRules != "" && *policyOPAURL != "":
+		log.Fatalf("--policy-rules and --policy-opa-url are mutually exclusive")
+	case *policyRules != "":
+		rules, err := auth.LoadRulesPolicy(*policyRules)
+		if err != nil {
+			log.Fatalf("Failed to load policy rules: %v", err)
+		}
+		auth.SetPolicy(rules)
+		log.Printf("Authorization policy enabled with %d rules\n", len(rules.Rules))
+	case *policyOPAURL != "":
+		auth.SetPolicy(auth.NewOPAPolicy(*policyOPAURL))
+		log.Printf("Authorization policy delegated to OPA at %s\n", *policyOPAURL)
+	}
+
+	// Policies see both the kind and the resource of every operation
+	if config != nil {
+		if mapper, err := k8s.NewKindMapper(config); err == nil {
+			auth.SetKindMapper(mapper)
+		} else {
+			log.Printf("Warning: policy kinds won't be resolved: %v\n", err)
+		}
+	}
+
+	// API tokens for automation clients
+	tokens := auth.NewTokenManager(appStore)
+
+	// Maintenance windows silencing alerts
+	maintenanceWindows := maintenance.NewManager(appStore)
+	maintenance.Configure(maintenanceWindows)
+
+	// Break-glass exec/log access, enforced by auth.Authorize and the scope checks
+	accessGrants := grants.NewManager(appStore)
+	auth.SetGrantSource(accessGrants)
+
+	// Notes and pins on graph resources
+	resourceNotes := notes.NewManager(appStore)
+	notes.Configure(resourceNotes)
+
+	// Golden baselines that cluster states are compared against
+	clusterBaselines := baseline.NewManager(appStore)
+
+	// Leader election for background subsystems
+	elector := ha.NewSingleReplica()
+	if *haMode {
+		if *storageType != "kubernetes" {
+			log.Fatal("--ha requires --storage=kubernetes so replicas share state")
+		}
+		identity := os.Getenv("POD_NAME")
+		if identity == "" {
+			identity, _ = os.Hostname()
+		}
+		elector, err = ha.NewLeaseElector(config, *storageNamespace, *haLease, identity)
+		if err != nil {
+			log.Fatalf("Failed to create leader elector: %v", err)
+		}
+		go elector.Run(context.Background())
+		log.Printf("HA mode enabled, replica %s competing for lease %s\n", identity, *haLease)
+	}
+
+	// Scheduled maintenance cleanup, on the leader only
+	if *cleanupInterval > 0 {
+		if *cleanupDays < 0 {
+			log.Fatal("--cleanup-days must not be negative")
+		}
+		if *cleanupKeepRevisions < 1 {
+			log.Fatal("--cleanup-keep-revisions must be at least 1")
+		}
+		if config == nil {
+			log.Println("Warning: --cleanup-interval requires a cluster connection, skipping")
+		} else {
+			opts := k8s.CleanupOptions{
+				MaxAge:        time.Duration(*cleanupDays) * 24 * time.Hour,
+				KeepRevisions: *cleanupKeepRevisions,
+			}
+			elector.RunWhenLeader("cleanup", k8s.CleanupLoop(config, opts, *cleanupInterval))
+			log.Printf("Scheduled cleanup every %s\n", *cleanupInterval)
+		}
+	}
+
+	// Baseline deviation checks, on the leader only
+	if *baselineInterval > 0 {
+		elector.RunWhenLeader("baseline", baseline.Loop(clusterBaselines, *baselineInterval))
+	}
+
+	// Registered clusters resolve their token Secrets from the config namespace
+	k8s.ConfigureClusters(config, *configNamespace)
+
+	// Configuration-as-code
+	if *crdConfig {
+		if config == nil || *configNamespace == "" {
+			log.Println("Warning: --crd-config requires a cluster connection and --config-namespace, skipping")
+		} else {
+			controller, err := settings.NewController(config, *configNamespace, settings.Current())
+			if err != nil {
+				log.Fatalf("Failed to create config controller: %v", err)
+			}
+			go controller.Run(context.Background())
+			log.Printf("Reconciling anakosmos CRDs from namespace %s\n", *configNamespace)
+		}
+	}
+
 	// API Routes
 	// Status
-	http.HandleFunc("/api/status", api.StatusHandler(config))
+	http.HandleFunc("/api/status", api.StatusHandler(config, elector))
+
+	// Slow request traces
+	http.HandleFunc("/api/debug/slow-requests", api.SlowRequestsHandler())
+
+	// API token issuance (admin only)
+	http.HandleFunc("/api/tokens", auth.TokensHandler(tokens))
+	http.HandleFunc("/api/tokens/", auth.TokensHandler(tokens))
+
+	// Maintenance windows (declaring and ending them is admin only)
+	http.HandleFunc("/api/maintenance", maintenance.Handler(maintenanceWindows))
+	http.HandleFunc("/api/maintenance/", maintenance.Handler(maintenanceWindows))
+
+	// Incident-response drills: synthetic health failures (admin only)
+	http.HandleFunc("/api/drills", k8s.DrillsHandler())
+	http.HandleFunc("/api/drills/", k8s.DrillsHandler())
+
+	// Time-bounded exec/log access grants (granting and revoking are admin only)
+	http.HandleFunc("/api/access-grants", grants.Handler(accessGrants))
+	http.HandleFunc("/api/access-grants/", grants.Handler(accessGrants))
+
+	// Notes and pins on graph resources, merged into /api/cluster/init
+	http.HandleFunc("/api/resources/notes", notes.Handler(resourceNotes))
+
+	// Cluster baselines (marking and removing are admin only) and deviations
+	http.HandleFunc("/api/cluster/baseline", baseline.Handler(clusterBaselines))
+	http.HandleFunc("/api/cluster/baseline-diff", baseline.DiffHandler(clusterBaselines))
+
+	// Runtime configuration reconciled from CRDs
+	http.HandleFunc("/api/config", settings.HandleConfig)
+
+	// Localized message catalog for backend statuses, reasons and errors
+	http.HandleFunc("/api/i18n", i18n.HandleCatalog)
+
+	// Pods with the most restarts seen by init/watch
+	http.HandleFunc("/api/pods/restarts", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var restartsConfig *rest.Config
+		if targetUrl != "" {
+			restartsConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			restartsConfig = config
+		}
+
+		if restartsConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleRestartOffenders(restartsConfig, w, r)
+	})
+
+	// DNS checks of external endpoint hostnames
+	http.HandleFunc("/api/external/resolve", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var resolveConfig *rest.Config
+		if targetUrl != "" {
+			resolveConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			resolveConfig = config
+		}
+
+		if resolveConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleResolveHosts(resolveConfig, w, r)
+	})
+
+	// Resource health for Prometheus (opt-in, local cluster only)
+	if *healthMetrics {
+		http.HandleFunc("/metrics", k8s.HealthMetricsHandler(config, *healthMetricsInterval, *healthMetricsToken))
+	}
+
+	// Public status page (opt-in, always reports on the local cluster)
+	if *publicStatus {
+		var proxies []string
+		for _, p := range strings.Split(*trustedProxies, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				proxies = append(proxies, p)
+			}
+		}
+		trusted, err := k8s.ParseTrustedProxies(proxies)
+		if err != nil {
+			log.Fatalf("--trusted-proxies: %v", err)
+		}
+		http.HandleFunc("/api/public/status", k8s.PublicStatusHandler(config, *publicStatusTTL, trusted))
+	}
+
+	// Observed service mesh traffic (opt-in, local cluster only)
+	if *trafficPrometheus != "" {
+		http.HandleFunc("/api/traffic", k8s.TrafficHandler(config, *trafficPrometheus, *trafficInterval))
+		log.Printf("Observed traffic layer enabled from %s\n", *trafficPrometheus)
+	}
 
 	// Exec Handler
 	http.HandleFunc("/api/sock/exec", func(w http.ResponseWriter, r *http.Request) {
@@ -58,7 +364,7 @@ func main() {
 		}
 
 		if execConfig == nil {
-			http.Error(w, "Kubernetes config not loaded", http.StatusServiceUnavailable)
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
 			return
 		}
 		k8s.HandleExec(execConfig, w, r)
@@ -81,7 +387,7 @@ func main() {
 		}
 
 		if watchConfig == nil {
-			http.Error(w, "Kubernetes config not loaded", http.StatusServiceUnavailable)
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
 			return
 		}
 		k8s.HandleWatch(watchConfig, w, r)
@@ -104,12 +410,84 @@ func main() {
 		}
 
 		if watchConfig == nil {
-			http.Error(w, "Kubernetes config not loaded", http.StatusServiceUnavailable)
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
 			return
 		}
 		k8s.HandleSingleWatch(watchConfig, w, r)
 	})
 
+	// Target/token check with actionable errors
+	http.HandleFunc("/api/clusters/validate", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var validateConfig *rest.Config
+		if targetUrl != "" {
+			validateConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			validateConfig = config
+		}
+
+		if validateConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleValidateCluster(validateConfig, w, r)
+	})
+
+	// Optional APIs and features of a cluster: /api/clusters/{id}/capabilities
+	http.HandleFunc("/api/clusters/", k8s.HandleClusterCapabilities)
+
+	// Rollout progress stream
+	http.HandleFunc("/api/sock/rollout", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var rolloutConfig *rest.Config
+		if targetUrl != "" {
+			rolloutConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			rolloutConfig = config
+		}
+
+		if rolloutConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleRolloutWatch(rolloutConfig, w, r)
+	})
+
+	// Activity feed (operations, alerts, cluster connectivity)
+	http.HandleFunc("/api/sock/activity", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var activityConfig *rest.Config
+		if targetUrl != "" {
+			activityConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			activityConfig = config
+		}
+
+		if activityConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleActivity(activityConfig, w, r)
+	})
+
 	// Cluster Init Handler - returns all resources in lightweight format with pre-calculated links
 	http.HandleFunc("/api/cluster/init", func(w http.ResponseWriter, r *http.Request) {
 		targetUrl := r.URL.Query().Get("target")
@@ -127,12 +505,35 @@ func main() {
 		}
 
 		if initConfig == nil {
-			http.Error(w, "Kubernetes config not loaded", http.StatusServiceUnavailable)
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
 			return
 		}
 		k8s.HandleInit(initConfig, w, r)
 	})
 
+	// Changes since the resourceVersion of an init response, for reconnects
+	http.HandleFunc("/api/cluster/delta", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var deltaConfig *rest.Config
+		if targetUrl != "" {
+			deltaConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			deltaConfig = config
+		}
+
+		if deltaConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleDelta(deltaConfig, w, r)
+	})
+
 	// Apply YAML Handler
 	http.HandleFunc("/api/resources/apply-yaml", func(w http.ResponseWriter, r *http.Request) {
 		targetUrl := r.URL.Query().Get("target")
@@ -150,12 +551,448 @@ func main() {
 		}
 
 		if applyConfig == nil {
-			http.Error(w, "Kubernetes config not loaded", http.StatusServiceUnavailable)
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
 			return
 		}
 		k8s.HandleApplyYaml(applyConfig, w, r)
 	})
 
+	// YAML editing with optimistic concurrency (GET, PUT)
+	http.HandleFunc("/api/resources/edit", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var editConfig *rest.Config
+		if targetUrl != "" {
+			editConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			editConfig = config
+		}
+
+		if editConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleEdit(editConfig, w, r)
+	})
+
+	// Field manager ownership of a resource (managedFields summary)
+	http.HandleFunc("/api/resources/managers", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var managersConfig *rest.Config
+		if targetUrl != "" {
+			managersConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			managersConfig = config
+		}
+
+		if managersConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleManagedFields(managersConfig, w, r)
+	})
+
+	// Image manifest, config and attached SBOM/attestations from the registry
+	http.HandleFunc("/api/images/inspect", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var imageConfig *rest.Config
+		if targetUrl != "" {
+			imageConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			imageConfig = config
+		}
+
+		if imageConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleImageInspect(imageConfig, w, r)
+	})
+
+	// ConfigMap/Secret consumers, with a rollout restart action
+	http.HandleFunc("/api/config/usage", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var usageConfig *rest.Config
+		if targetUrl != "" {
+			usageConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			usageConfig = config
+		}
+
+		if usageConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleConfigUsage(usageConfig, w, r)
+	})
+
+	// Immutable ConfigMap/Secret rotation
+	http.HandleFunc("/api/config/rotate", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var rotateConfig *rest.Config
+		if targetUrl != "" {
+			rotateConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			rotateConfig = config
+		}
+
+		if rotateConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleConfigRotate(rotateConfig, w, r)
+	})
+
+	// Quota headroom check for YAML about to be applied
+	http.HandleFunc("/api/resources/quota-check", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var checkConfig *rest.Config
+		if targetUrl != "" {
+			checkConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			checkConfig = config
+		}
+
+		if checkConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleQuotaCheck(checkConfig, w, r)
+	})
+
+	// Dry-run blast radius of a delete
+	http.HandleFunc("/api/resources/impact", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var impactConfig *rest.Config
+		if targetUrl != "" {
+			impactConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			impactConfig = config
+		}
+
+		if impactConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleImpact(impactConfig, w, r)
+	})
+
+	// PodSecurity admission levels and workload evaluation
+	http.HandleFunc("/api/security/podsecurity", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var securityConfig *rest.Config
+		if targetUrl != "" {
+			securityConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			securityConfig = config
+		}
+
+		if securityConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandlePodSecurityReport(securityConfig, w, r)
+	})
+
+	// Warning event trends for triage
+	http.HandleFunc("/api/events/summary", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var eventsConfig *rest.Config
+		if targetUrl != "" {
+			eventsConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			eventsConfig = config
+		}
+
+		if eventsConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleEventSummary(eventsConfig, w, r)
+	})
+
+	// Requested vs allocatable capacity and scheduling headroom
+	http.HandleFunc("/api/cluster/capacity", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var capacityConfig *rest.Config
+		if targetUrl != "" {
+			capacityConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			capacityConfig = config
+		}
+
+		if capacityConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleCapacity(capacityConfig, w, r)
+	})
+
+	// Resources partitioned by a label or annotation value
+	http.HandleFunc("/api/cluster/groups", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var groupsConfig *rest.Config
+		if targetUrl != "" {
+			groupsConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			groupsConfig = config
+		}
+
+		if groupsConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleGroups(groupsConfig, w, r)
+	})
+
+	// Resources of one Helm release or ArgoCD Application
+	http.HandleFunc("/api/cluster/subgraph", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var subgraphConfig *rest.Config
+		if targetUrl != "" {
+			subgraphConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			subgraphConfig = config
+		}
+
+		if subgraphConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleSubgraph(subgraphConfig, w, r)
+	})
+
+	// Ranked, paginated search over the cached graph
+	http.HandleFunc("/api/cluster/search", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var searchConfig *rest.Config
+		if targetUrl != "" {
+			searchConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			searchConfig = config
+		}
+
+		if searchConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleSearch(searchConfig, w, r)
+	})
+
+	// Per namespace counts, health rollup and quota usage
+	http.HandleFunc("/api/cluster/namespaces", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var namespacesConfig *rest.Config
+		if targetUrl != "" {
+			namespacesConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			namespacesConfig = config
+		}
+
+		if namespacesConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleNamespaces(namespacesConfig, w, r)
+	})
+
+	http.HandleFunc("/api/cluster/graph-stats", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var statsConfig *rest.Config
+		if targetUrl != "" {
+			statsConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			statsConfig = config
+		}
+
+		if statsConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleGraphStats(statsConfig, w, r)
+	})
+
+	// Inventory export as CSV or JSON
+	http.HandleFunc("/api/resources/export", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var exportConfig *rest.Config
+		if targetUrl != "" {
+			exportConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			exportConfig = config
+		}
+
+		if exportConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleExport(exportConfig, w, r)
+	})
+
+	// Kind search over the cached discovery document
+	http.HandleFunc("/api/meta/resources", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var metaConfig *rest.Config
+		if targetUrl != "" {
+			metaConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			metaConfig = config
+		}
+
+		if metaConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleMetaResources(metaConfig, w, r)
+	})
+
+	// Cleanup of finished Jobs, succeeded Pods and old ReplicaSets (GET previews)
+	http.HandleFunc("/api/maintenance/cleanup", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var cleanupConfig *rest.Config
+		if targetUrl != "" {
+			cleanupConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			cleanupConfig = config
+		}
+
+		if cleanupConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleCleanup(cleanupConfig, w, r)
+	})
+
+	// Node drain simulation: /api/nodes/{name}/drain-plan
+	http.HandleFunc("/api/nodes/", func(w http.ResponseWriter, r *http.Request) {
+		targetUrl := r.URL.Query().Get("target")
+		token := r.URL.Query().Get("token")
+
+		var nodesConfig *rest.Config
+		if targetUrl != "" {
+			nodesConfig = &rest.Config{
+				Host:            targetUrl,
+				BearerToken:     token,
+				TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+			}
+		} else {
+			nodesConfig = config
+		}
+
+		if nodesConfig == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		k8s.HandleDrainPlan(nodesConfig, w, r)
+	})
+
 	// Helm Handler - MUST be registered BEFORE /api/ catch-all
 	http.HandleFunc("/api/helm/", func(w http.ResponseWriter, r *http.Request) {
 		targetUrl := r.URL.Query().Get("target")
@@ -173,7 +1010,7 @@ func main() {
 		}
 
 		if helmConfig == nil {
-			http.Error(w, "Kubernetes config not loaded", http.StatusServiceUnavailable)
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
 			return
 		}
 		helm.HandleHelmRequest(helmConfig, w, r)
@@ -198,7 +1035,11 @@ func main() {
 	}
 
 	log.Printf("Server starting on :%s\n", *port)
-	if err := http.ListenAndServe(":"+*port, nil); err != nil {
+	handler := api.LoggingMiddleware(auth.Middleware(http.DefaultServeMux, tenancy, tokens), api.LoggingOptions{
+		SlowThreshold: *slowRequest,
+		TraceSlow:     *traceSlow,
+	})
+	if err := http.ListenAndServe(":"+*port, handler); err != nil {
 		log.Fatal(err)
 	}
 }
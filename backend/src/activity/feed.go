@@ -0,0 +1,192 @@
+package activity
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anakosmos/backend/src/auth"
+)
+
+// Item is one significant happening pushed to activity feed subscribers
+type Item struct {
+	ID       int64  `json:"id"`
+	Time     string `json:"time"`
+	Type     string `json:"type"`     // operation, alert, cluster, maintenance, drill
+	Severity string `json:"severity"` // info, success, warning, error
+	// Cluster is the target URL or "local", normalized by ClusterID when
+	// published; subscribers only receive items of the cluster they proved
+	// access to, or every item when it is empty
+	Cluster   string `json:"cluster"`
+	Actor     string `json:"actor,omitempty"`
+	Verb      string `json:"verb,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Message   string `json:"message"`
+	// Self is set per subscriber when the item results from its own user
+	Self bool `json:"self,omitempty"`
+}
+
+// subscriberBuffer is how many items a slow subscriber may lag behind before
+// new items are dropped for it
+const subscriberBuffer = 64
+
+var feed = struct {
+	sync.Mutex
+	nextID      int64
+	subscribers map[chan Item]bool
+}{subscribers: make(map[chan Item]bool)}
+
+// Stamp assigns the item its ID and time, for items sent to a single
+// subscriber without being published
+func Stamp(item Item) Item {
+	feed.Lock()
+	defer feed.Unlock()
+	return stamp(item)
+}
+
+func stamp(item Item) Item {
+	feed.nextID++
+	item.ID = feed.nextID
+	if item.Time == "" {
+		item.Time = time.Now().UTC().Format(time.RFC3339)
+	}
+	return item
+}
+
+// Publish stamps the item and fans it out to every subscriber without
+// blocking; subscribers filter what they forward by ClusterID
+func Publish(item Item) {
+	item.Cluster = ClusterID(item.Cluster)
+	feed.Lock()
+	defer feed.Unlock()
+	item = stamp(item)
+	for ch := range feed.subscribers {
+		select {
+		case ch <- item:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel receiving every published item and a function
+// releasing it
+func Subscribe() (<-chan Item, func()) {
+	ch := make(chan Item, subscriberBuffer)
+	feed.Lock()
+	feed.subscribers[ch] = true
+	feed.Unlock()
+	return ch, func() {
+		feed.Lock()
+		delete(feed.subscribers, ch)
+		feed.Unlock()
+	}
+}
+
+// Operation builds an item for a mutating operation performed on behalf of
+// the caller of r. A nil err reports success.
+func Operation(r *http.Request, verb, kind, namespace, name string, err error) Item {
+	item := Item{
+		Type:      "operation",
+		Severity:  "success",
+		Cluster:   auth.RequestCluster(r),
+		Actor:     auth.IdentityFromContext(r.Context()).User,
+		Verb:      verb,
+		Kind:      kind,
+		Namespace: namespace,
+		Name:      name,
+		Message:   describe(verb, kind, namespace, name),
+	}
+	if err != nil {
+		item.Severity = "error"
+		item.Message += " failed: " + err.Error()
+	}
+	return item
+}
+
+// Denied records an operation refused by the authorization policy on the
+// feed and in the log, so blocked attempts leave an audit trail
+func Denied(r *http.Request, req auth.PolicyRequest, decision auth.PolicyDecision) {
+	kind := req.Kind
+	if kind == "" {
+		kind = req.Resource
+	}
+	item := Operation(r, req.Verb, kind, req.Namespace, req.Name, nil)
+	item.Severity = "warning"
+	item.Message += " denied"
+	logged := item.Message
+	if len(req.Command) > 0 {
+		// Arguments may hold secrets: only the log gets the full command line
+		item.Message += " (" + req.Command[0] + ")"
+		logged += " (" + strings.Join(req.Command, " ") + ")"
+	}
+	item.Message += ": " + decision.Denied()
+	log.Printf("Policy: %s: %s (user %q)", logged, decision.Denied(), req.User)
+	Publish(item)
+}
+
+// BreakGlass records an exec or log session opened while the caller holds an
+// access grant, on the feed, in the log and on the grant's audit trail
+func BreakGlass(r *http.Request, req auth.PolicyRequest, grant *auth.Grant) {
+	kind := req.Kind
+	if kind == "" {
+		kind = req.Resource
+	}
+	item := Operation(r, req.Verb, kind, req.Namespace, req.Name, nil)
+	item.Severity = "warning"
+	logged := item.Message
+	if len(req.Command) > 0 {
+		// The grant's audit trail and the log keep the full command line
+		item.Message += " (" + req.Command[0] + ")"
+		logged += " (" + strings.Join(req.Command, " ") + ")"
+	}
+	suffix := " under access grant " + grant.ID + ": " + grant.Reason
+	item.Message += suffix
+	log.Printf("Access grant: %s%s (user %q)", logged, suffix, req.User)
+	Publish(item)
+	auth.RecordGrantUse(r.Context(), grant.ID, auth.GrantUse{
+		Time:    time.Now().UTC(),
+		Verb:    req.Verb,
+		Pod:     req.Name,
+		Command: req.Command,
+	})
+}
+
+// ClusterID normalizes a target URL so the spellings of one API server
+// ("https://Prod:443/", "https://prod") name the same cluster; "local" and
+// unparsable targets are returned as they are
+func ClusterID(target string) string {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return target
+	}
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" || (u.Scheme == "https" && port == "443") || (u.Scheme == "http" && port == "80") {
+		port = ""
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	return strings.ToLower(u.Scheme) + "://" + host + strings.TrimRight(u.Path, "/")
+}
+
+func describe(verb, kind, namespace, name string) string {
+	switch {
+	case name == "" && namespace != "":
+		return fmt.Sprintf("%s %s in %s", verb, kind, namespace)
+	case name == "":
+		return fmt.Sprintf("%s %s", verb, kind)
+	case namespace == "":
+		return fmt.Sprintf("%s %s %s", verb, kind, name)
+	}
+	return fmt.Sprintf("%s %s %s/%s", verb, kind, namespace, name)
+}
@@ -0,0 +1,23 @@
+package activity
+
+import "testing"
+
+func TestClusterID(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"local", "local"},
+		{"https://prod.example.com", "https://prod.example.com"},
+		{"https://Prod.Example.com:443/", "https://prod.example.com"},
+		{"HTTPS://prod.example.com:6443", "https://prod.example.com:6443"},
+		{"http://10.0.0.1:80", "http://10.0.0.1"},
+		{"https://[fd00::1]:443", "https://[fd00::1]"},
+		{"https://gateway.example.com/k8s/prod/", "https://gateway.example.com/k8s/prod"},
+	}
+	for _, tt := range tests {
+		if got := ClusterID(tt.target); got != tt.want {
+			t.Errorf("ClusterID(%q) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
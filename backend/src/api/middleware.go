@@ -0,0 +1,218 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anakosmos/backend/src/auth"
+)
+
+// LoggingOptions configures the request logging middleware
+type LoggingOptions struct {
+	// SlowThreshold flags requests taking longer than this (0 disables)
+	SlowThreshold time.Duration
+	// TraceSlow keeps the span timeline of slow requests for /api/debug/slow-requests
+	TraceSlow bool
+}
+
+// responseRecorder captures status and size while staying usable for
+// WebSocket upgrades and streaming responses.
+type responseRecorder struct {
+	http.ResponseWriter
+	status   int
+	bytes    int64
+	hijacked bool
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(p []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	n, err := rr.ResponseWriter.Write(p)
+	rr.bytes += int64(n)
+	return n, err
+}
+
+func (rr *responseRecorder) Flush() {
+	if f, ok := rr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rr *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rr.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	rr.hijacked = true
+	rr.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+// Span is a named, timed step recorded while serving a request
+type Span struct {
+	Name     string        `json:"name"`
+	Start    time.Duration `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Trace collects the spans of a single request
+type Trace struct {
+	mu    sync.Mutex
+	start time.Time
+	spans []Span
+}
+
+// SlowRequest is a logged request that exceeded the slow threshold
+type SlowRequest struct {
+	Time     time.Time     `json:"time"`
+	Method   string        `json:"method"`
+	Path     string        `json:"path"`
+	Target   string        `json:"target,omitempty"`
+	User     string        `json:"user,omitempty"`
+	Status   int           `json:"status"`
+	Duration time.Duration `json:"duration"`
+	Spans    []Span        `json:"spans,omitempty"`
+}
+
+type traceKey struct{}
+
+// StartSpan records a step of the current request when tracing is enabled.
+// Usage: defer api.StartSpan(ctx, "list pods")()
+func StartSpan(ctx context.Context, name string) func() {
+	t, _ := ctx.Value(traceKey{}).(*Trace)
+	if t == nil {
+		return func() {}
+	}
+	begin := time.Now()
+	return func() {
+		t.mu.Lock()
+		t.spans = append(t.spans, Span{Name: name, Start: begin.Sub(t.start), Duration: time.Since(begin)})
+		t.mu.Unlock()
+	}
+}
+
+const maxSlowRequests = 50
+
+var (
+	slowMu       sync.Mutex
+	slowRequests []SlowRequest
+)
+
+func recordSlowRequest(req SlowRequest) {
+	slowMu.Lock()
+	defer slowMu.Unlock()
+	slowRequests = append(slowRequests, req)
+	if len(slowRequests) > maxSlowRequests {
+		slowRequests = slowRequests[len(slowRequests)-maxSlowRequests:]
+	}
+}
+
+// requestTarget returns the host of the cluster a request is aimed at, never the token
+func requestTarget(r *http.Request) string {
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		target = r.Header.Get("X-Kube-Target")
+	}
+	if target == "" {
+		return ""
+	}
+	if u, err := url.Parse(target); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return target
+}
+
+// LoggingMiddleware logs every API request with method, path, cluster target,
+// user, status, bytes and duration, and flags requests slower than the threshold.
+func LoggingMiddleware(next http.Handler, opts LoggingOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Static assets and the dev proxy are not worth logging
+		if !strings.HasPrefix(r.URL.Path, "/api/") && !strings.HasPrefix(r.URL.Path, "/proxy/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		// The user is the identity the auth middleware resolved (API token or
+		// trusted proxy headers), not whatever headers the client sent
+		ctx, identity := auth.TrackIdentity(r.Context())
+		r = r.WithContext(ctx)
+		var trace *Trace
+		if opts.TraceSlow {
+			trace = &Trace{start: start}
+			r = r.WithContext(context.WithValue(r.Context(), traceKey{}, trace))
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		duration := time.Since(start)
+
+		target := requestTarget(r)
+		user := identity().User
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		slow := !rec.hijacked && opts.SlowThreshold > 0 && duration > opts.SlowThreshold
+		flag := ""
+		if slow {
+			flag = " SLOW"
+		}
+		log.Printf("%s %s target=%q user=%q status=%d bytes=%d duration=%s%s",
+			r.Method, r.URL.Path, target, user, status, rec.bytes, duration.Round(time.Millisecond), flag)
+
+		if slow && trace != nil {
+			trace.mu.Lock()
+			spans := append([]Span(nil), trace.spans...)
+			trace.mu.Unlock()
+			for _, s := range spans {
+				log.Printf("  span %s start=%s duration=%s", s.Name, s.Start.Round(time.Millisecond), s.Duration.Round(time.Millisecond))
+			}
+			recordSlowRequest(SlowRequest{
+				Time:     start,
+				Method:   r.Method,
+				Path:     r.URL.Path,
+				Target:   target,
+				User:     user,
+				Status:   status,
+				Duration: duration,
+				Spans:    spans,
+			})
+		}
+	})
+}
+
+// SlowRequestsHandler returns the most recent slow requests with their
+// traces. Paths, targets and users of every caller are listed, so only admins
+// may read them.
+func SlowRequestsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !auth.RequireAdmin(w, r) {
+			return
+		}
+		slowMu.Lock()
+		requests := append([]SlowRequest{}, slowRequests...)
+		slowMu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(requests)
+	}
+}
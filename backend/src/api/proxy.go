@@ -2,11 +2,18 @@ package api
 
 import (
 	"crypto/tls"
+	"fmt"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"strings"
 
+	"github.com/anakosmos/backend/src/activity"
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/rest"
 )
 
@@ -25,17 +32,22 @@ func ProxyHandler() http.HandlerFunc {
 
 		targetUrlStr := r.Header.Get("X-Kube-Target")
 		if targetUrlStr == "" {
-			http.Error(w, "X-Kube-Target header missing", http.StatusBadRequest)
+			i18n.Error(w, r, http.StatusBadRequest, "error.required", "X-Kube-Target")
 			return
 		}
 
 		target, err := url.Parse(targetUrlStr)
 		if err != nil {
-			http.Error(w, "Invalid target URL", http.StatusBadRequest)
+			i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "target URL")
+			return
+		}
+		if !requirePolicy(w, r, strings.TrimPrefix(r.URL.Path, "/proxy")) {
 			return
 		}
+		auditGrant(r, strings.TrimPrefix(r.URL.Path, "/proxy"))
 
 		proxy := httputil.NewSingleHostReverseProxy(target)
+		reportMutations(proxy, r, strings.TrimPrefix(r.URL.Path, "/proxy"))
 
 		originalDirector := proxy.Director
 		proxy.Director = func(req *http.Request) {
@@ -62,14 +74,46 @@ func ProxyHandler() http.HandlerFunc {
 
 // InternalProxyHandler handles requests to the local/in-cluster Kubernetes API
 func InternalProxyHandler(config *rest.Config) http.HandlerFunc {
+	var disco discovery.DiscoveryInterface
+	if config != nil {
+		if client, err := discovery.NewDiscoveryClientForConfig(config); err == nil {
+			disco = memory.NewMemCacheClient(client)
+		}
+	}
 	return func(w http.ResponseWriter, r *http.Request) {
 		if config == nil {
-			http.Error(w, "Kubernetes config not loaded", http.StatusServiceUnavailable)
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+
+		// Enforce tenancy scope on the proxied Kubernetes API path
+		ns, namespaced := namespaceFromAPIPath(strings.TrimPrefix(r.URL.Path, "/api"))
+		if namespaced || !isDiscoveryPath(strings.TrimPrefix(r.URL.Path, "/api")) {
+			// Access grants open exec and logs beyond the scope
+			if grantReq, ok := auth.GrantRequest(r, strings.TrimPrefix(r.URL.Path, "/api")); ok {
+				if !auth.RequireNamespaceFor(w, r, ns, grantReq.Verb) {
+					return
+				}
+			} else if !auth.RequireNamespace(w, r, ns) {
+				return
+			}
+		}
+		// Without a namespace, namespaced kinds are listed across all of them
+		// (/api/v1/secrets), which only an unrestricted scope may do
+		if !namespaced && !auth.ScopeFromContext(r.Context()).All {
+			if resource, ok := clusterScopedPath(disco, strings.TrimPrefix(r.URL.Path, "/api")); !ok {
+				i18n.Error(w, r, http.StatusForbidden, "error.namespaceRequiredFor", resource)
+				return
+			}
+		}
+		if !requirePolicy(w, r, strings.TrimPrefix(r.URL.Path, "/api")) {
 			return
 		}
+		auditGrant(r, strings.TrimPrefix(r.URL.Path, "/api"))
 
 		target, _ := url.Parse(config.Host)
 		proxy := httputil.NewSingleHostReverseProxy(target)
+		reportMutations(proxy, r, strings.TrimPrefix(r.URL.Path, "/api"))
 
 		// Update headers for auth
 		originalDirector := proxy.Director
@@ -106,6 +150,131 @@ func InternalProxyHandler(config *rest.Config) http.HandlerFunc {
 	}
 }
 
+// requirePolicy evaluates the authorization policy for mutating proxied
+// requests, writing 403 when denied
+func requirePolicy(w http.ResponseWriter, r *http.Request, path string) bool {
+	req, ok := auth.APIPathPolicyRequest(r, path)
+	if !ok {
+		return true
+	}
+	decision := auth.Authorize(r.Context(), req)
+	if !decision.Allowed {
+		// Interactive sessions aren't reported when they run, so refused
+		// ones are
+		switch req.Verb {
+		case "exec", "attach", "portforward":
+			activity.Denied(r, req, decision)
+		}
+		http.Error(w, decision.Denied(), http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// auditGrant records exec and log sessions opened under an access grant
+func auditGrant(r *http.Request, path string) {
+	req, ok := auth.GrantRequest(r, path)
+	if !ok {
+		return
+	}
+	if grant := auth.ActiveGrant(r.Context(), req); grant != nil {
+		activity.BreakGlass(r, req, grant)
+	}
+}
+
+// reportMutations publishes a proxied mutation to the activity feed once the
+// API server answered. Dry runs and interactive sessions aren't reported.
+func reportMutations(proxy *httputil.ReverseProxy, r *http.Request, path string) {
+	req, ok := auth.APIPathPolicyRequest(r, path)
+	if !ok || r.URL.Query().Get("dryRun") != "" {
+		return
+	}
+	switch req.Verb {
+	case "exec", "attach", "portforward":
+		return
+	}
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		var err error
+		if resp.StatusCode >= 400 {
+			err = fmt.Errorf("%s", resp.Status)
+		}
+		activity.Publish(activity.Operation(r, req.Verb, req.Resource, req.Namespace, req.Name, err))
+		return nil
+	}
+}
+
+// namespaceFromAPIPath extracts the namespace from a Kubernetes API path such as
+// /api/v1/namespaces/foo/pods or /apis/apps/v1/namespaces/foo/deployments.
+// GET /api/v1/namespaces/foo itself is treated as belonging to namespace foo.
+func namespaceFromAPIPath(path string) (string, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == "namespaces" && parts[i+1] != "" {
+			return parts[i+1], true
+		}
+	}
+	return "", false
+}
+
+// clusterScopedPath reports whether a Kubernetes API path without a namespace
+// addresses a cluster-scoped kind (Nodes, StorageClasses, ...) rather than a
+// namespaced one across all namespaces. Discovery paths count as
+// cluster-scoped; resources discovery doesn't know count as namespaced.
+func clusterScopedPath(disco discovery.DiscoveryInterface, path string) (resource string, ok bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	var groupVersion string
+	var rest []string
+	switch {
+	case isDiscoveryPath(path):
+		return "", true
+	case parts[0] == "api" && len(parts) > 2:
+		groupVersion, rest = parts[1], parts[2:]
+	case parts[0] == "apis" && len(parts) > 3:
+		groupVersion, rest = parts[1]+"/"+parts[2], parts[3:]
+	default:
+		return path, false
+	}
+	// Deprecated /api/v1/watch/pods form
+	if rest[0] == "watch" && len(rest) > 1 {
+		rest = rest[1:]
+	}
+	resource = rest[0]
+	if disco == nil {
+		return resource, false
+	}
+	list, err := disco.ServerResourcesForGroupVersion(groupVersion)
+	if cached, ok := disco.(discovery.CachedDiscoveryInterface); err != nil && ok {
+		// A group served since discovery was cached, e.g. a new CRD
+		cached.Invalidate()
+		list, err = disco.ServerResourcesForGroupVersion(groupVersion)
+	}
+	if err != nil {
+		return resource, false
+	}
+	for _, res := range list.APIResources {
+		if res.Name == resource {
+			return resource, !res.Namespaced
+		}
+	}
+	return resource, false
+}
+
+// isDiscoveryPath reports whether path only reads API discovery information
+// (/api, /api/v1, /apis/apps/v1, /version, /openapi/...), which carries no
+// tenant data.
+func isDiscoveryPath(path string) bool {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch parts[0] {
+	case "version", "openapi":
+		return true
+	case "api":
+		return len(parts) <= 2
+	case "apis":
+		return len(parts) <= 3
+	}
+	return false
+}
+
 // FrontendProxyHandler proxies requests to the frontend dev server (Vite)
 func FrontendProxyHandler(devProxy string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -5,11 +5,13 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/anakosmos/backend/src/ha"
+
 	"k8s.io/client-go/rest"
 )
 
 // StatusHandler returns the running environment status (in-cluster vs local)
-func StatusHandler(config *rest.Config) http.HandlerFunc {
+func StatusHandler(config *rest.Config, elector *ha.Elector) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		// Check if we are running in-cluster
@@ -17,6 +19,8 @@ func StatusHandler(config *rest.Config) http.HandlerFunc {
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"inCluster":  inCluster,
 			"configured": config != nil,
+			"replica":    elector.Identity(),
+			"leader":     elector.IsLeader(),
 		})
 	}
 }
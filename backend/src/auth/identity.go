@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Identity is the caller of an API request as forwarded by the authenticating
+// proxy in front of anakosmos (oauth2-proxy, Pomerium, ...).
+type Identity struct {
+	User   string   `json:"user"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// IdentityFromRequest extracts the caller identity from proxy headers
+func IdentityFromRequest(r *http.Request) Identity {
+	var id Identity
+	for _, h := range []string{"X-Forwarded-User", "X-Forwarded-Email", "X-Remote-User"} {
+		if v := r.Header.Get(h); v != "" {
+			id.User = v
+			break
+		}
+	}
+	for _, h := range []string{"X-Forwarded-Groups", "X-Remote-Group"} {
+		for _, v := range r.Header.Values(h) {
+			for _, g := range strings.Split(v, ",") {
+				if g = strings.TrimSpace(g); g != "" {
+					id.Groups = append(id.Groups, g)
+				}
+			}
+		}
+	}
+	return id
+}
+
+// InGroup reports whether the identity is a member of any of the given groups
+func (id Identity) InGroup(groups []string) bool {
+	for _, want := range groups {
+		for _, g := range id.Groups {
+			if g == want {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/anakosmos/backend/src/i18n"
+)
+
+type contextKey int
+
+const (
+	identityKey contextKey = iota
+	scopeKey
+	adminKey
+	tokenKey
+	identitySinkKey
+)
+
+// WithScope returns a context carrying the caller identity and scope
+func WithScope(ctx context.Context, id Identity, scope Scope) context.Context {
+	if sink, ok := ctx.Value(identitySinkKey).(*Identity); ok {
+		*sink = id
+	}
+	ctx = context.WithValue(ctx, identityKey, id)
+	return context.WithValue(ctx, scopeKey, scope)
+}
+
+// ScopeFromContext returns the request scope, unrestricted if none was set
+func ScopeFromContext(ctx context.Context) Scope {
+	if scope, ok := ctx.Value(scopeKey).(Scope); ok {
+		return scope
+	}
+	return Unrestricted
+}
+
+// IdentityFromContext returns the identity resolved by the middleware
+func IdentityFromContext(ctx context.Context) Identity {
+	id, _ := ctx.Value(identityKey).(Identity)
+	return id
+}
+
+// TrackIdentity lets a handler wrapping the middleware, like request logging,
+// learn the identity resolved further down: the returned function reports it
+// once the request was served, empty when none was resolved.
+func TrackIdentity(ctx context.Context) (context.Context, func() Identity) {
+	sink := &Identity{}
+	return context.WithValue(ctx, identitySinkKey, sink), func() Identity { return *sink }
+}
+
+// IsAdmin reports whether the request was made by an anakosmos admin
+func IsAdmin(ctx context.Context) bool {
+	admin, _ := ctx.Value(adminKey).(bool)
+	return admin
+}
+
+// AllowsCluster reports whether the API token of the request, if any, may
+// reach the cluster known by any of names (target URL, registered
+// ClusterConnection name or "local"). Requests without a token may reach any.
+func AllowsCluster(ctx context.Context, names ...string) bool {
+	token, ok := ctx.Value(tokenKey).(APIToken)
+	return !ok || token.AllowsCluster(names...)
+}
+
+// RequireCluster writes 403 and returns false when the API token of the
+// request may not reach the cluster
+func RequireCluster(w http.ResponseWriter, r *http.Request, names ...string) bool {
+	if AllowsCluster(r.Context(), names...) {
+		return true
+	}
+	i18n.Error(w, r, http.StatusForbidden, "error.clusterForbidden")
+	return false
+}
+
+// RequireAdmin writes 403 and returns false for non-admin callers
+func RequireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if IsAdmin(r.Context()) {
+		return true
+	}
+	i18n.Error(w, r, http.StatusForbidden, "error.adminRequired")
+	return false
+}
+
+// Middleware resolves the identity and tenancy scope of every API request,
+// either from an anakosmos API token or from the authenticating proxy headers.
+// With a nil tenancy config every proxy-authenticated request is unrestricted.
+func Middleware(next http.Handler, tenancy *TenancyConfig, tokens *TokenManager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") && !strings.HasPrefix(r.URL.Path, "/proxy/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Public endpoints expose only aggregate data and skip identity checks
+		if strings.HasPrefix(r.URL.Path, "/api/public/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if credential := bearerAPIToken(r); credential != "" && tokens != nil {
+			token, err := tokens.Validate(r.Context(), credential)
+			if err != nil {
+				i18n.ErrorFrom(w, r, http.StatusUnauthorized, err)
+				return
+			}
+			if err := token.AllowsRequest(r); err != nil {
+				i18n.ErrorFrom(w, r, http.StatusForbidden, err)
+				return
+			}
+			// The anakosmos token must not be forwarded to the Kubernetes API
+			r.Header.Del("Authorization")
+			id := Identity{User: "token:" + token.Name}
+			ctx := WithScope(r.Context(), id, token.Scope())
+			ctx = context.WithValue(ctx, tokenKey, token)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		id := IdentityFromRequest(r)
+		scope, ok := tenancy.ScopeFor(id)
+		if !ok {
+			i18n.Error(w, r, http.StatusForbidden, "error.noNamespaces", id.User)
+			return
+		}
+		ctx := WithScope(r.Context(), id, scope)
+		ctx = context.WithValue(ctx, adminKey, tenancy.IsAdmin(id))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequireNamespace writes 403 and returns false when ns is outside the request scope
+func RequireNamespace(w http.ResponseWriter, r *http.Request, ns string) bool {
+	if ScopeFromContext(r.Context()).Allows(ns) {
+		return true
+	}
+	if ns == "" {
+		i18n.Error(w, r, http.StatusForbidden, "error.clusterScopeForbidden")
+	} else {
+		i18n.Error(w, r, http.StatusForbidden, "error.namespaceForbidden", ns)
+	}
+	return false
+}
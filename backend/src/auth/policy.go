@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// PolicyRequest describes a mutating operation about to be performed
+type PolicyRequest struct {
+	User      string   `json:"user"`
+	Groups    []string `json:"groups,omitempty"`
+	Verb      string   `json:"verb"` // create, update, patch (also server-side apply), delete, exec, connect (a registered cluster), ...
+	Kind      string   `json:"kind,omitempty"`
+	Resource  string   `json:"resource,omitempty"` // plural, resolved from Kind and back by the KindMapper
+	Namespace string   `json:"namespace,omitempty"`
+	Name      string   `json:"name,omitempty"`
+	Cluster   string   `json:"cluster"` // target URL, or "local"
+	// Command is the command line of exec requests
+	Command []string `json:"command,omitempty"`
+}
+
+// PolicyDecision is the answer of a Policy
+type PolicyDecision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+	// Grant is the break-glass grant that overrode a denial
+	Grant *Grant `json:"grant,omitempty"`
+}
+
+// Policy decides whether a mutating operation may proceed. It runs after the
+// tenancy scope check, so it can only narrow what a caller may do.
+type Policy interface {
+	Evaluate(ctx context.Context, req PolicyRequest) (PolicyDecision, error)
+}
+
+// AllowAll is the default policy
+type AllowAll struct{}
+
+func (AllowAll) Evaluate(context.Context, PolicyRequest) (PolicyDecision, error) {
+	return PolicyDecision{Allowed: true}, nil
+}
+
+var policy = struct {
+	sync.RWMutex
+	p Policy
+}{p: AllowAll{}}
+
+// SetPolicy replaces the policy evaluated before mutating operations
+func SetPolicy(p Policy) {
+	policy.Lock()
+	defer policy.Unlock()
+	policy.p = p
+}
+
+// Authorize evaluates the policy for req. Evaluation errors deny the
+// operation: a policy that can't be consulted must not be bypassed. A denied
+// exec or log access is allowed when the caller holds an active grant.
+func Authorize(ctx context.Context, req PolicyRequest) PolicyDecision {
+	policy.RLock()
+	p := policy.p
+	policy.RUnlock()
+
+	decision, err := p.Evaluate(ctx, req)
+	if err != nil {
+		log.Printf("Policy evaluation failed for %s %s %s/%s: %v\n", req.Verb, req.Kind+req.Resource, req.Namespace, req.Name, err)
+		return PolicyDecision{Reason: "policy evaluation failed: " + err.Error()}
+	}
+	if !decision.Allowed {
+		if grant := ActiveGrant(ctx, req); grant != nil {
+			return PolicyDecision{Allowed: true, Reason: "break-glass grant " + grant.ID, Grant: grant}
+		}
+	}
+	return decision
+}
+
+// KindMapper resolves kinds to plural resource names and back, so policy
+// requests carry both whichever one the caller knows. Names it doesn't know
+// resolve to "".
+type KindMapper interface {
+	KindFor(group, resource string) string
+	ResourceFor(kind string) string
+}
+
+var kindMapper = struct {
+	sync.RWMutex
+	m KindMapper
+}{}
+
+// SetKindMapper sets the mapper filling Kind and Resource of policy requests
+func SetKindMapper(m KindMapper) {
+	kindMapper.Lock()
+	defer kindMapper.Unlock()
+	kindMapper.m = m
+}
+
+func currentKindMapper() KindMapper {
+	kindMapper.RLock()
+	defer kindMapper.RUnlock()
+	return kindMapper.m
+}
+
+// RequestCluster names the cluster a request operates on: its target URL, or
+// "local" for the cluster anakosmos runs with
+func RequestCluster(r *http.Request) string {
+	cluster := r.URL.Query().Get("target")
+	if cluster == "" {
+		cluster = r.Header.Get("X-Kube-Target")
+	}
+	if cluster == "" {
+		return "local"
+	}
+	return strings.TrimRight(cluster, "/")
+}
+
+// NewPolicyRequest fills the caller and cluster of a policy request from the
+// HTTP request
+func NewPolicyRequest(r *http.Request, verb, kind, namespace, name string) PolicyRequest {
+	id := IdentityFromContext(r.Context())
+	req := PolicyRequest{
+		User:      id.User,
+		Groups:    id.Groups,
+		Verb:      verb,
+		Kind:      kind,
+		Namespace: namespace,
+		Name:      name,
+		Cluster:   RequestCluster(r),
+	}
+	if m := currentKindMapper(); m != nil && kind != "" {
+		req.Resource = m.ResourceFor(kind)
+	}
+	return req
+}
+
+// Denied formats a denial for API responses
+func (d PolicyDecision) Denied() string {
+	if d.Reason == "" {
+		return "denied by policy"
+	}
+	return "denied by policy: " + d.Reason
+}
+
+// RequireAllowed writes 403 and returns false when the policy denies the
+// operation
+func RequireAllowed(w http.ResponseWriter, r *http.Request, verb, kind, namespace, name string) bool {
+	decision := Authorize(r.Context(), NewPolicyRequest(r, verb, kind, namespace, name))
+	if decision.Allowed {
+		return true
+	}
+	http.Error(w, decision.Denied(), http.StatusForbidden)
+	return false
+}
+
+// APIPathPolicyRequest maps a proxied Kubernetes API request to a policy
+// request. ok is false for reads, which policies don't cover.
+func APIPathPolicyRequest(r *http.Request, path string) (req PolicyRequest, ok bool) {
+	verb := ""
+	switch r.Method {
+	case http.MethodPost:
+		verb = "create"
+	case http.MethodPut:
+		verb = "update"
+	case http.MethodPatch:
+		verb = "patch"
+	case http.MethodDelete:
+		verb = "delete"
+	}
+
+	// /api/v1/namespaces/ns/pods/name/exec, /apis/apps/v1/deployments, ...
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	var group string
+	var rest []string
+	switch {
+	case len(parts) >= 2 && parts[0] == "api":
+		rest = parts[2:]
+	case len(parts) >= 3 && parts[0] == "apis":
+		group, rest = parts[1], parts[3:]
+	}
+	namespace := ""
+	if len(rest) >= 2 && rest[0] == "namespaces" {
+		namespace = rest[1]
+		if len(rest) == 2 {
+			// The namespace object itself
+			rest = []string{"namespaces", namespace}
+		} else {
+			rest = rest[2:]
+		}
+	}
+	resource, name, subresource := "", "", ""
+	if len(rest) > 0 {
+		resource = rest[0]
+	}
+	if len(rest) > 1 {
+		name = rest[1]
+	}
+	if len(rest) > 2 {
+		subresource = rest[2]
+	}
+
+	// Exec and attach upgrade a GET into an interactive session
+	switch subresource {
+	case "exec", "attach", "portforward":
+		verb = subresource
+	}
+	if verb == "" {
+		return PolicyRequest{}, false
+	}
+	if verb == "delete" && name == "" {
+		verb = "deletecollection"
+	}
+
+	req = NewPolicyRequest(r, verb, "", namespace, name)
+	req.Resource = resource
+	if m := currentKindMapper(); m != nil && resource != "" {
+		req.Kind = m.KindFor(group, resource)
+	}
+	if verb == "exec" {
+		req.Command = r.URL.Query()["command"]
+	}
+	return req, true
+}
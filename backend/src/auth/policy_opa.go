@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// OPAPolicy asks an Open Policy Agent server through its data API. The
+// decision document may be a boolean or an object {allowed, reason}:
+//
+//	package anakosmos
+//	default allow := true
+//	allow := false if { input.verb == "exec"; input.namespace == "payments" }
+//	allow := false if { input.verb == "exec"; input.command[0] == "kubectl" }
+//
+// queried at e.g. http://localhost:8181/v1/data/anakosmos/allow.
+type OPAPolicy struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewOPAPolicy queries the decision document at url
+func NewOPAPolicy(url string) *OPAPolicy {
+	return &OPAPolicy{URL: url, Client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+func (p *OPAPolicy) Evaluate(ctx context.Context, req PolicyRequest) (PolicyDecision, error) {
+	body, err := json.Marshal(map[string]PolicyRequest{"input": req})
+	if err != nil {
+		return PolicyDecision{}, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return PolicyDecision{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.Client.Do(httpReq)
+	if err != nil {
+		return PolicyDecision{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return PolicyDecision{}, fmt.Errorf("OPA returned %s", resp.Status)
+	}
+
+	var result struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return PolicyDecision{}, fmt.Errorf("invalid OPA response: %w", err)
+	}
+	if len(result.Result) == 0 {
+		// Undefined document: the policy isn't loaded
+		return PolicyDecision{}, fmt.Errorf("OPA decision %s is undefined", p.URL)
+	}
+
+	var allowed bool
+	if err := json.Unmarshal(result.Result, &allowed); err == nil {
+		return PolicyDecision{Allowed: allowed}, nil
+	}
+	var decision PolicyDecision
+	if err := json.Unmarshal(result.Result, &decision); err != nil {
+		return PolicyDecision{}, fmt.Errorf("OPA decision must be a boolean or {allowed, reason}: %w", err)
+	}
+	return decision, nil
+}
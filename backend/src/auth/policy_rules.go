@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// PolicyRule matches mutating operations. Empty lists match anything; list
+// entries may use shell globs (e.g. "payments-*"). Kinds match either the
+// kind or the plural resource name, case-insensitively.
+type PolicyRule struct {
+	Name       string   `json:"name"`
+	Effect     string   `json:"effect"` // allow or deny
+	Users      []string `json:"users,omitempty"`
+	Groups     []string `json:"groups,omitempty"`
+	Verbs      []string `json:"verbs,omitempty"`
+	Kinds      []string `json:"kinds,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+	Clusters   []string `json:"clusters,omitempty"`
+	// Commands restrict the rule to exec requests. Allow rules match the full
+	// command line, where * also spans spaces and slashes ("cat *"), and never
+	// match commands running a shell or wrapper program (env, sh, xargs, ...).
+	// Deny rules also match the program name of any argument ("bash" matches
+	// "env /bin/bash" too).
+	Commands []string `json:"commands,omitempty"`
+	Message  string   `json:"message,omitempty"`
+}
+
+// RulesPolicy evaluates rules in order; the first matching rule decides and
+// DefaultEffect applies when none matches
+type RulesPolicy struct {
+	DefaultEffect string       `json:"defaultEffect"` // allow (default) or deny
+	Rules         []PolicyRule `json:"rules"`
+}
+
+// LoadRulesPolicy reads a YAML or JSON rules file, e.g.
+//
+//	rules:
+//	  - name: no-exec-in-payments
+//	    effect: deny
+//	    verbs: [exec]
+//	    namespaces: [payments]
+//	    message: exec is disabled in payments
+//	  - name: read-only-commands
+//	    effect: allow
+//	    verbs: [exec]
+//	    commands: [ls, "ls *", "cat *"]
+//	  - name: no-other-commands
+//	    effect: deny
+//	    verbs: [exec]
+func LoadRulesPolicy(file string) (*RulesPolicy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var p RulesPolicy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid policy rules %s: %w", file, err)
+	}
+	if p.DefaultEffect == "" {
+		p.DefaultEffect = "allow"
+	}
+	if p.DefaultEffect != "allow" && p.DefaultEffect != "deny" {
+		return nil, fmt.Errorf("invalid policy rules %s: defaultEffect must be allow or deny", file)
+	}
+	for i, rule := range p.Rules {
+		if rule.Effect != "allow" && rule.Effect != "deny" {
+			return nil, fmt.Errorf("invalid policy rules %s: rule %d (%s) effect must be allow or deny", file, i, rule.Name)
+		}
+	}
+	return &p, nil
+}
+
+// matchAny reports whether value matches one of the patterns; an empty
+// pattern list matches everything
+func matchAny(patterns []string, values ...string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		for _, v := range values {
+			if v == "" {
+				continue
+			}
+			if ok, _ := path.Match(pattern, strings.ToLower(v)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (rule *PolicyRule) matches(req PolicyRequest) bool {
+	if len(rule.Users) > 0 || len(rule.Groups) > 0 {
+		byUser := len(rule.Users) > 0 && matchAny(rule.Users, req.User)
+		byGroup := len(rule.Groups) > 0 && matchAny(rule.Groups, req.Groups...)
+		if !byUser && !byGroup {
+			return false
+		}
+	}
+	return matchAny(rule.Verbs, req.Verb) &&
+		matchAny(rule.Kinds, req.Kind, req.Resource) &&
+		matchAny(rule.Namespaces, req.Namespace) &&
+		matchAny(rule.Clusters, req.Cluster) &&
+		matchCommand(rule.Commands, req.Command, rule.Effect == "allow")
+}
+
+// wrapperPrograms run other programs given as arguments, so an allowed
+// wrapper would allow anything
+var wrapperPrograms = map[string]bool{
+	"env": true, "sh": true, "bash": true, "dash": true, "ash": true, "zsh": true, "ksh": true,
+	"busybox": true, "xargs": true, "nohup": true, "timeout": true, "nice": true, "ionice": true,
+	"setsid": true, "stdbuf": true, "chroot": true, "sudo": true, "su": true, "doas": true,
+	"time": true, "watch": true, "script": true,
+}
+
+// matchCommand matches a command of an exec request. Allow rules match the
+// whole command line only and never a command involving a wrapper program;
+// deny rules also match the base name of any of its arguments. Rules with
+// commands never match requests without one.
+func matchCommand(patterns, command []string, allow bool) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	if len(command) == 0 {
+		return false
+	}
+	line := strings.Join(command, " ")
+	if allow {
+		for _, arg := range command {
+			if wrapperPrograms[path.Base(arg)] {
+				return false
+			}
+		}
+		for _, pattern := range patterns {
+			if matchCommandLine(pattern, line) {
+				return true
+			}
+		}
+		return false
+	}
+	names := make([]string, 0, len(command)+1)
+	for _, arg := range command {
+		names = append(names, path.Base(arg))
+	}
+	if matchAny(patterns, names...) {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matchCommandLine(pattern, line) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchCommandLine matches a command line against a glob whose * and ?
+// also match spaces and slashes
+func matchCommandLine(pattern, line string) bool {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	re, err := regexp.Compile("^" + expr + "$")
+	return err == nil && re.MatchString(line)
+}
+
+func (p *RulesPolicy) Evaluate(_ context.Context, req PolicyRequest) (PolicyDecision, error) {
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if !rule.matches(req) {
+			continue
+		}
+		reason := rule.Message
+		if reason == "" {
+			reason = "rule " + rule.Name
+		}
+		return PolicyDecision{Allowed: rule.Effect == "allow", Reason: reason}, nil
+	}
+	if p.DefaultEffect == "deny" {
+		return PolicyDecision{Reason: "no policy rule allows this operation"}, nil
+	}
+	return PolicyDecision{Allowed: true}, nil
+}
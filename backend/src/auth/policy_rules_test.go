@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMatchCommand(t *testing.T) {
+	allowList := []string{"ls", "ls *", "cat *"}
+	denyList := []string{"kubectl", "*sh"}
+	tests := []struct {
+		name     string
+		patterns []string
+		command  []string
+		allow    bool
+		want     bool
+	}{
+		{"no patterns", nil, []string{"anything"}, true, true},
+		{"no command", allowList, nil, true, false},
+		{"bare program", allowList, []string{"ls"}, true, true},
+		{"program with args", allowList, []string{"ls", "-la", "/tmp"}, true, true},
+		{"path spans slashes", allowList, []string{"cat", "/etc/hostname"}, true, true},
+		{"program not listed", allowList, []string{"rm", "-rf", "/"}, true, false},
+		{"allow needs full line", []string{"ls"}, []string{"ls", "-la"}, true, false},
+		{"allow by base name only", []string{"cat *"}, []string{"/bin/cat", "x"}, true, false},
+		{"env wrapper", []string{"env *"}, []string{"env", "sh", "-c", "id"}, true, false},
+		{"shell wrapper", []string{"*"}, []string{"/bin/sh", "-c", "ls"}, true, false},
+		{"xargs wrapper", allowList, []string{"ls", "xargs"}, true, false},
+		{"busybox wrapper", []string{"busybox *"}, []string{"busybox", "ls"}, true, false},
+		{"deny program", denyList, []string{"kubectl", "get", "pods"}, false, true},
+		{"deny shell by path", denyList, []string{"/bin/bash"}, false, true},
+		{"deny through env", denyList, []string{"env", "bash"}, false, true},
+		{"deny other program", denyList, []string{"ls"}, false, false},
+	}
+	for _, tt := range tests {
+		if got := matchCommand(tt.patterns, tt.command, tt.allow); got != tt.want {
+			t.Errorf("%s: matchCommand(%v, %v, allow=%t) = %t, want %t", tt.name, tt.patterns, tt.command, tt.allow, got, tt.want)
+		}
+	}
+}
+
+func TestRulesPolicyCommandAllowlist(t *testing.T) {
+	p := &RulesPolicy{
+		DefaultEffect: "allow",
+		Rules: []PolicyRule{
+			{Name: "read-only-commands", Effect: "allow", Verbs: []string{"exec"}, Commands: []string{"ls", "ls *", "cat *"}},
+			{Name: "no-other-commands", Effect: "deny", Verbs: []string{"exec"}},
+		},
+	}
+	tests := []struct {
+		command []string
+		allowed bool
+	}{
+		{[]string{"ls", "/"}, true},
+		{[]string{"cat", "/etc/os-release"}, true},
+		{[]string{"env", "sh", "-c", "rm -rf /"}, false},
+		{[]string{"env", "bash"}, false},
+		{[]string{"sh", "-c", "cat /etc/passwd"}, false},
+	}
+	for _, tt := range tests {
+		decision, err := p.Evaluate(context.Background(), PolicyRequest{Verb: "exec", Command: tt.command})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decision.Allowed != tt.allowed {
+			t.Errorf("exec %v: allowed = %t, want %t (%s)", tt.command, decision.Allowed, tt.allowed, decision.Reason)
+		}
+	}
+}
+
+type fakeKindMapper map[string]string // group/resource -> kind
+
+func (m fakeKindMapper) KindFor(group, resource string) string { return m[group+"/"+resource] }
+
+func (m fakeKindMapper) ResourceFor(kind string) string {
+	for key, k := range m {
+		if k == kind {
+			return key[strings.Index(key, "/")+1:]
+		}
+	}
+	return ""
+}
+
+func TestRulesPolicyKindsOnEveryPath(t *testing.T) {
+	SetKindMapper(fakeKindMapper{"/secrets": "Secret", "apps/deployments": "Deployment"})
+	defer SetKindMapper(nil)
+	p := &RulesPolicy{Rules: []PolicyRule{{Name: "no-secrets", Effect: "deny", Kinds: []string{"Secret"}}}}
+
+	proxied := func(method, path string) PolicyRequest {
+		req, ok := APIPathPolicyRequest(httptest.NewRequest(method, "/api"+path, nil), path)
+		if !ok {
+			t.Fatalf("%s %s: not a policy request", method, path)
+		}
+		return req
+	}
+	byKind := func(verb, kind string) PolicyRequest {
+		return NewPolicyRequest(httptest.NewRequest("POST", "/api/resources/edit", nil), verb, kind, "x", "y")
+	}
+	tests := []struct {
+		name    string
+		req     PolicyRequest
+		allowed bool
+	}{
+		{"proxied secret delete", proxied("DELETE", "/api/v1/namespaces/x/secrets/y"), false},
+		{"proxied deployment patch", proxied("PATCH", "/apis/apps/v1/namespaces/x/deployments/y"), true},
+		{"edited secret", byKind("update", "Secret"), false},
+		{"edited deployment", byKind("update", "Deployment"), true},
+	}
+	for _, tt := range tests {
+		decision, err := p.Evaluate(context.Background(), tt.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decision.Allowed != tt.allowed {
+			t.Errorf("%s (kind %q, resource %q): allowed = %t, want %t", tt.name, tt.req.Kind, tt.req.Resource, decision.Allowed, tt.allowed)
+		}
+	}
+	if req := proxied("DELETE", "/api/v1/namespaces/x/secrets/y"); req.Kind != "Secret" || req.Resource != "secrets" {
+		t.Errorf("proxied request kind %q, resource %q, want Secret and secrets", req.Kind, req.Resource)
+	}
+	if req := byKind("update", "Deployment"); req.Resource != "deployments" {
+		t.Errorf("edit request resource %q, want deployments", req.Resource)
+	}
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Tenant maps a set of users/groups to the namespaces they may access
+type Tenant struct {
+	Name          string   `json:"name"`
+	Users         []string `json:"users"`
+	Groups        []string `json:"groups"`
+	Namespaces    []string `json:"namespaces"`
+	ClusterScoped bool     `json:"clusterScoped"` // may see Nodes, StorageClasses, ...
+}
+
+// TenancyConfig is loaded from the file given with --tenancy-config
+type TenancyConfig struct {
+	Admins struct {
+		Users  []string `json:"users"`
+		Groups []string `json:"groups"`
+	} `json:"admins"`
+	Tenants []Tenant `json:"tenants"`
+}
+
+// LoadTenancyConfig reads a YAML or JSON tenancy file
+func LoadTenancyConfig(path string) (*TenancyConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg TenancyConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid tenancy config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Scope is the set of namespaces a request may read or mutate
+type Scope struct {
+	// All grants unrestricted access (tenancy disabled or admin)
+	All bool
+	// Namespaces lists the allowed namespaces when All is false
+	Namespaces map[string]bool
+	// ClusterScoped allows cluster-scoped resources (namespace "")
+	ClusterScoped bool
+}
+
+// Unrestricted is the scope used when tenancy mode is disabled
+var Unrestricted = Scope{All: true, ClusterScoped: true}
+
+// Allows reports whether a resource in namespace ns is visible in this scope.
+// The empty namespace denotes cluster-scoped resources.
+func (s Scope) Allows(ns string) bool {
+	if s.All {
+		return true
+	}
+	if ns == "" {
+		return s.ClusterScoped
+	}
+	return s.Namespaces[ns]
+}
+
+// NamespaceList returns the allowed namespaces sorted, or nil when unrestricted
+func (s Scope) NamespaceList() []string {
+	if s.All {
+		return nil
+	}
+	list := make([]string, 0, len(s.Namespaces))
+	for ns := range s.Namespaces {
+		list = append(list, ns)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// IsAdmin reports whether id may use admin endpoints. Without a tenancy
+// config anakosmos runs single-user and everyone is an admin.
+func (c *TenancyConfig) IsAdmin(id Identity) bool {
+	if c == nil {
+		return true
+	}
+	return contains(c.Admins.Users, id.User) || id.InGroup(c.Admins.Groups)
+}
+
+// ScopeFor resolves the scope of an identity. ok is false for callers that
+// are not mapped to any tenant.
+func (c *TenancyConfig) ScopeFor(id Identity) (Scope, bool) {
+	if c == nil {
+		return Unrestricted, true
+	}
+	if c.IsAdmin(id) {
+		return Unrestricted, true
+	}
+
+	scope := Scope{Namespaces: make(map[string]bool)}
+	matched := false
+	for _, t := range c.Tenants {
+		if !contains(t.Users, id.User) && !id.InGroup(t.Groups) {
+			continue
+		}
+		matched = true
+		for _, ns := range t.Namespaces {
+			scope.Namespaces[ns] = true
+		}
+		if t.ClusterScoped {
+			scope.ClusterScoped = true
+		}
+	}
+	return scope, matched
+}
+
+func contains(list []string, v string) bool {
+	if v == "" {
+		return false
+	}
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,302 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anakosmos/backend/src/i18n"
+	"github.com/anakosmos/backend/src/store"
+)
+
+const tokensCollection = "tokens"
+
+// tokenPrefix marks anakosmos API tokens in Authorization headers so they are
+// never confused with Kubernetes bearer tokens.
+const tokenPrefix = "ak_"
+
+// APIToken is a scoped, expiring credential for automation clients. Only the
+// hash of the secret is persisted.
+type APIToken struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	CreatedBy  string    `json:"createdBy"`
+	CreatedAt  time.Time `json:"createdAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	ReadOnly   bool      `json:"readOnly"`
+	Clusters   []string  `json:"clusters,omitempty"`   // allowed target hosts, "local" for the default cluster
+	Namespaces []string  `json:"namespaces,omitempty"` // empty means all namespaces
+	SecretHash string    `json:"secretHash,omitempty"`
+}
+
+// TokenManager mints, validates and revokes API tokens
+type TokenManager struct {
+	store store.Store
+}
+
+func NewTokenManager(s store.Store) *TokenManager {
+	return &TokenManager{store: s}
+}
+
+var errInvalidToken = i18n.Errorf("error.tokenInvalid")
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create mints a new token and returns it along with the plaintext credential,
+// which is shown exactly once.
+func (m *TokenManager) Create(ctx context.Context, t APIToken, ttl time.Duration) (APIToken, string, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return APIToken{}, "", err
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return APIToken{}, "", err
+	}
+
+	t.ID = id
+	t.CreatedAt = time.Now().UTC()
+	t.ExpiresAt = t.CreatedAt.Add(ttl)
+	t.SecretHash = hashSecret(secret)
+	if err := store.PutJSON(ctx, m.store, tokensCollection, id, t); err != nil {
+		return APIToken{}, "", err
+	}
+	return t, tokenPrefix + id + "_" + secret, nil
+}
+
+// List returns all tokens without their secret hashes
+func (m *TokenManager) List(ctx context.Context) ([]APIToken, error) {
+	entries, err := m.store.List(ctx, tokensCollection)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]APIToken, 0, len(entries))
+	for _, e := range entries {
+		var t APIToken
+		if err := json.Unmarshal(e.Value, &t); err != nil {
+			continue
+		}
+		t.SecretHash = ""
+		tokens = append(tokens, t)
+	}
+	return tokens, nil
+}
+
+// Revoke deletes a token by ID
+func (m *TokenManager) Revoke(ctx context.Context, id string) error {
+	return m.store.Delete(ctx, tokensCollection, id)
+}
+
+// Validate resolves a plaintext credential to its token
+func (m *TokenManager) Validate(ctx context.Context, credential string) (APIToken, error) {
+	rest := strings.TrimPrefix(credential, tokenPrefix)
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok {
+		return APIToken{}, errInvalidToken
+	}
+
+	var t APIToken
+	if err := store.GetJSON(ctx, m.store, tokensCollection, id, &t); err != nil {
+		return APIToken{}, errInvalidToken
+	}
+	if subtle.ConstantTimeCompare([]byte(t.SecretHash), []byte(hashSecret(secret))) != 1 {
+		return APIToken{}, errInvalidToken
+	}
+	if time.Now().After(t.ExpiresAt) {
+		return APIToken{}, i18n.Errorf("error.tokenExpired")
+	}
+	return t, nil
+}
+
+// Scope converts the token restrictions into a request scope
+func (t APIToken) Scope() Scope {
+	if len(t.Namespaces) == 0 {
+		return Unrestricted
+	}
+	scope := Scope{Namespaces: make(map[string]bool)}
+	for _, ns := range t.Namespaces {
+		scope.Namespaces[ns] = true
+	}
+	return scope
+}
+
+// writeSockets are WebSocket endpoints treated as writes although they
+// upgrade a GET: the exec shell. The rollout stream only watches status and
+// stays open to read-only tokens waiting on a rollout.
+var writeSockets = map[string]bool{
+	"/api/sock/exec": true,
+}
+
+// isWriteRequest reports whether r may change cluster state: every method but
+// GET, HEAD and OPTIONS, the write sockets and proxied exec, attach and
+// port-forward sessions, which also arrive as GET upgrades
+func isWriteRequest(r *http.Request) bool {
+	if r.Method != "GET" && r.Method != "HEAD" && r.Method != "OPTIONS" {
+		return true
+	}
+	if writeSockets[r.URL.Path] {
+		return true
+	}
+	// /api/api/v1/namespaces/ns/pods/name/exec, /proxy/api/v1/...
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	for i := 0; i+2 < len(parts); i++ {
+		if parts[i] != "pods" {
+			continue
+		}
+		switch parts[i+2] {
+		case "exec", "attach", "portforward":
+			return true
+		}
+	}
+	return false
+}
+
+// requestClusters names the clusters r addresses: its target URL ("local"
+// without one) and the registered cluster of ?cluster=, if any
+func requestClusters(r *http.Request) []string {
+	names := []string{RequestCluster(r)}
+	if cluster := r.URL.Query().Get("cluster"); cluster != "" {
+		names = append(names, cluster)
+	}
+	return names
+}
+
+// AllowsCluster reports whether the token may reach the cluster known by any
+// of names: its target URL, registered ClusterConnection name or "local"
+func (t APIToken) AllowsCluster(names ...string) bool {
+	if len(t.Clusters) == 0 {
+		return true
+	}
+	for _, c := range t.Clusters {
+		c = strings.TrimRight(c, "/")
+		for _, name := range names {
+			if name != "" && c == strings.TrimRight(name, "/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AllowsRequest checks the read-only and cluster restrictions of a token.
+// Endpoints addressing a cluster by path or message check AllowsCluster
+// themselves.
+func (t APIToken) AllowsRequest(r *http.Request) error {
+	if t.ReadOnly && isWriteRequest(r) {
+		return i18n.Errorf("error.tokenReadOnly")
+	}
+	for _, name := range requestClusters(r) {
+		if !t.AllowsCluster(name) {
+			return i18n.Errorf("error.tokenCluster")
+		}
+	}
+	return nil
+}
+
+// bearerAPIToken returns the anakosmos token from the Authorization header, if any
+func bearerAPIToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	if !strings.HasPrefix(h, "Bearer "+tokenPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(h, "Bearer ")
+}
+
+type createTokenRequest struct {
+	Name       string   `json:"name"`
+	TTL        string   `json:"ttl"` // Go duration, e.g. "720h"
+	ReadOnly   *bool    `json:"readOnly"`
+	Clusters   []string `json:"clusters"`
+	Namespaces []string `json:"namespaces"`
+}
+
+// TokensHandler serves /api/tokens (GET list, POST create) and
+// /api/tokens/{id} (DELETE revoke). Admin only.
+func TokensHandler(m *TokenManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !RequireAdmin(w, r) {
+			return
+		}
+
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/tokens"), "/")
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == "GET" && id == "":
+			tokens, err := m.List(r.Context())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(tokens)
+
+		case r.Method == "POST" && id == "":
+			var req createTokenRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				i18n.Error(w, r, http.StatusBadRequest, "error.invalidJSON")
+				return
+			}
+			if req.Name == "" {
+				i18n.Error(w, r, http.StatusBadRequest, "error.required", "name")
+				return
+			}
+			ttl := 30 * 24 * time.Hour
+			if req.TTL != "" {
+				var err error
+				ttl, err = time.ParseDuration(req.TTL)
+				if err != nil || ttl <= 0 {
+					i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "ttl")
+					return
+				}
+			}
+			readOnly := true
+			if req.ReadOnly != nil {
+				readOnly = *req.ReadOnly
+			}
+			token, credential, err := m.Create(r.Context(), APIToken{
+				Name:       req.Name,
+				CreatedBy:  IdentityFromContext(r.Context()).User,
+				ReadOnly:   readOnly,
+				Clusters:   req.Clusters,
+				Namespaces: req.Namespaces,
+			}, ttl)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			token.SecretHash = ""
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"token":      token,
+				"credential": credential,
+			})
+
+		case r.Method == "DELETE" && id != "":
+			if err := m.Revoke(r.Context(), id); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"status": "revoked"})
+
+		default:
+			i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		}
+	}
+}
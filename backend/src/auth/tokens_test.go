@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsWriteRequest(t *testing.T) {
+	tests := []struct {
+		method string
+		target string
+		write  bool
+	}{
+		{"GET", "/api/cluster/init", false},
+		{"HEAD", "/api/api/v1/pods", false},
+		{"OPTIONS", "/api/api/v1/pods", false},
+		{"POST", "/api/resources/apply-yaml", true},
+		{"DELETE", "/api/api/v1/namespaces/a/pods/p", true},
+		{"GET", "/api/sock/exec?namespace=a&pod=p", true},
+		{"GET", "/api/sock/rollout?kind=deployment", false},
+		{"GET", "/api/sock/watch", false},
+		{"GET", "/api/api/v1/namespaces/a/pods/p/exec?command=sh", true},
+		{"GET", "/api/api/v1/namespaces/a/pods/p/attach", true},
+		{"GET", "/proxy/api/v1/namespaces/a/pods/p/portforward", true},
+		{"GET", "/api/api/v1/namespaces/a/pods/p/log", false},
+		{"GET", "/api/api/v1/namespaces/a/pods/exec", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.target, nil)
+		if got := isWriteRequest(r); got != tt.write {
+			t.Errorf("isWriteRequest(%s %s) = %t, want %t", tt.method, tt.target, got, tt.write)
+		}
+	}
+}
+
+func TestAllowsRequest(t *testing.T) {
+	readOnly := APIToken{ReadOnly: true}
+	scoped := APIToken{Clusters: []string{"local", "https://prod.example.com/", "staging"}}
+	tests := []struct {
+		name    string
+		token   APIToken
+		method  string
+		target  string
+		allowed bool
+	}{
+		{"read-only list", readOnly, "GET", "/api/api/v1/pods", true},
+		{"read-only patch", readOnly, "PATCH", "/api/apis/apps/v1/namespaces/a/deployments/d", false},
+		{"read-only exec socket", readOnly, "GET", "/api/sock/exec", false},
+		{"read-only rollout socket", readOnly, "GET", "/api/sock/rollout?kind=deployment&namespace=a&name=d", true},
+		{"read-only proxied exec", readOnly, "GET", "/api/api/v1/namespaces/a/pods/p/exec", false},
+		{"local by default", scoped, "GET", "/api/cluster/init", true},
+		{"allowed target", scoped, "GET", "/api/cluster/init?target=https://prod.example.com", true},
+		{"other target", scoped, "GET", "/api/cluster/init?target=https://dev.example.com", false},
+		{"allowed cluster id", scoped, "GET", "/api/cluster/baseline?cluster=staging", true},
+		{"other cluster id", scoped, "GET", "/api/cluster/baseline?cluster=dev", false},
+		{"unrestricted clusters", APIToken{}, "GET", "/api/cluster/baseline?cluster=dev", true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.target, nil)
+		if err := tt.token.AllowsRequest(r); (err == nil) != tt.allowed {
+			t.Errorf("%s: AllowsRequest() = %v, want allowed %t", tt.name, err, tt.allowed)
+		}
+	}
+}
@@ -0,0 +1,415 @@
+package baseline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anakosmos/backend/src/activity"
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	"github.com/anakosmos/backend/src/k8s"
+	"github.com/anakosmos/backend/src/maintenance"
+	"github.com/anakosmos/backend/src/store"
+)
+
+const (
+	baselinesCollection = "baselines"
+	reportsCollection   = "baseline-reports"
+)
+
+// trackedKinds are the kinds compared against a baseline: workloads, whose
+// images are compared too, and NetworkPolicies
+var trackedKinds = map[string]bool{
+	"Deployment":    true,
+	"StatefulSet":   true,
+	"DaemonSet":     true,
+	"CronJob":       true,
+	"NetworkPolicy": true,
+}
+
+// Entry is one tracked resource of a baseline
+type Entry struct {
+	Kind      string   `json:"kind"`
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Images    []string `json:"images,omitempty"`
+}
+
+func (e Entry) key() string {
+	return e.Kind + "/" + e.Namespace + "/" + e.Name
+}
+
+// Baseline is the "golden" state of a cluster that later states are compared
+// against
+type Baseline struct {
+	// Cluster is a registered ClusterConnection or "local"
+	Cluster   string    `json:"cluster"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	Entries   []Entry   `json:"entries"`
+}
+
+// Deviation is one difference from the baseline
+type Deviation struct {
+	// Type is "added", "removed" or "imagesChanged"
+	Type      string `json:"type"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Baseline and Current are the images of an imagesChanged workload
+	Baseline []string `json:"baseline,omitempty"`
+	Current  []string `json:"current,omitempty"`
+	// Since is the first check that found the deviation
+	Since time.Time `json:"since"`
+}
+
+func (d Deviation) key() string {
+	return d.Type + "|" + d.Kind + "/" + d.Namespace + "/" + d.Name
+}
+
+// Report is the result of comparing a cluster with its baseline
+type Report struct {
+	Cluster    string      `json:"cluster"`
+	BaselineAt time.Time   `json:"baselineAt"`
+	CheckedAt  time.Time   `json:"checkedAt"`
+	Deviations []Deviation `json:"deviations"`
+}
+
+// Snapshot returns the tracked resources of a graph, sorted
+func Snapshot(graph *k8s.InitResponse) []Entry {
+	entries := []Entry{}
+	for _, res := range graph.Resources {
+		if trackedKinds[res.Kind] {
+			entries = append(entries, Entry{Kind: res.Kind, Namespace: res.Namespace, Name: res.Name, Images: res.Images})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key() < entries[j].key() })
+	return entries
+}
+
+// Compare returns how current deviates from baseline: tracked resources added
+// or removed since, and workloads whose images changed
+func Compare(baseline, current []Entry) []Deviation {
+	before := make(map[string]Entry, len(baseline))
+	for _, e := range baseline {
+		before[e.key()] = e
+	}
+	deviations := []Deviation{}
+	seen := make(map[string]bool, len(current))
+	for _, e := range current {
+		seen[e.key()] = true
+		b, ok := before[e.key()]
+		switch {
+		case !ok:
+			deviations = append(deviations, Deviation{Type: "added", Kind: e.Kind, Namespace: e.Namespace, Name: e.Name, Current: e.Images})
+		case !sameImages(b.Images, e.Images):
+			deviations = append(deviations, Deviation{Type: "imagesChanged", Kind: e.Kind, Namespace: e.Namespace, Name: e.Name, Baseline: b.Images, Current: e.Images})
+		}
+	}
+	for _, e := range baseline {
+		if !seen[e.key()] {
+			deviations = append(deviations, Deviation{Type: "removed", Kind: e.Kind, Namespace: e.Namespace, Name: e.Name, Baseline: e.Images})
+		}
+	}
+	sort.Slice(deviations, func(i, j int) bool { return deviations[i].key() < deviations[j].key() })
+	return deviations
+}
+
+func sameImages(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string{}, a...)
+	b = append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Manager persists baselines and the latest report of each cluster, so every
+// replica serves the reports the leader computes
+type Manager struct {
+	store store.Store
+}
+
+func NewManager(s store.Store) *Manager {
+	return &Manager{store: s}
+}
+
+// Get returns the baseline of a cluster
+func (m *Manager) Get(ctx context.Context, cluster string) (*Baseline, error) {
+	var b Baseline
+	if err := store.GetJSON(ctx, m.store, baselinesCollection, cluster, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Mark records the current state of a cluster as its baseline
+func (m *Manager) Mark(ctx context.Context, cluster, user string) (*Baseline, error) {
+	config, err := k8s.ClusterConfig(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := k8s.BuildGraph(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	b := &Baseline{Cluster: cluster, CreatedBy: user, CreatedAt: time.Now().UTC(), Entries: Snapshot(graph)}
+	if err := store.PutJSON(ctx, m.store, baselinesCollection, cluster, b); err != nil {
+		return nil, err
+	}
+	// The previous report compares against the replaced baseline
+	if err := m.store.Delete(ctx, reportsCollection, cluster); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Delete removes the baseline of a cluster and its report
+func (m *Manager) Delete(ctx context.Context, cluster string) error {
+	if _, err := m.Get(ctx, cluster); err != nil {
+		return err
+	}
+	if err := m.store.Delete(ctx, baselinesCollection, cluster); err != nil {
+		return err
+	}
+	return m.store.Delete(ctx, reportsCollection, cluster)
+}
+
+// Report returns the latest report of a cluster
+func (m *Manager) Report(ctx context.Context, cluster string) (*Report, error) {
+	var report Report
+	if err := store.GetJSON(ctx, m.store, reportsCollection, cluster, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Check compares a cluster with its baseline and stores the report.
+// Deviations not in the previous report are published as alerts.
+func (m *Manager) Check(ctx context.Context, cluster string) (*Report, error) {
+	b, err := m.Get(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	config, err := k8s.ClusterConfig(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := k8s.BuildGraph(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{
+		Cluster:    cluster,
+		BaselineAt: b.CreatedAt,
+		CheckedAt:  time.Now().UTC(),
+		Deviations: Compare(b.Entries, Snapshot(graph)),
+	}
+
+	known := make(map[string]time.Time)
+	if previous, err := m.Report(ctx, cluster); err == nil && previous.BaselineAt.Equal(b.CreatedAt) {
+		for _, d := range previous.Deviations {
+			known[d.key()] = d.Since
+		}
+	}
+	// Activity items name clusters by target URL
+	feedCluster := cluster
+	if cluster != k8s.LocalClusterID {
+		feedCluster = config.Host
+	}
+	for i := range report.Deviations {
+		d := &report.Deviations[i]
+		if since, ok := known[d.key()]; ok {
+			d.Since = since
+			continue
+		}
+		d.Since = report.CheckedAt
+		if !maintenance.UnderMaintenance(feedCluster, d.Namespace) {
+			activity.Publish(activity.Item{
+				Type:      "alert",
+				Severity:  "warning",
+				Cluster:   feedCluster,
+				Kind:      d.Kind,
+				Namespace: d.Namespace,
+				Name:      d.Name,
+				Message:   describe(d),
+			})
+		}
+	}
+
+	if err := store.PutJSON(ctx, m.store, reportsCollection, cluster, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func describe(d *Deviation) string {
+	target := d.Kind + " " + d.Namespace + "/" + d.Name
+	switch d.Type {
+	case "added":
+		return target + " is not part of the baseline"
+	case "removed":
+		return target + " of the baseline was removed"
+	}
+	return fmt.Sprintf("%s images changed from the baseline: %s", target, strings.Join(d.Current, ", "))
+}
+
+// Loop re-checks every cluster with a baseline each interval
+func Loop(m *Manager, interval time.Duration) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			entries, err := m.store.List(ctx, baselinesCollection)
+			if err != nil {
+				log.Printf("Baseline check failed: %v", err)
+				continue
+			}
+			for _, e := range entries {
+				report, err := m.Check(ctx, e.Key)
+				if err != nil {
+					log.Printf("Baseline check of cluster %s failed: %v", e.Key, err)
+					continue
+				}
+				if len(report.Deviations) > 0 {
+					log.Printf("Cluster %s deviates from its baseline in %d resources", e.Key, len(report.Deviations))
+				}
+			}
+		}
+	}
+}
+
+// Handler serves /api/cluster/baseline for ?cluster= (a registered
+// ClusterConnection, "local" by default): GET returns the baseline, POST
+// marks the current state as the baseline and DELETE removes it. Marking and
+// removing are admin only.
+func Handler(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cluster := requestCluster(r)
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.Method {
+		case http.MethodGet:
+			b, err := m.Get(r.Context(), cluster)
+			if errors.Is(err, store.ErrNotFound) {
+				i18n.Error(w, r, http.StatusNotFound, "error.resourceNotFound")
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			b.Entries = visibleEntries(r.Context(), b.Entries)
+			json.NewEncoder(w).Encode(b)
+
+		case http.MethodPost:
+			if !auth.RequireAdmin(w, r) {
+				return
+			}
+			b, err := m.Mark(r.Context(), cluster, auth.IdentityFromContext(r.Context()).User)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			item := activity.Operation(r, "baseline", "", "", "", nil)
+			item.Message = fmt.Sprintf("marked the current state of %s as baseline (%d resources)", cluster, len(b.Entries))
+			activity.Publish(item)
+			json.NewEncoder(w).Encode(b)
+
+		case http.MethodDelete:
+			if !auth.RequireAdmin(w, r) {
+				return
+			}
+			err := m.Delete(r.Context(), cluster)
+			if errors.Is(err, store.ErrNotFound) {
+				i18n.Error(w, r, http.StatusNotFound, "error.resourceNotFound")
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
+
+		default:
+			i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		}
+	}
+}
+
+// DiffHandler serves /api/cluster/baseline-diff, the latest deviations of
+// ?cluster= from its baseline in namespaces the caller may see.
+// ?refresh=true compares again instead of waiting for the next check.
+func DiffHandler(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodRequired", "GET")
+			return
+		}
+		cluster := requestCluster(r)
+		var report *Report
+		var err error
+		if r.URL.Query().Get("refresh") == "true" {
+			report, err = m.Check(r.Context(), cluster)
+		} else if report, err = m.Report(r.Context(), cluster); errors.Is(err, store.ErrNotFound) {
+			// Baseline marked, not checked yet
+			report, err = m.Check(r.Context(), cluster)
+		}
+		if errors.Is(err, store.ErrNotFound) {
+			i18n.Error(w, r, http.StatusNotFound, "error.resourceNotFound")
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		scope := auth.ScopeFromContext(r.Context())
+		visible := []Deviation{}
+		for _, d := range report.Deviations {
+			if scope.Allows(d.Namespace) {
+				visible = append(visible, d)
+			}
+		}
+		report.Deviations = visible
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+func requestCluster(r *http.Request) string {
+	if cluster := r.URL.Query().Get("cluster"); cluster != "" {
+		return cluster
+	}
+	return k8s.LocalClusterID
+}
+
+func visibleEntries(ctx context.Context, entries []Entry) []Entry {
+	scope := auth.ScopeFromContext(ctx)
+	visible := []Entry{}
+	for _, e := range entries {
+		if scope.Allows(e.Namespace) {
+			visible = append(visible, e)
+		}
+	}
+	return visible
+}
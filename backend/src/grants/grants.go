@@ -0,0 +1,285 @@
+package grants
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anakosmos/backend/src/activity"
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	"github.com/anakosmos/backend/src/store"
+)
+
+const grantsCollection = "access-grants"
+
+// reloadInterval bounds how long a grant created or revoked on another
+// replica takes to be honoured here
+const reloadInterval = 30 * time.Second
+
+// maxHours caps how long a single grant lasts
+const maxHours = 72
+
+// maxUses caps the uses kept on a grant's audit trail
+const maxUses = 500
+
+// Manager persists break-glass grants in the store. Revoked and expired
+// grants are kept for their audit trail.
+type Manager struct {
+	store store.Store
+
+	mu       sync.Mutex
+	grants   []auth.Grant
+	loadedAt time.Time
+}
+
+func NewManager(s store.Store) *Manager {
+	return &Manager{store: s}
+}
+
+// List returns every stored grant, newest first. The cache is refreshed when
+// older than reloadInterval.
+func (m *Manager) List(ctx context.Context) ([]auth.Grant, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.loadedAt.IsZero() && time.Since(m.loadedAt) < reloadInterval {
+		return m.grants, nil
+	}
+	entries, err := m.store.List(ctx, grantsCollection)
+	if err != nil {
+		return m.grants, err
+	}
+	grants := make([]auth.Grant, 0, len(entries))
+	for _, e := range entries {
+		var g auth.Grant
+		if err := json.Unmarshal(e.Value, &g); err != nil {
+			continue
+		}
+		grants = append(grants, g)
+	}
+	sort.Slice(grants, func(i, j int) bool { return grants[i].CreatedAt.After(grants[j].CreatedAt) })
+	m.grants = grants
+	m.loadedAt = time.Now()
+	return grants, nil
+}
+
+// Create stores a new grant
+func (m *Manager) Create(ctx context.Context, g auth.Grant) (auth.Grant, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return auth.Grant{}, err
+	}
+	g.ID = hex.EncodeToString(id)
+	g.CreatedAt = time.Now().UTC()
+	if err := store.PutJSON(ctx, m.store, grantsCollection, g.ID, g); err != nil {
+		return auth.Grant{}, err
+	}
+	m.invalidate()
+	return g, nil
+}
+
+// Revoke ends a grant early, keeping it for the audit trail
+func (m *Manager) Revoke(ctx context.Context, id, by string) (auth.Grant, error) {
+	var g auth.Grant
+	if err := store.GetJSON(ctx, m.store, grantsCollection, id, &g); err != nil {
+		return auth.Grant{}, err
+	}
+	if g.RevokedAt == nil {
+		now := time.Now().UTC()
+		g.RevokedAt = &now
+		g.RevokedBy = by
+		if err := store.PutJSON(ctx, m.store, grantsCollection, id, g); err != nil {
+			return auth.Grant{}, err
+		}
+		m.invalidate()
+	}
+	return g, nil
+}
+
+// ActiveGrant implements auth.GrantSource
+func (m *Manager) ActiveGrant(ctx context.Context, user, cluster, namespace, verb string) *auth.Grant {
+	grants, err := m.List(ctx)
+	if err != nil {
+		log.Printf("Failed to load access grants: %v", err)
+	}
+	now := time.Now()
+	for i := range grants {
+		if grants[i].ActiveAt(now) && grants[i].Covers(user, cluster, namespace, verb) {
+			g := grants[i]
+			return &g
+		}
+	}
+	return nil
+}
+
+// RecordUse implements auth.GrantSource
+func (m *Manager) RecordUse(ctx context.Context, id string, use auth.GrantUse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var g auth.Grant
+	if err := store.GetJSON(ctx, m.store, grantsCollection, id, &g); err != nil {
+		return err
+	}
+	g.Uses = append(g.Uses, use)
+	if len(g.Uses) > maxUses {
+		g.Uses = g.Uses[len(g.Uses)-maxUses:]
+	}
+	if err := store.PutJSON(ctx, m.store, grantsCollection, id, g); err != nil {
+		return err
+	}
+	m.loadedAt = time.Time{}
+	return nil
+}
+
+func (m *Manager) invalidate() {
+	m.mu.Lock()
+	m.loadedAt = time.Time{}
+	m.mu.Unlock()
+}
+
+type createGrantRequest struct {
+	User      string   `json:"user"`
+	Namespace string   `json:"namespace"`
+	Cluster   string   `json:"cluster"` // defaults to the request's cluster
+	Verbs     []string `json:"verbs"`   // defaults to exec and logs
+	Hours     int      `json:"hours"`
+	Reason    string   `json:"reason"`
+}
+
+// notice publishes a grant being given or revoked on the activity feed and in
+// the log
+func notice(r *http.Request, g auth.Grant, verb, message string) {
+	item := activity.Item{
+		Type:      "operation",
+		Severity:  "warning",
+		Cluster:   g.Cluster,
+		Actor:     auth.IdentityFromContext(r.Context()).User,
+		Verb:      verb,
+		Kind:      "AccessGrant",
+		Namespace: g.Namespace,
+		Name:      g.ID,
+		Message:   message,
+	}
+	log.Printf("Access grant: %s (by %q)", message, item.Actor)
+	activity.Publish(item)
+}
+
+// Handler serves /api/access-grants (GET list, POST grant) and
+// /api/access-grants/{id} (DELETE revoke). Granting and revoking are admin
+// only; other callers only see their own grants.
+func Handler(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/access-grants"), "/")
+		caller := auth.IdentityFromContext(r.Context()).User
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == "GET" && id == "":
+			grants, err := m.List(r.Context())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			admin := auth.IsAdmin(r.Context())
+			now := time.Now()
+			result := make([]auth.Grant, 0, len(grants))
+			for _, g := range grants {
+				if !admin && g.User != caller {
+					continue
+				}
+				if r.URL.Query().Get("active") == "true" && !g.ActiveAt(now) {
+					continue
+				}
+				result = append(result, g)
+			}
+			json.NewEncoder(w).Encode(result)
+
+		case r.Method == "POST" && id == "":
+			if !auth.RequireAdmin(w, r) {
+				return
+			}
+			var req createGrantRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				i18n.Error(w, r, http.StatusBadRequest, "error.invalidJSON")
+				return
+			}
+			req.Reason = strings.TrimSpace(req.Reason)
+			if req.User == "" || req.Namespace == "" || req.Reason == "" {
+				i18n.Error(w, r, http.StatusBadRequest, "error.required", "user, namespace, reason")
+				return
+			}
+			if req.Hours <= 0 || req.Hours > maxHours {
+				i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "hours")
+				return
+			}
+			if len(req.Verbs) == 0 {
+				req.Verbs = auth.GrantableVerbs
+			}
+			for _, verb := range req.Verbs {
+				if !contains(auth.GrantableVerbs, verb) {
+					i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "verbs")
+					return
+				}
+			}
+			cluster := strings.TrimRight(req.Cluster, "/")
+			if cluster == "" {
+				cluster = auth.RequestCluster(r)
+			}
+			now := time.Now().UTC()
+			g, err := m.Create(r.Context(), auth.Grant{
+				User:      req.User,
+				Cluster:   cluster,
+				Namespace: req.Namespace,
+				Verbs:     req.Verbs,
+				Reason:    req.Reason,
+				GrantedBy: caller,
+				ExpiresAt: now.Add(time.Duration(req.Hours) * time.Hour),
+			})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			notice(r, g, "grant", fmt.Sprintf("%s granted %s in %s for %dh: %s",
+				g.User, strings.Join(g.Verbs, "/"), g.Namespace, req.Hours, g.Reason))
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(g)
+
+		case r.Method == "DELETE" && id != "":
+			if !auth.RequireAdmin(w, r) {
+				return
+			}
+			g, err := m.Revoke(r.Context(), id, caller)
+			if errors.Is(err, store.ErrNotFound) {
+				i18n.Error(w, r, http.StatusNotFound, "error.resourceNotFound")
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			notice(r, g, "revoke", fmt.Sprintf("access grant of %s in %s revoked", g.User, g.Namespace))
+			json.NewEncoder(w).Encode(map[string]string{"status": "revoked"})
+
+		default:
+			i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
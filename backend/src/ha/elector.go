@@ -0,0 +1,145 @@
+package ha
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// Elector decides which replica runs the background subsystems (history
+// recorder, notifications, snapshot scheduler, ...). Request serving is
+// stateless and runs on every replica.
+type Elector struct {
+	identity string
+	lock     resourcelock.Interface
+
+	mu         sync.Mutex
+	leader     bool
+	leaderCtx  context.Context
+	cancel     context.CancelFunc
+	subsystems []subsystem
+}
+
+type subsystem struct {
+	name string
+	run  func(ctx context.Context)
+}
+
+// NewSingleReplica returns an elector that is always the leader, used when HA
+// mode is disabled.
+func NewSingleReplica() *Elector {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Elector{
+		identity:  "standalone",
+		leader:    true,
+		leaderCtx: ctx,
+		cancel:    cancel,
+	}
+}
+
+// NewLeaseElector returns an elector backed by a coordination.k8s.io Lease
+func NewLeaseElector(config *rest.Config, namespace, leaseName, identity string) (*Elector, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{Name: leaseName, Namespace: namespace},
+		Client:    clientset.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: identity,
+		},
+	}
+	return &Elector{
+		identity: identity,
+		lock:     lock,
+	}, nil
+}
+
+// Identity returns the name of this replica
+func (e *Elector) Identity() string {
+	return e.identity
+}
+
+// IsLeader reports whether this replica currently runs the background subsystems
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+// RunWhenLeader registers a background subsystem. It is started whenever this
+// replica acquires leadership and its context is cancelled when leadership is lost.
+func (e *Elector) RunWhenLeader(name string, run func(ctx context.Context)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.subsystems = append(e.subsystems, subsystem{name: name, run: run})
+	if e.leader {
+		go run(e.leaderCtx)
+	}
+}
+
+// Run participates in leader election until ctx is done. For single replica
+// electors it returns immediately.
+func (e *Elector) Run(ctx context.Context) {
+	if e.lock == nil {
+		return
+	}
+
+	for {
+		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+			Lock:            e.lock,
+			ReleaseOnCancel: true,
+			LeaseDuration:   15 * time.Second,
+			RenewDeadline:   10 * time.Second,
+			RetryPeriod:     2 * time.Second,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: e.startLeading,
+				OnStoppedLeading: e.stopLeading,
+				OnNewLeader: func(identity string) {
+					if identity != e.identity {
+						log.Printf("HA: %s is the leader", identity)
+					}
+				},
+			},
+		})
+
+		// RunOrDie returns when leadership is lost; rejoin unless shutting down
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(1 * time.Second):
+		}
+	}
+}
+
+func (e *Elector) startLeading(ctx context.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	log.Printf("HA: %s acquired leadership, starting %d background subsystems", e.identity, len(e.subsystems))
+	e.leader = true
+	e.leaderCtx, e.cancel = context.WithCancel(ctx)
+	for _, s := range e.subsystems {
+		go s.run(e.leaderCtx)
+	}
+}
+
+func (e *Elector) stopLeading() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	log.Printf("HA: %s lost leadership, stopping background subsystems", e.identity)
+	e.leader = false
+	if e.cancel != nil {
+		e.cancel()
+	}
+}
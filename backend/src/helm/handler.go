@@ -2,10 +2,15 @@ package helm
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
 
+	"github.com/anakosmos/backend/src/activity"
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
 	"sigs.k8s.io/yaml"
 
 	"k8s.io/client-go/rest"
@@ -23,13 +28,15 @@ func HandleHelmRequest(config *rest.Config, w http.ResponseWriter, r *http.Reque
 	}
 
 	manager := NewHelmManager(config)
+	// Objects a chart renders must stay within the caller's namespaces
+	allowed := auth.ScopeFromContext(r.Context()).Allows
 	
 	// Extract action from path
 	// Path is expected to be /api/helm/<action>
 	path := r.URL.Path
 	prefix := "/api/helm/"
 	if !strings.HasPrefix(path, prefix) {
-		http.Error(w, "Invalid path", http.StatusBadRequest)
+		i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "path")
 		return
 	}
 	action := path[len(prefix):]
@@ -39,15 +46,25 @@ func HandleHelmRequest(config *rest.Config, w http.ResponseWriter, r *http.Reque
     name := r.URL.Query().Get("name")
 
     if ns == "" && action != "list" && action != "repo-index" && action != "chart-values" { // list might support all namespaces later, but for now strict
-        http.Error(w, "namespace required", http.StatusBadRequest)
+        i18n.Error(w, r, http.StatusBadRequest, "error.required", "namespace")
         return
     }
 
+	if ns != "" && !auth.RequireNamespace(w, r, ns) {
+		return
+	}
+	switch action {
+	case "install", "upgrade", "rollback", "sync":
+		if r.Method == http.MethodPost && !auth.RequireAllowed(w, r, action, "HelmRelease", ns, name) {
+			return
+		}
+	}
+
 	switch action {
 	case "repo-index":
         repoURL := r.URL.Query().Get("repoUrl")
         if repoURL == "" {
-            http.Error(w, "repoUrl required", http.StatusBadRequest)
+            i18n.Error(w, r, http.StatusBadRequest, "error.required", "repoUrl")
             return
         }
         index, err := fetchRepoIndex(repoURL)
@@ -63,7 +80,7 @@ func HandleHelmRequest(config *rest.Config, w http.ResponseWriter, r *http.Reque
         chart := r.URL.Query().Get("chart")
         version := r.URL.Query().Get("version")
         if repoURL == "" || chart == "" {
-            http.Error(w, "repoUrl and chart required", http.StatusBadRequest)
+            i18n.Error(w, r, http.StatusBadRequest, "error.required", "repoUrl, chart")
             return
         }
         values, err := fetchChartValues(repoURL, chart, version)
@@ -76,7 +93,7 @@ func HandleHelmRequest(config *rest.Config, w http.ResponseWriter, r *http.Reque
 
 	case "release":
 		if name == "" {
-			http.Error(w, "name required", http.StatusBadRequest)
+			i18n.Error(w, r, http.StatusBadRequest, "error.required", "name")
 			return
 		}
 		rel, err := manager.GetRelease(ns, name)
@@ -99,7 +116,7 @@ func HandleHelmRequest(config *rest.Config, w http.ResponseWriter, r *http.Reque
 
 	case "values":
         if name == "" {
-            http.Error(w, "name required", http.StatusBadRequest)
+            i18n.Error(w, r, http.StatusBadRequest, "error.required", "name")
             return
         }
         // all=true returns computed values (defaults + user), all=false returns user-only
@@ -113,7 +130,7 @@ func HandleHelmRequest(config *rest.Config, w http.ResponseWriter, r *http.Reque
 
 	case "history":
         if name == "" {
-            http.Error(w, "name required", http.StatusBadRequest)
+            i18n.Error(w, r, http.StatusBadRequest, "error.required", "name")
             return
         }
 		hist, err := manager.GetHistory(ns, name)
@@ -125,11 +142,11 @@ func HandleHelmRequest(config *rest.Config, w http.ResponseWriter, r *http.Reque
 
 	case "rollback":
         if r.Method != "POST" {
-            http.Error(w, "POST required", http.StatusMethodNotAllowed)
+            i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodRequired", "POST")
             return
         }
         if name == "" {
-            http.Error(w, "name required", http.StatusBadRequest)
+            i18n.Error(w, r, http.StatusBadRequest, "error.required", "name")
             return
         }
         var req struct {
@@ -139,7 +156,9 @@ func HandleHelmRequest(config *rest.Config, w http.ResponseWriter, r *http.Reque
              http.Error(w, err.Error(), http.StatusBadRequest)
              return
         }
-        if err := manager.Rollback(ns, name, req.Revision); err != nil {
+        err := manager.Rollback(ns, name, req.Revision)
+        activity.Publish(activity.Operation(r, "rollback", "HelmRelease", ns, name, err))
+        if err != nil {
              http.Error(w, err.Error(), http.StatusInternalServerError)
              return
         }
@@ -148,11 +167,11 @@ func HandleHelmRequest(config *rest.Config, w http.ResponseWriter, r *http.Reque
 
     case "upgrade":
         if r.Method != "POST" {
-            http.Error(w, "POST required", http.StatusMethodNotAllowed)
+            i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodRequired", "POST")
             return
         }
         if name == "" {
-            http.Error(w, "name required", http.StatusBadRequest)
+            i18n.Error(w, r, http.StatusBadRequest, "error.required", "name")
             return
         }
         body, err := io.ReadAll(r.Body)
@@ -193,55 +212,81 @@ func HandleHelmRequest(config *rest.Config, w http.ResponseWriter, r *http.Reque
         var rel interface{}
         if req.RepoURL != "" || req.Chart != "" {
             if req.RepoURL == "" || req.Chart == "" {
-                http.Error(w, "repoUrl and chart required", http.StatusBadRequest)
+                i18n.Error(w, r, http.StatusBadRequest, "error.required", "repoUrl, chart")
                 return
             }
-            rel, err = manager.UpgradeFromRepo(ns, name, req.RepoURL, req.Chart, req.Version, values)
+            rel, err = manager.UpgradeFromRepo(ns, name, req.RepoURL, req.Chart, req.Version, values, allowed)
         } else {
-            rel, err = manager.Upgrade(ns, name, values)
+            rel, err = manager.Upgrade(ns, name, values, allowed)
         }
+        activity.Publish(activity.Operation(r, "upgrade", "HelmRelease", ns, name, err))
         if err != nil {
-             http.Error(w, err.Error(), http.StatusInternalServerError)
+             http.Error(w, err.Error(), applyStatus(err))
              return
         }
         json.NewEncoder(w).Encode(rel)
 
+	case "sync":
+		// GET reports the drift of the release's objects, POST restores them
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+			return
+		}
+		if name == "" {
+			i18n.Error(w, r, http.StatusBadRequest, "error.required", "name")
+			return
+		}
+		dryRun := r.Method == http.MethodGet || r.URL.Query().Get("dryRun") == "true"
+		results, err := manager.Sync(r.Context(), ns, name, allowed, dryRun)
+		if !dryRun {
+			activity.Publish(activity.Operation(r, "sync", "HelmRelease", ns, name, syncError(results, err)))
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"dryRun":  dryRun,
+			"results": results,
+		})
+
 	case "install":
         if r.Method != "POST" {
-            http.Error(w, "POST required", http.StatusMethodNotAllowed)
+            i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodRequired", "POST")
             return
         }
         if name == "" {
-            http.Error(w, "name required", http.StatusBadRequest)
+            i18n.Error(w, r, http.StatusBadRequest, "error.required", "name")
             return
         }
         var values map[string]interface{}
         if strings.Contains(r.Header.Get("Content-Type"), "multipart/form-data") {
             if err := r.ParseMultipartForm(10 << 20); err != nil {
-                http.Error(w, "invalid multipart form", http.StatusBadRequest)
+                i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "multipart form")
                 return
             }
             file, _, err := r.FormFile("chart")
             if err != nil {
-                http.Error(w, "chart file required", http.StatusBadRequest)
+                i18n.Error(w, r, http.StatusBadRequest, "error.required", "chart file")
                 return
             }
             defer file.Close()
             chartData, err := io.ReadAll(file)
             if err != nil {
-                http.Error(w, "failed to read chart file", http.StatusBadRequest)
+                i18n.Error(w, r, http.StatusBadRequest, "error.readFailed", "chart file")
                 return
             }
             valuesYaml := r.FormValue("valuesYaml")
             if valuesYaml != "" {
                 if err := yaml.Unmarshal([]byte(valuesYaml), &values); err != nil {
-                    http.Error(w, "invalid values yaml", http.StatusBadRequest)
+                    i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "values yaml")
                     return
                 }
             }
-            rel, err := manager.InstallFromArchive(ns, name, chartData, values)
+            rel, err := manager.InstallFromArchive(ns, name, chartData, values, allowed)
+            activity.Publish(activity.Operation(r, "install", "HelmRelease", ns, name, err))
             if err != nil {
-                http.Error(w, err.Error(), http.StatusInternalServerError)
+                http.Error(w, err.Error(), applyStatus(err))
                 return
             }
             json.NewEncoder(w).Encode(rel)
@@ -259,23 +304,49 @@ func HandleHelmRequest(config *rest.Config, w http.ResponseWriter, r *http.Reque
             return
         }
         if req.RepoURL == "" || req.Chart == "" {
-            http.Error(w, "repoUrl and chart required", http.StatusBadRequest)
+            i18n.Error(w, r, http.StatusBadRequest, "error.required", "repoUrl, chart")
             return
         }
         if req.ValuesYaml != "" {
             if err := yaml.Unmarshal([]byte(req.ValuesYaml), &values); err != nil {
-                http.Error(w, "invalid values yaml", http.StatusBadRequest)
+                i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "values yaml")
                 return
             }
         }
-        rel, err := manager.InstallFromRepo(ns, name, req.RepoURL, req.Chart, req.Version, values)
+        rel, err := manager.InstallFromRepo(ns, name, req.RepoURL, req.Chart, req.Version, values, allowed)
+        activity.Publish(activity.Operation(r, "install", "HelmRelease", ns, name, err))
         if err != nil {
-            http.Error(w, err.Error(), http.StatusInternalServerError)
+            http.Error(w, err.Error(), applyStatus(err))
             return
         }
         json.NewEncoder(w).Encode(rel)
 
 	default:
-		http.Error(w, "Unknown action: " + action, http.StatusNotFound)
+		i18n.Error(w, r, http.StatusNotFound, "error.unknownAction", action)
+	}
+}
+
+// applyStatus is the HTTP status of a failed install or upgrade
+func applyStatus(err error) int {
+	if errors.Is(err, errNamespaceNotPermitted) {
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+// syncError reports a sync that failed outright or left objects unrestored
+func syncError(results []SyncResult, err error) error {
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, res := range results {
+		if res.Status == "error" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d objects not restored", failed, len(results))
 	}
+	return nil
 }
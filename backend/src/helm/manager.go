@@ -110,8 +110,9 @@ func (m *HelmManager) Rollback(namespace, name string, revision int) error {
 	return client.Run(name)
 }
 
-// Upgrade upgrades a release using existing chart but new values
-func (m *HelmManager) Upgrade(namespace, name string, values map[string]interface{}) (*release.Release, error) {
+// Upgrade upgrades a release using existing chart but new values. Only objects
+// in namespaces allowed permits are applied.
+func (m *HelmManager) Upgrade(namespace, name string, values map[string]interface{}, allowed func(namespace string) bool) (*release.Release, error) {
 	cfg, err := m.getActionConfig(namespace)
 	if err != nil {
 		return nil, err
@@ -135,12 +136,16 @@ func (m *HelmManager) Upgrade(namespace, name string, values map[string]interfac
 	client := action.NewUpgrade(cfg)
 	client.Namespace = namespace
 	client.ReuseValues = false // We want to override with provided values
-	
-	return client.Run(name, chart, values)
+
+	return m.runChecked(allowed, nil, func(dryRun bool) (*release.Release, error) {
+		client.DryRun = dryRun
+		return client.Run(name, chart, values)
+	})
 }
 
 // UpgradeFromRepo upgrades a release using a chart fetched from a repo URL.
-func (m *HelmManager) UpgradeFromRepo(namespace, name, repoURL, chartName, version string, values map[string]interface{}) (*release.Release, error) {
+// Only objects in namespaces allowed permits are applied.
+func (m *HelmManager) UpgradeFromRepo(namespace, name, repoURL, chartName, version string, values map[string]interface{}, allowed func(namespace string) bool) (*release.Release, error) {
 	cfg, err := m.getActionConfig(namespace)
 	if err != nil {
 		return nil, err
@@ -181,11 +186,15 @@ func (m *HelmManager) UpgradeFromRepo(namespace, name, repoURL, chartName, versi
 		values = map[string]interface{}{}
 	}
 
-	return client.Run(name, chart, values)
+	return m.runChecked(allowed, nil, func(dryRun bool) (*release.Release, error) {
+		client.DryRun = dryRun
+		return client.Run(name, chart, values)
+	})
 }
 
-// InstallFromRepo installs a chart from a repository URL.
-func (m *HelmManager) InstallFromRepo(namespace, releaseName, repoURL, chartName, version string, values map[string]interface{}) (*release.Release, error) {
+// InstallFromRepo installs a chart from a repository URL. Only objects in
+// namespaces allowed permits are applied.
+func (m *HelmManager) InstallFromRepo(namespace, releaseName, repoURL, chartName, version string, values map[string]interface{}, allowed func(namespace string) bool) (*release.Release, error) {
 	cfg, err := m.getActionConfig(namespace)
 	if err != nil {
 		return nil, err
@@ -225,11 +234,15 @@ func (m *HelmManager) InstallFromRepo(namespace, releaseName, repoURL, chartName
 		values = map[string]interface{}{}
 	}
 
-	return client.Run(chart, values)
+	return m.runChecked(allowed, chart, func(dryRun bool) (*release.Release, error) {
+		client.DryRun = dryRun
+		return client.Run(chart, values)
+	})
 }
 
-// InstallFromArchive installs a chart from a .tgz archive.
-func (m *HelmManager) InstallFromArchive(namespace, releaseName string, chartData []byte, values map[string]interface{}) (*release.Release, error) {
+// InstallFromArchive installs a chart from a .tgz archive. Only objects in
+// namespaces allowed permits are applied.
+func (m *HelmManager) InstallFromArchive(namespace, releaseName string, chartData []byte, values map[string]interface{}, allowed func(namespace string) bool) (*release.Release, error) {
 	cfg, err := m.getActionConfig(namespace)
 	if err != nil {
 		return nil, err
@@ -248,7 +261,10 @@ func (m *HelmManager) InstallFromArchive(namespace, releaseName string, chartDat
 		values = map[string]interface{}{}
 	}
 
-	return client.Run(chart, values)
+	return m.runChecked(allowed, chart, func(dryRun bool) (*release.Release, error) {
+		client.DryRun = dryRun
+		return client.Run(chart, values)
+	})
 }
 
 
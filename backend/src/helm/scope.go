@@ -0,0 +1,82 @@
+package helm
+
+import (
+	"errors"
+	"fmt"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+var errNamespaceNotPermitted = errors.New("namespace not permitted")
+
+// runChecked renders a release with a dry run and applies it only when every
+// object it would create, hooks included, is in a namespace allowed permits.
+// Releases are applied with the backend's credentials, so the caller's scope
+// is enforced here. crdChart is the chart of an install, whose CRDs are
+// cluster-scoped.
+func (m *HelmManager) runChecked(allowed func(namespace string) bool, crdChart *chart.Chart, run func(dryRun bool) (*release.Release, error)) (*release.Release, error) {
+	if crdChart != nil && len(crdChart.CRDObjects()) > 0 && !allowed("") {
+		return nil, fmt.Errorf("chart CRDs: cluster-scoped objects: %w", errNamespaceNotPermitted)
+	}
+	rendered, err := run(true)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkNamespaces(rendered, allowed); err != nil {
+		return nil, err
+	}
+	return run(false)
+}
+
+// checkNamespaces returns an error for the first object of rel outside the
+// namespaces allowed permits. Namespaced objects without a namespace go to
+// the release namespace; cluster-scoped and unknown kinds without one are
+// checked as cluster-scoped.
+func (m *HelmManager) checkNamespaces(rel *release.Release, allowed func(namespace string) bool) error {
+	getter := &simpleRESTClientGetter{config: m.config, namespace: rel.Namespace}
+	mapper, err := getter.ToRESTMapper()
+	if err != nil {
+		return err
+	}
+
+	manifests := []string{rel.Manifest}
+	for _, hook := range rel.Hooks {
+		manifests = append(manifests, hook.Manifest)
+	}
+	for _, manifest := range manifests {
+		for _, doc := range releaseutil.SplitManifests(manifest) {
+			var obj map[string]interface{}
+			if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+				return fmt.Errorf("invalid manifest of release %s: %v", rel.Name, err)
+			}
+			if len(obj) == 0 {
+				continue
+			}
+			u := &unstructured.Unstructured{Object: obj}
+			gvk := u.GroupVersionKind()
+			namespace := u.GetNamespace()
+			mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+			switch {
+			case err != nil && namespace == "":
+				// Kinds the chart's own CRDs define aren't served yet
+			case err == nil && mapping.Scope.Name() != meta.RESTScopeNameNamespace:
+				namespace = ""
+			case namespace == "":
+				namespace = rel.Namespace
+			}
+			if allowed(namespace) {
+				continue
+			}
+			if namespace == "" {
+				return fmt.Errorf("%s %s: cluster-scoped objects: %w", u.GetKind(), u.GetName(), errNamespaceNotPermitted)
+			}
+			return fmt.Errorf("%s %s: %s: %w", u.GetKind(), u.GetName(), namespace, errNamespaceNotPermitted)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package helm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"helm.sh/helm/v3/pkg/releaseutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+// maxDriftPaths caps the drifted field paths reported per object
+const maxDriftPaths = 20
+
+// SyncResult is the drift state of one object of a release's manifest
+type SyncResult struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// Status is "inSync", "drifted" or "missing" on a dry run, and
+	// "restored" or "error" for drifted and missing objects otherwise
+	Status string `json:"status"`
+	// Drifted are the declared field paths whose live value differs
+	Drifted []string `json:"drifted,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Sync re-applies the objects of a release whose live state diverges from the
+// stored manifest, restoring the declared fields after manual changes. The
+// release revision is left as is. With dryRun only the drift is reported.
+func (m *HelmManager) Sync(ctx context.Context, namespace, name string, allowed func(namespace string) bool, dryRun bool) ([]SyncResult, error) {
+	rel, err := m.GetRelease(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	getter := &simpleRESTClientGetter{config: m.config, namespace: namespace}
+	mapper, err := getter.ToRESTMapper()
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(m.config)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []*unstructured.Unstructured
+	for _, doc := range releaseutil.SplitManifests(rel.Manifest) {
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return nil, fmt.Errorf("invalid manifest of release %s: %v", name, err)
+		}
+		if len(obj) > 0 {
+			objects = append(objects, &unstructured.Unstructured{Object: obj})
+		}
+	}
+	// Restore in install order, e.g. ConfigMaps before the Deployments using them
+	rank := make(map[string]int, len(releaseutil.InstallOrder))
+	for i, kind := range releaseutil.InstallOrder {
+		rank[kind] = i + 1
+	}
+	order := func(kind string) int {
+		if r, ok := rank[kind]; ok {
+			return r
+		}
+		return len(rank) + 1
+	}
+	sort.SliceStable(objects, func(i, j int) bool { return order(objects[i].GetKind()) < order(objects[j].GetKind()) })
+
+	results := make([]SyncResult, 0, len(objects))
+	for _, obj := range objects {
+		res := SyncResult{Kind: obj.GetKind(), Name: obj.GetName()}
+		gvk := obj.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			res.Status, res.Error = "error", err.Error()
+			results = append(results, res)
+			continue
+		}
+		var client dynamic.ResourceInterface = dynamicClient.Resource(mapping.Resource)
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			if obj.GetNamespace() == "" {
+				obj.SetNamespace(rel.Namespace)
+			}
+			res.Namespace = obj.GetNamespace()
+			client = dynamicClient.Resource(mapping.Resource).Namespace(res.Namespace)
+		}
+		if !allowed(res.Namespace) {
+			res.Status, res.Error = "error", "namespace not permitted"
+			results = append(results, res)
+			continue
+		}
+
+		live, err := client.Get(ctx, obj.GetName(), metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			res.Status = "missing"
+		case err != nil:
+			res.Status, res.Error = "error", err.Error()
+			results = append(results, res)
+			continue
+		default:
+			driftedPaths(declaredFields(obj.Object), live.Object, "", &res.Drifted)
+			res.Status = "inSync"
+			if len(res.Drifted) > 0 {
+				res.Status = "drifted"
+			}
+		}
+		if dryRun || res.Status == "inSync" {
+			results = append(results, res)
+			continue
+		}
+
+		data, err := json.Marshal(obj)
+		if err == nil {
+			force := true
+			_, err = client.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+				FieldManager: "helm",
+				Force:        &force,
+			})
+		}
+		if err != nil {
+			res.Status, res.Error = "error", err.Error()
+		} else {
+			res.Status = "restored"
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// declaredFields is the part of a manifest object compared with the live
+// state; status and server-managed metadata are left out. Secret stringData
+// is compared as the data the API server stores it in.
+func declaredFields(obj map[string]interface{}) map[string]interface{} {
+	declared := make(map[string]interface{}, len(obj))
+	if stringData, ok := obj["stringData"].(map[string]interface{}); ok && obj["kind"] == "Secret" {
+		data := make(map[string]interface{})
+		if d, ok := obj["data"].(map[string]interface{}); ok {
+			for k, v := range d {
+				data[k] = v
+			}
+		}
+		for k, v := range stringData {
+			if s, ok := v.(string); ok {
+				data[k] = base64.StdEncoding.EncodeToString([]byte(s))
+			}
+		}
+		declared["data"] = data
+	}
+	for k, v := range obj {
+		switch k {
+		case "apiVersion", "kind", "status", "stringData":
+		case "data":
+			if _, ok := declared["data"]; !ok {
+				declared[k] = v
+			}
+		case "metadata":
+			if md, ok := v.(map[string]interface{}); ok {
+				kept := make(map[string]interface{})
+				for _, field := range []string{"labels", "annotations"} {
+					if fv, ok := md[field]; ok {
+						kept[field] = fv
+					}
+				}
+				if len(kept) > 0 {
+					declared[k] = kept
+				}
+			}
+		default:
+			declared[k] = v
+		}
+	}
+	return declared
+}
+
+// driftedPaths appends the paths of declared values that differ from live.
+// Fields set only in the live object (defaults, other managers) don't count.
+func driftedPaths(declared, live interface{}, path string, out *[]string) {
+	if len(*out) >= maxDriftPaths {
+		return
+	}
+	switch d := declared.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			*out = append(*out, pathOrRoot(path))
+			return
+		}
+		keys := make([]string, 0, len(d))
+		for k := range d {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			lv, ok := l[k]
+			if !ok {
+				if !isEmpty(d[k]) {
+					*out = append(*out, path+"."+k)
+				}
+				continue
+			}
+			driftedPaths(d[k], lv, path+"."+k, out)
+		}
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok || len(l) != len(d) {
+			*out = append(*out, pathOrRoot(path))
+			return
+		}
+		for i := range d {
+			driftedPaths(d[i], l[i], path+"["+strconv.Itoa(i)+"]", out)
+		}
+	default:
+		if !scalarEqual(declared, live) {
+			*out = append(*out, pathOrRoot(path))
+		}
+	}
+}
+
+// isEmpty reports whether a declared value is dropped by the API server
+// when stored, like null or {}
+func isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
+
+// scalarEqual compares manifest and live scalars the way the API server
+// normalizes them: numbers by value, quantities such as "0.5" and "500m" as
+// equal, and numbers written as strings where the schema says int-or-string
+func scalarEqual(declared, live interface{}) bool {
+	if reflect.DeepEqual(declared, live) {
+		return true
+	}
+	ds, dok := scalarString(declared)
+	ls, lok := scalarString(live)
+	if !dok || !lok {
+		return false
+	}
+	if ds == ls {
+		return true
+	}
+	dq, err1 := resource.ParseQuantity(ds)
+	lq, err2 := resource.ParseQuantity(ls)
+	return err1 == nil && err2 == nil && dq.Cmp(lq) == 0
+}
+
+func scalarString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case int:
+		return strconv.Itoa(v), true
+	}
+	return "", false
+}
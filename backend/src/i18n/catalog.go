@@ -0,0 +1,153 @@
+package i18n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used when a request names no supported language, and
+// fills in messages a translation lacks
+const DefaultLanguage = "en"
+
+// Languages lists the supported language codes, default first
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		if lang != DefaultLanguage {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs)
+	return append([]string{DefaultLanguage}, langs...)
+}
+
+// Lang picks the language for a request: a supported ?lang= wins, then the
+// highest weighted supported Accept-Language entry, then DefaultLanguage.
+// Regional variants fall back to their base language (it-CH -> it).
+func Lang(r *http.Request) string {
+	if lang := supported(r.URL.Query().Get("lang")); lang != "" {
+		return lang
+	}
+	best, bestQ := DefaultLanguage, -1.0
+	for _, entry := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(entry), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if lang := supported(tag); lang != "" && q > bestQ && q > 0 {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+// supported maps a language tag to a catalog language, or ""
+func supported(tag string) string {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	base, _, _ = strings.Cut(base, "_")
+	if _, ok := catalogs[base]; ok {
+		return base
+	}
+	return ""
+}
+
+// T renders message id in lang, formatting args into it. Missing
+// translations fall back to English, unknown ids to the id itself.
+func T(lang, id string, args ...interface{}) string {
+	format, ok := catalogs[lang][id]
+	if !ok {
+		if format, ok = catalogs[DefaultLanguage][id]; !ok {
+			format = id
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Error replies like http.Error with message id translated for the request.
+// The id is sent in the X-Message-Id header so clients can match errors
+// without parsing the text.
+func Error(w http.ResponseWriter, r *http.Request, code int, id string, args ...interface{}) {
+	lang := Lang(r)
+	w.Header().Set("X-Message-Id", id)
+	w.Header().Set("Content-Language", lang)
+	http.Error(w, T(lang, id, args...), code)
+}
+
+// Message is an error carrying a catalog message, for code that reports
+// errors without the request at hand. Its text is the English message;
+// ErrorFrom translates it for the request it is reported to.
+type Message struct {
+	ID   string
+	Args []interface{}
+}
+
+// Errorf returns a Message error for id
+func Errorf(id string, args ...interface{}) error {
+	return &Message{ID: id, Args: args}
+}
+
+func (m *Message) Error() string {
+	return T(DefaultLanguage, m.ID, m.Args...)
+}
+
+// ErrorFrom replies like Error when err is a Message, and with its text
+// otherwise
+func ErrorFrom(w http.ResponseWriter, r *http.Request, code int, err error) {
+	var msg *Message
+	if errors.As(err, &msg) {
+		Error(w, r, code, msg.ID, msg.Args...)
+		return
+	}
+	http.Error(w, err.Error(), code)
+}
+
+// Catalog returns every message of lang, English filling the gaps
+func Catalog(lang string) map[string]string {
+	messages := make(map[string]string, len(catalogs[DefaultLanguage]))
+	for id, text := range catalogs[DefaultLanguage] {
+		messages[id] = text
+	}
+	for id, text := range catalogs[lang] {
+		messages[id] = text
+	}
+	return messages
+}
+
+// CatalogResponse is served by /api/i18n
+type CatalogResponse struct {
+	Lang      string   `json:"lang"`
+	Languages []string `json:"languages"`
+	// Messages by id: status.<Status>, health.<health>, flag.<securityFlag>,
+	// error.<name>. Placeholders are Go verbs (%s, %d).
+	Messages map[string]string `json:"messages"`
+}
+
+// HandleCatalog serves /api/i18n[?lang=]: the message catalog for the
+// request's language, so clients translate backend statuses and reasons by
+// identifier.
+func HandleCatalog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		Error(w, r, http.StatusMethodNotAllowed, "error.methodRequired", "GET")
+		return
+	}
+	lang := Lang(r)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", lang)
+	w.Header().Set("Vary", "Accept-Language")
+	json.NewEncoder(w).Encode(CatalogResponse{
+		Lang:      lang,
+		Languages: Languages(),
+		Messages:  Catalog(lang),
+	})
+}
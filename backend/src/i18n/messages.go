@@ -0,0 +1,465 @@
+package i18n
+
+// catalogs holds the messages of every supported language by id. English is
+// complete; other languages may omit entries, which then fall back to it.
+// Resource statuses use the exact Status string the backend reports
+// (status.CrashLoopBackOff, status.Pending-install).
+var catalogs = map[string]map[string]string{
+	"en": {
+		// Resource statuses
+		"status.Running":          "Running",
+		"status.Pending":          "Pending",
+		"status.Succeeded":        "Succeeded",
+		"status.Failed":           "Failed",
+		"status.Unknown":          "Unknown",
+		"status.Active":           "Active",
+		"status.Terminating":      "Terminating",
+		"status.Available":        "Available",
+		"status.Progressing":      "Progressing",
+		"status.Ready":            "Ready",
+		"status.NotReady":         "Not ready",
+		"status.ScaledDown":       "Scaled down",
+		"status.Complete":         "Complete",
+		"status.Suspended":        "Suspended",
+		"status.Inactive":         "Inactive",
+		"status.Bound":            "Bound",
+		"status.Lost":             "Lost",
+		"status.ExternalName":     "External name",
+		"status.External":         "External",
+		"status.Accepted":         "Accepted",
+		"status.NotAccepted":      "Not accepted",
+		"status.UnresolvedRefs":   "Unresolved references",
+		"status.Healthy":          "Healthy",
+		"status.Degraded":         "Degraded",
+		"status.Paused":           "Paused",
+		"status.Synced":           "Synced",
+		"status.OutOfSync":        "Out of sync",
+		"status.Initialized":      "Initialized",
+		"status.Initializing":     "Initializing",
+		"status.Waiting":          "Waiting",
+		"status.WaitingPromotion": "Waiting for promotion",
+		"status.Promoting":        "Promoting",
+		"status.Finalising":       "Finalising",
+		"status.Deployed":         "Deployed",
+		"status.Superseded":       "Superseded",
+		"status.Uninstalling":     "Uninstalling",
+		"status.Uninstalled":      "Uninstalled",
+		"status.Pending-install":  "Installing",
+		"status.Pending-upgrade":  "Upgrading",
+		"status.Pending-rollback": "Rolling back",
+		"status.Exhausted":        "Exhausted",
+		"status.NearLimit":        "Near limit",
+		"status.Blocking":         "Blocking evictions",
+		"status.Drill":            "Drill",
+		"status.NoPods":           "No pods",
+
+		// Health and what drives it
+		"health.ok":                         "Healthy",
+		"health.warning":                    "Warning",
+		"health.error":                      "Error",
+		"health.flapping":                   "Flapping",
+		"flag.default-serviceaccount-token": "Mounts a token of the default ServiceAccount",
+		"qos.BestEffort":                    "BestEffort: first evicted under node pressure",
+		"qos.Burstable":                     "Burstable",
+		"qos.Guaranteed":                    "Guaranteed",
+
+		// Errors
+		"error.configNotLoaded":       "Kubernetes config not loaded",
+		"error.clientFailed":          "Failed to create client",
+		"error.dynamicClientFailed":   "Failed to create dynamic client",
+		"error.discoveryClientFailed": "Failed to create discovery client",
+		"error.methodRequired":        "%s required",
+		"error.methodNotAllowed":      "Method not allowed",
+		"error.required":              "%s is required",
+		"error.requiredOneOf":         "one of %s is required",
+		"error.invalid":               "invalid %s",
+		"error.invalidJSON":           "Invalid JSON payload",
+		"error.readBodyFailed":        "Failed to read request body",
+		"error.tooMany":               "too many %s",
+		"error.unavailable":           "%s unavailable",
+		"error.resourceNotFound":      "resource not found",
+		"error.nodeNotFound":          "node not found",
+		"error.containerNotFound":     "container not found",
+		"error.configNotFound":        "ConfigMap or Secret not found",
+		"error.rateLimited":           "rate limit exceeded",
+		"error.adminRequired":         "admin access required",
+		"error.noNamespaces":          "no namespaces assigned to user %s",
+		"error.namespaceForbidden":    "access to namespace %s is not permitted",
+		"error.clusterScopeForbidden": "cluster-scoped access is not permitted",
+		"error.clusterForbidden":      "access to this cluster is not permitted",
+		"error.namespaceRequiredFor":  "namespace is required for %s",
+		"error.localClusterOnly":      "%s is only available for the local cluster",
+		"error.expired":               "%s is too old",
+		"error.hostNotReferenced":     "%s is not an external endpoint of a permitted resource",
+		"error.mustBeOneOf":           "%s must be one of %s",
+		"error.invalidYAML":           "Invalid YAML: %s",
+		"error.readFailed":            "failed to read %s",
+		"error.unknownAction":         "unknown action: %s",
+		"error.tokenInvalid":          "invalid API token",
+		"error.tokenExpired":          "API token expired",
+		"error.tokenReadOnly":         "API token is read-only",
+		"error.tokenCluster":          "API token is not valid for this cluster",
+		"error.yamlMustDescribe":      "the YAML must describe %s %s",
+		"error.tooManyFiles":          "too many files (max %d)",
+		"error.readFileFailed":        "failed to read %s: %v",
+		"error.bundleTooLarge":        "manifests exceed %d MiB",
+		"error.invalidArchive":        "invalid archive %s: %v",
+		"error.invalidMultipart":      "invalid multipart upload: %v",
+		"error.noManifests":           "no manifest files in upload",
+	},
+	"it": {
+		"status.Running":          "In esecuzione",
+		"status.Pending":          "In attesa",
+		"status.Succeeded":        "Completato",
+		"status.Failed":           "Fallito",
+		"status.Unknown":          "Sconosciuto",
+		"status.Active":           "Attivo",
+		"status.Terminating":      "In terminazione",
+		"status.Available":        "Disponibile",
+		"status.Progressing":      "In avanzamento",
+		"status.Ready":            "Pronto",
+		"status.NotReady":         "Non pronto",
+		"status.ScaledDown":       "Ridimensionato a zero",
+		"status.Complete":         "Completato",
+		"status.Suspended":        "Sospeso",
+		"status.Inactive":         "Inattivo",
+		"status.Bound":            "Associato",
+		"status.Lost":             "Perso",
+		"status.ExternalName":     "Nome esterno",
+		"status.External":         "Esterno",
+		"status.Accepted":         "Accettato",
+		"status.NotAccepted":      "Non accettato",
+		"status.UnresolvedRefs":   "Riferimenti non risolti",
+		"status.Healthy":          "Integro",
+		"status.Degraded":         "Degradato",
+		"status.Paused":           "In pausa",
+		"status.Synced":           "Sincronizzato",
+		"status.OutOfSync":        "Non sincronizzato",
+		"status.Initialized":      "Inizializzato",
+		"status.Initializing":     "In inizializzazione",
+		"status.Waiting":          "In attesa",
+		"status.WaitingPromotion": "In attesa di promozione",
+		"status.Promoting":        "In promozione",
+		"status.Finalising":       "In finalizzazione",
+		"status.Deployed":         "Installato",
+		"status.Superseded":       "Sostituito",
+		"status.Uninstalling":     "In disinstallazione",
+		"status.Uninstalled":      "Disinstallato",
+		"status.Pending-install":  "Installazione in corso",
+		"status.Pending-upgrade":  "Aggiornamento in corso",
+		"status.Pending-rollback": "Rollback in corso",
+		"status.Exhausted":        "Esaurita",
+		"status.NearLimit":        "Vicina al limite",
+		"status.Blocking":         "Blocca le evizioni",
+		"status.Drill":            "Esercitazione",
+		"status.NoPods":           "Nessun pod",
+
+		"health.ok":                         "Integro",
+		"health.warning":                    "Avviso",
+		"health.error":                      "Errore",
+		"health.flapping":                   "Instabile",
+		"flag.default-serviceaccount-token": "Monta un token del ServiceAccount default",
+		"qos.BestEffort":                    "BestEffort: sfrattato per primo se il nodo è sotto pressione",
+
+		"error.configNotLoaded":       "Configurazione Kubernetes non caricata",
+		"error.clientFailed":          "Impossibile creare il client",
+		"error.dynamicClientFailed":   "Impossibile creare il client dinamico",
+		"error.discoveryClientFailed": "Impossibile creare il client di discovery",
+		"error.methodRequired":        "Richiesto il metodo %s",
+		"error.methodNotAllowed":      "Metodo non consentito",
+		"error.required":              "%s obbligatorio",
+		"error.requiredOneOf":         "è obbligatorio uno tra %s",
+		"error.invalid":               "%s non valido",
+		"error.invalidJSON":           "Payload JSON non valido",
+		"error.readBodyFailed":        "Impossibile leggere il corpo della richiesta",
+		"error.tooMany":               "troppi %s",
+		"error.unavailable":           "%s non disponibile",
+		"error.resourceNotFound":      "risorsa non trovata",
+		"error.nodeNotFound":          "nodo non trovato",
+		"error.containerNotFound":     "container non trovato",
+		"error.configNotFound":        "ConfigMap o Secret non trovato",
+		"error.rateLimited":           "limite di richieste superato",
+		"error.adminRequired":         "accesso amministratore richiesto",
+		"error.noNamespaces":          "nessun namespace assegnato all'utente %s",
+		"error.namespaceForbidden":    "accesso al namespace %s non consentito",
+		"error.clusterScopeForbidden": "accesso alle risorse di cluster non consentito",
+		"error.clusterForbidden":      "accesso a questo cluster non consentito",
+		"error.namespaceRequiredFor":  "namespace obbligatorio per %s",
+		"error.localClusterOnly":      "%s è disponibile solo per il cluster locale",
+		"error.expired":               "%s troppo vecchio",
+		"error.hostNotReferenced":     "%s non è un endpoint esterno di una risorsa consentita",
+		"error.mustBeOneOf":           "%s deve essere uno tra %s",
+		"error.invalidYAML":           "YAML non valido: %s",
+		"error.readFailed":            "impossibile leggere %s",
+		"error.unknownAction":         "azione sconosciuta: %s",
+		"error.tokenInvalid":          "token API non valido",
+		"error.tokenExpired":          "token API scaduto",
+		"error.tokenReadOnly":         "il token API è di sola lettura",
+		"error.tokenCluster":          "token API non valido per questo cluster",
+		"error.yamlMustDescribe":      "lo YAML deve descrivere %s %s",
+		"error.tooManyFiles":          "troppi file (max %d)",
+		"error.readFileFailed":        "impossibile leggere %s: %v",
+		"error.bundleTooLarge":        "i manifest superano %d MiB",
+		"error.invalidArchive":        "archivio %s non valido: %v",
+		"error.invalidMultipart":      "upload multipart non valido: %v",
+		"error.noManifests":           "nessun file di manifest nell'upload",
+	},
+	"fr": {
+		"status.Running":          "En cours d'exécution",
+		"status.Pending":          "En attente",
+		"status.Succeeded":        "Réussi",
+		"status.Failed":           "Échoué",
+		"status.Unknown":          "Inconnu",
+		"status.Active":           "Actif",
+		"status.Terminating":      "En cours d'arrêt",
+		"status.Available":        "Disponible",
+		"status.Progressing":      "En cours",
+		"status.Ready":            "Prêt",
+		"status.NotReady":         "Pas prêt",
+		"status.ScaledDown":       "Réduit à zéro",
+		"status.Complete":         "Terminé",
+		"status.Suspended":        "Suspendu",
+		"status.Inactive":         "Inactif",
+		"status.Bound":            "Lié",
+		"status.Lost":             "Perdu",
+		"status.ExternalName":     "Nom externe",
+		"status.External":         "Externe",
+		"status.Accepted":         "Accepté",
+		"status.NotAccepted":      "Non accepté",
+		"status.UnresolvedRefs":   "Références non résolues",
+		"status.Healthy":          "Sain",
+		"status.Degraded":         "Dégradé",
+		"status.Paused":           "En pause",
+		"status.Synced":           "Synchronisé",
+		"status.OutOfSync":        "Désynchronisé",
+		"status.Deployed":         "Déployé",
+		"status.Pending-install":  "Installation en cours",
+		"status.Pending-upgrade":  "Mise à niveau en cours",
+		"status.Pending-rollback": "Retour arrière en cours",
+		"status.Exhausted":        "Épuisé",
+		"status.NearLimit":        "Proche de la limite",
+		"status.Blocking":         "Bloque les évictions",
+		"status.Drill":            "Exercice",
+		"status.NoPods":           "Aucun pod",
+
+		"health.ok":       "Sain",
+		"health.warning":  "Avertissement",
+		"health.error":    "Erreur",
+		"health.flapping": "Instable",
+
+		"error.configNotLoaded":       "Configuration Kubernetes non chargée",
+		"error.clientFailed":          "Impossible de créer le client",
+		"error.dynamicClientFailed":   "Impossible de créer le client dynamique",
+		"error.discoveryClientFailed": "Impossible de créer le client de découverte",
+		"error.methodRequired":        "Méthode %s requise",
+		"error.methodNotAllowed":      "Méthode non autorisée",
+		"error.required":              "%s est requis",
+		"error.requiredOneOf":         "l'un de %s est requis",
+		"error.invalid":               "%s invalide",
+		"error.invalidJSON":           "Contenu JSON invalide",
+		"error.readBodyFailed":        "Impossible de lire le corps de la requête",
+		"error.tooMany":               "trop de %s",
+		"error.unavailable":           "%s indisponible",
+		"error.resourceNotFound":      "ressource introuvable",
+		"error.nodeNotFound":          "nœud introuvable",
+		"error.containerNotFound":     "conteneur introuvable",
+		"error.configNotFound":        "ConfigMap ou Secret introuvable",
+		"error.rateLimited":           "limite de requêtes dépassée",
+		"error.adminRequired":         "accès administrateur requis",
+		"error.noNamespaces":          "aucun namespace attribué à l'utilisateur %s",
+		"error.namespaceForbidden":    "accès au namespace %s non autorisé",
+		"error.clusterScopeForbidden": "accès aux ressources du cluster non autorisé",
+		"error.clusterForbidden":      "accès à ce cluster non autorisé",
+		"error.namespaceRequiredFor":  "namespace requis pour %s",
+		"error.localClusterOnly":      "%s n'est disponible que pour le cluster local",
+		"error.expired":               "%s trop ancien",
+		"error.hostNotReferenced":     "%s n'est pas un point de terminaison externe d'une ressource autorisée",
+		"error.mustBeOneOf":           "%s doit être l'un de %s",
+		"error.invalidYAML":           "YAML invalide : %s",
+		"error.readFailed":            "impossible de lire %s",
+		"error.unknownAction":         "action inconnue : %s",
+		"error.tokenInvalid":          "jeton d'API invalide",
+		"error.tokenExpired":          "jeton d'API expiré",
+		"error.tokenReadOnly":         "le jeton d'API est en lecture seule",
+		"error.tokenCluster":          "jeton d'API non valide pour ce cluster",
+		"error.yamlMustDescribe":      "le YAML doit décrire %s %s",
+		"error.tooManyFiles":          "trop de fichiers (max %d)",
+		"error.readFileFailed":        "impossible de lire %s : %v",
+		"error.bundleTooLarge":        "les manifestes dépassent %d Mio",
+		"error.invalidArchive":        "archive %s invalide : %v",
+		"error.invalidMultipart":      "envoi multipart invalide : %v",
+		"error.noManifests":           "aucun fichier de manifeste dans l'envoi",
+	},
+	"de": {
+		"status.Running":          "Läuft",
+		"status.Pending":          "Ausstehend",
+		"status.Succeeded":        "Erfolgreich",
+		"status.Failed":           "Fehlgeschlagen",
+		"status.Unknown":          "Unbekannt",
+		"status.Active":           "Aktiv",
+		"status.Terminating":      "Wird beendet",
+		"status.Available":        "Verfügbar",
+		"status.Progressing":      "In Bearbeitung",
+		"status.Ready":            "Bereit",
+		"status.NotReady":         "Nicht bereit",
+		"status.ScaledDown":       "Auf null skaliert",
+		"status.Complete":         "Abgeschlossen",
+		"status.Suspended":        "Angehalten",
+		"status.Inactive":         "Inaktiv",
+		"status.Bound":            "Gebunden",
+		"status.Lost":             "Verloren",
+		"status.ExternalName":     "Externer Name",
+		"status.External":         "Extern",
+		"status.Accepted":         "Akzeptiert",
+		"status.NotAccepted":      "Nicht akzeptiert",
+		"status.UnresolvedRefs":   "Unaufgelöste Referenzen",
+		"status.Healthy":          "Gesund",
+		"status.Degraded":         "Beeinträchtigt",
+		"status.Paused":           "Pausiert",
+		"status.Synced":           "Synchronisiert",
+		"status.OutOfSync":        "Nicht synchron",
+		"status.Deployed":         "Bereitgestellt",
+		"status.Pending-install":  "Wird installiert",
+		"status.Pending-upgrade":  "Wird aktualisiert",
+		"status.Pending-rollback": "Wird zurückgesetzt",
+		"status.Exhausted":        "Ausgeschöpft",
+		"status.NearLimit":        "Nahe am Limit",
+		"status.Blocking":         "Blockiert Evictions",
+		"status.Drill":            "Übung",
+		"status.NoPods":           "Keine Pods",
+
+		"health.ok":       "Gesund",
+		"health.warning":  "Warnung",
+		"health.error":    "Fehler",
+		"health.flapping": "Instabil",
+
+		"error.configNotLoaded":       "Kubernetes-Konfiguration nicht geladen",
+		"error.clientFailed":          "Client konnte nicht erstellt werden",
+		"error.dynamicClientFailed":   "Dynamischer Client konnte nicht erstellt werden",
+		"error.discoveryClientFailed": "Discovery-Client konnte nicht erstellt werden",
+		"error.methodRequired":        "%s erforderlich",
+		"error.methodNotAllowed":      "Methode nicht erlaubt",
+		"error.required":              "%s ist erforderlich",
+		"error.requiredOneOf":         "eines von %s ist erforderlich",
+		"error.invalid":               "ungültiger Wert für %s",
+		"error.invalidJSON":           "Ungültige JSON-Nutzdaten",
+		"error.readBodyFailed":        "Anfragetext konnte nicht gelesen werden",
+		"error.tooMany":               "zu viele %s",
+		"error.unavailable":           "%s nicht verfügbar",
+		"error.resourceNotFound":      "Ressource nicht gefunden",
+		"error.nodeNotFound":          "Node nicht gefunden",
+		"error.containerNotFound":     "Container nicht gefunden",
+		"error.configNotFound":        "ConfigMap oder Secret nicht gefunden",
+		"error.rateLimited":           "Anfragelimit überschritten",
+		"error.adminRequired":         "Administratorzugriff erforderlich",
+		"error.noNamespaces":          "dem Benutzer %s sind keine Namespaces zugewiesen",
+		"error.namespaceForbidden":    "Zugriff auf Namespace %s nicht erlaubt",
+		"error.clusterScopeForbidden": "Zugriff auf clusterweite Ressourcen nicht erlaubt",
+		"error.clusterForbidden":      "Zugriff auf diesen Cluster nicht erlaubt",
+		"error.namespaceRequiredFor":  "Namespace ist für %s erforderlich",
+		"error.localClusterOnly":      "%s ist nur für den lokalen Cluster verfügbar",
+		"error.expired":               "%s ist zu alt",
+		"error.hostNotReferenced":     "%s ist kein externer Endpunkt einer erlaubten Ressource",
+		"error.mustBeOneOf":           "%s muss einer von %s sein",
+		"error.invalidYAML":           "Ungültiges YAML: %s",
+		"error.readFailed":            "%s konnte nicht gelesen werden",
+		"error.unknownAction":         "unbekannte Aktion: %s",
+		"error.tokenInvalid":          "ungültiges API-Token",
+		"error.tokenExpired":          "API-Token abgelaufen",
+		"error.tokenReadOnly":         "API-Token ist schreibgeschützt",
+		"error.tokenCluster":          "API-Token ist für diesen Cluster nicht gültig",
+		"error.yamlMustDescribe":      "das YAML muss %s %s beschreiben",
+		"error.tooManyFiles":          "zu viele Dateien (max. %d)",
+		"error.readFileFailed":        "%s konnte nicht gelesen werden: %v",
+		"error.bundleTooLarge":        "Manifeste überschreiten %d MiB",
+		"error.invalidArchive":        "ungültiges Archiv %s: %v",
+		"error.invalidMultipart":      "ungültiger Multipart-Upload: %v",
+		"error.noManifests":           "keine Manifestdateien im Upload",
+	},
+	"es": {
+		"status.Running":          "En ejecución",
+		"status.Pending":          "Pendiente",
+		"status.Succeeded":        "Completado",
+		"status.Failed":           "Fallido",
+		"status.Unknown":          "Desconocido",
+		"status.Active":           "Activo",
+		"status.Terminating":      "Terminando",
+		"status.Available":        "Disponible",
+		"status.Progressing":      "En progreso",
+		"status.Ready":            "Listo",
+		"status.NotReady":         "No listo",
+		"status.ScaledDown":       "Escalado a cero",
+		"status.Complete":         "Completado",
+		"status.Suspended":        "Suspendido",
+		"status.Inactive":         "Inactivo",
+		"status.Bound":            "Vinculado",
+		"status.Lost":             "Perdido",
+		"status.ExternalName":     "Nombre externo",
+		"status.External":         "Externo",
+		"status.Accepted":         "Aceptado",
+		"status.NotAccepted":      "No aceptado",
+		"status.UnresolvedRefs":   "Referencias sin resolver",
+		"status.Healthy":          "Saludable",
+		"status.Degraded":         "Degradado",
+		"status.Paused":           "En pausa",
+		"status.Synced":           "Sincronizado",
+		"status.OutOfSync":        "Sin sincronizar",
+		"status.Deployed":         "Desplegado",
+		"status.Pending-install":  "Instalando",
+		"status.Pending-upgrade":  "Actualizando",
+		"status.Pending-rollback": "Revirtiendo",
+		"status.Exhausted":        "Agotada",
+		"status.NearLimit":        "Cerca del límite",
+		"status.Blocking":         "Bloquea desalojos",
+		"status.Drill":            "Simulacro",
+		"status.NoPods":           "Sin pods",
+
+		"health.ok":       "Saludable",
+		"health.warning":  "Advertencia",
+		"health.error":    "Error",
+		"health.flapping": "Inestable",
+
+		"error.configNotLoaded":       "Configuración de Kubernetes no cargada",
+		"error.clientFailed":          "No se pudo crear el cliente",
+		"error.dynamicClientFailed":   "No se pudo crear el cliente dinámico",
+		"error.discoveryClientFailed": "No se pudo crear el cliente de descubrimiento",
+		"error.methodRequired":        "Se requiere %s",
+		"error.methodNotAllowed":      "Método no permitido",
+		"error.required":              "%s es obligatorio",
+		"error.requiredOneOf":         "se requiere uno de %s",
+		"error.invalid":               "%s no válido",
+		"error.invalidJSON":           "Contenido JSON no válido",
+		"error.readBodyFailed":        "No se pudo leer el cuerpo de la solicitud",
+		"error.tooMany":               "demasiados %s",
+		"error.unavailable":           "%s no disponible",
+		"error.resourceNotFound":      "recurso no encontrado",
+		"error.nodeNotFound":          "nodo no encontrado",
+		"error.containerNotFound":     "contenedor no encontrado",
+		"error.configNotFound":        "ConfigMap o Secret no encontrado",
+		"error.rateLimited":           "límite de solicitudes superado",
+		"error.adminRequired":         "se requiere acceso de administrador",
+		"error.noNamespaces":          "no hay namespaces asignados al usuario %s",
+		"error.namespaceForbidden":    "acceso al namespace %s no permitido",
+		"error.clusterScopeForbidden": "acceso a recursos del clúster no permitido",
+		"error.clusterForbidden":      "acceso a este clúster no permitido",
+		"error.namespaceRequiredFor":  "namespace obligatorio para %s",
+		"error.localClusterOnly":      "%s solo está disponible para el clúster local",
+		"error.expired":               "%s demasiado antiguo",
+		"error.hostNotReferenced":     "%s no es un endpoint externo de un recurso permitido",
+		"error.mustBeOneOf":           "%s debe ser uno de %s",
+		"error.invalidYAML":           "YAML no válido: %s",
+		"error.readFailed":            "no se pudo leer %s",
+		"error.unknownAction":         "acción desconocida: %s",
+		"error.tokenInvalid":          "token de API no válido",
+		"error.tokenExpired":          "token de API caducado",
+		"error.tokenReadOnly":         "el token de API es de solo lectura",
+		"error.tokenCluster":          "token de API no válido para este clúster",
+		"error.yamlMustDescribe":      "el YAML debe describir %s %s",
+		"error.tooManyFiles":          "demasiados archivos (máx. %d)",
+		"error.readFileFailed":        "no se pudo leer %s: %v",
+		"error.bundleTooLarge":        "los manifiestos superan %d MiB",
+		"error.invalidArchive":        "archivo comprimido %s no válido: %v",
+		"error.invalidMultipart":      "subida multipart no válida: %v",
+		"error.noManifests":           "ningún archivo de manifiesto en la subida",
+	},
+}
@@ -0,0 +1,245 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anakosmos/backend/src/activity"
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	"github.com/anakosmos/backend/src/maintenance"
+	"github.com/anakosmos/backend/src/settings"
+	"github.com/gorilla/websocket"
+	"k8s.io/client-go/rest"
+)
+
+// activityInterval is how often a session re-evaluates alert rules and the
+// reachability of its cluster
+const activityInterval = 30 * time.Second
+
+// activitySession holds what one feed connection has already reported
+type activitySession struct {
+	config  *rest.Config
+	scope   auth.Scope
+	cluster string
+	// clusterID is the activity.ClusterID of cluster, reached with the
+	// session's credential before it subscribed
+	clusterID string
+
+	seeded      bool
+	unreachable bool
+	firing      map[string]bool // rule name | resource ID
+}
+
+// wants reports whether a published item concerns this session's cluster and
+// namespaces
+func (s *activitySession) wants(item activity.Item) bool {
+	if item.Cluster != "" && item.Cluster != s.clusterID {
+		return false
+	}
+	if item.Namespace != "" || item.Type == "operation" {
+		return s.scope.Allows(item.Namespace)
+	}
+	return true
+}
+
+// alertFiring reports whether a resource has been in the rule's health state
+// for at least its duration
+func alertFiring(rule *settings.AlertRule, res *LightResource) bool {
+	if res.Health != rule.Health {
+		return false
+	}
+	if rule.Kind != "" && !strings.EqualFold(rule.Kind, res.Kind) {
+		return false
+	}
+	if rule.Namespace != "" && rule.Namespace != res.Namespace {
+		return false
+	}
+	if rule.ForMinutes <= 0 {
+		return true
+	}
+	since, err := time.Parse(time.RFC3339, res.StaleSince)
+	if err != nil {
+		return false
+	}
+	return time.Since(since) >= time.Duration(rule.ForMinutes)*time.Minute
+}
+
+// evaluate checks cluster reachability and alert rules, returning the changes
+// since the previous evaluation. The first evaluation only records the state:
+// alerts already firing on connect aren't news. Changes inside a maintenance
+// window are not reported; alerts that started firing during one are reported
+// once it ends.
+func (s *activitySession) evaluate() []activity.Item {
+	var items []activity.Item
+	muted := make(map[string]bool)
+	underMaintenance := func(namespace string) bool {
+		m, ok := muted[namespace]
+		if !ok {
+			m = maintenance.UnderMaintenance(s.cluster, namespace)
+			muted[namespace] = m
+		}
+		return m
+	}
+
+	graph, _, err := cachedGraph(s.config)
+	if drillErr := unreachableDrill(s.config.Host); drillErr != nil {
+		graph, err = nil, drillErr
+	}
+	switch {
+	case err != nil && !s.unreachable:
+		s.unreachable = true
+		if s.seeded && !underMaintenance("") {
+			items = append(items, activity.Item{Type: "cluster", Severity: "error", Cluster: s.cluster, Message: "cluster unreachable: " + err.Error()})
+		}
+	case err == nil && s.unreachable:
+		s.unreachable = false
+		if s.seeded && !underMaintenance("") {
+			items = append(items, activity.Item{Type: "cluster", Severity: "success", Cluster: s.cluster, Message: "cluster reachable again"})
+		}
+	}
+	if graph == nil {
+		s.seeded = true
+		return items
+	}
+
+	resources, _ := filterByScope(graph.Resources, graph.Links, s.scope)
+	resources = withDrills(s.config.Host, resources)
+	rules := settings.Current().AlertRules()
+	firing := make(map[string]bool)
+	for i := range rules {
+		rule := &rules[i]
+		for j := range resources {
+			res := &resources[j]
+			if !alertFiring(rule, res) {
+				continue
+			}
+			key := rule.Name + "|" + res.ID
+			if s.seeded && underMaintenance(res.Namespace) {
+				// Keep what was already reported, without adding new alerts
+				if s.firing[key] {
+					firing[key] = true
+				}
+				continue
+			}
+			firing[key] = true
+			if !s.seeded || s.firing[key] {
+				continue
+			}
+			severity := "warning"
+			if rule.Health == "error" {
+				severity = "error"
+			}
+			message := rule.Message
+			if message == "" {
+				message = fmt.Sprintf("%s %s/%s is %s", res.Kind, res.Namespace, res.Name, res.Health)
+			}
+			if res.Drill {
+				message += " (drill)"
+			}
+			items = append(items, activity.Item{
+				Type: "alert", Severity: severity, Cluster: s.cluster, Verb: "firing",
+				Kind: res.Kind, Namespace: res.Namespace, Name: res.Name,
+				Message: rule.Name + ": " + message,
+			})
+		}
+	}
+	if s.seeded {
+		for key := range s.firing {
+			if firing[key] {
+				continue
+			}
+			ruleName, id, _ := strings.Cut(key, "|")
+			item := activity.Item{Type: "alert", Severity: "success", Cluster: s.cluster, Verb: "resolved", Message: ruleName + ": resolved"}
+			for j := range resources {
+				if resources[j].ID == id {
+					item.Kind, item.Namespace, item.Name = resources[j].Kind, resources[j].Namespace, resources[j].Name
+					break
+				}
+			}
+			if underMaintenance(item.Namespace) {
+				continue
+			}
+			items = append(items, item)
+		}
+	}
+	s.firing = firing
+	s.seeded = true
+	return items
+}
+
+// HandleActivity serves the /api/sock/activity feed: operations performed
+// through anakosmos on this cluster (by anyone, marked self for the caller's
+// own), alert rules starting or stopping to fire and cluster connectivity
+// changes, as JSON activity items. The client only listens. A remote target's
+// token must authenticate to it before the feed opens, so nobody receives
+// another cluster's operations by naming its URL.
+func HandleActivity(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	session := &activitySession{
+		config:  config,
+		scope:   auth.ScopeFromContext(r.Context()),
+		cluster: auth.RequestCluster(r),
+		firing:  make(map[string]bool),
+	}
+	session.clusterID = activity.ClusterID(session.cluster)
+	if session.cluster != LocalClusterID {
+		// The config must be the target's, not the local one behind a header
+		if activity.ClusterID(config.Host) != session.clusterID || !ValidateCluster(r.Context(), config).OK {
+			i18n.Error(w, r, http.StatusForbidden, "error.clusterForbidden")
+			return
+		}
+	}
+	user := auth.IdentityFromContext(r.Context()).User
+
+	ws, _, err := upgradeWebSocket(w, r, ProtocolActivityV1)
+	if err != nil {
+		log.Println("Activity feed upgrade error:", err)
+		return
+	}
+	defer ws.Close()
+
+	items, unsubscribe := activity.Subscribe()
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for {
+			if _, _, err := ws.NextReader(); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	session.evaluate()
+	ticker := time.NewTicker(activityInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case item := <-items:
+			if !session.wants(item) {
+				continue
+			}
+			item.Self = item.Actor == user
+			if err := ws.WriteJSON(item); err != nil {
+				return
+			}
+		case <-ticker.C:
+			for _, item := range session.evaluate() {
+				if err := ws.WriteJSON(activity.Stamp(item)); err != nil {
+					return
+				}
+			}
+			if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
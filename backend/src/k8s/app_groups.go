@@ -0,0 +1,78 @@
+package k8s
+
+import (
+	"sort"
+	"sync"
+)
+
+// DefaultAppGroupLabels are the labels resources are grouped into logical
+// applications by, first found wins: the recommended Kubernetes labels
+var DefaultAppGroupLabels = []string{"app.kubernetes.io/part-of", "app.kubernetes.io/instance"}
+
+var appGroupLabels = struct {
+	sync.RWMutex
+	keys []string
+}{keys: DefaultAppGroupLabels}
+
+// ConfigureAppGroupLabels replaces the labels resources are grouped into
+// logical applications by; an empty set turns grouping off
+func ConfigureAppGroupLabels(keys []string) {
+	appGroupLabels.Lock()
+	defer appGroupLabels.Unlock()
+	appGroupLabels.keys = keys
+}
+
+// appGroups turns the values of the app group labels into ApplicationGroup
+// nodes, one per namespace and value, linked from their members so large
+// graphs can be collapsed into logical applications. A resource joins the
+// group of the first label it carries. Like ExternalEndpoints the groups have
+// no Kubernetes object and get a synthetic ID; their health is the worst of
+// their members'.
+func appGroups(resources []LightResource) ([]LightResource, []ClusterLink) {
+	appGroupLabels.RLock()
+	keys := appGroupLabels.keys
+	appGroupLabels.RUnlock()
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	groups := make(map[string]*LightResource)
+	var links []ClusterLink
+	for i := range resources {
+		res := &resources[i]
+		if res.Namespace == "" {
+			continue
+		}
+		for _, key := range keys {
+			value := res.Labels[key]
+			if value == "" {
+				continue
+			}
+			id := "appgroup-" + res.Namespace + "-" + value
+			g, ok := groups[id]
+			if !ok {
+				g = &LightResource{
+					ID:        id,
+					Name:      value,
+					Namespace: res.Namespace,
+					Kind:      "ApplicationGroup",
+					Status:    "Active",
+					Health:    res.Health,
+					Labels:    map[string]string{key: value},
+					OwnerRefs: []string{},
+				}
+				groups[id] = g
+			}
+			g.Health = worseHealth(g.Health, res.Health)
+			links = append(links, ClusterLink{Source: res.ID, Target: id, Type: "containment"})
+			break
+		}
+	}
+
+	nodes := make([]LightResource, 0, len(groups))
+	for _, g := range groups {
+		nodes = append(nodes, *g)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, links
+}
@@ -4,10 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
 
+	"github.com/anakosmos/backend/src/activity"
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -26,6 +32,9 @@ type applyRequest struct {
 }
 
 type applyResult struct {
+	// File and Document (1-based) locate the object in a multi-file upload
+	File      string `json:"file,omitempty"`
+	Document  int    `json:"document,omitempty"`
 	Kind      string `json:"kind"`
 	Name      string `json:"name"`
 	Namespace string `json:"namespace,omitempty"`
@@ -33,151 +42,174 @@ type applyResult struct {
 	Error     string `json:"error,omitempty"`
 }
 
-// HandleApplyYaml accepts multi-document YAML and applies resources to the cluster.
+// HandleApplyYaml accepts multi-document YAML and applies resources to the
+// cluster. A multipart upload may carry several YAML files or zip/tar archives
+// of a manifest directory; results then name the file of each object.
 func HandleApplyYaml(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 	if config == nil {
-		http.Error(w, "Kubernetes config not loaded", http.StatusServiceUnavailable)
+		i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
 		return
 	}
 	if r.Method != "POST" {
-		http.Error(w, "POST required", http.StatusMethodNotAllowed)
+		i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodRequired", "POST")
 		return
 	}
 
-	body, err := io.ReadAll(r.Body)
+	files, defaultNamespace, err := readApplyRequest(r)
 	if err != nil {
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
-		return
-	}
-
-	defaultNamespace := r.URL.Query().Get("defaultNamespace")
-	yamlContent := string(body)
-
-	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
-		var payload applyRequest
-		if err := json.Unmarshal(body, &payload); err != nil {
-			http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
-			return
-		}
-		yamlContent = payload.YAML
-		if defaultNamespace == "" {
-			defaultNamespace = payload.DefaultNamespace
-		}
-	}
-
-	if strings.TrimSpace(yamlContent) == "" {
-		http.Error(w, "YAML content is empty", http.StatusBadRequest)
+		i18n.ErrorFrom(w, r, http.StatusBadRequest, err)
 		return
 	}
 
 	dynamicClient, err := dynamic.NewForConfig(config)
 	if err != nil {
-		http.Error(w, "Failed to create dynamic client", http.StatusInternalServerError)
+		i18n.Error(w, r, http.StatusInternalServerError, "error.dynamicClientFailed")
 		return
 	}
 
 	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
 	if err != nil {
-		http.Error(w, "Failed to create discovery client", http.StatusInternalServerError)
+		i18n.Error(w, r, http.StatusInternalServerError, "error.discoveryClientFailed")
 		return
 	}
 
 	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
-	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader([]byte(yamlContent)), 4096)
-
+	scope := auth.ScopeFromContext(r.Context())
 	results := []applyResult{}
 	applied := 0
-	for {
-		var rawObj map[string]interface{}
-		if err := decoder.Decode(&rawObj); err != nil {
-			if err == io.EOF {
-				break
+	for _, file := range files {
+		decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(file.Content), 4096)
+		document := 0
+		add := func(res applyResult) {
+			if file.Name != "" {
+				res.File = file.Name
+				res.Document = document
 			}
-			results = append(results, applyResult{Status: "error", Error: err.Error()})
-			continue
-		}
-		if len(rawObj) == 0 {
-			continue
-		}
-
-		u := &unstructured.Unstructured{Object: rawObj}
-		if u.GetName() == "" {
-			results = append(results, applyResult{Status: "error", Error: "resource name missing"})
-			continue
-		}
-
-		gvk := u.GroupVersionKind()
-		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
-		if err != nil {
-			results = append(results, applyResult{
-				Kind:   gvk.Kind,
-				Name:   u.GetName(),
-				Status: "error",
-				Error:  err.Error(),
-			})
-			continue
+			results = append(results, res)
 		}
+		for {
+			var rawObj map[string]interface{}
+			document++
+			if err := decoder.Decode(&rawObj); err != nil {
+				if err == io.EOF {
+					break
+				}
+				add(applyResult{Status: "error", Error: err.Error()})
+				continue
+			}
+			if len(rawObj) == 0 {
+				continue
+			}
 
-		baseResource := dynamicClient.Resource(mapping.Resource)
-		var resourceInterface dynamic.ResourceInterface = baseResource
-		namespace := u.GetNamespace()
-		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
-			if namespace == "" {
-				namespace = defaultNamespace
-				u.SetNamespace(namespace)
+			u := &unstructured.Unstructured{Object: rawObj}
+			if u.GetName() == "" {
+				add(applyResult{Status: "error", Error: "resource name missing"})
+				continue
 			}
-			if namespace == "" {
-				results = append(results, applyResult{
+
+			gvk := u.GroupVersionKind()
+			mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+			if err != nil {
+				add(applyResult{
 					Kind:   gvk.Kind,
 					Name:   u.GetName(),
 					Status: "error",
-					Error:  "namespace missing",
+					Error:  err.Error(),
 				})
 				continue
 			}
-			resourceInterface = baseResource.Namespace(namespace)
-		}
 
-		data, err := json.Marshal(u)
-		if err != nil {
-			results = append(results, applyResult{
-				Kind:      gvk.Kind,
-				Name:      u.GetName(),
-				Namespace: namespace,
-				Status:    "error",
-				Error:     err.Error(),
-			})
-			continue
-		}
+			baseResource := dynamicClient.Resource(mapping.Resource)
+			var resourceInterface dynamic.ResourceInterface = baseResource
+			namespace := u.GetNamespace()
+			if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+				if namespace == "" {
+					namespace = defaultNamespace
+					u.SetNamespace(namespace)
+				}
+				if namespace == "" {
+					add(applyResult{
+						Kind:   gvk.Kind,
+						Name:   u.GetName(),
+						Status: "error",
+						Error:  "namespace missing",
+					})
+					continue
+				}
+				resourceInterface = baseResource.Namespace(namespace)
+			}
 
-		force := true
-		_, err = resourceInterface.Patch(
-			context.Background(),
-			u.GetName(),
-			types.ApplyPatchType,
-			data,
-			metav1PatchOptions(force),
-		)
-		if err != nil {
-			results = append(results, applyResult{
+			if !scope.Allows(namespace) {
+				msg := i18n.T(i18n.Lang(r), "error.clusterScopeForbidden")
+				if namespace != "" {
+					msg = i18n.T(i18n.Lang(r), "error.namespaceForbidden", namespace)
+				}
+				add(applyResult{
+					Kind:      gvk.Kind,
+					Name:      u.GetName(),
+					Namespace: namespace,
+					Status:    "error",
+					Error:     msg,
+				})
+				continue
+			}
+			// Server-side apply is a patch, as it is through the API proxy
+			policyReq := auth.NewPolicyRequest(r, "patch", gvk.Kind, namespace, u.GetName())
+			policyReq.Resource = mapping.Resource.Resource
+			if decision := auth.Authorize(r.Context(), policyReq); !decision.Allowed {
+				add(applyResult{
+					Kind:      gvk.Kind,
+					Name:      u.GetName(),
+					Namespace: namespace,
+					Status:    "error",
+					Error:     decision.Denied(),
+				})
+				continue
+			}
+
+			data, err := json.Marshal(u)
+			if err != nil {
+				add(applyResult{
+					Kind:      gvk.Kind,
+					Name:      u.GetName(),
+					Namespace: namespace,
+					Status:    "error",
+					Error:     err.Error(),
+				})
+				continue
+			}
+
+			force := true
+			_, err = resourceInterface.Patch(
+				context.Background(),
+				u.GetName(),
+				types.ApplyPatchType,
+				data,
+				metav1PatchOptions(force),
+			)
+			if err != nil {
+				add(applyResult{
+					Kind:      gvk.Kind,
+					Name:      u.GetName(),
+					Namespace: namespace,
+					Status:    "error",
+					Error:     err.Error(),
+				})
+				continue
+			}
+
+			applied++
+			add(applyResult{
 				Kind:      gvk.Kind,
 				Name:      u.GetName(),
 				Namespace: namespace,
-				Status:    "error",
-				Error:     err.Error(),
+				Status:    "applied",
 			})
-			continue
 		}
-
-		applied++
-		results = append(results, applyResult{
-			Kind:      gvk.Kind,
-			Name:      u.GetName(),
-			Namespace: namespace,
-			Status:    "applied",
-		})
 	}
 
+	publishApplied(r, results, applied)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"applied": applied,
@@ -185,6 +217,66 @@ func HandleApplyYaml(config *rest.Config, w http.ResponseWriter, r *http.Request
 	})
 }
 
+// publishApplied reports an apply to the activity feed: the object itself for
+// single documents, a count otherwise
+func publishApplied(r *http.Request, results []applyResult, applied int) {
+	switch len(results) {
+	case 0:
+		return
+	case 1:
+		var err error
+		if results[0].Error != "" {
+			err = errors.New(results[0].Error)
+		}
+		activity.Publish(activity.Operation(r, "apply", results[0].Kind, results[0].Namespace, results[0].Name, err))
+		return
+	}
+	item := activity.Operation(r, "apply", "", "", "", nil)
+	item.Message = fmt.Sprintf("applied %d of %d objects", applied, len(results))
+	files := make(map[string]bool)
+	for _, res := range results {
+		files[res.File] = true
+	}
+	if len(files) > 1 {
+		item.Message += fmt.Sprintf(" from %d files", len(files))
+	}
+	if applied < len(results) {
+		item.Severity = "warning"
+	}
+	activity.Publish(item)
+}
+
+// readApplyRequest extracts the manifests and default namespace from a raw
+// YAML body, an applyRequest JSON payload or a multipart upload of files.
+func readApplyRequest(r *http.Request) ([]manifestFile, string, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return readApplyBundle(r)
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, "", i18n.Errorf("error.readBodyFailed")
+	}
+
+	defaultNamespace := r.URL.Query().Get("defaultNamespace")
+	yamlContent := string(body)
+
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		var payload applyRequest
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, "", i18n.Errorf("error.invalidJSON")
+		}
+		yamlContent = payload.YAML
+		if defaultNamespace == "" {
+			defaultNamespace = payload.DefaultNamespace
+		}
+	}
+
+	if strings.TrimSpace(yamlContent) == "" {
+		return nil, "", i18n.Errorf("error.required", "YAML content")
+	}
+	return []manifestFile{{Content: []byte(yamlContent)}}, defaultNamespace, nil
+}
+
 func metav1PatchOptions(force bool) metav1.PatchOptions {
 	return metav1.PatchOptions{
 		FieldManager: "anakosmos-ui",
@@ -0,0 +1,181 @@
+package k8s
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/anakosmos/backend/src/i18n"
+)
+
+// Limits on uploaded manifest bundles, counted after extraction
+const (
+	maxBundleBytes = 32 << 20
+	maxBundleFiles = 500
+)
+
+// manifestFile is one uploaded file of a bundle. Name is its path within the
+// upload ("" for a plain YAML body) and is reported with each result.
+type manifestFile struct {
+	Name    string
+	Content []byte
+}
+
+// isManifestName reports whether a file inside an archive holds manifests.
+// Hidden files and directories (e.g. __MACOSX, .git) are skipped.
+func isManifestName(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return false
+		}
+	}
+	switch strings.ToLower(path.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// bundleReader collects manifest files while enforcing the bundle limits
+type bundleReader struct {
+	files []manifestFile
+	size  int64
+}
+
+func (b *bundleReader) add(name string, r io.Reader) error {
+	if len(b.files) >= maxBundleFiles {
+		return i18n.Errorf("error.tooManyFiles", maxBundleFiles)
+	}
+	content, err := io.ReadAll(io.LimitReader(r, maxBundleBytes-b.size+1))
+	if err != nil {
+		return i18n.Errorf("error.readFileFailed", name, err)
+	}
+	b.size += int64(len(content))
+	if b.size > maxBundleBytes {
+		return i18n.Errorf("error.bundleTooLarge", maxBundleBytes>>20)
+	}
+	b.files = append(b.files, manifestFile{Name: name, Content: content})
+	return nil
+}
+
+// addUpload adds an uploaded file, extracting zip and tar (optionally
+// gzipped) archives of a manifest directory
+func (b *bundleReader) addUpload(header *multipart.FileHeader) error {
+	f, err := header.Open()
+	if err != nil {
+		return i18n.Errorf("error.readFileFailed", header.Filename, err)
+	}
+	defer f.Close()
+
+	name := path.Clean("/" + strings.ReplaceAll(header.Filename, "\\", "/"))[1:]
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return b.addZip(name, f, header.Size)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return i18n.Errorf("error.invalidArchive", name, err)
+		}
+		defer gz.Close()
+		return b.addTar(name, gz)
+	case strings.HasSuffix(lower, ".tar"):
+		return b.addTar(name, f)
+	}
+	return b.add(name, f)
+}
+
+func (b *bundleReader) addZip(archive string, f multipart.File, size int64) error {
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		return i18n.Errorf("error.invalidArchive", archive, err)
+	}
+	for _, entry := range zr.File {
+		if entry.FileInfo().IsDir() || !isManifestName(entry.Name) {
+			continue
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			return i18n.Errorf("error.invalidArchive", archive, err)
+		}
+		err = b.add(archive+"/"+path.Clean(entry.Name), rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *bundleReader) addTar(archive string, r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return i18n.Errorf("error.invalidArchive", archive, err)
+		}
+		if header.Typeflag != tar.TypeReg || !isManifestName(strings.TrimPrefix(header.Name, "./")) {
+			continue
+		}
+		if err := b.add(archive+"/"+path.Clean(header.Name), tr); err != nil {
+			return err
+		}
+	}
+}
+
+// readApplyBundle reads a multipart upload of YAML files and archives. Files
+// are applied in path order, like a manifest directory with kubectl.
+func readApplyBundle(r *http.Request) ([]manifestFile, string, error) {
+	r.Body = http.MaxBytesReader(nil, r.Body, maxBundleBytes)
+	if err := r.ParseMultipartForm(maxBundleBytes); err != nil {
+		return nil, "", i18n.Errorf("error.invalidMultipart", err)
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	defaultNamespace := r.URL.Query().Get("defaultNamespace")
+	if defaultNamespace == "" {
+		defaultNamespace = r.FormValue("defaultNamespace")
+	}
+
+	b := &bundleReader{}
+	for _, headers := range r.MultipartForm.File {
+		for _, header := range headers {
+			if err := b.addUpload(header); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	sort.SliceStable(b.files, func(i, j int) bool { return b.files[i].Name < b.files[j].Name })
+
+	nonEmpty := b.files[:0]
+	for _, f := range b.files {
+		if len(bytes.TrimSpace(f.Content)) > 0 {
+			nonEmpty = append(nonEmpty, f)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return nil, "", i18n.Errorf("error.noManifests")
+	}
+	return nonEmpty, defaultNamespace, nil
+}
+
+// joinManifests concatenates the files of a bundle into one multi-document
+// stream, for checks that don't report per file
+func joinManifests(files []manifestFile) []byte {
+	var buf bytes.Buffer
+	for _, f := range files {
+		buf.WriteString("\n---\n")
+		buf.Write(f.Content)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,103 @@
+package k8s
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ArgoCDInfo summarizes ArgoCD Applications, ApplicationSets and AppProjects.
+// Applications link to their AppProject; the ApplicationSet generating an
+// Application owns it.
+type ArgoCDInfo struct {
+	// Project is the AppProject of an Application
+	Project string `json:"project,omitempty"`
+	// RepoURL, Path (or Chart) and Revision are what an Application syncs
+	RepoURL  string `json:"repoURL,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Chart    string `json:"chart,omitempty"`
+	Revision string `json:"revision,omitempty"`
+	// Generators are the generator types of an ApplicationSet, e.g. git, list
+	Generators []string `json:"generators,omitempty"`
+	// SourceRepos and Destinations count what an AppProject allows
+	SourceRepos  int `json:"sourceRepos,omitempty"`
+	Destinations int `json:"destinations,omitempty"`
+	// Message is the error condition of an ApplicationSet
+	Message string `json:"message,omitempty"`
+}
+
+// applyArgoApplicationStatus reports an Application's sync status, with the
+// health ArgoCD assessed: degraded or missing is an error, progressing,
+// suspended or unknown a warning
+func applyArgoApplicationStatus(obj *unstructured.Unstructured, res *LightResource) {
+	info := &ArgoCDInfo{Project: "default"}
+	if project, _, _ := unstructured.NestedString(obj.Object, "spec", "project"); project != "" {
+		info.Project = project
+	}
+	source, found, _ := unstructured.NestedMap(obj.Object, "spec", "source")
+	if !found {
+		// Multi-source Applications: the first source stands for them
+		if sources, _, _ := unstructured.NestedSlice(obj.Object, "spec", "sources"); len(sources) > 0 {
+			source, _ = sources[0].(map[string]interface{})
+		}
+	}
+	info.RepoURL, _, _ = unstructured.NestedString(source, "repoURL")
+	info.Path, _, _ = unstructured.NestedString(source, "path")
+	info.Chart, _, _ = unstructured.NestedString(source, "chart")
+	info.Revision, _, _ = unstructured.NestedString(obj.Object, "status", "sync", "revision")
+	res.ArgoCD = info
+
+	if syncStatus, found, _ := unstructured.NestedString(obj.Object, "status", "sync", "status"); found {
+		res.Status = syncStatus
+	}
+	if healthStatus, found, _ := unstructured.NestedString(obj.Object, "status", "health", "status"); found {
+		switch healthStatus {
+		case "Degraded", "Missing":
+			res.Health = "error"
+		case "Progressing", "Suspended":
+			res.Health = "warning"
+		case "Healthy":
+			res.Health = "ok"
+		default:
+			res.Health = "warning"
+		}
+	}
+}
+
+// applyApplicationSetStatus fills an ApplicationSet's generators and derives
+// health from its conditions: an error occurred is an error, Applications not
+// up to date yet a warning
+func applyApplicationSetStatus(obj *unstructured.Unstructured, res *LightResource) {
+	info := &ArgoCDInfo{}
+	generators, _, _ := unstructured.NestedSlice(obj.Object, "spec", "generators")
+	for _, g := range generators {
+		m, _ := g.(map[string]interface{})
+		for generator := range m {
+			info.Generators = append(info.Generators, generator)
+		}
+	}
+	sort.Strings(info.Generators)
+	res.ArgoCD = info
+
+	for _, cond := range objectConditions(obj) {
+		switch {
+		case cond.condType == "ErrorOccurred" && cond.status == "True":
+			res.Status, res.Health = "Error", "error"
+			info.Message = cond.message
+			return
+		case cond.condType == "ResourcesUpToDate" && cond.status == "True":
+			res.Status, res.Health = "UpToDate", "ok"
+		case cond.condType == "ResourcesUpToDate" && cond.status == "False":
+			res.Status, res.Health = "Progressing", "warning"
+		}
+	}
+}
+
+// applyAppProjectStatus fills what an AppProject allows; projects have no
+// status of their own
+func applyAppProjectStatus(obj *unstructured.Unstructured, res *LightResource) {
+	repos, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "sourceRepos")
+	destinations, _, _ := unstructured.NestedSlice(obj.Object, "spec", "destinations")
+	res.ArgoCD = &ArgoCDInfo{SourceRepos: len(repos), Destinations: len(destinations)}
+	res.Status, res.Health = "Active", "ok"
+}
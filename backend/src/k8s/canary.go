@@ -0,0 +1,194 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// CanaryInfo is the progressive delivery state of an Argo Rollout or a
+// Flagger Canary
+type CanaryInfo struct {
+	Provider string `json:"provider"` // argo-rollouts or flagger
+	Strategy string `json:"strategy"` // canary or blueGreen
+	Phase    string `json:"phase,omitempty"`
+	// Traffic split in percent; blue-green reports the active side as stable
+	CanaryWeight int `json:"canaryWeight"`
+	StableWeight int `json:"stableWeight"`
+	// Step is current/total canary steps (Argo) or the analysis iteration (Flagger)
+	Step string `json:"step,omitempty"`
+	// Services routing to each side (blue-green: active is stable, preview canary)
+	StableService string `json:"stableService,omitempty"`
+	CanaryService string `json:"canaryService,omitempty"`
+	// Workloads running each side: ReplicaSets (Argo) or Deployments (Flagger)
+	StableWorkload string `json:"stableWorkload,omitempty"`
+	CanaryWorkload string `json:"canaryWorkload,omitempty"`
+	// WorkloadRef is the Deployment a Rollout takes its pod template from, or
+	// the Flagger target
+	WorkloadRef *ScaleTargetRef `json:"workloadRef,omitempty"`
+}
+
+// applyRolloutStatus fills an Argo Rollout's health and canary state
+func applyRolloutStatus(obj *unstructured.Unstructured, res *LightResource) {
+	info := &CanaryInfo{Provider: "argo-rollouts", Strategy: "canary", StableWeight: 100}
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	info.Phase = phase
+	res.Status = phase
+	switch phase {
+	case "Healthy":
+		res.Health = "ok"
+	case "Degraded":
+		res.Health = "error"
+	case "":
+		res.Status = "Unknown"
+		res.Health = "warning"
+	default: // Progressing, Paused
+		res.Health = "warning"
+	}
+	if selector, found, _ := unstructured.NestedStringMap(obj.Object, "spec", "selector", "matchLabels"); found {
+		res.Selector = selector
+	}
+	if kind, found, _ := unstructured.NestedString(obj.Object, "spec", "workloadRef", "kind"); found {
+		name, _, _ := unstructured.NestedString(obj.Object, "spec", "workloadRef", "name")
+		info.WorkloadRef = &ScaleTargetRef{Kind: kind, Name: name}
+	}
+
+	// ReplicaSets are named <rollout>-<pod-template-hash>
+	stableHash, _, _ := unstructured.NestedString(obj.Object, "status", "stableRS")
+	currentHash, _, _ := unstructured.NestedString(obj.Object, "status", "currentPodHash")
+	if stableHash != "" {
+		info.StableWorkload = obj.GetName() + "-" + stableHash
+	}
+	if currentHash != "" && currentHash != stableHash {
+		info.CanaryWorkload = obj.GetName() + "-" + currentHash
+	}
+
+	if _, found, _ := unstructured.NestedMap(obj.Object, "spec", "strategy", "blueGreen"); found {
+		info.Strategy = "blueGreen"
+		info.StableService, _, _ = unstructured.NestedString(obj.Object, "spec", "strategy", "blueGreen", "activeService")
+		info.CanaryService, _, _ = unstructured.NestedString(obj.Object, "spec", "strategy", "blueGreen", "previewService")
+		res.Canary = info
+		return
+	}
+	info.StableService, _, _ = unstructured.NestedString(obj.Object, "spec", "strategy", "canary", "stableService")
+	info.CanaryService, _, _ = unstructured.NestedString(obj.Object, "spec", "strategy", "canary", "canaryService")
+
+	steps, _, _ := unstructured.NestedSlice(obj.Object, "spec", "strategy", "canary", "steps")
+	index, hasIndex, _ := unstructured.NestedInt64(obj.Object, "status", "currentStepIndex")
+	if len(steps) > 0 && hasIndex {
+		info.Step = fmt.Sprintf("%d/%d", min(int(index), len(steps)), len(steps))
+	}
+
+	// With traffic routing the controller reports the applied weights;
+	// otherwise derive them from the last setWeight step reached
+	if weight, found, _ := unstructured.NestedInt64(obj.Object, "status", "canary", "weights", "canary", "weight"); found {
+		info.CanaryWeight = int(weight)
+		if stable, found, _ := unstructured.NestedInt64(obj.Object, "status", "canary", "weights", "stable", "weight"); found {
+			info.StableWeight = int(stable)
+		} else {
+			info.StableWeight = 100 - info.CanaryWeight
+		}
+	} else if info.CanaryWorkload != "" && hasIndex && int(index) < len(steps) {
+		for _, step := range steps[:index+1] {
+			if m, ok := step.(map[string]interface{}); ok {
+				if w, ok := m["setWeight"].(int64); ok {
+					info.CanaryWeight = int(w)
+				}
+			}
+		}
+		info.StableWeight = 100 - info.CanaryWeight
+	}
+	res.Canary = info
+}
+
+// applyFlaggerStatus fills a Flagger Canary's health and canary state.
+// Flagger runs the stable version as <target>-primary and shifts traffic
+// between the <service>-primary and <service>-canary Services.
+func applyFlaggerStatus(obj *unstructured.Unstructured, res *LightResource) {
+	info := &CanaryInfo{Provider: "flagger", Strategy: "canary"}
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	info.Phase = phase
+	res.Status = phase
+	switch phase {
+	case "Initialized", "Succeeded":
+		res.Health = "ok"
+	case "Failed":
+		res.Health = "error"
+	case "":
+		res.Status = "Unknown"
+		res.Health = "warning"
+	default: // Initializing, Waiting, Progressing, WaitingPromotion, Promoting, Finalising
+		res.Health = "warning"
+	}
+
+	targetKind, _, _ := unstructured.NestedString(obj.Object, "spec", "targetRef", "kind")
+	targetName, _, _ := unstructured.NestedString(obj.Object, "spec", "targetRef", "name")
+	if targetName != "" {
+		if targetKind == "" {
+			targetKind = "Deployment"
+		}
+		info.WorkloadRef = &ScaleTargetRef{Kind: targetKind, Name: targetName}
+		info.StableWorkload = targetName + "-primary"
+		info.CanaryWorkload = targetName
+	}
+	service, _, _ := unstructured.NestedString(obj.Object, "spec", "service", "name")
+	if service == "" {
+		service = targetName
+	}
+	if service != "" {
+		info.StableService = service + "-primary"
+		info.CanaryService = service + "-canary"
+	}
+
+	weight, _, _ := unstructured.NestedInt64(obj.Object, "status", "canaryWeight")
+	info.CanaryWeight = int(weight)
+	info.StableWeight = 100 - info.CanaryWeight
+	if iterations, found, _ := unstructured.NestedInt64(obj.Object, "status", "iterations"); found && iterations > 0 {
+		info.Step = fmt.Sprintf("%d", iterations)
+	}
+	res.Canary = info
+}
+
+// canaryLinks connects a Rollout or Canary to the Services splitting its
+// traffic and the workloads running each side, skipping anything already
+// linked to it (the controllers own their ReplicaSets, primaries and
+// generated Services)
+func canaryLinks(res *LightResource, svcMap, workloadMap map[string]string, existing []ClusterLink) []ClusterLink {
+	info := res.Canary
+	if info == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, l := range existing {
+		if l.Source == res.ID {
+			seen[l.Target] = true
+		} else if l.Target == res.ID {
+			seen[l.Source] = true
+		}
+	}
+	var links []ClusterLink
+	link := func(target, linkType string) {
+		if target != "" && !seen[target] {
+			seen[target] = true
+			links = append(links, ClusterLink{Source: res.ID, Target: target, Type: linkType})
+		}
+	}
+
+	for _, name := range []string{info.StableService, info.CanaryService} {
+		if name != "" {
+			link(svcMap[res.Namespace+"/"+name], "network")
+		}
+	}
+	if info.WorkloadRef != nil {
+		link(workloadMap[res.Namespace+"/"+info.WorkloadRef.Kind+"/"+info.WorkloadRef.Name], "owner")
+	}
+	if info.Provider == "flagger" && info.WorkloadRef != nil {
+		// Flagger's apex Service (<service>) sits in front of both sides
+		if apex := strings.TrimSuffix(info.CanaryService, "-canary"); apex != "" {
+			link(svcMap[res.Namespace+"/"+apex], "network")
+		}
+		link(workloadMap[res.Namespace+"/"+info.WorkloadRef.Kind+"/"+info.StableWorkload], "owner")
+	}
+	return links
+}
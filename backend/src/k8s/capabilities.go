@@ -0,0 +1,173 @@
+package k8s
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anakosmos/backend/src/i18n"
+
+	"k8s.io/client-go/rest"
+)
+
+// ClusterCapabilities are the optional APIs and features a cluster offers, so
+// subsystems and the UI only enable what will work there
+type ClusterCapabilities struct {
+	Cluster string `json:"cluster"`
+	Version string `json:"version,omitempty"`
+	// Metrics is the metrics.k8s.io API (metrics-server)
+	Metrics        bool `json:"metrics"`
+	ArgoCD         bool `json:"argoCD"`
+	ArgoRollouts   bool `json:"argoRollouts"`
+	Flagger        bool `json:"flagger"`
+	Flux           bool `json:"flux"`
+	Istio          bool `json:"istio"`
+	GatewayAPI     bool `json:"gatewayAPI"`
+	OpenShift      bool `json:"openShift"` // route.openshift.io Routes
+	EndpointSlices bool `json:"endpointSlices"`
+	CertManager    bool `json:"certManager"`
+	ClusterAPI     bool `json:"clusterAPI"`
+	// PodSecurity is the Pod Security admission, on by default from 1.23
+	PodSecurity bool   `json:"podSecurity"`
+	ProbedAt    string `json:"probedAt"`
+}
+
+var capabilityCache = struct {
+	sync.Mutex
+	results map[string]*ClusterCapabilities
+	at      map[string]time.Time
+}{results: make(map[string]*ClusterCapabilities), at: make(map[string]time.Time)}
+
+// Capabilities returns the capabilities of the cluster behind config, probed
+// on first contact and again once discovery is refreshed (discoveryTTL, or a
+// CRD added or removed)
+func Capabilities(config *rest.Config) (*ClusterCapabilities, error) {
+	key := credentialKey(config)
+	capabilityCache.Lock()
+	if c, ok := capabilityCache.results[key]; ok && time.Since(capabilityCache.at[key]) < discoveryTTL {
+		capabilityCache.Unlock()
+		return c, nil
+	}
+	capabilityCache.Unlock()
+
+	c, err := probeCapabilities(config)
+	if err != nil {
+		return nil, err
+	}
+	capabilityCache.Lock()
+	capabilityCache.results[key] = c
+	capabilityCache.at[key] = time.Now()
+	capabilityCache.Unlock()
+	return c, nil
+}
+
+// invalidateCapabilities drops the probed capabilities of the cluster at host
+func invalidateCapabilities(host string) {
+	capabilityCache.Lock()
+	defer capabilityCache.Unlock()
+	for k := range capabilityCache.results {
+		if strings.HasPrefix(k, host+"|") {
+			delete(capabilityCache.results, k)
+			delete(capabilityCache.at, k)
+		}
+	}
+}
+
+// probeOnContact probes a cluster when a watch connects to it, so requests
+// for its capabilities are answered from the cache
+func probeOnContact(config *rest.Config) {
+	if _, err := Capabilities(config); err != nil {
+		log.Printf("Capability probe failed: %v", err)
+	}
+}
+
+func probeCapabilities(config *rest.Config) (*ClusterCapabilities, error) {
+	served := clusterServes(config)
+	if served == nil {
+		// Has would report everything as served
+		return nil, fmt.Errorf("discovery of %s failed", config.Host)
+	}
+	client, err := cachedDiscovery(config)
+	if err != nil {
+		return nil, err
+	}
+	servesAny := func(group, resource string, versions ...string) bool {
+		for _, v := range versions {
+			if served.Has(group, v, resource) {
+				return true
+			}
+		}
+		return false
+	}
+	c := &ClusterCapabilities{
+		Cluster:        config.Host,
+		Metrics:        served.Has("metrics.k8s.io", "v1beta1", "pods"),
+		ArgoCD:         served.Has("argoproj.io", "v1alpha1", "applications"),
+		ArgoRollouts:   served.Has("argoproj.io", "v1alpha1", "rollouts"),
+		Flagger:        served.Has("flagger.app", "v1beta1", "canaries"),
+		Flux:           servesAny("kustomize.toolkit.fluxcd.io", "kustomizations", "v1", "v1beta2") || servesAny("helm.toolkit.fluxcd.io", "helmreleases", "v2", "v2beta2", "v2beta1"),
+		Istio:          servesAny("networking.istio.io", "virtualservices", "v1", "v1beta1", "v1alpha3"),
+		GatewayAPI:     served.Has("gateway.networking.k8s.io", "v1", "httproutes"),
+		OpenShift:      served.Has("route.openshift.io", "v1", "routes"),
+		EndpointSlices: served.Has("discovery.k8s.io", "v1", "endpointslices"),
+		CertManager:    served.Has(certManagerGroup, "v1", "certificates"),
+		ClusterAPI:     servesAny(capiGroup, "machines", "v1beta2", "v1beta1"),
+		ProbedAt:       time.Now().UTC().Format(time.RFC3339),
+	}
+	if version, err := client.ServerVersion(); err == nil {
+		c.Version = version.GitVersion
+		major, _ := strconv.Atoi(version.Major)
+		minor, _ := strconv.Atoi(strings.TrimRight(version.Minor, "+"))
+		c.PodSecurity = major > 1 || (major == 1 && minor >= 23)
+	}
+	log.Printf("Cluster %s %s: metrics=%t argocd=%t rollouts=%t flagger=%t flux=%t istio=%t gateway=%t openshift=%t endpointslices=%t certmanager=%t clusterapi=%t podsecurity=%t",
+		c.Cluster, c.Version, c.Metrics, c.ArgoCD, c.ArgoRollouts, c.Flagger, c.Flux, c.Istio, c.GatewayAPI, c.OpenShift, c.EndpointSlices, c.CertManager, c.ClusterAPI, c.PodSecurity)
+	return c, nil
+}
+
+// HandleClusterCapabilities serves /api/clusters/{id}/capabilities, where id
+// is a registered ClusterConnection or "local". ?refresh=true probes again.
+func HandleClusterCapabilities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodRequired", "GET")
+		return
+	}
+	id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/clusters/"), "/capabilities")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		i18n.Error(w, r, http.StatusNotFound, "error.resourceNotFound")
+		return
+	}
+	if err := AuthorizeCluster(r.Context(), id); err != nil {
+		if errors.Is(err, errClusterForbidden) {
+			i18n.Error(w, r, http.StatusForbidden, "error.clusterForbidden")
+		} else {
+			http.Error(w, err.Error(), http.StatusForbidden)
+		}
+		return
+	}
+	config, err := ClusterConfig(r.Context(), id)
+	if err != nil {
+		log.Printf("Resolving cluster %s failed: %v", id, err)
+		i18n.Error(w, r, http.StatusBadGateway, "error.unavailable", id)
+		return
+	}
+	if r.URL.Query().Get("refresh") == "true" {
+		invalidateDiscovery(config.Host)
+	}
+	c, err := Capabilities(config)
+	if err != nil {
+		log.Printf("Capability probe of cluster %s failed: %v", id, err)
+		i18n.Error(w, r, http.StatusBadGateway, "error.unavailable", id)
+		return
+	}
+	result := *c
+	result.Cluster = id
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,260 @@
+package k8s
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// CapacityAmounts holds CPU (millicores), memory (bytes) and pod slots
+type CapacityAmounts struct {
+	CPU    int64 `json:"cpu"`
+	Memory int64 `json:"memory"`
+	Pods   int64 `json:"pods"`
+}
+
+func (a *CapacityAmounts) add(b CapacityAmounts) {
+	a.CPU += b.CPU
+	a.Memory += b.Memory
+	a.Pods += b.Pods
+}
+
+// CapacityUsage is the share of allocatable already requested, in percent
+type CapacityUsage struct {
+	CPU    float64 `json:"cpu"`
+	Memory float64 `json:"memory"`
+	Pods   float64 `json:"pods"`
+}
+
+// NodeCapacity is the scheduling headroom of one node
+type NodeCapacity struct {
+	Name        string          `json:"name"`
+	Schedulable bool            `json:"schedulable"`
+	Reasons     []string        `json:"reasons,omitempty"` // why new pods won't land here
+	Pressure    []string        `json:"pressure,omitempty"`
+	Allocatable CapacityAmounts `json:"allocatable"`
+	Requested   CapacityAmounts `json:"requested"`
+	Free        CapacityAmounts `json:"free"`
+	Usage       CapacityUsage   `json:"usage"`
+	Fits        *bool           `json:"fits,omitempty"` // only with ?cpu=/?memory=
+}
+
+// LargestPod is the biggest pod (by one resource) a single node can still take
+type LargestPod struct {
+	CPU    int64  `json:"cpu"`
+	Memory int64  `json:"memory"`
+	Node   string `json:"node"`
+}
+
+// CapacityResponse is served by /api/cluster/capacity
+type CapacityResponse struct {
+	Nodes            []NodeCapacity  `json:"nodes"`
+	SchedulableNodes int             `json:"schedulableNodes"`
+	Allocatable      CapacityAmounts `json:"allocatable"`
+	Requested        CapacityAmounts `json:"requested"`
+	Free             CapacityAmounts `json:"free"`
+	Usage            CapacityUsage   `json:"usage"`
+	// Headroom on schedulable nodes only: the largest CPU and memory requests
+	// that still fit somewhere, with the other resource available alongside
+	LargestByCPU    *LargestPod `json:"largestByCpu,omitempty"`
+	LargestByMemory *LargestPod `json:"largestByMemory,omitempty"`
+	FittingNodes    *int        `json:"fittingNodes,omitempty"` // only with ?cpu=/?memory=
+}
+
+func percent(used, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return math.Round(float64(used)/float64(total)*1000) / 10
+}
+
+func capacityUsage(requested, allocatable CapacityAmounts) CapacityUsage {
+	return CapacityUsage{
+		CPU:    percent(requested.CPU, allocatable.CPU),
+		Memory: percent(requested.Memory, allocatable.Memory),
+		Pods:   percent(requested.Pods, allocatable.Pods),
+	}
+}
+
+// podRequests returns the CPU and memory a pod requests from the scheduler,
+// taking one pod slot
+func podRequests(spec *corev1.PodSpec) CapacityAmounts {
+	usage, _ := podComputeUsage(spec)
+	return CapacityAmounts{
+		CPU:    usage.Name(corev1.ResourceRequestsCPU, resource.DecimalSI).MilliValue(),
+		Memory: usage.Name(corev1.ResourceRequestsMemory, resource.BinarySI).Value(),
+		Pods:   1,
+	}
+}
+
+func nodeAllocatable(n *corev1.Node) CapacityAmounts {
+	alloc := n.Status.Allocatable
+	return CapacityAmounts{
+		CPU:    alloc.Cpu().MilliValue(),
+		Memory: alloc.Memory().Value(),
+		Pods:   alloc.Pods().Value(),
+	}
+}
+
+// nodeRequests sums the requests of scheduled pods per node name
+func nodeRequests(pods []corev1.Pod) map[string]CapacityAmounts {
+	requested := make(map[string]CapacityAmounts)
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		amounts := requested[pod.Spec.NodeName]
+		amounts.add(podRequests(&pod.Spec))
+		requested[pod.Spec.NodeName] = amounts
+	}
+	return requested
+}
+
+// nodeSchedulability lists why the scheduler would skip the node for a pod
+// without tolerations, and which pressure conditions it reports
+func nodeSchedulability(n *corev1.Node) (reasons, pressure []string) {
+	if n.Spec.Unschedulable {
+		reasons = append(reasons, "cordoned")
+	}
+	ready := false
+	for _, cond := range n.Status.Conditions {
+		switch cond.Type {
+		case corev1.NodeReady:
+			ready = cond.Status == corev1.ConditionTrue
+		case corev1.NodeMemoryPressure, corev1.NodeDiskPressure, corev1.NodePIDPressure:
+			if cond.Status == corev1.ConditionTrue {
+				pressure = append(pressure, string(cond.Type))
+			}
+		}
+	}
+	if !ready {
+		reasons = append(reasons, "not ready")
+	}
+	for _, taint := range n.Spec.Taints {
+		if taint.Effect == corev1.TaintEffectNoSchedule || taint.Effect == corev1.TaintEffectNoExecute {
+			reasons = append(reasons, "taint "+taint.Key+":"+string(taint.Effect))
+		}
+	}
+	return reasons, pressure
+}
+
+// HandleCapacity serves /api/cluster/capacity: requested vs allocatable CPU,
+// memory and pod slots per node and cluster-wide, and the largest pod that can
+// still be scheduled. ?cpu=&memory= (quantities) additionally reports which
+// nodes could take a pod with those requests.
+func HandleCapacity(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	// Node capacity is cluster-scoped information
+	if !auth.RequireNamespace(w, r, "") {
+		return
+	}
+
+	var probe *CapacityAmounts
+	if cpu, mem := r.URL.Query().Get("cpu"), r.URL.Query().Get("memory"); cpu != "" || mem != "" {
+		probe = &CapacityAmounts{Pods: 1}
+		for _, p := range []struct {
+			value string
+			dst   *int64
+			milli bool
+		}{{cpu, &probe.CPU, true}, {mem, &probe.Memory, false}} {
+			if p.value == "" {
+				continue
+			}
+			q, err := resource.ParseQuantity(p.value)
+			if err != nil {
+				i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "quantity "+p.value)
+				return
+			}
+			if p.milli {
+				*p.dst = q.MilliValue()
+			} else {
+				*p.dst = q.Value()
+			}
+		}
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		i18n.Error(w, r, http.StatusInternalServerError, "error.clientFailed")
+		return
+	}
+	nodes, err := clientset.CoreV1().Nodes().List(r.Context(), metav1.ListOptions{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Finished pods no longer hold their requests
+	pods, err := clientset.CoreV1().Pods("").List(r.Context(), metav1.ListOptions{
+		FieldSelector: "status.phase!=Succeeded,status.phase!=Failed",
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	requested := nodeRequests(pods.Items)
+
+	response := CapacityResponse{Nodes: []NodeCapacity{}}
+	fitting := 0
+	for i := range nodes.Items {
+		n := &nodes.Items[i]
+		node := NodeCapacity{
+			Name:        n.Name,
+			Allocatable: nodeAllocatable(n),
+			Requested:   requested[n.Name],
+		}
+		node.Reasons, node.Pressure = nodeSchedulability(n)
+		node.Schedulable = len(node.Reasons) == 0
+		node.Free = CapacityAmounts{
+			CPU:    max(node.Allocatable.CPU-node.Requested.CPU, 0),
+			Memory: max(node.Allocatable.Memory-node.Requested.Memory, 0),
+			Pods:   max(node.Allocatable.Pods-node.Requested.Pods, 0),
+		}
+		node.Usage = capacityUsage(node.Requested, node.Allocatable)
+
+		response.Allocatable.add(node.Allocatable)
+		response.Requested.add(node.Requested)
+
+		if node.Schedulable {
+			response.SchedulableNodes++
+			response.Free.add(node.Free)
+			if node.Free.Pods > 0 {
+				if response.LargestByCPU == nil || node.Free.CPU > response.LargestByCPU.CPU {
+					response.LargestByCPU = &LargestPod{CPU: node.Free.CPU, Memory: node.Free.Memory, Node: n.Name}
+				}
+				if response.LargestByMemory == nil || node.Free.Memory > response.LargestByMemory.Memory {
+					response.LargestByMemory = &LargestPod{CPU: node.Free.CPU, Memory: node.Free.Memory, Node: n.Name}
+				}
+			}
+		}
+
+		if probe != nil {
+			fits := node.Schedulable && node.Free.Pods >= 1 && node.Free.CPU >= probe.CPU && node.Free.Memory >= probe.Memory
+			node.Fits = &fits
+			if fits {
+				fitting++
+			}
+		}
+		response.Nodes = append(response.Nodes, node)
+	}
+	response.Usage = capacityUsage(response.Requested, response.Allocatable)
+	if probe != nil {
+		response.FittingNodes = &fitting
+	}
+
+	sort.Slice(response.Nodes, func(i, j int) bool {
+		return response.Nodes[i].Name < response.Nodes[j].Name
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
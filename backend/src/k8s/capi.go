@@ -0,0 +1,86 @@
+package k8s
+
+import (
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const capiGroup = "cluster.x-k8s.io"
+
+// capiKinds are the Cluster API kinds shown in the graph, parents first
+var capiKinds = []versionedKind{
+	{"Cluster", capiGroup, "clusters", []string{"v1beta2", "v1beta1"}},
+	{"MachineDeployment", capiGroup, "machinedeployments", []string{"v1beta2", "v1beta1"}},
+	{"Machine", capiGroup, "machines", []string{"v1beta2", "v1beta1"}},
+}
+
+// CAPIInfo summarizes a Cluster API Cluster, MachineDeployment or Machine.
+// Machines link to the Node they back and to their MachineDeployment, which
+// links to its Cluster.
+type CAPIInfo struct {
+	// ClusterName is the CAPI Cluster of a MachineDeployment or Machine
+	ClusterName string `json:"clusterName,omitempty"`
+	// Phase is the lifecycle phase reported by CAPI, e.g. Provisioning
+	Phase string `json:"phase,omitempty"`
+	// NodeName is the Node a Machine backs, once it joined
+	NodeName   string `json:"nodeName,omitempty"`
+	ProviderID string `json:"providerID,omitempty"`
+	// Version is the Kubernetes version of a Machine or MachineDeployment
+	Version string `json:"version,omitempty"`
+	// Replicas is "ready/desired" of a MachineDeployment
+	Replicas       string `json:"replicas,omitempty"`
+	FailureMessage string `json:"failureMessage,omitempty"`
+}
+
+// capiDeploymentLabel names the MachineDeployment of a Machine
+const capiDeploymentLabel = "cluster.x-k8s.io/deployment-name"
+
+// applyCAPIStatus fills a Cluster API object's topology and derives health
+// from its phase: running (or provisioned) is ok, provisioning, scaling and
+// deleting a warning, failed an error
+func applyCAPIStatus(obj *unstructured.Unstructured, res *LightResource) {
+	info := &CAPIInfo{}
+	info.ClusterName, _, _ = unstructured.NestedString(obj.Object, "spec", "clusterName")
+	info.Phase, _, _ = unstructured.NestedString(obj.Object, "status", "phase")
+	info.NodeName, _, _ = unstructured.NestedString(obj.Object, "status", "nodeRef", "name")
+	info.ProviderID, _, _ = unstructured.NestedString(obj.Object, "spec", "providerID")
+	info.Version, _, _ = unstructured.NestedString(obj.Object, "spec", "version")
+	if info.Version == "" {
+		info.Version, _, _ = unstructured.NestedString(obj.Object, "spec", "template", "spec", "version")
+	}
+	if res.Kind == "MachineDeployment" {
+		desired, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		if !found {
+			desired = 1
+		}
+		ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+		info.Replicas = strconv.FormatInt(ready, 10) + "/" + strconv.FormatInt(desired, 10)
+	}
+	info.FailureMessage, _, _ = unstructured.NestedString(obj.Object, "status", "failureMessage")
+	if info.FailureMessage == "" {
+		// v1beta2 keeps the v1beta1 failure fields under status.deprecated
+		info.FailureMessage, _, _ = unstructured.NestedString(obj.Object, "status", "deprecated", "v1beta1", "failureMessage")
+	}
+	res.CAPI = info
+
+	if obj.GetDeletionTimestamp() != nil && info.Phase == "" {
+		info.Phase = "Deleting"
+	}
+	res.Status = info.Phase
+	switch info.Phase {
+	case "Running", "Provisioned":
+		res.Health = "ok"
+	case "Failed":
+		res.Health = "error"
+	case "":
+		res.Status = "Unknown"
+		res.Health = "warning"
+	default:
+		// Pending, Provisioning, ScalingUp, ScalingDown, Deleting, Unknown
+		res.Health = "warning"
+	}
+	if info.FailureMessage != "" {
+		res.Health = "error"
+	}
+}
@@ -0,0 +1,95 @@
+package k8s
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const certManagerGroup = "cert-manager.io"
+
+// certExpiryWarning is how close to notAfter a Certificate turns to warning.
+// cert-manager renews well before that, so reaching it means renewal is stuck.
+const certExpiryWarning = 7 * 24 * time.Hour
+
+// CertificateInfo summarizes a cert-manager Certificate, Issuer or
+// ClusterIssuer. Certificates link to the Secret they write and their issuer.
+type CertificateInfo struct {
+	// SecretName is the Secret a Certificate stores the key pair in
+	SecretName string `json:"secretName,omitempty"`
+	// IssuerKind is Issuer or ClusterIssuer, IssuerName its name
+	IssuerKind  string   `json:"issuerKind,omitempty"`
+	IssuerName  string   `json:"issuerName,omitempty"`
+	DNSNames    []string `json:"dnsNames,omitempty"`
+	NotAfter    string   `json:"notAfter,omitempty"`
+	RenewalTime string   `json:"renewalTime,omitempty"`
+	// IssuerType is how an issuer signs: acme, ca, selfSigned, vault, venafi
+	IssuerType string `json:"issuerType,omitempty"`
+}
+
+// applyCertificateStatus fills a Certificate's secret, issuer and validity.
+// Health comes from the Ready condition and how close notAfter is.
+func applyCertificateStatus(obj *unstructured.Unstructured, res *LightResource) {
+	info := &CertificateInfo{}
+	info.SecretName, _, _ = unstructured.NestedString(obj.Object, "spec", "secretName")
+	info.IssuerName, _, _ = unstructured.NestedString(obj.Object, "spec", "issuerRef", "name")
+	info.IssuerKind, _, _ = unstructured.NestedString(obj.Object, "spec", "issuerRef", "kind")
+	if group, found, _ := unstructured.NestedString(obj.Object, "spec", "issuerRef", "group"); found && group != "" && group != certManagerGroup {
+		// External issuer (e.g. an AWS PCA issuer); not a node of the graph
+		info.IssuerKind = group + "/" + info.IssuerKind
+	} else if info.IssuerKind == "" {
+		info.IssuerKind = "Issuer"
+	}
+	info.DNSNames, _, _ = unstructured.NestedStringSlice(obj.Object, "spec", "dnsNames")
+	info.NotAfter, _, _ = unstructured.NestedString(obj.Object, "status", "notAfter")
+	info.RenewalTime, _, _ = unstructured.NestedString(obj.Object, "status", "renewalTime")
+	res.Certificate = info
+
+	res.Status = "Pending"
+	res.Health = "warning"
+	for _, cond := range objectConditions(obj) {
+		if cond.condType != "Ready" {
+			continue
+		}
+		if cond.status == "True" {
+			res.Status, res.Health = "Ready", "ok"
+		} else if cond.status == "False" {
+			res.Status, res.Health = "NotReady", "error"
+		}
+	}
+	if notAfter, err := time.Parse(time.RFC3339, info.NotAfter); err == nil {
+		switch remaining := time.Until(notAfter); {
+		case remaining <= 0:
+			res.Status, res.Health = "Expired", "error"
+		case remaining < certExpiryWarning && res.Health == "ok":
+			res.Status, res.Health = "ExpiringSoon", "warning"
+		}
+	}
+}
+
+// applyIssuerStatus fills an Issuer's or ClusterIssuer's type and health
+// from its Ready condition
+func applyIssuerStatus(obj *unstructured.Unstructured, res *LightResource) {
+	info := &CertificateInfo{}
+	spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+	for _, t := range []string{"acme", "ca", "selfSigned", "vault", "venafi"} {
+		if _, ok := spec[t]; ok {
+			info.IssuerType = t
+			break
+		}
+	}
+	res.Certificate = info
+
+	res.Status = "Pending"
+	res.Health = "warning"
+	for _, cond := range objectConditions(obj) {
+		if cond.condType != "Ready" {
+			continue
+		}
+		if cond.status == "True" {
+			res.Status, res.Health = "Ready", "ok"
+		} else if cond.status == "False" {
+			res.Status, res.Health = "NotReady", "error"
+		}
+	}
+}
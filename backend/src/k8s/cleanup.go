@@ -0,0 +1,372 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/anakosmos/backend/src/activity"
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// CleanupOptions selects what maintenance cleanup removes
+type CleanupOptions struct {
+	// Namespace limits the cleanup ("" for all namespaces)
+	Namespace string
+	// MaxAge is how long finished Jobs and succeeded Pods are kept
+	MaxAge time.Duration
+	// KeepRevisions is how many scaled-down ReplicaSets are kept per
+	// Deployment, at least 1. A Deployment's revisionHistoryLimit keeps more.
+	KeepRevisions int
+}
+
+// DefaultCleanupOptions keeps a week of finished work and three old revisions
+var DefaultCleanupOptions = CleanupOptions{MaxAge: 7 * 24 * time.Hour, KeepRevisions: 3}
+
+// revisionAnnotation numbers the revisions of a Deployment and its ReplicaSets
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// CleanupCandidate is a resource the cleanup removes (or would remove)
+type CleanupCandidate struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason"`
+	Age       string `json:"age,omitempty"` // time since it finished or was scaled down
+	Error     string `json:"error,omitempty"`
+}
+
+// CleanupResponse is served by /api/maintenance/cleanup
+type CleanupResponse struct {
+	DryRun     bool               `json:"dryRun"`
+	Candidates []CleanupCandidate `json:"candidates"`
+	Deleted    int                `json:"deleted"`
+	Failed     int                `json:"failed"`
+}
+
+func ownedBy(meta metav1.Object, kind string) *metav1.OwnerReference {
+	for i, ref := range meta.GetOwnerReferences() {
+		if ref.Kind == kind {
+			return &meta.GetOwnerReferences()[i]
+		}
+	}
+	return nil
+}
+
+// jobFinished returns when a Job completed or failed, or zero while it runs
+func jobFinished(job *batchv1.Job) (time.Time, string) {
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			if job.Status.CompletionTime != nil {
+				return job.Status.CompletionTime.Time, "completed"
+			}
+			return cond.LastTransitionTime.Time, "completed"
+		case batchv1.JobFailed:
+			return cond.LastTransitionTime.Time, "failed"
+		}
+	}
+	return time.Time{}, ""
+}
+
+// podFinished returns when the last container of a succeeded pod terminated
+func podFinished(pod *corev1.Pod) time.Time {
+	finished := pod.CreationTimestamp.Time
+	for _, cs := range pod.Status.ContainerStatuses {
+		if t := cs.State.Terminated; t != nil && t.FinishedAt.After(finished) {
+			finished = t.FinishedAt.Time
+		}
+	}
+	return finished
+}
+
+// PlanCleanup lists what a cleanup with opts would delete, restricted to scope:
+//   - Jobs finished longer than MaxAge ago. Jobs with a TTL or owned by a
+//     CronJob are left to the TTL controller and the CronJob history limits.
+//   - Succeeded Pods finished longer than MaxAge ago, unless a Job owns them
+//     (they go with the Job).
+//   - ReplicaSets scaled to zero beyond a Deployment's newest KeepRevisions
+//     (or revisionHistoryLimit, when higher). The current revision is always
+//     kept, even when scaled to zero with the Deployment.
+func PlanCleanup(ctx context.Context, clientset *kubernetes.Clientset, opts CleanupOptions, scope auth.Scope) ([]CleanupCandidate, error) {
+	now := time.Now()
+	candidates := []CleanupCandidate{}
+
+	jobs, err := clientset.BatchV1().Jobs(opts.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if !scope.Allows(job.Namespace) || job.Spec.TTLSecondsAfterFinished != nil || ownedBy(job, "CronJob") != nil {
+			continue
+		}
+		finished, state := jobFinished(job)
+		if finished.IsZero() || now.Sub(finished) < opts.MaxAge {
+			continue
+		}
+		candidates = append(candidates, CleanupCandidate{
+			Kind: "Job", Namespace: job.Namespace, Name: job.Name,
+			Reason: "job " + state,
+			Age:    now.Sub(finished).Round(time.Minute).String(),
+		})
+	}
+
+	pods, err := clientset.CoreV1().Pods(opts.Namespace).List(ctx, metav1.ListOptions{FieldSelector: "status.phase=Succeeded"})
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !scope.Allows(pod.Namespace) || ownedBy(pod, "Job") != nil {
+			continue
+		}
+		finished := podFinished(pod)
+		if now.Sub(finished) < opts.MaxAge {
+			continue
+		}
+		candidates = append(candidates, CleanupCandidate{
+			Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name,
+			Reason: "pod succeeded",
+			Age:    now.Sub(finished).Round(time.Minute).String(),
+		})
+	}
+
+	deployments, err := clientset.AppsV1().Deployments(opts.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	owners := make(map[string]*appsv1.Deployment, len(deployments.Items))
+	for i := range deployments.Items {
+		owners[string(deployments.Items[i].UID)] = &deployments.Items[i]
+	}
+	replicaSets, err := clientset.AppsV1().ReplicaSets(opts.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	idle := make(map[string][]*appsv1.ReplicaSet) // deployment UID -> scaled-down ReplicaSets
+	for i := range replicaSets.Items {
+		rs := &replicaSets.Items[i]
+		owner := ownedBy(rs, "Deployment")
+		if owner == nil || !scope.Allows(rs.Namespace) {
+			continue
+		}
+		// ReplicaSets of a Deployment not listed (being deleted, or created
+		// since) are left alone: its current revision isn't known
+		deployment, ok := owners[string(owner.UID)]
+		if !ok || rs.Annotations[revisionAnnotation] == deployment.Annotations[revisionAnnotation] {
+			continue
+		}
+		if (rs.Spec.Replicas != nil && *rs.Spec.Replicas > 0) || rs.Status.Replicas > 0 {
+			continue
+		}
+		idle[string(owner.UID)] = append(idle[string(owner.UID)], rs)
+	}
+	for uid, list := range idle {
+		keep := opts.KeepRevisions
+		if limit := owners[uid].Spec.RevisionHistoryLimit; limit != nil && int(*limit) > keep {
+			keep = int(*limit)
+		}
+		if len(list) <= keep {
+			continue
+		}
+		revision := func(rs *appsv1.ReplicaSet) int64 {
+			v, _ := strconv.ParseInt(rs.Annotations[revisionAnnotation], 10, 64)
+			return v
+		}
+		sort.Slice(list, func(i, j int) bool { return revision(list[i]) > revision(list[j]) })
+		for _, rs := range list[keep:] {
+			candidates = append(candidates, CleanupCandidate{
+				Kind: "ReplicaSet", Namespace: rs.Namespace, Name: rs.Name,
+				Reason: "scaled down revision " + strconv.FormatInt(revision(rs), 10),
+			})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+	return candidates, nil
+}
+
+// ExecuteCleanup deletes the candidates in the background (dependents are
+// garbage collected), recording per-resource errors. Objects already gone count
+// as deleted; candidates already carrying an error (denied by policy) are
+// skipped.
+func ExecuteCleanup(ctx context.Context, clientset *kubernetes.Clientset, candidates []CleanupCandidate) (deleted, failed int) {
+	policy := metav1.DeletePropagationBackground
+	opts := metav1.DeleteOptions{PropagationPolicy: &policy}
+	for i := range candidates {
+		c := &candidates[i]
+		if c.Error != "" {
+			continue
+		}
+		var err error
+		switch c.Kind {
+		case "Job":
+			err = clientset.BatchV1().Jobs(c.Namespace).Delete(ctx, c.Name, opts)
+		case "Pod":
+			err = clientset.CoreV1().Pods(c.Namespace).Delete(ctx, c.Name, opts)
+		case "ReplicaSet":
+			err = clientset.AppsV1().ReplicaSets(c.Namespace).Delete(ctx, c.Name, opts)
+		}
+		if err != nil && !apierrors.IsNotFound(err) {
+			c.Error = err.Error()
+			failed++
+			continue
+		}
+		deleted++
+	}
+	return deleted, failed
+}
+
+// planCleanup plans a cleanup with opts. In namespaced mode, where the
+// credential can't list across namespaces, each permitted namespace in scope
+// is planned on its own.
+func planCleanup(ctx context.Context, config *rest.Config, clientset *kubernetes.Clientset, opts CleanupOptions, scope auth.Scope) ([]CleanupCandidate, error) {
+	permitted := permittedNamespaces(ctx, config)
+	if permitted == nil || opts.Namespace != "" {
+		if permitted != nil && !containsString(permitted, opts.Namespace) {
+			return []CleanupCandidate{}, nil
+		}
+		return PlanCleanup(ctx, clientset, opts, scope)
+	}
+	candidates := []CleanupCandidate{}
+	for _, ns := range permitted {
+		if !scope.Allows(ns) {
+			continue
+		}
+		nsOpts := opts
+		nsOpts.Namespace = ns
+		found, err := PlanCleanup(ctx, clientset, nsOpts, scope)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, found...)
+	}
+	return candidates, nil
+}
+
+// CleanupLoop returns a background task running the cleanup every interval,
+// meant to be registered with the HA elector so only the leader deletes. In
+// namespaced mode only the permitted namespaces are cleaned up.
+func CleanupLoop(config *rest.Config, opts CleanupOptions, interval time.Duration) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		clientset, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			log.Printf("Scheduled cleanup disabled: %v", err)
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			candidates, err := planCleanup(ctx, config, clientset, opts, auth.Unrestricted)
+			if err != nil {
+				log.Printf("Scheduled cleanup failed: %v", err)
+				continue
+			}
+			if len(candidates) == 0 {
+				continue
+			}
+			deleted, failed := ExecuteCleanup(ctx, clientset, candidates)
+			log.Printf("Scheduled cleanup removed %d resources (%d failed)", deleted, failed)
+		}
+	}
+}
+
+// HandleCleanup serves /api/maintenance/cleanup. GET previews the candidates,
+// POST deletes them (POST with ?dryRun=true previews too). ?namespace=,
+// ?days= (finished Jobs and Pods, default 7) and ?keepRevisions= (default 3,
+// at least 1) tune the selection.
+func HandleCleanup(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+	q := r.URL.Query()
+	opts := DefaultCleanupOptions
+	opts.Namespace = q.Get("namespace")
+	if v := q.Get("days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 {
+			i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "days")
+			return
+		}
+		opts.MaxAge = time.Duration(days) * 24 * time.Hour
+	}
+	if v := q.Get("keepRevisions"); v != "" {
+		keep, err := strconv.Atoi(v)
+		if err != nil || keep < 1 {
+			i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "keepRevisions")
+			return
+		}
+		opts.KeepRevisions = keep
+	}
+	if opts.Namespace != "" && !auth.RequireNamespace(w, r, opts.Namespace) {
+		return
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		i18n.Error(w, r, http.StatusInternalServerError, "error.clientFailed")
+		return
+	}
+	candidates, err := planCleanup(r.Context(), config, clientset, opts, auth.ScopeFromContext(r.Context()))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := CleanupResponse{
+		DryRun:     r.Method == http.MethodGet || q.Get("dryRun") == "true",
+		Candidates: candidates,
+	}
+	if !response.DryRun {
+		denied := 0
+		for i := range candidates {
+			c := &candidates[i]
+			if decision := auth.Authorize(r.Context(), auth.NewPolicyRequest(r, "delete", c.Kind, c.Namespace, c.Name)); !decision.Allowed {
+				c.Error = decision.Denied()
+				denied++
+			}
+		}
+		response.Deleted, response.Failed = ExecuteCleanup(r.Context(), clientset, candidates)
+		response.Failed += denied
+
+		item := activity.Operation(r, "cleanup", "", "", "", nil)
+		item.Message = fmt.Sprintf("cleanup deleted %d resources", response.Deleted)
+		if response.Failed > 0 {
+			item.Severity = "warning"
+			item.Message += fmt.Sprintf(", %d failed", response.Failed)
+		}
+		activity.Publish(item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,134 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/settings"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// LocalClusterID names the cluster anakosmos itself is connected to
+const LocalClusterID = "local"
+
+var clusters = struct {
+	sync.RWMutex
+	local     *rest.Config
+	namespace string // holds the token Secrets of registered clusters
+}{}
+
+// ConfigureClusters sets the local connection and the namespace where the
+// token Secrets referenced by ClusterConnections live
+func ConfigureClusters(local *rest.Config, namespace string) {
+	clusters.Lock()
+	defer clusters.Unlock()
+	clusters.local = local
+	clusters.namespace = namespace
+}
+
+var (
+	errClusterNotRegistered = errors.New("cluster is not registered")
+	errClusterForbidden     = errors.New("access to this cluster is not permitted")
+	errClusterUnavailable   = errors.New("cluster is unavailable")
+)
+
+// ClusterConfig resolves a cluster id to a rest config: "" or "local" is the
+// local connection, anything else a registered ClusterConnection
+func ClusterConfig(ctx context.Context, id string) (*rest.Config, error) {
+	clusters.RLock()
+	local, namespace := clusters.local, clusters.namespace
+	clusters.RUnlock()
+
+	if id == "" || id == LocalClusterID {
+		if local == nil {
+			return nil, fmt.Errorf("no local cluster connection")
+		}
+		return local, nil
+	}
+
+	conn, ok := settings.Current().Cluster(id)
+	if !ok {
+		return nil, fmt.Errorf("cluster %q: %w", id, errClusterNotRegistered)
+	}
+	config := &rest.Config{
+		Host:            conn.Server,
+		TLSClientConfig: rest.TLSClientConfig{Insecure: conn.InsecureSkipTLSVerify},
+	}
+	if ref := conn.TokenSecretRef; ref != nil {
+		if local == nil || namespace == "" {
+			return nil, fmt.Errorf("cluster %q: token secrets need a local connection and config namespace", id)
+		}
+		clientset, err := kubernetes.NewForConfig(local)
+		if err != nil {
+			return nil, err
+		}
+		secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("cluster %q: reading token secret: %w", id, err)
+		}
+		token, ok := secret.Data[ref.Key]
+		if !ok {
+			return nil, fmt.Errorf("cluster %q: secret %s has no key %s", id, ref.Name, ref.Key)
+		}
+		config.BearerToken = string(token)
+	}
+	return config, nil
+}
+
+// clusterServer is the API server URL of cluster id, read from the settings
+// without touching its token Secret. ok is false for unknown clusters.
+func clusterServer(id string) (host string, ok bool) {
+	if id == "" || id == LocalClusterID {
+		clusters.RLock()
+		defer clusters.RUnlock()
+		if clusters.local == nil {
+			return "", false
+		}
+		return clusters.local.Host, true
+	}
+	conn, ok := settings.Current().Cluster(id)
+	if !ok {
+		return "", false
+	}
+	return conn.Server, true
+}
+
+// AuthorizeCluster checks whether the caller may reach cluster id before
+// ClusterConfig reads any token Secret for it; the registered server URL comes
+// from the settings. An API token must list the cluster. Registered clusters
+// are reached with the backend's credentials and tenancy namespaces only apply
+// to the local cluster, so they also need an unrestricted scope and a policy
+// allowing "connect" to them. Unknown clusters fail like forbidden ones, after
+// every other check, so callers can't enumerate registrations.
+func AuthorizeCluster(ctx context.Context, id string) error {
+	host, known := clusterServer(id)
+	if !auth.AllowsCluster(ctx, id, host) {
+		return errClusterForbidden
+	}
+	if id != "" && id != LocalClusterID {
+		if !auth.ScopeFromContext(ctx).All {
+			return errClusterForbidden
+		}
+		caller := auth.IdentityFromContext(ctx)
+		decision := auth.Authorize(ctx, auth.PolicyRequest{
+			User:    caller.User,
+			Groups:  caller.Groups,
+			Verb:    "connect",
+			Kind:    "ClusterConnection",
+			Name:    id,
+			Cluster: id,
+		})
+		if !decision.Allowed {
+			return errors.New(decision.Denied())
+		}
+	}
+	if !known {
+		return errClusterForbidden
+	}
+	return nil
+}
@@ -0,0 +1,502 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/anakosmos/backend/src/activity"
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+)
+
+// ConfigRotation is the outcome of rotating a ConfigMap or Secret
+type ConfigRotation struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	OldName   string `json:"oldName"`
+	NewName   string `json:"newName"`
+	// Workloads whose pod template now references the new name
+	Updated []RotatedWorkload `json:"updated"`
+	// Pods and Jobs still using the old name; they can't be patched in place
+	Unmanaged []ImpactResource `json:"unmanaged"`
+	// DeleteOld is "pending" while waiting for rollouts before deleting the
+	// old object, "skipped" (with a reason) or "" when not requested
+	DeleteOld       string `json:"deleteOld,omitempty"`
+	DeleteOldReason string `json:"deleteOldReason,omitempty"`
+}
+
+// RotatedWorkload is a workload patched by a rotation
+type RotatedWorkload struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Error string `json:"error,omitempty"`
+}
+
+// rotationSuffix matches the default suffix so repeated rotations replace it
+// instead of stacking suffixes
+var rotationSuffix = regexp.MustCompile(`-\d{8}-\d{6}$`)
+
+// rotationTimeout bounds the wait for rollouts before deleting the old object
+const rotationTimeout = 10 * time.Minute
+
+// renameConfigRefs points every reference to a ConfigMap/Secret in the pod
+// spec, imagePullSecrets included, at a new name, reporting whether anything
+// changed
+func renameConfigRefs(spec *corev1.PodSpec, refType, from, to string) bool {
+	changed := false
+	rename := func(name *string) {
+		if *name == from {
+			*name = to
+			changed = true
+		}
+	}
+	for i := range spec.Volumes {
+		vol := &spec.Volumes[i]
+		if refType == "configMap" && vol.ConfigMap != nil {
+			rename(&vol.ConfigMap.Name)
+		}
+		if refType == "secret" && vol.Secret != nil {
+			rename(&vol.Secret.SecretName)
+		}
+		if vol.Projected != nil {
+			for j := range vol.Projected.Sources {
+				src := &vol.Projected.Sources[j]
+				if refType == "configMap" && src.ConfigMap != nil {
+					rename(&src.ConfigMap.Name)
+				}
+				if refType == "secret" && src.Secret != nil {
+					rename(&src.Secret.Name)
+				}
+			}
+		}
+	}
+	if refType == "secret" && renamePullSecrets(spec.ImagePullSecrets, from, to) {
+		changed = true
+	}
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			c := &containers[i]
+			for j := range c.EnvFrom {
+				if refType == "configMap" && c.EnvFrom[j].ConfigMapRef != nil {
+					rename(&c.EnvFrom[j].ConfigMapRef.Name)
+				}
+				if refType == "secret" && c.EnvFrom[j].SecretRef != nil {
+					rename(&c.EnvFrom[j].SecretRef.Name)
+				}
+			}
+			for j := range c.Env {
+				source := c.Env[j].ValueFrom
+				if source == nil {
+					continue
+				}
+				if refType == "configMap" && source.ConfigMapKeyRef != nil {
+					rename(&source.ConfigMapKeyRef.Name)
+				}
+				if refType == "secret" && source.SecretKeyRef != nil {
+					rename(&source.SecretKeyRef.Name)
+				}
+			}
+		}
+	}
+	return changed
+}
+
+// renamePullSecrets points imagePullSecrets references at a new Secret name,
+// reporting whether anything changed
+func renamePullSecrets(refs []corev1.LocalObjectReference, from, to string) bool {
+	changed := false
+	for i := range refs {
+		if refs[i].Name == from {
+			refs[i].Name = to
+			changed = true
+		}
+	}
+	return changed
+}
+
+// cloneConfig creates the renamed copy, marked immutable
+func cloneConfig(ctx context.Context, client *kubernetes.Clientset, kind, namespace, name, newName string) error {
+	immutable := true
+	copyMeta := func(src metav1.ObjectMeta) metav1.ObjectMeta {
+		annotations := make(map[string]string)
+		for k, v := range src.Annotations {
+			if k != "kubectl.kubernetes.io/last-applied-configuration" {
+				annotations[k] = v
+			}
+		}
+		return metav1.ObjectMeta{Name: newName, Namespace: namespace, Labels: src.Labels, Annotations: annotations}
+	}
+
+	if kind == "Secret" {
+		src, err := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		_, err = client.CoreV1().Secrets(namespace).Create(ctx, &corev1.Secret{
+			ObjectMeta: copyMeta(src.ObjectMeta),
+			Type:       src.Type,
+			Data:       src.Data,
+			Immutable:  &immutable,
+		}, metav1.CreateOptions{FieldManager: "anakosmos-ui"})
+		return err
+	}
+	src, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	_, err = client.CoreV1().ConfigMaps(namespace).Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: copyMeta(src.ObjectMeta),
+		Data:       src.Data,
+		BinaryData: src.BinaryData,
+		Immutable:  &immutable,
+	}, metav1.CreateOptions{FieldManager: "anakosmos-ui"})
+	return err
+}
+
+// retargetWorkloads rewrites the pod templates of every Deployment,
+// StatefulSet, DaemonSet and CronJob in the namespace that references the old
+// name, and the imagePullSecrets of ServiceAccounts for a Secret. Templates are
+// scanned directly so workloads scaled to zero are included.
+// Workloads for which authorize returns an error are left untouched.
+func retargetWorkloads(ctx context.Context, client *kubernetes.Clientset, namespace, refType, from, to string, authorize func(kind, name string) error) ([]RotatedWorkload, error) {
+	var result []RotatedWorkload
+	update := func(kind, name string, fn func() error) {
+		w := RotatedWorkload{Kind: kind, Name: name}
+		if err := authorize(kind, name); err != nil {
+			w.Error = err.Error()
+		} else if err := retry.RetryOnConflict(retry.DefaultRetry, fn); err != nil {
+			w.Error = err.Error()
+		}
+		result = append(result, w)
+	}
+	opts := metav1.UpdateOptions{FieldManager: "anakosmos-ui"}
+	apps := client.AppsV1()
+
+	deployments, err := apps.Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deployments.Items {
+		if !renameConfigRefs(&d.Spec.Template.Spec, refType, from, to) {
+			continue
+		}
+		update("Deployment", d.Name, func() error {
+			current, err := apps.Deployments(namespace).Get(ctx, d.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			renameConfigRefs(&current.Spec.Template.Spec, refType, from, to)
+			_, err = apps.Deployments(namespace).Update(ctx, current, opts)
+			return err
+		})
+	}
+
+	statefulSets, err := apps.StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, ss := range statefulSets.Items {
+		if !renameConfigRefs(&ss.Spec.Template.Spec, refType, from, to) {
+			continue
+		}
+		update("StatefulSet", ss.Name, func() error {
+			current, err := apps.StatefulSets(namespace).Get(ctx, ss.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			renameConfigRefs(&current.Spec.Template.Spec, refType, from, to)
+			_, err = apps.StatefulSets(namespace).Update(ctx, current, opts)
+			return err
+		})
+	}
+
+	daemonSets, err := apps.DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, ds := range daemonSets.Items {
+		if !renameConfigRefs(&ds.Spec.Template.Spec, refType, from, to) {
+			continue
+		}
+		update("DaemonSet", ds.Name, func() error {
+			current, err := apps.DaemonSets(namespace).Get(ctx, ds.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			renameConfigRefs(&current.Spec.Template.Spec, refType, from, to)
+			_, err = apps.DaemonSets(namespace).Update(ctx, current, opts)
+			return err
+		})
+	}
+
+	cronJobs, err := client.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, cj := range cronJobs.Items {
+		if !renameConfigRefs(&cj.Spec.JobTemplate.Spec.Template.Spec, refType, from, to) {
+			continue
+		}
+		update("CronJob", cj.Name, func() error {
+			current, err := client.BatchV1().CronJobs(namespace).Get(ctx, cj.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			renameConfigRefs(&current.Spec.JobTemplate.Spec.Template.Spec, refType, from, to)
+			_, err = client.BatchV1().CronJobs(namespace).Update(ctx, current, opts)
+			return err
+		})
+	}
+
+	if refType != "secret" {
+		return result, nil
+	}
+	serviceAccounts, err := client.CoreV1().ServiceAccounts(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, sa := range serviceAccounts.Items {
+		if !renamePullSecrets(sa.ImagePullSecrets, from, to) {
+			continue
+		}
+		update("ServiceAccount", sa.Name, func() error {
+			current, err := client.CoreV1().ServiceAccounts(namespace).Get(ctx, sa.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			renamePullSecrets(current.ImagePullSecrets, from, to)
+			_, err = client.CoreV1().ServiceAccounts(namespace).Update(ctx, current, opts)
+			return err
+		})
+	}
+	return result, nil
+}
+
+// rolloutPhase reads the current rollout phase of a patched workload.
+// CronJobs have nothing to roll out: their next Job uses the new template.
+func rolloutPhase(ctx context.Context, client *kubernetes.Clientset, kind, namespace, name string) (string, error) {
+	switch kind {
+	case "Deployment":
+		d, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		return deploymentRollout(d).Phase, nil
+	case "StatefulSet":
+		ss, err := client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		return statefulSetRollout(ss).Phase, nil
+	case "DaemonSet":
+		ds, err := client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		return daemonSetRollout(ds).Phase, nil
+	}
+	return "complete", nil
+}
+
+// deleteAfterRollout waits for the patched workloads to finish rolling out and
+// then deletes the old ConfigMap/Secret. Any failed or stuck rollout keeps it.
+func deleteAfterRollout(client *kubernetes.Clientset, rotation ConfigRotation, done activity.Item) {
+	ctx, cancel := context.WithTimeout(context.Background(), rotationTimeout)
+	defer cancel()
+	what := fmt.Sprintf("%s %s/%s", rotation.Kind, rotation.Namespace, rotation.OldName)
+	report := func(severity, message string) {
+		done.Severity, done.Message = severity, message
+		activity.Publish(done)
+	}
+
+	pending := rotation.Updated
+	for len(pending) > 0 {
+		var still []RotatedWorkload
+		for _, w := range pending {
+			phase, err := rolloutPhase(ctx, client, w.Kind, rotation.Namespace, w.Name)
+			if err != nil || phase == "failed" {
+				log.Printf("Rotation: keeping %s, rollout of %s %s did not succeed: %v\n", what, w.Kind, w.Name, err)
+				report("warning", fmt.Sprintf("kept %s: rollout of %s %s did not succeed", what, w.Kind, w.Name))
+				return
+			}
+			if phase != "complete" {
+				still = append(still, w)
+			}
+		}
+		pending = still
+		if len(pending) == 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			log.Printf("Rotation: keeping %s, rollouts did not complete within %s\n", what, rotationTimeout)
+			report("warning", fmt.Sprintf("kept %s: rollouts did not complete within %s", what, rotationTimeout))
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+
+	var err error
+	if rotation.Kind == "Secret" {
+		err = client.CoreV1().Secrets(rotation.Namespace).Delete(ctx, rotation.OldName, metav1.DeleteOptions{})
+	} else {
+		err = client.CoreV1().ConfigMaps(rotation.Namespace).Delete(ctx, rotation.OldName, metav1.DeleteOptions{})
+	}
+	if err != nil {
+		log.Printf("Rotation: failed to delete %s: %v\n", what, err)
+		report("error", fmt.Sprintf("failed to delete %s: %v", what, err))
+		return
+	}
+	log.Printf("Rotation: deleted %s after rollout to %s\n", what, rotation.NewName)
+	report("success", fmt.Sprintf("deleted %s after rollout to %s", what, rotation.NewName))
+}
+
+// HandleConfigRotate serves POST /api/config/rotate?uid=&suffix=&deleteOld=.
+// It clones the ConfigMap/Secret to an immutable copy named <name>-<suffix>
+// (default: a timestamp), points every referencing workload at the copy, and
+// with deleteOld=true removes the original once all rollouts succeed.
+func HandleConfigRotate(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+	q := r.URL.Query()
+	uid := q.Get("uid")
+	if uid == "" {
+		i18n.Error(w, r, http.StatusBadRequest, "error.required", "uid")
+		return
+	}
+
+	graph, _, err := cachedGraph(config)
+	if graph == nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var target *LightResource
+	for i := range graph.Resources {
+		if graph.Resources[i].ID == uid {
+			target = &graph.Resources[i]
+			break
+		}
+	}
+	if target == nil || (target.Kind != "ConfigMap" && target.Kind != "Secret") {
+		i18n.Error(w, r, http.StatusNotFound, "error.configNotFound")
+		return
+	}
+	if !auth.RequireNamespace(w, r, target.Namespace) {
+		return
+	}
+
+	suffix := q.Get("suffix")
+	if suffix == "" {
+		suffix = time.Now().UTC().Format("20060102-150405")
+	}
+	newName := rotationSuffix.ReplaceAllString(target.Name, "") + "-" + suffix
+	if errs := validation.IsDNS1123Subdomain(newName); len(errs) > 0 {
+		http.Error(w, fmt.Sprintf("invalid name %q: %s", newName, strings.Join(errs, "; ")), http.StatusBadRequest)
+		return
+	}
+	refType := "configMap"
+	if target.Kind == "Secret" {
+		refType = "secret"
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		i18n.Error(w, r, http.StatusInternalServerError, "error.clientFailed")
+		return
+	}
+	ctx := r.Context()
+
+	if !auth.RequireAllowed(w, r, "create", target.Kind, target.Namespace, newName) {
+		return
+	}
+	authorize := func(kind, name string) error {
+		if decision := auth.Authorize(ctx, auth.NewPolicyRequest(r, "update", kind, target.Namespace, name)); !decision.Allowed {
+			return errors.New(decision.Denied())
+		}
+		return nil
+	}
+
+	if err := cloneConfig(ctx, clientset, target.Kind, target.Namespace, target.Name, newName); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	updated, err := retargetWorkloads(ctx, clientset, target.Namespace, refType, target.Name, newName, authorize)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	rotation := ConfigRotation{
+		Kind:      target.Kind,
+		Namespace: target.Namespace,
+		OldName:   target.Name,
+		NewName:   newName,
+		Updated:   updated,
+		Unmanaged: []ImpactResource{},
+	}
+	// Consumers from the graph that no template rewrite covers. Pods can also
+	// carry imagePullSecrets their ServiceAccount injected at admission, which
+	// no template holds; those pods keep the old name until they're replaced.
+	patched := make(map[string]bool, len(updated))
+	for _, u := range updated {
+		patched[u.Kind+"/"+u.Name] = true
+	}
+	pullSecretRefs := 0
+	for _, c := range configConsumers(graph, target) {
+		switch {
+		case c.Workload.Kind == "ServiceAccount":
+		case !restartableKinds[c.Workload.Kind] && c.Workload.Kind != "CronJob":
+			rotation.Unmanaged = append(rotation.Unmanaged, c.Workload)
+		case containsString(c.Via, "imagePullSecret") && !patched[c.Workload.Kind+"/"+c.Workload.Name]:
+			pullSecretRefs++
+		}
+	}
+	if rotation.Updated == nil {
+		rotation.Updated = []RotatedWorkload{}
+	}
+
+	if q.Get("deleteOld") == "true" {
+		failed := false
+		for _, u := range rotation.Updated {
+			if u.Error != "" {
+				failed = true
+			}
+		}
+		deleteDecision := auth.Authorize(ctx, auth.NewPolicyRequest(r, "delete", target.Kind, target.Namespace, target.Name))
+		switch {
+		case !deleteDecision.Allowed:
+			rotation.DeleteOld, rotation.DeleteOldReason = "skipped", deleteDecision.Denied()
+		case failed:
+			rotation.DeleteOld, rotation.DeleteOldReason = "skipped", "some workloads could not be updated"
+		case len(rotation.Unmanaged) > 0:
+			rotation.DeleteOld, rotation.DeleteOldReason = "skipped", "pods outside a workload still use the old name"
+		case pullSecretRefs > 0:
+			rotation.DeleteOld, rotation.DeleteOldReason = "skipped", fmt.Sprintf("%d workloads still pull images with the old name", pullSecretRefs)
+		default:
+			rotation.DeleteOld = "pending"
+			go deleteAfterRollout(clientset, rotation, activity.Operation(r, "delete", target.Kind, target.Namespace, target.Name, nil))
+		}
+	}
+
+	item := activity.Operation(r, "rotate", target.Kind, target.Namespace, target.Name, nil)
+	item.Message = fmt.Sprintf("rotated %s %s/%s to %s, %d workloads updated", target.Kind, target.Namespace, target.Name, newName, len(rotation.Updated))
+	activity.Publish(item)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rotation)
+}
@@ -0,0 +1,259 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anakosmos/backend/src/activity"
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// ConfigConsumer is a workload (or standalone pod) using a ConfigMap or Secret
+type ConfigConsumer struct {
+	Workload ImpactResource `json:"workload"`
+	Pods     []string       `json:"pods"`
+	Via      []string       `json:"via"` // volume, env, imagePullSecret
+	// Refresh tells how changes reach the consumer:
+	//   auto-reload: a reloader annotation rolls the workload on change
+	//   files-update: mounted files are refreshed in place by the kubelet
+	//   restart-required: env values are only read at container start
+	//   pull-time: image pull secrets are only read when an image is pulled
+	Refresh     string `json:"refresh"`
+	Reason      string `json:"reason"`
+	Restartable bool   `json:"restartable"`
+	// Set by the restart action
+	Restarted bool   `json:"restarted,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ConfigUsageResponse is served by /api/config/usage
+type ConfigUsageResponse struct {
+	Config    ImpactResource   `json:"config"`
+	Consumers []ConfigConsumer `json:"consumers"`
+}
+
+// restartableKinds are the controllers that support a rollout restart
+var restartableKinds = map[string]bool{"Deployment": true, "StatefulSet": true, "DaemonSet": true}
+
+// topController follows controller owner references up from a pod
+func topController(res *LightResource, byID map[string]*LightResource) *LightResource {
+	for len(res.OwnerRefs) > 0 {
+		owner, ok := byID[res.OwnerRefs[0]]
+		if !ok {
+			break
+		}
+		res = owner
+	}
+	return res
+}
+
+// reloaderWatches reports whether a Stakater Reloader style annotation on the
+// workload rolls it when the named ConfigMap/Secret changes
+func reloaderWatches(annotations map[string]string, refType, name string) bool {
+	if annotations["reloader.stakater.com/auto"] == "true" || annotations[refType+".reloader.stakater.com/auto"] == "true" {
+		return true
+	}
+	for _, n := range strings.Split(annotations[refType+".reloader.stakater.com/reload"], ",") {
+		if strings.TrimSpace(n) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// configConsumers lists the workloads whose pods reference the ConfigMap or
+// Secret, using the references extracted into the graph. For Secrets, pods
+// pulling images with it and ServiceAccounts listing it in imagePullSecrets
+// count too.
+func configConsumers(graph *InitResponse, config *LightResource) []ConfigConsumer {
+	refType := "configMap"
+	if config.Kind == "Secret" {
+		refType = "secret"
+	}
+	byID := make(map[string]*LightResource, len(graph.Resources))
+	for i := range graph.Resources {
+		byID[graph.Resources[i].ID] = &graph.Resources[i]
+	}
+
+	consumers := make(map[string]*ConfigConsumer)
+	var order []string
+	for i := range graph.Resources {
+		pod := &graph.Resources[i]
+		if pod.Namespace != config.Namespace {
+			continue
+		}
+		// ServiceAccounts hand their imagePullSecrets to every new pod
+		if pod.Kind == "ServiceAccount" && refType == "secret" && containsString(pod.ImagePullSecrets, config.Name) {
+			consumers[pod.ID] = &ConfigConsumer{
+				Workload: impactResource(pod),
+				Pods:     []string{},
+				Via:      []string{"imagePullSecret"},
+				Refresh:  "pull-time",
+				Reason:   "new pods of the ServiceAccount pull images with it",
+			}
+			order = append(order, pod.ID)
+			continue
+		}
+		if pod.Kind != "Pod" {
+			continue
+		}
+		var via []string
+		for _, v := range pod.Volumes {
+			if v.Type == refType && v.Name == config.Name {
+				via = append(via, "volume")
+				break
+			}
+		}
+		for _, e := range pod.EnvRefs {
+			if e.Type == refType && e.Name == config.Name {
+				via = append(via, "env")
+				break
+			}
+		}
+		if refType == "secret" && containsString(pod.ImagePullSecrets, config.Name) {
+			via = append(via, "imagePullSecret")
+		}
+		if len(via) == 0 {
+			continue
+		}
+
+		workload := topController(pod, byID)
+		c, ok := consumers[workload.ID]
+		if !ok {
+			c = &ConfigConsumer{
+				Workload:    impactResource(workload),
+				Pods:        []string{},
+				Restartable: restartableKinds[workload.Kind],
+			}
+			consumers[workload.ID] = c
+			order = append(order, workload.ID)
+		}
+		c.Pods = append(c.Pods, pod.Name)
+		for _, v := range via {
+			if !containsString(c.Via, v) {
+				c.Via = append(c.Via, v)
+			}
+		}
+
+		switch {
+		case reloaderWatches(workload.Annotations, refType, config.Name):
+			c.Refresh, c.Reason = "auto-reload", "a reloader annotation rolls the workload on change"
+		case containsString(c.Via, "env"):
+			c.Refresh, c.Reason = "restart-required", "environment variables are read at container start"
+		case !containsString(c.Via, "volume"):
+			c.Refresh, c.Reason = "pull-time", "image pull secrets are read when a node pulls the image"
+		default:
+			c.Refresh, c.Reason = "files-update", "mounted files refresh within the kubelet sync period (not subPath mounts); the application must re-read them"
+		}
+	}
+
+	result := make([]ConfigConsumer, 0, len(order))
+	for _, id := range order {
+		c := consumers[id]
+		sort.Strings(c.Pods)
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i].Workload, result[j].Workload
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return result
+}
+
+// rolloutRestart triggers a rolling restart like kubectl rollout restart
+func rolloutRestart(ctx context.Context, client *kubernetes.Clientset, kind, namespace, name string) error {
+	patch := []byte(fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{"kubectl.kubernetes.io/restartedAt":%q}}}}}`, time.Now().Format(time.RFC3339)))
+	opts := metav1.PatchOptions{FieldManager: "anakosmos-ui"}
+	var err error
+	switch kind {
+	case "Deployment":
+		_, err = client.AppsV1().Deployments(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, opts)
+	case "StatefulSet":
+		_, err = client.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, opts)
+	case "DaemonSet":
+		_, err = client.AppsV1().DaemonSets(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, opts)
+	default:
+		err = fmt.Errorf("%s does not support rollout restart", kind)
+	}
+	return err
+}
+
+// HandleConfigUsage serves /api/config/usage?uid= for a ConfigMap or Secret.
+// GET lists the consuming workloads, how they reference it and whether they
+// pick up changes by themselves. POST additionally rollout-restarts every
+// consumer that needs it (?all=true restarts the restartable ones regardless).
+func HandleConfigUsage(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+	uid := r.URL.Query().Get("uid")
+	if uid == "" {
+		i18n.Error(w, r, http.StatusBadRequest, "error.required", "uid")
+		return
+	}
+
+	graph, _, err := cachedGraph(config)
+	if graph == nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var target *LightResource
+	for i := range graph.Resources {
+		if graph.Resources[i].ID == uid {
+			target = &graph.Resources[i]
+			break
+		}
+	}
+	if target == nil || (target.Kind != "ConfigMap" && target.Kind != "Secret") {
+		i18n.Error(w, r, http.StatusNotFound, "error.configNotFound")
+		return
+	}
+	if !auth.RequireNamespace(w, r, target.Namespace) {
+		return
+	}
+
+	response := ConfigUsageResponse{Config: impactResource(target), Consumers: configConsumers(graph, target)}
+
+	if r.Method == http.MethodPost {
+		clientset, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			i18n.Error(w, r, http.StatusInternalServerError, "error.clientFailed")
+			return
+		}
+		all := r.URL.Query().Get("all") == "true"
+		for i := range response.Consumers {
+			c := &response.Consumers[i]
+			if !c.Restartable || (c.Refresh == "auto-reload" && !all) {
+				continue
+			}
+			if decision := auth.Authorize(r.Context(), auth.NewPolicyRequest(r, "patch", c.Workload.Kind, c.Workload.Namespace, c.Workload.Name)); !decision.Allowed {
+				c.Error = decision.Denied()
+				continue
+			}
+			err := rolloutRestart(r.Context(), clientset, c.Workload.Kind, c.Workload.Namespace, c.Workload.Name)
+			activity.Publish(activity.Operation(r, "restart", c.Workload.Kind, c.Workload.Namespace, c.Workload.Name, err))
+			if err != nil {
+				c.Error = err.Error()
+				continue
+			}
+			c.Restarted = true
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
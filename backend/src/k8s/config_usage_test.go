@@ -0,0 +1,76 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestConfigConsumers(t *testing.T) {
+	graph := &InitResponse{Resources: []LightResource{
+		{ID: "deploy", Kind: "Deployment", Namespace: "a", Name: "web"},
+		{ID: "rs", Kind: "ReplicaSet", Namespace: "a", Name: "web-1", OwnerRefs: []string{"deploy"}},
+		{ID: "pod-1", Kind: "Pod", Namespace: "a", Name: "web-1-x", OwnerRefs: []string{"rs"},
+			EnvRefs: []EnvRef{{Type: "secret", Name: "creds"}}},
+		{ID: "pod-2", Kind: "Pod", Namespace: "a", Name: "puller", ImagePullSecrets: []string{"registry"}},
+		{ID: "pod-3", Kind: "Pod", Namespace: "a", Name: "both", ImagePullSecrets: []string{"creds"}},
+		{ID: "pod-4", Kind: "Pod", Namespace: "b", Name: "other", ImagePullSecrets: []string{"registry"}},
+		{ID: "sa", Kind: "ServiceAccount", Namespace: "a", Name: "builder", ImagePullSecrets: []string{"registry"}},
+	}}
+	tests := []struct {
+		name   string
+		config LightResource
+		want   map[string][]string // consumer name -> via
+	}{
+		{"env", LightResource{Kind: "Secret", Namespace: "a", Name: "creds"},
+			map[string][]string{"web": {"env"}, "both": {"imagePullSecret"}}},
+		{"pull secret", LightResource{Kind: "Secret", Namespace: "a", Name: "registry"},
+			map[string][]string{"puller": {"imagePullSecret"}, "builder": {"imagePullSecret"}}},
+		{"configmap of same name", LightResource{Kind: "ConfigMap", Namespace: "a", Name: "registry"},
+			map[string][]string{}},
+		{"unused", LightResource{Kind: "Secret", Namespace: "a", Name: "unused"},
+			map[string][]string{}},
+	}
+	for _, tt := range tests {
+		got := make(map[string][]string)
+		for _, c := range configConsumers(graph, &tt.config) {
+			got[c.Workload.Name] = c.Via
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: consumers = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRenameConfigRefs(t *testing.T) {
+	spec := func() *corev1.PodSpec {
+		return &corev1.PodSpec{
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}, {Name: "other"}},
+			Containers: []corev1.Container{{
+				EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "creds"}}}},
+			}},
+		}
+	}
+	tests := []struct {
+		refType, from string
+		changed       bool
+	}{
+		{"secret", "registry", true},
+		{"secret", "creds", true},
+		{"configMap", "registry", false},
+		{"secret", "missing", false},
+	}
+	for _, tt := range tests {
+		s := spec()
+		if got := renameConfigRefs(s, tt.refType, tt.from, "renamed"); got != tt.changed {
+			t.Errorf("rename %s %s: changed = %t, want %t", tt.refType, tt.from, got, tt.changed)
+		}
+		if tt.changed && tt.from == "registry" && s.ImagePullSecrets[0].Name != "renamed" {
+			t.Errorf("imagePullSecrets = %v, want registry renamed", s.ImagePullSecrets)
+		}
+		if s.ImagePullSecrets[1].Name != "other" {
+			t.Errorf("rename %s: unrelated pull secret renamed to %s", tt.from, s.ImagePullSecrets[1].Name)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package k8s
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ContainerSummary sums up the containers of a Pod, enough for "2/3 Running,
+// 14 restarts, CrashLoopBackOff" without fetching the Pod
+type ContainerSummary struct {
+	Count int `json:"count"`
+	Ready int `json:"ready"`
+	// WaitingReason is the reason most waiting containers give, e.g.
+	// CrashLoopBackOff, or Init:<reason> while init containers are stuck
+	WaitingReason string          `json:"waitingReason,omitempty"`
+	Containers    []ContainerInfo `json:"containers"`
+}
+
+// ContainerInfo is the state of one regular container of a Pod
+type ContainerInfo struct {
+	Name     string `json:"name"`
+	Image    string `json:"image"`
+	Ready    bool   `json:"ready"`
+	Restarts int32  `json:"restarts"`
+	// State is running, waiting or terminated; Reason explains the last two
+	State  string `json:"state,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func podContainerSummary(p *corev1.Pod) *ContainerSummary {
+	statuses := make(map[string]*corev1.ContainerStatus, len(p.Status.ContainerStatuses))
+	for i := range p.Status.ContainerStatuses {
+		statuses[p.Status.ContainerStatuses[i].Name] = &p.Status.ContainerStatuses[i]
+	}
+
+	summary := &ContainerSummary{Count: len(p.Spec.Containers)}
+	var waiting []string
+	for _, c := range p.Spec.Containers {
+		info := ContainerInfo{Name: c.Name, Image: c.Image}
+		if cs := statuses[c.Name]; cs != nil {
+			info.Ready, info.Restarts = cs.Ready, cs.RestartCount
+			switch {
+			case cs.State.Running != nil:
+				info.State = "running"
+			case cs.State.Waiting != nil:
+				info.State, info.Reason = "waiting", cs.State.Waiting.Reason
+				if info.Reason != "" {
+					waiting = append(waiting, info.Reason)
+				}
+			case cs.State.Terminated != nil:
+				info.State, info.Reason = "terminated", cs.State.Terminated.Reason
+			}
+		}
+		if info.Ready {
+			summary.Ready++
+		}
+		summary.Containers = append(summary.Containers, info)
+	}
+	if len(waiting) == 0 {
+		for _, cs := range p.Status.InitContainerStatuses {
+			if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" && cs.State.Waiting.Reason != "PodInitializing" {
+				waiting = append(waiting, "Init:"+cs.State.Waiting.Reason)
+			}
+		}
+	}
+	summary.WaitingReason = dominantReason(waiting)
+	return summary
+}
+
+// dominantReason returns the most frequent reason; on ties, the one that
+// got there first
+func dominantReason(reasons []string) string {
+	counts := make(map[string]int, len(reasons))
+	dominant := ""
+	for _, reason := range reasons {
+		counts[reason]++
+		if counts[reason] > counts[dominant] {
+			dominant = reason
+		}
+	}
+	return dominant
+}
@@ -0,0 +1,768 @@
+package k8s
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// toLightResource converts a typed Kubernetes object into the LightResource
+// format. It is the single converter shared by /api/cluster/init and the watch
+// stream so both emit exactly the same schema. Returns nil for unsupported types.
+func toLightResource(obj interface{}) *LightResource {
+	var res LightResource
+	switch o := obj.(type) {
+	case *corev1.Namespace:
+		res = lightNamespace(o)
+	case *corev1.Node:
+		res = lightNode(o)
+	case *corev1.Pod:
+		res = lightPod(o)
+	case *corev1.Service:
+		res = lightService(o)
+	case *appsv1.Deployment:
+		res = lightDeployment(o)
+	case *appsv1.StatefulSet:
+		res = lightStatefulSet(o)
+	case *appsv1.DaemonSet:
+		res = lightDaemonSet(o)
+	case *appsv1.ReplicaSet:
+		res = lightReplicaSet(o)
+	case *networkingv1.Ingress:
+		res = lightIngress(o)
+	case *corev1.PersistentVolumeClaim:
+		res = lightPVC(o)
+	case *corev1.ConfigMap:
+		res = lightConfigMap(o)
+	case *corev1.Secret:
+		res = lightSecret(o)
+	case *storagev1.StorageClass:
+		res = lightStorageClass(o)
+	case *storagev1.CSIDriver:
+		res = lightCSIDriver(o)
+	case *storagev1.VolumeAttachment:
+		res = lightVolumeAttachment(o)
+	case *batchv1.Job:
+		res = lightJob(o)
+	case *batchv1.CronJob:
+		res = lightCronJob(o)
+	case *autoscalingv2.HorizontalPodAutoscaler:
+		res = lightHPA(o)
+	case *corev1.ResourceQuota:
+		res = lightResourceQuota(o)
+	case *corev1.LimitRange:
+		res = lightLimitRange(o)
+	case *policyv1.PodDisruptionBudget:
+		res = lightPDB(o)
+	default:
+		return nil
+	}
+	applyHealthRule(&res)
+	applyTerminating(&res)
+	return &res
+}
+
+// baseLightResource fills the fields common to every kind from object metadata,
+// plus the display fields and health rule configured for the kind
+func baseLightResource(meta metav1.Object, kind string) LightResource {
+	return LightResource{
+		ID:                string(meta.GetUID()),
+		Name:              meta.GetName(),
+		Namespace:         meta.GetNamespace(),
+		Kind:              kind,
+		Labels:            meta.GetLabels(),
+		OwnerRefs:         extractOwnerRefs(meta.GetOwnerReferences()),
+		ownerKinds:        ownerKinds(meta.GetOwnerReferences()),
+		CreationTimestamp: formatTimestamp(meta.GetCreationTimestamp()),
+		DeletionTimestamp: deletionTimestamp(meta),
+		AgeSeconds:        ageSeconds(meta.GetCreationTimestamp()),
+		Annotations:       groupingAnnotations(meta.GetAnnotations()),
+		ExtraFields:       typedExtraFields(meta, kind),
+		ruleHealth:        typedRuleHealth(meta, kind),
+	}
+}
+
+// groupingAnnotations drops the bulky kubectl bookkeeping annotation, which is
+// never useful as a grouping key
+func groupingAnnotations(annotations map[string]string) map[string]string {
+	if _, ok := annotations[corev1.LastAppliedConfigAnnotation]; !ok {
+		return annotations
+	}
+	filtered := make(map[string]string, len(annotations)-1)
+	for k, v := range annotations {
+		if k != corev1.LastAppliedConfigAnnotation {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
+
+func formatTimestamp(t metav1.Time) string {
+	return t.Format("2006-01-02T15:04:05Z")
+}
+
+func deletionTimestamp(meta metav1.Object) string {
+	if t := meta.GetDeletionTimestamp(); t != nil {
+		return formatTimestamp(*t)
+	}
+	return ""
+}
+
+// applyTerminating reports resources being deleted as Terminating whatever
+// their kind says, as kubectl does: they stay unhealthy since the deletion
+// started, so ones held back by a finalizer show up as stuck
+func applyTerminating(res *LightResource) {
+	if res.DeletionTimestamp == "" {
+		return
+	}
+	res.Status = "Terminating"
+	if res.Health != "error" {
+		res.Health = "warning"
+	}
+	res.StaleSince = res.DeletionTimestamp
+}
+
+func ageSeconds(created metav1.Time) int64 {
+	if created.IsZero() {
+		return 0
+	}
+	return int64(time.Since(created.Time).Seconds())
+}
+
+func lightNode(n *corev1.Node) LightResource {
+	res := baseLightResource(n, "Node")
+	res.CPUAllocatable = n.Status.Allocatable.Cpu().MilliValue()
+	res.MemoryAllocatable = n.Status.Allocatable.Memory().Value()
+	res.Status = "NotReady"
+	res.Health = "warning"
+	for _, cond := range n.Status.Conditions {
+		res.LastTransitionTime = latestTransition(res.LastTransitionTime, cond.LastTransitionTime)
+		if cond.Type != corev1.NodeReady {
+			continue
+		}
+		if cond.Status == corev1.ConditionTrue {
+			res.Status = "Ready"
+			res.Health = "ok"
+		} else {
+			res.StaleSince = formatTimestamp(cond.LastTransitionTime)
+		}
+	}
+	return res
+}
+
+func lightPod(p *corev1.Pod) LightResource {
+	res := baseLightResource(p, "Pod")
+	res.CPURequests, res.CPULimits, res.MemoryRequests, res.MemoryLimits = podReservations(&p.Spec)
+	res.Status = string(p.Status.Phase)
+	res.Health = "ok"
+
+	if p.Status.Phase == corev1.PodFailed {
+		res.Health = "error"
+	} else if p.Status.Phase == corev1.PodPending {
+		res.Health = "warning"
+	} else if p.Status.Phase == corev1.PodRunning {
+		isReady := false
+		for _, c := range p.Status.Conditions {
+			if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
+				isReady = true
+				break
+			}
+		}
+		if !isReady {
+			res.Health = "warning"
+		}
+		for _, cs := range p.Status.ContainerStatuses {
+			// e.g. ImagePullBackOff, CrashLoopBackOff, ImageInspectError
+			if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+				res.Health = "error"
+			}
+			if cs.State.Terminated != nil && cs.State.Terminated.ExitCode != 0 {
+				res.Health = "error"
+			}
+		}
+	}
+
+	for _, c := range p.Status.Conditions {
+		res.LastTransitionTime = latestTransition(res.LastTransitionTime, c.LastTransitionTime)
+		if c.Type == corev1.PodReady && c.Status != corev1.ConditionTrue && res.Health != "ok" && !c.LastTransitionTime.IsZero() {
+			res.StaleSince = formatTimestamp(c.LastTransitionTime)
+		}
+	}
+	for _, cs := range p.Status.ContainerStatuses {
+		res.Restarts += cs.RestartCount
+	}
+	res.Containers = podContainerSummary(p)
+	res.NodeName = p.Spec.NodeName
+	res.Volumes = extractVolumeRefs(p.Spec.Volumes)
+	res.EnvRefs = extractEnvRefs(p.Spec.Containers)
+	res.PodSecurity = workloadPodSecurity(&p.Spec)
+	applyServiceAccountInfo(&res, &p.Spec, true)
+	res.HelmRelease = extractHelmInfo(p.Labels, p.Annotations, p.Namespace)
+	return res
+}
+
+// extractVolumeRefs collects ConfigMap, Secret and PVC references from pod volumes
+func extractVolumeRefs(vols []corev1.Volume) []VolumeRef {
+	var volumes []VolumeRef
+	for _, vol := range vols {
+		if vol.ConfigMap != nil {
+			volumes = append(volumes, VolumeRef{Type: "configMap", Name: vol.ConfigMap.Name})
+		}
+		if vol.Secret != nil {
+			volumes = append(volumes, VolumeRef{Type: "secret", Name: vol.Secret.SecretName})
+		}
+		if vol.PersistentVolumeClaim != nil {
+			volumes = append(volumes, VolumeRef{Type: "pvc", Name: vol.PersistentVolumeClaim.ClaimName})
+		}
+		if vol.Projected != nil {
+			for _, src := range vol.Projected.Sources {
+				if src.ConfigMap != nil {
+					volumes = append(volumes, VolumeRef{Type: "configMap", Name: src.ConfigMap.Name})
+				}
+				if src.Secret != nil {
+					volumes = append(volumes, VolumeRef{Type: "secret", Name: src.Secret.Name})
+				}
+			}
+		}
+	}
+	return volumes
+}
+
+// extractEnvRefs collects deduplicated ConfigMap/Secret references from container env
+func extractEnvRefs(containers []corev1.Container) []EnvRef {
+	var envRefs []EnvRef
+	seenRefs := make(map[string]bool)
+	add := func(refType, name string) {
+		key := refType + ":" + name
+		if !seenRefs[key] {
+			envRefs = append(envRefs, EnvRef{Type: refType, Name: name})
+			seenRefs[key] = true
+		}
+	}
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				add("configMap", envFrom.ConfigMapRef.Name)
+			}
+			if envFrom.SecretRef != nil {
+				add("secret", envFrom.SecretRef.Name)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom != nil {
+				if env.ValueFrom.ConfigMapKeyRef != nil {
+					add("configMap", env.ValueFrom.ConfigMapKeyRef.Name)
+				}
+				if env.ValueFrom.SecretKeyRef != nil {
+					add("secret", env.ValueFrom.SecretKeyRef.Name)
+				}
+			}
+		}
+	}
+	return envRefs
+}
+
+// applyServiceAccountInfo records the ServiceAccount, imagePullSecrets and
+// whether an API token is mounted. Running pods are checked for the injected
+// projected token volume; templates only know the pod-level automount setting.
+func applyServiceAccountInfo(res *LightResource, spec *corev1.PodSpec, running bool) {
+	res.ServiceAccount = spec.ServiceAccountName
+	if res.ServiceAccount == "" {
+		res.ServiceAccount = "default"
+	}
+	for _, ref := range spec.ImagePullSecrets {
+		if ref.Name != "" {
+			res.ImagePullSecrets = append(res.ImagePullSecrets, ref.Name)
+		}
+	}
+
+	if running {
+		for _, vol := range spec.Volumes {
+			if vol.Projected == nil {
+				continue
+			}
+			for _, src := range vol.Projected.Sources {
+				if src.ServiceAccountToken != nil {
+					res.ServiceAccountToken = true
+				}
+			}
+		}
+	} else {
+		res.ServiceAccountToken = spec.AutomountServiceAccountToken == nil || *spec.AutomountServiceAccountToken
+	}
+
+	if res.ServiceAccount == "default" && res.ServiceAccountToken {
+		res.SecurityFlags = append(res.SecurityFlags, "default-serviceaccount-token")
+	}
+}
+
+func lightService(s *corev1.Service) LightResource {
+	res := baseLightResource(s, "Service")
+	res.Status = "Active"
+	res.Health = "ok"
+	if len(s.Spec.Selector) > 0 {
+		res.Selector = s.Spec.Selector
+	}
+	if s.Spec.Type == corev1.ServiceTypeExternalName && s.Spec.ExternalName != "" {
+		res.Status = "ExternalName"
+		res.ExternalHosts = []string{strings.TrimSuffix(s.Spec.ExternalName, ".")}
+	}
+	res.Service = serviceInfo(s)
+	applyLoadBalancerPending(s, &res)
+	res.HelmRelease = extractHelmInfo(s.Labels, s.Annotations, s.Namespace)
+	return res
+}
+
+func lightDeployment(d *appsv1.Deployment) LightResource {
+	res := baseLightResource(d, "Deployment")
+	res.Status = "Progressing"
+	res.Health = "warning"
+	if d.Spec.Replicas != nil && *d.Spec.Replicas == 0 {
+		res.Status = "ScaledDown"
+		res.Health = "ok"
+	} else if d.Status.AvailableReplicas == d.Status.Replicas && d.Status.Replicas > 0 {
+		res.Status = "Available"
+		res.Health = "ok"
+	}
+	for _, c := range d.Status.Conditions {
+		res.LastTransitionTime = latestTransition(res.LastTransitionTime, c.LastTransitionTime)
+		if c.Type == appsv1.DeploymentAvailable && c.Status != corev1.ConditionTrue && res.Health != "ok" {
+			res.StaleSince = formatTimestamp(c.LastTransitionTime)
+		}
+	}
+	res.PodSecurity = workloadPodSecurity(&d.Spec.Template.Spec)
+	applyServiceAccountInfo(&res, &d.Spec.Template.Spec, false)
+	res.ResourceDefaults = templateResourceDefaults(&d.Spec.Template.Spec)
+	res.Images = templateImages(&d.Spec.Template.Spec)
+	res.Deployment = deploymentInfo(d)
+	res.HelmRelease = extractHelmInfo(d.Labels, d.Annotations, d.Namespace)
+	return res
+}
+
+func lightStatefulSet(s *appsv1.StatefulSet) LightResource {
+	res := baseLightResource(s, "StatefulSet")
+	res.Status = "Progressing"
+	res.Health = "warning"
+	if s.Status.ReadyReplicas == s.Status.Replicas && s.Status.Replicas > 0 {
+		res.Status = "Ready"
+		res.Health = "ok"
+	}
+	if s.Spec.Selector != nil && s.Spec.Selector.MatchLabels != nil {
+		res.Selector = s.Spec.Selector.MatchLabels
+	}
+	res.SelectorExpression = selectorExpression(s.Spec.Selector)
+	res.PodSecurity = workloadPodSecurity(&s.Spec.Template.Spec)
+	applyServiceAccountInfo(&res, &s.Spec.Template.Spec, false)
+	res.ResourceDefaults = templateResourceDefaults(&s.Spec.Template.Spec)
+	res.Images = templateImages(&s.Spec.Template.Spec)
+	res.HelmRelease = extractHelmInfo(s.Labels, s.Annotations, s.Namespace)
+	return res
+}
+
+func lightDaemonSet(d *appsv1.DaemonSet) LightResource {
+	res := baseLightResource(d, "DaemonSet")
+	res.Status = "Progressing"
+	res.Health = "warning"
+	if d.Status.NumberReady == d.Status.DesiredNumberScheduled && d.Status.DesiredNumberScheduled > 0 {
+		res.Status = "Ready"
+		res.Health = "ok"
+	}
+	if d.Spec.Selector != nil && d.Spec.Selector.MatchLabels != nil {
+		res.Selector = d.Spec.Selector.MatchLabels
+	}
+	res.SelectorExpression = selectorExpression(d.Spec.Selector)
+	res.PodSecurity = workloadPodSecurity(&d.Spec.Template.Spec)
+	applyServiceAccountInfo(&res, &d.Spec.Template.Spec, false)
+	res.ResourceDefaults = templateResourceDefaults(&d.Spec.Template.Spec)
+	res.Images = templateImages(&d.Spec.Template.Spec)
+	res.HelmRelease = extractHelmInfo(d.Labels, d.Annotations, d.Namespace)
+	return res
+}
+
+// templateImages lists the images of a pod template, init containers first
+func templateImages(spec *corev1.PodSpec) []string {
+	var images []string
+	for _, c := range append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...) {
+		if !containsString(images, c.Image) {
+			images = append(images, c.Image)
+		}
+	}
+	return images
+}
+
+func lightReplicaSet(r *appsv1.ReplicaSet) LightResource {
+	res := baseLightResource(r, "ReplicaSet")
+	res.Status = "Active"
+	res.Health = "ok"
+	res.scaledDown = r.Spec.Replicas != nil && *r.Spec.Replicas == 0 && r.Status.Replicas == 0
+	res.PodSecurity = workloadPodSecurity(&r.Spec.Template.Spec)
+	applyServiceAccountInfo(&res, &r.Spec.Template.Spec, false)
+	res.HelmRelease = extractHelmInfo(r.Labels, r.Annotations, r.Namespace)
+	return res
+}
+
+func lightIngress(i *networkingv1.Ingress) LightResource {
+	res := baseLightResource(i, "Ingress")
+	res.Status = "Active"
+	res.Health = "ok"
+	for _, rule := range i.Spec.Rules {
+		res.ExternalHosts = appendHost(res.ExternalHosts, rule.Host)
+		if rule.HTTP != nil {
+			for _, path := range rule.HTTP.Paths {
+				if path.Backend.Service != nil && path.Backend.Service.Name != "" {
+					res.IngressBackends = append(res.IngressBackends, IngressBackend{ServiceName: path.Backend.Service.Name})
+				}
+			}
+		}
+	}
+	for _, tls := range i.Spec.TLS {
+		for _, host := range tls.Hosts {
+			res.ExternalHosts = appendHost(res.ExternalHosts, host)
+		}
+	}
+	res.Ingress = ingressInfo(i)
+	res.HelmRelease = extractHelmInfo(i.Labels, i.Annotations, i.Namespace)
+	return res
+}
+
+func lightPVC(pvc *corev1.PersistentVolumeClaim) LightResource {
+	res := baseLightResource(pvc, "PersistentVolumeClaim")
+	res.Status = string(pvc.Status.Phase)
+	res.Health = "ok"
+	if res.Status == "Lost" {
+		res.Health = "error"
+	} else if res.Status == "Pending" {
+		res.Health = "warning"
+	}
+	res.StorageClassName = getStorageClassName(pvc.Spec.StorageClassName)
+	res.HelmRelease = extractHelmInfo(pvc.Labels, pvc.Annotations, pvc.Namespace)
+	return res
+}
+
+// lightPersistentVolume records the claim a PV is bound to and, for local
+// volumes, the nodes its node affinity pins it to
+func lightPersistentVolume(pv *corev1.PersistentVolume) LightResource {
+	res := baseLightResource(pv, "PersistentVolume")
+	res.Status = string(pv.Status.Phase)
+	switch pv.Status.Phase {
+	case corev1.VolumeFailed:
+		res.Health = "error"
+	case corev1.VolumePending, corev1.VolumeReleased:
+		res.Health = "warning"
+	default:
+		res.Health = "ok"
+	}
+	res.StorageClassName = pv.Spec.StorageClassName
+	if ref := pv.Spec.ClaimRef; ref != nil {
+		res.ClaimRef = ref.Namespace + "/" + ref.Name
+	}
+	if pv.Spec.NodeAffinity != nil && pv.Spec.NodeAffinity.Required != nil {
+		for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+			for _, expr := range term.MatchExpressions {
+				if expr.Key == corev1.LabelHostname && expr.Operator == corev1.NodeSelectorOpIn {
+					for _, node := range expr.Values {
+						if !containsString(res.VolumeNodes, node) {
+							res.VolumeNodes = append(res.VolumeNodes, node)
+						}
+					}
+				}
+			}
+		}
+	}
+	return res
+}
+
+func lightConfigMap(cm *corev1.ConfigMap) LightResource {
+	res := baseLightResource(cm, "ConfigMap")
+	res.Status = "Active"
+	res.Health = "ok"
+	res.HelmRelease = extractHelmInfo(cm.Labels, cm.Annotations, cm.Namespace)
+	return res
+}
+
+func lightSecret(sec *corev1.Secret) LightResource {
+	res := baseLightResource(sec, "Secret")
+	if res.Labels == nil {
+		res.Labels = make(map[string]string)
+	}
+	res.Status = "Active"
+	res.Health = "ok"
+	res.HelmRelease = extractHelmInfo(sec.Labels, sec.Annotations, sec.Namespace)
+	return res
+}
+
+func lightStorageClass(sc *storagev1.StorageClass) LightResource {
+	res := baseLightResource(sc, "StorageClass")
+	res.Status = "Active"
+	res.Health = "ok"
+	return res
+}
+
+func lightJob(j *batchv1.Job) LightResource {
+	res := baseLightResource(j, "Job")
+	res.Status = "Pending"
+	res.Health = "warning"
+
+	completeCond := false
+	failedCond := false
+	for _, c := range j.Status.Conditions {
+		res.LastTransitionTime = latestTransition(res.LastTransitionTime, c.LastTransitionTime)
+		if c.Type == batchv1.JobComplete && c.Status == corev1.ConditionTrue {
+			completeCond = true
+		}
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			failedCond = true
+			res.StaleSince = formatTimestamp(c.LastTransitionTime)
+		}
+	}
+
+	if completeCond {
+		res.Status = "Complete"
+		res.Health = "ok"
+	} else if failedCond {
+		res.Status = "Failed"
+		res.Health = "error"
+	} else if j.Status.Active > 0 {
+		res.Status = "Running"
+		res.Health = "ok"
+	} else if j.Status.Succeeded > 0 {
+		res.Status = "Complete"
+		res.Health = "ok"
+	}
+	res.Job = jobProgress(j, !completeCond && !failedCond)
+	if res.Job.NearBackoffLimit && res.Health == "ok" {
+		res.Health = "warning"
+	}
+	res.PodSecurity = workloadPodSecurity(&j.Spec.Template.Spec)
+	applyServiceAccountInfo(&res, &j.Spec.Template.Spec, false)
+	res.ResourceDefaults = templateResourceDefaults(&j.Spec.Template.Spec)
+	res.HelmRelease = extractHelmInfo(j.Labels, j.Annotations, j.Namespace)
+	return res
+}
+
+// JobProgress carries the counters of a Job so progress and backoff can be
+// shown without opening it
+type JobProgress struct {
+	// Completions is nil when any single successful pod completes the Job
+	Completions  *int32 `json:"completions,omitempty"`
+	Parallelism  int32  `json:"parallelism"`
+	Succeeded    int32  `json:"succeeded"`
+	Failed       int32  `json:"failed"`
+	Active       int32  `json:"active"`
+	BackoffLimit int32  `json:"backoffLimit"`
+	// NearBackoffLimit is set on unfinished Jobs one more failure away from
+	// being marked Failed
+	NearBackoffLimit bool   `json:"nearBackoffLimit,omitempty"`
+	StartTime        string `json:"startTime,omitempty"`
+	CompletionTime   string `json:"completionTime,omitempty"`
+	// DurationSeconds runs until completion, or until now for unfinished Jobs
+	DurationSeconds int64 `json:"durationSeconds,omitempty"`
+}
+
+func jobProgress(j *batchv1.Job, unfinished bool) *JobProgress {
+	p := &JobProgress{
+		Completions:  j.Spec.Completions,
+		Parallelism:  1,
+		Succeeded:    j.Status.Succeeded,
+		Failed:       j.Status.Failed,
+		Active:       j.Status.Active,
+		BackoffLimit: 6, // the API default
+	}
+	if j.Spec.Parallelism != nil {
+		p.Parallelism = *j.Spec.Parallelism
+	}
+	if j.Spec.BackoffLimit != nil {
+		p.BackoffLimit = *j.Spec.BackoffLimit
+	}
+	p.NearBackoffLimit = unfinished && p.Failed > 0 && p.Failed >= p.BackoffLimit
+	if start := j.Status.StartTime; start != nil {
+		p.StartTime = formatTimestamp(*start)
+		end := time.Now()
+		if done := j.Status.CompletionTime; done != nil {
+			p.CompletionTime = formatTimestamp(*done)
+			end = done.Time
+		}
+		p.DurationSeconds = int64(end.Sub(start.Time).Seconds())
+	}
+	return p
+}
+
+func lightCronJob(cj *batchv1.CronJob) LightResource {
+	res := baseLightResource(cj, "CronJob")
+	res.Status = "Active"
+	if cj.Spec.Suspend != nil && *cj.Spec.Suspend {
+		res.Status = "Suspended"
+	}
+	res.PodSecurity = workloadPodSecurity(&cj.Spec.JobTemplate.Spec.Template.Spec)
+	applyServiceAccountInfo(&res, &cj.Spec.JobTemplate.Spec.Template.Spec, false)
+	res.ResourceDefaults = templateResourceDefaults(&cj.Spec.JobTemplate.Spec.Template.Spec)
+	res.Images = templateImages(&cj.Spec.JobTemplate.Spec.Template.Spec)
+	res.HelmRelease = extractHelmInfo(cj.Labels, cj.Annotations, cj.Namespace)
+	return res
+}
+
+func lightHPA(hpa *autoscalingv2.HorizontalPodAutoscaler) LightResource {
+	res := baseLightResource(hpa, "HorizontalPodAutoscaler")
+	res.Health = "warning"
+
+	ableCond := false
+	scalingActiveCond := false
+	for _, c := range hpa.Status.Conditions {
+		if c.Type == autoscalingv2.AbleToScale && c.Status == corev1.ConditionTrue {
+			ableCond = true
+		}
+		if c.Type == autoscalingv2.ScalingActive && c.Status == corev1.ConditionTrue {
+			scalingActiveCond = true
+		}
+	}
+
+	if ableCond && scalingActiveCond {
+		res.Status = "Active"
+		res.Health = "ok"
+	} else if ableCond {
+		res.Status = "Ready"
+		res.Health = "ok"
+	} else {
+		res.Status = "Inactive"
+	}
+
+	if hpa.Spec.ScaleTargetRef.Kind != "" {
+		res.ScaleTargetRef = &ScaleTargetRef{
+			Kind: hpa.Spec.ScaleTargetRef.Kind,
+			Name: hpa.Spec.ScaleTargetRef.Name,
+		}
+	}
+	res.HelmRelease = extractHelmInfo(hpa.Labels, hpa.Annotations, hpa.Namespace)
+	return res
+}
+
+// lightUnstructured converts a CRD object into a LightResource. Kind-specific
+// status/health mapping is applied for the CRDs we know about.
+func lightUnstructured(obj *unstructured.Unstructured, kind string) LightResource {
+	res := LightResource{
+		ID:                string(obj.GetUID()),
+		Name:              obj.GetName(),
+		Namespace:         obj.GetNamespace(),
+		Kind:              kind,
+		Status:            "Unknown",
+		Health:            "ok",
+		Labels:            obj.GetLabels(),
+		OwnerRefs:         extractOwnerRefs(obj.GetOwnerReferences()),
+		ownerKinds:        ownerKinds(obj.GetOwnerReferences()),
+		CreationTimestamp: formatTimestamp(obj.GetCreationTimestamp()),
+		DeletionTimestamp: deletionTimestamp(obj),
+		AgeSeconds:        ageSeconds(obj.GetCreationTimestamp()),
+		ExtraFields:       objectExtraFields(obj.Object, obj.GroupVersionKind().GroupKind(), kind),
+		ruleHealth:        objectRuleHealth(obj.Object, obj.GetKind(), kind),
+	}
+	if res.Labels == nil {
+		res.Labels = make(map[string]string)
+	}
+
+	switch kind {
+	case "Rollout":
+		applyRolloutStatus(obj, &res)
+	case "ScaledObject":
+		applyScaledObjectStatus(obj, &res)
+	case "VerticalPodAutoscaler":
+		applyVPAStatus(obj, &res)
+	case "Canary":
+		applyFlaggerStatus(obj, &res)
+	case "HTTPRoute":
+		applyHTTPRouteStatus(obj, &res)
+	case "Gateway":
+		applyGatewayStatus(obj, &res)
+	case "GatewayClass":
+		applyGatewayClassStatus(obj, &res)
+	case "Certificate":
+		applyCertificateStatus(obj, &res)
+	case "Issuer", "ClusterIssuer":
+		applyIssuerStatus(obj, &res)
+	case "GitRepository", "HelmRepository", "Kustomization", "FluxHelmRelease":
+		applyFluxStatus(obj, &res)
+	case "Cluster", "MachineDeployment", "Machine":
+		applyCAPIStatus(obj, &res)
+	case "VirtualService", "IstioGateway", "DestinationRule":
+		applyIstioStatus(obj, &res)
+	case "ServiceMonitor", "PodMonitor", "PrometheusRule":
+		applyMonitoringStatus(obj, &res)
+	case "Application":
+		applyArgoApplicationStatus(obj, &res)
+	case "ApplicationSet":
+		applyApplicationSetStatus(obj, &res)
+	case "AppProject":
+		applyAppProjectStatus(obj, &res)
+	}
+	applyHealthRule(&res)
+	applyTerminating(&res)
+	return res
+}
+
+// extractHelmInfo derives Helm release membership from labels and annotations
+func extractHelmInfo(labels, annotations map[string]string, ns string) *HelmReleaseInfo {
+	releaseName := labels["app.kubernetes.io/instance"]
+	if releaseName == "" {
+		releaseName = labels["helm.sh/release-name"]
+	}
+	if releaseName == "" {
+		releaseName = annotations["meta.helm.sh/release-name"]
+	}
+	if releaseName == "" {
+		return nil
+	}
+
+	// Check if actually Helm-managed
+	hasManagedByHelm := labels["app.kubernetes.io/managed-by"] == "Helm"
+	hasHelmChart := labels["helm.sh/chart"] != ""
+	hasHelmMetadata := labels["meta.helm.sh/release-name"] != "" || annotations["meta.helm.sh/release-name"] != ""
+
+	if !hasManagedByHelm && !hasHelmChart && !hasHelmMetadata {
+		return nil
+	}
+
+	releaseNs := labels["meta.helm.sh/release-namespace"]
+	if releaseNs == "" {
+		releaseNs = annotations["meta.helm.sh/release-namespace"]
+	}
+	if releaseNs == "" {
+		releaseNs = ns
+	}
+
+	return &HelmReleaseInfo{
+		ReleaseName:      releaseName,
+		ReleaseNamespace: releaseNs,
+		ChartName:        labels["helm.sh/chart"],
+	}
+}
+
+// stateHash fingerprints a LightResource so the watch stream can skip MODIFIED
+// events that don't change anything the frontend renders.
+func stateHash(res *LightResource) string {
+	// Aging alone is not a state change, nor is a running Job's duration
+	state := *res
+	state.AgeSeconds = 0
+	if res.Job != nil && res.Job.CompletionTime == "" {
+		job := *res.Job
+		job.DurationSeconds = 0
+		state.Job = &job
+	}
+	data, err := json.Marshal(&state)
+	if err != nil {
+		return ""
+	}
+	h := fnv.New64a()
+	h.Write(data)
+	return strconv.FormatUint(h.Sum64(), 16)
+}
@@ -0,0 +1,81 @@
+package k8s
+
+import (
+	storagev1 "k8s.io/api/storage/v1"
+)
+
+// CSIDriverInfo summarizes how a CSIDriver's volumes are handled. The
+// StorageClasses it provisions link to it.
+type CSIDriverInfo struct {
+	// AttachRequired drivers get a VolumeAttachment per volume and node
+	AttachRequired bool `json:"attachRequired"`
+	PodInfoOnMount bool `json:"podInfoOnMount,omitempty"`
+	// StorageCapacity drivers have the scheduler check their capacity
+	StorageCapacity bool   `json:"storageCapacity,omitempty"`
+	FSGroupPolicy   string `json:"fsGroupPolicy,omitempty"`
+	// LifecycleModes are Persistent and/or Ephemeral
+	LifecycleModes []string `json:"lifecycleModes,omitempty"`
+}
+
+// VolumeAttachmentInfo summarizes a VolumeAttachment, which links to the Node
+// and the PersistentVolume it attaches
+type VolumeAttachmentInfo struct {
+	Attacher         string `json:"attacher"`
+	PersistentVolume string `json:"persistentVolume,omitempty"`
+	Attached         bool   `json:"attached"`
+	// Error is the attach error, or the detach error of an attachment being
+	// deleted
+	Error string `json:"error,omitempty"`
+}
+
+func lightCSIDriver(d *storagev1.CSIDriver) LightResource {
+	res := baseLightResource(d, "CSIDriver")
+	res.Status = "Active"
+	res.Health = "ok"
+	info := &CSIDriverInfo{AttachRequired: true}
+	if d.Spec.AttachRequired != nil {
+		info.AttachRequired = *d.Spec.AttachRequired
+	}
+	if d.Spec.PodInfoOnMount != nil {
+		info.PodInfoOnMount = *d.Spec.PodInfoOnMount
+	}
+	if d.Spec.StorageCapacity != nil {
+		info.StorageCapacity = *d.Spec.StorageCapacity
+	}
+	if d.Spec.FSGroupPolicy != nil {
+		info.FSGroupPolicy = string(*d.Spec.FSGroupPolicy)
+	}
+	for _, mode := range d.Spec.VolumeLifecycleModes {
+		info.LifecycleModes = append(info.LifecycleModes, string(mode))
+	}
+	res.CSIDriver = info
+	return res
+}
+
+// lightVolumeAttachment reports an attachment error as an error and an
+// attachment still pending as a warning
+func lightVolumeAttachment(va *storagev1.VolumeAttachment) LightResource {
+	res := baseLightResource(va, "VolumeAttachment")
+	res.NodeName = va.Spec.NodeName
+	info := &VolumeAttachmentInfo{Attacher: va.Spec.Attacher, Attached: va.Status.Attached}
+	if pv := va.Spec.Source.PersistentVolumeName; pv != nil {
+		info.PersistentVolume = *pv
+	}
+	switch {
+	case va.Status.AttachError != nil:
+		info.Error = va.Status.AttachError.Message
+	case va.Status.DetachError != nil:
+		info.Error = va.Status.DetachError.Message
+	}
+	res.Attachment = info
+
+	switch {
+	case info.Error != "":
+		res.Status, res.Health = "Failed", "error"
+	case info.Attached:
+		res.Status, res.Health = "Attached", "ok"
+	default:
+		res.Status, res.Health = "Attaching", "warning"
+	}
+	return res
+}
@@ -0,0 +1,205 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// NodeCoverage compares the nodes a DaemonSet should run on with the nodes
+// its pods are ready on. The DaemonSet's own counters only cover nodes the
+// controller already considers, so a partial rollout can look Ready.
+type NodeCoverage struct {
+	Eligible  int       `json:"eligible"`  // nodes matching selector, affinity and taints
+	Covered   int       `json:"covered"`   // eligible nodes with a ready pod
+	Uncovered []NodeGap `json:"uncovered"` // eligible nodes without a ready pod
+	// Excluded counts the nodes the pod template rules out, by reason
+	Excluded map[string]int `json:"excluded,omitempty"`
+}
+
+// NodeGap is a node a DaemonSet doesn't cover and why
+type NodeGap struct {
+	Node   string `json:"node"`
+	Reason string `json:"reason"`
+}
+
+// daemonSetTolerated are the taints the DaemonSet controller tolerates on
+// every pod it creates
+var daemonSetTolerated = map[string]bool{
+	corev1.TaintNodeNotReady:           true,
+	corev1.TaintNodeUnreachable:        true,
+	corev1.TaintNodeDiskPressure:       true,
+	corev1.TaintNodeMemoryPressure:     true,
+	corev1.TaintNodePIDPressure:        true,
+	corev1.TaintNodeUnschedulable:      true,
+	corev1.TaintNodeNetworkUnavailable: true,
+}
+
+// daemonSetCoverage works out which nodes d should cover from its node
+// selector, required node affinity and tolerations, and which of them have
+// no ready pod of it
+func daemonSetCoverage(d *appsv1.DaemonSet, nodes []corev1.Node, pods []corev1.Pod) *NodeCoverage {
+	// Pods of the DaemonSet by the node they run on or are bound for
+	onNode := make(map[string]*corev1.Pod)
+	for i := range pods {
+		p := &pods[i]
+		if p.Namespace != d.Namespace || p.DeletionTimestamp != nil {
+			continue
+		}
+		if ref := podController(p); ref == nil || ref.UID != d.UID {
+			continue
+		}
+		node := p.Spec.NodeName
+		if node == "" {
+			node = daemonPodTargetNode(p)
+		}
+		if node != "" && (onNode[node] == nil || podReady(p)) {
+			onNode[node] = p
+		}
+	}
+
+	coverage := &NodeCoverage{Uncovered: []NodeGap{}}
+	spec := &d.Spec.Template.Spec
+	for i := range nodes {
+		node := &nodes[i]
+		if reason := nodeExcludes(spec, node); reason != "" {
+			if coverage.Excluded == nil {
+				coverage.Excluded = make(map[string]int)
+			}
+			coverage.Excluded[reason]++
+			continue
+		}
+		coverage.Eligible++
+		pod := onNode[node.Name]
+		switch {
+		case pod == nil:
+			coverage.Uncovered = append(coverage.Uncovered, NodeGap{Node: node.Name, Reason: "no pod scheduled"})
+		case pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodPending:
+			coverage.Uncovered = append(coverage.Uncovered, NodeGap{Node: node.Name, Reason: "pod " + pod.Name + " pending"})
+		case !podReady(pod):
+			coverage.Uncovered = append(coverage.Uncovered, NodeGap{Node: node.Name, Reason: "pod " + pod.Name + " not ready"})
+		default:
+			coverage.Covered++
+		}
+	}
+	sort.Slice(coverage.Uncovered, func(i, j int) bool { return coverage.Uncovered[i].Node < coverage.Uncovered[j].Node })
+	return coverage
+}
+
+// nodeExcludes returns why a pod with spec can't run on node, or "" if it can
+func nodeExcludes(spec *corev1.PodSpec, node *corev1.Node) string {
+	for k, v := range spec.NodeSelector {
+		if node.Labels[k] != v {
+			return fmt.Sprintf("nodeSelector %s=%s not matched", k, v)
+		}
+	}
+	if a := spec.Affinity; a != nil && a.NodeAffinity != nil && a.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		matched := false
+		for _, term := range a.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+			if nodeMatchesTerm(node, term) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return "required node affinity not matched"
+		}
+	}
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect != corev1.TaintEffectNoSchedule && taint.Effect != corev1.TaintEffectNoExecute {
+			continue
+		}
+		if daemonSetTolerated[taint.Key] {
+			continue
+		}
+		tolerated := false
+		for j := range spec.Tolerations {
+			if spec.Tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return "taint " + taint.ToString() + " not tolerated"
+		}
+	}
+	return ""
+}
+
+// nodeMatchesTerm reports whether node satisfies every requirement of a node
+// selector term
+func nodeMatchesTerm(node *corev1.Node, term corev1.NodeSelectorTerm) bool {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+	for _, req := range term.MatchExpressions {
+		value, exists := node.Labels[req.Key]
+		if !requirementMatches(req, value, exists) {
+			return false
+		}
+	}
+	for _, req := range term.MatchFields {
+		// metadata.name is the only field selector nodes support
+		if req.Key != "metadata.name" || !requirementMatches(req, node.Name, true) {
+			return false
+		}
+	}
+	return true
+}
+
+func requirementMatches(req corev1.NodeSelectorRequirement, value string, exists bool) bool {
+	switch req.Operator {
+	case corev1.NodeSelectorOpIn:
+		return exists && containsString(req.Values, value)
+	case corev1.NodeSelectorOpNotIn:
+		return !exists || !containsString(req.Values, value)
+	case corev1.NodeSelectorOpExists:
+		return exists
+	case corev1.NodeSelectorOpDoesNotExist:
+		return !exists
+	case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+		if !exists || len(req.Values) != 1 {
+			return false
+		}
+		have, err1 := strconv.ParseInt(value, 10, 64)
+		want, err2 := strconv.ParseInt(req.Values[0], 10, 64)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		if req.Operator == corev1.NodeSelectorOpGt {
+			return have > want
+		}
+		return have < want
+	}
+	return false
+}
+
+// daemonPodTargetNode returns the node an unscheduled DaemonSet pod is bound
+// for; the controller pins each pod with a metadata.name node affinity
+func daemonPodTargetNode(p *corev1.Pod) string {
+	a := p.Spec.Affinity
+	if a == nil || a.NodeAffinity == nil || a.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return ""
+	}
+	for _, term := range a.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		for _, req := range term.MatchFields {
+			if req.Key == "metadata.name" && req.Operator == corev1.NodeSelectorOpIn && len(req.Values) == 1 {
+				return req.Values[0]
+			}
+		}
+	}
+	return ""
+}
+
+func podReady(p *corev1.Pod) bool {
+	for _, c := range p.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package k8s
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// DeploymentInfo sums up the replicas and rollout of a Deployment, enough for
+// a progress bar without fetching it
+type DeploymentInfo struct {
+	Desired   int32 `json:"desired"`
+	Ready     int32 `json:"ready"`
+	Updated   int32 `json:"updated"`
+	Available int32 `json:"available"`
+	// Strategy is RollingUpdate or Recreate; MaxSurge and MaxUnavailable are
+	// the rolling update bounds, as counts or percentages
+	Strategy       string `json:"strategy"`
+	MaxSurge       string `json:"maxSurge,omitempty"`
+	MaxUnavailable string `json:"maxUnavailable,omitempty"`
+	// Revision is the deployment.kubernetes.io/revision of the current rollout
+	Revision string `json:"revision,omitempty"`
+	// Paused rollouts wait for kubectl rollout resume
+	Paused bool `json:"paused,omitempty"`
+}
+
+func deploymentInfo(d *appsv1.Deployment) *DeploymentInfo {
+	info := &DeploymentInfo{
+		Desired:   1,
+		Ready:     d.Status.ReadyReplicas,
+		Updated:   d.Status.UpdatedReplicas,
+		Available: d.Status.AvailableReplicas,
+		Strategy:  string(d.Spec.Strategy.Type),
+		Revision:  d.Annotations["deployment.kubernetes.io/revision"],
+		Paused:    d.Spec.Paused,
+	}
+	if d.Spec.Replicas != nil {
+		info.Desired = *d.Spec.Replicas
+	}
+	if info.Strategy == "" {
+		info.Strategy = string(appsv1.RollingUpdateDeploymentStrategyType)
+	}
+	if ru := d.Spec.Strategy.RollingUpdate; ru != nil {
+		if ru.MaxSurge != nil {
+			info.MaxSurge = ru.MaxSurge.String()
+		}
+		if ru.MaxUnavailable != nil {
+			info.MaxUnavailable = ru.MaxUnavailable.String()
+		}
+	}
+	return info
+}
@@ -0,0 +1,318 @@
+package k8s
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// DrainPod is what a drain would do to one pod on the node
+type DrainPod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Owner     string `json:"owner,omitempty"` // Kind/Name of the controller
+	// rescheduled: evicted and recreated by its controller
+	// lost: evicted with no controller to recreate it
+	// blocked: eviction refused by a PodDisruptionBudget
+	// ignored: DaemonSet or static pods, which drains leave alone
+	// completed: finished pods, deleted without replacement
+	Outcome     string          `json:"outcome"`
+	Reasons     []string        `json:"reasons,omitempty"`
+	PDB         string          `json:"pdb,omitempty"`
+	Requests    CapacityAmounts `json:"requests"`
+	Destination string          `json:"destination,omitempty"` // simulated target node
+}
+
+// DrainSummary counts pods per outcome
+type DrainSummary struct {
+	Rescheduled int `json:"rescheduled"`
+	Lost        int `json:"lost"`
+	Blocked     int `json:"blocked"`
+	Ignored     int `json:"ignored"`
+	Completed   int `json:"completed"`
+}
+
+// DrainPlan is served by /api/nodes/{name}/drain-plan
+type DrainPlan struct {
+	Node     string       `json:"node"`
+	Cordoned bool         `json:"cordoned"`
+	Pods     []DrainPod   `json:"pods"`
+	Summary  DrainSummary `json:"summary"`
+	// CapacityOK is false when some rescheduled pods fit on no remaining node
+	CapacityOK  bool     `json:"capacityOk"`
+	Unplaceable []string `json:"unplaceable"`
+	// Safe means nothing would be blocked, lost or left pending
+	Safe bool `json:"safe"`
+}
+
+type drainTarget struct {
+	node *corev1.Node
+	free CapacityAmounts
+}
+
+// canHost reports whether a pod could be scheduled on the target, considering
+// its node selector, the node's NoSchedule/NoExecute taints and free capacity.
+// Affinity and topology spread are not evaluated.
+func (t *drainTarget) canHost(pod *corev1.Pod, req CapacityAmounts) bool {
+	for k, v := range pod.Spec.NodeSelector {
+		if t.node.Labels[k] != v {
+			return false
+		}
+	}
+	for i := range t.node.Spec.Taints {
+		taint := &t.node.Spec.Taints[i]
+		if taint.Effect != corev1.TaintEffectNoSchedule && taint.Effect != corev1.TaintEffectNoExecute {
+			continue
+		}
+		tolerated := false
+		for j := range pod.Spec.Tolerations {
+			if pod.Spec.Tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return t.free.Pods >= 1 && t.free.CPU >= req.CPU && t.free.Memory >= req.Memory
+}
+
+func podController(pod *corev1.Pod) *metav1.OwnerReference {
+	for i := range pod.OwnerReferences {
+		if ref := &pod.OwnerReferences[i]; ref.Controller != nil && *ref.Controller {
+			return ref
+		}
+	}
+	return nil
+}
+
+// HandleDrainPlan serves /api/nodes/{name}/drain-plan. It simulates draining
+// the node without touching it: which pods would be evicted and recreated
+// elsewhere, which are blocked by PodDisruptionBudgets, which have no controller
+// and would be lost, and whether the remaining nodes can take the displaced pods.
+// The summary and verdict cover every pod on the node; only pods in the
+// caller's namespaces are listed by name.
+func HandleDrainPlan(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/nodes/"), "/")
+	name, action, _ := strings.Cut(path, "/")
+	if name == "" || action != "drain-plan" {
+		http.NotFound(w, r)
+		return
+	}
+	// Nodes are cluster-scoped
+	if !auth.RequireNamespace(w, r, "") {
+		return
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		i18n.Error(w, r, http.StatusInternalServerError, "error.clientFailed")
+		return
+	}
+	ctx := r.Context()
+
+	node, err := clientset.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		i18n.Error(w, r, http.StatusNotFound, "error.nodeNotFound")
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// The other nodes are the candidate destinations
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		FieldSelector: "status.phase!=Succeeded,status.phase!=Failed",
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	onNode, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{FieldSelector: "spec.nodeName=" + name})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	pdbs, err := clientset.PolicyV1().PodDisruptionBudgets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Evictions draw from each budget's allowed disruptions in turn
+	budgets := make(map[string]int32)
+	for _, pdb := range pdbs.Items {
+		budgets[pdb.Namespace+"/"+pdb.Name] = pdb.Status.DisruptionsAllowed
+	}
+	matchingPDB := func(pod *corev1.Pod) *policyv1.PodDisruptionBudget {
+		for i := range pdbs.Items {
+			pdb := &pdbs.Items[i]
+			if pdb.Namespace != pod.Namespace {
+				continue
+			}
+			selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+			if err != nil || !selector.Matches(labels.Set(pod.Labels)) {
+				continue
+			}
+			return pdb
+		}
+		return nil
+	}
+
+	plan := DrainPlan{Node: name, Cordoned: node.Spec.Unschedulable, Pods: []DrainPod{}, Unplaceable: []string{}}
+	var displaced []*corev1.Pod
+	displacedIndex := make(map[*corev1.Pod]int)
+	for i := range onNode.Items {
+		pod := &onNode.Items[i]
+		entry := DrainPod{Namespace: pod.Namespace, Name: pod.Name, Requests: podRequests(&pod.Spec)}
+		controller := podController(pod)
+		if controller != nil {
+			entry.Owner = controller.Kind + "/" + controller.Name
+		}
+
+		switch {
+		case pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed:
+			entry.Outcome = "completed"
+		case pod.Annotations[corev1.MirrorPodAnnotationKey] != "":
+			entry.Outcome = "ignored"
+			entry.Reasons = append(entry.Reasons, "static pod")
+		case controller != nil && controller.Kind == "DaemonSet":
+			entry.Outcome = "ignored"
+			entry.Reasons = append(entry.Reasons, "DaemonSet pod")
+		default:
+			entry.Outcome = "rescheduled"
+			if controller == nil {
+				entry.Outcome = "lost"
+				entry.Reasons = append(entry.Reasons, "no controller to recreate it")
+			}
+			if pdb := matchingPDB(pod); pdb != nil {
+				key := pdb.Namespace + "/" + pdb.Name
+				entry.PDB = pdb.Name
+				if budgets[key] <= 0 {
+					entry.Outcome = "blocked"
+					entry.Reasons = append(entry.Reasons, "PodDisruptionBudget "+pdb.Name+" allows no more disruptions")
+				} else {
+					budgets[key]--
+				}
+			}
+			for _, v := range pod.Spec.Volumes {
+				if v.EmptyDir != nil {
+					entry.Reasons = append(entry.Reasons, "emptyDir "+v.Name+" data is lost")
+				}
+			}
+		}
+
+		switch entry.Outcome {
+		case "rescheduled":
+			plan.Summary.Rescheduled++
+			displacedIndex[pod] = len(plan.Pods)
+			displaced = append(displaced, pod)
+		case "lost":
+			plan.Summary.Lost++
+		case "blocked":
+			plan.Summary.Blocked++
+		case "ignored":
+			plan.Summary.Ignored++
+		case "completed":
+			plan.Summary.Completed++
+		}
+		plan.Pods = append(plan.Pods, entry)
+	}
+
+	// First-fit decreasing placement on the remaining schedulable nodes
+	requested := nodeRequests(pods.Items)
+	var targets []*drainTarget
+	for i := range nodes.Items {
+		n := &nodes.Items[i]
+		if n.Name == name {
+			continue
+		}
+		reasons, _ := nodeSchedulability(n)
+		unschedulable := false
+		for _, reason := range reasons {
+			// Taints are checked per pod against its tolerations
+			if !strings.HasPrefix(reason, "taint ") {
+				unschedulable = true
+			}
+		}
+		if unschedulable {
+			continue
+		}
+		alloc, used := nodeAllocatable(n), requested[n.Name]
+		targets = append(targets, &drainTarget{node: n, free: CapacityAmounts{
+			CPU:    alloc.CPU - used.CPU,
+			Memory: alloc.Memory - used.Memory,
+			Pods:   alloc.Pods - used.Pods,
+		}})
+	}
+	sort.SliceStable(displaced, func(i, j int) bool {
+		a, b := plan.Pods[displacedIndex[displaced[i]]].Requests, plan.Pods[displacedIndex[displaced[j]]].Requests
+		if a.CPU != b.CPU {
+			return a.CPU > b.CPU
+		}
+		return a.Memory > b.Memory
+	})
+	for _, pod := range displaced {
+		entry := &plan.Pods[displacedIndex[pod]]
+		placed := false
+		for _, t := range targets {
+			if t.canHost(pod, entry.Requests) {
+				t.free.CPU -= entry.Requests.CPU
+				t.free.Memory -= entry.Requests.Memory
+				t.free.Pods--
+				entry.Destination = t.node.Name
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			entry.Reasons = append(entry.Reasons, "no remaining node can fit it")
+			plan.Unplaceable = append(plan.Unplaceable, pod.Namespace+"/"+pod.Name)
+		}
+	}
+	plan.CapacityOK = len(plan.Unplaceable) == 0
+	plan.Safe = plan.CapacityOK && plan.Summary.Blocked == 0 && plan.Summary.Lost == 0
+
+	// Pods of other tenants are counted above but not named
+	scope := auth.ScopeFromContext(ctx)
+	visible := plan.Pods[:0]
+	for _, entry := range plan.Pods {
+		if scope.Allows(entry.Namespace) {
+			visible = append(visible, entry)
+		}
+	}
+	plan.Pods = visible
+	unplaceable := plan.Unplaceable[:0]
+	for _, pod := range plan.Unplaceable {
+		if ns, _, _ := strings.Cut(pod, "/"); scope.Allows(ns) {
+			unplaceable = append(unplaceable, pod)
+		}
+	}
+	plan.Unplaceable = unplaceable
+
+	sort.SliceStable(plan.Pods, func(i, j int) bool {
+		a, b := plan.Pods[i], plan.Pods[j]
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
@@ -0,0 +1,314 @@
+package k8s
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anakosmos/backend/src/activity"
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	"github.com/anakosmos/backend/src/settings"
+)
+
+// maxDrillDuration bounds a drill, so a forgotten one can't hide the real
+// health of a resource for long
+const maxDrillDuration = 24 * time.Hour
+
+// Drill is a synthetic failure for incident-response drills. It only changes
+// what anakosmos reports (init, watch events, alerts and health metrics) and
+// never touches the cluster. Drills live in memory: they end with the process
+// and are only seen by the replica that started them.
+type Drill struct {
+	ID string `json:"id"`
+	// Cluster is "local" or a registered ClusterConnection
+	Cluster string `json:"cluster"`
+	// Kind, Namespace and Name select the resource whose Health is overridden
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Health    string `json:"health,omitempty"`
+	// Unreachable makes the whole cluster look unreachable instead
+	Unreachable bool      `json:"unreachable,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	CreatedBy   string    `json:"createdBy"`
+
+	host  string // API server the drill applies to
+	timer *time.Timer
+}
+
+func (d *Drill) active(now time.Time) bool {
+	return !now.Before(d.Start) && now.Before(d.End)
+}
+
+func (d *Drill) unreachableError() error {
+	return fmt.Errorf("drill %s: cluster unreachable (simulated)", d.ID)
+}
+
+// matches reports whether a resource drill targets res
+func (d *Drill) matches(res *LightResource) bool {
+	return !d.Unreachable && strings.EqualFold(d.Kind, res.Kind) && d.Namespace == res.Namespace && d.Name == res.Name
+}
+
+// drills holds the drills in progress and the watch connections following
+// them, which re-send the affected resource when a drill starts or ends
+var drills = struct {
+	sync.Mutex
+	byID        map[string]*Drill
+	subscribers map[chan Drill]struct{}
+}{byID: make(map[string]*Drill), subscribers: make(map[chan Drill]struct{})}
+
+// drillHost resolves the API server of "local" or a registered cluster
+func drillHost(cluster string) (string, error) {
+	if cluster == "" || cluster == LocalClusterID {
+		clusters.RLock()
+		local := clusters.local
+		clusters.RUnlock()
+		if local == nil {
+			return "", errors.New("no local cluster connection")
+		}
+		return strings.TrimRight(local.Host, "/"), nil
+	}
+	conn, ok := settings.Current().Cluster(cluster)
+	if !ok {
+		return "", fmt.Errorf("cluster %q is not registered", cluster)
+	}
+	return strings.TrimRight(conn.Server, "/"), nil
+}
+
+// activeDrills returns the drills in progress on the API server host
+func activeDrills(host string) []Drill {
+	host = strings.TrimRight(host, "/")
+	now := time.Now()
+	drills.Lock()
+	defer drills.Unlock()
+	var active []Drill
+	for _, d := range drills.byID {
+		if d.host == host && d.active(now) {
+			active = append(active, *d)
+		}
+	}
+	return active
+}
+
+// unreachableDrill returns the error of a drill making host unreachable, or
+// nil when there is none
+func unreachableDrill(host string) error {
+	for _, d := range activeDrills(host) {
+		if d.Unreachable {
+			return d.unreachableError()
+		}
+	}
+	return nil
+}
+
+// applyDrills overrides the health of res with the resource drills of host.
+// The resource is marked unhealthy since the drill started, so alert rules
+// with a duration fire as they would for a real failure.
+func applyDrills(host string, res *LightResource) {
+	for _, d := range activeDrills(host) {
+		if !d.matches(res) {
+			continue
+		}
+		res.Health = d.Health
+		res.Status = "Drill"
+		res.StaleSince = d.Start.UTC().Format("2006-01-02T15:04:05Z")
+		res.Drill = true
+	}
+}
+
+// withDrills returns resources with the resource drills of host applied,
+// copying them only when a drill is in progress
+func withDrills(host string, resources []LightResource) []LightResource {
+	if len(activeDrills(host)) == 0 {
+		return resources
+	}
+	drilled := make([]LightResource, len(resources))
+	copy(drilled, resources)
+	for i := range drilled {
+		applyDrills(host, &drilled[i])
+	}
+	return drilled
+}
+
+// subscribeDrills returns a channel receiving every drill that starts or ends
+func subscribeDrills() (<-chan Drill, func()) {
+	ch := make(chan Drill, 16)
+	drills.Lock()
+	drills.subscribers[ch] = struct{}{}
+	drills.Unlock()
+	return ch, func() {
+		drills.Lock()
+		delete(drills.subscribers, ch)
+		drills.Unlock()
+	}
+}
+
+// notifyDrill tells subscribers and the activity feed that a drill started or
+// ended. Slow subscribers miss it rather than block.
+func notifyDrill(d Drill, verb, actor string) {
+	drills.Lock()
+	for ch := range drills.subscribers {
+		select {
+		case ch <- d:
+		default:
+		}
+	}
+	drills.Unlock()
+
+	// Feed sessions know remote clusters by their API server
+	cluster := d.Cluster
+	if cluster != LocalClusterID {
+		cluster = d.host
+	}
+	target := "cluster unreachable"
+	if !d.Unreachable {
+		target = fmt.Sprintf("%s %s/%s %s", d.Kind, d.Namespace, d.Name, d.Health)
+	}
+	activity.Publish(activity.Item{
+		Type: "drill", Severity: "info", Cluster: cluster, Actor: actor, Verb: verb,
+		Kind: d.Kind, Namespace: d.Namespace, Name: d.Name,
+		Message: "drill " + verb + ": " + target,
+	})
+}
+
+// startDrill registers d and schedules its end
+func startDrill(d *Drill) error {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	d.ID = hex.EncodeToString(id)
+	drills.Lock()
+	drills.byID[d.ID] = d
+	d.timer = time.AfterFunc(time.Until(d.End), func() {
+		if ended, ok := endDrill(d.ID); ok {
+			notifyDrill(ended, "ended", ended.CreatedBy)
+		}
+	})
+	drills.Unlock()
+	return nil
+}
+
+// endDrill removes a drill, ending it early when still in progress
+func endDrill(id string) (Drill, bool) {
+	drills.Lock()
+	defer drills.Unlock()
+	d, ok := drills.byID[id]
+	if !ok {
+		return Drill{}, false
+	}
+	d.timer.Stop()
+	delete(drills.byID, id)
+	return *d, true
+}
+
+type startDrillRequest struct {
+	Cluster     string `json:"cluster"`
+	Kind        string `json:"kind"`
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	Health      string `json:"health"`
+	Unreachable bool   `json:"unreachable"`
+	Reason      string `json:"reason"`
+	// Duration is how long the drill lasts, e.g. "15m"
+	Duration string `json:"duration"`
+}
+
+// DrillsHandler serves /api/drills (GET the drills in progress, POST start
+// one) and /api/drills/{id} (DELETE end it early). A drill either marks one
+// resource as error or warning, or makes a cluster look unreachable, for the
+// given duration. Everything is admin only.
+func DrillsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !auth.RequireAdmin(w, r) {
+			return
+		}
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/drills"), "/")
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == "GET" && id == "":
+			drills.Lock()
+			result := make([]Drill, 0, len(drills.byID))
+			for _, d := range drills.byID {
+				result = append(result, *d)
+			}
+			drills.Unlock()
+			sort.Slice(result, func(i, j int) bool { return result[i].Start.Before(result[j].Start) })
+			json.NewEncoder(w).Encode(result)
+
+		case r.Method == "POST" && id == "":
+			var req startDrillRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				i18n.Error(w, r, http.StatusBadRequest, "error.invalidJSON")
+				return
+			}
+			d := &Drill{
+				Cluster:     req.Cluster,
+				Unreachable: req.Unreachable,
+				Reason:      req.Reason,
+				Start:       time.Now().UTC(),
+				CreatedBy:   auth.IdentityFromContext(r.Context()).User,
+			}
+			if d.Cluster == "" {
+				d.Cluster = LocalClusterID
+			}
+			if !d.Unreachable {
+				if req.Kind == "" || req.Name == "" {
+					i18n.Error(w, r, http.StatusBadRequest, "error.required", "kind, name")
+					return
+				}
+				d.Kind, d.Namespace, d.Name = req.Kind, req.Namespace, req.Name
+				d.Health = req.Health
+				if d.Health == "" {
+					d.Health = "error"
+				}
+				if d.Health != "error" && d.Health != "warning" {
+					i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "health")
+					return
+				}
+			}
+			duration, err := time.ParseDuration(req.Duration)
+			if err != nil || duration <= 0 || duration > maxDrillDuration {
+				i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "duration")
+				return
+			}
+			d.End = d.Start.Add(duration)
+			host, err := drillHost(d.Cluster)
+			if err != nil {
+				i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "cluster")
+				return
+			}
+			d.host = host
+			if err := startDrill(d); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			notifyDrill(*d, "started", d.CreatedBy)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(d)
+
+		case r.Method == "DELETE" && id != "":
+			d, ok := endDrill(id)
+			if !ok {
+				i18n.Error(w, r, http.StatusNotFound, "error.resourceNotFound")
+				return
+			}
+			notifyDrill(d, "ended", auth.IdentityFromContext(r.Context()).User)
+			json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
+
+		default:
+			i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/anakosmos/backend/src/activity"
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"sigs.k8s.io/yaml"
+)
+
+// EditDocument is the editable form of a resource
+type EditDocument struct {
+	APIVersion      string `json:"apiVersion"`
+	Kind            string `json:"kind"`
+	Namespace       string `json:"namespace,omitempty"`
+	Name            string `json:"name"`
+	ResourceVersion string `json:"resourceVersion"`
+	YAML            string `json:"yaml"`
+}
+
+// EditConflict is returned with 409 when the resource changed since it was read
+type EditConflict struct {
+	Message         string `json:"message"`
+	ResourceVersion string `json:"resourceVersion"` // current version on the server
+	YAML            string `json:"yaml"`            // current server state
+	// Diff goes from the server state to the submitted YAML, unified style
+	Diff string `json:"diff"`
+}
+
+type editRequest struct {
+	YAML            string `json:"yaml"`
+	ResourceVersion string `json:"resourceVersion"`
+}
+
+// resolveResource maps a kind (with optional apiVersion) to its REST mapping
+func resolveResource(config *rest.Config, apiVersion, kind string) (*meta.RESTMapping, error) {
+	discoveryClient, err := cachedDiscovery(config)
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(discoveryClient)
+
+	if apiVersion != "" {
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			return nil, err
+		}
+		return mapper.RESTMapping(schema.GroupKind{Group: gv.Group, Kind: kind}, gv.Version)
+	}
+	// Without an apiVersion, take the preferred version of the kind
+	gvk, err := mapper.KindFor(schema.GroupVersionResource{Resource: strings.ToLower(kind)})
+	if err != nil {
+		return nil, err
+	}
+	return mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+}
+
+// editableYAML renders an object without its managed fields. The status stays:
+// the edit is a full update, and kinds without a status subresource would
+// lose it otherwise.
+func editableYAML(u *unstructured.Unstructured) (string, error) {
+	obj := u.DeepCopy()
+	unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// hunkRange formats one side of a unified diff hunk header: the 1-based first
+// line and the line count, or the line before an empty range
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+// unifiedDiff is a line diff from a to b with three lines of context per hunk,
+// each introduced by a "@@ -a,b +c,d @@" header
+func unifiedDiff(a, b string) string {
+	x, y := strings.Split(a, "\n"), strings.Split(b, "\n")
+	// The table below is quadratic; very large documents are shown replaced
+	if len(x)*len(y) > 4_000_000 {
+		return "@@ -" + hunkRange(0, len(x)) + " +" + hunkRange(0, len(y)) + " @@\n" +
+			"-" + strings.Join(x, "\n-") + "\n+" + strings.Join(y, "\n+") + "\n"
+	}
+	// Longest common subsequence table, from the end
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type line struct {
+		op   byte
+		text string
+	}
+	var lines []line
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			lines = append(lines, line{' ', x[i]})
+			i++
+			j++
+		case j < len(y) && (i == len(x) || lcs[i][j+1] >= lcs[i+1][j]):
+			lines = append(lines, line{'+', y[j]})
+			j++
+		default:
+			lines = append(lines, line{'-', x[i]})
+			i++
+		}
+	}
+
+	// Keep changed lines and three lines of context around them
+	const context = 3
+	keep := make([]bool, len(lines))
+	for k, l := range lines {
+		if l.op == ' ' {
+			continue
+		}
+		for c := max(k-context, 0); c <= min(k+context, len(lines)-1); c++ {
+			keep[c] = true
+		}
+	}
+	var out strings.Builder
+	oldLine, newLine := 0, 0 // lines of a and b before lines[k]
+	for k := 0; k < len(lines); {
+		if !keep[k] {
+			if lines[k].op != '+' {
+				oldLine++
+			}
+			if lines[k].op != '-' {
+				newLine++
+			}
+			k++
+			continue
+		}
+		end := k
+		oldCount, newCount := 0, 0
+		for ; end < len(lines) && keep[end]; end++ {
+			if lines[end].op != '+' {
+				oldCount++
+			}
+			if lines[end].op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for _, l := range lines[k:end] {
+			out.WriteByte(l.op)
+			out.WriteString(l.text)
+			out.WriteByte('\n')
+		}
+		oldLine, newLine = oldLine+oldCount, newLine+newCount
+		k = end
+	}
+	return out.String()
+}
+
+// HandleEdit serves /api/resources/edit?apiVersion=&kind=&namespace=&name=.
+// GET returns the resource as YAML with its resourceVersion. PUT takes
+// {yaml, resourceVersion} and replaces the resource only if it still has that
+// version, replying 409 with the current state and a diff otherwise.
+func HandleEdit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	kind, namespace, name := q.Get("kind"), q.Get("namespace"), q.Get("name")
+	if kind == "" || name == "" {
+		i18n.Error(w, r, http.StatusBadRequest, "error.required", "kind, name")
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	mapping, err := resolveResource(config, q.Get("apiVersion"), kind)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		i18n.Error(w, r, http.StatusInternalServerError, "error.dynamicClientFailed")
+		return
+	}
+	var resource dynamic.ResourceInterface = dynamicClient.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if namespace == "" {
+			i18n.Error(w, r, http.StatusBadRequest, "error.namespaceRequiredFor", mapping.GroupVersionKind.Kind)
+			return
+		}
+		resource = dynamicClient.Resource(mapping.Resource).Namespace(namespace)
+	} else {
+		namespace = ""
+	}
+	if !auth.RequireNamespace(w, r, namespace) {
+		return
+	}
+
+	writeDocument := func(u *unstructured.Unstructured) {
+		text, err := editableYAML(u)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(EditDocument{
+			APIVersion:      u.GetAPIVersion(),
+			Kind:            u.GetKind(),
+			Namespace:       u.GetNamespace(),
+			Name:            u.GetName(),
+			ResourceVersion: u.GetResourceVersion(),
+			YAML:            text,
+		})
+	}
+
+	if r.Method == http.MethodGet {
+		current, err := resource.Get(r.Context(), name, metav1.GetOptions{})
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		writeDocument(current)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		i18n.Error(w, r, http.StatusBadRequest, "error.readBodyFailed")
+		return
+	}
+	var req editRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		i18n.Error(w, r, http.StatusBadRequest, "error.invalidJSON")
+		return
+	}
+	if req.ResourceVersion == "" {
+		i18n.Error(w, r, http.StatusBadRequest, "error.required", "resourceVersion")
+		return
+	}
+	var obj map[string]interface{}
+	if err := yaml.Unmarshal([]byte(req.YAML), &obj); err != nil {
+		i18n.Error(w, r, http.StatusBadRequest, "error.invalidYAML", err.Error())
+		return
+	}
+	edited := &unstructured.Unstructured{Object: obj}
+	if edited.GetKind() != mapping.GroupVersionKind.Kind || edited.GetName() != name || edited.GetNamespace() != namespace {
+		i18n.Error(w, r, http.StatusBadRequest, "error.yamlMustDescribe", mapping.GroupVersionKind.Kind, name)
+		return
+	}
+	if !auth.RequireAllowed(w, r, "update", mapping.GroupVersionKind.Kind, namespace, name) {
+		return
+	}
+	// The API server rejects the update when the object moved past this version
+	edited.SetResourceVersion(req.ResourceVersion)
+
+	updated, err := resource.Update(r.Context(), edited, metav1.UpdateOptions{FieldManager: "anakosmos-ui"})
+	if apierrors.IsConflict(err) {
+		current, getErr := resource.Get(r.Context(), name, metav1.GetOptions{})
+		if getErr != nil {
+			writeAPIError(w, getErr)
+			return
+		}
+		currentYAML, _ := editableYAML(current)
+		submitted := edited.DeepCopy()
+		submitted.SetResourceVersion(current.GetResourceVersion())
+		submittedYAML, _ := editableYAML(submitted)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(EditConflict{
+			Message:         fmt.Sprintf("%s %s was modified (now at resourceVersion %s); review the changes and retry", kind, name, current.GetResourceVersion()),
+			ResourceVersion: current.GetResourceVersion(),
+			YAML:            currentYAML,
+			Diff:            unifiedDiff(currentYAML, submittedYAML),
+		})
+		return
+	}
+	activity.Publish(activity.Operation(r, "update", mapping.GroupVersionKind.Kind, namespace, name, err))
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeDocument(updated)
+}
+
+// writeAPIError relays a Kubernetes API error with its status code
+func writeAPIError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if s, ok := err.(apierrors.APIStatus); ok && s.Status().Code != 0 {
+		status = int(s.Status().Code)
+	}
+	http.Error(w, err.Error(), status)
+}
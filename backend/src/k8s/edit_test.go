@@ -0,0 +1,44 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(n int, change map[int]string) string {
+		var b strings.Builder
+		for i := 1; i <= n; i++ {
+			if text, ok := change[i]; ok {
+				b.WriteString(text)
+			} else {
+				b.WriteString("line")
+				b.WriteString(strings.Repeat("x", i))
+			}
+			b.WriteByte('\n')
+		}
+		return b.String()
+	}
+	tests := []struct {
+		name    string
+		a, b    string
+		headers []string
+	}{
+		{"identical", lines(5, nil), lines(5, nil), nil},
+		{"one change", lines(10, nil), lines(10, map[int]string{5: "changed"}), []string{"@@ -2,7 +2,7 @@"}},
+		{"two hunks", lines(20, nil), lines(20, map[int]string{2: "a", 18: "b"}), []string{"@@ -1,5 +1,5 @@", "@@ -15,7 +15,7 @@"}},
+		{"added at end", "a\nb\n", "a\nb\nc\n", []string{"@@ -1,3 +1,4 @@"}},
+		{"from empty", "", "a\n", []string{"@@ -1,1 +1,2 @@"}},
+	}
+	for _, tt := range tests {
+		var headers []string
+		for _, line := range strings.Split(unifiedDiff(tt.a, tt.b), "\n") {
+			if strings.HasPrefix(line, "@@") {
+				headers = append(headers, line)
+			}
+		}
+		if strings.Join(headers, "|") != strings.Join(tt.headers, "|") {
+			t.Errorf("%s: hunk headers = %q, want %q", tt.name, headers, tt.headers)
+		}
+	}
+}
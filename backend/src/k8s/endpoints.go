@@ -0,0 +1,92 @@
+package k8s
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+)
+
+// Link states of Service -> Pod links derived from EndpointSlices
+const (
+	linkReady    = "ready"
+	linkNotReady = "notReady"
+)
+
+// indexEndpointSlices groups EndpointSlices by the namespace/name of the
+// Service they belong to
+func indexEndpointSlices(slices []discoveryv1.EndpointSlice) map[string][]*discoveryv1.EndpointSlice {
+	index := make(map[string][]*discoveryv1.EndpointSlice)
+	for i := range slices {
+		s := &slices[i]
+		if svc := s.Labels[discoveryv1.LabelServiceName]; svc != "" {
+			index[s.Namespace+"/"+svc] = append(index[s.Namespace+"/"+svc], s)
+		}
+	}
+	return index
+}
+
+// podIndex resolves endpoint targets to pod UIDs, by reference or, for
+// endpoints of selectorless Services, by address
+type podIndex struct {
+	byName map[string]string // namespace/name -> uid
+	byIP   map[string]string // namespace/ip -> uid
+}
+
+func newPodIndex(pods []corev1.Pod) podIndex {
+	idx := podIndex{byName: make(map[string]string), byIP: make(map[string]string)}
+	for i := range pods {
+		p := &pods[i]
+		idx.byName[p.Namespace+"/"+p.Name] = string(p.UID)
+		// Host-network pods share the node IP and can't be told apart by it
+		if p.Spec.HostNetwork {
+			continue
+		}
+		for _, ip := range p.Status.PodIPs {
+			idx.byIP[p.Namespace+"/"+ip.IP] = string(p.UID)
+		}
+	}
+	return idx
+}
+
+func (idx podIndex) resolve(namespace string, ep *discoveryv1.Endpoint) string {
+	if ref := ep.TargetRef; ref != nil && ref.Kind == "Pod" {
+		if ref.Namespace != "" {
+			namespace = ref.Namespace
+		}
+		return idx.byName[namespace+"/"+ref.Name]
+	}
+	for _, addr := range ep.Addresses {
+		if uid := idx.byIP[namespace+"/"+addr]; uid != "" {
+			return uid
+		}
+	}
+	return ""
+}
+
+// endpointLinks links a Service to the pods behind its EndpointSlices. Each
+// link is "ready" when the pod serves traffic (readiness gates included) and
+// "notReady" otherwise.
+func endpointLinks(svcUID string, slices []*discoveryv1.EndpointSlice, idx podIndex) []ClusterLink {
+	states := make(map[string]string) // pod uid -> state
+	for _, slice := range slices {
+		for i := range slice.Endpoints {
+			ep := &slice.Endpoints[i]
+			uid := idx.resolve(slice.Namespace, ep)
+			if uid == "" {
+				continue
+			}
+			if ep.Conditions.Ready == nil || *ep.Conditions.Ready {
+				states[uid] = linkReady
+			} else if states[uid] == "" {
+				states[uid] = linkNotReady
+			}
+		}
+	}
+	links := make([]ClusterLink, 0, len(states))
+	for uid, state := range states {
+		links = append(links, ClusterLink{Source: svcUID, Target: uid, Type: "network", State: state})
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].Target < links[j].Target })
+	return links
+}
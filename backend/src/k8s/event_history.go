@@ -0,0 +1,123 @@
+package k8s
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// historyPerResource is how many transitions are kept per resource UID
+	historyPerResource = 20
+	// historyMaxResources bounds memory; the least recently updated resources
+	// are evicted first
+	historyMaxResources = 5000
+)
+
+// EventTransition is a recorded watch event for a resource
+type EventTransition struct {
+	Type     string         `json:"type"` // ADDED, MODIFIED, DELETED
+	Time     string         `json:"time"`
+	Resource *LightResource `json:"resource"`
+	hash     string
+}
+
+type resourceHistory struct {
+	cluster string
+	key     string // kind/namespace/name
+	events  []EventTransition
+	updated time.Time
+}
+
+// EventHistory keeps a short ring of recent transitions per resource so detail
+// panels opened late can replay them. It is fed by the cluster watch streams
+// and shared between connections using the same credential, keyed by
+// credentialKey and UID: callers with other credentials may not be allowed
+// to read the resource.
+type EventHistory struct {
+	mu        sync.Mutex
+	resources map[string]*resourceHistory // credential|uid -> history
+	index     map[string]string           // credential|kind/namespace/name -> credential|uid
+}
+
+var eventHistory = &EventHistory{
+	resources: make(map[string]*resourceHistory),
+	index:     make(map[string]string),
+}
+
+// Record appends a transition seen with the credential of cluster, a
+// credentialKey. Several connections watching the same cluster report the
+// same change; consecutive identical states are stored once.
+func (h *EventHistory) Record(cluster, eventType string, res *LightResource) {
+	hash := stateHash(res)
+	id := cluster + "|" + res.ID
+	key := res.Kind + "/" + res.Namespace + "/" + res.Name
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry, ok := h.resources[id]
+	if !ok {
+		if len(h.resources) >= historyMaxResources {
+			h.evictOldest()
+		}
+		entry = &resourceHistory{cluster: cluster, key: key}
+		h.resources[id] = entry
+		h.index[cluster+"|"+key] = id
+	}
+	// New connections start with synthetic ADDED events for existing objects,
+	// which are not transitions
+	if n := len(entry.events); n > 0 && entry.events[n-1].hash == hash &&
+		(entry.events[n-1].Type == eventType || eventType == "ADDED") {
+		return
+	}
+
+	entry.events = append(entry.events, EventTransition{
+		Type:     eventType,
+		Time:     time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		Resource: res,
+		hash:     hash,
+	})
+	if len(entry.events) > historyPerResource {
+		entry.events = entry.events[len(entry.events)-historyPerResource:]
+	}
+	entry.updated = time.Now()
+}
+
+// Recent returns up to n transitions, oldest first, for the resource currently
+// known under kind/namespace/name, as seen with the credential of cluster.
+func (h *EventHistory) Recent(cluster, kind, namespace, name string, n int) []EventTransition {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	id, ok := h.index[cluster+"|"+kind+"/"+namespace+"/"+name]
+	if !ok {
+		return nil
+	}
+	entry := h.resources[id]
+	if entry == nil {
+		return nil
+	}
+	events := entry.events
+	if n > 0 && len(events) > n {
+		events = events[len(events)-n:]
+	}
+	result := make([]EventTransition, len(events))
+	copy(result, events)
+	return result
+}
+
+func (h *EventHistory) evictOldest() {
+	var oldestID string
+	var oldest time.Time
+	for id, entry := range h.resources {
+		if oldestID == "" || entry.updated.Before(oldest) {
+			oldestID, oldest = id, entry.updated
+		}
+	}
+	if entry, ok := h.resources[oldestID]; ok {
+		if h.index[entry.cluster+"|"+entry.key] == oldestID {
+			delete(h.index, entry.cluster+"|"+entry.key)
+		}
+		delete(h.resources, oldestID)
+	}
+}
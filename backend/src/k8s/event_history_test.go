@@ -0,0 +1,49 @@
+package k8s
+
+import (
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestEventHistoryReplayKeying(t *testing.T) {
+	h := &EventHistory{
+		resources: make(map[string]*resourceHistory),
+		index:     make(map[string]string),
+	}
+	watcher := &rest.Config{Host: "https://cluster-a", BearerToken: "token-a"}
+	res := &LightResource{ID: "uid-1", Kind: "Secret", Namespace: "team-a", Name: "db", Status: "Active"}
+	h.Record(credentialKey(watcher), "ADDED", res)
+
+	tests := []struct {
+		name   string
+		caller *rest.Config
+		want   int
+	}{
+		{"same credential", &rest.Config{Host: "https://cluster-a", BearerToken: "token-a"}, 1},
+		{"other credential", &rest.Config{Host: "https://cluster-a", BearerToken: "token-b"}, 0},
+		{"no credential", &rest.Config{Host: "https://cluster-a"}, 0},
+		{"other cluster", &rest.Config{Host: "https://cluster-b", BearerToken: "token-a"}, 0},
+	}
+	for _, tt := range tests {
+		if got := h.Recent(credentialKey(tt.caller), "Secret", "team-a", "db", 10); len(got) != tt.want {
+			t.Errorf("%s: replayed %d transitions, want %d", tt.name, len(got), tt.want)
+		}
+	}
+}
+
+func TestEventHistoryDeduplicatesStates(t *testing.T) {
+	h := &EventHistory{
+		resources: make(map[string]*resourceHistory),
+		index:     make(map[string]string),
+	}
+	key := credentialKey(&rest.Config{Host: "https://cluster-a", BearerToken: "token-a"})
+	res := &LightResource{ID: "uid-1", Kind: "Pod", Namespace: "a", Name: "p", Status: "Running"}
+	h.Record(key, "ADDED", res)
+	h.Record(key, "ADDED", res)
+	h.Record(key, "MODIFIED", &LightResource{ID: "uid-1", Kind: "Pod", Namespace: "a", Name: "p", Status: "Failed"})
+
+	if got := h.Recent(key, "Pod", "a", "p", 0); len(got) != 2 {
+		t.Errorf("recorded %d transitions, want 2", len(got))
+	}
+}
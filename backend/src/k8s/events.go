@@ -0,0 +1,262 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	defaultEventWindow = 15 * time.Minute
+	// A group is highlighted once it reaches this many events in the window and
+	// grew by at least eventSurgeRatio (or is new)
+	eventHighlightMin = 5
+	eventSurgeRatio   = 3.0
+)
+
+// EventSummaryGroup aggregates Warning events sharing a reason and involved kind
+type EventSummaryGroup struct {
+	Reason     string   `json:"reason"`
+	Kind       string   `json:"kind"`
+	Count      int      `json:"count"`    // current window
+	Previous   int      `json:"previous"` // window before it
+	Delta      int      `json:"delta"`
+	Ratio      float64  `json:"ratio,omitempty"` // count/previous, 0 when previous is 0
+	Trend      string   `json:"trend"`           // new, up, down, steady
+	Objects    int      `json:"objects"`         // distinct involved objects
+	Namespaces []string `json:"namespaces"`
+	Message    string   `json:"message"` // most recent message
+	LastSeen   string   `json:"lastSeen"`
+
+	current, previous float64
+	objects           map[string]bool
+	namespaces        map[string]bool
+	last              time.Time
+}
+
+// EventSummaryResponse is served by /api/events/summary
+type EventSummaryResponse struct {
+	GeneratedAt string              `json:"generatedAt"`
+	Window      string              `json:"window"`
+	Total       int                 `json:"total"`
+	Previous    int                 `json:"previous"`
+	Groups      []EventSummaryGroup `json:"groups"`
+	Highlights  []string            `json:"highlights"`
+}
+
+// eventSpan returns when an event was first and last observed and how many
+// times it occurred, covering both core/v1 fields and event series
+func eventSpan(ev *corev1.Event) (first, last time.Time, count float64) {
+	first, last = ev.FirstTimestamp.Time, ev.LastTimestamp.Time
+	if first.IsZero() {
+		first = ev.EventTime.Time
+	}
+	if ev.Series != nil && !ev.Series.LastObservedTime.IsZero() {
+		last = ev.Series.LastObservedTime.Time
+	}
+	if last.IsZero() {
+		last = first
+	}
+	if first.IsZero() || first.After(last) {
+		first = last
+	}
+
+	count = float64(ev.Count)
+	if ev.Series != nil && ev.Series.Count > 0 {
+		count = float64(ev.Series.Count)
+	}
+	if count < 1 {
+		count = 1
+	}
+	return first, last, count
+}
+
+// occurrencesBetween estimates how many of an event's occurrences fall in
+// [from, to). Events only keep their first/last time and a count, so repeats
+// are assumed to be spread evenly between the two.
+func occurrencesBetween(first, last time.Time, count float64, from, to time.Time) float64 {
+	if last.Before(from) || !first.Before(to) {
+		return 0
+	}
+	span := last.Sub(first)
+	if span <= 0 {
+		return count
+	}
+	start, end := first, last
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	return count * float64(end.Sub(start)) / float64(span)
+}
+
+// shortDuration formats whole minutes and hours without trailing zero units
+// ("15m" rather than "15m0s")
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// HandleEventSummary serves /api/events/summary. Warning events are grouped by
+// reason and involved kind, counted over the last ?window= (default 15m) and
+// compared with the window before it. ?namespace= narrows the summary.
+func HandleEventSummary(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	window := defaultEventWindow
+	if v := r.URL.Query().Get("window"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "window")
+			return
+		}
+		window = d
+	}
+
+	namespace := r.URL.Query().Get("namespace")
+	namespaces := []string{namespace}
+	if namespace != "" {
+		if !auth.RequireNamespace(w, r, namespace) {
+			return
+		}
+	} else if scoped := auth.ScopeFromContext(r.Context()).NamespaceList(); scoped != nil {
+		namespaces = scoped
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		i18n.Error(w, r, http.StatusInternalServerError, "error.clientFailed")
+		return
+	}
+
+	var events []corev1.Event
+	for _, ns := range namespaces {
+		list, err := clientset.CoreV1().Events(ns).List(r.Context(), metav1.ListOptions{FieldSelector: "type=" + corev1.EventTypeWarning})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		events = append(events, list.Items...)
+	}
+
+	now := time.Now()
+	currentStart, previousStart := now.Add(-window), now.Add(-2*window)
+
+	groups := make(map[string]*EventSummaryGroup)
+	for i := range events {
+		ev := &events[i]
+		first, last, count := eventSpan(ev)
+		current := occurrencesBetween(first, last, count, currentStart, now.Add(time.Second))
+		previous := occurrencesBetween(first, last, count, previousStart, currentStart)
+		if current == 0 && previous == 0 {
+			continue
+		}
+
+		key := ev.Reason + "|" + ev.InvolvedObject.Kind
+		g, ok := groups[key]
+		if !ok {
+			g = &EventSummaryGroup{
+				Reason:     ev.Reason,
+				Kind:       ev.InvolvedObject.Kind,
+				objects:    make(map[string]bool),
+				namespaces: make(map[string]bool),
+			}
+			groups[key] = g
+		}
+		g.current += current
+		g.previous += previous
+		if current > 0 {
+			g.objects[ev.InvolvedObject.Namespace+"/"+ev.InvolvedObject.Name] = true
+			if ev.InvolvedObject.Namespace != "" {
+				g.namespaces[ev.InvolvedObject.Namespace] = true
+			}
+		}
+		if last.After(g.last) {
+			g.last = last
+			g.Message = ev.Message
+		}
+	}
+
+	response := EventSummaryResponse{
+		GeneratedAt: now.UTC().Format("2006-01-02T15:04:05Z"),
+		Window:      shortDuration(window),
+		Groups:      []EventSummaryGroup{},
+		Highlights:  []string{},
+	}
+	for _, g := range groups {
+		g.Count = int(math.Round(g.current))
+		g.Previous = int(math.Round(g.previous))
+		if g.Count == 0 && g.Previous == 0 {
+			continue
+		}
+		g.Delta = g.Count - g.Previous
+		g.Objects = len(g.objects)
+		g.Namespaces = make([]string, 0, len(g.namespaces))
+		for ns := range g.namespaces {
+			g.Namespaces = append(g.Namespaces, ns)
+		}
+		sort.Strings(g.Namespaces)
+		g.LastSeen = g.last.UTC().Format("2006-01-02T15:04:05Z")
+
+		switch {
+		case g.Previous == 0:
+			g.Trend = "new"
+		case g.Count > g.Previous:
+			g.Trend = "up"
+		case g.Count < g.Previous:
+			g.Trend = "down"
+		default:
+			g.Trend = "steady"
+		}
+		if g.Previous > 0 {
+			g.Ratio = math.Round(float64(g.Count)/float64(g.Previous)*10) / 10
+		}
+
+		response.Total += g.Count
+		response.Previous += g.Previous
+		response.Groups = append(response.Groups, *g)
+	}
+
+	sort.Slice(response.Groups, func(i, j int) bool {
+		a, b := response.Groups[i], response.Groups[j]
+		if a.Delta != b.Delta {
+			return a.Delta > b.Delta
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Reason+a.Kind < b.Reason+b.Kind
+	})
+
+	for _, g := range response.Groups {
+		if g.Count < eventHighlightMin {
+			continue
+		}
+		switch {
+		case g.Trend == "new":
+			response.Highlights = append(response.Highlights, fmt.Sprintf("%d new %s events on %s in the last %s", g.Count, g.Reason, g.Kind, response.Window))
+		case g.Ratio >= eventSurgeRatio:
+			response.Highlights = append(response.Highlights, fmt.Sprintf("%s events on %s up %gx in the last %s", g.Reason, g.Kind, g.Ratio, response.Window))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
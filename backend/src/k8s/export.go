@@ -0,0 +1,154 @@
+package k8s
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	"k8s.io/client-go/rest"
+)
+
+// ExportRow is one line of a resource inventory export
+type ExportRow struct {
+	Kind      string            `json:"kind"`
+	Namespace string            `json:"namespace"`
+	Name      string            `json:"name"`
+	Status    string            `json:"status"`
+	Health    string            `json:"health"`
+	CreatedAt string            `json:"createdAt"`
+	Age       string            `json:"age"`
+	Labels    map[string]string `json:"labels"`
+	Owner     string            `json:"owner"` // Kind/name of the first owner
+}
+
+var exportColumns = []string{"kind", "namespace", "name", "status", "health", "createdAt", "age", "labels", "owner"}
+
+// resourceAge formats an age the way kubectl get does
+func resourceAge(created string, now time.Time) string {
+	t, err := time.Parse("2006-01-02T15:04:05Z", created)
+	if err != nil {
+		return ""
+	}
+	d := now.Sub(t)
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%ds", int(max(d.Seconds(), 0)))
+}
+
+// formatLabels renders labels as a sorted k=v list, like a label selector
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// exportRows selects and flattens resources for an inventory. Kinds and
+// namespaces are comma-separated lists; empty means all.
+func exportRows(resources []LightResource, kinds, namespaces, health string) []ExportRow {
+	byID := make(map[string]*LightResource, len(resources))
+	for i := range resources {
+		byID[resources[i].ID] = &resources[i]
+	}
+	matches := func(list, value string) bool {
+		if list == "" {
+			return true
+		}
+		for _, v := range strings.Split(list, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	now := time.Now()
+	rows := []ExportRow{}
+	for i := range resources {
+		res := &resources[i]
+		if !matches(kinds, res.Kind) || !matches(namespaces, res.Namespace) || !matches(health, res.Health) {
+			continue
+		}
+		row := ExportRow{
+			Kind:      res.Kind,
+			Namespace: res.Namespace,
+			Name:      res.Name,
+			Status:    res.Status,
+			Health:    res.Health,
+			CreatedAt: res.CreationTimestamp,
+			Age:       resourceAge(res.CreationTimestamp, now),
+			Labels:    res.Labels,
+		}
+		if len(res.OwnerRefs) > 0 {
+			if owner, ok := byID[res.OwnerRefs[0]]; ok {
+				row.Owner = owner.Kind + "/" + owner.Name
+			}
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return rows
+}
+
+// HandleExport serves /api/resources/export?kind=&namespace=&health=&format=
+// as a downloadable inventory table. format is csv (default) or json; the
+// filters take comma-separated values.
+func HandleExport(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		i18n.Error(w, r, http.StatusBadRequest, "error.mustBeOneOf", "format", "csv, json")
+		return
+	}
+
+	graph, _, err := cachedGraph(config)
+	if graph == nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resources, _ := filterByScope(graph.Resources, nil, auth.ScopeFromContext(r.Context()))
+	rows := exportRows(resources, q.Get("kind"), q.Get("namespace"), q.Get("health"))
+
+	filename := fmt.Sprintf("anakosmos-inventory-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rows)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	out := csv.NewWriter(w)
+	out.Write(exportColumns)
+	for _, row := range rows {
+		out.Write([]string{row.Kind, row.Namespace, row.Name, row.Status, row.Health, row.CreatedAt, row.Age, formatLabels(row.Labels), row.Owner})
+	}
+	out.Flush()
+}
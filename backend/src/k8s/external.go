@@ -0,0 +1,245 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+)
+
+// appendHost adds a hostname once, ignoring empty ones
+func appendHost(hosts []string, host string) []string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" || containsString(hosts, host) {
+		return hosts
+	}
+	return append(hosts, host)
+}
+
+// applyHTTPRouteStatus fills a Gateway API HTTPRoute's hostnames, Service
+// backends and health from the conditions its parent Gateways report
+func applyHTTPRouteStatus(obj *unstructured.Unstructured, res *LightResource) {
+	hostnames, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "hostnames")
+	for _, host := range hostnames {
+		res.ExternalHosts = appendHost(res.ExternalHosts, host)
+	}
+
+	rules, _, _ := unstructured.NestedSlice(obj.Object, "spec", "rules")
+	for _, rule := range rules {
+		m, _ := rule.(map[string]interface{})
+		refs, _, _ := unstructured.NestedSlice(m, "backendRefs")
+		for _, ref := range refs {
+			backend, _ := ref.(map[string]interface{})
+			kind, _, _ := unstructured.NestedString(backend, "kind")
+			namespace, _, _ := unstructured.NestedString(backend, "namespace")
+			name, _, _ := unstructured.NestedString(backend, "name")
+			// Cross-namespace backends need a ReferenceGrant; only same
+			// namespace Services are linked
+			if name == "" || (kind != "" && kind != "Service") || (namespace != "" && namespace != res.Namespace) {
+				continue
+			}
+			res.IngressBackends = append(res.IngressBackends, IngressBackend{ServiceName: name})
+		}
+	}
+
+	res.Status = "Pending"
+	res.Health = "warning"
+	parents, _, _ := unstructured.NestedSlice(obj.Object, "status", "parents")
+	for _, parent := range parents {
+		p, _ := parent.(map[string]interface{})
+		conditions, _, _ := unstructured.NestedSlice(p, "conditions")
+		for _, c := range conditions {
+			cond, _ := c.(map[string]interface{})
+			condType, _, _ := unstructured.NestedString(cond, "type")
+			status, _, _ := unstructured.NestedString(cond, "status")
+			switch {
+			case condType == "Accepted" && status == "True" && res.Status == "Pending":
+				res.Status = "Accepted"
+				res.Health = "ok"
+			case condType == "Accepted" && status == "False":
+				res.Status = "NotAccepted"
+				res.Health = "error"
+			case condType == "ResolvedRefs" && status == "False" && res.Health != "error":
+				res.Status = "UnresolvedRefs"
+				res.Health = "warning"
+			}
+		}
+	}
+}
+
+// externalEndpoints turns the ExternalHosts of resources into ExternalEndpoint
+// nodes, one per namespace and hostname, linked from every resource
+// referencing them. Like HelmReleases they have no Kubernetes object and get a
+// synthetic ID.
+func externalEndpoints(resources []LightResource) ([]LightResource, []ClusterLink) {
+	var endpoints []LightResource
+	var links []ClusterLink
+	seen := make(map[string]bool)
+	for i := range resources {
+		res := &resources[i]
+		for _, host := range res.ExternalHosts {
+			id := "external-" + res.Namespace + "-" + host
+			if !seen[id] {
+				seen[id] = true
+				endpoints = append(endpoints, LightResource{
+					ID:        id,
+					Name:      host,
+					Namespace: res.Namespace,
+					Kind:      "ExternalEndpoint",
+					Status:    "External",
+					Health:    "ok",
+					Labels:    map[string]string{},
+					OwnerRefs: []string{},
+				})
+			}
+			links = append(links, ClusterLink{Source: res.ID, Target: id, Type: "network"})
+		}
+	}
+	return endpoints, links
+}
+
+// HostResolution is the outcome of resolving one external hostname
+type HostResolution struct {
+	Host      string   `json:"host"`
+	Addresses []string `json:"addresses"`
+	CNAME     string   `json:"cname,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	// Wildcard hosts (*.example.com) aren't resolved
+	Wildcard  bool   `json:"wildcard,omitempty"`
+	CheckedAt string `json:"checkedAt"`
+}
+
+const (
+	// maxResolveHosts caps the hostnames resolved per request
+	maxResolveHosts = 50
+	// maxResolveCache caps the cached resolutions
+	maxResolveCache = 1000
+	// resolveCacheTTL is how long a resolution is reused
+	resolveCacheTTL = time.Minute
+)
+
+type cachedResolution struct {
+	result  HostResolution
+	checked time.Time
+}
+
+var resolveCache = struct {
+	sync.Mutex
+	results map[string]cachedResolution
+}{results: make(map[string]cachedResolution)}
+
+// storeResolution caches a resolution. A full cache first drops expired
+// entries, then the oldest one.
+func storeResolution(host string, result HostResolution, now time.Time) {
+	resolveCache.Lock()
+	defer resolveCache.Unlock()
+	if _, ok := resolveCache.results[host]; !ok && len(resolveCache.results) >= maxResolveCache {
+		oldest := ""
+		for h, c := range resolveCache.results {
+			if now.Sub(c.checked) >= resolveCacheTTL {
+				delete(resolveCache.results, h)
+			} else if oldest == "" || c.checked.Before(resolveCache.results[oldest].checked) {
+				oldest = h
+			}
+		}
+		if len(resolveCache.results) >= maxResolveCache {
+			delete(resolveCache.results, oldest)
+		}
+	}
+	resolveCache.results[host] = cachedResolution{result: result, checked: now}
+}
+
+func resolveHost(ctx context.Context, host string) HostResolution {
+	resolveCache.Lock()
+	cached, ok := resolveCache.results[host]
+	resolveCache.Unlock()
+	if ok && time.Since(cached.checked) < resolveCacheTTL {
+		return cached.result
+	}
+
+	result := HostResolution{Host: host, Addresses: []string{}, CheckedAt: time.Now().UTC().Format(time.RFC3339)}
+	if strings.HasPrefix(host, "*.") {
+		result.Wildcard = true
+		return result
+	}
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	addresses, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		sort.Strings(addresses)
+		result.Addresses = addresses
+		if cname, err := net.DefaultResolver.LookupCNAME(ctx, host); err == nil {
+			if cname = strings.TrimSuffix(cname, "."); cname != host {
+				result.CNAME = cname
+			}
+		}
+	}
+
+	storeResolution(host, result, time.Now())
+	return result
+}
+
+// HandleResolveHosts serves /api/external/resolve?host=a&host=b: DNS
+// resolution of external endpoint hostnames, as seen from the anakosmos
+// backend. Only hostnames of ExternalEndpoints in the caller's view of the
+// cached graph are resolved. Results are cached for a minute.
+func HandleResolveHosts(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	var hosts []string
+	for _, host := range r.URL.Query()["host"] {
+		hosts = appendHost(hosts, host)
+	}
+	if len(hosts) == 0 {
+		i18n.Error(w, r, http.StatusBadRequest, "error.required", "host")
+		return
+	}
+	if len(hosts) > maxResolveHosts {
+		i18n.Error(w, r, http.StatusBadRequest, "error.tooMany", "hosts")
+		return
+	}
+
+	graph, _, err := cachedGraph(config)
+	if graph == nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	scope := auth.ScopeFromContext(r.Context())
+	referenced := make(map[string]bool)
+	for i := range graph.Resources {
+		res := &graph.Resources[i]
+		if res.Kind == "ExternalEndpoint" && resourceAllowed(scope, res) {
+			referenced[res.Name] = true
+		}
+	}
+	for _, host := range hosts {
+		if !referenced[host] {
+			i18n.Error(w, r, http.StatusForbidden, "error.hostNotReferenced", host)
+			return
+		}
+	}
+
+	results := make([]HostResolution, len(hosts))
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = resolveHost(r.Context(), host)
+		}()
+	}
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,169 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anakosmos/backend/src/settings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// maxExtraFieldLength caps an extracted value, e.g. a list of conditions
+const maxExtraFieldLength = 256
+
+type printerColumn struct {
+	name, jsonPath string
+}
+
+// printerColumns holds the additionalPrinterColumns of the CRDs seen so far
+// by group/Kind. CRDs define the same columns wherever they are installed, so
+// one table serves every cluster.
+var printerColumns = struct {
+	sync.RWMutex
+	byKind   map[string][]printerColumn
+	loadedAt map[string]time.Time // cluster host -> last full listing
+}{byKind: make(map[string][]printerColumn), loadedAt: make(map[string]time.Time)}
+
+// crdPrinterColumns returns the group/Kind of a CRD and the printer columns
+// of its storage version. Age is left out: every resource shows its age.
+func crdPrinterColumns(crd *unstructured.Unstructured) (string, []printerColumn) {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	var columns []printerColumn
+	for _, v := range versions {
+		m, _ := v.(map[string]interface{})
+		if storage, _, _ := unstructured.NestedBool(m, "storage"); !storage {
+			continue
+		}
+		defs, _, _ := unstructured.NestedSlice(m, "additionalPrinterColumns")
+		for _, d := range defs {
+			def, _ := d.(map[string]interface{})
+			name, _, _ := unstructured.NestedString(def, "name")
+			path, _, _ := unstructured.NestedString(def, "jsonPath")
+			// Priority > 0 columns are only shown by kubectl -o wide
+			priority, _, _ := unstructured.NestedInt64(def, "priority")
+			if name == "" || path == "" || priority > 0 || path == ".metadata.creationTimestamp" {
+				continue
+			}
+			columns = append(columns, printerColumn{name: name, jsonPath: path})
+		}
+	}
+	return group + "/" + kind, columns
+}
+
+// setPrinterColumns records the printer columns of an added or changed CRD
+func setPrinterColumns(crd *unstructured.Unstructured) {
+	key, columns := crdPrinterColumns(crd)
+	printerColumns.Lock()
+	defer printerColumns.Unlock()
+	if len(columns) == 0 {
+		delete(printerColumns.byKind, key)
+		return
+	}
+	printerColumns.byKind[key] = columns
+}
+
+// deletePrinterColumns forgets the printer columns of a removed CRD
+func deletePrinterColumns(crd *unstructured.Unstructured) {
+	key, _ := crdPrinterColumns(crd)
+	printerColumns.Lock()
+	defer printerColumns.Unlock()
+	delete(printerColumns.byKind, key)
+}
+
+// loadPrinterColumns lists the CRDs of a cluster for their printer columns,
+// at most once per discoveryTTL; watches keep them current in between
+func loadPrinterColumns(ctx context.Context, client dynamic.Interface, host string) {
+	printerColumns.RLock()
+	fresh := time.Since(printerColumns.loadedAt[host]) < discoveryTTL
+	printerColumns.RUnlock()
+	if fresh {
+		return
+	}
+	gvr := schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+	list, err := client.Resource(gvr).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		log.Printf("CRD printer columns not available: %v", err)
+		return
+	}
+	for i := range list.Items {
+		setPrinterColumns(&list.Items[i])
+	}
+	printerColumns.Lock()
+	printerColumns.loadedAt[host] = time.Now()
+	printerColumns.Unlock()
+}
+
+// objectExtraFields extracts the printer columns of a CRD object and the
+// display fields configured for its kind. kind is the graph kind, which may
+// differ from the API kind (FluxHelmRelease); fields may name either.
+func objectExtraFields(obj map[string]interface{}, groupKind schema.GroupKind, kind string) map[string]string {
+	printerColumns.RLock()
+	columns := printerColumns.byKind[groupKind.Group+"/"+groupKind.Kind]
+	printerColumns.RUnlock()
+
+	fields := settings.Current().DisplayFields(kind)
+	if groupKind.Kind != "" && groupKind.Kind != kind {
+		fields = append(fields, settings.Current().DisplayFields(groupKind.Kind)...)
+	}
+	if len(columns) == 0 && len(fields) == 0 {
+		return nil
+	}
+
+	values := make(map[string]string)
+	for _, c := range columns {
+		if v := evalJSONPath(c.name, c.jsonPath, obj); v != "" {
+			values[c.name] = v
+		}
+	}
+	// Configured fields win over the CRD's own columns
+	for _, f := range fields {
+		if v := evalJSONPath(f.Name, f.JSONPath, obj); v != "" {
+			values[f.Label] = v
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// typedExtraFields extracts the display fields configured for a built-in
+// kind. The object is only converted when the kind has fields.
+func typedExtraFields(obj metav1.Object, kind string) map[string]string {
+	if len(settings.Current().DisplayFields(kind)) == 0 {
+		return nil
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil
+	}
+	return objectExtraFields(content, schema.GroupKind{Kind: kind}, kind)
+}
+
+func evalJSONPath(name, path string, obj map[string]interface{}) string {
+	j := jsonpath.New(name).AllowMissingKeys(true)
+	if err := j.Parse(settings.RelaxedJSONPath(path)); err != nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := j.Execute(&buf, obj); err != nil {
+		return ""
+	}
+	value := strings.TrimSpace(buf.String())
+	if len(value) > maxExtraFieldLength {
+		value = value[:maxExtraFieldLength] + "…"
+	}
+	return value
+}
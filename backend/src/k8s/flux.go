@@ -0,0 +1,96 @@
+package k8s
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// fluxKinds are the Flux sources and the reconcilers using them. Flux
+// HelmReleases are "FluxHelmRelease" in the graph, as "HelmRelease" stands for
+// the Helm releases themselves.
+var fluxKinds = []versionedKind{
+	{"GitRepository", "source.toolkit.fluxcd.io", "gitrepositories", []string{"v1", "v1beta2"}},
+	{"HelmRepository", "source.toolkit.fluxcd.io", "helmrepositories", []string{"v1", "v1beta2"}},
+	{"Kustomization", "kustomize.toolkit.fluxcd.io", "kustomizations", []string{"v1", "v1beta2"}},
+	{"FluxHelmRelease", "helm.toolkit.fluxcd.io", "helmreleases", []string{"v2", "v2beta2", "v2beta1"}},
+}
+
+// FluxInfo summarizes a Flux source or reconciler. Kustomizations and
+// HelmReleases link to the source they are built from.
+type FluxInfo struct {
+	// SourceKind and SourceName (namespace/name) are the source of a
+	// Kustomization or HelmRelease
+	SourceKind string `json:"sourceKind,omitempty"`
+	SourceName string `json:"sourceName,omitempty"`
+	// Path is the directory a Kustomization applies, Chart a HelmRelease's chart
+	Path  string `json:"path,omitempty"`
+	Chart string `json:"chart,omitempty"`
+	// URL is a source's repository
+	URL string `json:"url,omitempty"`
+	// Revision is the last applied revision, or a source's fetched artifact
+	Revision  string `json:"revision,omitempty"`
+	Suspended bool   `json:"suspended,omitempty"`
+	// Message is the Ready condition's message
+	Message string `json:"message,omitempty"`
+}
+
+// applyFluxStatus fills a Flux object's source, revision and health. Like
+// every Flux kind it reports through the Ready and Reconciling conditions:
+// Ready is ok, a failed Ready an error, and reconciling or suspended a
+// warning.
+func applyFluxStatus(obj *unstructured.Unstructured, res *LightResource) {
+	info := &FluxInfo{}
+	sourceRef := []string{"spec", "sourceRef"}
+	if res.Kind == "FluxHelmRelease" {
+		sourceRef = []string{"spec", "chart", "spec", "sourceRef"}
+		info.Chart, _, _ = unstructured.NestedString(obj.Object, "spec", "chart", "spec", "chart")
+		if info.Chart == "" {
+			// Charts from an OCIRepository or HelmChart
+			if ref, found, _ := unstructured.NestedString(obj.Object, "spec", "chartRef", "name"); found {
+				sourceRef = []string{"spec", "chartRef"}
+				info.Chart = ref
+			}
+		}
+	}
+	if kind, _, _ := unstructured.NestedString(obj.Object, append(sourceRef, "kind")...); kind != "" {
+		name, _, _ := unstructured.NestedString(obj.Object, append(sourceRef, "name")...)
+		namespace, _, _ := unstructured.NestedString(obj.Object, append(sourceRef, "namespace")...)
+		if namespace == "" {
+			namespace = obj.GetNamespace()
+		}
+		info.SourceKind, info.SourceName = kind, namespace+"/"+name
+	}
+	info.Path, _, _ = unstructured.NestedString(obj.Object, "spec", "path")
+	info.URL, _, _ = unstructured.NestedString(obj.Object, "spec", "url")
+	info.Revision, _, _ = unstructured.NestedString(obj.Object, "status", "lastAppliedRevision")
+	if info.Revision == "" {
+		info.Revision, _, _ = unstructured.NestedString(obj.Object, "status", "artifact", "revision")
+	}
+	info.Suspended, _, _ = unstructured.NestedBool(obj.Object, "spec", "suspend")
+	res.Flux = info
+
+	res.Status = "Pending"
+	res.Health = "warning"
+	reconciling := false
+	for _, cond := range objectConditions(obj) {
+		switch cond.condType {
+		case "Ready":
+			info.Message = cond.message
+			if cond.status == "True" {
+				res.Status, res.Health = "Ready", "ok"
+			} else if cond.status == "False" {
+				res.Status, res.Health = "NotReady", "error"
+				if cond.reason != "" {
+					res.Status = cond.reason
+				}
+			}
+		case "Reconciling":
+			reconciling = cond.status == "True"
+		}
+	}
+	switch {
+	case info.Suspended:
+		res.Status, res.Health = "Suspended", "warning"
+	case reconciling && res.Health != "error":
+		res.Status, res.Health = "Progressing", "warning"
+	}
+}
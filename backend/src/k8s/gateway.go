@@ -0,0 +1,136 @@
+package k8s
+
+import (
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const gatewayAPIGroup = "gateway.networking.k8s.io"
+
+// GatewayInfo summarizes a Gateway API Gateway or GatewayClass. Gateways link
+// to the HTTPRoutes attached to them and to their GatewayClass.
+type GatewayInfo struct {
+	// ClassName is the GatewayClass of a Gateway
+	ClassName string `json:"className,omitempty"`
+	// Controller is the controllerName of a GatewayClass
+	Controller string `json:"controller,omitempty"`
+	// Listeners are "name protocol/port hostname" lines of a Gateway
+	Listeners []string `json:"listeners,omitempty"`
+	// Addresses are the addresses a Gateway is reachable at
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// applyGatewayStatus fills a Gateway's listeners, addresses and health from
+// its Programmed and Accepted conditions. Listener hostnames become external
+// hosts, like those of Ingresses.
+func applyGatewayStatus(obj *unstructured.Unstructured, res *LightResource) {
+	info := &GatewayInfo{}
+	info.ClassName, _, _ = unstructured.NestedString(obj.Object, "spec", "gatewayClassName")
+	listeners, _, _ := unstructured.NestedSlice(obj.Object, "spec", "listeners")
+	for _, l := range listeners {
+		m, _ := l.(map[string]interface{})
+		name, _, _ := unstructured.NestedString(m, "name")
+		protocol, _, _ := unstructured.NestedString(m, "protocol")
+		port, _, _ := unstructured.NestedInt64(m, "port")
+		hostname, _, _ := unstructured.NestedString(m, "hostname")
+		line := name + " " + protocol + "/" + strconv.FormatInt(port, 10)
+		if hostname != "" {
+			line += " " + hostname
+			res.ExternalHosts = appendHost(res.ExternalHosts, hostname)
+		}
+		info.Listeners = append(info.Listeners, line)
+	}
+	addresses, _, _ := unstructured.NestedSlice(obj.Object, "status", "addresses")
+	for _, a := range addresses {
+		m, _ := a.(map[string]interface{})
+		if value, _, _ := unstructured.NestedString(m, "value"); value != "" {
+			info.Addresses = append(info.Addresses, value)
+		}
+	}
+	res.Gateway = info
+
+	res.Status = "Pending"
+	res.Health = "warning"
+	for _, cond := range objectConditions(obj) {
+		switch {
+		case cond.condType == "Programmed" && cond.status == "True":
+			res.Status = "Programmed"
+			res.Health = "ok"
+		case cond.condType == "Programmed" && cond.status == "False":
+			res.Status = "NotProgrammed"
+			res.Health = "error"
+		case cond.condType == "Accepted" && cond.status == "False":
+			res.Status = "NotAccepted"
+			res.Health = "error"
+		}
+		if res.Health == "error" {
+			break
+		}
+	}
+}
+
+// applyGatewayClassStatus fills a GatewayClass's controller and whether the
+// controller accepted it
+func applyGatewayClassStatus(obj *unstructured.Unstructured, res *LightResource) {
+	info := &GatewayInfo{}
+	info.Controller, _, _ = unstructured.NestedString(obj.Object, "spec", "controllerName")
+	res.Gateway = info
+
+	res.Status = "Pending"
+	res.Health = "warning"
+	for _, cond := range objectConditions(obj) {
+		if cond.condType != "Accepted" {
+			continue
+		}
+		if cond.status == "True" {
+			res.Status, res.Health = "Accepted", "ok"
+		} else if cond.status == "False" {
+			res.Status, res.Health = "NotAccepted", "error"
+		}
+	}
+}
+
+type objectCondition struct {
+	condType, status, reason, message string
+}
+
+func objectConditions(obj *unstructured.Unstructured) []objectCondition {
+	var result []objectCondition
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		m, _ := c.(map[string]interface{})
+		condType, _, _ := unstructured.NestedString(m, "type")
+		status, _, _ := unstructured.NestedString(m, "status")
+		reason, _, _ := unstructured.NestedString(m, "reason")
+		message, _, _ := unstructured.NestedString(m, "message")
+		result = append(result, objectCondition{condType: condType, status: status, reason: reason, message: message})
+	}
+	return result
+}
+
+// routeParentGateways returns the namespace/name of the Gateways an HTTPRoute
+// attaches to through its parentRefs
+func routeParentGateways(obj *unstructured.Unstructured) []string {
+	var gateways []string
+	refs, _, _ := unstructured.NestedSlice(obj.Object, "spec", "parentRefs")
+	for _, ref := range refs {
+		m, _ := ref.(map[string]interface{})
+		group, found, _ := unstructured.NestedString(m, "group")
+		if found && group != gatewayAPIGroup {
+			continue
+		}
+		if kind, found, _ := unstructured.NestedString(m, "kind"); found && kind != "Gateway" {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(m, "name")
+		namespace, _, _ := unstructured.NestedString(m, "namespace")
+		if namespace == "" {
+			namespace = obj.GetNamespace()
+		}
+		if key := namespace + "/" + name; name != "" && !containsString(gateways, key) {
+			gateways = append(gateways, key)
+		}
+	}
+	return gateways
+}
@@ -0,0 +1,99 @@
+package k8s
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+// graphCache holds a periodically refreshed snapshot of the local cluster graph
+// for read-only consumers (public status, metrics) that must not list the
+// cluster on every request.
+type graphCache struct {
+	config *rest.Config
+	ttl    time.Duration
+
+	mu        sync.Mutex
+	graph     *InitResponse
+	fetchedAt time.Time
+}
+
+func newGraphCache(config *rest.Config, ttl time.Duration) *graphCache {
+	return &graphCache{config: config, ttl: ttl}
+}
+
+// Get returns the cached graph, rebuilding it when older than the TTL; the
+// graph precomputed from the informers is taken when there is one. The lock
+// is held while refreshing so concurrent misses list the cluster once. On
+// refresh failure the previous snapshot is returned along with the error.
+func (c *graphCache) Get() (*InitResponse, time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.graph != nil && time.Since(c.fetchedAt) <= c.ttl {
+		return c.graph, c.fetchedAt, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if graph, builtAt := precomputedGraph(ctx, c.config); graph != nil {
+		c.graph, c.fetchedAt = graph, builtAt
+		return c.graph, c.fetchedAt, nil
+	}
+	graph, err := BuildGraph(ctx, c.config)
+	if err != nil {
+		return c.graph, c.fetchedAt, err
+	}
+	c.graph = graph
+	c.fetchedAt = time.Now()
+	return c.graph, c.fetchedAt, nil
+}
+
+// sharedGraphTTL bounds how stale the graph behind interactive read endpoints
+// may be; it only saves relisting when several views are opened in a row
+const sharedGraphTTL = 15 * time.Second
+
+// sharedGraphs holds one short-lived graphCache per cluster and credential, so
+// users with different tokens never share a snapshot
+var sharedGraphs = struct {
+	sync.Mutex
+	caches map[string]*sharedGraph
+}{caches: make(map[string]*sharedGraph)}
+
+type sharedGraph struct {
+	cache    *graphCache
+	lastUsed time.Time
+}
+
+// credentialKey identifies the cluster and credential behind config without
+// keeping the token itself
+func credentialKey(config *rest.Config) string {
+	sum := sha256.Sum256([]byte(config.BearerToken))
+	return config.Host + "|" + hex.EncodeToString(sum[:8])
+}
+
+// cachedGraph returns a recent graph of the cluster behind config
+func cachedGraph(config *rest.Config) (*InitResponse, time.Time, error) {
+	key := credentialKey(config)
+
+	sharedGraphs.Lock()
+	entry, ok := sharedGraphs.caches[key]
+	if !ok {
+		// Forget clusters nobody asked about for a while
+		for k, e := range sharedGraphs.caches {
+			if time.Since(e.lastUsed) > 10*sharedGraphTTL {
+				delete(sharedGraphs.caches, k)
+			}
+		}
+		entry = &sharedGraph{cache: newGraphCache(config, sharedGraphTTL)}
+		sharedGraphs.caches[key] = entry
+	}
+	entry.lastUsed = time.Now()
+	sharedGraphs.Unlock()
+
+	return entry.cache.Get()
+}
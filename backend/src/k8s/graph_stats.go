@@ -0,0 +1,152 @@
+package k8s
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/anakosmos/backend/src/auth"
+	"k8s.io/client-go/rest"
+)
+
+// NamespaceSize counts the graph nodes and links of a namespace. A link counts
+// for the namespace of its source.
+type NamespaceSize struct {
+	Namespace string `json:"namespace"`
+	Resources int    `json:"resources"`
+	Links     int    `json:"links"`
+}
+
+// GraphHub is one of the most connected resources
+type GraphHub struct {
+	ImpactResource
+	Degree int `json:"degree"`
+}
+
+// GraphStats is served by /api/cluster/graph-stats
+type GraphStats struct {
+	GeneratedAt string         `json:"generatedAt"`
+	Nodes       int            `json:"nodes"`
+	NodesByKind map[string]int `json:"nodesByKind"`
+	Links       int            `json:"links"`
+	LinksByType map[string]int `json:"linksByType"`
+	// Density is links over the possible undirected pairs, AvgDegree the mean
+	// number of links per node
+	Density   float64 `json:"density"`
+	AvgDegree float64 `json:"avgDegree"`
+	MaxDegree int     `json:"maxDegree"`
+	// Orphans have no link at all
+	Orphans       int              `json:"orphans"`
+	OrphansByKind map[string]int   `json:"orphansByKind"`
+	OrphanSample  []ImpactResource `json:"orphanSample"`
+	Hubs          []GraphHub       `json:"hubs"`
+	// Namespaces, largest first; cluster-scoped resources are under ""
+	Namespaces []NamespaceSize `json:"namespaces"`
+}
+
+const (
+	graphStatsSample = 50
+	graphStatsHubs   = 10
+)
+
+// graphStats summarizes a (scoped) graph
+func graphStats(resources []LightResource, links []ClusterLink) GraphStats {
+	stats := GraphStats{
+		Nodes:         len(resources),
+		NodesByKind:   make(map[string]int),
+		Links:         len(links),
+		LinksByType:   make(map[string]int),
+		OrphansByKind: make(map[string]int),
+		OrphanSample:  []ImpactResource{},
+		Hubs:          []GraphHub{},
+		Namespaces:    []NamespaceSize{},
+	}
+
+	byID := make(map[string]*LightResource, len(resources))
+	namespaces := make(map[string]*NamespaceSize)
+	nsSize := func(ns string) *NamespaceSize {
+		s, ok := namespaces[ns]
+		if !ok {
+			s = &NamespaceSize{Namespace: ns}
+			namespaces[ns] = s
+		}
+		return s
+	}
+	for i := range resources {
+		res := &resources[i]
+		byID[res.ID] = res
+		stats.NodesByKind[res.Kind]++
+		nsSize(res.Namespace).Resources++
+	}
+
+	degree := make(map[string]int, len(resources))
+	for _, l := range links {
+		stats.LinksByType[l.Type]++
+		degree[l.Source]++
+		degree[l.Target]++
+		if src, ok := byID[l.Source]; ok {
+			nsSize(src.Namespace).Links++
+		}
+	}
+
+	for i := range resources {
+		res := &resources[i]
+		d := degree[res.ID]
+		if d == 0 {
+			stats.Orphans++
+			stats.OrphansByKind[res.Kind]++
+			if len(stats.OrphanSample) < graphStatsSample {
+				stats.OrphanSample = append(stats.OrphanSample, impactResource(res))
+			}
+			continue
+		}
+		stats.MaxDegree = max(stats.MaxDegree, d)
+		stats.Hubs = append(stats.Hubs, GraphHub{ImpactResource: impactResource(res), Degree: d})
+	}
+	sort.Slice(stats.Hubs, func(i, j int) bool {
+		if stats.Hubs[i].Degree != stats.Hubs[j].Degree {
+			return stats.Hubs[i].Degree > stats.Hubs[j].Degree
+		}
+		return stats.Hubs[i].ID < stats.Hubs[j].ID
+	})
+	if len(stats.Hubs) > graphStatsHubs {
+		stats.Hubs = stats.Hubs[:graphStatsHubs]
+	}
+
+	if n := float64(stats.Nodes); n > 1 {
+		stats.Density = float64(stats.Links) / (n * (n - 1) / 2)
+	}
+	if stats.Nodes > 0 {
+		stats.AvgDegree = 2 * float64(stats.Links) / float64(stats.Nodes)
+	}
+
+	for _, s := range namespaces {
+		stats.Namespaces = append(stats.Namespaces, *s)
+	}
+	sort.Slice(stats.Namespaces, func(i, j int) bool {
+		a, b := stats.Namespaces[i], stats.Namespaces[j]
+		if a.Resources != b.Resources {
+			return a.Resources > b.Resources
+		}
+		return a.Namespace < b.Namespace
+	})
+	return stats
+}
+
+// HandleGraphStats serves /api/cluster/graph-stats: node and link counts per
+// kind and type, density, orphaned resources, the most connected resources
+// and namespaces by size, computed over the caller's view of the cached graph.
+func HandleGraphStats(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	graph, fetchedAt, err := cachedGraph(config)
+	if graph == nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resources, links := filterByScope(graph.Resources, graph.Links, auth.ScopeFromContext(r.Context()))
+
+	stats := graphStats(resources, links)
+	stats.GeneratedAt = fetchedAt.UTC().Format("2006-01-02T15:04:05Z")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
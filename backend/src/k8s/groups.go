@@ -0,0 +1,140 @@
+package k8s
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	"k8s.io/client-go/rest"
+)
+
+// ResourceGroup is the set of resources sharing a value for the grouping key
+type ResourceGroup struct {
+	Value      string         `json:"value"`
+	Health     string         `json:"health"` // worst member health
+	Counts     HealthCounts   `json:"counts"`
+	Kinds      map[string]int `json:"kinds"`
+	Namespaces []string       `json:"namespaces"`
+	Members    []string       `json:"members"` // resource IDs
+
+	namespaces map[string]bool
+}
+
+// GroupsResponse is served by /api/cluster/groups
+type GroupsResponse struct {
+	By          string          `json:"by"`
+	GeneratedAt string          `json:"generatedAt"`
+	Groups      []ResourceGroup `json:"groups"`
+	// Resources without the key, so views can show them apart
+	Ungrouped *ResourceGroup `json:"ungrouped,omitempty"`
+}
+
+var healthSeverity = map[string]int{"ok": 0, "": 1, "unknown": 1, "warning": 2, "flapping": 3, "error": 4}
+
+// worseHealth returns whichever of the two health values is more severe
+func worseHealth(a, b string) string {
+	if healthSeverity[b] > healthSeverity[a] {
+		return b
+	}
+	return a
+}
+
+func (g *ResourceGroup) add(res *LightResource) {
+	if len(g.Members) == 0 {
+		g.Health = res.Health
+	} else {
+		g.Health = worseHealth(g.Health, res.Health)
+	}
+	g.Counts.add(res.Health)
+	g.Kinds[res.Kind]++
+	g.Members = append(g.Members, res.ID)
+	if res.Namespace != "" {
+		g.namespaces[res.Namespace] = true
+	}
+}
+
+func (g *ResourceGroup) finish() {
+	if g.Health == "" {
+		g.Health = "unknown"
+	}
+	g.Counts.Name = g.Value
+	g.Namespaces = make([]string, 0, len(g.namespaces))
+	for ns := range g.namespaces {
+		g.Namespaces = append(g.Namespaces, ns)
+	}
+	sort.Strings(g.Namespaces)
+}
+
+func newResourceGroup(value string) *ResourceGroup {
+	return &ResourceGroup{Value: value, Kinds: make(map[string]int), Members: []string{}, namespaces: make(map[string]bool)}
+}
+
+// HandleGroups serves /api/cluster/groups?by=label:<key> (or annotation:<key>),
+// partitioning the cached cluster graph by the key's value with a health rollup
+// per group. ?kind= restricts the resources considered.
+func HandleGroups(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	by := r.URL.Query().Get("by")
+	source, key, ok := strings.Cut(by, ":")
+	if !ok || key == "" || (source != "label" && source != "annotation") {
+		i18n.Error(w, r, http.StatusBadRequest, "error.mustBeOneOf", "by", "label:<key>, annotation:<key>")
+		return
+	}
+	kinds := make(map[string]bool)
+	for _, k := range r.URL.Query()["kind"] {
+		kinds[k] = true
+	}
+
+	graph, fetchedAt, err := cachedGraph(config)
+	if graph == nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	scope := auth.ScopeFromContext(r.Context())
+
+	groups := make(map[string]*ResourceGroup)
+	ungrouped := newResourceGroup("")
+	for i := range graph.Resources {
+		res := &graph.Resources[i]
+		if !resourceAllowed(scope, res) || (len(kinds) > 0 && !kinds[res.Kind]) {
+			continue
+		}
+		values := res.Labels
+		if source == "annotation" {
+			values = res.Annotations
+		}
+		value, found := values[key]
+		if !found {
+			ungrouped.add(res)
+			continue
+		}
+		g, ok := groups[value]
+		if !ok {
+			g = newResourceGroup(value)
+			groups[value] = g
+		}
+		g.add(res)
+	}
+
+	response := GroupsResponse{
+		By:          by,
+		GeneratedAt: fetchedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		Groups:      make([]ResourceGroup, 0, len(groups)),
+	}
+	for _, g := range groups {
+		g.finish()
+		response.Groups = append(response.Groups, *g)
+	}
+	sort.Slice(response.Groups, func(i, j int) bool {
+		return response.Groups[i].Value < response.Groups[j].Value
+	})
+	if len(ungrouped.Members) > 0 {
+		ungrouped.finish()
+		response.Ungrouped = ungrouped
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
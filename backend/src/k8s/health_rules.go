@@ -0,0 +1,52 @@
+package k8s
+
+import (
+	"github.com/anakosmos/backend/src/settings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// objectRuleHealth evaluates the health rules configured for a CRD object.
+// kind is the graph kind, which may differ from the API kind
+// (FluxHelmRelease); rules may name either.
+func objectRuleHealth(obj map[string]interface{}, apiKind, kind string) *settings.RuleHealth {
+	rules := settings.Current().HealthRules(kind)
+	if apiKind != "" && apiKind != kind {
+		rules = append(rules, settings.Current().HealthRules(apiKind)...)
+	}
+	for _, rule := range rules {
+		if result, ok := rule.Evaluate(obj); ok {
+			return &result
+		}
+	}
+	return nil
+}
+
+// typedRuleHealth evaluates the health rules configured for a built-in kind.
+// The object is only converted when the kind has rules.
+func typedRuleHealth(obj metav1.Object, kind string) *settings.RuleHealth {
+	if len(settings.Current().HealthRules(kind)) == 0 {
+		return nil
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil
+	}
+	return objectRuleHealth(content, kind, kind)
+}
+
+// applyHealthRule lets the health rule that matched the object override the
+// status and health its kind gave. Like applyTerminating it runs once the
+// kind-specific mapping is done, and Terminating still wins over it.
+func applyHealthRule(res *LightResource) {
+	if res.ruleHealth == nil {
+		return
+	}
+	res.Health = res.ruleHealth.Health
+	if res.ruleHealth.Status != "" {
+		res.Status = res.ruleHealth.Status
+	}
+	res.HealthRule = res.ruleHealth.Rule
+	res.HealthMessage = res.ruleHealth.Message
+}
@@ -0,0 +1,156 @@
+package k8s
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// helmManifest is what the stored manifest of a release rendered
+type helmManifest struct {
+	resourceVersion string
+	release         *HelmReleaseInfo
+	objects         []string // Kind/namespace/name
+}
+
+// helmManifestIndex keeps, per cluster (API server host), the objects the
+// Helm releases seen by the last graph build rendered, so resources without
+// Helm labels still join their release, on watch updates too. Manifests are
+// only decoded again when their release changes.
+var helmManifestIndex = struct {
+	sync.RWMutex
+	clusters map[string]*helmManifests
+}{clusters: make(map[string]*helmManifests)}
+
+type helmManifests struct {
+	releases map[string]*helmManifest    // namespace/name -> manifest
+	objects  map[string]*HelmReleaseInfo // Kind/namespace/name -> release
+}
+
+// recordHelmManifests replaces the release manifests known for a cluster, or
+// only those of one namespace when namespace is set. stored are the latest
+// revision of each release.
+func recordHelmManifests(cluster, namespace string, stored []helmStoredRelease) {
+	helmManifestIndex.RLock()
+	previous := helmManifestIndex.clusters[cluster]
+	helmManifestIndex.RUnlock()
+
+	index := &helmManifests{releases: make(map[string]*helmManifest), objects: make(map[string]*HelmReleaseInfo)}
+	if previous != nil && namespace != "" {
+		for key, m := range previous.releases {
+			if m.release.ReleaseNamespace != namespace {
+				index.releases[key] = m
+			}
+		}
+	}
+	for _, r := range stored {
+		key := r.namespace + "/" + r.name
+		if previous != nil {
+			if m, ok := previous.releases[key]; ok && m.resourceVersion == r.resourceVersion {
+				index.releases[key] = m
+				continue
+			}
+		}
+		index.releases[key] = decodeHelmManifest(r)
+	}
+	for _, m := range index.releases {
+		for _, key := range m.objects {
+			index.objects[key] = m.release
+		}
+	}
+
+	helmManifestIndex.Lock()
+	defer helmManifestIndex.Unlock()
+	helmManifestIndex.clusters[cluster] = index
+}
+
+// applyHelmManifest sets the release of a resource its labels don't tell, from
+// the manifests recorded for its cluster
+func applyHelmManifest(cluster string, res *LightResource) {
+	if res.HelmRelease != nil || res.Kind == "HelmRelease" {
+		return
+	}
+	helmManifestIndex.RLock()
+	index := helmManifestIndex.clusters[cluster]
+	helmManifestIndex.RUnlock()
+	if index == nil {
+		return
+	}
+	if release, ok := index.objects[res.Kind+"/"+res.Namespace+"/"+res.Name]; ok {
+		info := *release
+		res.HelmRelease = &info
+	}
+}
+
+// decodeHelmManifest lists the objects of a release's manifest. Secrets and
+// ConfigMaps store the release as base64 of gzipped JSON; one that doesn't
+// decode renders nothing.
+func decodeHelmManifest(r helmStoredRelease) *helmManifest {
+	m := &helmManifest{
+		resourceVersion: r.resourceVersion,
+		release:         &HelmReleaseInfo{ReleaseName: r.name, ReleaseNamespace: r.namespace},
+	}
+	var release struct {
+		Manifest string `json:"manifest"`
+		Chart    struct {
+			Metadata struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"metadata"`
+		} `json:"chart"`
+	}
+	if r.release != nil {
+		release.Manifest = r.release.Manifest
+		if r.release.Chart != nil && r.release.Chart.Metadata != nil {
+			release.Chart.Metadata.Name = r.release.Chart.Metadata.Name
+			release.Chart.Metadata.Version = r.release.Chart.Metadata.Version
+		}
+	} else {
+		if r.encoded == "" && r.load != nil {
+			r.encoded = r.load()
+		}
+		data, err := base64.StdEncoding.DecodeString(r.encoded)
+		if err != nil {
+			return m
+		}
+		if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+			gz, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return m
+			}
+			if data, err = io.ReadAll(gz); err != nil {
+				return m
+			}
+		}
+		if err := json.Unmarshal(data, &release); err != nil {
+			return m
+		}
+	}
+	m.release.ChartName = release.Chart.Metadata.Name
+	m.release.ChartVersion = release.Chart.Metadata.Version
+
+	for _, doc := range releaseutil.SplitManifests(release.Manifest) {
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil || len(obj) == 0 {
+			continue
+		}
+		u := unstructured.Unstructured{Object: obj}
+		if u.GetNamespace() != "" {
+			m.objects = append(m.objects, u.GetKind()+"/"+u.GetNamespace()+"/"+u.GetName())
+			continue
+		}
+		// Namespaced objects without one go to the release namespace; the
+		// manifest doesn't tell them from cluster-scoped ones
+		m.objects = append(m.objects,
+			u.GetKind()+"/"+r.namespace+"/"+u.GetName(),
+			u.GetKind()+"//"+u.GetName())
+	}
+	return m
+}
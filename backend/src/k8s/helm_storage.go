@@ -0,0 +1,121 @@
+package k8s
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"sync"
+
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// helmStoredRelease is the latest revision of a release as its storage driver
+// keeps it: encoded in a Secret or ConfigMap, or already decoded from SQL
+type helmStoredRelease struct {
+	name, namespace string
+	status, chart   string
+	version         int
+	created         metav1.Time
+	// uid is the Secret or ConfigMap holding the revision, linked from the
+	// HelmRelease; resourceVersion tells when to decode it again
+	uid, resourceVersion string
+	encoded              string
+	// load reads the encoded release when the object was cached without
+	// its data; it's only called when the manifest must be decoded
+	load    func() string
+	release *release.Release
+}
+
+// helmDriver is the Helm storage driver releases are read from, as HELM_DRIVER
+// tells the helm CLI: "secret" (the default), "configmap" or "sql". Releases
+// kept in memory can't be seen from here.
+func helmDriver() string {
+	switch d := os.Getenv("HELM_DRIVER"); d {
+	case "", "secrets":
+		return "secret"
+	case "configmaps":
+		return "configmap"
+	default:
+		return d
+	}
+}
+
+// helmStorageObject reads a release revision from the labels Helm sets on the
+// Secret or ConfigMap storing it
+func helmStorageObject(obj metav1.Object, encoded string) helmStoredRelease {
+	labels := obj.GetLabels()
+	version := 0
+	if v, err := strconv.Atoi(labels["version"]); err == nil {
+		version = v
+	}
+	return helmStoredRelease{
+		name:            labels["name"],
+		namespace:       obj.GetNamespace(),
+		status:          labels["status"],
+		chart:           labels["chart"],
+		version:         version,
+		created:         obj.GetCreationTimestamp(),
+		uid:             string(obj.GetUID()),
+		resourceVersion: obj.GetResourceVersion(),
+		encoded:         encoded,
+	}
+}
+
+// keepLatestRelease adds r to latest unless a later revision of the release
+// is there already
+func keepLatestRelease(latest map[string]helmStoredRelease, r helmStoredRelease) {
+	key := r.namespace + "/" + r.name
+	if existing, ok := latest[key]; !ok || r.version > existing.version {
+		latest[key] = r
+	}
+}
+
+// helmSQL is the SQL driver, connected on first use
+var helmSQL struct {
+	once   sync.Once
+	driver *driver.SQL
+}
+
+// sqlHelmReleases lists the releases of namespace ("" for all) kept in the
+// database HELM_DRIVER_SQL_CONNECTION_STRING points to
+func sqlHelmReleases(namespace string) []helmStoredRelease {
+	helmSQL.once.Do(func() {
+		d, err := driver.NewSQL(os.Getenv("HELM_DRIVER_SQL_CONNECTION_STRING"), log.Printf, "")
+		if err != nil {
+			log.Printf("Helm SQL storage not available: %v", err)
+			return
+		}
+		helmSQL.driver = d
+	})
+	if helmSQL.driver == nil {
+		return nil
+	}
+	releases, err := helmSQL.driver.List(func(r *release.Release) bool {
+		return namespace == "" || r.Namespace == namespace
+	})
+	if err != nil {
+		log.Printf("Helm SQL releases not available: %v", err)
+		return nil
+	}
+	stored := make([]helmStoredRelease, 0, len(releases))
+	for _, r := range releases {
+		s := helmStoredRelease{
+			name:            r.Name,
+			namespace:       r.Namespace,
+			version:         r.Version,
+			resourceVersion: strconv.Itoa(r.Version),
+			release:         r,
+		}
+		if r.Info != nil {
+			s.status = r.Info.Status.String()
+			s.created = metav1.NewTime(r.Info.FirstDeployed.Time)
+		}
+		if r.Chart != nil && r.Chart.Metadata != nil {
+			s.chart = r.Chart.Metadata.Name + "-" + r.Chart.Metadata.Version
+		}
+		stored = append(stored, s)
+	}
+	return stored
+}
@@ -0,0 +1,731 @@
+package k8s
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// ImageInspection is served by /api/images/inspect
+type ImageInspection struct {
+	Image      string `json:"image"`
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"`
+	Reference  string `json:"reference"` // tag or digest asked for
+	// Digest is the manifest of the inspected platform; IndexDigest the
+	// multi-platform index it was selected from
+	Digest       string            `json:"digest"`
+	IndexDigest  string            `json:"indexDigest,omitempty"`
+	Platforms    []string          `json:"platforms,omitempty"`
+	Platform     string            `json:"platform"`
+	Created      string            `json:"created,omitempty"`
+	Author       string            `json:"author,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Entrypoint   []string          `json:"entrypoint,omitempty"`
+	Cmd          []string          `json:"cmd,omitempty"`
+	User         string            `json:"user,omitempty"`
+	Layers       int               `json:"layers"`
+	Size         int64             `json:"size"` // compressed layers
+	Attestations []ImageArtifact   `json:"attestations"`
+	SBOM         *ImageSBOM        `json:"sbom,omitempty"`
+	// Warnings are lookups that failed without preventing the inspection,
+	// e.g. unreadable pull secrets or a registry without the referrers API
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// ImageArtifact is a signature, attestation or SBOM attached to the image
+type ImageArtifact struct {
+	Type   string `json:"type"` // artifact or in-toto predicate type
+	Digest string `json:"digest"`
+	// Source is how it was found: buildkit (attestation manifest in the
+	// index), referrers (OCI referrers API) or cosign (sha256-<digest>.att/.sbom tags)
+	Source string `json:"source"`
+}
+
+// ImageSBOM summarizes the first SBOM found
+type ImageSBOM struct {
+	Format       string        `json:"format"` // spdx or cyclonedx
+	Digest       string        `json:"digest"`
+	PackageCount int           `json:"packageCount"`
+	Packages     []SBOMPackage `json:"packages"` // at most maxSBOMPackages
+}
+
+// SBOMPackage is one package listed in an SBOM
+type SBOMPackage struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+}
+
+const (
+	maxSBOMPackages = 1000
+	// maxRegistryBlob bounds what is read from a registry (configs, SBOMs)
+	maxRegistryBlob = 32 << 20
+
+	mediaOCIIndex      = "application/vnd.oci.image.index.v1+json"
+	mediaOCIManifest   = "application/vnd.oci.image.manifest.v1+json"
+	mediaDockerList    = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaDockerImage   = "application/vnd.docker.distribution.manifest.v2+json"
+	inTotoPredicateKey = "in-toto.io/predicate-type"
+)
+
+type ociPlatform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+func (p *ociPlatform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+type ociDescriptor struct {
+	MediaType    string            `json:"mediaType"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Platform     *ociPlatform      `json:"platform,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// ociManifest covers image manifests and indexes of both OCI and Docker
+type ociManifest struct {
+	MediaType    string          `json:"mediaType"`
+	ArtifactType string          `json:"artifactType,omitempty"`
+	Config       ociDescriptor   `json:"config"`
+	Layers       []ociDescriptor `json:"layers"`
+	Manifests    []ociDescriptor `json:"manifests"`
+}
+
+func (m *ociManifest) isIndex() bool {
+	return m.MediaType == mediaOCIIndex || m.MediaType == mediaDockerList || (m.MediaType == "" && len(m.Manifests) > 0)
+}
+
+type imageConfig struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+	Created      string `json:"created,omitempty"`
+	Author       string `json:"author,omitempty"`
+	Config       struct {
+		Labels     map[string]string `json:"Labels"`
+		Entrypoint []string          `json:"Entrypoint"`
+		Cmd        []string          `json:"Cmd"`
+		User       string            `json:"User"`
+	} `json:"config"`
+}
+
+// imageRef is a parsed image reference
+type imageRef struct {
+	registry   string // as written, docker.io when omitted
+	repository string
+	tag        string
+	digest     string
+}
+
+func (ref imageRef) reference() string {
+	if ref.digest != "" {
+		return ref.digest
+	}
+	return ref.tag
+}
+
+// apiHost is where the registry API is served
+func (ref imageRef) apiHost() string {
+	if ref.registry == "docker.io" {
+		return "registry-1.docker.io"
+	}
+	return ref.registry
+}
+
+// parseImageRef splits an image like nginx, ghcr.io/org/app:1.2 or
+// registry:5000/app@sha256:...
+func parseImageRef(image string) (imageRef, error) {
+	ref := imageRef{}
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.digest = name[:i], name[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.tag = name[:i], name[i+1:]
+	}
+	if ref.tag == "" && ref.digest == "" {
+		ref.tag = "latest"
+	}
+	first, rest, found := strings.Cut(name, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.registry, ref.repository = first, rest
+	} else {
+		ref.registry, ref.repository = "docker.io", name
+	}
+	if ref.registry == "docker.io" && !strings.Contains(ref.repository, "/") {
+		ref.repository = "library/" + ref.repository
+	}
+	if ref.repository == "" {
+		return ref, fmt.Errorf("invalid image %q", image)
+	}
+	return ref, nil
+}
+
+// registryCredential is a username/password pair from a pull secret
+type registryCredential struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Auth     string `json:"auth"`
+}
+
+// normalizeRegistryHost maps the keys found in docker configs to a registry
+// host, folding Docker Hub aliases
+func normalizeRegistryHost(key string) string {
+	host := key
+	if u, err := url.Parse(key); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	host, _, _ = strings.Cut(host, "/")
+	switch host {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return "docker.io"
+	}
+	return host
+}
+
+// pullCredentials extracts per-registry credentials from dockerconfigjson
+// and dockercfg secrets
+func pullCredentials(secrets []*corev1.Secret) map[string]registryCredential {
+	creds := make(map[string]registryCredential)
+	for _, s := range secrets {
+		var auths map[string]registryCredential
+		switch s.Type {
+		case corev1.SecretTypeDockerConfigJson:
+			var cfg struct {
+				Auths map[string]registryCredential `json:"auths"`
+			}
+			if json.Unmarshal(s.Data[corev1.DockerConfigJsonKey], &cfg) == nil {
+				auths = cfg.Auths
+			}
+		case corev1.SecretTypeDockercfg:
+			json.Unmarshal(s.Data[corev1.DockerConfigKey], &auths)
+		}
+		for key, cred := range auths {
+			if cred.Username == "" && cred.Auth != "" {
+				if decoded, err := base64.StdEncoding.DecodeString(cred.Auth); err == nil {
+					cred.Username, cred.Password, _ = strings.Cut(string(decoded), ":")
+				}
+			}
+			host := normalizeRegistryHost(key)
+			if _, ok := creds[host]; !ok {
+				creds[host] = cred
+			}
+		}
+	}
+	return creds
+}
+
+// registryClient talks to one repository of an OCI distribution registry,
+// following bearer token challenges
+type registryClient struct {
+	ref   imageRef
+	cred  *registryCredential
+	http  *http.Client
+	token string
+}
+
+func newRegistryClient(ref imageRef, cred *registryCredential) *registryClient {
+	return &registryClient{ref: ref, cred: cred, http: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// parseChallenge reads a WWW-Authenticate header like
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+func parseChallenge(header string) (scheme string, params map[string]string) {
+	scheme, rest, _ := strings.Cut(header, " ")
+	params = make(map[string]string)
+	for _, part := range strings.Split(rest, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok {
+			params[strings.ToLower(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return strings.ToLower(scheme), params
+}
+
+// authorize obtains a pull token after a 401 challenge
+func (c *registryClient) authorize(ctx context.Context, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	if scheme == "basic" {
+		if c.cred == nil {
+			return fmt.Errorf("registry %s requires credentials", c.ref.registry)
+		}
+		return nil
+	}
+	if scheme != "bearer" || params["realm"] == "" {
+		return fmt.Errorf("unsupported registry auth challenge %q", challenge)
+	}
+	u, err := url.Parse(params["realm"])
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	if params["service"] != "" {
+		q.Set("service", params["service"])
+	}
+	q.Set("scope", "repository:"+c.ref.repository+":pull")
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	if c.cred != nil {
+		req.SetBasicAuth(c.cred.Username, c.cred.Password)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("registry token request failed: %s", resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return err
+	}
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	return nil
+}
+
+// get fetches /v2/<repo>/<path>, authenticating on the first 401. Callers
+// close the body.
+func (c *registryClient) get(ctx context.Context, path string, accept ...string) (*http.Response, error) {
+	target := "https://" + c.ref.apiHost() + "/v2/" + c.ref.repository + "/" + path
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(accept) > 0 {
+			req.Header.Set("Accept", strings.Join(accept, ", "))
+		}
+		switch {
+		case c.token != "":
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		case c.cred != nil:
+			req.SetBasicAuth(c.cred.Username, c.cred.Password)
+		}
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusUnauthorized || attempt > 0 {
+			return resp, nil
+		}
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if err := c.authorize(ctx, challenge); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// getJSON decodes a manifest or blob, returning its digest when the registry
+// reports one
+func (c *registryClient) getJSON(ctx context.Context, path string, out interface{}, accept ...string) (string, error) {
+	resp, err := c.get(ctx, path, accept...)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", &registryError{path: path, status: resp.StatusCode, text: resp.Status}
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRegistryBlob))
+	if err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return "", fmt.Errorf("invalid registry response for %s: %w", path, err)
+	}
+	return resp.Header.Get("Docker-Content-Digest"), nil
+}
+
+type registryError struct {
+	path   string
+	status int
+	text   string
+}
+
+func (e *registryError) Error() string {
+	return fmt.Sprintf("registry returned %s for %s", e.text, e.path)
+}
+
+func (c *registryClient) manifest(ctx context.Context, reference string) (*ociManifest, string, error) {
+	var m ociManifest
+	digest, err := c.getJSON(ctx, "manifests/"+reference, &m, mediaOCIIndex, mediaDockerList, mediaOCIManifest, mediaDockerImage)
+	if err != nil {
+		return nil, "", err
+	}
+	if digest == "" && strings.HasPrefix(reference, "sha256:") {
+		digest = reference
+	}
+	return &m, digest, nil
+}
+
+// selectPlatform picks the index entry for platform (os/arch[/variant]),
+// defaulting to linux/amd64, then to the first image
+func selectPlatform(index *ociManifest, platform string) *ociDescriptor {
+	if platform == "" {
+		platform = "linux/amd64"
+	}
+	var first *ociDescriptor
+	for i := range index.Manifests {
+		d := &index.Manifests[i]
+		if d.Platform == nil || d.Platform.OS == "unknown" {
+			continue
+		}
+		if first == nil {
+			first = d
+		}
+		if d.Platform.String() == platform || d.Platform.OS+"/"+d.Platform.Architecture == platform {
+			return d
+		}
+	}
+	return first
+}
+
+// isSBOMType reports whether an artifact or predicate type is an SBOM
+func isSBOMType(t string) bool {
+	t = strings.ToLower(t)
+	return strings.Contains(t, "spdx") || strings.Contains(t, "cyclonedx")
+}
+
+// parseSBOM summarizes an SPDX or CycloneDX document, unwrapping in-toto
+// statements and DSSE envelopes
+func parseSBOM(data []byte) (*ImageSBOM, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if payload, ok := doc["payload"]; ok {
+		var encoded string
+		json.Unmarshal(payload, &encoded)
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DSSE payload: %w", err)
+		}
+		return parseSBOM(decoded)
+	}
+	if predicate, ok := doc["predicate"]; ok {
+		return parseSBOM(predicate)
+	}
+
+	sbom := &ImageSBOM{Packages: []SBOMPackage{}}
+	add := func(p SBOMPackage) {
+		sbom.PackageCount++
+		if len(sbom.Packages) < maxSBOMPackages {
+			sbom.Packages = append(sbom.Packages, p)
+		}
+	}
+	switch {
+	case doc["spdxVersion"] != nil:
+		sbom.Format = "spdx"
+		var spdx struct {
+			Packages []struct {
+				Name         string `json:"name"`
+				VersionInfo  string `json:"versionInfo"`
+				ExternalRefs []struct {
+					ReferenceType    string `json:"referenceType"`
+					ReferenceLocator string `json:"referenceLocator"`
+				} `json:"externalRefs"`
+			} `json:"packages"`
+		}
+		if err := json.Unmarshal(data, &spdx); err != nil {
+			return nil, err
+		}
+		for _, p := range spdx.Packages {
+			pkg := SBOMPackage{Name: p.Name, Version: p.VersionInfo}
+			for _, ref := range p.ExternalRefs {
+				if ref.ReferenceType == "purl" {
+					pkg.PURL = ref.ReferenceLocator
+				}
+			}
+			add(pkg)
+		}
+	case doc["bomFormat"] != nil:
+		sbom.Format = "cyclonedx"
+		var cdx struct {
+			Components []SBOMPackage `json:"components"`
+		}
+		if err := json.Unmarshal(data, &cdx); err != nil {
+			return nil, err
+		}
+		for _, p := range cdx.Components {
+			add(p)
+		}
+	default:
+		return nil, fmt.Errorf("not an SPDX or CycloneDX document")
+	}
+	return sbom, nil
+}
+
+// findAttestations looks for artifacts attached to the image digest (and its
+// index) the three common ways, returning them with the digests of the blobs
+// holding SBOMs
+func (c *registryClient) findAttestations(ctx context.Context, index *ociManifest, indexDigest, digest string, warn func(string)) ([]ImageArtifact, []string) {
+	artifacts := []ImageArtifact{}
+	var sboms []string
+
+	// BuildKit stores attestation manifests in the index, pointing at the image
+	if index != nil {
+		for _, d := range index.Manifests {
+			if d.Annotations["vnd.docker.reference.type"] != "attestation-manifest" || d.Annotations["vnd.docker.reference.digest"] != digest {
+				continue
+			}
+			m, _, err := c.manifest(ctx, d.Digest)
+			if err != nil {
+				warn("attestation manifest: " + err.Error())
+				continue
+			}
+			for _, layer := range m.Layers {
+				predicate := layer.Annotations[inTotoPredicateKey]
+				artifacts = append(artifacts, ImageArtifact{Type: predicate, Digest: layer.Digest, Source: "buildkit"})
+				if isSBOMType(predicate) {
+					sboms = append(sboms, layer.Digest)
+				}
+			}
+		}
+	}
+
+	// OCI 1.1 referrers, for the image and the index
+	for _, subject := range []string{digest, indexDigest} {
+		if subject == "" {
+			continue
+		}
+		var referrers ociManifest
+		if _, err := c.getJSON(ctx, "referrers/"+subject, &referrers, mediaOCIIndex); err != nil {
+			if re, ok := err.(*registryError); !ok || re.status != http.StatusNotFound {
+				warn("referrers: " + err.Error())
+			}
+			continue
+		}
+		for _, d := range referrers.Manifests {
+			artifacts = append(artifacts, ImageArtifact{Type: d.ArtifactType, Digest: d.Digest, Source: "referrers"})
+			if !isSBOMType(d.ArtifactType) {
+				continue
+			}
+			if m, _, err := c.manifest(ctx, d.Digest); err == nil && len(m.Layers) > 0 {
+				sboms = append(sboms, m.Layers[0].Digest)
+			}
+		}
+	}
+
+	// cosign tag conventions: sha256-<hex>.att, .sbom and .sig
+	tagBase := strings.Replace(digest, ":", "-", 1)
+	for _, suffix := range []string{".sig", ".att", ".sbom"} {
+		m, tagDigest, err := c.manifest(ctx, tagBase+suffix)
+		if err != nil {
+			continue
+		}
+		artifactType := "cosign" + suffix
+		for _, layer := range m.Layers {
+			if predicate := layer.Annotations["predicateType"]; predicate != "" {
+				artifactType = predicate
+			}
+			if suffix == ".sbom" || isSBOMType(artifactType) || isSBOMType(layer.MediaType) {
+				sboms = append(sboms, layer.Digest)
+			}
+		}
+		artifacts = append(artifacts, ImageArtifact{Type: artifactType, Digest: tagDigest, Source: "cosign"})
+	}
+	return artifacts, sboms
+}
+
+// inspectImage reads the manifest, config and attachments of an image
+func inspectImage(ctx context.Context, image, platform string, creds map[string]registryCredential) (*ImageInspection, error) {
+	ref, err := parseImageRef(image)
+	if err != nil {
+		return nil, err
+	}
+	var cred *registryCredential
+	if c, ok := creds[ref.registry]; ok {
+		cred = &c
+	}
+	client := newRegistryClient(ref, cred)
+	result := &ImageInspection{
+		Image:      image,
+		Registry:   ref.registry,
+		Repository: ref.repository,
+		Reference:  ref.reference(),
+	}
+	warn := func(msg string) { result.Warnings = append(result.Warnings, msg) }
+
+	m, digest, err := client.manifest(ctx, ref.reference())
+	if err != nil {
+		return nil, err
+	}
+	var index *ociManifest
+	if m.isIndex() {
+		index, result.IndexDigest = m, digest
+		for _, d := range m.Manifests {
+			if d.Platform != nil && d.Platform.OS != "unknown" {
+				result.Platforms = append(result.Platforms, d.Platform.String())
+			}
+		}
+		selected := selectPlatform(m, platform)
+		if selected == nil {
+			return nil, fmt.Errorf("no image manifest in index %s", digest)
+		}
+		if m, _, err = client.manifest(ctx, selected.Digest); err != nil {
+			return nil, err
+		}
+		digest = selected.Digest
+	}
+	result.Digest = digest
+	result.Layers = len(m.Layers)
+	for _, l := range m.Layers {
+		result.Size += l.Size
+	}
+
+	var cfg imageConfig
+	if _, err := client.getJSON(ctx, "blobs/"+m.Config.Digest, &cfg); err != nil {
+		warn("config: " + err.Error())
+	} else {
+		p := ociPlatform{OS: cfg.OS, Architecture: cfg.Architecture, Variant: cfg.Variant}
+		result.Platform = p.String()
+		result.Created = cfg.Created
+		result.Author = cfg.Author
+		result.Labels = cfg.Config.Labels
+		result.Entrypoint = cfg.Config.Entrypoint
+		result.Cmd = cfg.Config.Cmd
+		result.User = cfg.Config.User
+	}
+
+	var sboms []string
+	result.Attestations, sboms = client.findAttestations(ctx, index, result.IndexDigest, digest, warn)
+	for _, blob := range sboms {
+		var raw json.RawMessage
+		if _, err := client.getJSON(ctx, "blobs/"+blob, &raw); err != nil {
+			warn("sbom: " + err.Error())
+			continue
+		}
+		sbom, err := parseSBOM(raw)
+		if err != nil {
+			warn("sbom " + blob + ": " + err.Error())
+			continue
+		}
+		sbom.Digest = blob
+		result.SBOM = sbom
+		break
+	}
+	return result, nil
+}
+
+// HandleImageInspect serves /api/images/inspect?image=[&namespace=&pod=&container=&platform=].
+// With a pod, the image defaults to its container's and the pod's (and its
+// service account's) pull secrets authenticate to the registry; with only a
+// namespace, the default service account's pull secrets are used.
+func HandleImageInspect(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	image, namespace, podName := q.Get("image"), q.Get("namespace"), q.Get("pod")
+	if image == "" && podName == "" {
+		i18n.Error(w, r, http.StatusBadRequest, "error.requiredOneOf", "image, pod")
+		return
+	}
+	if podName != "" && namespace == "" {
+		i18n.Error(w, r, http.StatusBadRequest, "error.required", "namespace")
+		return
+	}
+
+	var warnings []string
+	var secrets []*corev1.Secret
+	if namespace != "" {
+		if !auth.RequireNamespace(w, r, namespace) {
+			return
+		}
+		clientset, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			i18n.Error(w, r, http.StatusInternalServerError, "error.clientFailed")
+			return
+		}
+		serviceAccount := "default"
+		var secretNames []string
+		if podName != "" {
+			pod, err := clientset.CoreV1().Pods(namespace).Get(r.Context(), podName, metav1.GetOptions{})
+			if err != nil {
+				writeAPIError(w, err)
+				return
+			}
+			if image == "" {
+				container := q.Get("container")
+				containers := append(append([]corev1.Container{}, pod.Spec.Containers...), pod.Spec.InitContainers...)
+				for _, c := range containers {
+					if container == "" || c.Name == container {
+						image = c.Image
+						break
+					}
+				}
+				if image == "" {
+					i18n.Error(w, r, http.StatusNotFound, "error.containerNotFound")
+					return
+				}
+			}
+			if pod.Spec.ServiceAccountName != "" {
+				serviceAccount = pod.Spec.ServiceAccountName
+			}
+			for _, ref := range pod.Spec.ImagePullSecrets {
+				secretNames = append(secretNames, ref.Name)
+			}
+		}
+		if sa, err := clientset.CoreV1().ServiceAccounts(namespace).Get(r.Context(), serviceAccount, metav1.GetOptions{}); err == nil {
+			for _, ref := range sa.ImagePullSecrets {
+				secretNames = append(secretNames, ref.Name)
+			}
+		}
+		for _, name := range secretNames {
+			secret, err := clientset.CoreV1().Secrets(namespace).Get(r.Context(), name, metav1.GetOptions{})
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("pull secret %s: %v", name, err))
+				continue
+			}
+			secrets = append(secrets, secret)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Minute)
+	defer cancel()
+	result, err := inspectImage(ctx, image, q.Get("platform"), pullCredentials(secrets))
+	if err != nil {
+		status := http.StatusBadGateway
+		if re, ok := err.(*registryError); ok && re.status == http.StatusNotFound {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	result.Warnings = append(warnings, result.Warnings...)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,158 @@
+package k8s
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	"k8s.io/client-go/rest"
+)
+
+// ImpactResource identifies a resource affected by a delete
+type ImpactResource struct {
+	ID        string `json:"id"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// ImpactDescendant is an object removed by garbage collection cascade
+type ImpactDescendant struct {
+	ImpactResource
+	Depth int `json:"depth"`
+}
+
+// ImpactReference is a surviving resource that points at a deleted object
+type ImpactReference struct {
+	ImpactResource
+	LinkType string `json:"linkType"`
+	Target   string `json:"target"` // ID of the deleted object it references
+}
+
+// ImpactResponse is the dry-run blast radius of deleting a resource
+type ImpactResponse struct {
+	Target       ImpactResource     `json:"target"`
+	Cascade      bool               `json:"cascade"`
+	Descendants  []ImpactDescendant `json:"descendants"`
+	ReferencedBy []ImpactReference  `json:"referencedBy"`
+	HelmRelease  *HelmReleaseInfo   `json:"helmRelease,omitempty"`
+}
+
+func impactResource(res *LightResource) ImpactResource {
+	return ImpactResource{ID: res.ID, Kind: res.Kind, Namespace: res.Namespace, Name: res.Name}
+}
+
+// HandleImpact serves /api/resources/impact?kind=&namespace=&name= (or ?id=).
+// Nothing is deleted: the cluster graph is used to compute the descendant tree
+// that would cascade, the resources still referencing the deleted objects, and
+// the Helm release managing the target. ?propagationPolicy=Orphan skips the
+// cascade like the corresponding delete option.
+func HandleImpact(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	id, kind, namespace, name := q.Get("id"), q.Get("kind"), q.Get("namespace"), q.Get("name")
+	if id == "" && (kind == "" || name == "") {
+		i18n.Error(w, r, http.StatusBadRequest, "error.requiredOneOf", "id, kind+name")
+		return
+	}
+
+	graph, err := BuildGraph(r.Context(), config)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	byID := make(map[string]*LightResource, len(graph.Resources))
+	var target *LightResource
+	for i := range graph.Resources {
+		res := &graph.Resources[i]
+		byID[res.ID] = res
+		if target != nil {
+			continue
+		}
+		if (id != "" && res.ID == id) || (id == "" && res.Kind == kind && res.Namespace == namespace && res.Name == name) {
+			target = res
+		}
+	}
+	if target == nil {
+		i18n.Error(w, r, http.StatusNotFound, "error.resourceNotFound")
+		return
+	}
+	if !auth.RequireNamespace(w, r, target.Namespace) {
+		return
+	}
+	scope := auth.ScopeFromContext(r.Context())
+
+	response := ImpactResponse{
+		Target:       impactResource(target),
+		Cascade:      q.Get("propagationPolicy") != "Orphan",
+		Descendants:  []ImpactDescendant{},
+		ReferencedBy: []ImpactReference{},
+		HelmRelease:  target.HelmRelease,
+	}
+
+	// Walk real ownerReferences (not display links) breadth-first
+	deleted := map[string]bool{target.ID: true}
+	if response.Cascade {
+		children := make(map[string][]*LightResource)
+		for i := range graph.Resources {
+			res := &graph.Resources[i]
+			for _, owner := range res.OwnerRefs {
+				children[owner] = append(children[owner], res)
+			}
+		}
+		queue := []string{target.ID}
+		for depth := 1; len(queue) > 0; depth++ {
+			var next []string
+			for _, parent := range queue {
+				for _, child := range children[parent] {
+					if deleted[child.ID] {
+						continue
+					}
+					deleted[child.ID] = true
+					next = append(next, child.ID)
+					if scope.Allows(child.Namespace) {
+						response.Descendants = append(response.Descendants, ImpactDescendant{ImpactResource: impactResource(child), Depth: depth})
+					}
+				}
+			}
+			queue = next
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, link := range graph.Links {
+		if !deleted[link.Target] || deleted[link.Source] {
+			continue
+		}
+		src, ok := byID[link.Source]
+		if !ok || !scope.Allows(src.Namespace) {
+			continue
+		}
+		key := link.Source + "|" + link.Target
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		response.ReferencedBy = append(response.ReferencedBy, ImpactReference{
+			ImpactResource: impactResource(src),
+			LinkType:       link.Type,
+			Target:         link.Target,
+		})
+	}
+
+	sort.SliceStable(response.Descendants, func(i, j int) bool {
+		return response.Descendants[i].Depth < response.Descendants[j].Depth
+	})
+	sort.Slice(response.ReferencedBy, func(i, j int) bool {
+		a, b := response.ReferencedBy[i], response.ReferencedBy[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,303 @@
+package k8s
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// informerIdleTTL is how long the informers of a cluster keep running after
+// the last graph built from them
+const informerIdleTTL = 30 * time.Minute
+
+// maxInformerSources caps the clusters and credentials kept in informers; the
+// least recently used source is stopped to make room
+const maxInformerSources = 8
+
+// informerRetryAfter is how long a credential whose list failed isn't tried
+// again for informers
+const informerRetryAfter = time.Minute
+
+// informerSource keeps the built-in kinds of one cluster and credential in
+// shared informer caches, so building the graph reads memory instead of
+// listing the API server every time. Kinds whose informer hasn't synced
+// (still starting, or forbidden by RBAC) are listed as before. Secrets are
+// cached by metadata only, see secretFromMetadata.
+type informerSource struct {
+	stop     chan struct{}
+	lastUsed time.Time
+
+	// changed is signalled by informer events; graph is the full graph
+	// precomputed from the informers, see precompute
+	changed chan struct{}
+	graphMu sync.RWMutex
+	graph   *InitResponse
+	builtAt time.Time
+	// journal keeps the recent changes for /api/cluster/delta
+	journal *changeJournal
+
+	namespaces, nodes, pods, services, deployments, statefulsets, daemonsets,
+	replicasets, ingresses, networkpolicies, endpointslices, pvcs, pvs, configmaps, secrets,
+	storageclasses, csidrivers, volumeattachments, jobs, cronjobs, hpas, limitranges,
+	resourcequotas, pdbs, serviceaccounts, roles, rolebindings, clusterroles,
+	clusterrolebindings cache.SharedIndexInformer
+	// events only holds Warning events; being noisy, they don't trigger
+	// rebuilds of the precomputed graph
+	events cache.SharedIndexInformer
+}
+
+var informerSources = struct {
+	sync.Mutex
+	enabled bool
+	sources map[string]*informerSource
+	// failed holds when the list of a credential last failed
+	failed  map[string]time.Time
+	pruning sync.Once
+}{enabled: true, sources: make(map[string]*informerSource), failed: make(map[string]time.Time)}
+
+// ConfigureInformerCache turns the informer-backed graph on or off. When off
+// every graph build lists the cluster.
+func ConfigureInformerCache(enabled bool) {
+	informerSources.Lock()
+	defer informerSources.Unlock()
+	informerSources.enabled = enabled
+}
+
+// informersFor returns the informer source of the cluster and credential
+// behind config, starting it on first use. A source is only started once a
+// list with the credential succeeded, so unreachable clusters and rejected
+// tokens don't keep relisting; at most maxInformerSources run, and sources
+// idle for informerIdleTTL are stopped.
+func informersFor(config *rest.Config) *informerSource {
+	key := credentialKey(config)
+
+	informerSources.Lock()
+	if !informerSources.enabled {
+		informerSources.Unlock()
+		return nil
+	}
+	informerSources.pruning.Do(func() { go pruneInformerSources() })
+	if src, ok := informerSources.sources[key]; ok {
+		src.lastUsed = time.Now()
+		informerSources.Unlock()
+		return src
+	}
+	if time.Since(informerSources.failed[key]) < informerRetryAfter {
+		informerSources.Unlock()
+		return nil
+	}
+	informerSources.Unlock()
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		log.Printf("Informer cache disabled for %s: %v", config.Host, err)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	_, err = clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{Limit: 1})
+	cancel()
+
+	informerSources.Lock()
+	defer informerSources.Unlock()
+	if err != nil {
+		// Graph builds list the cluster and report the error themselves
+		for k, at := range informerSources.failed {
+			if time.Since(at) >= informerRetryAfter {
+				delete(informerSources.failed, k)
+			}
+		}
+		informerSources.failed[key] = time.Now()
+		return nil
+	}
+	delete(informerSources.failed, key)
+	if src, ok := informerSources.sources[key]; ok {
+		// Started by a concurrent call meanwhile
+		src.lastUsed = time.Now()
+		return src
+	}
+	if len(informerSources.sources) >= maxInformerSources {
+		evictInformerSource()
+	}
+
+	metadataClient, err := metadata.NewForConfig(config)
+	if err != nil {
+		log.Printf("Informer cache disabled for %s: %v", config.Host, err)
+		return nil
+	}
+	served := clusterServes(config)
+	factory := informers.NewSharedInformerFactory(clientset, 0)
+	metadataFactory := metadatainformer.NewSharedInformerFactory(metadataClient, 0)
+	src := &informerSource{
+		stop:                make(chan struct{}),
+		changed:             make(chan struct{}, 1),
+		lastUsed:            time.Now(),
+		journal:             &changeJournal{},
+		namespaces:          factory.Core().V1().Namespaces().Informer(),
+		nodes:               factory.Core().V1().Nodes().Informer(),
+		pods:                factory.Core().V1().Pods().Informer(),
+		services:            factory.Core().V1().Services().Informer(),
+		deployments:         factory.Apps().V1().Deployments().Informer(),
+		statefulsets:        factory.Apps().V1().StatefulSets().Informer(),
+		daemonsets:          factory.Apps().V1().DaemonSets().Informer(),
+		replicasets:         factory.Apps().V1().ReplicaSets().Informer(),
+		pvcs:                factory.Core().V1().PersistentVolumeClaims().Informer(),
+		pvs:                 factory.Core().V1().PersistentVolumes().Informer(),
+		configmaps:          factory.Core().V1().ConfigMaps().Informer(),
+		secrets:             metadataFactory.ForResource(corev1.SchemeGroupVersion.WithResource("secrets")).Informer(),
+		storageclasses:      factory.Storage().V1().StorageClasses().Informer(),
+		jobs:                factory.Batch().V1().Jobs().Informer(),
+		limitranges:         factory.Core().V1().LimitRanges().Informer(),
+		resourcequotas:      factory.Core().V1().ResourceQuotas().Informer(),
+		serviceaccounts:     factory.Core().V1().ServiceAccounts().Informer(),
+		roles:               factory.Rbac().V1().Roles().Informer(),
+		rolebindings:        factory.Rbac().V1().RoleBindings().Informer(),
+		clusterroles:        factory.Rbac().V1().ClusterRoles().Informer(),
+		clusterrolebindings: factory.Rbac().V1().ClusterRoleBindings().Informer(),
+		events:              factory.InformerFor(&corev1.Event{}, newWarningEventInformer),
+	}
+	// Optional APIs only get an informer when served, or it would retry forever
+	if served.Has("networking.k8s.io", "v1", "ingresses") {
+		src.ingresses = factory.Networking().V1().Ingresses().Informer()
+	}
+	if served.Has("discovery.k8s.io", "v1", "endpointslices") {
+		src.endpointslices = factory.Discovery().V1().EndpointSlices().Informer()
+	}
+	if served.Has("networking.k8s.io", "v1", "networkpolicies") {
+		src.networkpolicies = factory.Networking().V1().NetworkPolicies().Informer()
+	}
+	if served.Has("batch", "v1", "cronjobs") {
+		src.cronjobs = factory.Batch().V1().CronJobs().Informer()
+	}
+	if served.Has("autoscaling", "v2", "horizontalpodautoscalers") {
+		src.hpas = factory.Autoscaling().V2().HorizontalPodAutoscalers().Informer()
+	}
+	if served.Has("policy", "v1", "poddisruptionbudgets") {
+		src.pdbs = factory.Policy().V1().PodDisruptionBudgets().Informer()
+	}
+	if served.Has("storage.k8s.io", "v1", "csidrivers") {
+		src.csidrivers = factory.Storage().V1().CSIDrivers().Informer()
+	}
+	if served.Has("storage.k8s.io", "v1", "volumeattachments") {
+		src.volumeattachments = factory.Storage().V1().VolumeAttachments().Informer()
+	}
+	if err := src.secrets.SetTransform(secretFromMetadata); err != nil {
+		log.Printf("Informer cache disabled for %s: %v", config.Host, err)
+		return nil
+	}
+	src.notifyChanges()
+	src.recordChanges()
+	factory.Start(src.stop)
+	metadataFactory.Start(src.stop)
+	go src.precompute(config)
+	informerSources.sources[key] = src
+	return src
+}
+
+// pruneInformerSources stops idle sources, whether or not graphs are still
+// requested for other clusters
+func pruneInformerSources() {
+	ticker := time.NewTicker(informerIdleTTL / 6)
+	defer ticker.Stop()
+	for range ticker.C {
+		informerSources.Lock()
+		for k, src := range informerSources.sources {
+			if time.Since(src.lastUsed) > informerIdleTTL {
+				close(src.stop)
+				delete(informerSources.sources, k)
+			}
+		}
+		for k, at := range informerSources.failed {
+			if time.Since(at) >= informerRetryAfter {
+				delete(informerSources.failed, k)
+			}
+		}
+		informerSources.Unlock()
+	}
+}
+
+// evictInformerSource stops the least recently used source. informerSources
+// is locked.
+func evictInformerSource() {
+	var oldest string
+	for k, src := range informerSources.sources {
+		if oldest == "" || src.lastUsed.Before(informerSources.sources[oldest].lastUsed) {
+			oldest = k
+		}
+	}
+	if src, ok := informerSources.sources[oldest]; ok {
+		close(src.stop)
+		delete(informerSources.sources, oldest)
+	}
+}
+
+// secretFromMetadata turns the metadata a Secret informer caches into a
+// Secret without data, so no secret values are held in memory. The type the
+// graph tells apart is recovered from the markers set alongside it: the
+// ServiceAccount annotation of legacy token Secrets and the owner label and
+// name prefix of Helm release Secrets. Helm releases are read on demand.
+func secretFromMetadata(obj interface{}) (interface{}, error) {
+	m, ok := obj.(*metav1.PartialObjectMetadata)
+	if !ok {
+		return obj, nil
+	}
+	sec := &corev1.Secret{ObjectMeta: m.ObjectMeta}
+	sec.ManagedFields = nil
+	switch {
+	case sec.Annotations[corev1.ServiceAccountNameKey] != "":
+		sec.Type = corev1.SecretTypeServiceAccountToken
+	case sec.Labels["owner"] == "helm" && strings.HasPrefix(sec.Name, "sh.helm.release.v1."):
+		sec.Type = "helm.sh/release.v1"
+	}
+	return sec, nil
+}
+
+// cachedItems returns the objects of an informer in namespace ("" for all)
+// matching labelSelector, sorted like a List response. ok is false when the
+// informer isn't usable and the kind must be listed.
+func cachedItems[T any](informer cache.SharedIndexInformer, opts graphOptions) (items []T, ok bool) {
+	if informer == nil || !informer.HasSynced() {
+		return nil, false
+	}
+	selector, err := labels.Parse(opts.labelSelector)
+	if err != nil {
+		return nil, false
+	}
+	type entry struct {
+		key  string
+		item *T
+	}
+	var entries []entry
+	for _, obj := range informer.GetStore().List() {
+		item, isT := obj.(*T)
+		meta, isMeta := obj.(metav1.Object)
+		if !isT || !isMeta {
+			continue
+		}
+		if opts.namespace != "" && meta.GetNamespace() != opts.namespace {
+			continue
+		}
+		if !selector.Matches(labels.Set(meta.GetLabels())) {
+			continue
+		}
+		entries = append(entries, entry{meta.GetNamespace() + "/" + meta.GetName(), item})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	items = make([]T, len(entries))
+	for i, e := range entries {
+		items[i] = *e.item
+	}
+	return items, true
+}
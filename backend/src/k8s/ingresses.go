@@ -0,0 +1,49 @@
+package k8s
+
+import (
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// IngressInfo sums up where an Ingress is reachable. TLS Secrets link to it
+// as config.
+type IngressInfo struct {
+	ClassName string       `json:"className,omitempty"`
+	Hosts     []string     `json:"hosts,omitempty"`
+	TLS       []IngressTLS `json:"tls,omitempty"`
+	// LoadBalancer are the IPs and hostnames the controller published in
+	// status.loadBalancer.ingress
+	LoadBalancer []string `json:"loadBalancer,omitempty"`
+}
+
+// IngressTLS is one TLS entry: the hosts served with the certificate of the
+// Secret. SecretName is empty for the controller's default certificate.
+type IngressTLS struct {
+	SecretName string   `json:"secretName,omitempty"`
+	Hosts      []string `json:"hosts,omitempty"`
+}
+
+func ingressInfo(i *networkingv1.Ingress) *IngressInfo {
+	info := &IngressInfo{}
+	if i.Spec.IngressClassName != nil {
+		info.ClassName = *i.Spec.IngressClassName
+	} else if class := i.Annotations["kubernetes.io/ingress.class"]; class != "" {
+		info.ClassName = class
+	}
+	for _, rule := range i.Spec.Rules {
+		if rule.Host != "" && !containsString(info.Hosts, rule.Host) {
+			info.Hosts = append(info.Hosts, rule.Host)
+		}
+	}
+	for _, tls := range i.Spec.TLS {
+		info.TLS = append(info.TLS, IngressTLS{SecretName: tls.SecretName, Hosts: tls.Hosts})
+	}
+	for _, ingress := range i.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			info.LoadBalancer = append(info.LoadBalancer, ingress.IP)
+		}
+		if ingress.Hostname != "" {
+			info.LoadBalancer = append(info.LoadBalancer, ingress.Hostname)
+		}
+	}
+	return info
+}
@@ -3,18 +3,31 @@ package k8s
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/anakosmos/backend/src/api"
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	"github.com/anakosmos/backend/src/notes"
+	"github.com/anakosmos/backend/src/settings"
 	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
@@ -32,15 +45,126 @@ type LightResource struct {
 	Labels            map[string]string `json:"labels"`
 	OwnerRefs         []string          `json:"ownerRefs"`
 	CreationTimestamp string            `json:"creationTimestamp"`
+	DeletionTimestamp string            `json:"deletionTimestamp,omitempty"`
+	// AgeSeconds is the age when the resource was sent; it is left out of
+	// state comparisons so aging alone never counts as a change
+	AgeSeconds int64 `json:"ageSeconds"`
+	// LastTransitionTime is the latest status condition transition and
+	// StaleSince when the resource became unhealthy (unset while healthy)
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+	StaleSince         string `json:"staleSince,omitempty"`
+	// HealthRule names the configured health rule that set Status and Health,
+	// and HealthMessage is what it said about them
+	HealthRule    string `json:"healthRule,omitempty"`
+	HealthMessage string `json:"healthMessage,omitempty"`
+	// ruleHealth is what the health rule said, applied once the kind-specific
+	// mapping is done
+	ruleHealth *settings.RuleHealth
+	// Orphaned resources have an owner that no longer exists, or are
+	// ReplicaSets scaled to zero with no Pods left; set by full graph builds
+	Orphaned bool `json:"orphaned,omitempty"`
+	// ownerKinds and scaledDown feed orphan detection
+	ownerKinds []string
+	scaledDown bool
 	// Extra fields needed for link calculation
-	NodeName         string            `json:"nodeName,omitempty"`         // For Pods
+	NodeName         string            `json:"nodeName,omitempty"`         // For Pods and VolumeAttachments
 	Selector         map[string]string `json:"selector,omitempty"`         // For Services, Deployments, etc.
-	ScaleTargetRef   *ScaleTargetRef   `json:"scaleTargetRef,omitempty"`   // For HPAs
-	StorageClassName string            `json:"storageClassName,omitempty"` // For PVCs
+	ScaleTargetRef   *ScaleTargetRef   `json:"scaleTargetRef,omitempty"`   // For HPAs, ScaledObjects and VPAs
+	StorageClassName string            `json:"storageClassName,omitempty"` // For PVCs and PVs
+	ClaimRef         string            `json:"claimRef,omitempty"`         // For PVs (namespace/name of the bound PVC)
+	VolumeNodes      []string          `json:"volumeNodes,omitempty"`      // For PVs (nodes local volumes are pinned to)
 	IngressBackends  []IngressBackend  `json:"ingressBackends,omitempty"`  // For Ingresses
 	Volumes          []VolumeRef       `json:"volumes,omitempty"`          // For Pods
 	EnvRefs          []EnvRef          `json:"envRefs,omitempty"`          // For Pods (ConfigMap/Secret refs from env)
-	HelmRelease      *HelmReleaseInfo  `json:"helmRelease,omitempty"`      // Helm management info
+	Restarts         int32             `json:"restarts,omitempty"`         // For Pods (sum of container restart counts)
+	PodSecurity      *PodSecurityInfo  `json:"podSecurity,omitempty"`      // For Namespaces and workloads
+	// SelectorExpression is the whole selector of a workload using
+	// matchExpressions, which Selector leaves out
+	SelectorExpression string `json:"selectorExpression,omitempty"`
+	// ServiceAccount usage for Pods and workloads
+	ServiceAccount      string           `json:"serviceAccount,omitempty"`
+	ServiceAccountToken bool             `json:"serviceAccountToken,omitempty"` // API token mounted (or automounted for templates)
+	ImagePullSecrets    []string         `json:"imagePullSecrets,omitempty"`
+	SecurityFlags       []string         `json:"securityFlags,omitempty"` // e.g. "default-serviceaccount-token"
+	HelmRelease         *HelmReleaseInfo `json:"helmRelease,omitempty"`   // Helm management info
+	Canary              *CanaryInfo      `json:"canary,omitempty"`        // Argo Rollouts and Flagger Canaries
+	// Deployment sums up the replicas, strategy and revision of Deployments
+	Deployment *DeploymentInfo `json:"deployment,omitempty"`
+	// Service sums up the type, ports and addresses of Services
+	Service *ServiceInfo `json:"service,omitempty"`
+	// Ingress sums up the class, hosts, TLS and addresses of Ingresses
+	Ingress *IngressInfo `json:"ingress,omitempty"`
+	// ExternalHosts are hostnames outside the cluster: the target of an
+	// ExternalName Service, the hosts of Ingresses and HTTPRoutes
+	ExternalHosts []string `json:"externalHosts,omitempty"`
+	// ResourceDefaults is set on workloads whose containers leave requests or
+	// limits unset, with the namespace LimitRange defaults that will apply
+	ResourceDefaults *ResourceDefaults `json:"resourceDefaults,omitempty"`
+	// NodeCoverage is set on DaemonSets with the nodes they should but don't
+	// run a ready pod on
+	NodeCoverage *NodeCoverage `json:"nodeCoverage,omitempty"`
+	// NetworkPolicy summarizes the restrictions of NetworkPolicies
+	NetworkPolicy *NetworkPolicyInfo `json:"networkPolicy,omitempty"`
+	// Job carries completion, parallelism and backoff progress of Jobs
+	Job *JobProgress `json:"job,omitempty"`
+	// RBAC summarizes the rules of Roles and what bindings grant to whom
+	RBAC *RBACInfo `json:"rbac,omitempty"`
+	// Termination is set on Namespaces being deleted
+	Termination *NamespaceTermination `json:"termination,omitempty"`
+	// Gateway summarizes Gateway API Gateways and GatewayClasses
+	Gateway *GatewayInfo `json:"gateway,omitempty"`
+	// Certificate summarizes cert-manager Certificates and issuers
+	Certificate *CertificateInfo `json:"certificate,omitempty"`
+	// ArgoCD summarizes ArgoCD Applications, ApplicationSets and AppProjects
+	ArgoCD *ArgoCDInfo `json:"argocd,omitempty"`
+	// Flux summarizes Flux sources, Kustomizations and HelmReleases
+	Flux *FluxInfo `json:"flux,omitempty"`
+	// CAPI summarizes Cluster API Clusters, MachineDeployments and Machines
+	CAPI *CAPIInfo `json:"capi,omitempty"`
+	// Quota summarizes ResourceQuotas (used vs hard) and LimitRanges
+	Quota *QuotaInfo `json:"quota,omitempty"`
+	// Disruption summarizes the budget and healthy pods of a PodDisruptionBudget
+	Disruption *DisruptionInfo `json:"disruption,omitempty"`
+	// VPA summarizes VerticalPodAutoscalers and their recommendation
+	VPA *VPAInfo `json:"vpa,omitempty"`
+	// KEDA summarizes KEDA ScaledObjects
+	KEDA *KEDAInfo `json:"keda,omitempty"`
+	// Istio summarizes Istio VirtualServices, Gateways and DestinationRules
+	Istio *IstioInfo `json:"istio,omitempty"`
+	// Monitoring summarizes prometheus-operator monitors and rules
+	Monitoring *MonitoringInfo `json:"monitoring,omitempty"`
+	// CSIDriver and Attachment summarize CSIDrivers and VolumeAttachments
+	CSIDriver  *CSIDriverInfo        `json:"csiDriver,omitempty"`
+	Attachment *VolumeAttachmentInfo `json:"attachment,omitempty"`
+	// Containers sums up the containers of a Pod: ready, restarts, images and
+	// why they wait
+	Containers *ContainerSummary `json:"containers,omitempty"`
+	// ExtraFields are values extracted by JSONPath: CRD printer columns and
+	// configured display fields, by column name or label
+	ExtraFields map[string]string `json:"extraFields,omitempty"`
+	// Note is the user note or pin stored for the resource, if any
+	Note *notes.Note `json:"note,omitempty"`
+	// CPUUsage (millicores) and MemoryUsage (bytes) of Pods and Nodes come
+	// from metrics-server, when the cluster serves metrics.k8s.io
+	CPUUsage    *int64 `json:"cpuUsage,omitempty"`
+	MemoryUsage *int64 `json:"memoryUsage,omitempty"`
+	// Requests and limits of a Pod, or summed over the pods of a Node
+	CPURequests    int64 `json:"cpuRequests,omitempty"`
+	CPULimits      int64 `json:"cpuLimits,omitempty"`
+	MemoryRequests int64 `json:"memoryRequests,omitempty"`
+	MemoryLimits   int64 `json:"memoryLimits,omitempty"`
+	// Allocatable CPU and memory of a Node
+	CPUAllocatable    int64 `json:"cpuAllocatable,omitempty"`
+	MemoryAllocatable int64 `json:"memoryAllocatable,omitempty"`
+	// WarningEvents are the latest Warning event reasons of the resource, with
+	// their counts over the last hour
+	WarningEvents []WarningEvent `json:"warningEvents,omitempty"`
+	// Drill is set while an incident-response drill overrides the health
+	Drill bool `json:"drill,omitempty"`
+	// Annotations stay server-side (grouping); they are not part of the payload
+	Annotations map[string]string `json:"-"`
+	// Images of a workload's pod template, server-side too (baselines)
+	Images []string `json:"-"`
 }
 
 type ScaleTargetRef struct {
@@ -74,26 +198,323 @@ type HelmReleaseInfo struct {
 type ClusterLink struct {
 	Source string `json:"source"`
 	Target string `json:"target"`
-	Type   string `json:"type"` // "owner", "network", "config", "storage"
+	Type   string `json:"type"` // "owner", "network", "config", "storage", "policy", "rbac", "containment"
+	// State is "ready" or "notReady" on Service -> Pod links taken from
+	// EndpointSlices
+	State string `json:"state,omitempty"`
+}
+
+// CompactLink is a ClusterLink referencing resources by their position in
+// InitResponse.Resources, sent as [source, target, type] or [source, target,
+// type, state]
+type CompactLink struct {
+	Source, Target int
+	Type, State    string
+}
+
+func (l CompactLink) MarshalJSON() ([]byte, error) {
+	if l.State == "" {
+		return json.Marshal([]interface{}{l.Source, l.Target, l.Type})
+	}
+	return json.Marshal([]interface{}{l.Source, l.Target, l.Type, l.State})
 }
 
 // InitResponse is the response for the /api/cluster/init endpoint
 type InitResponse struct {
 	Resources []LightResource `json:"resources"`
 	Links     []ClusterLink   `json:"links"`
+	// SnapshotHash can be passed back as ?since= to receive an InitDelta
+	SnapshotHash string `json:"snapshotHash,omitempty"`
+	// Access is set when only some namespaces could be listed
+	Access *ClusterAccess `json:"access,omitempty"`
+	// GeneratedAt is when the graph was read from the cluster; precomputed
+	// graphs can be a few seconds old
+	GeneratedAt string `json:"generatedAt,omitempty"`
+	// ResourceVersion can be passed to /api/cluster/delta?since= to receive
+	// the changes made after; only set on unfiltered graphs
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	// CompactLinks replace Links with ?compact=true
+	CompactLinks []CompactLink `json:"compactLinks,omitempty"`
 }
 
-// HandleInit handles the /api/cluster/init endpoint
+// HandleInit handles the /api/cluster/init endpoint. With ?since=<snapshotHash>
+// of a recent response it replies with an InitDelta instead of the full graph;
+// unknown or expired hashes get the full graph. Responses carry the snapshot
+// hash as ETag and If-None-Match gets 304 Not Modified while the graph is
+// unchanged (ages aside). ?namespaces=a,b and ?labelSelector= only list that
+// slice of the cluster (cluster-scoped kinds are left out when namespaces are
+// given); links to resources outside it are dropped. ?kinds=Pod,Service only
+// lists those kinds (and the few they are derived from), sparing the other List
+// calls and the Forbidden errors of credentials that can't read them. Built-in
+// kinds are read from shared informer caches once synced; ?refresh=true lists
+// the API server instead. The unfiltered graph is kept precomputed in the
+// background and rebuilt on informer events, so most calls only read it. System
+// namespaces are left out unless ?includeSystem=true. Stored resource notes and
+// pins are merged in. ?compact=true sends the links as CompactLinks.
+// ?stream=true sends InitChunk lines of newline-delimited JSON instead,
+// namespaces listed one by one as each is ready; streams carry no ETag nor
+// delta.
 func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 	if config == nil {
-		http.Error(w, "Kubernetes config not loaded", http.StatusServiceUnavailable)
+		i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
 		return
 	}
 
+	q := r.URL.Query()
+	var namespaces []string
+	if v := q.Get("namespaces"); v != "" {
+		namespaces = []string{}
+		for _, ns := range strings.Split(v, ",") {
+			if ns = strings.TrimSpace(ns); ns != "" && !containsString(namespaces, ns) {
+				namespaces = append(namespaces, ns)
+			}
+		}
+	}
+	var kinds []string
+	if v := q.Get("kinds"); v != "" {
+		kinds = []string{}
+		for _, kind := range strings.Split(v, ",") {
+			if kind = strings.TrimSpace(kind); kind != "" && !containsString(kinds, kind) {
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+	labelSelector := q.Get("labelSelector")
+	if _, err := labels.Parse(labelSelector); err != nil {
+		i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "labelSelector")
+		return
+	}
+
+	// A drill can make the cluster look unreachable
+	if err := unreachableDrill(config.Host); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	includeSystem := q.Get("includeSystem") == "true"
+	scope := auth.ScopeFromContext(r.Context())
+	noteByID := notes.ForCluster(auth.RequestCluster(r))
+	decorate := func(resources []LightResource) []LightResource {
+		resources = withDrills(config.Host, resources)
+		if len(noteByID) > 0 {
+			for i := range resources {
+				resources[i].Note = noteByID[resources[i].ID]
+			}
+		}
+		return resources
+	}
+
+	opts := graphOptions{labelSelector: labelSelector, kinds: listedKinds(kinds)}
+	var stream *initStream
+	if q.Get("stream") == "true" {
+		// Namespaces built one by one are sent as soon as each is ready; the
+		// links follow once the whole graph is known
+		stream = newInitStream(w)
+		opts.progress = func(partial *InitResponse) {
+			visible := make([]LightResource, 0, len(partial.Resources))
+			for i := range partial.Resources {
+				res := &partial.Resources[i]
+				if (kinds == nil || containsString(kinds, res.Kind)) &&
+					(includeSystem || !isSystemResource(res)) &&
+					(scope.All || resourceAllowed(scope, res)) {
+					visible = append(visible, *res)
+				}
+			}
+			stream.resources(decorate(visible))
+		}
+	}
+
+	refresh := q.Get("refresh") == "true"
+	var graph *InitResponse
+	var generatedAt time.Time
+	if namespaces == nil && labelSelector == "" && !refresh {
+		graph, generatedAt = precomputedGraph(r.Context(), config)
+	}
+	if graph == nil {
+		var err error
+		generatedAt = time.Now()
+		graph, err = buildFilteredGraph(r.Context(), config, namespaces, opts, refresh)
+		if err != nil {
+			if stream != nil {
+				stream.fail(err)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if namespaces != nil || labelSelector != "" {
+		graph.Links = linksWithin(graph.Resources, graph.Links)
+	}
+	if kinds != nil {
+		graph.Resources, graph.Links = onlyKinds(graph.Resources, graph.Links, kinds)
+	}
+	if !includeSystem {
+		graph.Resources, graph.Links = withoutSystem(graph.Resources, graph.Links)
+	}
+
+	// Enforce the tenancy scope of the caller
+	resources, links := filterByScope(graph.Resources, graph.Links, scope)
+	resources = decorate(resources)
+
+	credential := credentialKey(config)
+	hash, snap := newGraphSnapshot(resources, links)
+	rememberSnapshot(credential, hash, snap)
+
+	if stream != nil {
+		stream.resources(resources)
+		stream.finish(links, InitChunk{
+			SnapshotHash:    hash,
+			Access:          graph.Access,
+			GeneratedAt:     generatedAt.UTC().Format(time.RFC3339),
+			ResourceVersion: graph.ResourceVersion,
+		})
+		return
+	}
+
+	// The snapshot hash doubles as ETag: an unchanged graph isn't sent again.
+	// no-cache makes browsers revalidate on every load.
+	compact := q.Get("compact") == "true"
+	etag := `"` + hash + `"`
+	if compact {
+		etag = `"` + hash + `-compact"`
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if since := r.URL.Query().Get("since"); since != "" {
+		if prev := lookupSnapshot(credential, since); prev != nil {
+			delta := graphDelta(prev, snap, resources, links)
+			delta.Since = since
+			delta.SnapshotHash = hash
+			delta.GeneratedAt = generatedAt.UTC().Format(time.RFC3339)
+			json.NewEncoder(w).Encode(delta)
+			return
+		}
+	}
+
+	// Send response
+	response := InitResponse{
+		Resources:       resources,
+		Links:           links,
+		SnapshotHash:    hash,
+		Access:          graph.Access,
+		GeneratedAt:     generatedAt.UTC().Format(time.RFC3339),
+		ResourceVersion: graph.ResourceVersion,
+	}
+	if compact {
+		response.Links, response.CompactLinks = []ClusterLink{}, compactLinks(resources, links)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// BuildGraph lists every supported resource kind and returns them in
+// lightweight format together with the pre-calculated links. In namespaced
+// mode only the permitted namespaces are listed.
+func BuildGraph(ctx context.Context, config *rest.Config) (*InitResponse, error) {
+	return buildFilteredGraph(ctx, config, nil, graphOptions{}, false)
+}
+
+// buildFilteredGraph builds the graph of some namespaces (all when nil) with
+// opts, whose label selector and kinds are pushed down to the List calls. In
+// namespaced mode namespaces are narrowed to the permitted ones.
+func buildFilteredGraph(ctx context.Context, config *rest.Config, namespaces []string, opts graphOptions, live bool) (*InitResponse, error) {
+	opts.clusterScoped = true
+	if permitted := permittedNamespaces(ctx, config); permitted != nil {
+		if namespaces == nil {
+			namespaces = permitted
+		} else {
+			var allowed []string
+			for _, ns := range namespaces {
+				if containsString(permitted, ns) {
+					allowed = append(allowed, ns)
+				}
+			}
+			namespaces = allowed
+		}
+		graph, err := buildNamespacesGraph(ctx, config, namespaces, opts)
+		if graph != nil {
+			graph.Access = namespacedAccess(permitted)
+		}
+		return graph, err
+	}
+
+	// Informers watch the whole cluster, which namespace-scoped credentials
+	// can't do; live requests list it directly
+	if !live {
+		opts.informers = informersFor(config)
+	}
+	switch {
+	case len(namespaces) == 1:
+		opts.namespace = namespaces[0]
+		opts.clusterScoped = false
+		return buildGraph(ctx, config, opts)
+	case namespaces != nil:
+		return buildNamespacesGraph(ctx, config, namespaces, opts)
+	}
+	return buildGraph(ctx, config, opts)
+}
+
+// graphOptions narrows what buildGraph lists
+type graphOptions struct {
+	namespace     string // "" lists every namespace
+	labelSelector string
+	clusterScoped bool // also list Namespaces, Nodes and StorageClasses
+	// informers, when set, serve the kinds they have synced
+	informers *informerSource
+	// kinds, when set, are the only kinds listed besides those they depend on
+	// (kindDependencies); the caller drops the extra ones
+	kinds map[string]bool
+	// progress, when set, receives the graph of each namespace as soon as
+	// buildNamespacesGraph has built it
+	progress func(*InitResponse)
+}
+
+// kindDependencies are the kinds listed along with a requested one because it
+// is derived from them
+var kindDependencies = map[string][]string{
+	"HelmRelease":      {"Secret", "ConfigMap"},
+	"DaemonSet":        {"Node", "Pod"}, // node coverage
+	"ExternalEndpoint": {"Service", "Ingress", "HTTPRoute", "Gateway"},
+}
+
+// lists reports whether buildGraph lists any of kinds
+func (o graphOptions) lists(kinds ...string) bool {
+	if o.kinds == nil {
+		return true
+	}
+	for _, kind := range kinds {
+		if o.kinds[kind] {
+			return true
+		}
+	}
+	return false
+}
+
+// listedKinds returns the kinds to list for the requested ones, nil for all
+func listedKinds(requested []string) map[string]bool {
+	if requested == nil {
+		return nil
+	}
+	kinds := make(map[string]bool)
+	for _, kind := range requested {
+		kinds[kind] = true
+		for _, dep := range kindDependencies[kind] {
+			kinds[dep] = true
+		}
+	}
+	return kinds
+}
+
+func buildGraph(ctx context.Context, config *rest.Config, opts graphOptions) (*InitResponse, error) {
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		http.Error(w, "Failed to create client", http.StatusInternalServerError)
-		return
+		return nil, fmt.Errorf("failed to create client: %w", err)
 	}
 
 	// Create dynamic client for CRD fetching
@@ -102,29 +523,56 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 		log.Printf("Failed to create dynamic client: %v (CRD fetching disabled)", err)
 	}
 
-	ctx := context.Background()
-
 	// Fetch all resources in parallel
 	var (
-		nodes          *corev1.NodeList
-		pods           *corev1.PodList
-		services       *corev1.ServiceList
-		deployments    *appsv1.DeploymentList
-		statefulsets   *appsv1.StatefulSetList
-		daemonsets     *appsv1.DaemonSetList
-		replicasets    *appsv1.ReplicaSetList
-		ingresses      *networkingv1.IngressList
-		pvcs           *corev1.PersistentVolumeClaimList
-		configmaps     *corev1.ConfigMapList
-		secrets        *corev1.SecretList
-		storageclasses *storagev1.StorageClassList
-		jobs           *batchv1.JobList
-		cronjobs       *batchv1.CronJobList
-		hpas           *autoscalingv2.HorizontalPodAutoscalerList
-		argoApps       *unstructured.UnstructuredList
-		wg             sync.WaitGroup
-		mu             sync.Mutex
-		errors         []error
+		namespaces          *corev1.NamespaceList
+		nodes               *corev1.NodeList
+		pods                *corev1.PodList
+		services            *corev1.ServiceList
+		deployments         *appsv1.DeploymentList
+		statefulsets        *appsv1.StatefulSetList
+		daemonsets          *appsv1.DaemonSetList
+		replicasets         *appsv1.ReplicaSetList
+		ingresses           *networkingv1.IngressList
+		netpols             *networkingv1.NetworkPolicyList
+		pdbs                *policyv1.PodDisruptionBudgetList
+		endpointSlices      *discoveryv1.EndpointSliceList
+		pvcs                *corev1.PersistentVolumeClaimList
+		pvs                 *corev1.PersistentVolumeList
+		configmaps          *corev1.ConfigMapList
+		secrets             *corev1.SecretList
+		storageclasses      *storagev1.StorageClassList
+		csiDrivers          *storagev1.CSIDriverList
+		volumeAttachments   *storagev1.VolumeAttachmentList
+		jobs                *batchv1.JobList
+		cronjobs            *batchv1.CronJobList
+		hpas                *autoscalingv2.HorizontalPodAutoscalerList
+		argoApps            *unstructured.UnstructuredList
+		applicationSets     *unstructured.UnstructuredList
+		appProjects         *unstructured.UnstructuredList
+		rollouts            *unstructured.UnstructuredList
+		scaledObjects       *unstructured.UnstructuredList
+		vpas                *unstructured.UnstructuredList
+		canaries            *unstructured.UnstructuredList
+		httpRoutes          *unstructured.UnstructuredList
+		gateways            *unstructured.UnstructuredList
+		gatewayClasses      *unstructured.UnstructuredList
+		certificates        *unstructured.UnstructuredList
+		issuers             *unstructured.UnstructuredList
+		clusterIssuers      *unstructured.UnstructuredList
+		limitRanges         *corev1.LimitRangeList
+		warningEvents       *corev1.EventList
+		podMetrics          *unstructured.UnstructuredList
+		nodeMetrics         *unstructured.UnstructuredList
+		resourceQuotas      *corev1.ResourceQuotaList
+		serviceAccounts     *corev1.ServiceAccountList
+		roles               *rbacv1.RoleList
+		roleBindings        *rbacv1.RoleBindingList
+		clusterRoles        *rbacv1.ClusterRoleList
+		clusterRoleBindings *rbacv1.ClusterRoleBindingList
+		wg                  sync.WaitGroup
+		mu                  sync.Mutex
+		errors              []error
 	)
 
 	addError := func(err error) {
@@ -135,134 +583,885 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	listOpts := metav1.ListOptions{}
+	listOpts := metav1.ListOptions{LabelSelector: opts.labelSelector}
+	// Built-in kinds come from the informer caches when synced
+	src := opts.informers
+	if src == nil {
+		src = &informerSource{}
+	}
+
+	// Only list optional APIs the cluster actually serves
+	served := clusterServes(config)
 
 	// Fetch all resources in parallel
-	wg.Add(16)
+	wg.Add(46)
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list namespaces")()
+		if !opts.lists("Namespace") || !opts.clusterScoped {
+			return
+		}
+		if items, ok := cachedItems[corev1.Namespace](src.namespaces, opts); ok {
+			namespaces = &corev1.NamespaceList{Items: items}
+			return
+		}
+		var err error
+		namespaces, err = clientset.CoreV1().Namespaces().List(ctx, listOpts)
+		addError(err)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list nodes")()
+		if !opts.lists("Node") || !opts.clusterScoped {
+			return
+		}
+		if items, ok := cachedItems[corev1.Node](src.nodes, opts); ok {
+			nodes = &corev1.NodeList{Items: items}
+			return
+		}
+		var err error
+		nodes, err = clientset.CoreV1().Nodes().List(ctx, listOpts)
+		addError(err)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list pods")()
+		if !opts.lists("Pod") {
+			return
+		}
+		if items, ok := cachedItems[corev1.Pod](src.pods, opts); ok {
+			pods = &corev1.PodList{Items: items}
+			return
+		}
+		var err error
+		pods, err = clientset.CoreV1().Pods(opts.namespace).List(ctx, listOpts)
+		addError(err)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list services")()
+		if !opts.lists("Service") {
+			return
+		}
+		if items, ok := cachedItems[corev1.Service](src.services, opts); ok {
+			services = &corev1.ServiceList{Items: items}
+			return
+		}
+		var err error
+		services, err = clientset.CoreV1().Services(opts.namespace).List(ctx, listOpts)
+		addError(err)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list deployments")()
+		if !opts.lists("Deployment") {
+			return
+		}
+		if items, ok := cachedItems[appsv1.Deployment](src.deployments, opts); ok {
+			deployments = &appsv1.DeploymentList{Items: items}
+			return
+		}
+		var err error
+		deployments, err = clientset.AppsV1().Deployments(opts.namespace).List(ctx, listOpts)
+		addError(err)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list statefulsets")()
+		if !opts.lists("StatefulSet") {
+			return
+		}
+		if items, ok := cachedItems[appsv1.StatefulSet](src.statefulsets, opts); ok {
+			statefulsets = &appsv1.StatefulSetList{Items: items}
+			return
+		}
+		var err error
+		statefulsets, err = clientset.AppsV1().StatefulSets(opts.namespace).List(ctx, listOpts)
+		addError(err)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list daemonsets")()
+		if !opts.lists("DaemonSet") {
+			return
+		}
+		if items, ok := cachedItems[appsv1.DaemonSet](src.daemonsets, opts); ok {
+			daemonsets = &appsv1.DaemonSetList{Items: items}
+			return
+		}
+		var err error
+		daemonsets, err = clientset.AppsV1().DaemonSets(opts.namespace).List(ctx, listOpts)
+		addError(err)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list replicasets")()
+		if !opts.lists("ReplicaSet") {
+			return
+		}
+		if items, ok := cachedItems[appsv1.ReplicaSet](src.replicasets, opts); ok {
+			replicasets = &appsv1.ReplicaSetList{Items: items}
+			return
+		}
+		var err error
+		replicasets, err = clientset.AppsV1().ReplicaSets(opts.namespace).List(ctx, listOpts)
+		addError(err)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list ingresses")()
+		if !opts.lists("Ingress") || !served.Has("networking.k8s.io", "v1", "ingresses") {
+			return
+		}
+		if items, ok := cachedItems[networkingv1.Ingress](src.ingresses, opts); ok {
+			ingresses = &networkingv1.IngressList{Items: items}
+			return
+		}
+		var err error
+		ingresses, err = clientset.NetworkingV1().Ingresses(opts.namespace).List(ctx, listOpts)
+		addError(err)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list endpointslices")()
+		// Only used to link Services to their Pods
+		if !opts.lists("Service") || !opts.lists("Pod") || !served.Has("discovery.k8s.io", "v1", "endpointslices") {
+			return
+		}
+		if items, ok := cachedItems[discoveryv1.EndpointSlice](src.endpointslices, opts); ok {
+			endpointSlices = &discoveryv1.EndpointSliceList{Items: items}
+			return
+		}
+		var err error
+		endpointSlices, err = clientset.DiscoveryV1().EndpointSlices(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			// Service -> Pod links fall back to selector matching
+			log.Printf("EndpointSlices not available: %v", err)
+			endpointSlices = nil
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list networkpolicies")()
+		if !opts.lists("NetworkPolicy") || !served.Has("networking.k8s.io", "v1", "networkpolicies") {
+			return
+		}
+		if items, ok := cachedItems[networkingv1.NetworkPolicy](src.networkpolicies, opts); ok {
+			netpols = &networkingv1.NetworkPolicyList{Items: items}
+			return
+		}
+		var err error
+		netpols, err = clientset.NetworkingV1().NetworkPolicies(opts.namespace).List(ctx, listOpts)
+		addError(err)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list poddisruptionbudgets")()
+		if !opts.lists("PodDisruptionBudget") || !served.Has("policy", "v1", "poddisruptionbudgets") {
+			return
+		}
+		if items, ok := cachedItems[policyv1.PodDisruptionBudget](src.pdbs, opts); ok {
+			pdbs = &policyv1.PodDisruptionBudgetList{Items: items}
+			return
+		}
+		var err error
+		pdbs, err = clientset.PolicyV1().PodDisruptionBudgets(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			// The graph just has no disruption budgets
+			log.Printf("PodDisruptionBudgets not available: %v", err)
+			pdbs = nil
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list pvcs")()
+		if !opts.lists("PersistentVolumeClaim") {
+			return
+		}
+		if items, ok := cachedItems[corev1.PersistentVolumeClaim](src.pvcs, opts); ok {
+			pvcs = &corev1.PersistentVolumeClaimList{Items: items}
+			return
+		}
+		var err error
+		pvcs, err = clientset.CoreV1().PersistentVolumeClaims(opts.namespace).List(ctx, listOpts)
+		addError(err)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list pvs")()
+		if !opts.lists("PersistentVolume") || !opts.clusterScoped {
+			return
+		}
+		if items, ok := cachedItems[corev1.PersistentVolume](src.pvs, opts); ok {
+			pvs = &corev1.PersistentVolumeList{Items: items}
+			return
+		}
+		var err error
+		pvs, err = clientset.CoreV1().PersistentVolumes().List(ctx, listOpts)
+		addError(err)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list configmaps")()
+		if !opts.lists("ConfigMap") {
+			return
+		}
+		if items, ok := cachedItems[corev1.ConfigMap](src.configmaps, opts); ok {
+			configmaps = &corev1.ConfigMapList{Items: items}
+			return
+		}
+		var err error
+		configmaps, err = clientset.CoreV1().ConfigMaps(opts.namespace).List(ctx, listOpts)
+		addError(err)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list secrets")()
+		if !opts.lists("Secret") {
+			return
+		}
+		if items, ok := cachedItems[corev1.Secret](src.secrets, opts); ok {
+			secrets = &corev1.SecretList{Items: items}
+			return
+		}
+		var err error
+		secrets, err = clientset.CoreV1().Secrets(opts.namespace).List(ctx, listOpts)
+		addError(err)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list storageclasses")()
+		if !opts.lists("StorageClass") || !opts.clusterScoped {
+			return
+		}
+		if items, ok := cachedItems[storagev1.StorageClass](src.storageclasses, opts); ok {
+			storageclasses = &storagev1.StorageClassList{Items: items}
+			return
+		}
+		var err error
+		storageclasses, err = clientset.StorageV1().StorageClasses().List(ctx, listOpts)
+		addError(err)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list csidrivers")()
+		if !opts.lists("CSIDriver") || !opts.clusterScoped || !served.Has("storage.k8s.io", "v1", "csidrivers") {
+			return
+		}
+		if items, ok := cachedItems[storagev1.CSIDriver](src.csidrivers, opts); ok {
+			csiDrivers = &storagev1.CSIDriverList{Items: items}
+			return
+		}
+		var err error
+		csiDrivers, err = clientset.StorageV1().CSIDrivers().List(ctx, listOpts)
+		if err != nil {
+			log.Printf("csidrivers not available: %v", err)
+			csiDrivers = nil
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list volumeattachments")()
+		if !opts.lists("VolumeAttachment") || !opts.clusterScoped || !served.Has("storage.k8s.io", "v1", "volumeattachments") {
+			return
+		}
+		if items, ok := cachedItems[storagev1.VolumeAttachment](src.volumeattachments, opts); ok {
+			volumeAttachments = &storagev1.VolumeAttachmentList{Items: items}
+			return
+		}
+		var err error
+		volumeAttachments, err = clientset.StorageV1().VolumeAttachments().List(ctx, listOpts)
+		if err != nil {
+			log.Printf("volumeattachments not available: %v", err)
+			volumeAttachments = nil
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list jobs")()
+		if !opts.lists("Job") {
+			return
+		}
+		if items, ok := cachedItems[batchv1.Job](src.jobs, opts); ok {
+			jobs = &batchv1.JobList{Items: items}
+			return
+		}
+		var err error
+		jobs, err = clientset.BatchV1().Jobs(opts.namespace).List(ctx, listOpts)
+		addError(err)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list cronjobs")()
+		if !opts.lists("CronJob") || !served.Has("batch", "v1", "cronjobs") {
+			return
+		}
+		if items, ok := cachedItems[batchv1.CronJob](src.cronjobs, opts); ok {
+			cronjobs = &batchv1.CronJobList{Items: items}
+			return
+		}
+		var err error
+		cronjobs, err = clientset.BatchV1().CronJobs(opts.namespace).List(ctx, listOpts)
+		addError(err)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list hpas")()
+		if !opts.lists("HorizontalPodAutoscaler") || !served.Has("autoscaling", "v2", "horizontalpodautoscalers") {
+			return
+		}
+		if items, ok := cachedItems[autoscalingv2.HorizontalPodAutoscaler](src.hpas, opts); ok {
+			hpas = &autoscalingv2.HorizontalPodAutoscalerList{Items: items}
+			return
+		}
+		var err error
+		hpas, err = clientset.AutoscalingV2().HorizontalPodAutoscalers(opts.namespace).List(ctx, listOpts)
+		addError(err)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list limitranges")()
+		// Also needed for the defaults of workloads
+		if !opts.lists("LimitRange", "Pod", "Deployment", "StatefulSet", "DaemonSet", "Job", "CronJob") {
+			return
+		}
+		if items, ok := cachedItems[corev1.LimitRange](src.limitranges, opts); ok {
+			limitRanges = &corev1.LimitRangeList{Items: items}
+			return
+		}
+		var err error
+		limitRanges, err = clientset.CoreV1().LimitRanges(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			// Without them workloads lacking requests just show no defaults
+			log.Printf("LimitRanges not available: %v", err)
+			limitRanges = nil
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list warning events")()
+		// Events don't carry the labels of the objects they are about
+		eventOpts := opts
+		eventOpts.labelSelector = ""
+		if items, ok := cachedItems[corev1.Event](src.events, eventOpts); ok {
+			warningEvents = &corev1.EventList{Items: items}
+			return
+		}
+		var err error
+		warningEvents, err = clientset.CoreV1().Events(opts.namespace).List(ctx, metav1.ListOptions{FieldSelector: warningEventSelector})
+		if err != nil {
+			// Resources just come without their recent warnings
+			log.Printf("Events not available: %v", err)
+			warningEvents = nil
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list resourcequotas")()
+		if !opts.lists("ResourceQuota") {
+			return
+		}
+		if items, ok := cachedItems[corev1.ResourceQuota](src.resourcequotas, opts); ok {
+			resourceQuotas = &corev1.ResourceQuotaList{Items: items}
+			return
+		}
+		var err error
+		resourceQuotas, err = clientset.CoreV1().ResourceQuotas(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			log.Printf("ResourceQuotas not available: %v", err)
+			resourceQuotas = nil
+		}
+	}()
 
 	go func() {
 		defer wg.Done()
+		defer api.StartSpan(ctx, "list serviceaccounts")()
+		if !opts.lists("ServiceAccount") {
+			return
+		}
+		if items, ok := cachedItems[corev1.ServiceAccount](src.serviceaccounts, opts); ok {
+			serviceAccounts = &corev1.ServiceAccountList{Items: items}
+			return
+		}
+		var err error
+		serviceAccounts, err = clientset.CoreV1().ServiceAccounts(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			// Without RBAC read access the graph just has no RBAC layer
+			log.Printf("serviceaccounts not available: %v", err)
+			serviceAccounts = nil
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list roles")()
+		if !opts.lists("Role") {
+			return
+		}
+		if items, ok := cachedItems[rbacv1.Role](src.roles, opts); ok {
+			roles = &rbacv1.RoleList{Items: items}
+			return
+		}
+		var err error
+		roles, err = clientset.RbacV1().Roles(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			// Without RBAC read access the graph just has no RBAC layer
+			log.Printf("roles not available: %v", err)
+			roles = nil
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list rolebindings")()
+		if !opts.lists("RoleBinding") {
+			return
+		}
+		if items, ok := cachedItems[rbacv1.RoleBinding](src.rolebindings, opts); ok {
+			roleBindings = &rbacv1.RoleBindingList{Items: items}
+			return
+		}
+		var err error
+		roleBindings, err = clientset.RbacV1().RoleBindings(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			// Without RBAC read access the graph just has no RBAC layer
+			log.Printf("rolebindings not available: %v", err)
+			roleBindings = nil
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list clusterroles")()
+		if !opts.lists("ClusterRole") || !opts.clusterScoped {
+			return
+		}
+		if items, ok := cachedItems[rbacv1.ClusterRole](src.clusterroles, opts); ok {
+			clusterRoles = &rbacv1.ClusterRoleList{Items: items}
+			return
+		}
+		var err error
+		clusterRoles, err = clientset.RbacV1().ClusterRoles().List(ctx, listOpts)
+		if err != nil {
+			// Without RBAC read access the graph just has no RBAC layer
+			log.Printf("clusterroles not available: %v", err)
+			clusterRoles = nil
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer api.StartSpan(ctx, "list clusterrolebindings")()
+		if !opts.lists("ClusterRoleBinding") || !opts.clusterScoped {
+			return
+		}
+		if items, ok := cachedItems[rbacv1.ClusterRoleBinding](src.clusterrolebindings, opts); ok {
+			clusterRoleBindings = &rbacv1.ClusterRoleBindingList{Items: items}
+			return
+		}
 		var err error
-		nodes, err = clientset.CoreV1().Nodes().List(ctx, listOpts)
-		addError(err)
+		clusterRoleBindings, err = clientset.RbacV1().ClusterRoleBindings().List(ctx, listOpts)
+		if err != nil {
+			// Without RBAC read access the graph just has no RBAC layer
+			log.Printf("clusterrolebindings not available: %v", err)
+			clusterRoleBindings = nil
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
+		defer api.StartSpan(ctx, "list argoApps")()
+		if !opts.lists("Application") || dynamicClient == nil || !served.Has("argoproj.io", "v1alpha1", "applications") {
+			return
+		}
+		gvr := schema.GroupVersionResource{
+			Group:    "argoproj.io",
+			Version:  "v1alpha1",
+			Resource: "applications",
+		}
 		var err error
-		pods, err = clientset.CoreV1().Pods("").List(ctx, listOpts)
-		addError(err)
+		argoApps, err = dynamicClient.Resource(gvr).Namespace(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			// ArgoCD might not be installed, just log
+			log.Printf("ArgoCD applications not available: %v", err)
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
+		defer api.StartSpan(ctx, "list applicationSets")()
+		if !opts.lists("ApplicationSet") || dynamicClient == nil || !served.Has("argoproj.io", "v1alpha1", "applicationsets") {
+			return
+		}
+		gvr := schema.GroupVersionResource{
+			Group:    "argoproj.io",
+			Version:  "v1alpha1",
+			Resource: "applicationsets",
+		}
 		var err error
-		services, err = clientset.CoreV1().Services("").List(ctx, listOpts)
-		addError(err)
+		applicationSets, err = dynamicClient.Resource(gvr).Namespace(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			log.Printf("ArgoCD applicationsets not available: %v", err)
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
+		defer api.StartSpan(ctx, "list appProjects")()
+		if !opts.lists("AppProject") || dynamicClient == nil || !served.Has("argoproj.io", "v1alpha1", "appprojects") {
+			return
+		}
+		gvr := schema.GroupVersionResource{
+			Group:    "argoproj.io",
+			Version:  "v1alpha1",
+			Resource: "appprojects",
+		}
 		var err error
-		deployments, err = clientset.AppsV1().Deployments("").List(ctx, listOpts)
-		addError(err)
+		appProjects, err = dynamicClient.Resource(gvr).Namespace(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			log.Printf("ArgoCD appprojects not available: %v", err)
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
+		defer api.StartSpan(ctx, "list rollouts")()
+		if !opts.lists("Rollout") || dynamicClient == nil || !served.Has("argoproj.io", "v1alpha1", "rollouts") {
+			return
+		}
+		gvr := schema.GroupVersionResource{
+			Group:    "argoproj.io",
+			Version:  "v1alpha1",
+			Resource: "rollouts",
+		}
 		var err error
-		statefulsets, err = clientset.AppsV1().StatefulSets("").List(ctx, listOpts)
-		addError(err)
+		rollouts, err = dynamicClient.Resource(gvr).Namespace(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			log.Printf("Argo Rollouts not available: %v", err)
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
+		defer api.StartSpan(ctx, "list vpas")()
+		if !opts.lists("VerticalPodAutoscaler") || dynamicClient == nil || !served.Has("autoscaling.k8s.io", "v1", "verticalpodautoscalers") {
+			return
+		}
+		gvr := schema.GroupVersionResource{
+			Group:    "autoscaling.k8s.io",
+			Version:  "v1",
+			Resource: "verticalpodautoscalers",
+		}
 		var err error
-		daemonsets, err = clientset.AppsV1().DaemonSets("").List(ctx, listOpts)
-		addError(err)
+		vpas, err = dynamicClient.Resource(gvr).Namespace(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			log.Printf("VerticalPodAutoscalers not available: %v", err)
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
+		defer api.StartSpan(ctx, "list scaledObjects")()
+		if !opts.lists("ScaledObject") || dynamicClient == nil || !served.Has("keda.sh", "v1alpha1", "scaledobjects") {
+			return
+		}
+		gvr := schema.GroupVersionResource{
+			Group:    "keda.sh",
+			Version:  "v1alpha1",
+			Resource: "scaledobjects",
+		}
 		var err error
-		replicasets, err = clientset.AppsV1().ReplicaSets("").List(ctx, listOpts)
-		addError(err)
+		scaledObjects, err = dynamicClient.Resource(gvr).Namespace(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			log.Printf("KEDA scaledobjects not available: %v", err)
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
+		defer api.StartSpan(ctx, "list canaries")()
+		if !opts.lists("Canary") || dynamicClient == nil || !served.Has("flagger.app", "v1beta1", "canaries") {
+			return
+		}
+		gvr := schema.GroupVersionResource{
+			Group:    "flagger.app",
+			Version:  "v1beta1",
+			Resource: "canaries",
+		}
 		var err error
-		ingresses, err = clientset.NetworkingV1().Ingresses("").List(ctx, listOpts)
-		addError(err)
+		canaries, err = dynamicClient.Resource(gvr).Namespace(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			log.Printf("Flagger canaries not available: %v", err)
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
+		defer api.StartSpan(ctx, "list httproutes")()
+		if !opts.lists("HTTPRoute") || dynamicClient == nil || !served.Has("gateway.networking.k8s.io", "v1", "httproutes") {
+			return
+		}
+		gvr := schema.GroupVersionResource{
+			Group:    "gateway.networking.k8s.io",
+			Version:  "v1",
+			Resource: "httproutes",
+		}
 		var err error
-		pvcs, err = clientset.CoreV1().PersistentVolumeClaims("").List(ctx, listOpts)
-		addError(err)
+		httpRoutes, err = dynamicClient.Resource(gvr).Namespace(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			log.Printf("Gateway API HTTPRoutes not available: %v", err)
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
+		defer api.StartSpan(ctx, "list gateways")()
+		if !opts.lists("Gateway") || dynamicClient == nil || !served.Has("gateway.networking.k8s.io", "v1", "gateways") {
+			return
+		}
+		gvr := schema.GroupVersionResource{
+			Group:    "gateway.networking.k8s.io",
+			Version:  "v1",
+			Resource: "gateways",
+		}
 		var err error
-		configmaps, err = clientset.CoreV1().ConfigMaps("").List(ctx, listOpts)
-		addError(err)
+		gateways, err = dynamicClient.Resource(gvr).Namespace(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			log.Printf("Gateway API Gateways not available: %v", err)
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
+		defer api.StartSpan(ctx, "list gatewayclasses")()
+		if !opts.lists("GatewayClass") || !opts.clusterScoped || dynamicClient == nil || !served.Has("gateway.networking.k8s.io", "v1", "gatewayclasses") {
+			return
+		}
+		gvr := schema.GroupVersionResource{
+			Group:    "gateway.networking.k8s.io",
+			Version:  "v1",
+			Resource: "gatewayclasses",
+		}
 		var err error
-		secrets, err = clientset.CoreV1().Secrets("").List(ctx, listOpts)
-		addError(err)
+		gatewayClasses, err = dynamicClient.Resource(gvr).List(ctx, listOpts)
+		if err != nil {
+			log.Printf("Gateway API GatewayClasses not available: %v", err)
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
+		defer api.StartSpan(ctx, "list pod metrics")()
+		if !opts.lists("Pod") || dynamicClient == nil || !served.Has(metricsGroup, "v1beta1", "pods") {
+			return
+		}
+		gvr := schema.GroupVersionResource{Group: metricsGroup, Version: "v1beta1", Resource: "pods"}
 		var err error
-		storageclasses, err = clientset.StorageV1().StorageClasses().List(ctx, listOpts)
-		addError(err)
+		podMetrics, err = dynamicClient.Resource(gvr).Namespace(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			log.Printf("Pod metrics not available: %v", err)
+			podMetrics = nil
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
+		defer api.StartSpan(ctx, "list node metrics")()
+		if !opts.lists("Node") || !opts.clusterScoped || dynamicClient == nil || !served.Has(metricsGroup, "v1beta1", "nodes") {
+			return
+		}
+		gvr := schema.GroupVersionResource{Group: metricsGroup, Version: "v1beta1", Resource: "nodes"}
 		var err error
-		jobs, err = clientset.BatchV1().Jobs("").List(ctx, listOpts)
-		addError(err)
+		nodeMetrics, err = dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			log.Printf("Node metrics not available: %v", err)
+			nodeMetrics = nil
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
+		defer api.StartSpan(ctx, "list certificates")()
+		if !opts.lists("Certificate") || dynamicClient == nil || !served.Has(certManagerGroup, "v1", "certificates") {
+			return
+		}
+		gvr := schema.GroupVersionResource{
+			Group:    certManagerGroup,
+			Version:  "v1",
+			Resource: "certificates",
+		}
 		var err error
-		cronjobs, err = clientset.BatchV1().CronJobs("").List(ctx, listOpts)
-		addError(err)
+		certificates, err = dynamicClient.Resource(gvr).Namespace(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			log.Printf("cert-manager Certificates not available: %v", err)
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
+		defer api.StartSpan(ctx, "list issuers")()
+		if !opts.lists("Issuer") || dynamicClient == nil || !served.Has(certManagerGroup, "v1", "issuers") {
+			return
+		}
+		gvr := schema.GroupVersionResource{
+			Group:    certManagerGroup,
+			Version:  "v1",
+			Resource: "issuers",
+		}
 		var err error
-		hpas, err = clientset.AutoscalingV2().HorizontalPodAutoscalers("").List(ctx, listOpts)
-		addError(err)
+		issuers, err = dynamicClient.Resource(gvr).Namespace(opts.namespace).List(ctx, listOpts)
+		if err != nil {
+			log.Printf("cert-manager Issuers not available: %v", err)
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
-		if dynamicClient == nil {
+		defer api.StartSpan(ctx, "list clusterissuers")()
+		if !opts.lists("ClusterIssuer") || !opts.clusterScoped || dynamicClient == nil || !served.Has(certManagerGroup, "v1", "clusterissuers") {
 			return
 		}
 		gvr := schema.GroupVersionResource{
-			Group:    "argoproj.io",
-			Version:  "v1alpha1",
-			Resource: "applications",
+			Group:    certManagerGroup,
+			Version:  "v1",
+			Resource: "clusterissuers",
 		}
 		var err error
-		argoApps, err = dynamicClient.Resource(gvr).Namespace("").List(ctx, listOpts)
+		clusterIssuers, err = dynamicClient.Resource(gvr).List(ctx, listOpts)
 		if err != nil {
-			// ArgoCD might not be installed, just log
-			log.Printf("ArgoCD applications not available: %v", err)
+			log.Printf("cert-manager ClusterIssuers not available: %v", err)
+		}
+	}()
+
+	// Printer columns of CRD objects; cluster-scoped, like the definitions
+	go func() {
+		defer wg.Done()
+		if !opts.clusterScoped || dynamicClient == nil {
+			return
 		}
+		loadPrinterColumns(ctx, dynamicClient, config.Host)
 	}()
 
+	// Flux CD sources and reconcilers, at the version the cluster serves
+	fluxLists := make([]*unstructured.UnstructuredList, len(fluxKinds))
+	wg.Add(len(fluxKinds))
+	for i, fk := range fluxKinds {
+		go func() {
+			defer wg.Done()
+			defer api.StartSpan(ctx, "list "+fk.resource)()
+			if !opts.lists(fk.kind) {
+				return
+			}
+			version := fk.servedVersion(served)
+			if dynamicClient == nil || version == "" {
+				return
+			}
+			gvr := schema.GroupVersionResource{Group: fk.group, Version: version, Resource: fk.resource}
+			list, err := dynamicClient.Resource(gvr).Namespace(opts.namespace).List(ctx, listOpts)
+			if err != nil {
+				log.Printf("Flux %s not available: %v", fk.resource, err)
+				return
+			}
+			fluxLists[i] = list
+		}()
+	}
+
+	// Cluster API topology, at the version the cluster serves
+	capiLists := make([]*unstructured.UnstructuredList, len(capiKinds))
+	wg.Add(len(capiKinds))
+	for i, fk := range capiKinds {
+		go func() {
+			defer wg.Done()
+			defer api.StartSpan(ctx, "list "+fk.resource)()
+			if !opts.lists(fk.kind) {
+				return
+			}
+			version := fk.servedVersion(served)
+			if dynamicClient == nil || version == "" {
+				return
+			}
+			gvr := schema.GroupVersionResource{Group: fk.group, Version: version, Resource: fk.resource}
+			list, err := dynamicClient.Resource(gvr).Namespace(opts.namespace).List(ctx, listOpts)
+			if err != nil {
+				log.Printf("Cluster API %s not available: %v", fk.resource, err)
+				return
+			}
+			capiLists[i] = list
+		}()
+	}
+
+	// Istio traffic routing, at the version the cluster serves
+	istioLists := make([]*unstructured.UnstructuredList, len(istioKinds))
+	wg.Add(len(istioKinds))
+	for i, ik := range istioKinds {
+		go func() {
+			defer wg.Done()
+			defer api.StartSpan(ctx, "list istio "+ik.resource)()
+			if !opts.lists(ik.kind) {
+				return
+			}
+			version := ik.servedVersion(served)
+			if dynamicClient == nil || version == "" {
+				return
+			}
+			gvr := schema.GroupVersionResource{Group: ik.group, Version: version, Resource: ik.resource}
+			list, err := dynamicClient.Resource(gvr).Namespace(opts.namespace).List(ctx, listOpts)
+			if err != nil {
+				log.Printf("Istio %s not available: %v", ik.resource, err)
+				return
+			}
+			istioLists[i] = list
+		}()
+	}
+
+	// prometheus-operator monitors and rules
+	monitoringLists := make([]*unstructured.UnstructuredList, len(monitoringKinds))
+	wg.Add(len(monitoringKinds))
+	for i, mk := range monitoringKinds {
+		go func() {
+			defer wg.Done()
+			defer api.StartSpan(ctx, "list "+mk.resource)()
+			if !opts.lists(mk.kind) {
+				return
+			}
+			version := mk.servedVersion(served)
+			if dynamicClient == nil || version == "" {
+				return
+			}
+			gvr := schema.GroupVersionResource{Group: mk.group, Version: version, Resource: mk.resource}
+			list, err := dynamicClient.Resource(gvr).Namespace(opts.namespace).List(ctx, listOpts)
+			if err != nil {
+				log.Printf("prometheus-operator %s not available: %v", mk.resource, err)
+				return
+			}
+			monitoringLists[i] = list
+		}()
+	}
+
 	wg.Wait()
 
 	// Check for critical errors
@@ -270,15 +1469,19 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 		log.Printf("Some resources failed to fetch: %v", errors)
 	}
 
+	endBuild := api.StartSpan(ctx, "build graph")
+
 	// Build resource maps for link calculation
-	nodeMap := make(map[string]string)     // name -> uid
-	podMap := make(map[string]string)      // uid -> namespace/name
-	svcMap := make(map[string]string)      // namespace/name -> uid
-	cmMap := make(map[string]string)       // namespace/name -> uid
-	secretMap := make(map[string]string)   // namespace/name -> uid
-	pvcMap := make(map[string]string)      // namespace/name -> uid
-	scMap := make(map[string]string)       // name -> uid
-	workloadMap := make(map[string]string) // namespace/kind/name -> uid
+	nodeMap := make(map[string]string)      // name -> uid
+	podMap := make(map[string]string)       // uid -> namespace/name
+	svcMap := make(map[string]string)       // namespace/name -> uid
+	cmMap := make(map[string]string)        // namespace/name -> uid
+	secretMap := make(map[string]string)    // namespace/name -> uid
+	saTokenMap := make(map[string][]string) // namespace/serviceaccount -> token secret uids
+	pvcMap := make(map[string]string)       // namespace/name -> uid
+	pvMap := make(map[string]string)        // name -> uid
+	scMap := make(map[string]string)        // name -> uid
+	workloadMap := make(map[string]string)  // namespace/kind/name -> uid
 
 	// Initialize maps for safe iteration
 	if nodes != nil {
@@ -299,6 +1502,10 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 	if secrets != nil {
 		for _, sec := range secrets.Items {
 			secretMap[sec.Namespace+"/"+sec.Name] = string(sec.UID)
+			if sec.Type == corev1.SecretTypeServiceAccountToken {
+				key := sec.Namespace + "/" + sec.Annotations[corev1.ServiceAccountNameKey]
+				saTokenMap[key] = append(saTokenMap[key], string(sec.UID))
+			}
 		}
 	}
 	if pvcs != nil {
@@ -306,6 +1513,11 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 			pvcMap[pvc.Namespace+"/"+pvc.Name] = string(pvc.UID)
 		}
 	}
+	if pvs != nil {
+		for _, pv := range pvs.Items {
+			pvMap[pv.Name] = string(pv.UID)
+		}
+	}
 	if storageclasses != nil {
 		for _, sc := range storageclasses.Items {
 			scMap[sc.Name] = string(sc.UID)
@@ -326,189 +1538,61 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 			workloadMap[r.Namespace+"/ReplicaSet/"+r.Name] = string(r.UID)
 		}
 	}
+	if rollouts != nil {
+		for _, r := range rollouts.Items {
+			workloadMap[r.GetNamespace()+"/Rollout/"+r.GetName()] = string(r.GetUID())
+		}
+	}
 
 	// Process all resources and build links
 	resources := []LightResource{}
 	links := []ClusterLink{}
 
-	// Helper function to extract Helm info from labels
-	extractHelmInfo := func(labels, annotations map[string]string, ns string) *HelmReleaseInfo {
-		releaseName := labels["app.kubernetes.io/instance"]
-		if releaseName == "" {
-			releaseName = labels["helm.sh/release-name"]
-		}
-		if releaseName == "" {
-			releaseName = annotations["meta.helm.sh/release-name"]
-		}
-		if releaseName == "" {
-			return nil
+	// Process Nodes
+	if nodes != nil {
+		for i := range nodes.Items {
+			resources = append(resources, lightNode(&nodes.Items[i]))
 		}
+	}
 
-		// Check if actually Helm-managed
-		hasManagedByHelm := labels["app.kubernetes.io/managed-by"] == "Helm"
-		hasHelmChart := labels["helm.sh/chart"] != ""
-		hasHelmMetadata := labels["meta.helm.sh/release-name"] != "" || annotations["meta.helm.sh/release-name"] != ""
-
-		if !hasManagedByHelm && !hasHelmChart && !hasHelmMetadata {
-			return nil
+	// Process Namespaces (phase, termination and PodSecurity levels)
+	if namespaces != nil {
+		for i := range namespaces.Items {
+			resources = append(resources, lightNamespace(&namespaces.Items[i]))
 		}
+	}
 
-		releaseNs := labels["meta.helm.sh/release-namespace"]
-		if releaseNs == "" {
-			releaseNs = annotations["meta.helm.sh/release-namespace"]
+	// Process ResourceQuotas and LimitRanges, linked to their Namespace
+	namespaceMap := make(map[string]string) // name -> uid
+	if namespaces != nil {
+		for _, ns := range namespaces.Items {
+			namespaceMap[ns.Name] = string(ns.UID)
 		}
-		if releaseNs == "" {
-			releaseNs = ns
+	}
+	var namespacePolicies []LightResource
+	if resourceQuotas != nil {
+		for i := range resourceQuotas.Items {
+			namespacePolicies = append(namespacePolicies, lightResourceQuota(&resourceQuotas.Items[i]))
 		}
-
-		return &HelmReleaseInfo{
-			ReleaseName:      releaseName,
-			ReleaseNamespace: releaseNs,
-			ChartName:        labels["helm.sh/chart"],
+	}
+	if limitRanges != nil {
+		for i := range limitRanges.Items {
+			namespacePolicies = append(namespacePolicies, lightLimitRange(&limitRanges.Items[i]))
 		}
 	}
-
-	// Process Nodes
-	if nodes != nil {
-		for _, n := range nodes.Items {
-			status := "NotReady"
-			health := "warning"
-			for _, cond := range n.Status.Conditions {
-				if cond.Type == corev1.NodeReady && cond.Status == corev1.ConditionTrue {
-					status = "Ready"
-					health = "ok"
-					break
-				}
-			}
-			resources = append(resources, LightResource{
-				ID:                string(n.UID),
-				Name:              n.Name,
-				Namespace:         "",
-				Kind:              "Node",
-				Status:            status,
-				Health:            health,
-				Labels:            n.Labels,
-				OwnerRefs:         extractOwnerRefs(n.OwnerReferences),
-				CreationTimestamp: n.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
-			})
+	for _, res := range namespacePolicies {
+		resources = append(resources, res)
+		if nsUID, ok := namespaceMap[res.Namespace]; ok {
+			links = append(links, ClusterLink{Source: res.ID, Target: nsUID, Type: "policy"})
 		}
 	}
 
 	// Process Pods
 	if pods != nil {
-		for _, p := range pods.Items {
-			status := string(p.Status.Phase)
-			health := "ok"
-
-			if p.Status.Phase == corev1.PodFailed {
-				health = "error"
-			} else if p.Status.Phase == corev1.PodPending {
-				health = "warning"
-			} else if p.Status.Phase == corev1.PodRunning {
-				isReady := false
-				for _, c := range p.Status.Conditions {
-					if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
-						isReady = true
-						break
-					}
-				}
-				if !isReady {
-					health = "warning"
-				}
-				for _, cs := range p.Status.ContainerStatuses {
-					if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
-						health = "error"
-					}
-					if cs.State.Terminated != nil && cs.State.Terminated.ExitCode != 0 {
-						health = "error"
-					}
-				}
-			}
-
-			// Extract volume refs
-			var volumes []VolumeRef
-			for _, vol := range p.Spec.Volumes {
-				if vol.ConfigMap != nil {
-					volumes = append(volumes, VolumeRef{Type: "configMap", Name: vol.ConfigMap.Name})
-				}
-				if vol.Secret != nil {
-					volumes = append(volumes, VolumeRef{Type: "secret", Name: vol.Secret.SecretName})
-				}
-				if vol.PersistentVolumeClaim != nil {
-					volumes = append(volumes, VolumeRef{Type: "pvc", Name: vol.PersistentVolumeClaim.ClaimName})
-				}
-				if vol.Projected != nil {
-					for _, src := range vol.Projected.Sources {
-						if src.ConfigMap != nil {
-							volumes = append(volumes, VolumeRef{Type: "configMap", Name: src.ConfigMap.Name})
-						}
-						if src.Secret != nil {
-							volumes = append(volumes, VolumeRef{Type: "secret", Name: src.Secret.Name})
-						}
-					}
-				}
-			}
-
-			// Extract env refs
-			var envRefs []EnvRef
-			seenRefs := make(map[string]bool)
-			for _, container := range p.Spec.Containers {
-				for _, envFrom := range container.EnvFrom {
-					if envFrom.ConfigMapRef != nil {
-						key := "configMap:" + envFrom.ConfigMapRef.Name
-						if !seenRefs[key] {
-							envRefs = append(envRefs, EnvRef{Type: "configMap", Name: envFrom.ConfigMapRef.Name})
-							seenRefs[key] = true
-						}
-					}
-					if envFrom.SecretRef != nil {
-						key := "secret:" + envFrom.SecretRef.Name
-						if !seenRefs[key] {
-							envRefs = append(envRefs, EnvRef{Type: "secret", Name: envFrom.SecretRef.Name})
-							seenRefs[key] = true
-						}
-					}
-				}
-				for _, env := range container.Env {
-					if env.ValueFrom != nil {
-						if env.ValueFrom.ConfigMapKeyRef != nil {
-							key := "configMap:" + env.ValueFrom.ConfigMapKeyRef.Name
-							if !seenRefs[key] {
-								envRefs = append(envRefs, EnvRef{Type: "configMap", Name: env.ValueFrom.ConfigMapKeyRef.Name})
-								seenRefs[key] = true
-							}
-						}
-						if env.ValueFrom.SecretKeyRef != nil {
-							key := "secret:" + env.ValueFrom.SecretKeyRef.Name
-							if !seenRefs[key] {
-								envRefs = append(envRefs, EnvRef{Type: "secret", Name: env.ValueFrom.SecretKeyRef.Name})
-								seenRefs[key] = true
-							}
-						}
-					}
-				}
-			}
-
-			annotations := p.Annotations
-			if annotations == nil {
-				annotations = make(map[string]string)
-			}
-
-			res := LightResource{
-				ID:                string(p.UID),
-				Name:              p.Name,
-				Namespace:         p.Namespace,
-				Kind:              "Pod",
-				Status:            status,
-				Health:            health,
-				Labels:            p.Labels,
-				OwnerRefs:         extractOwnerRefs(p.OwnerReferences),
-				CreationTimestamp: p.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
-				NodeName:          p.Spec.NodeName,
-				Volumes:           volumes,
-				EnvRefs:           envRefs,
-				HelmRelease:       extractHelmInfo(p.Labels, annotations, p.Namespace),
-			}
+		for i := range pods.Items {
+			p := &pods.Items[i]
+			res := lightPod(p)
+			restartTracker.Observe(config.Host, &res)
 			resources = append(resources, res)
 			podMap[string(p.UID)] = p.Namespace + "/" + p.Name
 
@@ -525,7 +1609,7 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 			}
 
 			// Add Pod -> ConfigMap/Secret/PVC links
-			for _, vol := range volumes {
+			for _, vol := range res.Volumes {
 				var targetUID string
 				var linkType string
 				switch vol.Type {
@@ -544,8 +1628,22 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 				}
 			}
 
+			// Add Pod -> imagePullSecret links
+			for _, name := range res.ImagePullSecrets {
+				if targetUID := secretMap[p.Namespace+"/"+name]; targetUID != "" {
+					links = append(links, ClusterLink{Source: string(p.UID), Target: targetUID, Type: "config"})
+				}
+			}
+
+			// Add Pod -> ServiceAccount token Secret links (legacy token Secrets)
+			if res.ServiceAccountToken {
+				for _, targetUID := range saTokenMap[p.Namespace+"/"+res.ServiceAccount] {
+					links = append(links, ClusterLink{Source: string(p.UID), Target: targetUID, Type: "config"})
+				}
+			}
+
 			// Add Pod -> ConfigMap/Secret links from env
-			for _, envRef := range envRefs {
+			for _, envRef := range res.EnvRefs {
 				var targetUID string
 				if envRef.Type == "configMap" {
 					targetUID = cmMap[p.Namespace+"/"+envRef.Name]
@@ -559,32 +1657,24 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Selector lookups of Services, workloads and policies only scan the pods
+	// carrying a label the selector asks for
+	var podLabels *podLabelIndex
+	if pods != nil {
+		podLabels = newPodLabelIndex(pods.Items)
+	}
+
 	// Process Services
+	var serviceSlices map[string][]*discoveryv1.EndpointSlice
+	var endpointPods podIndex
+	if endpointSlices != nil && pods != nil {
+		serviceSlices = indexEndpointSlices(endpointSlices.Items)
+		endpointPods = newPodIndex(pods.Items)
+	}
 	if services != nil {
-		for _, s := range services.Items {
-			var selector map[string]string
-			if s.Spec.Selector != nil && len(s.Spec.Selector) > 0 {
-				selector = s.Spec.Selector
-			}
-
-			annotations := s.Annotations
-			if annotations == nil {
-				annotations = make(map[string]string)
-			}
-
-			res := LightResource{
-				ID:                string(s.UID),
-				Name:              s.Name,
-				Namespace:         s.Namespace,
-				Kind:              "Service",
-				Status:            "Active",
-				Health:            "ok",
-				Labels:            s.Labels,
-				OwnerRefs:         extractOwnerRefs(s.OwnerReferences),
-				CreationTimestamp: s.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
-				Selector:          selector,
-				HelmRelease:       extractHelmInfo(s.Labels, annotations, s.Namespace),
-			}
+		for i := range services.Items {
+			s := &services.Items[i]
+			res := lightService(s)
 			resources = append(resources, res)
 
 			// Add owner links
@@ -592,15 +1682,14 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 				links = append(links, ClusterLink{Source: string(s.UID), Target: string(ref.UID), Type: "owner"})
 			}
 
-			// Add Service -> Pod network links
-			if selector != nil && pods != nil {
-				for _, p := range pods.Items {
-					if p.Namespace != s.Namespace {
-						continue
-					}
-					if matchLabels(p.Labels, selector) {
-						links = append(links, ClusterLink{Source: string(s.UID), Target: string(p.UID), Type: "network"})
-					}
+			// Add Service -> Pod network links from the Service's
+			// EndpointSlices, which also cover headless and selectorless
+			// Services and readiness; selectors are matched without them
+			if slices, ok := serviceSlices[s.Namespace+"/"+s.Name]; ok {
+				links = append(links, endpointLinks(string(s.UID), slices, endpointPods)...)
+			} else if res.Selector != nil && podLabels != nil {
+				for _, p := range podLabels.matching(s.Namespace, res.Selector) {
+					links = append(links, ClusterLink{Source: string(s.UID), Target: string(p.UID), Type: "network"})
 				}
 			}
 		}
@@ -608,35 +1697,9 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 
 	// Process Deployments
 	if deployments != nil {
-		for _, d := range deployments.Items {
-			status := "Progressing"
-			health := "warning"
-			if d.Spec.Replicas != nil && *d.Spec.Replicas == 0 {
-				status = "ScaledDown"
-				health = "ok"
-			} else if d.Status.AvailableReplicas == d.Status.Replicas && d.Status.Replicas > 0 {
-				status = "Available"
-				health = "ok"
-			}
-
-			annotations := d.Annotations
-			if annotations == nil {
-				annotations = make(map[string]string)
-			}
-
-			res := LightResource{
-				ID:                string(d.UID),
-				Name:              d.Name,
-				Namespace:         d.Namespace,
-				Kind:              "Deployment",
-				Status:            status,
-				Health:            health,
-				Labels:            d.Labels,
-				OwnerRefs:         extractOwnerRefs(d.OwnerReferences),
-				CreationTimestamp: d.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
-				HelmRelease:       extractHelmInfo(d.Labels, annotations, d.Namespace),
-			}
-			resources = append(resources, res)
+		for i := range deployments.Items {
+			d := &deployments.Items[i]
+			resources = append(resources, lightDeployment(d))
 
 			for _, ref := range d.OwnerReferences {
 				links = append(links, ClusterLink{Source: string(d.UID), Target: string(ref.UID), Type: "owner"})
@@ -646,37 +1709,9 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 
 	// Process StatefulSets
 	if statefulsets != nil {
-		for _, s := range statefulsets.Items {
-			status := "Progressing"
-			health := "warning"
-			if s.Status.ReadyReplicas == s.Status.Replicas && s.Status.Replicas > 0 {
-				status = "Ready"
-				health = "ok"
-			}
-
-			var selector map[string]string
-			if s.Spec.Selector != nil && s.Spec.Selector.MatchLabels != nil {
-				selector = s.Spec.Selector.MatchLabels
-			}
-
-			annotations := s.Annotations
-			if annotations == nil {
-				annotations = make(map[string]string)
-			}
-
-			res := LightResource{
-				ID:                string(s.UID),
-				Name:              s.Name,
-				Namespace:         s.Namespace,
-				Kind:              "StatefulSet",
-				Status:            status,
-				Health:            health,
-				Labels:            s.Labels,
-				OwnerRefs:         extractOwnerRefs(s.OwnerReferences),
-				CreationTimestamp: s.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
-				Selector:          selector,
-				HelmRelease:       extractHelmInfo(s.Labels, annotations, s.Namespace),
-			}
+		for i := range statefulsets.Items {
+			s := &statefulsets.Items[i]
+			res := lightStatefulSet(s)
 			resources = append(resources, res)
 
 			for _, ref := range s.OwnerReferences {
@@ -684,24 +1719,9 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 			}
 
 			// StatefulSets often don't have direct OwnerReferences from pods, use selector
-			if selector != nil && pods != nil {
-				for _, p := range pods.Items {
-					if p.Namespace != s.Namespace {
-						continue
-					}
-					if matchLabels(p.Labels, selector) {
-						// Check if link doesn't already exist (from OwnerRef)
-						exists := false
-						for _, l := range links {
-							if l.Source == string(p.UID) && l.Target == string(s.UID) {
-								exists = true
-								break
-							}
-						}
-						if !exists {
-							links = append(links, ClusterLink{Source: string(p.UID), Target: string(s.UID), Type: "owner"})
-						}
-					}
+			if s.Spec.Selector != nil && podLabels != nil {
+				for _, p := range podLabels.selecting(s.Namespace, workloadSelector(s.Spec.Selector)) {
+					links = append(links, ClusterLink{Source: string(p.UID), Target: string(s.UID), Type: "owner"})
 				}
 			}
 		}
@@ -709,36 +1729,16 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 
 	// Process DaemonSets
 	if daemonsets != nil {
-		for _, d := range daemonsets.Items {
-			status := "Progressing"
-			health := "warning"
-			if d.Status.NumberReady == d.Status.DesiredNumberScheduled && d.Status.DesiredNumberScheduled > 0 {
-				status = "Ready"
-				health = "ok"
-			}
-
-			var selector map[string]string
-			if d.Spec.Selector != nil && d.Spec.Selector.MatchLabels != nil {
-				selector = d.Spec.Selector.MatchLabels
-			}
-
-			annotations := d.Annotations
-			if annotations == nil {
-				annotations = make(map[string]string)
-			}
-
-			res := LightResource{
-				ID:                string(d.UID),
-				Name:              d.Name,
-				Namespace:         d.Namespace,
-				Kind:              "DaemonSet",
-				Status:            status,
-				Health:            health,
-				Labels:            d.Labels,
-				OwnerRefs:         extractOwnerRefs(d.OwnerReferences),
-				CreationTimestamp: d.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
-				Selector:          selector,
-				HelmRelease:       extractHelmInfo(d.Labels, annotations, d.Namespace),
+		for i := range daemonsets.Items {
+			d := &daemonsets.Items[i]
+			res := lightDaemonSet(d)
+			// Coverage needs every node and pod, which namespaced and
+			// label-filtered graphs don't have
+			if nodes != nil && pods != nil && opts.labelSelector == "" {
+				res.NodeCoverage = daemonSetCoverage(d, nodes.Items, pods.Items)
+				if len(res.NodeCoverage.Uncovered) > 0 {
+					res.Health = "warning"
+				}
 			}
 			resources = append(resources, res)
 
@@ -747,23 +1747,9 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 			}
 
 			// Link pods via selector
-			if selector != nil && pods != nil {
-				for _, p := range pods.Items {
-					if p.Namespace != d.Namespace {
-						continue
-					}
-					if matchLabels(p.Labels, selector) {
-						exists := false
-						for _, l := range links {
-							if l.Source == string(p.UID) && l.Target == string(d.UID) {
-								exists = true
-								break
-							}
-						}
-						if !exists {
-							links = append(links, ClusterLink{Source: string(p.UID), Target: string(d.UID), Type: "owner"})
-						}
-					}
+			if d.Spec.Selector != nil && podLabels != nil {
+				for _, p := range podLabels.selecting(d.Namespace, workloadSelector(d.Spec.Selector)) {
+					links = append(links, ClusterLink{Source: string(p.UID), Target: string(d.UID), Type: "owner"})
 				}
 			}
 		}
@@ -771,25 +1757,9 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 
 	// Process ReplicaSets
 	if replicasets != nil {
-		for _, r := range replicasets.Items {
-			annotations := r.Annotations
-			if annotations == nil {
-				annotations = make(map[string]string)
-			}
-
-			res := LightResource{
-				ID:                string(r.UID),
-				Name:              r.Name,
-				Namespace:         r.Namespace,
-				Kind:              "ReplicaSet",
-				Status:            "Active",
-				Health:            "ok",
-				Labels:            r.Labels,
-				OwnerRefs:         extractOwnerRefs(r.OwnerReferences),
-				CreationTimestamp: r.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
-				HelmRelease:       extractHelmInfo(r.Labels, annotations, r.Namespace),
-			}
-			resources = append(resources, res)
+		for i := range replicasets.Items {
+			r := &replicasets.Items[i]
+			resources = append(resources, lightReplicaSet(r))
 
 			for _, ref := range r.OwnerReferences {
 				links = append(links, ClusterLink{Source: string(r.UID), Target: string(ref.UID), Type: "owner"})
@@ -799,46 +1769,75 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 
 	// Process Ingresses
 	if ingresses != nil {
-		for _, i := range ingresses.Items {
-			var backends []IngressBackend
-			for _, rule := range i.Spec.Rules {
-				if rule.HTTP != nil {
-					for _, path := range rule.HTTP.Paths {
-						if path.Backend.Service != nil && path.Backend.Service.Name != "" {
-							backends = append(backends, IngressBackend{ServiceName: path.Backend.Service.Name})
-						}
-					}
+		for idx := range ingresses.Items {
+			i := &ingresses.Items[idx]
+			res := lightIngress(i)
+			resources = append(resources, res)
+
+			for _, ref := range i.OwnerReferences {
+				links = append(links, ClusterLink{Source: string(i.UID), Target: string(ref.UID), Type: "owner"})
+			}
+
+			// Add Ingress -> Service network links
+			for _, backend := range res.IngressBackends {
+				if svcUID, ok := svcMap[i.Namespace+"/"+backend.ServiceName]; ok {
+					links = append(links, ClusterLink{Source: string(i.UID), Target: svcUID, Type: "network"})
 				}
 			}
 
-			annotations := i.Annotations
-			if annotations == nil {
-				annotations = make(map[string]string)
+			// Add Ingress -> TLS Secret config links
+			for _, tls := range res.Ingress.TLS {
+				if secretUID, ok := secretMap[i.Namespace+"/"+tls.SecretName]; ok {
+					links = append(links, ClusterLink{Source: string(i.UID), Target: secretUID, Type: "config"})
+				}
+			}
+		}
+	}
+
+	// Process NetworkPolicies
+	if netpols != nil {
+		for idx := range netpols.Items {
+			np := &netpols.Items[idx]
+			resources = append(resources, lightNetworkPolicy(np))
+
+			for _, ref := range np.OwnerReferences {
+				links = append(links, ClusterLink{Source: string(np.UID), Target: string(ref.UID), Type: "owner"})
+			}
+
+			// Add NetworkPolicy -> Pod policy links for the pods it applies to
+			if pods != nil {
+				selector := policySelector(np)
+				for _, p := range podLabels.selecting(np.Namespace, selector) {
+					links = append(links, ClusterLink{Source: string(np.UID), Target: string(p.UID), Type: "policy"})
+				}
 			}
+		}
+	}
+
+	// Process PodDisruptionBudgets, linked to the pods and workloads they cover
+	if pdbs != nil {
+		for idx := range pdbs.Items {
+			pdb := &pdbs.Items[idx]
+			resources = append(resources, lightPDB(pdb))
 
-			res := LightResource{
-				ID:                string(i.UID),
-				Name:              i.Name,
-				Namespace:         i.Namespace,
-				Kind:              "Ingress",
-				Status:            "Active",
-				Health:            "ok",
-				Labels:            i.Labels,
-				OwnerRefs:         extractOwnerRefs(i.OwnerReferences),
-				CreationTimestamp: i.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
-				IngressBackends:   backends,
-				HelmRelease:       extractHelmInfo(i.Labels, annotations, i.Namespace),
+			selector := pdbSelector(pdb)
+			if pods != nil {
+				for _, p := range podLabels.selecting(pdb.Namespace, selector) {
+					links = append(links, ClusterLink{Source: string(pdb.UID), Target: string(p.UID), Type: "policy"})
+				}
 			}
-			resources = append(resources, res)
-
-			for _, ref := range i.OwnerReferences {
-				links = append(links, ClusterLink{Source: string(i.UID), Target: string(ref.UID), Type: "owner"})
+			if deployments != nil {
+				for _, d := range deployments.Items {
+					if d.Namespace == pdb.Namespace && selector.Matches(labels.Set(d.Spec.Template.Labels)) {
+						links = append(links, ClusterLink{Source: string(pdb.UID), Target: string(d.UID), Type: "policy"})
+					}
+				}
 			}
-
-			// Add Ingress -> Service network links
-			for _, backend := range backends {
-				if svcUID, ok := svcMap[i.Namespace+"/"+backend.ServiceName]; ok {
-					links = append(links, ClusterLink{Source: string(i.UID), Target: svcUID, Type: "network"})
+			if statefulsets != nil {
+				for _, sts := range statefulsets.Items {
+					if sts.Namespace == pdb.Namespace && selector.Matches(labels.Set(sts.Spec.Template.Labels)) {
+						links = append(links, ClusterLink{Source: string(pdb.UID), Target: string(sts.UID), Type: "policy"})
+					}
 				}
 			}
 		}
@@ -846,34 +1845,9 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 
 	// Process PVCs
 	if pvcs != nil {
-		for _, pvc := range pvcs.Items {
-			annotations := pvc.Annotations
-			if annotations == nil {
-				annotations = make(map[string]string)
-			}
-
-			status := string(pvc.Status.Phase)
-			health := "ok"
-			if status == "Lost" {
-				health = "error"
-			} else if status == "Pending" {
-				health = "warning"
-			}
-
-			res := LightResource{
-				ID:                string(pvc.UID),
-				Name:              pvc.Name,
-				Namespace:         pvc.Namespace,
-				Kind:              "PersistentVolumeClaim",
-				Status:            status,
-				Health:            health,
-				Labels:            pvc.Labels,
-				OwnerRefs:         extractOwnerRefs(pvc.OwnerReferences),
-				CreationTimestamp: pvc.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
-				StorageClassName:  getStorageClassName(pvc.Spec.StorageClassName),
-				HelmRelease:       extractHelmInfo(pvc.Labels, annotations, pvc.Namespace),
-			}
-			resources = append(resources, res)
+		for i := range pvcs.Items {
+			pvc := &pvcs.Items[i]
+			resources = append(resources, lightPVC(pvc))
 
 			for _, ref := range pvc.OwnerReferences {
 				links = append(links, ClusterLink{Source: string(pvc.UID), Target: string(ref.UID), Type: "owner"})
@@ -885,30 +1859,32 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 					links = append(links, ClusterLink{Source: string(pvc.UID), Target: scUID, Type: "storage"})
 				}
 			}
+
+			// Add PVC -> PersistentVolume link
+			if pvUID, ok := pvMap[pvc.Spec.VolumeName]; ok {
+				links = append(links, ClusterLink{Source: string(pvc.UID), Target: pvUID, Type: "storage"})
+			}
+		}
+	}
+
+	// Helm release revisions, from the storage driver HELM_DRIVER names
+	helmDriverName := helmDriver()
+	helmReleaseMap := make(map[string]helmStoredRelease) // namespace/name -> latest revision
+	if helmDriverName == "sql" && opts.lists("HelmRelease") {
+		for _, r := range sqlHelmReleases(opts.namespace) {
+			keepLatestRelease(helmReleaseMap, r)
 		}
 	}
 
-	// Process ConfigMaps
+	// Process ConfigMaps (excluding Helm release ConfigMaps)
 	if configmaps != nil {
-		for _, cm := range configmaps.Items {
-			annotations := cm.Annotations
-			if annotations == nil {
-				annotations = make(map[string]string)
-			}
-
-			res := LightResource{
-				ID:                string(cm.UID),
-				Name:              cm.Name,
-				Namespace:         cm.Namespace,
-				Kind:              "ConfigMap",
-				Status:            "Active",
-				Health:            "ok",
-				Labels:            cm.Labels,
-				OwnerRefs:         extractOwnerRefs(cm.OwnerReferences),
-				CreationTimestamp: cm.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
-				HelmRelease:       extractHelmInfo(cm.Labels, annotations, cm.Namespace),
+		for i := range configmaps.Items {
+			cm := &configmaps.Items[i]
+			if helmDriverName == "configmap" && cm.Labels["owner"] == "helm" && cm.Labels["name"] != "" {
+				keepLatestRelease(helmReleaseMap, helmStorageObject(cm, cm.Data["release"]))
+				continue
 			}
-			resources = append(resources, res)
+			resources = append(resources, lightConfigMap(cm))
 
 			for _, ref := range cm.OwnerReferences {
 				links = append(links, ClusterLink{Source: string(cm.UID), Target: string(ref.UID), Type: "owner"})
@@ -916,78 +1892,43 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Process Secrets (excluding Helm release secrets, create HelmRelease resources)
-	helmReleaseMap := make(map[string]struct {
-		secret  *corev1.Secret
-		version int
-	})
-
+	// Process Secrets (excluding Helm release secrets)
 	if secrets != nil {
 		for i := range secrets.Items {
 			sec := &secrets.Items[i]
-			labels := sec.Labels
-			if labels == nil {
-				labels = make(map[string]string)
-			}
-
-			// Check if this is a Helm release secret
-			isHelmSecret := labels["owner"] == "helm" && sec.Type == "helm.sh/release.v1"
-
-			if isHelmSecret {
-				releaseName := labels["name"]
-				namespace := sec.Namespace
-				version := 0
-				if v, ok := labels["version"]; ok {
-					var err error
-					_, err = json.Number(v).Int64()
-					if err == nil {
-						version = int(mustParseInt(v))
+			if helmDriverName == "secret" && sec.Labels["owner"] == "helm" && sec.Type == "helm.sh/release.v1" {
+				stored := helmStorageObject(sec, string(sec.Data["release"]))
+				if sec.Data == nil {
+					// Cached by metadata only
+					namespace, name := sec.Namespace, sec.Name
+					stored.load = func() string {
+						full, err := clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+						if err != nil {
+							log.Printf("Failed to read Helm release %s/%s: %v", namespace, name, err)
+							return ""
+						}
+						return string(full.Data["release"])
 					}
 				}
+				keepLatestRelease(helmReleaseMap, stored)
+				continue
+			}
+			resources = append(resources, lightSecret(sec))
 
-				key := namespace + "/" + releaseName
-				existing, exists := helmReleaseMap[key]
-				if !exists || version > existing.version {
-					helmReleaseMap[key] = struct {
-						secret  *corev1.Secret
-						version int
-					}{secret: sec, version: version}
-				}
-			} else {
-				annotations := sec.Annotations
-				if annotations == nil {
-					annotations = make(map[string]string)
-				}
-
-				res := LightResource{
-					ID:                string(sec.UID),
-					Name:              sec.Name,
-					Namespace:         sec.Namespace,
-					Kind:              "Secret",
-					Status:            "Active",
-					Health:            "ok",
-					Labels:            labels,
-					OwnerRefs:         extractOwnerRefs(sec.OwnerReferences),
-					CreationTimestamp: sec.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
-					HelmRelease:       extractHelmInfo(labels, annotations, sec.Namespace),
-				}
-				resources = append(resources, res)
-
-				for _, ref := range sec.OwnerReferences {
-					links = append(links, ClusterLink{Source: string(sec.UID), Target: string(ref.UID), Type: "owner"})
-				}
+			for _, ref := range sec.OwnerReferences {
+				links = append(links, ClusterLink{Source: string(sec.UID), Target: string(ref.UID), Type: "owner"})
 			}
 		}
 	}
 
-	// Create HelmRelease resources from grouped secrets
+	// Create HelmRelease resources from the latest revisions
+	helmReleases := make([]helmStoredRelease, 0, len(helmReleaseMap))
 	for _, entry := range helmReleaseMap {
-		sec := entry.secret
-		labels := sec.Labels
-		releaseName := labels["name"]
-		namespace := sec.Namespace
-		status := labels["status"]
-		chartInfo := labels["chart"]
+		helmReleases = append(helmReleases, entry)
+		releaseName := entry.name
+		namespace := entry.namespace
+		status := entry.status
+		chartInfo := entry.chart
 
 		helmReleaseID := "helm-" + namespace + "-" + releaseName
 
@@ -1024,7 +1965,8 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 				"helm.sh/release-namespace": namespace,
 			},
 			OwnerRefs:         []string{},
-			CreationTimestamp: sec.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
+			CreationTimestamp: formatTimestamp(entry.created),
+			AgeSeconds:        ageSeconds(entry.created),
 			HelmRelease: &HelmReleaseInfo{
 				ReleaseName:      releaseName,
 				ReleaseNamespace: namespace,
@@ -1035,79 +1977,77 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 		}
 		resources = append(resources, res)
 
-		// Link HelmRelease to its secret
-		links = append(links, ClusterLink{Source: helmReleaseID, Target: string(sec.UID), Type: "owner"})
+		// Link HelmRelease to its Secret or ConfigMap
+		if entry.uid != "" {
+			links = append(links, ClusterLink{Source: helmReleaseID, Target: entry.uid, Type: "owner"})
+		}
 	}
 
 	// Process StorageClasses
 	if storageclasses != nil {
-		for _, sc := range storageclasses.Items {
-			res := LightResource{
-				ID:                string(sc.UID),
-				Name:              sc.Name,
-				Namespace:         "",
-				Kind:              "StorageClass",
-				Status:            "Active",
-				Health:            "ok",
-				Labels:            sc.Labels,
-				OwnerRefs:         extractOwnerRefs(sc.OwnerReferences),
-				CreationTimestamp: sc.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
-			}
-			resources = append(resources, res)
+		for i := range storageclasses.Items {
+			resources = append(resources, lightStorageClass(&storageclasses.Items[i]))
 		}
 	}
 
-	// Process Jobs
-	if jobs != nil {
-		for _, j := range jobs.Items {
-			status := "Pending"
-			health := "warning"
-
-			conditions := j.Status.Conditions
-			completeCond := false
-			failedCond := false
-			for _, c := range conditions {
-				if c.Type == batchv1.JobComplete && c.Status == corev1.ConditionTrue {
-					completeCond = true
-				}
-				if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
-					failedCond = true
+	// Process PersistentVolumes
+	if pvs != nil {
+		for i := range pvs.Items {
+			pv := &pvs.Items[i]
+			res := lightPersistentVolume(pv)
+			resources = append(resources, res)
+
+			// Add PV -> StorageClass link
+			if scUID, ok := scMap[pv.Spec.StorageClassName]; ok {
+				links = append(links, ClusterLink{Source: string(pv.UID), Target: scUID, Type: "storage"})
+			}
+			// Add PV -> Node links for local volumes
+			for _, node := range res.VolumeNodes {
+				if nodeUID, ok := nodeMap[node]; ok {
+					links = append(links, ClusterLink{Source: string(pv.UID), Target: nodeUID, Type: "storage"})
 				}
 			}
+		}
+	}
 
-			if completeCond {
-				status = "Complete"
-				health = "ok"
-			} else if failedCond {
-				status = "Failed"
-				health = "error"
-			} else if j.Status.Active > 0 {
-				status = "Running"
-				health = "ok"
-			} else if j.Status.Succeeded > 0 {
-				status = "Complete"
-				health = "ok"
-			}
-
-			annotations := j.Annotations
-			if annotations == nil {
-				annotations = make(map[string]string)
-			}
-
-			res := LightResource{
-				ID:                string(j.UID),
-				Name:              j.Name,
-				Namespace:         j.Namespace,
-				Kind:              "Job",
-				Status:            status,
-				Health:            health,
-				Labels:            j.Labels,
-				OwnerRefs:         extractOwnerRefs(j.OwnerReferences),
-				CreationTimestamp: j.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
-				HelmRelease:       extractHelmInfo(j.Labels, annotations, j.Namespace),
+	// Process CSIDrivers, linked from the StorageClasses they provision
+	if csiDrivers != nil {
+		csiDriverMap := make(map[string]string) // name -> uid
+		for i := range csiDrivers.Items {
+			res := lightCSIDriver(&csiDrivers.Items[i])
+			csiDriverMap[res.Name] = res.ID
+			resources = append(resources, res)
+		}
+		if storageclasses != nil {
+			for _, sc := range storageclasses.Items {
+				if driverUID, ok := csiDriverMap[sc.Provisioner]; ok {
+					links = append(links, ClusterLink{Source: string(sc.UID), Target: driverUID, Type: "storage"})
+				}
 			}
+		}
+	}
+
+	// Process VolumeAttachments, linked to the Node and the PV they attach
+	if volumeAttachments != nil {
+		for i := range volumeAttachments.Items {
+			res := lightVolumeAttachment(&volumeAttachments.Items[i])
 			resources = append(resources, res)
 
+			if nodeUID, ok := nodeMap[res.NodeName]; ok {
+				links = append(links, ClusterLink{Source: res.ID, Target: nodeUID, Type: "storage"})
+			}
+			if pvUID, ok := pvMap[res.Attachment.PersistentVolume]; ok {
+				links = append(links, ClusterLink{Source: res.ID, Target: pvUID, Type: "storage"})
+			}
+		}
+	}
+
+	// Process Jobs
+	if jobs != nil {
+		for i := range jobs.Items {
+			j := &jobs.Items[i]
+			resources = append(resources, lightJob(j))
+
 			for _, ref := range j.OwnerReferences {
 				links = append(links, ClusterLink{Source: string(j.UID), Target: string(ref.UID), Type: "owner"})
 			}
@@ -1116,29 +2056,9 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 
 	// Process CronJobs
 	if cronjobs != nil {
-		for _, cj := range cronjobs.Items {
-			status := "Active"
-			if cj.Spec.Suspend != nil && *cj.Spec.Suspend {
-				status = "Suspended"
-			}
-
-			annotations := cj.Annotations
-			if annotations == nil {
-				annotations = make(map[string]string)
-			}
-
-			res := LightResource{
-				ID:                string(cj.UID),
-				Name:              cj.Name,
-				Namespace:         cj.Namespace,
-				Kind:              "CronJob",
-				Status:            status,
-				Labels:            cj.Labels,
-				OwnerRefs:         extractOwnerRefs(cj.OwnerReferences),
-				CreationTimestamp: cj.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
-				HelmRelease:       extractHelmInfo(cj.Labels, annotations, cj.Namespace),
-			}
-			resources = append(resources, res)
+		for i := range cronjobs.Items {
+			cj := &cronjobs.Items[i]
+			resources = append(resources, lightCronJob(cj))
 
 			for _, ref := range cj.OwnerReferences {
 				links = append(links, ClusterLink{Source: string(cj.UID), Target: string(ref.UID), Type: "owner"})
@@ -1148,148 +2068,397 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 
 	// Process HPAs
 	if hpas != nil {
-		for _, hpa := range hpas.Items {
-			status := "Unknown"
-			health := "warning"
-
-			conditions := hpa.Status.Conditions
-			ableCond := false
-			scalingActiveCond := false
-			for _, c := range conditions {
-				if c.Type == autoscalingv2.AbleToScale && c.Status == corev1.ConditionTrue {
-					ableCond = true
+		for i := range hpas.Items {
+			hpa := &hpas.Items[i]
+			res := lightHPA(hpa)
+			resources = append(resources, res)
+
+			for _, ref := range hpa.OwnerReferences {
+				links = append(links, ClusterLink{Source: string(hpa.UID), Target: string(ref.UID), Type: "owner"})
+			}
+
+			// Add HPA -> target workload link
+			if res.ScaleTargetRef != nil {
+				targetKey := hpa.Namespace + "/" + res.ScaleTargetRef.Kind + "/" + res.ScaleTargetRef.Name
+				if targetUID, ok := workloadMap[targetKey]; ok {
+					links = append(links, ClusterLink{Source: string(hpa.UID), Target: targetUID, Type: "owner"})
 				}
-				if c.Type == autoscalingv2.ScalingActive && c.Status == corev1.ConditionTrue {
-					scalingActiveCond = true
+			}
+		}
+	}
+
+	// Process VerticalPodAutoscalers, linked to the workload they size
+	if vpas != nil {
+		for i := range vpas.Items {
+			res := lightUnstructured(&vpas.Items[i], "VerticalPodAutoscaler")
+			resources = append(resources, res)
+
+			// Add VPA -> target workload link
+			if res.ScaleTargetRef != nil {
+				targetKey := res.Namespace + "/" + res.ScaleTargetRef.Kind + "/" + res.ScaleTargetRef.Name
+				if targetUID, ok := workloadMap[targetKey]; ok {
+					links = append(links, ClusterLink{Source: res.ID, Target: targetUID, Type: "owner"})
 				}
 			}
+		}
+	}
+
+	// Process KEDA ScaledObjects, linked to the workload they scale like HPAs
+	if scaledObjects != nil {
+		for i := range scaledObjects.Items {
+			res := lightUnstructured(&scaledObjects.Items[i], "ScaledObject")
+			resources = append(resources, res)
 
-			if ableCond && scalingActiveCond {
-				status = "Active"
-				health = "ok"
-			} else if ableCond {
-				status = "Ready"
-				health = "ok"
-			} else {
-				status = "Inactive"
+			for _, refUID := range res.OwnerRefs {
+				links = append(links, ClusterLink{Source: res.ID, Target: refUID, Type: "owner"})
 			}
 
-			var scaleTargetRef *ScaleTargetRef
-			if hpa.Spec.ScaleTargetRef.Kind != "" {
-				scaleTargetRef = &ScaleTargetRef{
-					Kind: hpa.Spec.ScaleTargetRef.Kind,
-					Name: hpa.Spec.ScaleTargetRef.Name,
+			// Add ScaledObject -> target workload link
+			if res.ScaleTargetRef != nil {
+				targetKey := res.Namespace + "/" + res.ScaleTargetRef.Kind + "/" + res.ScaleTargetRef.Name
+				if targetUID, ok := workloadMap[targetKey]; ok {
+					links = append(links, ClusterLink{Source: res.ID, Target: targetUID, Type: "owner"})
 				}
 			}
+		}
+	}
+
+	// Process ArgoCD AppProjects and ApplicationSets, then the Applications:
+	// those an ApplicationSet generated are owned by it, and every one is
+	// governed by its AppProject
+	projectMap := make(map[string]string) // namespace/name -> uid
+	projectByName := make(map[string]string)
+	if appProjects != nil {
+		for i := range appProjects.Items {
+			res := lightUnstructured(&appProjects.Items[i], "AppProject")
+			projectMap[res.Namespace+"/"+res.Name] = res.ID
+			if _, ok := projectByName[res.Name]; !ok {
+				projectByName[res.Name] = res.ID
+			}
+			resources = append(resources, res)
+		}
+	}
+	if applicationSets != nil {
+		for i := range applicationSets.Items {
+			resources = append(resources, lightUnstructured(&applicationSets.Items[i], "ApplicationSet"))
+		}
+	}
+	if argoApps != nil {
+		for i := range argoApps.Items {
+			res := lightUnstructured(&argoApps.Items[i], "Application")
+			resources = append(resources, res)
 
-			annotations := hpa.Annotations
-			if annotations == nil {
-				annotations = make(map[string]string)
+			for _, refUID := range res.OwnerRefs {
+				links = append(links, ClusterLink{Source: res.ID, Target: refUID, Type: "owner"})
 			}
 
-			res := LightResource{
-				ID:                string(hpa.UID),
-				Name:              hpa.Name,
-				Namespace:         hpa.Namespace,
-				Kind:              "HorizontalPodAutoscaler",
-				Status:            status,
-				Health:            health,
-				Labels:            hpa.Labels,
-				OwnerRefs:         extractOwnerRefs(hpa.OwnerReferences),
-				CreationTimestamp: hpa.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
-				ScaleTargetRef:    scaleTargetRef,
-				HelmRelease:       extractHelmInfo(hpa.Labels, annotations, hpa.Namespace),
+			// Applications outside the ArgoCD namespace still use its projects
+			projectUID, ok := projectMap[res.Namespace+"/"+res.ArgoCD.Project]
+			if !ok {
+				projectUID, ok = projectByName[res.ArgoCD.Project]
+			}
+			if ok {
+				links = append(links, ClusterLink{Source: res.ID, Target: projectUID, Type: "policy"})
 			}
+		}
+	}
+
+	// Process Flux sources, then the Kustomizations and HelmReleases built
+	// from them (fluxKinds lists sources first)
+	fluxSourceMap := make(map[string]string) // kind/namespace/name -> uid
+	for i, list := range fluxLists {
+		if list == nil {
+			continue
+		}
+		for j := range list.Items {
+			res := lightUnstructured(&list.Items[j], fluxKinds[i].kind)
+			fluxSourceMap[res.Kind+"/"+res.Namespace+"/"+res.Name] = res.ID
 			resources = append(resources, res)
 
-			for _, ref := range hpa.OwnerReferences {
-				links = append(links, ClusterLink{Source: string(hpa.UID), Target: string(ref.UID), Type: "owner"})
+			for _, refUID := range res.OwnerRefs {
+				links = append(links, ClusterLink{Source: res.ID, Target: refUID, Type: "owner"})
 			}
 
-			// Add HPA -> target workload link
-			if scaleTargetRef != nil {
-				targetKey := hpa.Namespace + "/" + scaleTargetRef.Kind + "/" + scaleTargetRef.Name
-				if targetUID, ok := workloadMap[targetKey]; ok {
-					links = append(links, ClusterLink{Source: string(hpa.UID), Target: targetUID, Type: "owner"})
+			// Add Kustomization/HelmRelease -> source config link
+			if res.Flux.SourceKind != "" {
+				if sourceUID, ok := fluxSourceMap[res.Flux.SourceKind+"/"+res.Flux.SourceName]; ok {
+					links = append(links, ClusterLink{Source: res.ID, Target: sourceUID, Type: "config"})
 				}
 			}
 		}
 	}
 
-	// Process ArgoCD Applications
-	if argoApps != nil {
-		for _, item := range argoApps.Items {
-			metadata := item.Object["metadata"].(map[string]interface{})
-			uid := getNestedString(metadata, "uid")
-			name := getNestedString(metadata, "name")
-			namespace := getNestedString(metadata, "namespace")
-			creationTimestamp := getNestedString(metadata, "creationTimestamp")
-			labels, _ := metadata["labels"].(map[string]interface{})
-
-			labelsMap := make(map[string]string)
-			for k, v := range labels {
-				if vs, ok := v.(string); ok {
-					labelsMap[k] = vs
+	// Process Cluster API Clusters, MachineDeployments and Machines, linked
+	// Node -> Machine -> MachineDeployment -> Cluster (capiKinds lists
+	// parents first)
+	capiMap := make(map[string]string) // kind/namespace/name -> uid
+	for i, list := range capiLists {
+		if list == nil {
+			continue
+		}
+		for j := range list.Items {
+			res := lightUnstructured(&list.Items[j], capiKinds[i].kind)
+			capiMap[res.Kind+"/"+res.Namespace+"/"+res.Name] = res.ID
+			resources = append(resources, res)
+
+			parent := ""
+			switch res.Kind {
+			case "MachineDeployment":
+				parent = capiMap["Cluster/"+res.Namespace+"/"+res.CAPI.ClusterName]
+			case "Machine":
+				if deployment := res.Labels[capiDeploymentLabel]; deployment != "" {
+					parent = capiMap["MachineDeployment/"+res.Namespace+"/"+deployment]
+				}
+				if parent == "" {
+					// Control plane and standalone Machines
+					parent = capiMap["Cluster/"+res.Namespace+"/"+res.CAPI.ClusterName]
 				}
+				if nodeUID, ok := nodeMap[res.CAPI.NodeName]; ok {
+					links = append(links, ClusterLink{Source: nodeUID, Target: res.ID, Type: "owner"})
+				}
+			}
+			if parent != "" {
+				links = append(links, ClusterLink{Source: res.ID, Target: parent, Type: "owner"})
 			}
+		}
+	}
 
-			ownerRefs := []string{}
-			if refs, ok := metadata["ownerReferences"].([]interface{}); ok {
-				for _, ref := range refs {
-					if refMap, ok := ref.(map[string]interface{}); ok {
-						if refUID, ok := refMap["uid"].(string); ok {
-							ownerRefs = append(ownerRefs, refUID)
-						}
+	// Process prometheus-operator ServiceMonitors and PodMonitors, linked to
+	// the Services and Pods they scrape, and PrometheusRules
+	for i, list := range monitoringLists {
+		if list == nil {
+			continue
+		}
+		for j := range list.Items {
+			res := lightUnstructured(&list.Items[j], monitoringKinds[i].kind)
+			resources = append(resources, res)
+			if res.Kind == "PrometheusRule" {
+				continue
+			}
+
+			selector := monitorSelector(&list.Items[j])
+			namespaces := monitorNamespaces(&res)
+			if res.Kind == "ServiceMonitor" && services != nil {
+				for k := range services.Items {
+					s := &services.Items[k]
+					if (namespaces == nil || containsString(namespaces, s.Namespace)) && selector.Matches(labels.Set(s.Labels)) {
+						links = append(links, ClusterLink{Source: res.ID, Target: string(s.UID), Type: "network"})
 					}
 				}
 			}
-
-			status := "Unknown"
-			health := "ok"
-
-			statusObj, _ := item.Object["status"].(map[string]interface{})
-			if statusObj != nil {
-				if sync, ok := statusObj["sync"].(map[string]interface{}); ok {
-					if syncStatus, ok := sync["status"].(string); ok {
-						status = syncStatus
+			if res.Kind == "PodMonitor" && podLabels != nil {
+				if namespaces == nil {
+					for ns := range podLabels.byNamespace {
+						namespaces = append(namespaces, ns)
 					}
+					sort.Strings(namespaces)
 				}
-				if healthObj, ok := statusObj["health"].(map[string]interface{}); ok {
-					if healthStatus, ok := healthObj["status"].(string); ok {
-						switch healthStatus {
-						case "Degraded", "Missing":
-							health = "error"
-						case "Progressing", "Suspended":
-							health = "warning"
-						case "Healthy":
-							health = "ok"
-						default:
-							health = "warning"
-						}
+				for _, ns := range namespaces {
+					for _, p := range podLabels.selecting(ns, selector) {
+						links = append(links, ClusterLink{Source: res.ID, Target: string(p.UID), Type: "network"})
 					}
 				}
 			}
+		}
+	}
+
+	// Process Argo Rollouts and Flagger Canaries, linked to the Services and
+	// workloads of both sides once the owner links are known
+	var progressive []LightResource
+	if rollouts != nil {
+		for i := range rollouts.Items {
+			progressive = append(progressive, lightUnstructured(&rollouts.Items[i], "Rollout"))
+		}
+	}
+	if canaries != nil {
+		for i := range canaries.Items {
+			progressive = append(progressive, lightUnstructured(&canaries.Items[i], "Canary"))
+		}
+	}
+	for i := range progressive {
+		res := &progressive[i]
+		for _, refUID := range res.OwnerRefs {
+			links = append(links, ClusterLink{Source: res.ID, Target: refUID, Type: "owner"})
+		}
+	}
+	for i := range progressive {
+		links = append(links, canaryLinks(&progressive[i], svcMap, workloadMap, links)...)
+	}
+	resources = append(resources, progressive...)
+
+	// Process Gateway API GatewayClasses and Gateways
+	gatewayClassMap := make(map[string]string) // name -> uid
+	if gatewayClasses != nil {
+		for i := range gatewayClasses.Items {
+			res := lightUnstructured(&gatewayClasses.Items[i], "GatewayClass")
+			gatewayClassMap[res.Name] = res.ID
+			resources = append(resources, res)
+		}
+	}
+	gatewayMap := make(map[string]string) // namespace/name -> uid
+	if gateways != nil {
+		for i := range gateways.Items {
+			res := lightUnstructured(&gateways.Items[i], "Gateway")
+			gatewayMap[res.Namespace+"/"+res.Name] = res.ID
+			resources = append(resources, res)
+
+			// Add Gateway -> GatewayClass config link
+			if classUID, ok := gatewayClassMap[res.Gateway.ClassName]; ok {
+				links = append(links, ClusterLink{Source: res.ID, Target: classUID, Type: "config"})
+			}
+		}
+	}
+
+	// Process cert-manager issuers and Certificates
+	issuerMap := make(map[string]string) // kind/namespace/name -> uid
+	for _, list := range []struct {
+		items *unstructured.UnstructuredList
+		kind  string
+	}{{clusterIssuers, "ClusterIssuer"}, {issuers, "Issuer"}} {
+		if list.items == nil {
+			continue
+		}
+		for i := range list.items.Items {
+			res := lightUnstructured(&list.items.Items[i], list.kind)
+			issuerMap[list.kind+"/"+res.Namespace+"/"+res.Name] = res.ID
+			resources = append(resources, res)
+		}
+	}
+	if certificates != nil {
+		for i := range certificates.Items {
+			res := lightUnstructured(&certificates.Items[i], "Certificate")
+			resources = append(resources, res)
+
+			for _, refUID := range res.OwnerRefs {
+				links = append(links, ClusterLink{Source: res.ID, Target: refUID, Type: "owner"})
+			}
+
+			// Add Certificate -> Secret and Certificate -> issuer config links
+			if secretUID, ok := secretMap[res.Namespace+"/"+res.Certificate.SecretName]; ok {
+				links = append(links, ClusterLink{Source: res.ID, Target: secretUID, Type: "config"})
+			}
+			issuerNamespace := res.Namespace
+			if res.Certificate.IssuerKind == "ClusterIssuer" {
+				issuerNamespace = ""
+			}
+			if issuerUID, ok := issuerMap[res.Certificate.IssuerKind+"/"+issuerNamespace+"/"+res.Certificate.IssuerName]; ok {
+				links = append(links, ClusterLink{Source: res.ID, Target: issuerUID, Type: "config"})
+			}
+		}
+	}
+
+	// Process Gateway API HTTPRoutes
+	if httpRoutes != nil {
+		for i := range httpRoutes.Items {
+			res := lightUnstructured(&httpRoutes.Items[i], "HTTPRoute")
+			resources = append(resources, res)
+
+			// Add Gateway -> HTTPRoute network links from the parentRefs
+			for _, key := range routeParentGateways(&httpRoutes.Items[i]) {
+				if gatewayUID, ok := gatewayMap[key]; ok {
+					links = append(links, ClusterLink{Source: gatewayUID, Target: res.ID, Type: "network"})
+				}
+			}
+
+			for _, refUID := range res.OwnerRefs {
+				links = append(links, ClusterLink{Source: res.ID, Target: refUID, Type: "owner"})
+			}
 
-			res := LightResource{
-				ID:                uid,
-				Name:              name,
-				Namespace:         namespace,
-				Kind:              "Application",
-				Status:            status,
-				Health:            health,
-				Labels:            labelsMap,
-				OwnerRefs:         ownerRefs,
-				CreationTimestamp: creationTimestamp,
+			// Add HTTPRoute -> Service network links
+			for _, backend := range res.IngressBackends {
+				if svcUID, ok := svcMap[res.Namespace+"/"+backend.ServiceName]; ok {
+					links = append(links, ClusterLink{Source: res.ID, Target: svcUID, Type: "network"})
+				}
 			}
+		}
+	}
+
+	// Process Istio Gateways, then the VirtualServices bound to them and
+	// routing to Services, and the DestinationRules of Services (istioKinds
+	// lists Gateways before VirtualServices)
+	istioGatewayMap := make(map[string]string) // namespace/name -> uid
+	for i, list := range istioLists {
+		if list == nil {
+			continue
+		}
+		for j := range list.Items {
+			res := lightUnstructured(&list.Items[j], istioKinds[i].kind)
 			resources = append(resources, res)
+			if res.Kind == "IstioGateway" {
+				istioGatewayMap[res.Namespace+"/"+res.Name] = res.ID
+			}
+
+			for _, refUID := range res.OwnerRefs {
+				links = append(links, ClusterLink{Source: res.ID, Target: refUID, Type: "owner"})
+			}
+
+			// Add VirtualService -> Gateway network links
+			for _, gw := range res.Istio.Gateways {
+				if gatewayUID, ok := istioGatewayMap[gw]; ok {
+					links = append(links, ClusterLink{Source: res.ID, Target: gatewayUID, Type: "network"})
+				}
+			}
 
-			for _, refUID := range ownerRefs {
-				links = append(links, ClusterLink{Source: uid, Target: refUID, Type: "owner"})
+			// Add VirtualService -> Service network links, and
+			// DestinationRule -> Service policy links
+			linkType := "network"
+			if res.Kind == "DestinationRule" {
+				linkType = "policy"
+			}
+			for _, svc := range res.Istio.Destinations {
+				if svcUID, ok := svcMap[svc]; ok {
+					links = append(links, ClusterLink{Source: res.ID, Target: svcUID, Type: linkType})
+				}
 			}
 		}
 	}
 
-	// Link Helm-managed resources to their HelmRelease
+	// Process ServiceAccounts, Roles and bindings
+	var (
+		podItems            []corev1.Pod
+		saItems             []corev1.ServiceAccount
+		roleItems           []rbacv1.Role
+		clusterRoleItems    []rbacv1.ClusterRole
+		roleBindingItems    []rbacv1.RoleBinding
+		clusterBindingItems []rbacv1.ClusterRoleBinding
+	)
+	if pods != nil {
+		podItems = pods.Items
+	}
+	if serviceAccounts != nil {
+		saItems = serviceAccounts.Items
+	}
+	if roles != nil {
+		roleItems = roles.Items
+	}
+	if clusterRoles != nil {
+		clusterRoleItems = clusterRoles.Items
+	}
+	if roleBindings != nil {
+		roleBindingItems = roleBindings.Items
+	}
+	if clusterRoleBindings != nil {
+		clusterBindingItems = clusterRoleBindings.Items
+	}
+	rbacResources, rbacLinks := rbacGraph(podItems, saItems, roleItems, clusterRoleItems, roleBindingItems, clusterBindingItems)
+	resources = append(resources, rbacResources...)
+	links = append(links, rbacLinks...)
+
+	// External hostnames (ExternalName Services, Ingress and HTTPRoute hosts)
+	// become endpoint nodes so traffic leaving the cluster doesn't dead-end
+	endpoints, endpointLinks := externalEndpoints(resources)
+	resources = append(resources, endpoints...)
+	links = append(links, endpointLinks...)
+
+	// Link Helm-managed resources to their HelmRelease, the release of those
+	// without Helm labels found in the stored manifests
+	helmListed := map[string]bool{"secret": secrets != nil, "configmap": configmaps != nil, "sql": opts.lists("HelmRelease")}
+	if helmListed[helmDriverName] && opts.labelSelector == "" {
+		recordHelmManifests(config.Host, opts.namespace, helmReleases)
+	}
+	for i := range resources {
+		applyHelmManifest(config.Host, &resources[i])
+	}
 	helmReleaseUIDs := make(map[string]string) // namespace/releaseName -> helmReleaseID
 	for _, res := range resources {
 		if res.Kind == "HelmRelease" && res.HelmRelease != nil {
@@ -1307,12 +2476,206 @@ func HandleInit(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Send response
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(InitResponse{
+	// Link namespaced resources to their Namespace
+	links = append(links, containmentLinks(resources)...)
+
+	// Apply LinkRules reconciled from the anakosmos CRDs
+	links = append(links, applyLinkRules(resources, settings.Current().LinkRules())...)
+
+	// LimitRange defaults for workloads leaving requests or limits unset
+	if limitRanges != nil {
+		recordLimitRanges(config.Host, opts.namespace, limitRanges)
+	}
+	for i := range resources {
+		applyLimitRangeDefaults(config.Host, &resources[i])
+	}
+
+	// Pod and Node usage from metrics-server, and what the pods of each Node
+	// reserve
+	usage := make(map[string]usageEntry)
+	if podMetrics != nil {
+		metricsUsage(podMetrics, "Pod", usage)
+	}
+	if nodeMetrics != nil {
+		metricsUsage(nodeMetrics, "Node", usage)
+	}
+	// Label- and kind-filtered builds only see some pods; they reuse the last
+	// full one
+	if opts.labelSelector == "" && opts.kinds == nil {
+		if pods != nil && opts.clusterScoped {
+			nodeReservations(pods.Items, usage)
+		}
+		recordUsage(config.Host, opts.namespace, usage)
+	}
+	for i := range resources {
+		applyUsage(config.Host, &resources[i])
+	}
+
+	// Recent warning events of each resource
+	if warningEvents != nil {
+		recordWarningEvents(config.Host, opts.namespace, warningEvents.Items)
+	}
+	for i := range resources {
+		applyWarningEvents(config.Host, &resources[i])
+	}
+
+	// The per-kind converters leave the health rule and Terminating
+	// overrides to toLightResource, which init doesn't go through
+	for i := range resources {
+		applyHealthRule(&resources[i])
+		applyTerminating(&resources[i])
+		unhealthyTracker.Observe(&resources[i])
+	}
+
+	// Filtered builds miss owners that still exist
+	if opts.labelSelector == "" && opts.kinds == nil {
+		markOrphans(resources)
+	}
+
+	// Logical applications from the part-of / instance labels, once member
+	// health is final; kind-filtered builds leave them out
+	if opts.kinds == nil {
+		groups, groupLinks := appGroups(resources)
+		resources = append(resources, groups...)
+		links = append(links, groupLinks...)
+	}
+
+	// Selector and ownerRef links often coincide
+	links = dedupLinks(links)
+
+	endBuild()
+
+	return &InitResponse{
 		Resources: resources,
 		Links:     links,
-	})
+	}, nil
+}
+
+// dedupLinks drops repeated links, keeping the first of each
+func dedupLinks(links []ClusterLink) []ClusterLink {
+	seen := make(map[ClusterLink]bool, len(links))
+	kept := links[:0]
+	for _, l := range links {
+		if !seen[l] {
+			seen[l] = true
+			kept = append(kept, l)
+		}
+	}
+	return kept
+}
+
+// compactLinks encodes links against the positions of resources, dropping
+// those pointing outside them
+func compactLinks(resources []LightResource, links []ClusterLink) []CompactLink {
+	index := make(map[string]int, len(resources))
+	for i := range resources {
+		index[resources[i].ID] = i
+	}
+	compact := make([]CompactLink, 0, len(links))
+	for _, l := range links {
+		source, okSource := index[l.Source]
+		target, okTarget := index[l.Target]
+		if okSource && okTarget {
+			compact = append(compact, CompactLink{Source: source, Target: target, Type: l.Type, State: l.State})
+		}
+	}
+	return compact
+}
+
+// linksWithin keeps the links joining two of resources, dropping those that
+// point outside a filtered graph
+func linksWithin(resources []LightResource, links []ClusterLink) []ClusterLink {
+	ids := make(map[string]bool, len(resources))
+	for i := range resources {
+		ids[resources[i].ID] = true
+	}
+	kept := make([]ClusterLink, 0, len(links))
+	for _, l := range links {
+		if ids[l.Source] && ids[l.Target] {
+			kept = append(kept, l)
+		}
+	}
+	return kept
+}
+
+// onlyKinds keeps the resources of kinds and the links between them
+func onlyKinds(resources []LightResource, links []ClusterLink, kinds []string) ([]LightResource, []ClusterLink) {
+	kept := make([]LightResource, 0, len(resources))
+	for _, res := range resources {
+		if containsString(kinds, res.Kind) {
+			kept = append(kept, res)
+		}
+	}
+	return kept, linksWithin(kept, links)
+}
+
+// filterByScope drops resources outside the caller's namespaces along with
+// every link touching them, so no dangling references are returned.
+func filterByScope(resources []LightResource, links []ClusterLink, scope auth.Scope) ([]LightResource, []ClusterLink) {
+	if scope.All {
+		return resources, links
+	}
+	kept := make(map[string]bool, len(resources))
+	filtered := make([]LightResource, 0, len(resources))
+	for _, res := range resources {
+		if resourceAllowed(scope, &res) {
+			filtered = append(filtered, res)
+			kept[res.ID] = true
+		}
+	}
+	filteredLinks := make([]ClusterLink, 0, len(links))
+	for _, l := range links {
+		if kept[l.Source] && kept[l.Target] {
+			filteredLinks = append(filteredLinks, l)
+		}
+	}
+	return filtered, filteredLinks
+}
+
+// resourceAllowed reports whether a resource is visible in scope. Namespace
+// objects are visible to the tenants of that namespace.
+func resourceAllowed(scope auth.Scope, res *LightResource) bool {
+	if res.Kind == "Namespace" {
+		return scope.Allows(res.Name)
+	}
+	return scope.Allows(res.Namespace)
+}
+
+// applyLinkRules links resources of a rule's source kind to resources of its
+// target kind in the same namespace when both carry the same value for the
+// rule's label.
+func applyLinkRules(resources []LightResource, rules []settings.LinkRule) []ClusterLink {
+	var links []ClusterLink
+	for _, rule := range rules {
+		if rule.MatchLabel == "" {
+			continue
+		}
+		targets := make(map[string][]string) // namespace/labelValue -> uids
+		for _, res := range resources {
+			if res.Kind != rule.TargetKind {
+				continue
+			}
+			if v, ok := res.Labels[rule.MatchLabel]; ok {
+				key := res.Namespace + "/" + v
+				targets[key] = append(targets[key], res.ID)
+			}
+		}
+		for _, res := range resources {
+			if res.Kind != rule.SourceKind {
+				continue
+			}
+			v, ok := res.Labels[rule.MatchLabel]
+			if !ok {
+				continue
+			}
+			for _, targetUID := range targets[res.Namespace+"/"+v] {
+				if targetUID != res.ID {
+					links = append(links, ClusterLink{Source: res.ID, Target: targetUID, Type: rule.LinkType})
+				}
+			}
+		}
+	}
+	return links
 }
 
 func extractOwnerRefs(refs []metav1.OwnerReference) []string {
@@ -1350,13 +2713,3 @@ func findLastDash(s string) int {
 	}
 	return -1
 }
-
-func mustParseInt(s string) int64 {
-	var result int64
-	for _, c := range s {
-		if c >= '0' && c <= '9' {
-			result = result*10 + int64(c-'0')
-		}
-	}
-	return result
-}
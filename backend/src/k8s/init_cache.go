@@ -0,0 +1,164 @@
+package k8s
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// initRebuildDelay batches the informer events of a burst (a rollout, a
+// namespace being deleted) into one rebuild of the precomputed graph, and is
+// the shortest gap between two rebuilds
+const initRebuildDelay = 5 * time.Second
+
+// initMaxAge bounds how stale the precomputed graph gets on a quiet cluster.
+// CRD kinds aren't behind informers, so their changes show up on this rebuild.
+const initMaxAge = time.Minute
+
+// initSyncWait is how long the first build waits for the informers to sync;
+// kinds still unsynced after that (e.g. forbidden by RBAC) are listed
+const initSyncWait = time.Minute
+
+// WarmInitCache starts the informers of the cluster behind config and the
+// background build of its graph, so the first /api/cluster/init doesn't pay
+// for a cold listing
+func WarmInitCache(config *rest.Config) {
+	if permittedNamespaces(context.Background(), config) != nil {
+		return
+	}
+	informersFor(config)
+}
+
+// notifyChanges has every informer of src signal a rebuild of the precomputed
+// graph. Sends never block: one pending signal covers any number of events.
+func (src *informerSource) notifyChanges() {
+	signal := func(interface{}) {
+		select {
+		case src.changed <- struct{}{}:
+		default:
+		}
+	}
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    signal,
+		UpdateFunc: func(_, obj interface{}) { signal(obj) },
+		DeleteFunc: signal,
+	}
+	for _, informer := range src.all() {
+		if informer != nil {
+			informer.AddEventHandler(handler)
+		}
+	}
+}
+
+// all returns every informer of src, nil for the APIs the cluster doesn't serve
+func (src *informerSource) all() []cache.SharedIndexInformer {
+	return []cache.SharedIndexInformer{
+		src.namespaces, src.nodes, src.pods, src.services, src.deployments, src.statefulsets,
+		src.daemonsets, src.replicasets, src.ingresses, src.networkpolicies, src.endpointslices,
+		src.pvcs, src.pvs, src.configmaps, src.secrets, src.storageclasses, src.csidrivers,
+		src.volumeattachments, src.jobs, src.cronjobs, src.hpas, src.limitranges,
+		src.resourcequotas, src.pdbs, src.serviceaccounts, src.roles, src.rolebindings,
+		src.clusterroles, src.clusterrolebindings,
+	}
+}
+
+// precompute keeps the full graph of the cluster built from the informers
+// until src is stopped. It is rebuilt once a burst of changes settles, and at
+// least every initMaxAge.
+func (src *informerSource) precompute(config *rest.Config) {
+	var synced []cache.InformerSynced
+	for _, informer := range src.all() {
+		if informer != nil {
+			synced = append(synced, informer.HasSynced)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), initSyncWait)
+	go func() {
+		select {
+		case <-src.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	cache.WaitForCacheSync(ctx.Done(), synced...)
+	cancel()
+	src.journal.open()
+
+	ticker := time.NewTicker(initMaxAge)
+	defer ticker.Stop()
+	for {
+		src.rebuild(config)
+		select {
+		case <-src.stop:
+			return
+		case <-ticker.C:
+		case <-src.changed:
+			select {
+			case <-src.stop:
+				return
+			case <-time.After(initRebuildDelay):
+			}
+		}
+		// Events seen while waiting are part of the rebuild below
+		select {
+		case <-src.changed:
+		default:
+		}
+	}
+}
+
+// rebuild builds the full graph from the informers and keeps it. On failure
+// the previous graph is kept.
+func (src *informerSource) rebuild(config *rest.Config) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	start := time.Now()
+	// Changes journaled by now are in the caches the build reads
+	resourceVersion := src.journal.resourceVersion()
+	graph, err := buildGraph(ctx, config, graphOptions{clusterScoped: true, informers: src})
+	if err != nil {
+		log.Printf("Failed to precompute the graph of %s: %v", config.Host, err)
+		return
+	}
+	graph.ResourceVersion = resourceVersion
+	src.graphMu.Lock()
+	src.graph = graph
+	src.builtAt = start
+	src.graphMu.Unlock()
+}
+
+// usesInformers reports whether the graph of the cluster behind config is
+// precomputed from informers, which it isn't with the informer cache off or in
+// namespaced mode
+func usesInformers(ctx context.Context, config *rest.Config) bool {
+	informerSources.Lock()
+	enabled := informerSources.enabled
+	informerSources.Unlock()
+	return enabled && permittedNamespaces(ctx, config) == nil
+}
+
+// precomputedGraph returns the full graph kept for the cluster behind config
+// and when its build started, or nil when there is none yet (still warming
+// up, informers disabled, or namespaced mode). The resources are a copy the
+// caller may modify.
+func precomputedGraph(ctx context.Context, config *rest.Config) (*InitResponse, time.Time) {
+	if permittedNamespaces(ctx, config) != nil {
+		return nil, time.Time{}
+	}
+	src := informersFor(config)
+	if src == nil {
+		return nil, time.Time{}
+	}
+	src.graphMu.RLock()
+	defer src.graphMu.RUnlock()
+	if src.graph == nil {
+		return nil, time.Time{}
+	}
+	graph := *src.graph
+	graph.Resources = slices.Clone(graph.Resources)
+	return &graph, src.builtAt
+}
@@ -0,0 +1,155 @@
+package k8s
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// InitDelta is returned by /api/cluster/init?since=<snapshotHash> when that
+// snapshot is still known: only what changed since, plus the new hash
+type InitDelta struct {
+	Since        string          `json:"since"`
+	SnapshotHash string          `json:"snapshotHash"`
+	Added        []LightResource `json:"added"`
+	Changed      []LightResource `json:"changed"`
+	Removed      []string        `json:"removed"` // resource IDs
+	AddedLinks   []ClusterLink   `json:"addedLinks"`
+	RemovedLinks []ClusterLink   `json:"removedLinks"`
+	GeneratedAt  string          `json:"generatedAt,omitempty"`
+}
+
+// snapshotTTL is how long an init snapshot can serve as a delta base, long
+// enough to cover tabs backgrounded for a few minutes
+const snapshotTTL = 10 * time.Minute
+
+// maxSnapshots bounds the memory held by remembered snapshots
+const maxSnapshots = 256
+
+// graphSnapshot is what a delta needs to remember of a sent graph: a content
+// hash per resource and the links
+type graphSnapshot struct {
+	resources map[string]string
+	links     map[ClusterLink]bool
+	at        time.Time
+}
+
+var snapshots = struct {
+	sync.Mutex
+	byKey map[string]*graphSnapshot
+}{byKey: make(map[string]*graphSnapshot)}
+
+// newGraphSnapshot hashes a graph as sent to one caller
+func newGraphSnapshot(resources []LightResource, links []ClusterLink) (string, *graphSnapshot) {
+	snap := &graphSnapshot{
+		resources: make(map[string]string, len(resources)),
+		links:     make(map[ClusterLink]bool, len(links)),
+		at:        time.Now(),
+	}
+	ids := make([]string, 0, len(resources))
+	for i := range resources {
+		snap.resources[resources[i].ID] = stateHash(&resources[i])
+		ids = append(ids, resources[i].ID)
+	}
+	sort.Strings(ids)
+	linkKeys := make([]string, 0, len(links))
+	for _, l := range links {
+		snap.links[l] = true
+		linkKeys = append(linkKeys, l.Source+">"+l.Target+">"+l.Type+">"+l.State)
+	}
+	sort.Strings(linkKeys)
+
+	h := sha256.New()
+	for _, id := range ids {
+		h.Write([]byte(id + "=" + snap.resources[id] + "\n"))
+	}
+	for _, k := range linkKeys {
+		h.Write([]byte(k + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:12]), snap
+}
+
+// rememberSnapshot stores a snapshot under the caller's credential so hashes
+// can't be used to read another user's view
+func rememberSnapshot(credential, hash string, snap *graphSnapshot) {
+	snapshots.Lock()
+	defer snapshots.Unlock()
+	for k, s := range snapshots.byKey {
+		if time.Since(s.at) > snapshotTTL {
+			delete(snapshots.byKey, k)
+		}
+	}
+	if len(snapshots.byKey) >= maxSnapshots {
+		oldest := ""
+		for k, s := range snapshots.byKey {
+			if oldest == "" || s.at.Before(snapshots.byKey[oldest].at) {
+				oldest = k
+			}
+		}
+		delete(snapshots.byKey, oldest)
+	}
+	snapshots.byKey[credential+"|"+hash] = snap
+}
+
+func lookupSnapshot(credential, hash string) *graphSnapshot {
+	snapshots.Lock()
+	defer snapshots.Unlock()
+	snap, ok := snapshots.byKey[credential+"|"+hash]
+	if !ok || time.Since(snap.at) > snapshotTTL {
+		return nil
+	}
+	return snap
+}
+
+// graphDelta lists the differences between a previous snapshot and the
+// current graph
+func graphDelta(prev, current *graphSnapshot, resources []LightResource, links []ClusterLink) InitDelta {
+	delta := InitDelta{
+		Added:        []LightResource{},
+		Changed:      []LightResource{},
+		Removed:      []string{},
+		AddedLinks:   []ClusterLink{},
+		RemovedLinks: []ClusterLink{},
+	}
+	for _, res := range resources {
+		old, ok := prev.resources[res.ID]
+		switch {
+		case !ok:
+			delta.Added = append(delta.Added, res)
+		case old != current.resources[res.ID]:
+			delta.Changed = append(delta.Changed, res)
+		}
+	}
+	for id := range prev.resources {
+		if _, ok := current.resources[id]; !ok {
+			delta.Removed = append(delta.Removed, id)
+		}
+	}
+	sort.Strings(delta.Removed)
+	for _, l := range links {
+		if !prev.links[l] {
+			delta.AddedLinks = append(delta.AddedLinks, l)
+		}
+	}
+	for l := range prev.links {
+		if !current.links[l] {
+			delta.RemovedLinks = append(delta.RemovedLinks, l)
+		}
+	}
+	return delta
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, comparing
+// weakly as RFC 9110 asks for GET
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
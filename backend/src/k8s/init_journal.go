@@ -0,0 +1,230 @@
+package k8s
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	"github.com/anakosmos/backend/src/notes"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// journalSize bounds the changes kept per cluster for /api/cluster/delta;
+// older resourceVersions get 410 Gone and a full init
+const journalSize = 10000
+
+// DeltaEvent is a change replayed by /api/cluster/delta, shaped like a watch
+// event
+type DeltaEvent struct {
+	Type     string         `json:"type"` // ADDED, MODIFIED, DELETED
+	Resource *LightResource `json:"resource"`
+}
+
+// ClusterDelta is the response of /api/cluster/delta: the last change of each
+// resource changed since, and the resourceVersion to ask from next time
+type ClusterDelta struct {
+	Since           string       `json:"since"`
+	ResourceVersion string       `json:"resourceVersion"`
+	Events          []DeltaEvent `json:"events"`
+}
+
+type journalEntry struct {
+	rv        uint64
+	eventType string
+	res       *LightResource
+}
+
+// changeJournal records the informer events of a cluster in the order the
+// caches applied them. Informers of different kinds don't deliver in global
+// resourceVersion order, so each entry is stamped with the object's
+// resourceVersion or one more than the last stamp, whichever is higher: the
+// changes after a stamp are then always a suffix of the journal.
+type changeJournal struct {
+	mu      sync.Mutex
+	entries []journalEntry
+	last    uint64
+	// floor is the lowest resourceVersion deltas can start from: the one
+	// the informers had synced to, then the last entry dropped
+	floor  uint64
+	opened bool
+}
+
+// recordChanges journals the events of every informer of src. Objects of the
+// initial lists aren't changes; they only move the resourceVersion forward.
+func (src *informerSource) recordChanges() {
+	record := func(eventType string, obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		src.journal.record(eventType, obj)
+	}
+	handler := cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
+			if isInInitialList {
+				src.journal.record("", obj)
+				return
+			}
+			record("ADDED", obj)
+		},
+		UpdateFunc: func(_, obj interface{}) { record("MODIFIED", obj) },
+		DeleteFunc: func(obj interface{}) { record("DELETED", obj) },
+	}
+	for _, informer := range src.all() {
+		if informer != nil {
+			informer.AddEventHandler(handler)
+		}
+	}
+}
+
+// record stamps and appends a change; an empty eventType only moves the
+// resourceVersion forward
+func (j *changeJournal) record(eventType string, obj interface{}) {
+	var rv uint64
+	if accessor, err := meta.Accessor(obj); err == nil {
+		rv, _ = strconv.ParseUint(accessor.GetResourceVersion(), 10, 64)
+	}
+	var res *LightResource
+	if eventType != "" {
+		res = toLightResource(obj)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if res == nil {
+		j.last = max(j.last, rv)
+		return
+	}
+	j.last = max(j.last+1, rv)
+	j.entries = append(j.entries, journalEntry{rv: j.last, eventType: eventType, res: res})
+	if len(j.entries) > journalSize {
+		drop := len(j.entries) - journalSize
+		j.floor = j.entries[drop-1].rv
+		j.entries = append([]journalEntry(nil), j.entries[drop:]...)
+	}
+}
+
+// open starts serving deltas from the current resourceVersion, once the
+// informers have synced
+func (j *changeJournal) open() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.floor = j.last
+	j.opened = true
+}
+
+// resourceVersion returns the stamp every change journaled so far is at or
+// below, "" before the journal is open
+func (j *changeJournal) resourceVersion() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.opened {
+		return ""
+	}
+	return strconv.FormatUint(j.last, 10)
+}
+
+// since returns the last change of each resource stamped after rv, in order,
+// and the current resourceVersion. ok is false when rv is older than the
+// journal.
+func (j *changeJournal) since(rv uint64) (changes []journalEntry, current uint64, ok bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.opened || rv < j.floor || rv > j.last {
+		return nil, 0, false
+	}
+	start := sort.Search(len(j.entries), func(i int) bool { return j.entries[i].rv > rv })
+	latest := make(map[string]int)
+	for i := start; i < len(j.entries); i++ {
+		latest[j.entries[i].res.ID] = i
+	}
+	for i := start; i < len(j.entries); i++ {
+		if latest[j.entries[i].res.ID] == i {
+			changes = append(changes, j.entries[i])
+		}
+	}
+	return changes, j.last, true
+}
+
+// HandleDelta handles /api/cluster/delta?since=<resourceVersion>, with the
+// resourceVersion of an init response or of the previous delta. It replays
+// the last change of each built-in resource since, shaped like watch events,
+// so a reconnecting client needn't reload the graph; CRD kinds only come back
+// through the watch. Answers 410 Gone when since is older than the changes
+// kept, and 503 when the cluster has no informers (--informer-cache=false or
+// namespaced mode): the client then loads init again. ?namespaces=,
+// ?kinds= and ?includeSystem= filter like on init.
+func HandleDelta(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	if config == nil {
+		i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+		return
+	}
+	q := r.URL.Query()
+	if q.Get("since") == "" {
+		i18n.Error(w, r, http.StatusBadRequest, "error.required", "since")
+		return
+	}
+	since, err := strconv.ParseUint(q.Get("since"), 10, 64)
+	if err != nil {
+		i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "since")
+		return
+	}
+	if err := unreachableDrill(config.Host); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	var src *informerSource
+	if permittedNamespaces(r.Context(), config) == nil {
+		src = informersFor(config)
+	}
+	if src == nil {
+		i18n.Error(w, r, http.StatusServiceUnavailable, "error.unavailable", "delta")
+		return
+	}
+	changes, current, ok := src.journal.since(since)
+	if !ok {
+		i18n.Error(w, r, http.StatusGone, "error.expired", "since")
+		return
+	}
+
+	var namespaces, kinds []string
+	if v := q.Get("namespaces"); v != "" {
+		namespaces = strings.Split(v, ",")
+	}
+	if v := q.Get("kinds"); v != "" {
+		kinds = strings.Split(v, ",")
+	}
+	includeSystem := q.Get("includeSystem") == "true"
+	scope := auth.ScopeFromContext(r.Context())
+	noteByID := notes.ForCluster(auth.RequestCluster(r))
+
+	delta := ClusterDelta{Since: q.Get("since"), ResourceVersion: strconv.FormatUint(current, 10), Events: []DeltaEvent{}}
+	for _, change := range changes {
+		res := *change.res
+		if namespaces != nil && !containsString(namespaces, res.Namespace) ||
+			kinds != nil && !containsString(kinds, res.Kind) ||
+			!includeSystem && isSystemResource(&res) ||
+			!scope.All && !resourceAllowed(scope, &res) {
+			continue
+		}
+		if change.eventType != "DELETED" {
+			applyHelmManifest(config.Host, &res)
+			applyLimitRangeDefaults(config.Host, &res)
+			applyWarningEvents(config.Host, &res)
+			applyUsage(config.Host, &res)
+			applyDrills(config.Host, &res)
+			res.Note = noteByID[res.ID]
+		}
+		delta.Events = append(delta.Events, DeltaEvent{Type: change.eventType, Resource: &res})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delta)
+}
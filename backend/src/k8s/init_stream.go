@@ -0,0 +1,81 @@
+package k8s
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// initStreamChunk is how many resources or links go on one streamed line
+const initStreamChunk = 500
+
+// InitChunk is one line of /api/cluster/init?stream=true. Resources come
+// first, possibly over many lines, then the links; the last line has Done set
+// and carries the rest of InitResponse, or Error when the build failed.
+type InitChunk struct {
+	Resources       []LightResource `json:"resources,omitempty"`
+	Links           []ClusterLink   `json:"links,omitempty"`
+	Done            bool            `json:"done,omitempty"`
+	SnapshotHash    string          `json:"snapshotHash,omitempty"`
+	Access          *ClusterAccess  `json:"access,omitempty"`
+	GeneratedAt     string          `json:"generatedAt,omitempty"`
+	ResourceVersion string          `json:"resourceVersion,omitempty"`
+	Error           string          `json:"error,omitempty"`
+}
+
+// initStream writes a streamed init response as newline-delimited JSON,
+// flushing every line. Each resource is only sent once.
+type initStream struct {
+	mu   sync.Mutex
+	w    http.ResponseWriter
+	enc  *json.Encoder
+	sent map[string]bool
+}
+
+func newInitStream(w http.ResponseWriter) *initStream {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	return &initStream{w: w, enc: json.NewEncoder(w), sent: make(map[string]bool)}
+}
+
+func (s *initStream) write(chunk InitChunk) {
+	s.enc.Encode(chunk)
+	http.NewResponseController(s.w).Flush()
+}
+
+// resources sends the resources not sent yet
+func (s *initStream) resources(resources []LightResource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pending []LightResource
+	for _, res := range resources {
+		if !s.sent[res.ID] {
+			s.sent[res.ID] = true
+			pending = append(pending, res)
+		}
+	}
+	for len(pending) > 0 {
+		n := min(len(pending), initStreamChunk)
+		s.write(InitChunk{Resources: pending[:n]})
+		pending = pending[n:]
+	}
+}
+
+// finish sends the links and the closing line
+func (s *initStream) finish(links []ClusterLink, last InitChunk) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(links) > 0 {
+		n := min(len(links), initStreamChunk)
+		s.write(InitChunk{Links: links[:n]})
+		links = links[n:]
+	}
+	last.Done = true
+	s.write(last)
+}
+
+func (s *initStream) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.write(InitChunk{Error: err.Error()})
+}
@@ -0,0 +1,134 @@
+package k8s
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const istioGroup = "networking.istio.io"
+
+// istioKinds are the Istio traffic kinds shown in the graph, Gateways first.
+// Istio Gateways are "IstioGateway" in the graph, as "Gateway" stands for
+// Gateway API ones.
+var istioKinds = []versionedKind{
+	{"IstioGateway", istioGroup, "gateways", []string{"v1", "v1beta1", "v1alpha3"}},
+	{"VirtualService", istioGroup, "virtualservices", []string{"v1", "v1beta1", "v1alpha3"}},
+	{"DestinationRule", istioGroup, "destinationrules", []string{"v1", "v1beta1", "v1alpha3"}},
+}
+
+// IstioInfo summarizes an Istio VirtualService, Gateway or DestinationRule.
+// VirtualServices link to the Services they route to and the Gateways they
+// are bound to, DestinationRules to the Service they apply to.
+type IstioInfo struct {
+	// Hosts are the hosts a VirtualService or the servers of a Gateway serve
+	Hosts []string `json:"hosts,omitempty"`
+	// Gateways (namespace/name) a VirtualService is bound to; "mesh" stands
+	// for the sidecars
+	Gateways []string `json:"gateways,omitempty"`
+	// Destinations (namespace/name) are the Services a VirtualService routes
+	// to, or the one a DestinationRule applies to
+	Destinations []string `json:"destinations,omitempty"`
+	// Subsets are the named subsets of a DestinationRule
+	Subsets []string `json:"subsets,omitempty"`
+	// Message is the code of the first validation message istiod reported,
+	// e.g. IST0101
+	Message string `json:"message,omitempty"`
+}
+
+// applyIstioStatus fills an Istio object's hosts and references, and derives
+// health from the validation messages istiod writes when status reporting is
+// on: an error level message is an error, a warning one a warning
+func applyIstioStatus(obj *unstructured.Unstructured, res *LightResource) {
+	info := &IstioInfo{}
+	switch res.Kind {
+	case "VirtualService":
+		info.Hosts, _, _ = unstructured.NestedStringSlice(obj.Object, "spec", "hosts")
+		gateways, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "gateways")
+		for _, gw := range gateways {
+			if gw != "mesh" && !strings.Contains(gw, "/") {
+				gw = res.Namespace + "/" + gw
+			}
+			info.Gateways = append(info.Gateways, gw)
+		}
+		for _, protocol := range []string{"http", "tcp", "tls"} {
+			routes, _, _ := unstructured.NestedSlice(obj.Object, "spec", protocol)
+			for _, route := range routes {
+				m, _ := route.(map[string]interface{})
+				destinations, _, _ := unstructured.NestedSlice(m, "route")
+				for _, d := range destinations {
+					dm, _ := d.(map[string]interface{})
+					host, _, _ := unstructured.NestedString(dm, "destination", "host")
+					if svc := istioServiceHost(host, res.Namespace); svc != "" && !containsString(info.Destinations, svc) {
+						info.Destinations = append(info.Destinations, svc)
+					}
+				}
+			}
+		}
+	case "IstioGateway":
+		servers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "servers")
+		for _, s := range servers {
+			m, _ := s.(map[string]interface{})
+			hosts, _, _ := unstructured.NestedStringSlice(m, "hosts")
+			for _, host := range hosts {
+				if !containsString(info.Hosts, host) {
+					info.Hosts = append(info.Hosts, host)
+				}
+			}
+		}
+	case "DestinationRule":
+		host, _, _ := unstructured.NestedString(obj.Object, "spec", "host")
+		if svc := istioServiceHost(host, res.Namespace); svc != "" {
+			info.Destinations = []string{svc}
+		}
+		subsets, _, _ := unstructured.NestedSlice(obj.Object, "spec", "subsets")
+		for _, s := range subsets {
+			m, _ := s.(map[string]interface{})
+			if name, _, _ := unstructured.NestedString(m, "name"); name != "" {
+				info.Subsets = append(info.Subsets, name)
+			}
+		}
+	}
+	res.Istio = info
+
+	res.Status, res.Health = "Active", "ok"
+	messages, _, _ := unstructured.NestedSlice(obj.Object, "status", "validationMessages")
+	for _, msg := range messages {
+		m, _ := msg.(map[string]interface{})
+		level, _, _ := unstructured.NestedString(m, "level")
+		code, _, _ := unstructured.NestedString(m, "type", "code")
+		switch {
+		case level == "ERROR":
+			res.Status, res.Health = "Invalid", "error"
+		case level == "WARNING" && res.Health == "ok":
+			res.Status, res.Health = "Warning", "warning"
+		default:
+			continue
+		}
+		if info.Message == "" {
+			info.Message = code
+		}
+	}
+}
+
+// istioServiceHost resolves a destination host to the namespace/name of the
+// Service it names: short names are relative to the referring namespace, and
+// "name.namespace" may be followed by ".svc" and the cluster domain. Other
+// hosts (external ones, wildcards) resolve to "".
+func istioServiceHost(host, namespace string) string {
+	if host == "" || strings.Contains(host, "*") {
+		return ""
+	}
+	if i := strings.Index(host, ".svc"); i > 0 {
+		host = host[:i]
+	}
+	parts := strings.Split(host, ".")
+	switch len(parts) {
+	case 1:
+		return namespace + "/" + parts[0]
+	case 2:
+		return parts[1] + "/" + parts[0]
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,65 @@
+package k8s
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// KEDAInfo summarizes a KEDA ScaledObject. Like HPAs, ScaledObjects link to
+// the workload they scale; the HPA KEDA manages for them is owned by them.
+type KEDAInfo struct {
+	// Triggers are the trigger types, e.g. prometheus, kafka, cron
+	Triggers    []string `json:"triggers,omitempty"`
+	MinReplicas int64    `json:"minReplicas"`
+	MaxReplicas int64    `json:"maxReplicas,omitempty"`
+	// Message is the Ready condition's message when not ready
+	Message string `json:"message,omitempty"`
+}
+
+// applyScaledObjectStatus fills a ScaledObject's target and triggers, and
+// derives health from its conditions: not ready is an error, paused or
+// scaling on fallback replicas a warning; ready ones are Active while a
+// trigger is, Idle otherwise
+func applyScaledObjectStatus(obj *unstructured.Unstructured, res *LightResource) {
+	info := &KEDAInfo{MaxReplicas: 100}
+	if name, _, _ := unstructured.NestedString(obj.Object, "spec", "scaleTargetRef", "name"); name != "" {
+		kind, _, _ := unstructured.NestedString(obj.Object, "spec", "scaleTargetRef", "kind")
+		if kind == "" {
+			kind = "Deployment"
+		}
+		res.ScaleTargetRef = &ScaleTargetRef{Kind: kind, Name: name}
+	}
+	if n, found, _ := unstructured.NestedInt64(obj.Object, "spec", "minReplicaCount"); found {
+		info.MinReplicas = n
+	}
+	if n, found, _ := unstructured.NestedInt64(obj.Object, "spec", "maxReplicaCount"); found {
+		info.MaxReplicas = n
+	}
+	triggers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "triggers")
+	for _, t := range triggers {
+		m, _ := t.(map[string]interface{})
+		if kind, _, _ := unstructured.NestedString(m, "type"); kind != "" && !containsString(info.Triggers, kind) {
+			info.Triggers = append(info.Triggers, kind)
+		}
+	}
+	res.KEDA = info
+
+	res.Status, res.Health = "Unknown", "warning"
+	conditions := make(map[string]objectCondition)
+	for _, cond := range objectConditions(obj) {
+		conditions[cond.condType] = cond
+	}
+	ready, active := conditions["Ready"], conditions["Active"]
+	switch {
+	case ready.status == "False":
+		res.Status, res.Health = "NotReady", "error"
+		info.Message = ready.message
+	case conditions["Paused"].status == "True":
+		res.Status, res.Health = "Paused", "warning"
+	case conditions["Fallback"].status == "True":
+		res.Status, res.Health = "Fallback", "warning"
+	case ready.status == "True" && active.status == "True":
+		res.Status, res.Health = "Active", "ok"
+	case ready.status == "True":
+		res.Status, res.Health = "Idle", "ok"
+	}
+}
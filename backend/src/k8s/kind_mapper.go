@@ -0,0 +1,96 @@
+package k8s
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anakosmos/backend/src/auth"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+)
+
+// kindRediscoverInterval bounds how often an unknown kind or resource
+// triggers a new discovery, for CRDs installed since the last one
+const kindRediscoverInterval = time.Minute
+
+// discoveryKindMapper resolves kinds and plural resources from the API
+// discovery of the local cluster. Policies see the same names for every
+// cluster; kinds only served by a remote cluster resolve to "".
+type discoveryKindMapper struct {
+	client discovery.DiscoveryInterface
+
+	mu         sync.Mutex
+	kinds      map[string]string // group/resource -> kind
+	resources  map[string]string // kind -> resource, the core group first
+	discovered time.Time
+}
+
+// NewKindMapper returns the auth.KindMapper filling the kind of proxied API
+// requests and the resource of operations known by kind
+func NewKindMapper(config *rest.Config) (auth.KindMapper, error) {
+	client, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &discoveryKindMapper{client: client}, nil
+}
+
+func (m *discoveryKindMapper) KindFor(group, resource string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := group + "/" + strings.ToLower(resource)
+	if kind, ok := m.kinds[key]; ok {
+		return kind
+	}
+	m.rediscover()
+	return m.kinds[key]
+}
+
+func (m *discoveryKindMapper) ResourceFor(kind string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if resource, ok := m.resources[kind]; ok {
+		return resource
+	}
+	m.rediscover()
+	return m.resources[kind]
+}
+
+// rediscover reloads the API resources unless it happened recently. m.mu is
+// held.
+func (m *discoveryKindMapper) rediscover() {
+	if time.Since(m.discovered) < kindRediscoverInterval {
+		return
+	}
+	m.discovered = time.Now()
+	// Groups failing discovery are left out, the others are still usable
+	lists, err := m.client.ServerPreferredResources()
+	if err != nil {
+		log.Printf("Kind discovery incomplete: %v\n", err)
+	}
+	kinds := make(map[string]string)
+	resources := make(map[string]string)
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, res := range list.APIResources {
+			if strings.Contains(res.Name, "/") {
+				continue // subresource
+			}
+			kinds[gv.Group+"/"+res.Name] = res.Kind
+			// Event is served by core and events.k8s.io: core wins
+			if _, ok := resources[res.Kind]; !ok || gv.Group == "" {
+				resources[res.Kind] = res.Name
+			}
+		}
+	}
+	if len(kinds) > 0 {
+		m.kinds, m.resources = kinds, resources
+	}
+}
@@ -0,0 +1,101 @@
+package k8s
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// podLabelIndex finds the pods a selector matches without scanning every pod
+// of the cluster: pods are indexed by namespace and by each of their labels,
+// and a selector only checks the pods carrying its rarest equality
+// requirement. Built once per graph and shared by every selector lookup.
+type podLabelIndex struct {
+	byNamespace map[string][]*corev1.Pod
+	byLabel     map[string][]*corev1.Pod // namespace/key=value -> pods
+}
+
+func newPodLabelIndex(pods []corev1.Pod) *podLabelIndex {
+	idx := &podLabelIndex{
+		byNamespace: make(map[string][]*corev1.Pod),
+		byLabel:     make(map[string][]*corev1.Pod),
+	}
+	for i := range pods {
+		p := &pods[i]
+		idx.byNamespace[p.Namespace] = append(idx.byNamespace[p.Namespace], p)
+		for k, v := range p.Labels {
+			key := p.Namespace + "/" + k + "=" + v
+			idx.byLabel[key] = append(idx.byLabel[key], p)
+		}
+	}
+	return idx
+}
+
+// matching returns the pods of namespace whose labels hold every key/value
+// of selector, as matchLabels decides, in list order
+func (idx *podLabelIndex) matching(namespace string, selector map[string]string) []*corev1.Pod {
+	if selector == nil {
+		return nil
+	}
+	candidates := idx.byNamespace[namespace]
+	for k, v := range selector {
+		if pods := idx.byLabel[namespace+"/"+k+"="+v]; len(pods) < len(candidates) {
+			candidates = pods
+		}
+	}
+	var matched []*corev1.Pod
+	for _, p := range candidates {
+		if matchLabels(p.Labels, selector) {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
+
+// selecting returns the pods of namespace selector matches, in list order
+func (idx *podLabelIndex) selecting(namespace string, selector labels.Selector) []*corev1.Pod {
+	candidates := idx.byNamespace[namespace]
+	if requirements, ok := selector.Requirements(); ok {
+		for _, req := range requirements {
+			values := req.Values()
+			equality := req.Operator() == selection.Equals || req.Operator() == selection.DoubleEquals ||
+				req.Operator() == selection.In && values.Len() == 1
+			if !equality {
+				continue
+			}
+			if pods := idx.byLabel[namespace+"/"+req.Key()+"="+values.UnsortedList()[0]]; len(pods) < len(candidates) {
+				candidates = pods
+			}
+		}
+	}
+	var matched []*corev1.Pod
+	for _, p := range candidates {
+		if selector.Matches(labels.Set(p.Labels)) {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
+
+// workloadSelector evaluates the whole LabelSelector of a workload,
+// matchExpressions included; a missing or invalid one selects nothing
+func workloadSelector(selector *metav1.LabelSelector) labels.Selector {
+	if selector == nil {
+		return labels.Nothing()
+	}
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return labels.Nothing()
+	}
+	return s
+}
+
+// selectorExpression is the full selector in label query syntax, e.g.
+// "app=web,tier in (api,worker)", when matchLabels alone don't tell it
+func selectorExpression(selector *metav1.LabelSelector) string {
+	if selector == nil || len(selector.MatchExpressions) == 0 {
+		return ""
+	}
+	return workloadSelector(selector).String()
+}
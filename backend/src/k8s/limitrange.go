@@ -0,0 +1,245 @@
+package k8s
+
+import (
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ResourceDefaults describes a workload template whose containers leave
+// compute requests or limits unset, and what the namespace LimitRanges will
+// fill in when its pods are admitted
+type ResourceDefaults struct {
+	// Containers leaving at least one of the Unset fields empty
+	Containers []string `json:"containers"`
+	// Unset fields, e.g. requests.cpu or limits.memory
+	Unset []string `json:"unset"`
+	// LimitRange supplying the defaults; empty when the namespace has none
+	LimitRange string `json:"limitRange,omitempty"`
+	// Defaults the LimitRange applies to the unset fields
+	Defaults map[string]string `json:"defaults,omitempty"`
+	// QOSClass the pods get once defaults apply (BestEffort, Burstable or
+	// Guaranteed)
+	QOSClass string `json:"qosClass"`
+
+	// containers of the template, kept server-side to recompute the QoS class
+	containers []corev1.Container
+}
+
+// computeFields are the container fields LimitRange defaults cover
+var computeFields = []struct {
+	name     string
+	resource corev1.ResourceName
+	limit    bool
+}{
+	{"requests.cpu", corev1.ResourceCPU, false},
+	{"requests.memory", corev1.ResourceMemory, false},
+	{"limits.cpu", corev1.ResourceCPU, true},
+	{"limits.memory", corev1.ResourceMemory, true},
+}
+
+// templateResourceDefaults records the containers of a pod template missing
+// requests or limits; nil when all are set. Defaults are added once the
+// namespace LimitRanges are known (applyLimitRangeDefaults).
+func templateResourceDefaults(spec *corev1.PodSpec) *ResourceDefaults {
+	unset := make(map[string]bool)
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	info := &ResourceDefaults{containers: containers}
+	for _, c := range containers {
+		missing := false
+		for _, f := range computeFields {
+			if _, ok := c.Resources.Limits[f.resource]; ok {
+				continue
+			}
+			// The API server copies limits into unset requests
+			if _, ok := c.Resources.Requests[f.resource]; ok && !f.limit {
+				continue
+			}
+			unset[f.name] = true
+			missing = true
+		}
+		if missing {
+			info.Containers = append(info.Containers, c.Name)
+		}
+	}
+	if len(info.Containers) == 0 {
+		return nil
+	}
+	for _, f := range computeFields {
+		if unset[f.name] {
+			info.Unset = append(info.Unset, f.name)
+		}
+	}
+	info.QOSClass = string(effectiveQOS(containers, nil))
+	return info
+}
+
+// containerDefaults returns the default requests and limits of the first
+// Container-type item of the LimitRanges, with its LimitRange name
+func containerDefaults(ranges []corev1.LimitRange) (string, corev1.ResourceList, corev1.ResourceList) {
+	for _, lr := range ranges {
+		for _, item := range lr.Spec.Limits {
+			if item.Type == corev1.LimitTypeContainer && (len(item.Default) > 0 || len(item.DefaultRequest) > 0) {
+				return lr.Name, item.DefaultRequest, item.Default
+			}
+		}
+	}
+	return "", nil, nil
+}
+
+// effectiveQOS computes the pod QoS class with the LimitRange item's defaults
+// applied to unset fields, following the admission defaulting order:
+// LimitRange defaults first, then limits copied into unset requests
+func effectiveQOS(containers []corev1.Container, item *corev1.LimitRangeItem) corev1.PodQOSClass {
+	anySet := false
+	guaranteed := true
+	for _, c := range containers {
+		requests := corev1.ResourceList{}
+		limits := corev1.ResourceList{}
+		for name, q := range c.Resources.Requests {
+			requests[name] = q
+		}
+		for name, q := range c.Resources.Limits {
+			limits[name] = q
+		}
+		if item != nil {
+			for name, q := range item.DefaultRequest {
+				if _, ok := requests[name]; !ok {
+					if _, ok := limits[name]; !ok {
+						requests[name] = q
+					}
+				}
+			}
+			for name, q := range item.Default {
+				if _, ok := limits[name]; !ok {
+					limits[name] = q
+				}
+			}
+		}
+		for name, q := range limits {
+			if _, ok := requests[name]; !ok {
+				requests[name] = q
+			}
+		}
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			req, hasReq := requests[name]
+			limit, hasLimit := limits[name]
+			if hasReq || hasLimit {
+				anySet = true
+			}
+			if !hasLimit || !hasReq || req.Cmp(limit) != 0 {
+				guaranteed = false
+			}
+		}
+	}
+	switch {
+	case !anySet:
+		return corev1.PodQOSBestEffort
+	case guaranteed:
+		return corev1.PodQOSGuaranteed
+	}
+	return corev1.PodQOSBurstable
+}
+
+// limitRangeDefaults resolves what a namespace's LimitRanges fill in for the
+// unset fields of info
+func limitRangeDefaults(info *ResourceDefaults, ranges []corev1.LimitRange) {
+	name, requests, limits := containerDefaults(ranges)
+	info.LimitRange = name
+	info.Defaults = nil
+	if name == "" {
+		info.QOSClass = string(effectiveQOS(info.containers, nil))
+		return
+	}
+	for _, f := range computeFields {
+		if !containsString(info.Unset, f.name) {
+			continue
+		}
+		var q resource.Quantity
+		var ok bool
+		if f.limit {
+			q, ok = limits[f.resource]
+		} else if q, ok = requests[f.resource]; !ok {
+			// An unset defaultRequest falls back to the default limit
+			q, ok = limits[f.resource]
+		}
+		if ok {
+			if info.Defaults == nil {
+				info.Defaults = make(map[string]string)
+			}
+			info.Defaults[f.name] = q.String()
+		}
+	}
+	info.QOSClass = string(effectiveQOS(info.containers, &corev1.LimitRangeItem{Default: limits, DefaultRequest: requests}))
+}
+
+// limitRangeIndex keeps the LimitRanges seen by the last graph build of each
+// cluster (API server host), so watch updates get the same defaults as init
+var limitRangeIndex = struct {
+	sync.RWMutex
+	clusters map[string]map[string][]corev1.LimitRange // host -> namespace -> LimitRanges
+}{clusters: make(map[string]map[string][]corev1.LimitRange)}
+
+// recordLimitRanges replaces the LimitRanges known for a cluster, or only
+// those of one namespace when namespace is set
+func recordLimitRanges(cluster, namespace string, list *corev1.LimitRangeList) {
+	byNamespace := make(map[string][]corev1.LimitRange)
+	if list != nil {
+		for _, lr := range list.Items {
+			byNamespace[lr.Namespace] = append(byNamespace[lr.Namespace], lr)
+		}
+		for ns := range byNamespace {
+			ranges := byNamespace[ns]
+			sort.Slice(ranges, func(i, j int) bool { return ranges[i].Name < ranges[j].Name })
+		}
+	}
+	limitRangeIndex.Lock()
+	defer limitRangeIndex.Unlock()
+	if namespace != "" {
+		merged := make(map[string][]corev1.LimitRange, len(limitRangeIndex.clusters[cluster])+1)
+		for ns, ranges := range limitRangeIndex.clusters[cluster] {
+			merged[ns] = ranges
+		}
+		merged[namespace] = byNamespace[namespace]
+		byNamespace = merged
+	}
+	limitRangeIndex.clusters[cluster] = byNamespace
+}
+
+// applyLimitRangeDefaults fills the LimitRange defaults into a workload's
+// ResourceDefaults from the ranges recorded for its cluster
+func applyLimitRangeDefaults(cluster string, res *LightResource) {
+	if res.ResourceDefaults == nil {
+		return
+	}
+	limitRangeIndex.RLock()
+	namespaces, known := limitRangeIndex.clusters[cluster]
+	limitRangeIndex.RUnlock()
+	if !known {
+		// LimitRanges couldn't be listed; don't claim there are none
+		return
+	}
+	limitRangeDefaults(res.ResourceDefaults, namespaces[res.Namespace])
+}
+
+// manifestResourceDefaults is templateResourceDefaults for a workload manifest
+// about to be applied; nil for non-workloads
+func manifestResourceDefaults(u *unstructured.Unstructured) *ResourceDefaults {
+	path, _ := podTemplateLocation(u)
+	if path == nil {
+		return nil
+	}
+	rawSpec, found, _ := unstructured.NestedMap(u.Object, path...)
+	if !found {
+		return nil
+	}
+	var spec corev1.PodSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawSpec, &spec); err != nil {
+		return nil
+	}
+	return templateResourceDefaults(&spec)
+}
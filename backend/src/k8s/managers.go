@@ -0,0 +1,233 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// FieldManagerSummary is one managedFields entry: who wrote which fields, how
+// and when
+type FieldManagerSummary struct {
+	Manager     string `json:"manager"`
+	Operation   string `json:"operation"` // Apply (server-side apply) or Update
+	Subresource string `json:"subresource,omitempty"`
+	APIVersion  string `json:"apiVersion"`
+	Time        string `json:"time,omitempty"`
+	// Fields are the owned paths cut at managedFieldsDepth, e.g.
+	// spec.replicas, metadata.labels.app or spec.template.spec
+	Fields []string `json:"fields"`
+	// FieldCount is the number of leaf fields owned
+	FieldCount int `json:"fieldCount"`
+}
+
+// ContestedField is a field path owned by several managers, the usual cause
+// of changes being reverted
+type ContestedField struct {
+	Field    string   `json:"field"`
+	Managers []string `json:"managers"`
+}
+
+// ManagedFieldsResponse is served by /api/resources/managers
+type ManagedFieldsResponse struct {
+	Resource  ImpactResource        `json:"resource"`
+	Managers  []FieldManagerSummary `json:"managers"` // most recent first
+	Contested []ContestedField      `json:"contested"`
+}
+
+// managedFieldsDepth is how many path segments summaries keep; deeper fields
+// are folded into their ancestor
+const managedFieldsDepth = 3
+
+// fieldSegment renders one FieldsV1 key: f:name -> name, k:{"name":"app"} ->
+// [name=app], i:0 -> [0], v:"x" -> [="x"]
+func fieldSegment(key string) string {
+	prefix, value, ok := strings.Cut(key, ":")
+	if !ok {
+		return key
+	}
+	switch prefix {
+	case "f":
+		return value
+	case "k":
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(value), &fields); err != nil {
+			return "[" + value + "]"
+		}
+		parts := make([]string, 0, len(fields))
+		for k, v := range fields {
+			parts = append(parts, fmt.Sprintf("%s=%v", k, v))
+		}
+		sort.Strings(parts)
+		return "[" + strings.Join(parts, ",") + "]"
+	case "i":
+		return "[" + value + "]"
+	case "v":
+		return "[=" + value + "]"
+	}
+	return key
+}
+
+// collectFieldPaths walks a FieldsV1 tree, adding the owned paths cut at
+// managedFieldsDepth and returning the number of leaves
+func collectFieldPaths(tree map[string]interface{}, prefix []string, paths map[string]bool) int {
+	leaves := 0
+	for key, child := range tree {
+		if key == "." {
+			// Ownership of the node itself, recorded with its parent
+			continue
+		}
+		path := append(append([]string{}, prefix...), fieldSegment(key))
+		sub, _ := child.(map[string]interface{})
+		children := len(sub)
+		if _, ok := sub["."]; ok {
+			children--
+		}
+		switch {
+		case children == 0:
+			leaves++
+			if len(path) > managedFieldsDepth {
+				path = path[:managedFieldsDepth]
+			}
+			paths[joinFieldPath(path)] = true
+		case len(path) >= managedFieldsDepth:
+			leaves += collectFieldPaths(sub, path, map[string]bool{})
+			paths[joinFieldPath(path[:managedFieldsDepth])] = true
+		default:
+			leaves += collectFieldPaths(sub, path, paths)
+		}
+	}
+	return leaves
+}
+
+func joinFieldPath(segments []string) string {
+	var b strings.Builder
+	for i, s := range segments {
+		if i > 0 && !strings.HasPrefix(s, "[") {
+			b.WriteByte('.')
+		}
+		b.WriteString(s)
+	}
+	return b.String()
+}
+
+// summarizeManagedFields parses managedFields into per-manager summaries and
+// the fields more than one manager claims
+func summarizeManagedFields(entries []metav1.ManagedFieldsEntry) ([]FieldManagerSummary, []ContestedField) {
+	managers := make([]FieldManagerSummary, 0, len(entries))
+	owners := make(map[string][]string)
+	for _, e := range entries {
+		summary := FieldManagerSummary{
+			Manager:     e.Manager,
+			Operation:   string(e.Operation),
+			Subresource: e.Subresource,
+			APIVersion:  e.APIVersion,
+			Fields:      []string{},
+		}
+		if e.Time != nil {
+			summary.Time = formatTimestamp(*e.Time)
+		}
+		if e.FieldsV1 != nil {
+			var tree map[string]interface{}
+			if err := json.Unmarshal(e.FieldsV1.Raw, &tree); err == nil {
+				paths := make(map[string]bool)
+				summary.FieldCount = collectFieldPaths(tree, nil, paths)
+				for p := range paths {
+					summary.Fields = append(summary.Fields, p)
+				}
+				sort.Strings(summary.Fields)
+			}
+		}
+		name := e.Manager
+		if e.Subresource != "" {
+			name += " (" + e.Subresource + ")"
+		}
+		for _, p := range summary.Fields {
+			owners[p] = append(owners[p], name)
+		}
+		managers = append(managers, summary)
+	}
+	sort.SliceStable(managers, func(i, j int) bool { return managers[i].Time > managers[j].Time })
+
+	contested := []ContestedField{}
+	for field, names := range owners {
+		if len(names) > 1 {
+			sort.Strings(names)
+			contested = append(contested, ContestedField{Field: field, Managers: names})
+		}
+	}
+	sort.Slice(contested, func(i, j int) bool { return contested[i].Field < contested[j].Field })
+	return managers, contested
+}
+
+// HandleManagedFields serves /api/resources/managers?uid=[&apiVersion=]: the
+// managedFields of a resource summarized per field manager, with the fields
+// several managers fight over.
+func HandleManagedFields(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	uid := r.URL.Query().Get("uid")
+	if uid == "" {
+		i18n.Error(w, r, http.StatusBadRequest, "error.required", "uid")
+		return
+	}
+
+	graph, _, err := cachedGraph(config)
+	if graph == nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var target *LightResource
+	for i := range graph.Resources {
+		if graph.Resources[i].ID == uid {
+			target = &graph.Resources[i]
+			break
+		}
+	}
+	if target == nil {
+		i18n.Error(w, r, http.StatusNotFound, "error.resourceNotFound")
+		return
+	}
+	if !auth.RequireNamespace(w, r, target.Namespace) {
+		return
+	}
+
+	mapping, err := resolveResource(config, r.URL.Query().Get("apiVersion"), target.Kind)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		i18n.Error(w, r, http.StatusInternalServerError, "error.dynamicClientFailed")
+		return
+	}
+	var resource dynamic.ResourceInterface = dynamicClient.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		resource = dynamicClient.Resource(mapping.Resource).Namespace(target.Namespace)
+	}
+	obj, err := resource.Get(r.Context(), target.Name, metav1.GetOptions{})
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if string(obj.GetUID()) != uid {
+		i18n.Error(w, r, http.StatusNotFound, "error.resourceNotFound")
+		return
+	}
+
+	managers, contested := summarizeManagedFields(obj.GetManagedFields())
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ManagedFieldsResponse{
+		Resource:  impactResource(target),
+		Managers:  managers,
+		Contested: contested,
+	})
+}
@@ -0,0 +1,306 @@
+package k8s
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anakosmos/backend/src/i18n"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+)
+
+// APIResourceInfo describes a kind served by the cluster
+type APIResourceInfo struct {
+	Kind             string   `json:"kind"`
+	Group            string   `json:"group"`
+	Resource         string   `json:"resource"` // plural name
+	Versions         []string `json:"versions"`
+	PreferredVersion string   `json:"preferredVersion"`
+	APIVersion       string   `json:"apiVersion"` // group/preferredVersion, ready for YAML
+	Namespaced       bool     `json:"namespaced"`
+	ShortNames       []string `json:"shortNames,omitempty"`
+	Verbs            []string `json:"verbs"`
+}
+
+// discoveryTTL is how long the discovery document of a cluster is reused.
+// Watch connections drop it as soon as a CRD is added or removed; the TTL
+// bounds staleness otherwise (aggregated APIs, clusters nobody watches).
+const discoveryTTL = 5 * time.Minute
+
+var discoveryCache = struct {
+	sync.Mutex
+	clients map[string]*cachedDiscoveryClient
+}{clients: make(map[string]*cachedDiscoveryClient)}
+
+type cachedDiscoveryClient struct {
+	client    discovery.CachedDiscoveryInterface
+	createdAt time.Time
+}
+
+// cachedDiscovery returns a discovery client for the cluster and credential
+// behind config whose answers are cached for discoveryTTL
+func cachedDiscovery(config *rest.Config) (discovery.CachedDiscoveryInterface, error) {
+	key := credentialKey(config)
+
+	discoveryCache.Lock()
+	defer discoveryCache.Unlock()
+	if entry, ok := discoveryCache.clients[key]; ok {
+		if time.Since(entry.createdAt) < discoveryTTL {
+			return entry.client, nil
+		}
+		delete(discoveryCache.clients, key)
+	}
+	for k, entry := range discoveryCache.clients {
+		if time.Since(entry.createdAt) >= discoveryTTL {
+			delete(discoveryCache.clients, k)
+		}
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	client := memory.NewMemCacheClient(discoveryClient)
+	discoveryCache.clients[key] = &cachedDiscoveryClient{client: client, createdAt: time.Now()}
+	return client, nil
+}
+
+// invalidateDiscovery drops the cached discovery documents of the cluster at
+// host, for every credential, and the capabilities probed from them
+func invalidateDiscovery(host string) {
+	invalidateCapabilities(host)
+	discoveryCache.Lock()
+	defer discoveryCache.Unlock()
+	for k := range discoveryCache.clients {
+		if strings.HasPrefix(k, host+"|") {
+			delete(discoveryCache.clients, k)
+		}
+	}
+}
+
+// servedResources is the set of group/version/resource a cluster serves. A nil
+// set serves everything: when discovery fails listing is attempted anyway.
+type servedResources map[string]bool
+
+// Has reports whether the cluster serves resource in group/version. Versions
+// whose discovery failed (e.g. an unavailable aggregated API) count as served.
+func (s servedResources) Has(group, version, resource string) bool {
+	if s == nil {
+		return true
+	}
+	gv := schema.GroupVersion{Group: group, Version: version}.String()
+	return s[gv+"/"+resource] || s[gv+"/*"]
+}
+
+// versionedKind is an optional CRD kind shown in the graph. Versions are in
+// order of preference; the first one the cluster serves is listed and watched.
+type versionedKind struct {
+	kind     string // graph kind
+	group    string
+	resource string
+	versions []string
+}
+
+// servedVersion returns the first of versions the cluster serves for a
+// resource, "" when none is
+func (k versionedKind) servedVersion(served servedResources) string {
+	for _, v := range k.versions {
+		if served.Has(k.group, v, k.resource) {
+			return v
+		}
+	}
+	return ""
+}
+
+// clusterServes reads what the cluster behind config serves from the cached
+// discovery document, so optional APIs that aren't installed (autoscaling/v2
+// on old clusters, Ingress, CRDs) are skipped instead of failing every list
+func clusterServes(config *rest.Config) servedResources {
+	client, err := cachedDiscovery(config)
+	if err != nil {
+		return nil
+	}
+	_, lists, err := client.ServerGroupsAndResources()
+	if err != nil && len(lists) == 0 {
+		log.Printf("Discovery failed, listing every kind: %v", err)
+		return nil
+	}
+	served := make(servedResources)
+	for _, list := range lists {
+		for _, r := range list.APIResources {
+			served[list.GroupVersion+"/"+r.Name] = true
+		}
+	}
+	var failed *discovery.ErrGroupDiscoveryFailed
+	if errors.As(err, &failed) {
+		for gv := range failed.Groups {
+			served[gv.String()+"/*"] = true
+		}
+	}
+	return served
+}
+
+// apiResources flattens discovery into one entry per group and kind,
+// collecting the versions serving it. Subresources are skipped.
+func apiResources(client discovery.DiscoveryInterface) ([]APIResourceInfo, error) {
+	groups, err := client.ServerGroups()
+	if err != nil {
+		return nil, err
+	}
+	preferred := make(map[string]string, len(groups.Groups))
+	for _, g := range groups.Groups {
+		preferred[g.Name] = g.PreferredVersion.Version
+	}
+
+	// Partial failures (e.g. an unavailable aggregated API) still return the rest
+	lists, err := client.ServerPreferredResources()
+	if err != nil && len(lists) == 0 {
+		return nil, err
+	}
+	_, all, _ := client.ServerGroupsAndResources()
+
+	byKind := make(map[string]*APIResourceInfo)
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") {
+				continue
+			}
+			key := gv.Group + "/" + r.Kind
+			if _, ok := byKind[key]; ok {
+				continue
+			}
+			byKind[key] = &APIResourceInfo{
+				Kind:             r.Kind,
+				Group:            gv.Group,
+				Resource:         r.Name,
+				Versions:         []string{},
+				PreferredVersion: gv.Version,
+				APIVersion:       gv.String(),
+				Namespaced:       r.Namespaced,
+				ShortNames:       r.ShortNames,
+				Verbs:            r.Verbs,
+			}
+		}
+	}
+	for _, list := range all {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if info, ok := byKind[gv.Group+"/"+r.Kind]; ok && !strings.Contains(r.Name, "/") {
+				info.Versions = append(info.Versions, gv.Version)
+			}
+		}
+	}
+
+	result := make([]APIResourceInfo, 0, len(byKind))
+	for _, info := range byKind {
+		if len(info.Versions) == 0 {
+			info.Versions = []string{info.PreferredVersion}
+		}
+		// Keep the group's preferred version first
+		sort.SliceStable(info.Versions, func(i, j int) bool {
+			return info.Versions[i] == preferred[info.Group] && info.Versions[j] != preferred[info.Group]
+		})
+		result = append(result, *info)
+	}
+	return result, nil
+}
+
+// searchRank orders matches: exact kind, short name or plural first, then
+// prefixes, then substrings. Returns -1 when the resource doesn't match.
+func searchRank(info *APIResourceInfo, search string) int {
+	if search == "" {
+		return 0
+	}
+	kind, resource := strings.ToLower(info.Kind), info.Resource
+	switch {
+	case kind == search || resource == search:
+		return 0
+	case containsString(info.ShortNames, search):
+		return 1
+	case strings.HasPrefix(kind, search) || strings.HasPrefix(resource, search):
+		return 2
+	case strings.Contains(kind, search) || strings.Contains(resource, search) || strings.Contains(info.Group, search):
+		return 3
+	}
+	return -1
+}
+
+// HandleMetaResources serves /api/meta/resources?search=&limit= from the
+// cached discovery document: matching kinds with their versions, scope, short
+// names and verbs, best matches first.
+func HandleMetaResources(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	search := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("search")))
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "limit")
+			return
+		}
+		limit = n
+	}
+
+	client, err := cachedDiscovery(config)
+	if err != nil {
+		i18n.Error(w, r, http.StatusInternalServerError, "error.discoveryClientFailed")
+		return
+	}
+	resources, err := apiResources(client)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	type ranked struct {
+		info APIResourceInfo
+		rank int
+	}
+	var matches []ranked
+	for i := range resources {
+		if rank := searchRank(&resources[i], search); rank >= 0 {
+			matches = append(matches, ranked{resources[i], rank})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		// Core kinds before CRDs sharing a kind name
+		if (a.info.Group == "") != (b.info.Group == "") {
+			return a.info.Group == ""
+		}
+		if a.info.Kind != b.info.Kind {
+			return a.info.Kind < b.info.Kind
+		}
+		return a.info.Group < b.info.Group
+	})
+
+	result := make([]APIResourceInfo, 0, min(len(matches), limit))
+	for _, m := range matches {
+		if len(result) == limit {
+			break
+		}
+		result = append(result, m.info)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,160 @@
+package k8s
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anakosmos/backend/src/i18n"
+	"github.com/anakosmos/backend/src/maintenance"
+
+	"k8s.io/client-go/rest"
+)
+
+// healthValue maps the graph health model onto a gauge value so alert rules
+// can use simple thresholds (e.g. anakosmos_resource_health >= 2).
+func healthValue(health string) int {
+	switch health {
+	case "ok":
+		return 0
+	case "warning", "flapping":
+		return 1
+	case "error":
+		return 2
+	default:
+		return -1
+	}
+}
+
+// escapeLabel escapes a Prometheus label value
+func escapeLabel(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	return strings.ReplaceAll(v, `"`, `\"`)
+}
+
+// HealthMetricsHandler exposes the computed resource health of the local
+// cluster in the Prometheus text format, using the same health model as the
+// graph. /metrics is outside the authenticated API, so the per-resource
+// gauges, which name every resource, are only served to scrapers sending
+// token as a bearer token; without a token only per-namespace counts are.
+// The graph is read from the informer cache, and only built every ttl when
+// there is none (informer cache off, namespaced mode).
+func HealthMetricsHandler(config *rest.Config, ttl time.Duration, token string) http.HandlerFunc {
+	cache := newGraphCache(config, ttl)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if config == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		perResource := false
+		if token != "" {
+			bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				i18n.Error(w, r, http.StatusUnauthorized, "error.required", "bearer token")
+				return
+			}
+			perResource = true
+		}
+
+		graph, fetchedAt := precomputedGraph(r.Context(), config)
+		if graph == nil && !usesInformers(r.Context(), config) {
+			var err error
+			graph, fetchedAt, err = cache.Get()
+			if err != nil {
+				log.Printf("Health metrics refresh failed: %v", err)
+			}
+		}
+		if graph == nil {
+			// Informers still warming up
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.unavailable", "metrics")
+			return
+		}
+
+		resources := make([]LightResource, len(graph.Resources))
+		copy(resources, graph.Resources)
+		for i := range resources {
+			applyDrills(config.Host, &resources[i])
+		}
+		sort.Slice(resources, func(i, j int) bool {
+			if resources[i].Kind != resources[j].Kind {
+				return resources[i].Kind < resources[j].Kind
+			}
+			if resources[i].Namespace != resources[j].Namespace {
+				return resources[i].Namespace < resources[j].Namespace
+			}
+			return resources[i].Name < resources[j].Name
+		})
+
+		type countKey struct{ kind, namespace, health string }
+		counts := make(map[countKey]int)
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		out := bufio.NewWriter(w)
+		defer out.Flush()
+
+		if perResource {
+			fmt.Fprintln(out, "# HELP anakosmos_resource_health Computed health of a resource (0=ok, 1=warning or flapping, 2=error, -1=unknown).")
+			fmt.Fprintln(out, "# TYPE anakosmos_resource_health gauge")
+			for _, res := range resources {
+				fmt.Fprintf(out, "anakosmos_resource_health{kind=\"%s\",namespace=\"%s\",name=\"%s\"} %d\n",
+					escapeLabel(res.Kind), escapeLabel(res.Namespace), escapeLabel(res.Name), healthValue(res.Health))
+			}
+		}
+		for _, res := range resources {
+			counts[countKey{res.Kind, res.Namespace, res.Health}]++
+		}
+
+		keys := make([]countKey, 0, len(counts))
+		for k := range counts {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			a, b := keys[i], keys[j]
+			if a.kind != b.kind {
+				return a.kind < b.kind
+			}
+			if a.namespace != b.namespace {
+				return a.namespace < b.namespace
+			}
+			return a.health < b.health
+		})
+		fmt.Fprintln(out, "# HELP anakosmos_resources Number of resources by kind, namespace and health.")
+		fmt.Fprintln(out, "# TYPE anakosmos_resources gauge")
+		for _, k := range keys {
+			fmt.Fprintf(out, "anakosmos_resources{kind=\"%s\",namespace=\"%s\",health=\"%s\"} %d\n",
+				escapeLabel(k.kind), escapeLabel(k.namespace), escapeLabel(k.health), counts[k])
+		}
+
+		// Lets Alertmanager inhibit alerts on namespaces under maintenance
+		fmt.Fprintln(out, "# HELP anakosmos_maintenance_active Whether a namespace is covered by a maintenance window in progress.")
+		fmt.Fprintln(out, "# TYPE anakosmos_maintenance_active gauge")
+		var namespaces []string
+		seen := make(map[string]bool)
+		for _, res := range resources {
+			if res.Namespace != "" && !seen[res.Namespace] {
+				seen[res.Namespace] = true
+				namespaces = append(namespaces, res.Namespace)
+			}
+		}
+		sort.Strings(namespaces)
+		for _, ns := range namespaces {
+			active := 0
+			if maintenance.UnderMaintenance("local", ns) {
+				active = 1
+			}
+			fmt.Fprintf(out, "anakosmos_maintenance_active{namespace=\"%s\"} %d\n", escapeLabel(ns), active)
+		}
+
+		fmt.Fprintln(out, "# HELP anakosmos_health_snapshot_timestamp_seconds When the health snapshot was taken.")
+		fmt.Fprintln(out, "# TYPE anakosmos_health_snapshot_timestamp_seconds gauge")
+		fmt.Fprintf(out, "anakosmos_health_snapshot_timestamp_seconds %d\n", fetchedAt.Unix())
+	}
+}
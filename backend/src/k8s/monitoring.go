@@ -0,0 +1,101 @@
+package k8s
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const monitoringGroup = "monitoring.coreos.com"
+
+// monitoringKinds are the prometheus-operator kinds shown in the graph
+var monitoringKinds = []versionedKind{
+	{"ServiceMonitor", monitoringGroup, "servicemonitors", []string{"v1"}},
+	{"PodMonitor", monitoringGroup, "podmonitors", []string{"v1"}},
+	{"PrometheusRule", monitoringGroup, "prometheusrules", []string{"v1"}},
+}
+
+// MonitoringInfo summarizes a prometheus-operator ServiceMonitor, PodMonitor
+// or PrometheusRule. Monitors link to the Services or Pods they scrape.
+type MonitoringInfo struct {
+	// Selector picks the scraped Services or Pods, in label query syntax
+	Selector string `json:"selector,omitempty"`
+	// Namespaces are where a monitor looks for targets; AnyNamespace means
+	// every namespace, and neither its own namespace
+	Namespaces   []string `json:"namespaces,omitempty"`
+	AnyNamespace bool     `json:"anyNamespace,omitempty"`
+	// Endpoints are the scraped ports of a monitor
+	Endpoints []string `json:"endpoints,omitempty"`
+	// Groups and Rules count the alerting and recording rules of a
+	// PrometheusRule
+	Groups int `json:"groups,omitempty"`
+	Rules  int `json:"rules,omitempty"`
+}
+
+// applyMonitoringStatus fills a prometheus-operator object's targets or rules.
+// They report no status of their own.
+func applyMonitoringStatus(obj *unstructured.Unstructured, res *LightResource) {
+	info := &MonitoringInfo{}
+	res.Status, res.Health = "Active", "ok"
+	if res.Kind == "PrometheusRule" {
+		groups, _, _ := unstructured.NestedSlice(obj.Object, "spec", "groups")
+		info.Groups = len(groups)
+		for _, g := range groups {
+			m, _ := g.(map[string]interface{})
+			rules, _, _ := unstructured.NestedSlice(m, "rules")
+			info.Rules += len(rules)
+		}
+		res.Monitoring = info
+		return
+	}
+
+	info.Selector = monitorSelector(obj).String()
+	info.AnyNamespace, _, _ = unstructured.NestedBool(obj.Object, "spec", "namespaceSelector", "any")
+	info.Namespaces, _, _ = unstructured.NestedStringSlice(obj.Object, "spec", "namespaceSelector", "matchNames")
+	field := "endpoints"
+	if res.Kind == "PodMonitor" {
+		field = "podMetricsEndpoints"
+	}
+	endpoints, _, _ := unstructured.NestedSlice(obj.Object, "spec", field)
+	for _, e := range endpoints {
+		m, _ := e.(map[string]interface{})
+		port, _, _ := unstructured.NestedString(m, "port")
+		if port == "" {
+			port, _, _ = unstructured.NestedString(m, "targetPort")
+		}
+		if port != "" {
+			info.Endpoints = append(info.Endpoints, port)
+		}
+	}
+	res.Monitoring = info
+}
+
+// monitorSelector returns the target selector of a ServiceMonitor or
+// PodMonitor. As for prometheus-operator an empty selector matches every
+// target and an invalid one none.
+func monitorSelector(obj *unstructured.Unstructured) labels.Selector {
+	raw, _, _ := unstructured.NestedMap(obj.Object, "spec", "selector")
+	var selector metav1.LabelSelector
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &selector); err != nil {
+		return labels.Nothing()
+	}
+	s, err := metav1.LabelSelectorAsSelector(&selector)
+	if err != nil {
+		return labels.Nothing()
+	}
+	return s
+}
+
+// monitorNamespaces returns the namespaces a monitor looks for targets in,
+// nil for every namespace
+func monitorNamespaces(res *LightResource) []string {
+	switch {
+	case res.Monitoring.AnyNamespace:
+		return nil
+	case len(res.Monitoring.Namespaces) > 0:
+		return res.Monitoring.Namespaces
+	default:
+		return []string{res.Namespace}
+	}
+}
@@ -0,0 +1,105 @@
+package k8s
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/anakosmos/backend/src/auth"
+	"k8s.io/client-go/rest"
+)
+
+// NamespaceQuota is the usage of one ResourceQuota of a namespace
+type NamespaceQuota struct {
+	Name     string            `json:"name"`
+	Hard     map[string]string `json:"hard,omitempty"`
+	Used     map[string]string `json:"used,omitempty"`
+	Pressure int               `json:"pressure"` // highest used/hard, in percent
+}
+
+// NamespaceSummary rolls up the resources of a namespace: counts per kind and
+// health, the worst health among them, and quota usage
+type NamespaceSummary struct {
+	Name string `json:"name"`
+	// Status is the Namespace's own, e.g. Active or Terminating
+	Status string           `json:"status,omitempty"`
+	Health string           `json:"health"`
+	Counts HealthCounts     `json:"counts"`
+	Kinds  map[string]int   `json:"kinds"`
+	Quotas []NamespaceQuota `json:"quotas"`
+	// QuotaPressure is the highest pressure of its ResourceQuotas, in percent
+	QuotaPressure int `json:"quotaPressure"`
+}
+
+// NamespacesResponse is served by /api/cluster/namespaces
+type NamespacesResponse struct {
+	GeneratedAt string             `json:"generatedAt"`
+	Namespaces  []NamespaceSummary `json:"namespaces"`
+}
+
+// namespaceSummaries rolls resources up per namespace. ApplicationGroups are
+// left out: they stand for resources already counted.
+func namespaceSummaries(resources []LightResource, scope auth.Scope) []NamespaceSummary {
+	summaries := make(map[string]*NamespaceSummary)
+	summary := func(name string) *NamespaceSummary {
+		s, ok := summaries[name]
+		if !ok {
+			s = &NamespaceSummary{Name: name, Health: "ok", Kinds: make(map[string]int), Quotas: []NamespaceQuota{}}
+			s.Counts.Name = name
+			summaries[name] = s
+		}
+		return s
+	}
+	for i := range resources {
+		res := &resources[i]
+		switch {
+		case res.Kind == "Namespace":
+			if scope.Allows(res.Name) {
+				s := summary(res.Name)
+				s.Status = res.Status
+				s.Health = worseHealth(s.Health, res.Health)
+			}
+			continue
+		case res.Namespace == "" || res.Kind == "ApplicationGroup" || !scope.Allows(res.Namespace):
+			continue
+		}
+		s := summary(res.Namespace)
+		s.Kinds[res.Kind]++
+		s.Counts.add(res.Health)
+		s.Health = worseHealth(s.Health, res.Health)
+		if res.Kind == "ResourceQuota" && res.Quota != nil {
+			s.Quotas = append(s.Quotas, NamespaceQuota{Name: res.Name, Hard: res.Quota.Hard, Used: res.Quota.Used, Pressure: res.Quota.Pressure})
+			s.QuotaPressure = max(s.QuotaPressure, res.Quota.Pressure)
+		}
+	}
+
+	result := make([]NamespaceSummary, 0, len(summaries))
+	for _, s := range summaries {
+		if s.Health == "" {
+			s.Health = "unknown"
+		}
+		sort.Slice(s.Quotas, func(i, j int) bool { return s.Quotas[i].Name < s.Quotas[j].Name })
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// HandleNamespaces serves /api/cluster/namespaces: per namespace resource
+// counts by kind and health, the worst health and ResourceQuota usage, from
+// the caller's view of the cached graph, without the graph itself.
+func HandleNamespaces(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	graph, fetchedAt, err := cachedGraph(config)
+	if graph == nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := NamespacesResponse{
+		GeneratedAt: fetchedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		Namespaces:  namespaceSummaries(graph.Resources, auth.ScopeFromContext(r.Context())),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,222 @@
+package k8s
+
+import (
+	"context"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// namespacedUnavailable are the kinds a namespace-scoped service account
+// can't list
+var namespacedUnavailable = []string{"Namespace", "Node", "PersistentVolume", "StorageClass", "GatewayClass", "ClusterIssuer"}
+
+// permittedTTL is how long the namespaces found by SelfSubjectRulesReview are
+// reused before RBAC is checked again
+const permittedTTL = 5 * time.Minute
+
+// ClusterAccess tells the UI that the graph only covers some namespaces and
+// which layers are missing from it
+type ClusterAccess struct {
+	Mode        string   `json:"mode"` // namespaced
+	Namespaces  []string `json:"namespaces"`
+	Unavailable []string `json:"unavailable"` // kinds that can't be listed
+}
+
+var namespacedMode = struct {
+	sync.Mutex
+	credential string   // credentialKey of the config running namespace-scoped
+	candidates []string // namespaces checked when Namespaces can't be listed
+	permitted  []string
+	checkedAt  time.Time
+	// reviewed is closed when the review in flight, if any, completes
+	reviewed chan struct{}
+}{}
+
+// ConfigureNamespacedMode restricts the graph and watches of config to the
+// namespaces its credential may read, for installs with only namespace-scoped
+// RBAC. The namespaces are discovered with SelfSubjectRulesReview among
+// candidates, or among all namespaces when those can be listed; the pod's own
+// namespace is always a candidate.
+func ConfigureNamespacedMode(config *rest.Config, candidates []string) {
+	if own := inClusterNamespace(); own != "" && !containsString(candidates, own) {
+		candidates = append(candidates, own)
+	}
+	namespacedMode.Lock()
+	defer namespacedMode.Unlock()
+	namespacedMode.credential = credentialKey(config)
+	namespacedMode.candidates = candidates
+	namespacedMode.permitted = nil
+	namespacedMode.checkedAt = time.Time{}
+	namespacedMode.reviewed = nil
+}
+
+// inClusterNamespace returns the namespace anakosmos runs in, if known
+func inClusterNamespace() string {
+	if ns := os.Getenv("POD_NAMESPACE"); ns != "" {
+		return ns
+	}
+	data, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// permittedNamespaces returns the namespaces config may read in namespaced
+// mode, or nil when its credential isn't restricted. The reviews run without
+// holding the lock: once namespaces are known, expired ones keep being
+// returned while a background review refreshes them; only the first callers
+// wait for a review.
+func permittedNamespaces(ctx context.Context, config *rest.Config) []string {
+	credential := credentialKey(config)
+	for {
+		namespacedMode.Lock()
+		if namespacedMode.credential == "" || namespacedMode.credential != credential {
+			namespacedMode.Unlock()
+			return nil
+		}
+		if namespacedMode.reviewed == nil && time.Since(namespacedMode.checkedAt) >= permittedTTL {
+			namespacedMode.reviewed = make(chan struct{})
+			go reviewPermitted(config, credential, namespacedMode.candidates, namespacedMode.reviewed)
+		}
+		permitted, reviewed := namespacedMode.permitted, namespacedMode.reviewed
+		namespacedMode.Unlock()
+		if permitted != nil {
+			return permitted
+		}
+
+		select {
+		case <-reviewed:
+		case <-ctx.Done():
+			return []string{}
+		}
+	}
+}
+
+// reviewPermitted reviews the namespaces of credential and stores them unless
+// namespaced mode was reconfigured meanwhile. On failure namespaces already
+// known are kept; with none known yet, none are permitted until the next try.
+func reviewPermitted(config *rest.Config, credential string, candidates []string, reviewed chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	permitted, err := reviewNamespaces(ctx, config, candidates)
+
+	namespacedMode.Lock()
+	defer namespacedMode.Unlock()
+	defer close(reviewed)
+	if namespacedMode.reviewed == reviewed {
+		namespacedMode.reviewed = nil
+	}
+	if namespacedMode.credential != credential {
+		return
+	}
+	namespacedMode.checkedAt = time.Now()
+	if err != nil {
+		log.Printf("Failed to review namespace permissions: %v", err)
+		if namespacedMode.permitted != nil {
+			return
+		}
+	}
+	if permitted == nil {
+		permitted = []string{}
+	}
+	namespacedMode.permitted = permitted
+}
+
+// reviewNamespaces keeps the candidates (or every namespace, when they can
+// be listed) in which the credential may list pods
+func reviewNamespaces(ctx context.Context, config *rest.Config, candidates []string) ([]string, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return []string{}, err
+	}
+	if list, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{}); err == nil {
+		candidates = nil
+		for _, ns := range list.Items {
+			candidates = append(candidates, ns.Name)
+		}
+	}
+
+	permitted := []string{}
+	var lastErr error
+	for _, ns := range candidates {
+		review, err := clientset.AuthorizationV1().SelfSubjectRulesReviews().Create(ctx, &authorizationv1.SelfSubjectRulesReview{
+			Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: ns},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if rulesAllow(review.Status.ResourceRules, "", "pods", "list") {
+			permitted = append(permitted, ns)
+		}
+	}
+	sort.Strings(permitted)
+	return permitted, lastErr
+}
+
+// rulesAllow reports whether resource rules grant verb on group/resource
+func rulesAllow(rules []authorizationv1.ResourceRule, group, resource, verb string) bool {
+	matches := func(values []string, want string) bool {
+		return containsString(values, want) || containsString(values, "*")
+	}
+	for _, rule := range rules {
+		if matches(rule.APIGroups, group) && matches(rule.Resources, resource) && matches(rule.Verbs, verb) {
+			return true
+		}
+	}
+	return false
+}
+
+// namespacedAccess describes a graph limited to namespaces by RBAC
+func namespacedAccess(namespaces []string) *ClusterAccess {
+	return &ClusterAccess{Mode: "namespaced", Namespaces: namespaces, Unavailable: namespacedUnavailable}
+}
+
+// buildNamespacesGraph builds the graph of each namespace concurrently and
+// joins them. Cluster-scoped kinds are left out.
+func buildNamespacesGraph(ctx context.Context, config *rest.Config, namespaces []string, opts graphOptions) (*InitResponse, error) {
+	graphs := make([]*InitResponse, len(namespaces))
+	errs := make([]error, len(namespaces))
+	var wg sync.WaitGroup
+	var progressMu sync.Mutex
+	// Each namespace already lists its kinds in parallel
+	sem := make(chan struct{}, 4)
+	for i, ns := range namespaces {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			nsOpts := opts
+			nsOpts.namespace = ns
+			nsOpts.clusterScoped = false
+			graphs[i], errs[i] = buildGraph(ctx, config, nsOpts)
+			if errs[i] == nil && opts.progress != nil {
+				progressMu.Lock()
+				opts.progress(graphs[i])
+				progressMu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	merged := &InitResponse{Resources: []LightResource{}, Links: []ClusterLink{}}
+	for i, graph := range graphs {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		merged.Resources = append(merged.Resources, graph.Resources...)
+		merged.Links = append(merged.Links, graph.Links...)
+	}
+	return merged, nil
+}
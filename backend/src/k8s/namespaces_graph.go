@@ -0,0 +1,76 @@
+package k8s
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// NamespaceTermination is set on Namespaces being deleted, with what holds
+// the deletion up
+type NamespaceTermination struct {
+	Since string `json:"since,omitempty"`
+	// Blockers are the messages of the deletion conditions that are true,
+	// e.g. content or finalizers remaining
+	Blockers []string `json:"blockers,omitempty"`
+}
+
+// namespaceDeletionConditions report why a terminating Namespace is not gone
+var namespaceDeletionConditions = []corev1.NamespaceConditionType{
+	corev1.NamespaceDeletionDiscoveryFailure,
+	corev1.NamespaceDeletionContentFailure,
+	corev1.NamespaceDeletionGVParsingFailure,
+	corev1.NamespaceContentRemaining,
+	corev1.NamespaceFinalizersRemaining,
+}
+
+// namespaceTermination returns the termination of a Namespace, or nil when it
+// is not being deleted. The phase lags the deletion timestamp, so both count.
+func namespaceTermination(ns *corev1.Namespace) *NamespaceTermination {
+	if ns.DeletionTimestamp == nil && ns.Status.Phase != corev1.NamespaceTerminating {
+		return nil
+	}
+	t := &NamespaceTermination{}
+	if ns.DeletionTimestamp != nil {
+		t.Since = formatTimestamp(*ns.DeletionTimestamp)
+	}
+	for _, c := range ns.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		for _, blocking := range namespaceDeletionConditions {
+			if c.Type == blocking {
+				msg := c.Message
+				if msg == "" {
+					msg = string(c.Type)
+				}
+				t.Blockers = append(t.Blockers, msg)
+			}
+		}
+	}
+	return t
+}
+
+// containmentLinks links every namespaced resource to its Namespace node so
+// the graph can be grouped and collapsed by namespace. ResourceQuotas and
+// LimitRanges already have a policy link to it.
+func containmentLinks(resources []LightResource) []ClusterLink {
+	namespaceIDs := make(map[string]string) // name -> uid
+	for i := range resources {
+		if resources[i].Kind == "Namespace" {
+			namespaceIDs[resources[i].Name] = resources[i].ID
+		}
+	}
+	if len(namespaceIDs) == 0 {
+		return nil
+	}
+	var links []ClusterLink
+	for i := range resources {
+		res := &resources[i]
+		if res.Kind == "Namespace" || res.Kind == "ResourceQuota" || res.Kind == "LimitRange" || res.Namespace == "" {
+			continue
+		}
+		if nsID, ok := namespaceIDs[res.Namespace]; ok {
+			links = append(links, ClusterLink{Source: res.ID, Target: nsID, Type: "containment"})
+		}
+	}
+	return links
+}
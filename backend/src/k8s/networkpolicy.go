@@ -0,0 +1,104 @@
+package k8s
+
+import (
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// NetworkPolicyInfo summarizes what a NetworkPolicy restricts. The pods it
+// applies to are linked with "policy" links.
+type NetworkPolicyInfo struct {
+	PolicyTypes  []string `json:"policyTypes"` // Ingress, Egress
+	IngressRules int      `json:"ingressRules"`
+	EgressRules  int      `json:"egressRules"`
+	// DenyAll* is set when the policy isolates a direction without allowing
+	// anything in it
+	DenyAllIngress bool `json:"denyAllIngress,omitempty"`
+	DenyAllEgress  bool `json:"denyAllEgress,omitempty"`
+	// Peers are the namespace and pod selectors of the allowed sources and
+	// destinations, e.g. "namespace team=a, pod app=web" or "10.0.0.0/8"
+	Peers []string `json:"peers,omitempty"`
+}
+
+func lightNetworkPolicy(np *networkingv1.NetworkPolicy) LightResource {
+	res := baseLightResource(np, "NetworkPolicy")
+	res.Status = "Active"
+	res.Health = "ok"
+
+	// Without policyTypes, Ingress always applies and Egress only with rules
+	types := np.Spec.PolicyTypes
+	if len(types) == 0 {
+		types = []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}
+		if len(np.Spec.Egress) > 0 {
+			types = append(types, networkingv1.PolicyTypeEgress)
+		}
+	}
+	info := &NetworkPolicyInfo{
+		PolicyTypes:  []string{},
+		IngressRules: len(np.Spec.Ingress),
+		EgressRules:  len(np.Spec.Egress),
+	}
+	for _, t := range types {
+		info.PolicyTypes = append(info.PolicyTypes, string(t))
+		switch t {
+		case networkingv1.PolicyTypeIngress:
+			info.DenyAllIngress = len(np.Spec.Ingress) == 0
+		case networkingv1.PolicyTypeEgress:
+			info.DenyAllEgress = len(np.Spec.Egress) == 0
+		}
+	}
+	addPeer := func(peer networkingv1.NetworkPolicyPeer) {
+		if desc := describePolicyPeer(peer); desc != "" && !containsString(info.Peers, desc) {
+			info.Peers = append(info.Peers, desc)
+		}
+	}
+	for _, rule := range np.Spec.Ingress {
+		for _, peer := range rule.From {
+			addPeer(peer)
+		}
+	}
+	for _, rule := range np.Spec.Egress {
+		for _, peer := range rule.To {
+			addPeer(peer)
+		}
+	}
+	res.NetworkPolicy = info
+	res.HelmRelease = extractHelmInfo(np.Labels, np.Annotations, np.Namespace)
+	return res
+}
+
+func describePolicyPeer(peer networkingv1.NetworkPolicyPeer) string {
+	if peer.IPBlock != nil {
+		return peer.IPBlock.CIDR
+	}
+	desc := ""
+	if peer.NamespaceSelector != nil {
+		desc = "namespace " + describeSelector(peer.NamespaceSelector)
+	}
+	if peer.PodSelector != nil {
+		if desc != "" {
+			desc += ", "
+		}
+		desc += "pod " + describeSelector(peer.PodSelector)
+	}
+	return desc
+}
+
+func describeSelector(selector *metav1.LabelSelector) string {
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil || s.Empty() {
+		return "*"
+	}
+	return s.String()
+}
+
+// policySelector returns the selector of the pods a NetworkPolicy applies
+// to, which are always in its own namespace
+func policySelector(np *networkingv1.NetworkPolicy) labels.Selector {
+	selector, err := metav1.LabelSelectorAsSelector(&np.Spec.PodSelector)
+	if err != nil {
+		return labels.Nothing()
+	}
+	return selector
+}
@@ -0,0 +1,54 @@
+package k8s
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// orphanOwnerVersions are the API versions of the owners orphan detection
+// trusts: their kinds are named alike in the graph, so a missing UID of a
+// listed kind means the owner is gone rather than not shown
+var orphanOwnerVersions = map[string]bool{"v1": true, "apps/v1": true, "batch/v1": true}
+
+// ownerKinds returns the kinds of an object's owners in the order of
+// extractOwnerRefs, "" for those orphan detection doesn't judge
+func ownerKinds(refs []metav1.OwnerReference) []string {
+	kinds := make([]string, len(refs))
+	for i, ref := range refs {
+		if orphanOwnerVersions[ref.APIVersion] {
+			kinds[i] = ref.Kind
+		}
+	}
+	return kinds
+}
+
+// markOrphans flags resources whose built-in owners no longer exist, and
+// ReplicaSets scaled to zero that no Pod belongs to anymore. Owners are only
+// judged when resources hold every object of their kind: the caller skips
+// label- and kind-filtered builds, and kinds the graph has none of are left
+// alone.
+func markOrphans(resources []LightResource) {
+	ids := make(map[string]bool, len(resources))
+	kinds := make(map[string]bool)
+	owned := make(map[string]bool) // UIDs some Pod belongs to
+	for i := range resources {
+		ids[resources[i].ID] = true
+		kinds[resources[i].Kind] = true
+		if resources[i].Kind == "Pod" {
+			for _, uid := range resources[i].OwnerRefs {
+				owned[uid] = true
+			}
+		}
+	}
+
+	for i := range resources {
+		res := &resources[i]
+		for j, uid := range res.OwnerRefs {
+			if j < len(res.ownerKinds) && kinds[res.ownerKinds[j]] && !ids[uid] {
+				res.Orphaned = true
+			}
+		}
+		if res.Kind == "ReplicaSet" && res.scaledDown && !owned[res.ID] {
+			res.Orphaned = true
+		}
+	}
+}
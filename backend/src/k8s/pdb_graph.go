@@ -0,0 +1,63 @@
+package k8s
+
+import (
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// DisruptionInfo summarizes a PodDisruptionBudget. PDBs link to the pods and
+// the Deployments and StatefulSets their selector matches.
+type DisruptionInfo struct {
+	// MinAvailable and MaxUnavailable are the budget, a count or a percentage
+	MinAvailable   string `json:"minAvailable,omitempty"`
+	MaxUnavailable string `json:"maxUnavailable,omitempty"`
+	CurrentHealthy int32  `json:"currentHealthy"`
+	DesiredHealthy int32  `json:"desiredHealthy"`
+	ExpectedPods   int32  `json:"expectedPods"`
+	// DisruptionsAllowed is how many pods may be evicted right now
+	DisruptionsAllowed int32 `json:"disruptionsAllowed"`
+}
+
+// lightPDB derives a PodDisruptionBudget's health from its status: fewer
+// healthy pods than desired is an error, as the budget is already spent and
+// the workload is one failure closer to an outage; a budget allowing no
+// evictions (which blocks node drains) or matching no pods is a warning
+func lightPDB(pdb *policyv1.PodDisruptionBudget) LightResource {
+	res := baseLightResource(pdb, "PodDisruptionBudget")
+	info := &DisruptionInfo{
+		CurrentHealthy:     pdb.Status.CurrentHealthy,
+		DesiredHealthy:     pdb.Status.DesiredHealthy,
+		ExpectedPods:       pdb.Status.ExpectedPods,
+		DisruptionsAllowed: pdb.Status.DisruptionsAllowed,
+	}
+	if pdb.Spec.MinAvailable != nil {
+		info.MinAvailable = pdb.Spec.MinAvailable.String()
+	}
+	if pdb.Spec.MaxUnavailable != nil {
+		info.MaxUnavailable = pdb.Spec.MaxUnavailable.String()
+	}
+	res.Disruption = info
+
+	switch {
+	case info.CurrentHealthy < info.DesiredHealthy:
+		res.Status, res.Health = "Degraded", "error"
+	case info.ExpectedPods == 0:
+		res.Status, res.Health = "NoPods", "warning"
+	case info.DisruptionsAllowed == 0:
+		res.Status, res.Health = "Blocking", "warning"
+	default:
+		res.Status, res.Health = "Healthy", "ok"
+	}
+	return res
+}
+
+// pdbSelector returns the pod selector of a PDB. In policy/v1 a missing
+// selector matches no pods and an empty one every pod of the namespace.
+func pdbSelector(pdb *policyv1.PodDisruptionBudget) labels.Selector {
+	selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+	if err != nil {
+		return labels.Nothing()
+	}
+	return selector
+}
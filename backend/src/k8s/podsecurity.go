@@ -0,0 +1,364 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/anakosmos/backend/src/auth"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+// Pod Security Standards levels, from least to most strict
+const (
+	podSecurityPrivileged = "privileged"
+	podSecurityBaseline   = "baseline"
+	podSecurityRestricted = "restricted"
+)
+
+var podSecurityRank = map[string]int{
+	podSecurityPrivileged: 0,
+	podSecurityBaseline:   1,
+	podSecurityRestricted: 2,
+}
+
+// PodSecurityInfo carries PodSecurity admission data. Namespaces fill the
+// declared Enforce/Audit/Warn levels; workloads fill the strictest level their
+// pod template satisfies plus the checks failing at stricter levels, so the
+// frontend can compare them with the levels of the workload's namespace.
+type PodSecurityInfo struct {
+	Enforce    string   `json:"enforce,omitempty"`
+	Audit      string   `json:"audit,omitempty"`
+	Warn       string   `json:"warn,omitempty"`
+	Level      string   `json:"level,omitempty"`
+	Baseline   []string `json:"baseline,omitempty"`   // checks failing the baseline level
+	Restricted []string `json:"restricted,omitempty"` // checks failing the restricted level
+}
+
+// namespacePodSecurity reads the pod-security.kubernetes.io labels. Missing
+// levels default to privileged, like the admission plugin.
+func namespacePodSecurity(labels map[string]string) *PodSecurityInfo {
+	level := func(mode string) string {
+		v := labels["pod-security.kubernetes.io/"+mode]
+		if _, ok := podSecurityRank[v]; ok {
+			return v
+		}
+		return podSecurityPrivileged
+	}
+	return &PodSecurityInfo{Enforce: level("enforce"), Audit: level("audit"), Warn: level("warn")}
+}
+
+func lightNamespace(ns *corev1.Namespace) LightResource {
+	res := baseLightResource(ns, "Namespace")
+	res.Status = string(ns.Status.Phase)
+	res.Health = "ok"
+	if t := namespaceTermination(ns); t != nil {
+		res.Status = string(corev1.NamespaceTerminating)
+		res.Health = "warning"
+		res.Termination = t
+	}
+	res.PodSecurity = namespacePodSecurity(ns.Labels)
+	return res
+}
+
+var baselineCapabilities = map[corev1.Capability]bool{
+	"AUDIT_WRITE": true, "CHOWN": true, "DAC_OVERRIDE": true, "FOWNER": true, "FSETID": true, "KILL": true,
+	"MKNOD": true, "NET_BIND_SERVICE": true, "SETFCAP": true, "SETGID": true, "SETPCAP": true, "SETUID": true, "SYS_CHROOT": true,
+}
+
+var safeSysctls = map[string]bool{
+	"kernel.shm_rmid_forced": true, "net.ipv4.ip_local_port_range": true, "net.ipv4.ip_unprivileged_port_start": true,
+	"net.ipv4.tcp_syncookies": true, "net.ipv4.ping_group_range": true, "net.ipv4.ip_local_reserved_ports": true,
+	"net.ipv4.tcp_keepalive_time": true, "net.ipv4.tcp_fin_timeout": true, "net.ipv4.tcp_keepalive_intvl": true,
+	"net.ipv4.tcp_keepalive_probes": true,
+}
+
+var seLinuxTypes = map[string]bool{"": true, "container_t": true, "container_init_t": true, "container_kvm_t": true, "container_engine_t": true}
+
+var restrictedVolumes = []string{"configMap", "csi", "downwardAPI", "emptyDir", "ephemeral", "persistentVolumeClaim", "projected", "secret"}
+
+// workloadPodSecurity evaluates a pod spec against the baseline and restricted
+// Pod Security Standards. It covers the checks that can be decided from the
+// spec alone, which are all of them for the current policy versions.
+func workloadPodSecurity(spec *corev1.PodSpec) *PodSecurityInfo {
+	info := &PodSecurityInfo{}
+	baseline := func(format string, args ...interface{}) {
+		info.Baseline = append(info.Baseline, fmt.Sprintf(format, args...))
+	}
+	restricted := func(format string, args ...interface{}) {
+		info.Restricted = append(info.Restricted, fmt.Sprintf(format, args...))
+	}
+
+	if spec.HostNetwork || spec.HostPID || spec.HostIPC {
+		baseline("host namespaces (hostNetwork/hostPID/hostIPC)")
+	}
+	podSC := spec.SecurityContext
+	if podSC == nil {
+		podSC = &corev1.PodSecurityContext{}
+	}
+	if podSC.SELinuxOptions != nil && !seLinuxTypes[podSC.SELinuxOptions.Type] {
+		baseline("pod seLinuxOptions.type %q", podSC.SELinuxOptions.Type)
+	}
+	if podSC.SeccompProfile != nil && podSC.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+		baseline("pod seccompProfile Unconfined")
+	}
+	for _, s := range podSC.Sysctls {
+		if !safeSysctls[s.Name] {
+			baseline("unsafe sysctl %s", s.Name)
+		}
+	}
+	if podSC.WindowsOptions != nil && podSC.WindowsOptions.HostProcess != nil && *podSC.WindowsOptions.HostProcess {
+		baseline("windows hostProcess")
+	}
+	if podSC.RunAsUser != nil && *podSC.RunAsUser == 0 {
+		restricted("pod runAsUser=0")
+	}
+
+	for _, vol := range spec.Volumes {
+		if vol.HostPath != nil {
+			baseline("hostPath volume %s", vol.Name)
+			continue
+		}
+		if t := volumeType(vol); t != "" && !containsString(restrictedVolumes, t) {
+			restricted("volume %s of type %s", vol.Name, t)
+		}
+	}
+
+	containers := make([]corev1.Container, 0, len(spec.InitContainers)+len(spec.Containers)+len(spec.EphemeralContainers))
+	containers = append(containers, spec.InitContainers...)
+	containers = append(containers, spec.Containers...)
+	for _, ec := range spec.EphemeralContainers {
+		containers = append(containers, corev1.Container(ec.EphemeralContainerCommon))
+	}
+
+	for _, c := range containers {
+		sc := c.SecurityContext
+		if sc == nil {
+			sc = &corev1.SecurityContext{}
+		}
+		for _, p := range c.Ports {
+			if p.HostPort != 0 {
+				baseline("container %s hostPort %d", c.Name, p.HostPort)
+			}
+		}
+		if sc.Privileged != nil && *sc.Privileged {
+			baseline("container %s privileged", c.Name)
+		}
+		if sc.ProcMount != nil && *sc.ProcMount != corev1.DefaultProcMount {
+			baseline("container %s procMount %s", c.Name, *sc.ProcMount)
+		}
+		if sc.SELinuxOptions != nil && !seLinuxTypes[sc.SELinuxOptions.Type] {
+			baseline("container %s seLinuxOptions.type %q", c.Name, sc.SELinuxOptions.Type)
+		}
+		if sc.SeccompProfile != nil && sc.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+			baseline("container %s seccompProfile Unconfined", c.Name)
+		}
+		if sc.AppArmorProfile != nil && sc.AppArmorProfile.Type == corev1.AppArmorProfileTypeUnconfined {
+			baseline("container %s appArmorProfile Unconfined", c.Name)
+		}
+		if sc.WindowsOptions != nil && sc.WindowsOptions.HostProcess != nil && *sc.WindowsOptions.HostProcess {
+			baseline("container %s windows hostProcess", c.Name)
+		}
+
+		var add, drop []corev1.Capability
+		if sc.Capabilities != nil {
+			add, drop = sc.Capabilities.Add, sc.Capabilities.Drop
+		}
+		for _, capability := range add {
+			if !baselineCapabilities[capability] {
+				baseline("container %s adds capability %s", c.Name, capability)
+			} else if capability != "NET_BIND_SERVICE" {
+				restricted("container %s adds capability %s", c.Name, capability)
+			}
+		}
+		dropsAll := false
+		for _, capability := range drop {
+			if capability == "ALL" {
+				dropsAll = true
+			}
+		}
+		if !dropsAll {
+			restricted("container %s does not drop ALL capabilities", c.Name)
+		}
+
+		if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+			restricted("container %s allowPrivilegeEscalation not false", c.Name)
+		}
+		nonRoot := podSC.RunAsNonRoot
+		if sc.RunAsNonRoot != nil {
+			nonRoot = sc.RunAsNonRoot
+		}
+		if nonRoot == nil || !*nonRoot {
+			restricted("container %s runAsNonRoot not true", c.Name)
+		}
+		if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			restricted("container %s runAsUser=0", c.Name)
+		}
+		seccomp := podSC.SeccompProfile
+		if sc.SeccompProfile != nil {
+			seccomp = sc.SeccompProfile
+		}
+		if seccomp == nil || (seccomp.Type != corev1.SeccompProfileTypeRuntimeDefault && seccomp.Type != corev1.SeccompProfileTypeLocalhost) {
+			restricted("container %s seccompProfile not RuntimeDefault or Localhost", c.Name)
+		}
+	}
+
+	switch {
+	case len(info.Baseline) > 0:
+		info.Level = podSecurityPrivileged
+	case len(info.Restricted) > 0:
+		info.Level = podSecurityBaseline
+	default:
+		info.Level = podSecurityRestricted
+	}
+	return info
+}
+
+// volumeType returns the volume source field name as used by the Pod
+// Security Standards volume type check
+func volumeType(vol corev1.Volume) string {
+	data, err := json.Marshal(vol.VolumeSource)
+	if err != nil {
+		return ""
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return ""
+	}
+	for name := range fields {
+		return name
+	}
+	return ""
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// PodSecurityWorkload is a workload entry in the PodSecurity report
+type PodSecurityWorkload struct {
+	ID          string   `json:"id"`
+	Kind        string   `json:"kind"`
+	Name        string   `json:"name"`
+	Level       string   `json:"level"`
+	Violations  []string `json:"violations,omitempty"` // checks failing the next stricter level
+	Rejected    bool     `json:"rejected"`             // rejected by the current enforce level
+	AuditOrWarn bool     `json:"auditOrWarn"`          // reported by the audit or warn level
+}
+
+// PodSecurityNamespace is a namespace entry in the PodSecurity report
+type PodSecurityNamespace struct {
+	Namespace string `json:"namespace"`
+	Enforce   string `json:"enforce"`
+	Audit     string `json:"audit"`
+	Warn      string `json:"warn"`
+	// StricterLevel is the next level above enforce, and BlockingStricter the
+	// workloads that would be rejected if enforce were raised to it
+	StricterLevel    string                `json:"stricterLevel,omitempty"`
+	BlockingStricter int                   `json:"blockingStricter"`
+	Workloads        []PodSecurityWorkload `json:"workloads"`
+}
+
+// HandlePodSecurityReport serves /api/security/podsecurity: per namespace, the
+// declared PodSecurity levels and how each workload fares against them and
+// against the next stricter level.
+func HandlePodSecurityReport(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	graph, err := BuildGraph(r.Context(), config)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	scope := auth.ScopeFromContext(r.Context())
+
+	report := make(map[string]*PodSecurityNamespace)
+	entry := func(ns string, info *PodSecurityInfo) *PodSecurityNamespace {
+		if e, ok := report[ns]; ok {
+			return e
+		}
+		e := &PodSecurityNamespace{Namespace: ns, Enforce: info.Enforce, Audit: info.Audit, Warn: info.Warn, Workloads: []PodSecurityWorkload{}}
+		if rank := podSecurityRank[info.Enforce]; rank < podSecurityRank[podSecurityRestricted] {
+			for level, r := range podSecurityRank {
+				if r == rank+1 {
+					e.StricterLevel = level
+				}
+			}
+		}
+		report[ns] = e
+		return e
+	}
+
+	levels := make(map[string]*PodSecurityInfo)
+	for i := range graph.Resources {
+		if res := &graph.Resources[i]; res.Kind == "Namespace" && res.PodSecurity != nil {
+			levels[res.Name] = res.PodSecurity
+		}
+	}
+
+	for i := range graph.Resources {
+		res := &graph.Resources[i]
+		if res.PodSecurity == nil || !resourceAllowed(scope, res) {
+			continue
+		}
+		if res.Kind == "Namespace" {
+			entry(res.Name, res.PodSecurity)
+			continue
+		}
+		// Pods owned by a workload are reported through their owner
+		if res.Kind == "Pod" && len(res.OwnerRefs) > 0 {
+			continue
+		}
+		if res.Kind == "ReplicaSet" && len(res.OwnerRefs) > 0 {
+			continue
+		}
+
+		info := res.PodSecurity
+		nsLevels, ok := levels[res.Namespace]
+		if !ok {
+			nsLevels = namespacePodSecurity(nil)
+		}
+		ns := entry(res.Namespace, nsLevels)
+		rank := podSecurityRank[info.Level]
+		workload := PodSecurityWorkload{
+			ID:          res.ID,
+			Kind:        res.Kind,
+			Name:        res.Name,
+			Level:       info.Level,
+			Rejected:    rank < podSecurityRank[nsLevels.Enforce],
+			AuditOrWarn: rank < podSecurityRank[nsLevels.Audit] || rank < podSecurityRank[nsLevels.Warn],
+		}
+		switch info.Level {
+		case podSecurityPrivileged:
+			workload.Violations = info.Baseline
+		case podSecurityBaseline:
+			workload.Violations = info.Restricted
+		}
+		if ns.StricterLevel != "" && rank < podSecurityRank[ns.StricterLevel] {
+			ns.BlockingStricter++
+		}
+		ns.Workloads = append(ns.Workloads, workload)
+	}
+
+	namespaces := make([]PodSecurityNamespace, 0, len(report))
+	for _, ns := range report {
+		sort.Slice(ns.Workloads, func(i, j int) bool {
+			a, b := ns.Workloads[i], ns.Workloads[j]
+			if a.Level != b.Level {
+				return podSecurityRank[a.Level] < podSecurityRank[b.Level]
+			}
+			return strings.Compare(a.Kind+"/"+a.Name, b.Kind+"/"+b.Name) < 0
+		})
+		namespaces = append(namespaces, *ns)
+	}
+	sort.Slice(namespaces, func(i, j int) bool { return namespaces[i].Namespace < namespaces[j].Namespace })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"namespaces": namespaces})
+}
@@ -0,0 +1,61 @@
+package k8s
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket subprotocols. Each socket endpoint names its wire format so it can
+// evolve behind a new version while older frontends keep the one they asked
+// for. Clients that request no subprotocol get the legacy format.
+const (
+	// ProtocolWatchV1: JSON WatchEvents carrying LightResources
+	ProtocolWatchV1 = "anakosmos.watch.v1"
+	// ProtocolWatchV2: v1 events tagged with clusterId; the client subscribes
+	// to registered clusters with WatchSubscription messages
+	ProtocolWatchV2 = "anakosmos.watch.v2"
+	// ProtocolResourceWatchV1: JSON SingleResourceWatchEvents, starting with a
+	// REPLAY of recent transitions
+	ProtocolResourceWatchV1 = "anakosmos.resource.v1"
+	// ProtocolExecV1: JSON ExecMessage envelopes (stdout/stderr/exit, stdin/resize)
+	ProtocolExecV1 = "anakosmos.exec.v1"
+	// ProtocolRolloutV1: JSON RolloutStatus messages until the rollout ends
+	ProtocolRolloutV1 = "anakosmos.rollout.v1"
+	// ProtocolActivityV1: JSON activity.Items (operations, alerts, cluster
+	// connectivity) for the session's cluster
+	ProtocolActivityV1 = "anakosmos.activity.v1"
+)
+
+// upgradeWebSocket upgrades the request, negotiating one of the supported
+// subprotocols (listed in order of preference). It returns the negotiated
+// protocol, or "" for legacy clients that didn't request one. A client asking
+// only for unknown protocols is rejected before the upgrade so it can fall back.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request, supported ...string) (*websocket.Conn, string, error) {
+	if requested := websocket.Subprotocols(r); len(requested) > 0 && !anyProtocol(requested, supported) {
+		msg := fmt.Sprintf("unsupported subprotocol %s (supported: %s)", strings.Join(requested, ", "), strings.Join(supported, ", "))
+		http.Error(w, msg, http.StatusBadRequest)
+		return nil, "", fmt.Errorf("%s", msg)
+	}
+
+	u := upgrader
+	u.Subprotocols = supported
+	ws, err := u.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	return ws, ws.Subprotocol(), nil
+}
+
+func anyProtocol(requested, supported []string) bool {
+	for _, p := range requested {
+		for _, s := range supported {
+			if p == s {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,237 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anakosmos/backend/src/i18n"
+	"github.com/anakosmos/backend/src/maintenance"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/rest"
+)
+
+// HealthCounts aggregates resource health for a namespace or application
+type HealthCounts struct {
+	Name    string `json:"name"`
+	Total   int    `json:"total"`
+	OK      int    `json:"ok"`
+	Warning int    `json:"warning"`
+	Error   int    `json:"error"`
+	Unknown int    `json:"unknown"`
+	// Maintenance is set on namespaces covered by a maintenance window in
+	// progress
+	Maintenance bool `json:"maintenance,omitempty"`
+}
+
+func (c *HealthCounts) add(health string) {
+	c.Total++
+	switch health {
+	case "ok":
+		c.OK++
+	case "warning", "flapping":
+		c.Warning++
+	case "error":
+		c.Error++
+	default:
+		c.Unknown++
+	}
+}
+
+// PublicStatus is the aggregate-only payload of /api/public/status. It never
+// contains resource names, only counts.
+type PublicStatus struct {
+	GeneratedAt  time.Time      `json:"generatedAt"`
+	Namespaces   []HealthCounts `json:"namespaces"`
+	Applications []HealthCounts `json:"applications"`
+	// Maintenance lists the windows in progress on the whole cluster
+	Maintenance []MaintenanceNotice `json:"maintenance,omitempty"`
+}
+
+// MaintenanceNotice is the public part of a maintenance window
+type MaintenanceNotice struct {
+	Title string    `json:"title"`
+	End   time.Time `json:"end"`
+}
+
+// applicationName returns the logical application a resource belongs to
+func applicationName(res *LightResource) string {
+	if v := res.Labels["app.kubernetes.io/part-of"]; v != "" {
+		return v
+	}
+	return res.Labels["app.kubernetes.io/instance"]
+}
+
+func buildPublicStatus(graph *InitResponse) *PublicStatus {
+	namespaces := make(map[string]*HealthCounts)
+	apps := make(map[string]*HealthCounts)
+	for i := range graph.Resources {
+		res := &graph.Resources[i]
+		if res.Namespace != "" {
+			if namespaces[res.Namespace] == nil {
+				namespaces[res.Namespace] = &HealthCounts{Name: res.Namespace}
+			}
+			namespaces[res.Namespace].add(res.Health)
+		}
+		if app := applicationName(res); app != "" {
+			if apps[app] == nil {
+				apps[app] = &HealthCounts{Name: app}
+			}
+			apps[app].add(res.Health)
+		}
+	}
+
+	status := &PublicStatus{
+		GeneratedAt:  time.Now().UTC(),
+		Namespaces:   make([]HealthCounts, 0, len(namespaces)),
+		Applications: make([]HealthCounts, 0, len(apps)),
+	}
+	for _, c := range namespaces {
+		c.Maintenance = maintenance.UnderMaintenance("local", c.Name)
+		status.Namespaces = append(status.Namespaces, *c)
+	}
+	for _, win := range maintenance.Active("local", "") {
+		status.Maintenance = append(status.Maintenance, MaintenanceNotice{Title: win.Title, End: win.End})
+	}
+	for _, c := range apps {
+		status.Applications = append(status.Applications, *c)
+	}
+	sort.Slice(status.Namespaces, func(i, j int) bool { return status.Namespaces[i].Name < status.Namespaces[j].Name })
+	sort.Slice(status.Applications, func(i, j int) bool { return status.Applications[i].Name < status.Applications[j].Name })
+	return status
+}
+
+// ParseTrustedProxies parses the addresses (IPs or CIDRs) of the reverse
+// proxies allowed to report the client address in X-Forwarded-For
+func ParseTrustedProxies(list []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, entry := range list {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy address %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy range %q: %w", entry, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// clientIP is the address of the client behind the request. X-Forwarded-For
+// is only believed when the connection comes from a trusted proxy, and is read
+// from the right so a client can't prepend addresses of its own: the first
+// hop that isn't a trusted proxy is the client. This assumes every trusted
+// proxy appends the address it received the request from.
+func clientIP(r *http.Request, trusted []*net.IPNet) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	isTrusted := func(addr string) bool {
+		parsed := net.ParseIP(addr)
+		if parsed == nil {
+			return false
+		}
+		for _, n := range trusted {
+			if n.Contains(parsed) {
+				return true
+			}
+		}
+		return false
+	}
+	if !isTrusted(ip) {
+		return ip
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if net.ParseIP(hop) == nil {
+			// Garbage can only come from the client, stop at the last proxy
+			return ip
+		}
+		ip = hop
+		if !isTrusted(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// PublicStatusHandler serves a cached, rate-limited aggregate health summary of
+// the local cluster. It is meant to be reachable without authentication.
+// Clients are rate limited by address; behind a reverse proxy, list it in
+// trustedProxies so clients are told apart by the X-Forwarded-For it sets
+// rather than all sharing the proxy's address.
+func PublicStatusHandler(config *rest.Config, ttl time.Duration, trustedProxies []*net.IPNet) http.HandlerFunc {
+	cache := newGraphCache(config, ttl)
+
+	var (
+		mu       sync.Mutex
+		limiters = make(map[string]*rate.Limiter)
+		pruned   = time.Now()
+	)
+	allow := func(ip string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if time.Since(pruned) > 10*time.Minute {
+			limiters = make(map[string]*rate.Limiter)
+			pruned = time.Now()
+		}
+		l, ok := limiters[ip]
+		if !ok {
+			// 1 request per second per client with a small burst
+			l = rate.NewLimiter(rate.Every(time.Second), 5)
+			limiters[ip] = l
+		}
+		return l.Allow()
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !allow(clientIP(r, trustedProxies)) {
+			w.Header().Set("Retry-After", "1")
+			i18n.Error(w, r, http.StatusTooManyRequests, "error.rateLimited")
+			return
+		}
+		if config == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+
+		graph, fetchedAt, err := cache.Get()
+		if err != nil {
+			log.Printf("Public status refresh failed: %v", err)
+		}
+		if graph == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.unavailable", "status")
+			return
+		}
+		status := buildPublicStatus(graph)
+		status.GeneratedAt = fetchedAt.UTC()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(ttl.Seconds())))
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		json.NewEncoder(w).Encode(status)
+	}
+}
@@ -0,0 +1,43 @@
+package k8s
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		want         string
+	}{
+		{"direct client", "203.0.113.5:4000", "", "203.0.113.5"},
+		{"untrusted peer ignores header", "203.0.113.5:4000", "198.51.100.1", "203.0.113.5"},
+		{"trusted proxy", "10.1.2.3:4000", "198.51.100.1", "198.51.100.1"},
+		{"single trusted address", "192.168.1.1:4000", "198.51.100.1", "198.51.100.1"},
+		{"spoofed prefix", "10.1.2.3:4000", "1.2.3.4, 198.51.100.1", "198.51.100.1"},
+		{"proxy chain", "10.1.2.3:4000", "198.51.100.1, 10.9.9.9", "198.51.100.1"},
+		{"only proxies", "10.1.2.3:4000", "10.9.9.9", "10.9.9.9"},
+		{"garbage hop", "10.1.2.3:4000", "not-an-ip", "10.1.2.3"},
+		{"no header", "10.1.2.3:4000", "", "10.1.2.3"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/public/status", nil)
+		r.RemoteAddr = tt.remoteAddr
+		if tt.forwardedFor != "" {
+			r.Header.Set("X-Forwarded-For", tt.forwardedFor)
+		}
+		if got := clientIP(r, trusted); got != tt.want {
+			t.Errorf("%s: clientIP = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := ParseTrustedProxies([]string{"not-a-cidr/8"}); err == nil {
+		t.Error("ParseTrustedProxies accepted an invalid range")
+	}
+}
@@ -0,0 +1,425 @@
+package k8s
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+)
+
+// QuotaResourceCheck compares the usage added by the YAML against one quota limit
+type QuotaResourceCheck struct {
+	Resource  string `json:"resource"`
+	Hard      string `json:"hard"`
+	Used      string `json:"used"`
+	Requested string `json:"requested"`
+	Remaining string `json:"remaining"`
+	Exceeded  bool   `json:"exceeded"`
+}
+
+type QuotaCheck struct {
+	Name      string               `json:"name"`
+	Resources []QuotaResourceCheck `json:"resources"`
+}
+
+// WorkloadDefaults reports a workload of the YAML leaving requests or limits
+// unset and what the namespace LimitRange fills in
+type WorkloadDefaults struct {
+	Object string `json:"object"`
+	*ResourceDefaults
+}
+
+type NamespaceQuotaCheck struct {
+	Namespace string             `json:"namespace"`
+	Quotas    []QuotaCheck       `json:"quotas"`
+	Defaults  []WorkloadDefaults `json:"defaults,omitempty"`
+	Warnings  []string           `json:"warnings,omitempty"`
+}
+
+type QuotaCheckResponse struct {
+	OK         bool                  `json:"ok"`
+	Namespaces []NamespaceQuotaCheck `json:"namespaces"`
+	Errors     []string              `json:"errors,omitempty"`
+}
+
+// HandleQuotaCheck accepts the same payload as apply-yaml and reports, per
+// target namespace, whether the resources would fit in the ResourceQuota
+// headroom. Objects that already exist only count for their difference.
+func HandleQuotaCheck(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	if config == nil {
+		i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+		return
+	}
+	if r.Method != "POST" {
+		i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodRequired", "POST")
+		return
+	}
+
+	files, defaultNamespace, err := readApplyRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		i18n.Error(w, r, http.StatusInternalServerError, "error.clientFailed")
+		return
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		i18n.Error(w, r, http.StatusInternalServerError, "error.dynamicClientFailed")
+		return
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		i18n.Error(w, r, http.StatusInternalServerError, "error.discoveryClientFailed")
+		return
+	}
+
+	ctx := r.Context()
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(joinManifests(files)), 4096)
+	scope := auth.ScopeFromContext(ctx)
+
+	response := QuotaCheckResponse{OK: true, Namespaces: []NamespaceQuotaCheck{}}
+	requested := make(map[string]corev1.ResourceList)
+	warnings := make(map[string][]string)
+	missingRequests := make(map[string]map[corev1.ResourceName][]string) // ns -> resource -> objects
+	defaults := make(map[string][]WorkloadDefaults)
+
+	for {
+		var rawObj map[string]interface{}
+		if err := decoder.Decode(&rawObj); err != nil {
+			if err == io.EOF {
+				break
+			}
+			response.Errors = append(response.Errors, err.Error())
+			continue
+		}
+		if len(rawObj) == 0 {
+			continue
+		}
+
+		u := &unstructured.Unstructured{Object: rawObj}
+		gvk := u.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			response.Errors = append(response.Errors, gvk.Kind+"/"+u.GetName()+": "+err.Error())
+			continue
+		}
+		if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+			// Quotas only apply to namespaced objects
+			continue
+		}
+		namespace := u.GetNamespace()
+		if namespace == "" {
+			namespace = defaultNamespace
+		}
+		if namespace == "" {
+			response.Errors = append(response.Errors, gvk.Kind+"/"+u.GetName()+": namespace missing")
+			continue
+		}
+		if !scope.Allows(namespace) {
+			response.Errors = append(response.Errors, gvk.Kind+"/"+u.GetName()+": namespace "+namespace+" is not permitted")
+			continue
+		}
+
+		ref := gvk.Kind + "/" + u.GetName()
+		usage, missing, note := quotaUsage(u, mapping.Resource)
+		if note != "" {
+			warnings[namespace] = append(warnings[namespace], ref+": "+note)
+		}
+		for _, name := range missing {
+			if missingRequests[namespace] == nil {
+				missingRequests[namespace] = make(map[corev1.ResourceName][]string)
+			}
+			missingRequests[namespace][name] = append(missingRequests[namespace][name], ref)
+		}
+		if info := manifestResourceDefaults(u); info != nil {
+			defaults[namespace] = append(defaults[namespace], WorkloadDefaults{Object: ref, ResourceDefaults: info})
+		}
+
+		// Updates only consume the difference to what the live object uses
+		if u.GetName() != "" {
+			existing, err := dynamicClient.Resource(mapping.Resource).Namespace(namespace).Get(ctx, u.GetName(), metav1.GetOptions{})
+			if err == nil {
+				current, _, _ := quotaUsage(existing, mapping.Resource)
+				for name, q := range current {
+					d := usage[name]
+					d.Sub(q)
+					usage[name] = d
+				}
+			} else if !apierrors.IsNotFound(err) {
+				warnings[namespace] = append(warnings[namespace], ref+": could not read live object: "+err.Error())
+			}
+		}
+
+		if requested[namespace] == nil {
+			requested[namespace] = corev1.ResourceList{}
+		}
+		for name, q := range usage {
+			total := requested[namespace][name]
+			total.Add(q)
+			requested[namespace][name] = total
+		}
+	}
+
+	namespaces := make([]string, 0, len(requested))
+	for ns := range requested {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	for _, ns := range namespaces {
+		result := NamespaceQuotaCheck{Namespace: ns, Quotas: []QuotaCheck{}, Warnings: warnings[ns]}
+		if pending := defaults[ns]; len(pending) > 0 {
+			ranges, err := clientset.CoreV1().LimitRanges(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				result.Warnings = append(result.Warnings, "could not list LimitRanges: "+err.Error())
+			} else {
+				sort.Slice(ranges.Items, func(i, j int) bool { return ranges.Items[i].Name < ranges.Items[j].Name })
+				for _, d := range pending {
+					limitRangeDefaults(d.ResourceDefaults, ranges.Items)
+					if d.QOSClass == string(corev1.PodQOSBestEffort) {
+						result.Warnings = append(result.Warnings, d.Object+" sets no requests or limits and no LimitRange provides defaults: its pods will be BestEffort")
+					}
+				}
+				result.Defaults = pending
+			}
+		}
+		quotas, err := clientset.CoreV1().ResourceQuotas(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			result.Warnings = append(result.Warnings, "could not list ResourceQuotas: "+err.Error())
+			response.Namespaces = append(response.Namespaces, result)
+			continue
+		}
+
+		for _, quota := range quotas.Items {
+			check := QuotaCheck{Name: quota.Name, Resources: []QuotaResourceCheck{}}
+			names := make([]string, 0, len(quota.Status.Hard))
+			for name := range quota.Status.Hard {
+				names = append(names, string(name))
+			}
+			sort.Strings(names)
+
+			for _, n := range names {
+				name := corev1.ResourceName(n)
+				hard := quota.Status.Hard[name]
+				used := quota.Status.Used[name]
+				// The API server rejects pods without requests/limits for quota-tracked compute resources
+				if objs := missingRequests[ns][name]; len(objs) > 0 {
+					result.Warnings = append(result.Warnings, "quota "+quota.Name+" tracks "+n+" but "+strings.Join(objs, ", ")+" do not set it")
+					response.OK = false
+				}
+				req, ok := requested[ns][name]
+				if !ok {
+					continue
+				}
+				remaining := hard.DeepCopy()
+				remaining.Sub(used)
+				after := used.DeepCopy()
+				after.Add(req)
+				exceeded := after.Cmp(hard) > 0 && req.Sign() > 0
+				if exceeded {
+					response.OK = false
+				}
+				check.Resources = append(check.Resources, QuotaResourceCheck{
+					Resource:  n,
+					Hard:      hard.String(),
+					Used:      used.String(),
+					Requested: req.String(),
+					Remaining: remaining.String(),
+					Exceeded:  exceeded,
+				})
+			}
+			if len(check.Resources) > 0 {
+				result.Quotas = append(result.Quotas, check)
+			}
+		}
+		response.Namespaces = append(response.Namespaces, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// quotaUsage computes the quota resources an object consumes: object counts,
+// pod compute requests/limits (multiplied by replicas), service types and PVC
+// storage. It also returns compute resources some containers leave unset and
+// an optional note about estimates.
+func quotaUsage(u *unstructured.Unstructured, gvr schema.GroupVersionResource) (corev1.ResourceList, []corev1.ResourceName, string) {
+	usage := corev1.ResourceList{}
+	one := resource.MustParse("1")
+
+	countKey := "count/" + gvr.Resource
+	if gvr.Group != "" {
+		countKey += "." + gvr.Group
+	}
+	usage[corev1.ResourceName(countKey)] = one.DeepCopy()
+	if gvr.Group == "" {
+		switch gvr.Resource {
+		case "pods", "services", "secrets", "configmaps", "persistentvolumeclaims", "replicationcontrollers", "resourcequotas":
+			usage[corev1.ResourceName(gvr.Resource)] = one.DeepCopy()
+		}
+	}
+
+	var note string
+	var missing []corev1.ResourceName
+	switch u.GetKind() {
+	case "Service":
+		switch svcType, _, _ := unstructured.NestedString(u.Object, "spec", "type"); svcType {
+		case "LoadBalancer":
+			usage[corev1.ResourceServicesLoadBalancers] = one.DeepCopy()
+			usage[corev1.ResourceServicesNodePorts] = servicePortCount(u)
+		case "NodePort":
+			usage[corev1.ResourceServicesNodePorts] = servicePortCount(u)
+		}
+		return usage, nil, ""
+
+	case "PersistentVolumeClaim":
+		if storage, found, _ := unstructured.NestedString(u.Object, "spec", "resources", "requests", "storage"); found {
+			if q, err := resource.ParseQuantity(storage); err == nil {
+				usage[corev1.ResourceRequestsStorage] = q
+				if sc, _, _ := unstructured.NestedString(u.Object, "spec", "storageClassName"); sc != "" {
+					usage[corev1.ResourceName(sc+".storageclass.storage.k8s.io/requests.storage")] = q.DeepCopy()
+					usage[corev1.ResourceName(sc+".storageclass.storage.k8s.io/persistentvolumeclaims")] = one.DeepCopy()
+				}
+			}
+		}
+		return usage, nil, ""
+	}
+
+	podSpecPath, replicas := podTemplateLocation(u)
+	if podSpecPath == nil {
+		return usage, nil, ""
+	}
+	if u.GetKind() == "DaemonSet" {
+		note = "DaemonSet pod usage is estimated for a single node"
+	}
+	rawSpec, found, _ := unstructured.NestedMap(u.Object, podSpecPath...)
+	if !found {
+		return usage, nil, note
+	}
+	var spec corev1.PodSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawSpec, &spec); err != nil {
+		return usage, nil, note
+	}
+
+	pod, missing := podComputeUsage(&spec)
+	usage[corev1.ResourcePods] = *resource.NewQuantity(replicas, resource.DecimalSI)
+	usage["count/pods"] = *resource.NewQuantity(replicas, resource.DecimalSI)
+	for name, q := range pod {
+		usage[name] = *resource.NewMilliQuantity(q.MilliValue()*replicas, q.Format)
+	}
+	return usage, missing, note
+}
+
+// podTemplateLocation returns where the pod spec lives for workload kinds and
+// how many pods the object creates
+func podTemplateLocation(u *unstructured.Unstructured) ([]string, int64) {
+	replicas := func(path ...string) int64 {
+		if v, found, _ := unstructured.NestedInt64(u.Object, path...); found {
+			return v
+		}
+		return 1
+	}
+	switch u.GetKind() {
+	case "Pod":
+		return []string{"spec"}, 1
+	case "Deployment", "ReplicaSet", "StatefulSet", "ReplicationController":
+		return []string{"spec", "template", "spec"}, replicas("spec", "replicas")
+	case "DaemonSet":
+		return []string{"spec", "template", "spec"}, 1
+	case "Job":
+		return []string{"spec", "template", "spec"}, replicas("spec", "parallelism")
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template", "spec"}, replicas("spec", "jobTemplate", "spec", "parallelism")
+	}
+	return nil, 0
+}
+
+// podComputeUsage returns the effective requests/limits of one pod: the sum
+// over containers or the largest init container, whichever is higher.
+// Requests default to limits when only limits are set, as the API server does.
+func podComputeUsage(spec *corev1.PodSpec) (corev1.ResourceList, []corev1.ResourceName) {
+	computed := []struct {
+		name     corev1.ResourceName
+		resource corev1.ResourceName
+		limit    bool
+	}{
+		{corev1.ResourceRequestsCPU, corev1.ResourceCPU, false},
+		{corev1.ResourceRequestsMemory, corev1.ResourceMemory, false},
+		{corev1.ResourceLimitsCPU, corev1.ResourceCPU, true},
+		{corev1.ResourceLimitsMemory, corev1.ResourceMemory, true},
+	}
+	value := func(c corev1.Container, limit bool, res corev1.ResourceName) (resource.Quantity, bool) {
+		if !limit {
+			if q, ok := c.Resources.Requests[res]; ok {
+				return q, true
+			}
+		}
+		q, ok := c.Resources.Limits[res]
+		return q, ok
+	}
+
+	usage := corev1.ResourceList{}
+	var missing []corev1.ResourceName
+	for _, entry := range computed {
+		sum := resource.Quantity{}
+		unset := false
+		for _, c := range spec.Containers {
+			q, ok := value(c, entry.limit, entry.resource)
+			if !ok {
+				unset = true
+				continue
+			}
+			sum.Add(q)
+		}
+		for _, c := range spec.InitContainers {
+			if q, ok := value(c, entry.limit, entry.resource); ok && q.Cmp(sum) > 0 {
+				sum = q
+			}
+		}
+		if q, ok := spec.Overhead[entry.resource]; ok {
+			sum.Add(q)
+		}
+		usage[entry.name] = sum
+		if unset {
+			missing = append(missing, entry.name)
+			// Quotas on the bare resource name mean requests
+			if !entry.limit {
+				missing = append(missing, entry.resource)
+			}
+		}
+	}
+	usage[corev1.ResourceCPU] = usage[corev1.ResourceRequestsCPU].DeepCopy()
+	usage[corev1.ResourceMemory] = usage[corev1.ResourceRequestsMemory].DeepCopy()
+	return usage, missing
+}
+
+func servicePortCount(u *unstructured.Unstructured) resource.Quantity {
+	ports, _, _ := unstructured.NestedSlice(u.Object, "spec", "ports")
+	return *resource.NewQuantity(int64(len(ports)), resource.DecimalSI)
+}
@@ -0,0 +1,105 @@
+package k8s
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// quotaPressure is the used/hard ratio at which a ResourceQuota turns to
+// warning; at 1 it is exhausted and an error
+const quotaPressure = 0.9
+
+// QuotaInfo summarizes a ResourceQuota or LimitRange. Both link to their
+// Namespace with a policy link.
+type QuotaInfo struct {
+	// Hard and Used are the limits of a ResourceQuota and their usage
+	Hard map[string]string `json:"hard,omitempty"`
+	Used map[string]string `json:"used,omitempty"`
+	// Pressure is the highest used/hard ratio of a ResourceQuota, in percent
+	Pressure int `json:"pressure,omitempty"`
+	// Limits are the "Type resource key=value ..." lines of a LimitRange
+	Limits []string `json:"limits,omitempty"`
+}
+
+func lightResourceQuota(q *corev1.ResourceQuota) LightResource {
+	res := baseLightResource(q, "ResourceQuota")
+	info := &QuotaInfo{Hard: make(map[string]string), Used: make(map[string]string)}
+	var pressure float64
+	for name, hard := range q.Status.Hard {
+		info.Hard[string(name)] = hard.String()
+		used, ok := q.Status.Used[name]
+		if !ok {
+			continue
+		}
+		info.Used[string(name)] = used.String()
+		if h := hard.AsApproximateFloat64(); h > 0 {
+			pressure = max(pressure, used.AsApproximateFloat64()/h)
+		} else if !used.IsZero() {
+			pressure = max(pressure, 1)
+		}
+	}
+	if len(info.Hard) == 0 {
+		// Not yet processed by the quota controller
+		for name, hard := range q.Spec.Hard {
+			info.Hard[string(name)] = hard.String()
+		}
+	}
+	info.Pressure = int(pressure * 100)
+	res.Quota = info
+
+	switch {
+	case pressure >= 1:
+		res.Status, res.Health = "Exhausted", "error"
+	case pressure >= quotaPressure:
+		res.Status, res.Health = "NearLimit", "warning"
+	default:
+		res.Status, res.Health = "Active", "ok"
+	}
+	return res
+}
+
+func lightLimitRange(lr *corev1.LimitRange) LightResource {
+	res := baseLightResource(lr, "LimitRange")
+	info := &QuotaInfo{}
+	for _, item := range lr.Spec.Limits {
+		for _, field := range []struct {
+			key    string
+			values corev1.ResourceList
+		}{
+			{"min", item.Min},
+			{"max", item.Max},
+			{"default", item.Default},
+			{"defaultRequest", item.DefaultRequest},
+			{"maxLimitRequestRatio", item.MaxLimitRequestRatio},
+		} {
+			for name, q := range field.values {
+				info.Limits = append(info.Limits, string(item.Type)+" "+string(name)+" "+field.key+"="+q.String())
+			}
+		}
+	}
+	sort.Strings(info.Limits)
+	info.Limits = mergeLimitLines(info.Limits)
+	res.Quota = info
+	res.Status, res.Health = "Active", "ok"
+	return res
+}
+
+// mergeLimitLines joins sorted "Type resource key=value" lines of the same
+// type and resource into one
+func mergeLimitLines(lines []string) []string {
+	var merged []string
+	prefix := ""
+	for _, line := range lines {
+		fields := strings.SplitN(line, " ", 3)
+		key := fields[0] + " " + fields[1]
+		if key == prefix {
+			merged[len(merged)-1] += " " + fields[2]
+			continue
+		}
+		prefix = key
+		merged = append(merged, line)
+	}
+	return merged
+}
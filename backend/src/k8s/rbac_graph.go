@@ -0,0 +1,223 @@
+package k8s
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RBACInfo summarizes a Role or ClusterRole, or what a binding grants
+type RBACInfo struct {
+	// Rules are "verbs: resources" lines, e.g. "get,list: pods, services"
+	Rules []string `json:"rules,omitempty"`
+	// RoleRef is the Kind/Name a binding grants
+	RoleRef string `json:"roleRef,omitempty"`
+	// Subjects are the Kind/namespace/name bound, for bindings
+	Subjects []string `json:"subjects,omitempty"`
+}
+
+func lightServiceAccount(sa *corev1.ServiceAccount) LightResource {
+	res := baseLightResource(sa, "ServiceAccount")
+	res.Status = "Active"
+	res.Health = "ok"
+	for _, ref := range sa.ImagePullSecrets {
+		if ref.Name != "" {
+			res.ImagePullSecrets = append(res.ImagePullSecrets, ref.Name)
+		}
+	}
+	res.HelmRelease = extractHelmInfo(sa.Labels, sa.Annotations, sa.Namespace)
+	return res
+}
+
+func lightRole(obj metav1.Object, kind string, rules []rbacv1.PolicyRule) LightResource {
+	res := baseLightResource(obj, kind)
+	res.Status = "Active"
+	res.Health = "ok"
+	info := &RBACInfo{}
+	wildcard := false
+	for _, rule := range rules {
+		info.Rules = append(info.Rules, describeRule(rule))
+		if containsString(rule.Verbs, "*") || containsString(rule.Resources, "*") {
+			wildcard = true
+		}
+	}
+	if wildcard {
+		res.SecurityFlags = append(res.SecurityFlags, "wildcard-permissions")
+	}
+	res.RBAC = info
+	res.HelmRelease = extractHelmInfo(obj.GetLabels(), obj.GetAnnotations(), obj.GetNamespace())
+	return res
+}
+
+func describeRule(rule rbacv1.PolicyRule) string {
+	targets := rule.Resources
+	if len(targets) == 0 {
+		targets = rule.NonResourceURLs
+	}
+	line := strings.Join(rule.Verbs, ",") + ": " + strings.Join(targets, ", ")
+	if len(rule.ResourceNames) > 0 {
+		line += " (" + strings.Join(rule.ResourceNames, ", ") + ")"
+	}
+	return line
+}
+
+func lightBinding(obj metav1.Object, kind string, ref rbacv1.RoleRef, subjects []rbacv1.Subject) LightResource {
+	res := baseLightResource(obj, kind)
+	res.Status = "Active"
+	res.Health = "ok"
+	info := &RBACInfo{RoleRef: ref.Kind + "/" + ref.Name}
+	for _, s := range subjects {
+		if s.Kind == rbacv1.ServiceAccountKind {
+			info.Subjects = append(info.Subjects, s.Kind+"/"+subjectNamespace(s, obj.GetNamespace())+"/"+s.Name)
+		} else {
+			info.Subjects = append(info.Subjects, s.Kind+"/"+s.Name)
+		}
+	}
+	res.RBAC = info
+	res.HelmRelease = extractHelmInfo(obj.GetLabels(), obj.GetAnnotations(), obj.GetNamespace())
+	return res
+}
+
+// subjectNamespace is the namespace of a ServiceAccount subject, which
+// defaults to the binding's own
+func subjectNamespace(s rbacv1.Subject, bindingNamespace string) string {
+	if s.Namespace != "" {
+		return s.Namespace
+	}
+	return bindingNamespace
+}
+
+// boundServiceAccounts returns the UIDs of the ServiceAccounts a binding's
+// subjects cover, including the system:serviceaccounts groups
+func boundServiceAccounts(subjects []rbacv1.Subject, bindingNamespace string, saMap map[string]string) []string {
+	var uids []string
+	add := func(uid string) {
+		if uid != "" && !containsString(uids, uid) {
+			uids = append(uids, uid)
+		}
+	}
+	for _, s := range subjects {
+		switch {
+		case s.Kind == rbacv1.ServiceAccountKind:
+			add(saMap[subjectNamespace(s, bindingNamespace)+"/"+s.Name])
+		case s.Kind == rbacv1.GroupKind && s.Name == "system:serviceaccounts":
+			for _, uid := range saMap {
+				add(uid)
+			}
+		case s.Kind == rbacv1.GroupKind && strings.HasPrefix(s.Name, "system:serviceaccounts:"):
+			prefix := strings.TrimPrefix(s.Name, "system:serviceaccounts:") + "/"
+			for key, uid := range saMap {
+				if strings.HasPrefix(key, prefix) {
+					add(uid)
+				}
+			}
+		}
+	}
+	sort.Strings(uids)
+	return uids
+}
+
+// rbacGraph turns ServiceAccounts, Roles and bindings into resources linked
+// Pod -> ServiceAccount -> RoleBinding -> Role. ClusterRoleBindings are only
+// kept when they bind a listed ServiceAccount, and ClusterRoles when a kept
+// binding grants them, so the cluster's many built-in roles stay out.
+func rbacGraph(pods []corev1.Pod, sas []corev1.ServiceAccount, roles []rbacv1.Role, clusterRoles []rbacv1.ClusterRole,
+	roleBindings []rbacv1.RoleBinding, clusterRoleBindings []rbacv1.ClusterRoleBinding) ([]LightResource, []ClusterLink) {
+	var resources []LightResource
+	var links []ClusterLink
+
+	saMap := make(map[string]string) // namespace/name -> uid
+	for i := range sas {
+		sa := &sas[i]
+		saMap[sa.Namespace+"/"+sa.Name] = string(sa.UID)
+		resources = append(resources, lightServiceAccount(sa))
+	}
+	if len(saMap) > 0 {
+		for i := range pods {
+			p := &pods[i]
+			name := p.Spec.ServiceAccountName
+			if name == "" {
+				name = "default"
+			}
+			if uid, ok := saMap[p.Namespace+"/"+name]; ok {
+				links = append(links, ClusterLink{Source: string(p.UID), Target: uid, Type: "rbac"})
+			}
+		}
+	}
+
+	roleMap := make(map[string]string) // namespace/name -> uid
+	for i := range roles {
+		r := &roles[i]
+		roleMap[r.Namespace+"/"+r.Name] = string(r.UID)
+		resources = append(resources, lightRole(r, "Role", r.Rules))
+	}
+	clusterRoleMap := make(map[string]*rbacv1.ClusterRole)
+	for i := range clusterRoles {
+		clusterRoleMap[clusterRoles[i].Name] = &clusterRoles[i]
+	}
+
+	granted := make(map[string]bool) // ClusterRoles a kept binding refers to
+	bindingLinks := func(uid string, ref rbacv1.RoleRef, namespace string, saUIDs []string) {
+		for _, saUID := range saUIDs {
+			links = append(links, ClusterLink{Source: saUID, Target: uid, Type: "rbac"})
+		}
+		switch ref.Kind {
+		case "Role":
+			if target, ok := roleMap[namespace+"/"+ref.Name]; ok {
+				links = append(links, ClusterLink{Source: uid, Target: target, Type: "rbac"})
+			}
+		case "ClusterRole":
+			if cr, ok := clusterRoleMap[ref.Name]; ok {
+				granted[ref.Name] = true
+				links = append(links, ClusterLink{Source: uid, Target: string(cr.UID), Type: "rbac"})
+			}
+		}
+	}
+	for i := range roleBindings {
+		rb := &roleBindings[i]
+		resources = append(resources, lightBinding(rb, "RoleBinding", rb.RoleRef, rb.Subjects))
+		bindingLinks(string(rb.UID), rb.RoleRef, rb.Namespace, boundServiceAccounts(rb.Subjects, rb.Namespace, saMap))
+	}
+	for i := range clusterRoleBindings {
+		crb := &clusterRoleBindings[i]
+		saUIDs := boundServiceAccounts(crb.Subjects, "", saMap)
+		if len(saUIDs) == 0 {
+			continue
+		}
+		resources = append(resources, lightBinding(crb, "ClusterRoleBinding", crb.RoleRef, crb.Subjects))
+		bindingLinks(string(crb.UID), crb.RoleRef, "", saUIDs)
+	}
+	for i := range clusterRoles {
+		cr := &clusterRoles[i]
+		if granted[cr.Name] {
+			resources = append(resources, lightRole(cr, "ClusterRole", cr.Rules))
+		}
+	}
+	return resources, links
+}
+
+// withoutUnbound drops the ClusterRoleBindings no remaining ServiceAccount
+// links to, then the ClusterRoles no remaining binding grants. They are only
+// in the graph for those links, so once system namespaces are filtered out
+// the cluster's built-in bindings would otherwise be left dangling.
+func withoutUnbound(resources []LightResource, links []ClusterLink) ([]LightResource, []ClusterLink) {
+	for _, kind := range []string{"ClusterRoleBinding", "ClusterRole"} {
+		targets := make(map[string]bool)
+		for _, l := range links {
+			targets[l.Target] = true
+		}
+		kept := make([]LightResource, 0, len(resources))
+		for i := range resources {
+			if resources[i].Kind != kind || targets[resources[i].ID] {
+				kept = append(kept, resources[i])
+			}
+		}
+		if len(kept) != len(resources) {
+			resources, links = kept, linksWithin(kept, links)
+		}
+	}
+	return resources, links
+}
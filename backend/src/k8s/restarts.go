@@ -0,0 +1,215 @@
+package k8s
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+
+	"k8s.io/client-go/rest"
+)
+
+// restartSample is a restart count observed for a pod at a point in time
+type restartSample struct {
+	at    time.Time
+	count int32
+}
+
+type podRestarts struct {
+	namespace string
+	name      string
+	samples   []restartSample
+	lastSeen  time.Time
+}
+
+// RestartTracker follows container restart counts across init and watch
+// updates and flags pods that restart too often within a sliding window.
+type RestartTracker struct {
+	mu        sync.Mutex
+	threshold int
+	window    time.Duration
+	pods      map[string]map[string]*podRestarts // cluster host -> pod UID -> samples
+}
+
+// RestartOffender is a pod entry in the restart offenders listing
+type RestartOffender struct {
+	ID        string `json:"id"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Restarts  int32  `json:"restarts"`       // current total restart count
+	Recent    int32  `json:"recentRestarts"` // restarts within the window
+	Flapping  bool   `json:"flapping"`
+}
+
+var restartTracker = NewRestartTracker(3, 10*time.Minute)
+
+// NewRestartTracker flags pods with more than threshold restarts within window
+func NewRestartTracker(threshold int, window time.Duration) *RestartTracker {
+	return &RestartTracker{
+		threshold: threshold,
+		window:    window,
+		pods:      make(map[string]map[string]*podRestarts),
+	}
+}
+
+// ConfigureRestartTracking replaces the flapping threshold and window
+func ConfigureRestartTracking(threshold int, window time.Duration) {
+	restartTracker.mu.Lock()
+	defer restartTracker.mu.Unlock()
+	restartTracker.threshold = threshold
+	restartTracker.window = window
+}
+
+// Observe records the restart count of a pod of the cluster at host and marks
+// it "flapping" when the restarts within the window exceed the threshold.
+// Health that is already worse, such as "error", is kept. Non-pod resources
+// are ignored.
+func (t *RestartTracker) Observe(cluster string, res *LightResource) {
+	if res.Kind != "Pod" {
+		return
+	}
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pods, ok := t.pods[cluster]
+	if !ok {
+		pods = make(map[string]*podRestarts)
+		t.pods[cluster] = pods
+	}
+	p, ok := pods[res.ID]
+	if !ok {
+		p = &podRestarts{namespace: res.Namespace, name: res.Name}
+		pods[res.ID] = p
+	}
+	p.lastSeen = now
+	// Only store increases, so repeated observations of the same count from
+	// several watch connections don't grow the sample list
+	if n := len(p.samples); n == 0 || p.samples[n-1].count != res.Restarts {
+		p.samples = append(p.samples, restartSample{at: now, count: res.Restarts})
+	}
+	t.prune(p, now)
+
+	if t.threshold > 0 && int(t.recent(p)) > t.threshold && healthSeverity[res.Health] < healthSeverity["flapping"] {
+		res.Health = "flapping"
+	}
+}
+
+// Forget drops tracking for a deleted pod of the cluster at host
+func (t *RestartTracker) Forget(cluster, id string) {
+	t.mu.Lock()
+	delete(t.pods[cluster], id)
+	t.mu.Unlock()
+}
+
+// prune drops samples older than the window but keeps the newest one of them
+// as the baseline for counting increases
+func (t *RestartTracker) prune(p *podRestarts, now time.Time) {
+	cutoff := now.Add(-t.window)
+	i := 0
+	for i < len(p.samples)-1 && p.samples[i+1].at.Before(cutoff) {
+		i++
+	}
+	p.samples = p.samples[i:]
+}
+
+// recent returns how many restarts happened within the window
+func (t *RestartTracker) recent(p *podRestarts) int32 {
+	if len(p.samples) < 2 {
+		return 0
+	}
+	delta := p.samples[len(p.samples)-1].count - p.samples[0].count
+	if delta < 0 {
+		// Restart counters can be reset by the kubelet
+		return 0
+	}
+	return delta
+}
+
+// Offenders returns tracked pods of the cluster at host ordered by restarts
+// within the window, then by total restarts. Pods without updates for a full
+// window are evicted.
+func (t *RestartTracker) Offenders(cluster string, scope auth.Scope, limit int) []RestartOffender {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := []RestartOffender{}
+	pods := t.pods[cluster]
+	for id, p := range pods {
+		if now.Sub(p.lastSeen) > t.window {
+			delete(pods, id)
+			continue
+		}
+		if !scope.Allows(p.namespace) || len(p.samples) == 0 {
+			continue
+		}
+		t.prune(p, now)
+		total := p.samples[len(p.samples)-1].count
+		if total == 0 {
+			continue
+		}
+		recent := t.recent(p)
+		result = append(result, RestartOffender{
+			ID:        id,
+			Namespace: p.namespace,
+			Name:      p.name,
+			Restarts:  total,
+			Recent:    recent,
+			Flapping:  t.threshold > 0 && int(recent) > t.threshold,
+		})
+	}
+
+	if len(pods) == 0 {
+		delete(t.pods, cluster)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Recent != result[j].Recent {
+			return result[i].Recent > result[j].Recent
+		}
+		return result[i].Restarts > result[j].Restarts
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// HandleRestartOffenders serves GET /api/pods/restarts: the pods of the cluster
+// behind config with the most restarts seen by init and watch streams, in
+// namespaces the caller may see. ?limit= caps the list (default 20).
+func HandleRestartOffenders(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodRequired", "GET")
+		return
+	}
+	limit := 20
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "limit")
+			return
+		}
+		limit = n
+	}
+
+	restartTracker.mu.Lock()
+	window := restartTracker.window
+	threshold := restartTracker.threshold
+	restartTracker.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"window":    window.String(),
+		"threshold": threshold,
+		"pods":      restartTracker.Offenders(config.Host, auth.ScopeFromContext(r.Context()), limit),
+	})
+}
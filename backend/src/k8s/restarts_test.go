@@ -0,0 +1,77 @@
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anakosmos/backend/src/auth"
+)
+
+func TestRestartTrackerHealth(t *testing.T) {
+	tests := []struct {
+		health string
+		want   string
+	}{
+		{"ok", "flapping"},
+		{"warning", "flapping"},
+		{"", "flapping"},
+		{"error", "error"},
+	}
+	for _, tt := range tests {
+		tracker := NewRestartTracker(2, time.Hour)
+		var res LightResource
+		for restarts := int32(0); restarts <= 5; restarts++ {
+			res = LightResource{ID: "uid-1", Kind: "Pod", Namespace: "a", Name: "p", Restarts: restarts, Health: tt.health}
+			tracker.Observe("https://cluster-a", &res)
+		}
+		if res.Health != tt.want {
+			t.Errorf("health %q after 5 restarts = %q, want %q", tt.health, res.Health, tt.want)
+		}
+	}
+}
+
+func TestRestartTrackerBelowThreshold(t *testing.T) {
+	tracker := NewRestartTracker(3, time.Hour)
+	for restarts := int32(0); restarts <= 3; restarts++ {
+		res := LightResource{ID: "uid-1", Kind: "Pod", Restarts: restarts, Health: "ok"}
+		tracker.Observe("https://cluster-a", &res)
+		if res.Health != "ok" {
+			t.Fatalf("%d restarts: health = %q, want ok", restarts, res.Health)
+		}
+	}
+}
+
+func TestRestartTrackerOffendersByCluster(t *testing.T) {
+	tracker := NewRestartTracker(3, time.Hour)
+	observe := func(cluster, id, namespace string, restarts int32) {
+		res := LightResource{ID: id, Kind: "Pod", Namespace: namespace, Name: id, Restarts: restarts}
+		tracker.Observe(cluster, &res)
+	}
+	observe("https://cluster-a", "a-1", "team-a", 4)
+	observe("https://cluster-a", "a-2", "team-b", 2)
+	observe("https://cluster-b", "b-1", "team-a", 7)
+
+	offenders := tracker.Offenders("https://cluster-a", auth.Unrestricted, 0)
+	if len(offenders) != 2 {
+		t.Fatalf("cluster-a offenders = %v, want 2 pods", offenders)
+	}
+	for _, o := range offenders {
+		if o.ID == "b-1" {
+			t.Errorf("cluster-a offenders include pod %s of cluster-b", o.ID)
+		}
+	}
+
+	scope := auth.Scope{Namespaces: map[string]bool{"team-a": true}}
+	offenders = tracker.Offenders("https://cluster-a", scope, 0)
+	if len(offenders) != 1 || offenders[0].ID != "a-1" {
+		t.Errorf("team-a offenders of cluster-a = %v, want only a-1", offenders)
+	}
+
+	tracker.Forget("https://cluster-b", "b-1")
+	if offenders := tracker.Offenders("https://cluster-b", auth.Unrestricted, 0); len(offenders) != 0 {
+		t.Errorf("cluster-b offenders after Forget = %v, want none", offenders)
+	}
+	if offenders := tracker.Offenders("https://cluster-c", auth.Unrestricted, 0); len(offenders) != 0 {
+		t.Errorf("unknown cluster offenders = %v, want none", offenders)
+	}
+}
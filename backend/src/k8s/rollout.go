@@ -0,0 +1,290 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	"github.com/gorilla/websocket"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// RolloutStatus is sent on the rollout socket each time the workload changes.
+// Phase is "progressing" until the final "complete", "failed" or "timeout"
+// message, after which the server closes the socket.
+type RolloutStatus struct {
+	Phase     string `json:"phase"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Message   string `json:"message"`
+	Revision  string `json:"revision,omitempty"`
+	Desired   int32  `json:"desired"`
+	Updated   int32  `json:"updated"`
+	Ready     int32  `json:"ready"`
+	Available int32  `json:"available"`
+	// Deployments only: pods still run by previous ReplicaSets
+	OldReplicas   int32  `json:"oldReplicas"`
+	NewReplicaSet string `json:"newReplicaSet,omitempty"`
+	Elapsed       string `json:"elapsed"`
+}
+
+// deploymentRollout mirrors kubectl rollout status for Deployments
+func deploymentRollout(d *appsv1.Deployment) RolloutStatus {
+	s := RolloutStatus{
+		Revision:  d.Annotations["deployment.kubernetes.io/revision"],
+		Desired:   1,
+		Updated:   d.Status.UpdatedReplicas,
+		Ready:     d.Status.ReadyReplicas,
+		Available: d.Status.AvailableReplicas,
+	}
+	if d.Spec.Replicas != nil {
+		s.Desired = *d.Spec.Replicas
+	}
+	s.OldReplicas = max(d.Status.Replicas-d.Status.UpdatedReplicas, 0)
+
+	switch {
+	case d.Generation > d.Status.ObservedGeneration:
+		s.Phase, s.Message = "progressing", "Waiting for the deployment spec update to be observed"
+	case deploymentDeadlineExceeded(d):
+		s.Phase, s.Message = "failed", fmt.Sprintf("Deployment %q exceeded its progress deadline", d.Name)
+	case s.Updated < s.Desired:
+		s.Phase, s.Message = "progressing", fmt.Sprintf("%d of %d new replicas have been updated", s.Updated, s.Desired)
+	case s.OldReplicas > 0:
+		s.Phase, s.Message = "progressing", fmt.Sprintf("%d old replicas are pending termination", s.OldReplicas)
+	case s.Available < s.Updated:
+		s.Phase, s.Message = "progressing", fmt.Sprintf("%d of %d updated replicas are available", s.Available, s.Updated)
+	default:
+		s.Phase, s.Message = "complete", fmt.Sprintf("Deployment %q successfully rolled out", d.Name)
+	}
+	return s
+}
+
+func deploymentDeadlineExceeded(d *appsv1.Deployment) bool {
+	for _, cond := range d.Status.Conditions {
+		if cond.Type == appsv1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded" {
+			return true
+		}
+	}
+	return false
+}
+
+func statefulSetRollout(ss *appsv1.StatefulSet) RolloutStatus {
+	s := RolloutStatus{
+		Revision:  ss.Status.UpdateRevision,
+		Desired:   1,
+		Updated:   ss.Status.UpdatedReplicas,
+		Ready:     ss.Status.ReadyReplicas,
+		Available: ss.Status.AvailableReplicas,
+	}
+	if ss.Spec.Replicas != nil {
+		s.Desired = *ss.Spec.Replicas
+	}
+	var partition int32
+	if ru := ss.Spec.UpdateStrategy.RollingUpdate; ru != nil && ru.Partition != nil {
+		partition = *ru.Partition
+	}
+
+	switch {
+	case ss.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType:
+		s.Phase, s.Message = "complete", "OnDelete update strategy: pods are updated when deleted"
+	case ss.Generation > ss.Status.ObservedGeneration:
+		s.Phase, s.Message = "progressing", "Waiting for the statefulset spec update to be observed"
+	case s.Ready < s.Desired:
+		s.Phase, s.Message = "progressing", fmt.Sprintf("%d of %d pods are ready", s.Ready, s.Desired)
+	case partition > 0 && s.Updated < s.Desired-partition:
+		s.Phase, s.Message = "progressing", fmt.Sprintf("%d of %d pods above partition %d have been updated", s.Updated, s.Desired-partition, partition)
+	case partition == 0 && ss.Status.UpdateRevision != ss.Status.CurrentRevision:
+		s.Phase, s.Message = "progressing", fmt.Sprintf("%d of %d pods have been updated to revision %s", s.Updated, s.Desired, ss.Status.UpdateRevision)
+	default:
+		s.Phase, s.Message = "complete", fmt.Sprintf("StatefulSet %q successfully rolled out", ss.Name)
+	}
+	return s
+}
+
+func daemonSetRollout(ds *appsv1.DaemonSet) RolloutStatus {
+	s := RolloutStatus{
+		Desired:   ds.Status.DesiredNumberScheduled,
+		Updated:   ds.Status.UpdatedNumberScheduled,
+		Ready:     ds.Status.NumberReady,
+		Available: ds.Status.NumberAvailable,
+	}
+
+	switch {
+	case ds.Spec.UpdateStrategy.Type == appsv1.OnDeleteDaemonSetStrategyType:
+		s.Phase, s.Message = "complete", "OnDelete update strategy: pods are updated when deleted"
+	case ds.Generation > ds.Status.ObservedGeneration:
+		s.Phase, s.Message = "progressing", "Waiting for the daemonset spec update to be observed"
+	case s.Updated < s.Desired:
+		s.Phase, s.Message = "progressing", fmt.Sprintf("%d of %d updated pods have been scheduled", s.Updated, s.Desired)
+	case s.Available < s.Desired:
+		s.Phase, s.Message = "progressing", fmt.Sprintf("%d of %d updated pods are available", s.Available, s.Desired)
+	default:
+		s.Phase, s.Message = "complete", fmt.Sprintf("DaemonSet %q successfully rolled out", ds.Name)
+	}
+	return s
+}
+
+// newReplicaSet finds the ReplicaSet of the deployment's current revision
+func newReplicaSet(ctx context.Context, client *kubernetes.Clientset, d *appsv1.Deployment) string {
+	revision := d.Annotations["deployment.kubernetes.io/revision"]
+	list, err := client.AppsV1().ReplicaSets(d.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return ""
+	}
+	for _, rs := range list.Items {
+		for _, ref := range rs.OwnerReferences {
+			if ref.UID == d.UID && rs.Annotations["deployment.kubernetes.io/revision"] == revision {
+				return rs.Name
+			}
+		}
+	}
+	return ""
+}
+
+// HandleRolloutWatch streams rollout progress for a Deployment, StatefulSet or
+// DaemonSet (?kind=&namespace=&name=) until it completes, fails or ?timeout=
+// (default 10m) passes. Each status is a RolloutStatus JSON message.
+func HandleRolloutWatch(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	kind, namespace, name := strings.ToLower(q.Get("kind")), q.Get("namespace"), q.Get("name")
+	if name == "" || namespace == "" {
+		i18n.Error(w, r, http.StatusBadRequest, "error.required", "namespace, name")
+		return
+	}
+	if kind != "deployment" && kind != "statefulset" && kind != "daemonset" {
+		i18n.Error(w, r, http.StatusBadRequest, "error.mustBeOneOf", "kind", "Deployment, StatefulSet, DaemonSet")
+		return
+	}
+	timeout := 10 * time.Minute
+	if v := q.Get("timeout"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "timeout")
+			return
+		}
+		timeout = d
+	}
+	if !auth.RequireNamespace(w, r, namespace) {
+		return
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		i18n.Error(w, r, http.StatusInternalServerError, "error.clientFailed")
+		return
+	}
+
+	// Fail before upgrading when there is nothing to follow
+	switch kind {
+	case "deployment":
+		_, err = clientset.AppsV1().Deployments(namespace).Get(r.Context(), name, metav1.GetOptions{})
+	case "statefulset":
+		_, err = clientset.AppsV1().StatefulSets(namespace).Get(r.Context(), name, metav1.GetOptions{})
+	case "daemonset":
+		_, err = clientset.AppsV1().DaemonSets(namespace).Get(r.Context(), name, metav1.GetOptions{})
+	}
+	if apierrors.IsNotFound(err) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ws, _, err := upgradeWebSocket(w, r, ProtocolRolloutV1)
+	if err != nil {
+		log.Println("Rollout watch upgrade error:", err)
+		return
+	}
+	defer ws.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	// The client only listens; a read error means it went away
+	go func() {
+		for {
+			if _, _, err := ws.NextReader(); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	started := time.Now()
+	send := func(s RolloutStatus) bool {
+		s.Kind, s.Namespace, s.Name = canonicalKind(kind), namespace, name
+		s.Elapsed = time.Since(started).Round(time.Second).String()
+		if err := ws.WriteJSON(s); err != nil {
+			return false
+		}
+		return s.Phase == "progressing"
+	}
+	finish := func(reason string) {
+		ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, reason), time.Now().Add(time.Second))
+	}
+
+	listOpts := metav1.ListOptions{FieldSelector: "metadata.name=" + name}
+	for {
+		var watcher watch.Interface
+		switch kind {
+		case "deployment":
+			watcher, err = clientset.AppsV1().Deployments(namespace).Watch(ctx, listOpts)
+		case "statefulset":
+			watcher, err = clientset.AppsV1().StatefulSets(namespace).Watch(ctx, listOpts)
+		case "daemonset":
+			watcher, err = clientset.AppsV1().DaemonSets(namespace).Watch(ctx, listOpts)
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				send(RolloutStatus{Phase: "failed", Message: err.Error()})
+				finish("error")
+			}
+			return
+		}
+
+		for event := range watcher.ResultChan() {
+			var status RolloutStatus
+			switch obj := event.Object.(type) {
+			case *appsv1.Deployment:
+				status = deploymentRollout(obj)
+				status.NewReplicaSet = newReplicaSet(ctx, clientset, obj)
+			case *appsv1.StatefulSet:
+				status = statefulSetRollout(obj)
+			case *appsv1.DaemonSet:
+				status = daemonSetRollout(obj)
+			default:
+				continue
+			}
+			if event.Type == watch.Deleted {
+				status.Phase, status.Message = "failed", "The workload was deleted"
+			}
+			if !send(status) {
+				watcher.Stop()
+				finish(status.Phase)
+				return
+			}
+		}
+		watcher.Stop()
+
+		// The watch ends on timeout, client disconnect or server-side expiry
+		switch ctx.Err() {
+		case context.DeadlineExceeded:
+			send(RolloutStatus{Phase: "timeout", Message: fmt.Sprintf("Rollout did not finish within %s", timeout)})
+			finish("timeout")
+			return
+		case context.Canceled:
+			return
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package k8s
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	"k8s.io/client-go/rest"
+)
+
+// maxSearchLimit caps a page of search results
+const maxSearchLimit = 500
+
+// SearchResult is a resource matching a search, with the field that matched
+// best: name, kind, namespace, label or annotation
+type SearchResult struct {
+	ImpactResource
+	Status string `json:"status"`
+	Health string `json:"health,omitempty"`
+	Match  string `json:"match"`
+}
+
+// SearchResponse is served by /api/cluster/search. Total counts every match,
+// Results holds the page starting at Offset.
+type SearchResponse struct {
+	Query       string         `json:"query"`
+	GeneratedAt string         `json:"generatedAt"`
+	Total       int            `json:"total"`
+	Offset      int            `json:"offset"`
+	Limit       int            `json:"limit"`
+	Results     []SearchResult `json:"results"`
+}
+
+// resourceTermRank ranks how one lowercase search term matches a resource:
+// exact name first, then name prefix and substring, kind, namespace, labels
+// (key=value, key or value) and annotation values. Returns -1 when the term
+// doesn't match.
+func resourceTermRank(res *LightResource, term string) (int, string) {
+	name := strings.ToLower(res.Name)
+	switch {
+	case name == term:
+		return 0, "name"
+	case strings.HasPrefix(name, term):
+		return 1, "name"
+	case strings.Contains(name, term):
+		return 2, "name"
+	case strings.ToLower(res.Kind) == term:
+		return 3, "kind"
+	case strings.ToLower(res.Namespace) == term:
+		return 4, "namespace"
+	}
+	key, value, pair := strings.Cut(term, "=")
+	for k, v := range res.Labels {
+		k, v = strings.ToLower(k), strings.ToLower(v)
+		if (pair && k == key && v == value) || (!pair && (k == term || v == term)) {
+			return 5, "label"
+		}
+	}
+	for k, v := range res.Labels {
+		if !pair && (strings.Contains(strings.ToLower(k), term) || strings.Contains(strings.ToLower(v), term)) {
+			return 6, "label"
+		}
+	}
+	for _, v := range res.Annotations {
+		if strings.Contains(strings.ToLower(v), term) {
+			return 7, "annotation"
+		}
+	}
+	return -1, ""
+}
+
+// resourceSearchRank ranks a resource against every term of a query, all of
+// which must match; the field reported is the first term's
+func resourceSearchRank(res *LightResource, terms []string) (int, string) {
+	total, match := 0, ""
+	for i, term := range terms {
+		rank, field := resourceTermRank(res, term)
+		if rank < 0 {
+			return -1, ""
+		}
+		total += rank
+		if i == 0 {
+			match = field
+		}
+	}
+	return total, match
+}
+
+// queryInt reads a non-negative integer parameter, def when unset
+func queryInt(r *http.Request, name string, def int) (int, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(v)
+	return n, err == nil && n >= 0
+}
+
+// searchPage bounds the page of total matches starting at offset. An offset
+// past the last match yields an empty page.
+func searchPage(total, offset, limit int) (start, end int) {
+	if offset >= total {
+		return total, total
+	}
+	return offset, offset + min(limit, total-offset)
+}
+
+// HandleSearch serves /api/cluster/search?q=&kind=&namespace=&limit=&offset=
+// over the caller's view of the cached graph. Whitespace separates terms that
+// must all match, "key=value" matches a label; best matches come first.
+// Resources of system namespaces are left out unless ?includeSystem=true.
+func HandleSearch(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	terms := strings.Fields(strings.ToLower(q.Get("q")))
+	if len(terms) == 0 {
+		i18n.Error(w, r, http.StatusBadRequest, "error.required", "q")
+		return
+	}
+	limit, ok := queryInt(r, "limit", 50)
+	if !ok || limit == 0 || limit > maxSearchLimit {
+		i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "limit")
+		return
+	}
+	offset, ok := queryInt(r, "offset", 0)
+	if !ok {
+		i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "offset")
+		return
+	}
+	kinds := make(map[string]bool)
+	for _, k := range q["kind"] {
+		kinds[k] = true
+	}
+	namespace := q.Get("namespace")
+	includeSystem := q.Get("includeSystem") == "true"
+
+	graph, fetchedAt, err := cachedGraph(config)
+	if graph == nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	scope := auth.ScopeFromContext(r.Context())
+
+	type ranked struct {
+		res   *LightResource
+		rank  int
+		match string
+	}
+	var matches []ranked
+	for i := range graph.Resources {
+		res := &graph.Resources[i]
+		if !resourceAllowed(scope, res) || (len(kinds) > 0 && !kinds[res.Kind]) || (namespace != "" && res.Namespace != namespace) ||
+			(!includeSystem && isSystemResource(res)) {
+			continue
+		}
+		if rank, match := resourceSearchRank(res, terms); rank >= 0 {
+			matches = append(matches, ranked{res, rank, match})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		if a.res.Kind != b.res.Kind {
+			return a.res.Kind < b.res.Kind
+		}
+		if a.res.Namespace != b.res.Namespace {
+			return a.res.Namespace < b.res.Namespace
+		}
+		return a.res.Name < b.res.Name
+	})
+
+	response := SearchResponse{
+		Query:       q.Get("q"),
+		GeneratedAt: fetchedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		Total:       len(matches),
+		Offset:      offset,
+		Limit:       limit,
+		Results:     []SearchResult{},
+	}
+	start, end := searchPage(len(matches), offset, limit)
+	for _, m := range matches[start:end] {
+		response.Results = append(response.Results, SearchResult{
+			ImpactResource: impactResource(m.res),
+			Status:         m.res.Status,
+			Health:         m.res.Health,
+			Match:          m.match,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
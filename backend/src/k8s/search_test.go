@@ -0,0 +1,30 @@
+package k8s
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSearchPage(t *testing.T) {
+	tests := []struct {
+		total, offset, limit int
+		start, end           int
+	}{
+		{0, 0, 50, 0, 0},
+		{10, 0, 50, 0, 10},
+		{100, 0, 50, 0, 50},
+		{100, 50, 50, 50, 100},
+		{100, 90, 50, 90, 100},
+		{100, 100, 50, 100, 100},
+		{100, 150, 50, 100, 100},
+		{100, math.MaxInt, 50, 100, 100},
+		{100, math.MaxInt - 10, maxSearchLimit, 100, 100},
+		{100, 99, maxSearchLimit, 99, 100},
+	}
+	for _, tt := range tests {
+		start, end := searchPage(tt.total, tt.offset, tt.limit)
+		if start != tt.start || end != tt.end {
+			t.Errorf("searchPage(%d, %d, %d) = [%d:%d], want [%d:%d]", tt.total, tt.offset, tt.limit, start, end, tt.start, tt.end)
+		}
+	}
+}
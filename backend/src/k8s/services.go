@@ -0,0 +1,74 @@
+package k8s
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// loadBalancerGrace is how long a LoadBalancer Service may wait for its
+// address before it is reported: cloud providers take a minute or two
+const loadBalancerGrace = 5 * time.Minute
+
+// ServiceInfo sums up how a Service is exposed
+type ServiceInfo struct {
+	Type      string        `json:"type"`
+	ClusterIP string        `json:"clusterIP,omitempty"`
+	Ports     []ServicePort `json:"ports,omitempty"`
+	// ExternalIPs are the spec's external IPs; LoadBalancer the IPs and
+	// hostnames the load balancer got, in status.loadBalancer.ingress
+	ExternalIPs  []string `json:"externalIPs,omitempty"`
+	LoadBalancer []string `json:"loadBalancer,omitempty"`
+}
+
+// ServicePort is one port of a Service. NodePort is set for NodePort and
+// LoadBalancer Services.
+type ServicePort struct {
+	Name       string `json:"name,omitempty"`
+	Protocol   string `json:"protocol"`
+	Port       int32  `json:"port"`
+	TargetPort string `json:"targetPort,omitempty"`
+	NodePort   int32  `json:"nodePort,omitempty"`
+}
+
+func serviceInfo(s *corev1.Service) *ServiceInfo {
+	info := &ServiceInfo{
+		Type:        string(s.Spec.Type),
+		ClusterIP:   s.Spec.ClusterIP,
+		ExternalIPs: s.Spec.ExternalIPs,
+	}
+	if info.Type == "" {
+		info.Type = string(corev1.ServiceTypeClusterIP)
+	}
+	for _, p := range s.Spec.Ports {
+		port := ServicePort{Name: p.Name, Protocol: string(p.Protocol), Port: p.Port, NodePort: p.NodePort}
+		if target := p.TargetPort.String(); target != "0" && target != "" {
+			port.TargetPort = target
+		}
+		info.Ports = append(info.Ports, port)
+	}
+	for _, ingress := range s.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			info.LoadBalancer = append(info.LoadBalancer, ingress.IP)
+		}
+		if ingress.Hostname != "" {
+			info.LoadBalancer = append(info.LoadBalancer, ingress.Hostname)
+		}
+	}
+	return info
+}
+
+// applyLoadBalancerPending reports a LoadBalancer Service still without an
+// address once loadBalancerGrace has passed since its creation
+func applyLoadBalancerPending(s *corev1.Service, res *LightResource) {
+	if s.Spec.Type != corev1.ServiceTypeLoadBalancer || len(res.Service.LoadBalancer) > 0 {
+		return
+	}
+	res.Status = "Pending"
+	due := s.CreationTimestamp.Add(loadBalancerGrace)
+	if time.Now().After(due) {
+		res.Health = "warning"
+		res.StaleSince = formatTimestamp(metav1.NewTime(due))
+	}
+}
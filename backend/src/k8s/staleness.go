@@ -0,0 +1,79 @@
+package k8s
+
+import (
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UnhealthyTracker remembers when resources were first seen unhealthy, for
+// kinds whose status carries no condition telling since when
+type UnhealthyTracker struct {
+	mu        sync.Mutex
+	since     map[string]time.Time // resource UID -> first seen unhealthy
+	lastSeen  map[string]time.Time
+	lastPrune time.Time
+}
+
+// unhealthyForget drops resources neither observed nor deleted for this long,
+// e.g. ones that vanished while no watch was open
+const unhealthyForget = time.Hour
+
+var unhealthyTracker = &UnhealthyTracker{
+	since:    make(map[string]time.Time),
+	lastSeen: make(map[string]time.Time),
+}
+
+// Observe fills res.StaleSince for unhealthy resources that don't have it
+// from their conditions, and resets the clock once the resource is healthy
+func (t *UnhealthyTracker) Observe(res *LightResource) {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now.Sub(t.lastPrune) > unhealthyForget/4 {
+		for id, seen := range t.lastSeen {
+			if now.Sub(seen) > unhealthyForget {
+				delete(t.since, id)
+				delete(t.lastSeen, id)
+			}
+		}
+		t.lastPrune = now
+	}
+
+	if res.Health == "" || res.Health == "ok" {
+		delete(t.since, res.ID)
+		delete(t.lastSeen, res.ID)
+		return
+	}
+	t.lastSeen[res.ID] = now
+	if res.StaleSince != "" {
+		return
+	}
+	since, ok := t.since[res.ID]
+	if !ok {
+		since = now
+		t.since[res.ID] = since
+	}
+	res.StaleSince = since.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+// Forget drops a deleted resource
+func (t *UnhealthyTracker) Forget(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.since, id)
+	delete(t.lastSeen, id)
+}
+
+// latestTransition keeps the later of a formatted timestamp and a condition time
+func latestTransition(current string, t metav1.Time) string {
+	if t.IsZero() {
+		return current
+	}
+	if formatted := formatTimestamp(t); formatted > current {
+		return formatted
+	}
+	return current
+}
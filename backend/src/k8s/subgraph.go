@@ -0,0 +1,127 @@
+package k8s
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	"k8s.io/client-go/rest"
+)
+
+// argoTrackingAnnotation is set by ArgoCD's annotation tracking, as
+// <app>:<group>/<kind>:<namespace>/<name>
+const argoTrackingAnnotation = "argocd.argoproj.io/tracking-id"
+
+// SubgraphResponse is served by /api/cluster/subgraph. Root is the
+// HelmRelease or Application node, when the graph has it.
+type SubgraphResponse struct {
+	GeneratedAt string          `json:"generatedAt"`
+	Root        *ImpactResource `json:"root,omitempty"`
+	Resources   []LightResource `json:"resources"`
+	Links       []ClusterLink   `json:"links"`
+}
+
+// helmReleaseMember reports whether res belongs to the Helm release, by its
+// labels or the stored manifest
+func helmReleaseMember(res *LightResource, namespace, name string) bool {
+	return res.HelmRelease != nil && res.HelmRelease.ReleaseNamespace == namespace && res.HelmRelease.ReleaseName == name
+}
+
+// argoAppMember reports whether res is tracked by the ArgoCD Application,
+// through the tracking annotation or the app.kubernetes.io/instance label.
+// Applications outside the ArgoCD namespace track as <namespace>_<name>.
+func argoAppMember(res *LightResource, namespace, name string) bool {
+	if res.Kind == "Application" {
+		return res.Namespace == namespace && res.Name == name
+	}
+	qualified := namespace + "_" + name
+	if id := res.Annotations[argoTrackingAnnotation]; id != "" {
+		app, _, _ := strings.Cut(id, ":")
+		return app == name || app == qualified
+	}
+	instance := res.Labels["app.kubernetes.io/instance"]
+	return instance != "" && (instance == name || instance == qualified)
+}
+
+// HandleSubgraph serves /api/cluster/subgraph?helmRelease=<ns>/<name> (or
+// ?argoApp=<ns>/<name>): the resources of a Helm release or ArgoCD
+// Application, the objects they own down the ownerReference tree, and the
+// links between them, taken from the cached graph.
+func HandleSubgraph(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	param, rootKind, member := "helmRelease", "HelmRelease", helmReleaseMember
+	if q.Get(param) == "" {
+		param, rootKind, member = "argoApp", "Application", argoAppMember
+	}
+	value := q.Get(param)
+	if value == "" {
+		i18n.Error(w, r, http.StatusBadRequest, "error.requiredOneOf", "helmRelease, argoApp")
+		return
+	}
+	namespace, name, ok := strings.Cut(value, "/")
+	if !ok || namespace == "" || name == "" {
+		i18n.Error(w, r, http.StatusBadRequest, "error.invalid", param)
+		return
+	}
+	if !auth.RequireNamespace(w, r, namespace) {
+		return
+	}
+
+	graph, fetchedAt, err := cachedGraph(config)
+	if graph == nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := SubgraphResponse{GeneratedAt: fetchedAt.UTC().Format("2006-01-02T15:04:05Z")}
+	kept := make(map[string]bool)
+	children := make(map[string][]string)
+	var queue []string
+	for i := range graph.Resources {
+		res := &graph.Resources[i]
+		for _, owner := range res.OwnerRefs {
+			children[owner] = append(children[owner], res.ID)
+		}
+		if !member(res, namespace, name) {
+			continue
+		}
+		if res.Kind == rootKind && res.Namespace == namespace && response.Root == nil {
+			root := impactResource(res)
+			response.Root = &root
+		}
+		kept[res.ID] = true
+		queue = append(queue, res.ID)
+	}
+	if len(kept) == 0 {
+		i18n.Error(w, r, http.StatusNotFound, "error.resourceNotFound")
+		return
+	}
+
+	// Pods, ReplicaSets and the like don't carry the release or app marks;
+	// they come with their owners
+	for len(queue) > 0 {
+		var next []string
+		for _, parent := range queue {
+			for _, child := range children[parent] {
+				if !kept[child] {
+					kept[child] = true
+					next = append(next, child)
+				}
+			}
+		}
+		queue = next
+	}
+
+	resources := make([]LightResource, 0, len(kept))
+	for i := range graph.Resources {
+		if kept[graph.Resources[i].ID] {
+			resources = append(resources, graph.Resources[i])
+		}
+	}
+	response.Resources, response.Links = filterByScope(resources, linksWithin(resources, graph.Links), auth.ScopeFromContext(r.Context()))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
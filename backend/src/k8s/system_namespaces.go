@@ -0,0 +1,62 @@
+package k8s
+
+import (
+	"path"
+	"sync"
+)
+
+// DefaultSystemNamespaces are hidden from init and watch unless a request
+// passes includeSystem=true
+var DefaultSystemNamespaces = []string{"kube-system", "kube-public", "kube-node-lease"}
+
+var systemNamespaces = struct {
+	sync.RWMutex
+	patterns []string
+}{patterns: DefaultSystemNamespaces}
+
+// ConfigureSystemNamespaces replaces the system namespace set. Entries may
+// be shell globs (e.g. "openshift-*"); an empty set hides nothing.
+func ConfigureSystemNamespaces(patterns []string) {
+	systemNamespaces.Lock()
+	defer systemNamespaces.Unlock()
+	systemNamespaces.patterns = patterns
+}
+
+// isSystemNamespace reports whether namespace is in the system set
+func isSystemNamespace(namespace string) bool {
+	if namespace == "" {
+		return false
+	}
+	systemNamespaces.RLock()
+	defer systemNamespaces.RUnlock()
+	for _, pattern := range systemNamespaces.patterns {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isSystemResource reports whether a resource lives in, or is, a system
+// namespace
+func isSystemResource(res *LightResource) bool {
+	if res.Kind == "Namespace" {
+		return isSystemNamespace(res.Name)
+	}
+	return isSystemNamespace(res.Namespace)
+}
+
+// withoutSystem drops resources of system namespaces, every link touching
+// them and the cluster RBAC left bound to nothing else
+func withoutSystem(resources []LightResource, links []ClusterLink) ([]LightResource, []ClusterLink) {
+	kept := make([]LightResource, 0, len(resources))
+	for i := range resources {
+		if !isSystemResource(&resources[i]) {
+			kept = append(kept, resources[i])
+		}
+	}
+	if len(kept) == len(resources) {
+		return resources, links
+	}
+	return withoutUnbound(kept, linksWithin(kept, links))
+}
@@ -2,16 +2,36 @@ package k8s
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"sync"
+	"time"
 
+	"github.com/anakosmos/backend/src/activity"
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
 	"github.com/gorilla/websocket"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+)
+
+const (
+	// execPingInterval is how often the exec socket is pinged; the stream is
+	// torn down when no pong (or other frame) arrives within execPongWait
+	execPingInterval = 15 * time.Second
+	execPongWait     = 45 * time.Second
+)
+
+var (
+	errClientDisconnected = errors.New("client disconnected")
+	errClientUnresponsive = errors.New("client stopped responding to keep-alive pings")
 )
 
 var upgrader = websocket.Upgrader{
@@ -20,11 +40,34 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-// TerminalSession implements a simplified PtyHandler
+// ExecMessage is the JSON envelope exchanged on the exec socket when the
+// ProtocolExecV1 subprotocol is negotiated.
+//
+// Server -> client: "stdout"/"stderr" carry Data, "exit" carries Code (and
+// Reason when the command could not complete normally).
+// Client -> server: "stdin" carries Data, "resize" carries Cols/Rows.
+//
+// Legacy clients (no subprotocol) exchange raw text frames instead: stdin in,
+// merged output out, with no resize or exit messages.
+type ExecMessage struct {
+	Type   string `json:"type"`
+	Data   string `json:"data,omitempty"`
+	Code   *int   `json:"code,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	Cols   uint16 `json:"cols,omitempty"`
+	Rows   uint16 `json:"rows,omitempty"`
+}
+
+// TerminalSession adapts the exec socket to the remotecommand streams. Its
+// cancel func ends the exec stream when the socket goes away.
 type TerminalSession struct {
 	ws       *websocket.Conn
+	writeMu  sync.Mutex
 	sizeChan chan remotecommand.TerminalSize
 	doneChan chan struct{}
+	cancel   context.CancelCauseFunc
+	framed   bool   // ProtocolExecV1 envelopes rather than raw text frames
+	pending  []byte // stdin data not yet consumed by Read
 }
 
 func (t *TerminalSession) Next() *remotecommand.TerminalSize {
@@ -36,45 +79,155 @@ func (t *TerminalSession) Next() *remotecommand.TerminalSize {
 	}
 }
 
+// Read returns stdin data, handling resize messages in between
 func (t *TerminalSession) Read(p []byte) (int, error) {
-	_, message, err := t.ws.ReadMessage()
-	if err != nil {
-		return 0, err
+	for len(t.pending) == 0 {
+		_, message, err := t.ws.ReadMessage()
+		if err != nil {
+			// A dropped socket must also end the SPDY stream, which doesn't
+			// stop on stdin EOF alone
+			var netErr interface{ Timeout() bool }
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				t.cancel(errClientUnresponsive)
+			} else {
+				t.cancel(errClientDisconnected)
+			}
+			return 0, err
+		}
+		t.ws.SetReadDeadline(time.Now().Add(execPongWait))
+		if !t.framed {
+			t.pending = message
+			continue
+		}
+		var msg ExecMessage
+		if err := json.Unmarshal(message, &msg); err != nil {
+			continue
+		}
+		switch msg.Type {
+		case "stdin":
+			t.pending = []byte(msg.Data)
+		case "resize":
+			select {
+			case t.sizeChan <- remotecommand.TerminalSize{Width: msg.Cols, Height: msg.Rows}:
+			case <-t.doneChan:
+			default:
+				// Nobody is waiting for a size (non-TTY); drop it
+			}
+		}
 	}
-	copy(p, message)
-	return len(message), nil
+	n := copy(p, t.pending)
+	t.pending = t.pending[n:]
+	return n, nil
 }
 
-func (t *TerminalSession) Write(p []byte) (int, error) {
-	err := t.ws.WriteMessage(websocket.TextMessage, p)
-	if err != nil {
+func (t *TerminalSession) send(msg ExecMessage) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if !t.framed {
+		if msg.Type != "stdout" && msg.Type != "stderr" {
+			return nil
+		}
+		return t.ws.WriteMessage(websocket.TextMessage, []byte(msg.Data))
+	}
+	return t.ws.WriteJSON(msg)
+}
+
+// stream returns a writer framing output for one channel
+func (t *TerminalSession) stream(channel string) *execStream {
+	return &execStream{session: t, channel: channel}
+}
+
+type execStream struct {
+	session *TerminalSession
+	channel string
+}
+
+func (s *execStream) Write(p []byte) (int, error) {
+	if err := s.session.send(ExecMessage{Type: s.channel, Data: string(p)}); err != nil {
 		return 0, err
 	}
 	return len(p), nil
 }
 
-func HandleExec(config *rest.Config, w http.ResponseWriter, r *http.Request) {
-	// ... logic from terminal.go ...
-	// Since we need to access query params, etc.
-	// This function was originally handling everything.
-	// We will just copy the body of handleExec here.
+// keepAlive pings the client until the session ends, cancelling the stream
+// when a ping can't be written
+func (t *TerminalSession) keepAlive() {
+	ticker := time.NewTicker(execPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.doneChan:
+			return
+		case <-ticker.C:
+			if err := t.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second)); err != nil {
+				t.cancel(errClientUnresponsive)
+				return
+			}
+		}
+	}
+}
 
-	namespace := r.URL.Query().Get("namespace")
-	pod := r.URL.Query().Get("pod")
-	container := r.URL.Query().Get("container")
-	shell := r.URL.Query().Get("shell")
-	if shell == "" {
-		shell = "sh"
+// exitMessage turns the stream result into the final control message. When the
+// stream was cancelled, cause explains why.
+func exitMessage(err, cause error) ExecMessage {
+	code := 0
+	msg := ExecMessage{Type: "exit", Code: &code}
+	if err == nil {
+		return msg
+	}
+	var exitErr exec.CodeExitError
+	if errors.As(err, &exitErr) {
+		code = exitErr.ExitStatus()
+		return msg
+	}
+	// The command didn't report a status (disconnect, connection or API error)
+	code = -1
+	msg.Reason = err.Error()
+	if cause != nil && !errors.Is(cause, context.Canceled) {
+		msg.Reason = cause.Error()
 	}
+	return msg
+}
+
+// HandleExec runs a command in a pod container over the WebSocket.
+// ?command= (repeatable) runs a command instead of an interactive shell, and
+// ?tty=false disables the TTY so stdout and stderr stay separate.
+func HandleExec(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	namespace := q.Get("namespace")
+	pod := q.Get("pod")
+	container := q.Get("container")
+	command := q["command"]
+	if len(command) == 0 {
+		shell := q.Get("shell")
+		if shell == "" {
+			shell = "sh"
+		}
+		command = []string{shell}
+	}
+	tty := q.Get("tty") != "false"
 
 	if namespace == "" || pod == "" {
-		http.Error(w, "Missing namespace or pod", http.StatusBadRequest)
+		i18n.Error(w, r, http.StatusBadRequest, "error.required", "namespace, pod")
+		return
+	}
+	if !auth.RequireNamespaceFor(w, r, namespace, "exec") {
+		return
+	}
+	policyReq := auth.NewPolicyRequest(r, "exec", "Pod", namespace, pod)
+	policyReq.Command = command
+	if decision := auth.Authorize(r.Context(), policyReq); !decision.Allowed {
+		activity.Denied(r, policyReq, decision)
+		http.Error(w, decision.Denied(), http.StatusForbidden)
 		return
 	}
+	if grant := auth.ActiveGrant(r.Context(), policyReq); grant != nil {
+		activity.BreakGlass(r, policyReq, grant)
+	}
 
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		http.Error(w, "Failed to create client", http.StatusInternalServerError)
+		i18n.Error(w, r, http.StatusInternalServerError, "error.clientFailed")
 		return
 	}
 
@@ -86,11 +239,11 @@ func HandleExec(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 
 	req.VersionedParams(&corev1.PodExecOptions{
 		Container: container,
-		Command:   []string{shell},
+		Command:   command,
 		Stdin:     true,
 		Stdout:    true,
-		Stderr:    true,
-		TTY:       true,
+		Stderr:    !tty, // with a TTY stderr is merged into stdout by the runtime
+		TTY:       tty,
 	}, scheme.ParameterCodec)
 
 	executor, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
@@ -99,28 +252,61 @@ func HandleExec(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ws, err := upgrader.Upgrade(w, r, nil)
+	ws, protocol, err := upgradeWebSocket(w, r, ProtocolExecV1)
 	if err != nil {
 		log.Println("Upgrade error:", err)
 		return
 	}
 	defer ws.Close()
 
+	// The exec stream lives only as long as the socket is healthy
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
 	session := &TerminalSession{
 		ws:       ws,
-		sizeChan: make(chan remotecommand.TerminalSize),
+		sizeChan: make(chan remotecommand.TerminalSize, 1),
 		doneChan: make(chan struct{}),
+		cancel:   cancel,
+		framed:   protocol == ProtocolExecV1,
 	}
+	defer close(session.doneChan)
 
-	err = executor.StreamWithContext(context.Background(), remotecommand.StreamOptions{
-		Stdin:  session,
-		Stdout: session,
-		Stderr: session,
-		Tty:    true,
+	ws.SetReadDeadline(time.Now().Add(execPongWait))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(execPongWait))
 	})
+	go session.keepAlive()
+
+	opts := remotecommand.StreamOptions{
+		Stdin:  session,
+		Stdout: session.stream("stdout"),
+		Tty:    tty,
+	}
+	if tty {
+		opts.TerminalSizeQueue = session
+	} else {
+		opts.Stderr = session.stream("stderr")
+	}
 
+	err = executor.StreamWithContext(ctx, opts)
+	cause := context.Cause(ctx)
 	if err != nil {
-		log.Println("Stream error:", err)
-		ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("Error: %v", err)))
+		log.Printf("Exec %s/%s ended: %v (cause: %v)", namespace, pod, err, cause)
+	}
+	exit := exitMessage(err, cause)
+	if errors.Is(cause, errClientDisconnected) || errors.Is(cause, errClientUnresponsive) {
+		return
+	}
+	session.send(exit)
+
+	reason := "exited"
+	if exit.Reason != "" {
+		reason = exit.Reason
+	}
+	// Close frame reasons are limited to 123 bytes
+	if len(reason) > 123 {
+		reason = reason[:123]
 	}
+	ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, reason), time.Now().Add(time.Second))
 }
@@ -0,0 +1,286 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	"k8s.io/client-go/rest"
+)
+
+// trafficWindow is the range Prometheus rates are computed over
+const trafficWindow = "5m"
+
+// TrafficLink is workload-to-workload traffic observed by a service mesh
+type TrafficLink struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"` // always "traffic"
+	Mesh   string `json:"mesh"` // istio or linkerd
+	// RequestRate is in requests per second over trafficWindow
+	RequestRate float64 `json:"requestRate"`
+	// ErrorRate is the fraction (0-1) of those requests that failed
+	ErrorRate float64 `json:"errorRate"`
+}
+
+// TrafficResponse is served by /api/traffic
+type TrafficResponse struct {
+	Meshes         []string      `json:"meshes"` // meshes that reported traffic
+	Window         string        `json:"window"`
+	RefreshSeconds int           `json:"refreshSeconds"`
+	UpdatedAt      string        `json:"updatedAt,omitempty"`
+	Error          string        `json:"error,omitempty"`
+	Links          []TrafficLink `json:"links"`
+}
+
+// meshQuery describes how one mesh labels the two ends of a request in its
+// Prometheus metrics
+type meshQuery struct {
+	mesh   string
+	metric string // selector without the error filter
+	errors string // extra matcher selecting failed requests
+	srcNS  string
+	src    string
+	dstNS  string
+	dst    string
+}
+
+var meshQueries = []meshQuery{
+	{
+		mesh: "istio", metric: `istio_requests_total{reporter="destination"}`, errors: `response_code=~"5.."`,
+		srcNS: "source_workload_namespace", src: "source_workload",
+		dstNS: "destination_workload_namespace", dst: "destination_workload",
+	},
+	{
+		mesh: "linkerd", metric: `response_total{direction="outbound"}`, errors: `classification="failure"`,
+		srcNS: "namespace", src: "deployment", dstNS: "dst_namespace", dst: "dst_deployment",
+	},
+	{
+		mesh: "linkerd", metric: `response_total{direction="outbound"}`, errors: `classification="failure"`,
+		srcNS: "namespace", src: "statefulset", dstNS: "dst_namespace", dst: "dst_statefulset",
+	},
+}
+
+// promQL returns the rate query of q, restricted to failed requests when
+// errorsOnly is set
+func (q meshQuery) promQL(errorsOnly bool) string {
+	selector := q.metric
+	if errorsOnly {
+		selector = strings.TrimSuffix(selector, "}") + "," + q.errors + "}"
+	}
+	return fmt.Sprintf("sum by (%s, %s, %s, %s) (rate(%s[%s]))", q.srcNS, q.src, q.dstNS, q.dst, selector, trafficWindow)
+}
+
+// trafficSample is the traffic between two workloads identified by
+// namespace/name, before resolution against the graph
+type trafficSample struct {
+	mesh     string
+	source   string
+	target   string
+	requests float64
+	errors   float64
+}
+
+// trafficCache holds the last samples read from Prometheus
+type trafficCache struct {
+	prometheusURL string
+	interval      time.Duration
+	client        *http.Client
+
+	mu        sync.Mutex
+	samples   []trafficSample
+	fetchedAt time.Time
+	err       error
+}
+
+// promVector is the part of a Prometheus instant query response we read
+type promVector struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		Result []struct {
+			Metric map[string]string `json:"metric"`
+			Value  [2]interface{}    `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+func (c *trafficCache) query(ctx context.Context, promql string) (*promVector, error) {
+	endpoint := strings.TrimRight(c.prometheusURL, "/") + "/api/v1/query?query=" + url.QueryEscape(promql)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var out promVector
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("prometheus returned %s", resp.Status)
+	}
+	if out.Status != "success" {
+		return nil, fmt.Errorf("prometheus query failed: %s", out.Error)
+	}
+	return &out, nil
+}
+
+// collect runs the queries of every mesh, keyed by source|target. Workloads
+// outside the mesh are reported as "unknown" by Istio and are dropped.
+func (c *trafficCache) collect(ctx context.Context) ([]trafficSample, error) {
+	byPair := make(map[string]*trafficSample)
+	var order []string
+	var lastErr error
+	for _, q := range meshQueries {
+		for _, errorsOnly := range []bool{false, true} {
+			vector, err := c.query(ctx, q.promQL(errorsOnly))
+			if err != nil {
+				lastErr = err
+				break
+			}
+			for _, r := range vector.Data.Result {
+				src, dst := r.Metric[q.src], r.Metric[q.dst]
+				if src == "" || dst == "" || src == "unknown" || dst == "unknown" {
+					continue
+				}
+				value, _ := r.Value[1].(string)
+				rate, err := strconv.ParseFloat(value, 64)
+				if err != nil || rate <= 0 {
+					continue
+				}
+				source := r.Metric[q.srcNS] + "/" + src
+				target := r.Metric[q.dstNS] + "/" + dst
+				key := q.mesh + "|" + source + "|" + target
+				sample, ok := byPair[key]
+				if !ok {
+					sample = &trafficSample{mesh: q.mesh, source: source, target: target}
+					byPair[key] = sample
+					order = append(order, key)
+				}
+				if errorsOnly {
+					sample.errors += rate
+				} else {
+					sample.requests += rate
+				}
+			}
+		}
+	}
+	samples := make([]trafficSample, 0, len(order))
+	for _, key := range order {
+		samples = append(samples, *byPair[key])
+	}
+	// A mesh that isn't installed just returns no series; only fail when
+	// Prometheus itself couldn't be queried
+	if len(samples) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return samples, nil
+}
+
+// Get returns the cached samples, querying Prometheus again when they are
+// older than the refresh interval. On failure the previous samples are kept.
+func (c *trafficCache) Get() ([]trafficSample, time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.fetchedAt.IsZero() && time.Since(c.fetchedAt) <= c.interval {
+		return c.samples, c.fetchedAt, c.err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	samples, err := c.collect(ctx)
+	c.fetchedAt = time.Now()
+	c.err = err
+	if err == nil {
+		c.samples = samples
+	}
+	return c.samples, c.fetchedAt, c.err
+}
+
+// trafficWorkloadKinds resolves mesh workload names, most specific first
+var trafficWorkloadKinds = []string{"Deployment", "Rollout", "StatefulSet", "DaemonSet", "Pod"}
+
+// TrafficHandler serves /api/traffic: an observed traffic link layer between
+// workloads of the local cluster, built from Istio or Linkerd metrics in
+// Prometheus and refreshed at most once per interval. Links only join
+// resources the caller may see.
+func TrafficHandler(config *rest.Config, prometheusURL string, interval time.Duration) http.HandlerFunc {
+	cache := &trafficCache{
+		prometheusURL: prometheusURL,
+		interval:      interval,
+		client:        &http.Client{Timeout: 10 * time.Second},
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if config == nil {
+			i18n.Error(w, r, http.StatusServiceUnavailable, "error.configNotLoaded")
+			return
+		}
+		if auth.RequestCluster(r) != "local" {
+			i18n.Error(w, r, http.StatusBadRequest, "error.localClusterOnly", "traffic telemetry")
+			return
+		}
+
+		samples, fetchedAt, err := cache.Get()
+		response := TrafficResponse{
+			Meshes:         []string{},
+			Window:         trafficWindow,
+			RefreshSeconds: int(interval.Seconds()),
+			Links:          []TrafficLink{},
+		}
+		if err != nil {
+			log.Printf("Traffic telemetry refresh failed: %v", err)
+			response.Error = err.Error()
+		}
+		if !fetchedAt.IsZero() {
+			response.UpdatedAt = fetchedAt.UTC().Format(time.RFC3339)
+		}
+
+		graph, _, graphErr := cachedGraph(config)
+		if graph == nil {
+			http.Error(w, graphErr.Error(), http.StatusInternalServerError)
+			return
+		}
+		resources, _ := filterByScope(graph.Resources, graph.Links, auth.ScopeFromContext(r.Context()))
+		ids := make(map[string]string)
+		for _, kind := range trafficWorkloadKinds {
+			for i := range resources {
+				res := &resources[i]
+				key := res.Namespace + "/" + res.Name
+				if res.Kind == kind && ids[key] == "" {
+					ids[key] = res.ID
+				}
+			}
+		}
+
+		meshes := make(map[string]bool)
+		for _, s := range samples {
+			source, target := ids[s.source], ids[s.target]
+			if source == "" || target == "" || source == target {
+				continue
+			}
+			link := TrafficLink{Source: source, Target: target, Type: "traffic", Mesh: s.mesh, RequestRate: s.requests}
+			if s.requests > 0 {
+				link.ErrorRate = min(s.errors/s.requests, 1)
+			}
+			response.Links = append(response.Links, link)
+			if !meshes[s.mesh] {
+				meshes[s.mesh] = true
+				response.Meshes = append(response.Meshes, s.mesh)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
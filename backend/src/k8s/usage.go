@@ -0,0 +1,144 @@
+package k8s
+
+import (
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const metricsGroup = "metrics.k8s.io"
+
+// usageEntry is what a Pod or Node consumes and what the pods of a Node
+// reserve. CPU is in millicores, memory in bytes.
+type usageEntry struct {
+	namespace string
+
+	// Usage as last reported by metrics-server; known is false without it
+	known       bool
+	cpu, memory int64
+	// Nodes: requests and limits summed over their pods
+	reserved                     bool
+	cpuRequests, cpuLimits       int64
+	memoryRequests, memoryLimits int64
+}
+
+// podReservations returns the effective CPU and memory requests and limits
+// of a pod spec, as the scheduler and quota see them
+func podReservations(spec *corev1.PodSpec) (cpuRequests, cpuLimits, memoryRequests, memoryLimits int64) {
+	usage, _ := podComputeUsage(spec)
+	return usage.Name(corev1.ResourceRequestsCPU, resource.DecimalSI).MilliValue(),
+		usage.Name(corev1.ResourceLimitsCPU, resource.DecimalSI).MilliValue(),
+		usage.Name(corev1.ResourceRequestsMemory, resource.BinarySI).Value(),
+		usage.Name(corev1.ResourceLimitsMemory, resource.BinarySI).Value()
+}
+
+// usageKey identifies a Pod or Node across graph builds and watch events
+func usageKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// metricsUsage sums the usage of PodMetrics (over containers) or NodeMetrics
+func metricsUsage(list *unstructured.UnstructuredList, kind string, into map[string]usageEntry) {
+	add := func(entry *usageEntry, usage map[string]interface{}) {
+		if cpu, ok := usage["cpu"].(string); ok {
+			if q, err := resource.ParseQuantity(cpu); err == nil {
+				entry.cpu += q.MilliValue()
+			}
+		}
+		if memory, ok := usage["memory"].(string); ok {
+			if q, err := resource.ParseQuantity(memory); err == nil {
+				entry.memory += q.Value()
+			}
+		}
+	}
+	for i := range list.Items {
+		item := &list.Items[i]
+		key := usageKey(kind, item.GetNamespace(), item.GetName())
+		entry := into[key]
+		entry.namespace = item.GetNamespace()
+		entry.known = true
+		if kind == "Node" {
+			usage, _, _ := unstructured.NestedMap(item.Object, "usage")
+			add(&entry, usage)
+		} else {
+			containers, _, _ := unstructured.NestedSlice(item.Object, "containers")
+			for _, c := range containers {
+				container, _ := c.(map[string]interface{})
+				usage, _, _ := unstructured.NestedMap(container, "usage")
+				add(&entry, usage)
+			}
+		}
+		into[key] = entry
+	}
+}
+
+// nodeReservations sums the requests and limits of the pods scheduled on
+// each node into its entry
+func nodeReservations(pods []corev1.Pod, into map[string]usageEntry) {
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		key := usageKey("Node", "", pod.Spec.NodeName)
+		entry := into[key]
+		cpuRequests, cpuLimits, memoryRequests, memoryLimits := podReservations(&pod.Spec)
+		entry.cpuRequests += cpuRequests
+		entry.cpuLimits += cpuLimits
+		entry.memoryRequests += memoryRequests
+		entry.memoryLimits += memoryLimits
+		entry.reserved = true
+		into[key] = entry
+	}
+}
+
+// usageIndex keeps the usage seen by the last graph build of each cluster
+// (API server host), so watch updates carry it like init does
+var usageIndex = struct {
+	sync.RWMutex
+	clusters map[string]map[string]usageEntry // host -> Kind/namespace/name -> usage
+}{clusters: make(map[string]map[string]usageEntry)}
+
+// recordUsage replaces the usage known for a cluster, or only that of one
+// namespace (keeping Nodes) when namespace is set
+func recordUsage(cluster, namespace string, entries map[string]usageEntry) {
+	usageIndex.Lock()
+	defer usageIndex.Unlock()
+	if namespace != "" {
+		merged := make(map[string]usageEntry, len(usageIndex.clusters[cluster])+len(entries))
+		for key, entry := range usageIndex.clusters[cluster] {
+			if entry.namespace != namespace {
+				merged[key] = entry
+			}
+		}
+		for key, entry := range entries {
+			merged[key] = entry
+		}
+		entries = merged
+	}
+	usageIndex.clusters[cluster] = entries
+}
+
+// applyUsage fills the recorded metrics-server usage of a Pod or Node, and
+// the requests and limits of the pods on a Node
+func applyUsage(cluster string, res *LightResource) {
+	if res.Kind != "Pod" && res.Kind != "Node" {
+		return
+	}
+	usageIndex.RLock()
+	entry, ok := usageIndex.clusters[cluster][usageKey(res.Kind, res.Namespace, res.Name)]
+	usageIndex.RUnlock()
+	if !ok {
+		return
+	}
+	if entry.known {
+		cpu, memory := entry.cpu, entry.memory
+		res.CPUUsage, res.MemoryUsage = &cpu, &memory
+	}
+	if entry.reserved {
+		res.CPURequests, res.CPULimits = entry.cpuRequests, entry.cpuLimits
+		res.MemoryRequests, res.MemoryLimits = entry.memoryRequests, entry.memoryLimits
+	}
+}
@@ -0,0 +1,172 @@
+package k8s
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// ClusterValidation is the result of checking a target/token pair
+type ClusterValidation struct {
+	OK      bool   `json:"ok"`
+	Host    string `json:"host"`
+	Version string `json:"version,omitempty"`
+	User    string `json:"user,omitempty"`
+	// Reason classifies failures: unauthorized, forbidden, tls, unreachable,
+	// dns, timeout or error
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// TLSWarning is set when the server certificate doesn't verify; requests
+	// still work because target connections skip verification
+	TLSWarning string `json:"tlsWarning,omitempty"`
+	CheckedAt  string `json:"checkedAt"`
+
+	status int
+}
+
+// validationTTL is how long a validation result is reused for the same pair
+const validationTTL = time.Minute
+
+var validations = struct {
+	sync.Mutex
+	results map[string]*ClusterValidation
+	at      map[string]time.Time
+}{results: make(map[string]*ClusterValidation), at: make(map[string]time.Time)}
+
+// classifyConnError maps transport and API errors to a reason, an HTTP status
+// and a message an operator can act on
+func classifyConnError(err error) (reason string, status int, message string) {
+	var unknownAuthority x509.UnknownAuthorityError
+	var certInvalid x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	var dnsErr *net.DNSError
+	var netErr net.Error
+
+	switch {
+	case apierrors.IsUnauthorized(err):
+		return "unauthorized", http.StatusUnauthorized, "401 invalid or expired token"
+	case apierrors.IsForbidden(err):
+		return "forbidden", http.StatusForbidden, "403 the token is valid but not allowed: " + err.Error()
+	case errors.As(err, &unknownAuthority):
+		return "tls", http.StatusBadGateway, "x509: certificate signed by unknown authority"
+	case errors.As(err, &hostname):
+		return "tls", http.StatusBadGateway, "x509: " + hostname.Error()
+	case errors.As(err, &certInvalid):
+		return "tls", http.StatusBadGateway, "x509: " + certInvalid.Error()
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "unreachable", http.StatusBadGateway, "connection refused"
+	case errors.As(err, &dnsErr):
+		return "dns", http.StatusBadGateway, "cannot resolve host " + dnsErr.Name
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout", http.StatusGatewayTimeout, "timed out connecting to the API server"
+	}
+	return "error", http.StatusBadGateway, err.Error()
+}
+
+// ValidateCluster checks that the API server behind config is reachable and
+// accepts its credentials. Successful results are cached per host and token;
+// failures are always rechecked so a fixed setup is picked up right away.
+func ValidateCluster(ctx context.Context, config *rest.Config) *ClusterValidation {
+	key := credentialKey(config)
+
+	validations.Lock()
+	if v, ok := validations.results[key]; ok && time.Since(validations.at[key]) < validationTTL {
+		validations.Unlock()
+		return v
+	}
+	validations.Unlock()
+
+	v := validateCluster(ctx, config)
+	if !v.OK {
+		return v
+	}
+
+	validations.Lock()
+	for k, at := range validations.at {
+		if time.Since(at) >= validationTTL {
+			delete(validations.results, k)
+			delete(validations.at, k)
+		}
+	}
+	validations.results[key] = v
+	validations.at[key] = time.Now()
+	validations.Unlock()
+	return v
+}
+
+func validateCluster(ctx context.Context, config *rest.Config) *ClusterValidation {
+	v := &ClusterValidation{Host: config.Host, CheckedAt: time.Now().UTC().Format("2006-01-02T15:04:05Z"), status: http.StatusOK}
+	fail := func(err error) *ClusterValidation {
+		v.Reason, v.status, v.Error = classifyConnError(err)
+		return v
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cfg := rest.CopyConfig(config)
+	cfg.Timeout = 10 * time.Second
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return fail(err)
+	}
+
+	// /version is often readable anonymously, so it only proves reachability
+	version, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return fail(err)
+	}
+	v.Version = version.GitVersion
+
+	// SelfSubjectReview (1.28+) proves the token and names the user; older
+	// servers fall back to a minimal authenticated list
+	review, err := clientset.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{})
+	switch {
+	case err == nil:
+		v.User = review.Status.UserInfo.Username
+	case apierrors.IsNotFound(err):
+		if _, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{Limit: 1}); err != nil {
+			return fail(err)
+		}
+	default:
+		return fail(err)
+	}
+
+	if config.Insecure {
+		strict := rest.CopyConfig(cfg)
+		strict.Insecure = false
+		if strictClient, err := kubernetes.NewForConfig(strict); err == nil {
+			if _, err := strictClient.Discovery().ServerVersion(); err != nil {
+				if reason, _, message := classifyConnError(err); reason == "tls" {
+					v.TLSWarning = message + " (verification is skipped for this target)"
+				}
+			}
+		}
+	}
+
+	v.OK = true
+	return v
+}
+
+// HandleValidateCluster serves /api/clusters/validate for the target/token
+// pair of the request, replying with the ClusterValidation and a status code
+// matching the failure (401, 403, 502, 504).
+func HandleValidateCluster(config *rest.Config, w http.ResponseWriter, r *http.Request) {
+	v := ValidateCluster(r.Context(), config)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(v.status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,63 @@
+package k8s
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// VPAInfo summarizes a VerticalPodAutoscaler's recommendation. Like HPAs,
+// VPAs link to the workload they size.
+type VPAInfo struct {
+	// UpdateMode is Off, Initial, Recreate, InPlaceOrRecreate or Auto
+	UpdateMode string `json:"updateMode"`
+	// Recommendations are the target requests per container
+	Recommendations []VPARecommendation `json:"recommendations,omitempty"`
+	// Message tells why there is no recommendation, or what is wrong
+	Message string `json:"message,omitempty"`
+}
+
+// VPARecommendation is the recommended requests of one container
+type VPARecommendation struct {
+	Container string `json:"container"`
+	CPU       string `json:"cpu,omitempty"`
+	Memory    string `json:"memory,omitempty"`
+}
+
+// applyVPAStatus fills a VerticalPodAutoscaler's target and recommendation.
+// A VPA with a recommendation is Active; one without (yet) is Pending, and
+// one whose target can't be found or sized an error.
+func applyVPAStatus(obj *unstructured.Unstructured, res *LightResource) {
+	info := &VPAInfo{UpdateMode: "Auto"}
+	if name, _, _ := unstructured.NestedString(obj.Object, "spec", "targetRef", "name"); name != "" {
+		kind, _, _ := unstructured.NestedString(obj.Object, "spec", "targetRef", "kind")
+		res.ScaleTargetRef = &ScaleTargetRef{Kind: kind, Name: name}
+	}
+	if mode, _, _ := unstructured.NestedString(obj.Object, "spec", "updatePolicy", "updateMode"); mode != "" {
+		info.UpdateMode = mode
+	}
+	recommendations, _, _ := unstructured.NestedSlice(obj.Object, "status", "recommendation", "containerRecommendations")
+	for _, r := range recommendations {
+		m, _ := r.(map[string]interface{})
+		rec := VPARecommendation{}
+		rec.Container, _, _ = unstructured.NestedString(m, "containerName")
+		rec.CPU, _, _ = unstructured.NestedString(m, "target", "cpu")
+		rec.Memory, _, _ = unstructured.NestedString(m, "target", "memory")
+		info.Recommendations = append(info.Recommendations, rec)
+	}
+	res.VPA = info
+
+	res.Status, res.Health = "Pending", "warning"
+	if len(info.Recommendations) > 0 {
+		res.Status, res.Health = "Active", "ok"
+	}
+	for _, cond := range objectConditions(obj) {
+		switch {
+		case cond.condType == "ConfigUnsupported" && cond.status == "True",
+			cond.condType == "NoPodsMatched" && cond.status == "True":
+			res.Status, res.Health = cond.condType, "error"
+			info.Message = cond.message
+			return
+		case cond.condType == "RecommendationProvided" && cond.status == "False" && info.Message == "":
+			info.Message = cond.message
+		}
+	}
+}
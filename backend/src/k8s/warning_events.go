@@ -0,0 +1,133 @@
+package k8s
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// maxWarningEvents is how many reasons are kept per resource, latest first
+	maxWarningEvents = 3
+	// warningEventMaxAge leaves out warnings not seen for a while; the
+	// cluster keeps events for an hour by default
+	warningEventMaxAge = time.Hour
+	// warningEventSelector narrows event listing and watching to warnings
+	warningEventSelector = "type=" + corev1.EventTypeWarning
+)
+
+// WarningEvent aggregates the recent Warning events of one reason on a
+// resource, e.g. FailedScheduling x12
+type WarningEvent struct {
+	Reason   string `json:"reason"`
+	Message  string `json:"message"` // most recent
+	Count    int    `json:"count"`
+	LastSeen string `json:"lastSeen"`
+
+	last time.Time
+}
+
+// warningEventEntry holds the warnings of one involved object
+type warningEventEntry struct {
+	namespace string
+	events    []WarningEvent
+}
+
+// groupWarningEvents aggregates Warning events by involved object UID and
+// reason, dropping those last seen before warningEventMaxAge
+func groupWarningEvents(events []corev1.Event) map[string]warningEventEntry {
+	cutoff := time.Now().Add(-warningEventMaxAge)
+	byUID := make(map[string]warningEventEntry)
+	for i := range events {
+		ev := &events[i]
+		if ev.Type != corev1.EventTypeWarning || ev.InvolvedObject.UID == "" {
+			continue
+		}
+		_, last, count := eventSpan(ev)
+		if last.Before(cutoff) {
+			continue
+		}
+		uid := string(ev.InvolvedObject.UID)
+		entry := byUID[uid]
+		entry.namespace = ev.InvolvedObject.Namespace
+		found := false
+		for j := range entry.events {
+			we := &entry.events[j]
+			if we.Reason != ev.Reason {
+				continue
+			}
+			found = true
+			we.Count += int(count)
+			if last.After(we.last) {
+				we.last, we.Message = last, ev.Message
+			}
+		}
+		if !found {
+			entry.events = append(entry.events, WarningEvent{Reason: ev.Reason, Message: ev.Message, Count: int(count), last: last})
+		}
+		byUID[uid] = entry
+	}
+	for uid, entry := range byUID {
+		sort.Slice(entry.events, func(i, j int) bool { return entry.events[i].last.After(entry.events[j].last) })
+		if len(entry.events) > maxWarningEvents {
+			entry.events = entry.events[:maxWarningEvents]
+		}
+		for j := range entry.events {
+			entry.events[j].LastSeen = entry.events[j].last.UTC().Format("2006-01-02T15:04:05Z")
+		}
+		byUID[uid] = entry
+	}
+	return byUID
+}
+
+// warningEventIndex keeps the warnings seen by the last graph build of each
+// cluster (API server host), so watch updates carry them like init does
+var warningEventIndex = struct {
+	sync.RWMutex
+	clusters map[string]map[string]warningEventEntry // host -> UID -> warnings
+}{clusters: make(map[string]map[string]warningEventEntry)}
+
+// recordWarningEvents replaces the warnings known for a cluster, or only
+// those of one namespace when namespace is set
+func recordWarningEvents(cluster, namespace string, events []corev1.Event) {
+	byUID := groupWarningEvents(events)
+	warningEventIndex.Lock()
+	defer warningEventIndex.Unlock()
+	if namespace != "" {
+		merged := make(map[string]warningEventEntry, len(warningEventIndex.clusters[cluster])+len(byUID))
+		for uid, entry := range warningEventIndex.clusters[cluster] {
+			if entry.namespace != namespace {
+				merged[uid] = entry
+			}
+		}
+		for uid, entry := range byUID {
+			merged[uid] = entry
+		}
+		byUID = merged
+	}
+	warningEventIndex.clusters[cluster] = byUID
+}
+
+// applyWarningEvents attaches the recorded warnings of res
+func applyWarningEvents(cluster string, res *LightResource) {
+	warningEventIndex.RLock()
+	entry, ok := warningEventIndex.clusters[cluster][res.ID]
+	warningEventIndex.RUnlock()
+	if ok {
+		res.WarningEvents = entry.events
+	}
+}
+
+// newWarningEventInformer watches only Warning events, the only ones the
+// graph shows
+func newWarningEventInformer(client kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+	return coreinformers.NewFilteredEventInformer(client, metav1.NamespaceAll, resync,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+		func(opts *metav1.ListOptions) { opts.FieldSelector = warningEventSelector })
+}
@@ -4,12 +4,13 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
 	"github.com/gorilla/websocket"
-	appsv1 "k8s.io/api/apps/v1"
-	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -23,7 +24,10 @@ import (
 type WatchEvent struct {
 	Type     string      `json:"type"` // ADDED, MODIFIED, DELETED
 	Kind     string      `json:"kind"`
-	Resource interface{} `json:"resource"`
+	Resource interface{} `json:"resource"` // *LightResource, same schema as /api/cluster/init
+	// Multi-cluster (v2) connections only
+	ClusterID string `json:"clusterId,omitempty"`
+	Error     string `json:"error,omitempty"`
 }
 
 // WatchManager handles the lifecycle of watchers for a single connection
@@ -31,39 +35,107 @@ type WatchManager struct {
 	client        *kubernetes.Clientset
 	dynamicClient dynamic.Interface
 	ws            *websocket.Conn
+	scope         auth.Scope
+	cluster       string       // API server host, keys the shared event history
+	config        *rest.Config // optional; enables discovery-driven watches
+	includeSystem bool         // also send resources of system namespaces
+	clusterID     string       // set when the connection carries several clusters
 	done          chan struct{}
+	stopped       chan struct{} // closed when sendLoop exits
 	eventChan     chan WatchEvent
 	wg            sync.WaitGroup
 	// Deduplication: track last sent state per resource to skip no-op MODIFIED events
-	lastSent   map[string]string // resourceUID -> "status|health"
+	lastSent   map[string]string // resourceUID -> stateHash
 	lastSentMu sync.RWMutex
 }
 
-func NewWatchManager(client *kubernetes.Clientset, dynamicClient dynamic.Interface, ws *websocket.Conn) *WatchManager {
+func NewWatchManager(client *kubernetes.Clientset, dynamicClient dynamic.Interface, ws *websocket.Conn, scope auth.Scope, cluster string) *WatchManager {
 	return &WatchManager{
 		client:        client,
 		dynamicClient: dynamicClient,
 		ws:            ws,
+		scope:         scope,
+		cluster:       cluster,
 		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
 		eventChan:     make(chan WatchEvent, 100),
 		lastSent:      make(map[string]string),
 	}
 }
 
 func (wm *WatchManager) Start() {
-	wm.watchResource("pods")
-	wm.watchResource("nodes")
-	wm.watchResource("services")
-	wm.watchResource("deployments")
-	wm.watchResource("statefulsets")
-	wm.watchResource("daemonsets")
-	wm.watchResource("replicasets")
-	wm.watchResource("ingresses")
-	// ArgoCD Applications (CRD) - watch if available
+	// Namespace-scoped credentials watch each permitted namespace and skip
+	// cluster-scoped kinds
+	namespaces := []string{""}
+	if wm.config != nil {
+		go probeOnContact(wm.config)
+		if permitted := permittedNamespaces(context.Background(), wm.config); permitted != nil {
+			namespaces = permitted
+		}
+	}
+	clusterScoped := len(namespaces) == 1 && namespaces[0] == ""
+	if clusterScoped {
+		wm.watchResource("namespaces", "")
+		wm.watchResource("nodes", "")
+	}
+	for _, ns := range namespaces {
+		wm.watchResource("pods", ns)
+		wm.watchResource("services", ns)
+		wm.watchResource("deployments", ns)
+		wm.watchResource("statefulsets", ns)
+		wm.watchResource("daemonsets", ns)
+		wm.watchResource("replicasets", ns)
+		if wm.serves("networking.k8s.io", "v1", "ingresses") {
+			wm.watchResource("ingresses", ns)
+		}
+	}
+	// ArgoCD Applications, ApplicationSets and AppProjects, Argo Rollouts, KEDA
+	// ScaledObjects, VerticalPodAutoscalers, Flagger Canaries, Gateway API HTTPRoutes, Gateways and GatewayClasses,
+	// cert-manager Certificates and issuers, Flux sources and reconcilers,
+	// Cluster API topology, Istio routing and prometheus-operator monitors and
+	// rules (CRDs) - watch if available
 	if wm.dynamicClient != nil {
-		wm.watchCRD("applications", "argoproj.io", "v1alpha1", "Application")
+		if clusterScoped {
+			wm.watchDefinitions()
+		}
+		for _, ns := range namespaces {
+			wm.watchCRD("applications", "argoproj.io", "v1alpha1", "Application", ns)
+			wm.watchCRD("applicationsets", "argoproj.io", "v1alpha1", "ApplicationSet", ns)
+			wm.watchCRD("appprojects", "argoproj.io", "v1alpha1", "AppProject", ns)
+			wm.watchCRD("rollouts", "argoproj.io", "v1alpha1", "Rollout", ns)
+			wm.watchCRD("scaledobjects", "keda.sh", "v1alpha1", "ScaledObject", ns)
+			wm.watchCRD("verticalpodautoscalers", "autoscaling.k8s.io", "v1", "VerticalPodAutoscaler", ns)
+			wm.watchCRD("canaries", "flagger.app", "v1beta1", "Canary", ns)
+			wm.watchCRD("httproutes", "gateway.networking.k8s.io", "v1", "HTTPRoute", ns)
+			wm.watchCRD("gateways", "gateway.networking.k8s.io", "v1", "Gateway", ns)
+			wm.watchCRD("certificates", certManagerGroup, "v1", "Certificate", ns)
+			wm.watchCRD("issuers", certManagerGroup, "v1", "Issuer", ns)
+			for _, fk := range fluxKinds {
+				wm.watchCRD(fk.resource, fk.group, wm.watchVersion(fk), fk.kind, ns)
+			}
+			for _, ck := range capiKinds {
+				wm.watchCRD(ck.resource, ck.group, wm.watchVersion(ck), ck.kind, ns)
+			}
+			for _, ik := range istioKinds {
+				wm.watchCRD(ik.resource, ik.group, wm.watchVersion(ik), ik.kind, ns)
+			}
+			for _, mk := range monitoringKinds {
+				wm.watchCRD(mk.resource, mk.group, wm.watchVersion(mk), mk.kind, ns)
+			}
+		}
+		if clusterScoped {
+			wm.watchCRD("gatewayclasses", "gateway.networking.k8s.io", "v1", "GatewayClass", "")
+			wm.watchCRD("clusterissuers", certManagerGroup, "v1", "ClusterIssuer", "")
+		}
+	}
+	if wm.config != nil {
+		wm.wg.Add(1)
+		go wm.followDrills()
+	}
+	// Cluster managers of a multi-cluster connection share the socket's sender
+	if wm.ws != nil {
+		go wm.sendLoop()
 	}
-	go wm.sendLoop()
 }
 
 func (wm *WatchManager) Stop() {
@@ -74,6 +146,7 @@ func (wm *WatchManager) Stop() {
 func (wm *WatchManager) sendLoop() {
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
+	defer close(wm.stopped)
 
 	for {
 		select {
@@ -92,7 +165,8 @@ func (wm *WatchManager) sendLoop() {
 	}
 }
 
-func (wm *WatchManager) watchResource(resource string) {
+// watchResource watches a built-in kind in namespace ("" for all)
+func (wm *WatchManager) watchResource(resource, namespace string) {
 	wm.wg.Add(1)
 	go func() {
 		defer wm.wg.Done()
@@ -115,27 +189,33 @@ func (wm *WatchManager) watchResource(resource string) {
 			}
 
 			switch resource {
+			case "namespaces":
+				kind = "Namespace"
+				watcher, err = wm.client.CoreV1().Namespaces().Watch(ctx, listOpts)
 			case "pods":
 				kind = "Pod"
-				watcher, err = wm.client.CoreV1().Pods("").Watch(ctx, listOpts)
+				watcher, err = wm.client.CoreV1().Pods(namespace).Watch(ctx, listOpts)
 			case "nodes":
 				kind = "Node"
 				watcher, err = wm.client.CoreV1().Nodes().Watch(ctx, listOpts)
 			case "services":
 				kind = "Service"
-				watcher, err = wm.client.CoreV1().Services("").Watch(ctx, listOpts)
+				watcher, err = wm.client.CoreV1().Services(namespace).Watch(ctx, listOpts)
 			case "deployments":
 				kind = "Deployment"
-				watcher, err = wm.client.AppsV1().Deployments("").Watch(ctx, listOpts)
+				watcher, err = wm.client.AppsV1().Deployments(namespace).Watch(ctx, listOpts)
 			case "statefulsets":
 				kind = "StatefulSet"
-				watcher, err = wm.client.AppsV1().StatefulSets("").Watch(ctx, listOpts)
+				watcher, err = wm.client.AppsV1().StatefulSets(namespace).Watch(ctx, listOpts)
 			case "daemonsets":
 				kind = "DaemonSet"
-				watcher, err = wm.client.AppsV1().DaemonSets("").Watch(ctx, listOpts)
+				watcher, err = wm.client.AppsV1().DaemonSets(namespace).Watch(ctx, listOpts)
 			case "replicasets":
 				kind = "ReplicaSet"
-				watcher, err = wm.client.AppsV1().ReplicaSets("").Watch(ctx, listOpts)
+				watcher, err = wm.client.AppsV1().ReplicaSets(namespace).Watch(ctx, listOpts)
+			case "ingresses":
+				kind = "Ingress"
+				watcher, err = wm.client.NetworkingV1().Ingresses(namespace).Watch(ctx, listOpts)
 			}
 
 			if err != nil {
@@ -163,8 +243,134 @@ func (wm *WatchManager) watchResource(resource string) {
 	}()
 }
 
-// watchCRD watches a Custom Resource Definition using the dynamic client
-func (wm *WatchManager) watchCRD(resource, group, version, kind string) {
+// followDrills re-sends the resource of a drill that starts or ends, so open
+// views show the overridden health and its recovery without waiting for a
+// real change. Drills making the cluster unreachable send an error event.
+func (wm *WatchManager) followDrills() {
+	defer wm.wg.Done()
+	changes, unsubscribe := subscribeDrills()
+	defer unsubscribe()
+	for {
+		select {
+		case <-wm.done:
+			return
+		case d := <-changes:
+			if d.host != strings.TrimRight(wm.cluster, "/") {
+				continue
+			}
+			if d.Unreachable {
+				if d.active(time.Now()) {
+					event := WatchEvent{Type: "ERROR", ClusterID: wm.clusterID, Error: d.unreachableError().Error()}
+					select {
+					case wm.eventChan <- event:
+					case <-wm.done:
+						return
+					}
+				}
+				continue
+			}
+			graph, _, err := cachedGraph(wm.config)
+			if err != nil || graph == nil {
+				continue
+			}
+			for i := range graph.Resources {
+				if !d.matches(&graph.Resources[i]) {
+					continue
+				}
+				res := graph.Resources[i]
+				if !wm.emit(string(watch.Modified), res.Kind, &res) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// serves reports whether the watched cluster serves a resource, according to
+// the cached discovery document
+func (wm *WatchManager) serves(group, version, resource string) bool {
+	if wm.config == nil {
+		return true
+	}
+	return clusterServes(wm.config).Has(group, version, resource)
+}
+
+// watchVersion is the version of an optional kind to watch: the served one,
+// or the newest so the watch starts once the CRD is installed
+func (wm *WatchManager) watchVersion(fk versionedKind) string {
+	if wm.config != nil {
+		if version := fk.servedVersion(clusterServes(wm.config)); version != "" {
+			return version
+		}
+	}
+	return fk.versions[0]
+}
+
+// watchDefinitions drops the cluster's cached discovery document whenever a
+// CRD is added or removed, so CRD watches and the next graph build pick up
+// APIs that appear or vanish
+func (wm *WatchManager) watchDefinitions() {
+	gvr := schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+	wm.wg.Add(1)
+	go func() {
+		defer wm.wg.Done()
+		for {
+			ctx := context.Background()
+			// Start from the current list so existing CRDs aren't replayed as added
+			list, err := wm.dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{Limit: 1})
+			var watcher watch.Interface
+			if err == nil {
+				watcher, err = wm.dynamicClient.Resource(gvr).Watch(ctx, metav1.ListOptions{ResourceVersion: list.GetResourceVersion()})
+			}
+			if err != nil {
+				log.Printf("Failed to watch CRD definitions: %v. Retrying in 60s...", err)
+				select {
+				case <-wm.done:
+					return
+				case <-time.After(60 * time.Second):
+					continue
+				}
+			}
+			wm.handleDefinitionStream(watcher)
+
+			select {
+			case <-wm.done:
+				return
+			case <-time.After(1 * time.Second):
+			}
+		}
+	}()
+}
+
+func (wm *WatchManager) handleDefinitionStream(watcher watch.Interface) {
+	defer watcher.Stop()
+	ch := watcher.ResultChan()
+	for {
+		select {
+		case <-wm.done:
+			return
+		case event, ok := <-ch:
+			if !ok || event.Type == watch.Error {
+				return
+			}
+			if crd, ok := event.Object.(*unstructured.Unstructured); ok {
+				if event.Type == watch.Deleted {
+					deletePrinterColumns(crd)
+				} else {
+					setPrinterColumns(crd)
+				}
+			}
+			if event.Type == watch.Added || event.Type == watch.Deleted {
+				invalidateDiscovery(wm.cluster)
+			}
+		}
+	}
+}
+
+// watchCRD watches a Custom Resource Definition using the dynamic client, in
+// namespace ("" for all)
+func (wm *WatchManager) watchCRD(resource, group, version, kind, namespace string) {
 	if wm.dynamicClient == nil {
 		return
 	}
@@ -186,10 +392,20 @@ func (wm *WatchManager) watchCRD(resource, group, version, kind string) {
 			default:
 			}
 
+			// Not installed: wait quietly until a CRD change refreshes discovery
+			if !wm.serves(group, version, resource) {
+				select {
+				case <-wm.done:
+					return
+				case <-time.After(30 * time.Second):
+					continue
+				}
+			}
+
 			ctx := context.Background()
 			listOpts := metav1.ListOptions{}
 
-			watcher, err := wm.dynamicClient.Resource(gvr).Namespace("").Watch(ctx, listOpts)
+			watcher, err := wm.dynamicClient.Resource(gvr).Namespace(namespace).Watch(ctx, listOpts)
 			if err != nil {
 				// CRD might not exist, just retry less frequently
 				log.Printf("Failed to watch CRD %s.%s: %v. Retrying in 30s...", resource, group, err)
@@ -239,147 +455,14 @@ func (wm *WatchManager) handleDynamicWatchStream(watcher watch.Interface, kind s
 				continue
 			}
 
-			simpleObj := wm.simplifyCRDObject(unstructuredObj, kind)
-			if simpleObj == nil {
-				continue
-			}
-
-			// Deduplication for CRD events
-			if event.Type == watch.Modified {
-				objMap, ok := simpleObj.(map[string]interface{})
-				if ok {
-					uid, _ := objMap["id"].(string)
-					status, _ := objMap["status"].(string)
-					health, _ := objMap["health"].(string)
-					stateKey := status + "|" + health
-
-					wm.lastSentMu.RLock()
-					lastState := wm.lastSent[uid]
-					wm.lastSentMu.RUnlock()
-
-					if lastState == stateKey {
-						continue
-					}
-
-					wm.lastSentMu.Lock()
-					wm.lastSent[uid] = stateKey
-					wm.lastSentMu.Unlock()
-				}
-			} else if event.Type == watch.Deleted {
-				objMap, ok := simpleObj.(map[string]interface{})
-				if ok {
-					uid, _ := objMap["id"].(string)
-					wm.lastSentMu.Lock()
-					delete(wm.lastSent, uid)
-					wm.lastSentMu.Unlock()
-				}
-			}
-
-			select {
-			case wm.eventChan <- WatchEvent{Type: string(event.Type), Kind: kind, Resource: simpleObj}:
-			case <-wm.done:
+			res := lightUnstructured(unstructuredObj, kind)
+			if !wm.emit(string(event.Type), kind, &res) {
 				return
 			}
 		}
 	}
 }
 
-// simplifyCRDObject converts an unstructured CRD object to a simple map for the frontend
-func (wm *WatchManager) simplifyCRDObject(obj *unstructured.Unstructured, kind string) interface{} {
-	metadata := obj.Object["metadata"].(map[string]interface{})
-
-	uid := getNestedString(metadata, "uid")
-	name := getNestedString(metadata, "name")
-	namespace := getNestedString(metadata, "namespace")
-	creationTimestamp := getNestedString(metadata, "creationTimestamp")
-	labels, _ := metadata["labels"].(map[string]interface{})
-
-	// Convert labels to string map
-	labelsMap := make(map[string]string)
-	for k, v := range labels {
-		if vs, ok := v.(string); ok {
-			labelsMap[k] = vs
-		}
-	}
-
-	// Get owner references
-	ownerRefs := make([]string, 0)
-	if refs, ok := metadata["ownerReferences"].([]interface{}); ok {
-		for _, ref := range refs {
-			if refMap, ok := ref.(map[string]interface{}); ok {
-				if refUID, ok := refMap["uid"].(string); ok {
-					ownerRefs = append(ownerRefs, refUID)
-				}
-			}
-		}
-	}
-
-	// Determine status based on kind
-	status := "Unknown"
-	health := "ok"
-
-	if kind == "Application" {
-		// ArgoCD Application specific status
-		statusObj, _ := obj.Object["status"].(map[string]interface{})
-		if statusObj != nil {
-			// Sync status
-			if sync, ok := statusObj["sync"].(map[string]interface{}); ok {
-				if syncStatus, ok := sync["status"].(string); ok {
-					status = syncStatus
-				}
-			}
-			// Health status
-			if healthObj, ok := statusObj["health"].(map[string]interface{}); ok {
-				if healthStatus, ok := healthObj["status"].(string); ok {
-					switch healthStatus {
-					case "Degraded", "Missing":
-						health = "error"
-					case "Progressing", "Suspended":
-						health = "warning"
-					case "Healthy":
-						health = "ok"
-					default:
-						health = "warning"
-					}
-				}
-			}
-		}
-	}
-
-	result := map[string]interface{}{
-		"id":                uid,
-		"name":              name,
-		"namespace":         namespace,
-		"kind":              kind,
-		"status":            status,
-		"health":            health,
-		"labels":            labelsMap,
-		"ownerRefs":         ownerRefs,
-		"creationTimestamp": creationTimestamp,
-	}
-
-	return result
-}
-
-// getNestedString safely gets a string from a nested map
-func getNestedString(obj map[string]interface{}, keys ...string) string {
-	current := obj
-	for i, key := range keys {
-		if i == len(keys)-1 {
-			if val, ok := current[key].(string); ok {
-				return val
-			}
-			return ""
-		}
-		if next, ok := current[key].(map[string]interface{}); ok {
-			current = next
-		} else {
-			return ""
-		}
-	}
-	return ""
-}
-
 func (wm *WatchManager) handleWatchStream(watcher watch.Interface, kind string) {
 	if watcher == nil {
 		return
@@ -399,185 +482,79 @@ func (wm *WatchManager) handleWatchStream(watcher watch.Interface, kind string)
 				log.Printf("Watch error for %s: %v", kind, event.Object)
 				return
 			}
-			simpleObj := wm.simplifyObject(event.Object)
-			if simpleObj == nil {
+			res := toLightResource(event.Object)
+			if res == nil {
 				continue
 			}
-
-			// Deduplication: for MODIFIED events, skip if nothing meaningful changed
-			if event.Type == watch.Modified {
-				objMap, ok := simpleObj.(map[string]interface{})
-				if ok {
-					uid, _ := objMap["id"].(string)
-					status, _ := objMap["status"].(string)
-					health, _ := objMap["health"].(string)
-					stateKey := status + "|" + health
-
-					wm.lastSentMu.RLock()
-					lastState := wm.lastSent[uid]
-					wm.lastSentMu.RUnlock()
-
-					if lastState == stateKey {
-						// State hasn't changed, skip this MODIFIED event
-						continue
-					}
-
-					// Update last sent state
-					wm.lastSentMu.Lock()
-					wm.lastSent[uid] = stateKey
-					wm.lastSentMu.Unlock()
-				}
-			} else if event.Type == watch.Deleted {
-				// Clean up tracking on delete
-				objMap, ok := simpleObj.(map[string]interface{})
-				if ok {
-					uid, _ := objMap["id"].(string)
-					wm.lastSentMu.Lock()
-					delete(wm.lastSent, uid)
-					wm.lastSentMu.Unlock()
-				}
-			}
-
-			select {
-			case wm.eventChan <- WatchEvent{Type: string(event.Type), Kind: kind, Resource: simpleObj}:
-			case <-wm.done:
+			if !wm.emit(string(event.Type), kind, res) {
 				return
 			}
 		}
 	}
 }
 
-func (wm *WatchManager) simplifyObject(obj interface{}) interface{} {
-	var meta metav1.Object
-	var status string
-	var kind string
-	var health string = "ok"
-
-	switch o := obj.(type) {
-	case *corev1.Pod:
-		meta = o
-		kind = "Pod"
-		status = string(o.Status.Phase)
-
-		// Calculate Health
-		if o.Status.Phase == corev1.PodFailed {
-			health = "error"
-		} else if o.Status.Phase == corev1.PodPending {
-			health = "warning"
-		} else if o.Status.Phase == corev1.PodRunning {
-			// Check readiness
-			isReady := false
-			for _, c := range o.Status.Conditions {
-				if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
-					isReady = true
-					break
-				}
-			}
-			if !isReady {
-				health = "warning"
-			}
-
-			// Check detailed container statuses for errors
-			for _, cs := range o.Status.ContainerStatuses {
-				if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
-					// e.g. ImagePullBackOff, CrashLoopBackOff, ImageInspectError
-					health = "error"
-				}
-				if cs.State.Terminated != nil && cs.State.Terminated.ExitCode != 0 {
-					health = "error"
-				}
-			}
-		}
-
-	case *corev1.Node:
-		meta = o
-		kind = "Node"
-		status = "NotReady"
-		health = "warning"
-		for _, cond := range o.Status.Conditions {
-			if cond.Type == corev1.NodeReady && cond.Status == corev1.ConditionTrue {
-				status = "Ready"
-				health = "ok"
-				break
-			}
-		}
-	case *corev1.Service:
-		meta = o
-		kind = "Service"
-		status = "Active"
-	case *appsv1.Deployment:
-		meta = o
-		kind = "Deployment"
-		if o.Spec.Replicas != nil && *o.Spec.Replicas == 0 {
-			status = "ScaledDown"
-			health = "ok"
-		} else if o.Status.AvailableReplicas == o.Status.Replicas {
-			status = "Available"
-		} else {
-			status = "Progressing"
-			health = "warning"
-		}
-	case *appsv1.StatefulSet:
-		meta = o
-		kind = "StatefulSet"
-		if o.Status.ReadyReplicas == o.Status.Replicas {
-			status = "Ready"
-		} else {
-			status = "Progressing"
-			health = "warning"
-		}
-	case *appsv1.DaemonSet:
-		meta = o
-		kind = "DaemonSet"
-		if o.Status.NumberReady == o.Status.DesiredNumberScheduled {
-			status = "Ready"
-		} else {
-			status = "Progressing"
-			health = "warning"
-		}
-	case *appsv1.ReplicaSet:
-		meta = o
-		kind = "ReplicaSet"
-		status = "Active"
-	default:
-		return nil
+// emit queues a LightResource event for the client. MODIFIED events are
+// deduplicated against the last state sent for the same UID so no-op updates
+// (e.g. resourceVersion bumps) don't reach the frontend. Returns false when the
+// manager is shutting down.
+func (wm *WatchManager) emit(eventType, kind string, res *LightResource) bool {
+	applyHelmManifest(wm.cluster, res)
+	applyLimitRangeDefaults(wm.cluster, res)
+	applyWarningEvents(wm.cluster, res)
+	applyUsage(wm.cluster, res)
+	if wm.config != nil {
+		// Replayed only to callers connecting with the same credential
+		eventHistory.Record(credentialKey(wm.config), eventType, res)
 	}
+	applyDrills(wm.cluster, res)
 
-	ownerRefs := make([]string, 0)
-	for _, ref := range meta.GetOwnerReferences() {
-		ownerRefs = append(ownerRefs, string(ref.UID))
+	// Events outside the caller's tenancy scope are never sent
+	if !resourceAllowed(wm.scope, res) {
+		return true
+	}
+	if !wm.includeSystem && isSystemResource(res) {
+		return true
 	}
 
-	extra := make(map[string]interface{})
-	if pod, ok := obj.(*corev1.Pod); ok {
-		if pod.Spec.NodeName != "" {
-			extra["nodeName"] = pod.Spec.NodeName
+	switch eventType {
+	case string(watch.Added), string(watch.Modified):
+		restartTracker.Observe(wm.cluster, res)
+		unhealthyTracker.Observe(res)
+		stateKey := stateHash(res)
+
+		wm.lastSentMu.RLock()
+		lastState := wm.lastSent[res.ID]
+		wm.lastSentMu.RUnlock()
+
+		if eventType == string(watch.Modified) && lastState == stateKey {
+			// State hasn't changed, skip this MODIFIED event
+			return true
 		}
-	}
 
-	result := map[string]interface{}{
-		"id":                string(meta.GetUID()),
-		"name":              meta.GetName(),
-		"namespace":         meta.GetNamespace(),
-		"kind":              kind,
-		"status":            status,
-		"health":            health,
-		"labels":            meta.GetLabels(),
-		"ownerRefs":         ownerRefs,
-		"creationTimestamp": meta.GetCreationTimestamp().Time,
+		wm.lastSentMu.Lock()
+		wm.lastSent[res.ID] = stateKey
+		wm.lastSentMu.Unlock()
+	case string(watch.Deleted):
+		// Clean up tracking on delete
+		restartTracker.Forget(wm.cluster, res.ID)
+		unhealthyTracker.Forget(res.ID)
+		wm.lastSentMu.Lock()
+		delete(wm.lastSent, res.ID)
+		wm.lastSentMu.Unlock()
 	}
 
-	for k, v := range extra {
-		result[k] = v
+	select {
+	case wm.eventChan <- WatchEvent{Type: eventType, Kind: kind, Resource: res, ClusterID: wm.clusterID}:
+		return true
+	case <-wm.done:
+		return false
 	}
-
-	return result
 }
 
 func HandleWatch(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		http.Error(w, "Failed to create client", http.StatusInternalServerError)
+		i18n.Error(w, r, http.StatusInternalServerError, "error.clientFailed")
 		return
 	}
 
@@ -588,14 +565,24 @@ func HandleWatch(config *rest.Config, w http.ResponseWriter, r *http.Request) {
 		// Don't fail, just continue without dynamic client
 	}
 
-	ws, err := upgrader.Upgrade(w, r, nil)
+	includeSystem := r.URL.Query().Get("includeSystem") == "true"
+
+	// Legacy clients get the v1 format
+	ws, protocol, err := upgradeWebSocket(w, r, ProtocolWatchV1, ProtocolWatchV2)
 	if err != nil {
 		log.Println("Watch upgrade error:", err)
 		return
 	}
 	defer ws.Close()
 
-	manager := NewWatchManager(clientset, dynamicClient, ws)
+	if protocol == ProtocolWatchV2 {
+		watchClusters(r.Context(), config, ws, includeSystem)
+		return
+	}
+
+	manager := NewWatchManager(clientset, dynamicClient, ws, auth.ScopeFromContext(r.Context()), config.Host)
+	manager.config = config
+	manager.includeSystem = includeSystem
 	manager.Start()
 	defer manager.Stop()
 
@@ -0,0 +1,110 @@
+package k8s
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/gorilla/websocket"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// WatchSubscription is sent by v2 clients to add or remove a cluster from the
+// connection. ClusterID is a registered ClusterConnection name or "local".
+type WatchSubscription struct {
+	Op        string `json:"op"` // subscribe, unsubscribe
+	ClusterID string `json:"clusterId"`
+}
+
+// watchClusters serves a v2 watch connection: one WatchManager per subscribed
+// cluster, all writing through the socket's single sender. Subscriptions are
+// acknowledged with SUBSCRIBED/UNSUBSCRIBED events, failures with ERROR.
+// "local" is the connection the socket was opened against. Every subscription
+// is checked with AuthorizeCluster against the caller of ctx.
+func watchClusters(ctx context.Context, config *rest.Config, ws *websocket.Conn, includeSystem bool) {
+	scope := auth.ScopeFromContext(ctx)
+	sender := NewWatchManager(nil, nil, ws, scope, "")
+	go sender.sendLoop()
+	defer sender.Stop()
+
+	managers := make(map[string]*WatchManager)
+	defer func() {
+		for _, m := range managers {
+			m.Stop()
+		}
+	}()
+
+	reply := func(evt WatchEvent) {
+		select {
+		case sender.eventChan <- evt:
+		case <-sender.stopped:
+		}
+	}
+
+	for {
+		var sub WatchSubscription
+		if err := ws.ReadJSON(&sub); err != nil {
+			return
+		}
+		id := sub.ClusterID
+		if id == "" {
+			id = LocalClusterID
+		}
+
+		switch sub.Op {
+		case "subscribe":
+			if _, ok := managers[id]; ok {
+				reply(WatchEvent{Type: "SUBSCRIBED", ClusterID: id})
+				continue
+			}
+			if err := AuthorizeCluster(ctx, id); err != nil {
+				reply(WatchEvent{Type: "ERROR", ClusterID: id, Error: err.Error()})
+				continue
+			}
+			clusterConfig := config
+			if id != LocalClusterID {
+				resolveCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+				var err error
+				clusterConfig, err = ClusterConfig(resolveCtx, id)
+				cancel()
+				if err != nil {
+					// The cause may name Secrets of the backend: log it only
+					log.Printf("Resolving cluster %s failed: %v", id, err)
+					reply(WatchEvent{Type: "ERROR", ClusterID: id, Error: errClusterUnavailable.Error()})
+					continue
+				}
+			}
+			clientset, err := kubernetes.NewForConfig(clusterConfig)
+			if err != nil {
+				reply(WatchEvent{Type: "ERROR", ClusterID: id, Error: err.Error()})
+				continue
+			}
+			dynamicClient, err := dynamic.NewForConfig(clusterConfig)
+			if err != nil {
+				log.Printf("Failed to create dynamic client for cluster %s: %v (CRD watching disabled)", id, err)
+			}
+
+			m := NewWatchManager(clientset, dynamicClient, nil, scope, clusterConfig.Host)
+			m.clusterID = id
+			m.config = clusterConfig
+			m.includeSystem = includeSystem
+			m.eventChan = sender.eventChan
+			m.Start()
+			managers[id] = m
+			reply(WatchEvent{Type: "SUBSCRIBED", ClusterID: id})
+
+		case "unsubscribe":
+			if m, ok := managers[id]; ok {
+				m.Stop()
+				delete(managers, id)
+			}
+			reply(WatchEvent{Type: "UNSUBSCRIBED", ClusterID: id})
+
+		default:
+			reply(WatchEvent{Type: "ERROR", ClusterID: id, Error: "unknown op " + sub.Op})
+		}
+	}
+}
@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
 	"github.com/gorilla/websocket"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
@@ -17,8 +20,10 @@ import (
 
 // SingleResourceWatchEvent is what we send for a single resource watch (full object)
 type SingleResourceWatchEvent struct {
-	Type     string      `json:"type"` // ADDED, MODIFIED, DELETED
+	Type     string      `json:"type"` // ADDED, MODIFIED, DELETED, REPLAY
 	Resource interface{} `json:"resource"` // Full K8s object
+	// Recent transitions recorded before the socket opened (REPLAY only)
+	Transitions []EventTransition `json:"transitions,omitempty"`
 }
 
 // SingleResourceWatcher watches a single resource and sends full updates
@@ -183,17 +188,21 @@ func HandleSingleWatch(config *rest.Config, w http.ResponseWriter, r *http.Reque
 	name := r.URL.Query().Get("name")
 
 	if kind == "" || name == "" {
-		http.Error(w, "kind and name are required", http.StatusBadRequest)
+		i18n.Error(w, r, http.StatusBadRequest, "error.required", "kind, name")
+		return
+	}
+
+	if !auth.RequireNamespace(w, r, namespace) {
 		return
 	}
 
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		http.Error(w, "Failed to create client", http.StatusInternalServerError)
+		i18n.Error(w, r, http.StatusInternalServerError, "error.clientFailed")
 		return
 	}
 
-	ws, err := upgrader.Upgrade(w, r, nil)
+	ws, protocol, err := upgradeWebSocket(w, r, ProtocolResourceWatchV1)
 	if err != nil {
 		log.Println("Single watch upgrade error:", err)
 		return
@@ -202,6 +211,22 @@ func HandleSingleWatch(config *rest.Config, w http.ResponseWriter, r *http.Reque
 
 	log.Printf("Starting single resource watch: %s/%s/%s", kind, namespace, name)
 
+	// Replay recent transitions first so the panel doesn't start empty. Legacy
+	// clients don't know the REPLAY event. Only transitions watched with the
+	// caller's own credential are replayed, so its RBAC applies to them.
+	replay := 10
+	if v, err := strconv.Atoi(r.URL.Query().Get("replay")); err == nil && v >= 0 {
+		replay = v
+	}
+	if replay > 0 && protocol == ProtocolResourceWatchV1 {
+		if transitions := eventHistory.Recent(credentialKey(config), canonicalKind(kind), namespace, name, replay); len(transitions) > 0 {
+			if err := ws.WriteJSON(SingleResourceWatchEvent{Type: "REPLAY", Transitions: transitions}); err != nil {
+				log.Println("Single watch WS write error:", err)
+				return
+			}
+		}
+	}
+
 	watcher := NewSingleResourceWatcher(clientset, ws, kind, namespace, name)
 	watcher.Start()
 	defer watcher.Stop()
@@ -215,3 +240,33 @@ func HandleSingleWatch(config *rest.Config, w http.ResponseWriter, r *http.Reque
 
 	log.Printf("Single resource watch ended: %s/%s/%s", kind, namespace, name)
 }
+
+// canonicalKind maps the lowercase or short kind names accepted by the single
+// watch to the Kind used in LightResources
+func canonicalKind(kind string) string {
+	switch strings.ToLower(kind) {
+	case "pod":
+		return "Pod"
+	case "node":
+		return "Node"
+	case "service":
+		return "Service"
+	case "deployment":
+		return "Deployment"
+	case "statefulset":
+		return "StatefulSet"
+	case "daemonset":
+		return "DaemonSet"
+	case "replicaset":
+		return "ReplicaSet"
+	case "configmap":
+		return "ConfigMap"
+	case "secret":
+		return "Secret"
+	case "persistentvolumeclaim", "pvc":
+		return "PersistentVolumeClaim"
+	case "ingress":
+		return "Ingress"
+	}
+	return kind
+}
@@ -0,0 +1,328 @@
+package maintenance
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anakosmos/backend/src/activity"
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	"github.com/anakosmos/backend/src/store"
+)
+
+const windowsCollection = "maintenance"
+
+// reloadInterval bounds how long a window created on another replica takes to
+// be honoured here
+const reloadInterval = 30 * time.Second
+
+// Window is a declared maintenance period. While it is active, alerts for the
+// clusters and namespaces it covers are not sent and summaries mark them as
+// under maintenance.
+type Window struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Reason string `json:"reason,omitempty"`
+	// Clusters are target URLs or "local"; empty covers every cluster
+	Clusters []string `json:"clusters,omitempty"`
+	// Namespaces covered; empty covers whole clusters
+	Namespaces []string  `json:"namespaces,omitempty"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	CreatedBy  string    `json:"createdBy"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// ActiveAt reports whether the window is in progress at t
+func (w *Window) ActiveAt(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// Covers reports whether the window applies to a namespace of a cluster. An
+// empty namespace asks about the cluster as a whole, which only windows
+// without namespaces cover.
+func (w *Window) Covers(cluster, namespace string) bool {
+	if len(w.Clusters) > 0 && !contains(w.Clusters, cluster) {
+		return false
+	}
+	if len(w.Namespaces) == 0 {
+		return true
+	}
+	return namespace != "" && contains(w.Namespaces, namespace)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Manager persists windows in the store and keeps them cached for the
+// frequent Active lookups
+type Manager struct {
+	store store.Store
+
+	mu       sync.Mutex
+	windows  []Window
+	loadedAt time.Time
+}
+
+var (
+	currentMu sync.RWMutex
+	current   *Manager
+)
+
+// Configure makes m the manager consulted by Active
+func Configure(m *Manager) {
+	currentMu.Lock()
+	current = m
+	currentMu.Unlock()
+}
+
+func NewManager(s store.Store) *Manager {
+	return &Manager{store: s}
+}
+
+// List returns every stored window, soonest start first. The cache is
+// refreshed when older than reloadInterval.
+func (m *Manager) List(ctx context.Context) ([]Window, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.loadedAt.IsZero() && time.Since(m.loadedAt) < reloadInterval {
+		return m.windows, nil
+	}
+	entries, err := m.store.List(ctx, windowsCollection)
+	if err != nil {
+		return m.windows, err
+	}
+	windows := make([]Window, 0, len(entries))
+	for _, e := range entries {
+		var w Window
+		if err := json.Unmarshal(e.Value, &w); err != nil {
+			continue
+		}
+		windows = append(windows, w)
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i].Start.Before(windows[j].Start) })
+	m.windows = windows
+	m.loadedAt = time.Now()
+	return windows, nil
+}
+
+// Create stores a new window
+func (m *Manager) Create(ctx context.Context, w Window) (Window, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return Window{}, err
+	}
+	w.ID = hex.EncodeToString(id)
+	w.CreatedAt = time.Now().UTC()
+	if err := store.PutJSON(ctx, m.store, windowsCollection, w.ID, w); err != nil {
+		return Window{}, err
+	}
+	m.invalidate()
+	return w, nil
+}
+
+// Delete removes a window, ending it early if it is in progress
+func (m *Manager) Delete(ctx context.Context, id string) (Window, error) {
+	var w Window
+	if err := store.GetJSON(ctx, m.store, windowsCollection, id, &w); err != nil {
+		return Window{}, err
+	}
+	if err := m.store.Delete(ctx, windowsCollection, id); err != nil {
+		return Window{}, err
+	}
+	m.invalidate()
+	return w, nil
+}
+
+func (m *Manager) invalidate() {
+	m.mu.Lock()
+	m.loadedAt = time.Time{}
+	m.mu.Unlock()
+}
+
+// Active returns the windows in progress covering a namespace of a cluster
+// (the cluster as a whole for an empty namespace). Without a configured
+// manager nothing is ever under maintenance.
+func Active(cluster, namespace string) []Window {
+	currentMu.RLock()
+	m := current
+	currentMu.RUnlock()
+	if m == nil {
+		return nil
+	}
+	windows, err := m.List(context.Background())
+	if err != nil {
+		log.Printf("Failed to load maintenance windows: %v", err)
+	}
+	now := time.Now()
+	var active []Window
+	for i := range windows {
+		if windows[i].ActiveAt(now) && windows[i].Covers(cluster, namespace) {
+			active = append(active, windows[i])
+		}
+	}
+	return active
+}
+
+// UnderMaintenance reports whether a namespace of a cluster (or the cluster as
+// a whole for an empty namespace) is covered by a window in progress
+func UnderMaintenance(cluster, namespace string) bool {
+	return len(Active(cluster, namespace)) > 0
+}
+
+type createWindowRequest struct {
+	Title      string   `json:"title"`
+	Reason     string   `json:"reason"`
+	Clusters   []string `json:"clusters"`
+	Namespaces []string `json:"namespaces"`
+	// Start defaults to now; End or Duration (e.g. "2h") is required
+	Start    *time.Time `json:"start"`
+	End      *time.Time `json:"end"`
+	Duration string     `json:"duration"`
+}
+
+// parse validates a create request into a window
+func (req *createWindowRequest) parse() (Window, error) {
+	w := Window{
+		Title:      strings.TrimSpace(req.Title),
+		Reason:     req.Reason,
+		Clusters:   req.Clusters,
+		Namespaces: req.Namespaces,
+		Start:      time.Now().UTC(),
+	}
+	if w.Title == "" {
+		return w, errors.New("title")
+	}
+	if req.Start != nil {
+		w.Start = req.Start.UTC()
+	}
+	switch {
+	case req.End != nil:
+		w.End = req.End.UTC()
+	case req.Duration != "":
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			return w, errors.New("duration")
+		}
+		w.End = w.Start.Add(d)
+	default:
+		return w, errors.New("end")
+	}
+	if !w.End.After(w.Start) {
+		return w, errors.New("end")
+	}
+	return w, nil
+}
+
+// notice publishes a window starting or ending on the activity feed, once per
+// covered cluster
+func notice(r *http.Request, w Window, verb, message string) {
+	clusters := w.Clusters
+	if len(clusters) == 0 {
+		clusters = []string{""}
+	}
+	for _, cluster := range clusters {
+		item := activity.Item{
+			Type:     "maintenance",
+			Severity: "info",
+			Cluster:  cluster,
+			Actor:    auth.IdentityFromContext(r.Context()).User,
+			Verb:     verb,
+			Message:  message,
+		}
+		if len(w.Namespaces) == 1 {
+			item.Namespace = w.Namespaces[0]
+		}
+		activity.Publish(item)
+	}
+}
+
+// Handler serves /api/maintenance (GET list, ?active=true for windows in
+// progress; POST declare) and /api/maintenance/{id} (DELETE end or cancel).
+// Anyone may list windows; declaring and ending them is admin only.
+func Handler(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/maintenance"), "/")
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == "GET" && id == "":
+			windows, err := m.List(r.Context())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			result := make([]Window, 0, len(windows))
+			now := time.Now()
+			for _, win := range windows {
+				if r.URL.Query().Get("active") == "true" && !win.ActiveAt(now) {
+					continue
+				}
+				result = append(result, win)
+			}
+			json.NewEncoder(w).Encode(result)
+
+		case r.Method == "POST" && id == "":
+			if !auth.RequireAdmin(w, r) {
+				return
+			}
+			var req createWindowRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				i18n.Error(w, r, http.StatusBadRequest, "error.invalidJSON")
+				return
+			}
+			win, err := req.parse()
+			if err != nil {
+				if err.Error() == "title" {
+					i18n.Error(w, r, http.StatusBadRequest, "error.required", "title")
+				} else {
+					i18n.Error(w, r, http.StatusBadRequest, "error.invalid", err.Error())
+				}
+				return
+			}
+			win.CreatedBy = auth.IdentityFromContext(r.Context()).User
+			win, err = m.Create(r.Context(), win)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			notice(r, win, "scheduled", "maintenance "+win.Title+" from "+win.Start.Format(time.RFC3339)+" to "+win.End.Format(time.RFC3339))
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(win)
+
+		case r.Method == "DELETE" && id != "":
+			if !auth.RequireAdmin(w, r) {
+				return
+			}
+			win, err := m.Delete(r.Context(), id)
+			if errors.Is(err, store.ErrNotFound) {
+				i18n.Error(w, r, http.StatusNotFound, "error.resourceNotFound")
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			notice(r, win, "ended", "maintenance "+win.Title+" ended")
+			json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
+
+		default:
+			i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package notes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anakosmos/backend/src/auth"
+	"github.com/anakosmos/backend/src/i18n"
+	"github.com/anakosmos/backend/src/store"
+)
+
+const notesCollection = "notes"
+
+// reloadInterval bounds how long a note written on another replica takes to
+// show up here
+const reloadInterval = 30 * time.Second
+
+// maxNoteLength caps the text of a note
+const maxNoteLength = 2000
+
+// Note annotates one graph resource without touching the object itself
+type Note struct {
+	Cluster    string `json:"cluster"` // target URL or "local"
+	ResourceID string `json:"resourceId"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	Text       string `json:"text,omitempty"`
+	Pinned     bool   `json:"pinned,omitempty"`
+	// Until hides the note once past, e.g. "known issue, ignore until Friday"
+	Until     *time.Time `json:"until,omitempty"`
+	Author    string     `json:"author"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// Expired reports whether the note's Until has passed
+func (n *Note) Expired(now time.Time) bool {
+	return n.Until != nil && !now.Before(*n.Until)
+}
+
+func noteKey(cluster, resourceID string) string {
+	return cluster + "|" + resourceID
+}
+
+// Manager persists notes in the store and keeps them cached for merging into
+// every init response
+type Manager struct {
+	store store.Store
+
+	mu       sync.Mutex
+	notes    []Note
+	loadedAt time.Time
+}
+
+var (
+	currentMu sync.RWMutex
+	current   *Manager
+)
+
+// Configure makes m the manager consulted by ForCluster
+func Configure(m *Manager) {
+	currentMu.Lock()
+	current = m
+	currentMu.Unlock()
+}
+
+func NewManager(s store.Store) *Manager {
+	return &Manager{store: s}
+}
+
+// List returns every stored note, expired ones included
+func (m *Manager) List(ctx context.Context) ([]Note, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.loadedAt.IsZero() && time.Since(m.loadedAt) < reloadInterval {
+		return m.notes, nil
+	}
+	entries, err := m.store.List(ctx, notesCollection)
+	if err != nil {
+		return m.notes, err
+	}
+	notes := make([]Note, 0, len(entries))
+	for _, e := range entries {
+		var n Note
+		if err := json.Unmarshal(e.Value, &n); err != nil {
+			continue
+		}
+		notes = append(notes, n)
+	}
+	m.notes = notes
+	m.loadedAt = time.Now()
+	return notes, nil
+}
+
+// Put creates or replaces the note of a resource
+func (m *Manager) Put(ctx context.Context, n Note) error {
+	if err := store.PutJSON(ctx, m.store, notesCollection, noteKey(n.Cluster, n.ResourceID), n); err != nil {
+		return err
+	}
+	m.invalidate()
+	return nil
+}
+
+// Get returns the note of a resource
+func (m *Manager) Get(ctx context.Context, cluster, resourceID string) (Note, error) {
+	var n Note
+	err := store.GetJSON(ctx, m.store, notesCollection, noteKey(cluster, resourceID), &n)
+	return n, err
+}
+
+// Delete removes the note of a resource
+func (m *Manager) Delete(ctx context.Context, cluster, resourceID string) error {
+	if err := m.store.Delete(ctx, notesCollection, noteKey(cluster, resourceID)); err != nil {
+		return err
+	}
+	m.invalidate()
+	return nil
+}
+
+func (m *Manager) invalidate() {
+	m.mu.Lock()
+	m.loadedAt = time.Time{}
+	m.mu.Unlock()
+}
+
+// ForCluster returns the current (not expired) notes of a cluster by
+// resource ID. Without a configured manager there are none.
+func ForCluster(cluster string) map[string]*Note {
+	currentMu.RLock()
+	m := current
+	currentMu.RUnlock()
+	if m == nil {
+		return nil
+	}
+	notes, err := m.List(context.Background())
+	if err != nil {
+		log.Printf("Failed to load resource notes: %v", err)
+	}
+	now := time.Now()
+	byID := make(map[string]*Note)
+	for i := range notes {
+		if notes[i].Cluster == cluster && !notes[i].Expired(now) {
+			n := notes[i]
+			byID[n.ResourceID] = &n
+		}
+	}
+	return byID
+}
+
+type putNoteRequest struct {
+	ResourceID string     `json:"resourceId"`
+	Kind       string     `json:"kind"`
+	Namespace  string     `json:"namespace"`
+	Name       string     `json:"name"`
+	Text       string     `json:"text"`
+	Pinned     bool       `json:"pinned"`
+	Until      *time.Time `json:"until"`
+}
+
+// Handler serves /api/resources/notes for the request's cluster: GET lists
+// the current notes of namespaces the caller may see, PUT sets the note of a
+// resource (an empty, unpinned note deletes it) and DELETE ?id= removes it.
+func Handler(m *Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cluster := auth.RequestCluster(r)
+		scope := auth.ScopeFromContext(r.Context())
+		user := auth.IdentityFromContext(r.Context()).User
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.Method {
+		case http.MethodGet:
+			all, err := m.List(r.Context())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			result := []Note{}
+			now := time.Now()
+			for _, n := range all {
+				if n.Cluster == cluster && !n.Expired(now) && (n.Namespace == "" || scope.Allows(n.Namespace)) {
+					result = append(result, n)
+				}
+			}
+			sort.Slice(result, func(i, j int) bool {
+				if result[i].Pinned != result[j].Pinned {
+					return result[i].Pinned
+				}
+				return result[i].UpdatedAt.After(result[j].UpdatedAt)
+			})
+			json.NewEncoder(w).Encode(result)
+
+		case http.MethodPut:
+			var req putNoteRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				i18n.Error(w, r, http.StatusBadRequest, "error.invalidJSON")
+				return
+			}
+			if req.ResourceID == "" || req.Kind == "" || req.Name == "" {
+				i18n.Error(w, r, http.StatusBadRequest, "error.required", "resourceId, kind, name")
+				return
+			}
+			req.Text = strings.TrimSpace(req.Text)
+			if len(req.Text) > maxNoteLength {
+				i18n.Error(w, r, http.StatusBadRequest, "error.invalid", "text")
+				return
+			}
+			if req.Namespace != "" && !auth.RequireNamespace(w, r, req.Namespace) {
+				return
+			}
+			if req.Text == "" && !req.Pinned {
+				err := m.Delete(r.Context(), cluster, req.ResourceID)
+				if err != nil && !errors.Is(err, store.ErrNotFound) {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
+				return
+			}
+			note := Note{
+				Cluster:    cluster,
+				ResourceID: req.ResourceID,
+				Kind:       req.Kind,
+				Namespace:  req.Namespace,
+				Name:       req.Name,
+				Text:       req.Text,
+				Pinned:     req.Pinned,
+				Until:      req.Until,
+				Author:     user,
+				UpdatedAt:  time.Now().UTC(),
+			}
+			if err := m.Put(r.Context(), note); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(note)
+
+		case http.MethodDelete:
+			id := r.URL.Query().Get("id")
+			if id == "" {
+				i18n.Error(w, r, http.StatusBadRequest, "error.required", "id")
+				return
+			}
+			note, err := m.Get(r.Context(), cluster, id)
+			if errors.Is(err, store.ErrNotFound) {
+				i18n.Error(w, r, http.StatusNotFound, "error.resourceNotFound")
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if note.Namespace != "" && !auth.RequireNamespace(w, r, note.Namespace) {
+				return
+			}
+			if err := m.Delete(r.Context(), cluster, id); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
+
+		default:
+			i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package settings
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+const crdGroup = "anakosmos.io"
+const crdVersion = "v1alpha1"
+
+// crdKind describes how one anakosmos CRD is reconciled into the Runtime
+type crdKind struct {
+	resource string
+	apply    func(rt *Runtime, obj *unstructured.Unstructured) error
+	remove   func(rt *Runtime, name string)
+}
+
+var crdKinds = []crdKind{
+	{
+		resource: "clusterconnections",
+		apply: func(rt *Runtime, obj *unstructured.Unstructured) error {
+			var c ClusterConnection
+			if err := decodeSpec(obj, &c); err != nil {
+				return err
+			}
+			c.Name = obj.GetName()
+			rt.SetCluster(c)
+			return nil
+		},
+		remove: (*Runtime).DeleteCluster,
+	},
+	{
+		resource: "linkrules",
+		apply: func(rt *Runtime, obj *unstructured.Unstructured) error {
+			var r LinkRule
+			if err := decodeSpec(obj, &r); err != nil {
+				return err
+			}
+			r.Name = obj.GetName()
+			if r.LinkType == "" {
+				r.LinkType = "config"
+			}
+			rt.SetLinkRule(r)
+			return nil
+		},
+		remove: (*Runtime).DeleteLinkRule,
+	},
+	{
+		resource: "alertrules",
+		apply: func(rt *Runtime, obj *unstructured.Unstructured) error {
+			var r AlertRule
+			if err := decodeSpec(obj, &r); err != nil {
+				return err
+			}
+			r.Name = obj.GetName()
+			rt.SetAlertRule(r)
+			return nil
+		},
+		remove: (*Runtime).DeleteAlertRule,
+	},
+	{
+		resource: "savedviews",
+		apply: func(rt *Runtime, obj *unstructured.Unstructured) error {
+			var v SavedView
+			if err := decodeSpec(obj, &v); err != nil {
+				return err
+			}
+			v.Name = obj.GetName()
+			rt.SetSavedView(v)
+			return nil
+		},
+		remove: (*Runtime).DeleteSavedView,
+	},
+	{
+		resource: "displayfields",
+		apply: func(rt *Runtime, obj *unstructured.Unstructured) error {
+			var f DisplayField
+			if err := decodeSpec(obj, &f); err != nil {
+				return err
+			}
+			f.Name = obj.GetName()
+			if f.Kind == "" || f.JSONPath == "" {
+				return fmt.Errorf("kind and jsonPath are required")
+			}
+			if _, err := jsonpath.Parse(f.Name, RelaxedJSONPath(f.JSONPath)); err != nil {
+				return fmt.Errorf("invalid jsonPath: %v", err)
+			}
+			if f.Label == "" {
+				f.Label = f.Name
+			}
+			rt.SetDisplayField(f)
+			return nil
+		},
+		remove: (*Runtime).DeleteDisplayField,
+	},
+	{
+		resource: "healthrules",
+		apply: func(rt *Runtime, obj *unstructured.Unstructured) error {
+			var r HealthRule
+			if err := decodeSpec(obj, &r); err != nil {
+				return err
+			}
+			r.Name = obj.GetName()
+			if r.Kind == "" || r.Expression == "" {
+				return fmt.Errorf("kind and expression are required")
+			}
+			if err := compileHealthRule(&r); err != nil {
+				return fmt.Errorf("invalid expression: %v", err)
+			}
+			rt.SetHealthRule(r)
+			return nil
+		},
+		remove: (*Runtime).DeleteHealthRule,
+	},
+}
+
+// RelaxedJSONPath accepts printer column paths like ".spec.replicas" as well
+// as templates like "{.spec.replicas}"
+func RelaxedJSONPath(path string) string {
+	path = strings.TrimSpace(path)
+	if strings.HasPrefix(path, "{") {
+		return path
+	}
+	if !strings.HasPrefix(path, ".") {
+		path = "." + path
+	}
+	return "{" + path + "}"
+}
+
+func decodeSpec(obj *unstructured.Unstructured, out interface{}) error {
+	spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(spec, out)
+}
+
+// Controller reconciles the anakosmos CRDs of one namespace into a Runtime.
+// An object that fails to apply, on the initial list or when modified, leaves
+// the last valid version of it in effect (or nothing, if it never applied). The
+// failure is logged as an error, listed in the Runtime snapshot served by
+// /api/config and reported as a Warning Event on the object.
+type Controller struct {
+	client    dynamic.Interface
+	events    kubernetes.Interface
+	namespace string
+	runtime   *Runtime
+}
+
+func NewController(config *rest.Config, namespace string, rt *Runtime) (*Controller, error) {
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	events, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Controller{
+		client:    client,
+		events:    events,
+		namespace: namespace,
+		runtime:   rt,
+	}, nil
+}
+
+// apply reconciles one object, surfacing a failure to apply it
+func (c *Controller) apply(ctx context.Context, kind crdKind, obj *unstructured.Unstructured) bool {
+	err := kind.apply(c.runtime, obj)
+	if err == nil {
+		c.runtime.ClearInvalid(kind.resource, obj.GetName())
+		return true
+	}
+	log.Printf("Error: invalid %s %s, keeping its last valid version: %v", kind.resource, obj.GetName(), err)
+	c.runtime.SetInvalid(InvalidConfig{Resource: kind.resource, Name: obj.GetName(), Error: err.Error()})
+
+	now := metav1.Now()
+	_, eventErr := c.events.CoreV1().Events(c.namespace).Create(ctx, &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: obj.GetName() + "."},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion:      obj.GetAPIVersion(),
+			Kind:            obj.GetKind(),
+			Namespace:       obj.GetNamespace(),
+			Name:            obj.GetName(),
+			UID:             obj.GetUID(),
+			ResourceVersion: obj.GetResourceVersion(),
+		},
+		Reason:         "InvalidSpec",
+		Message:        "not applied: " + err.Error(),
+		Type:           corev1.EventTypeWarning,
+		Source:         corev1.EventSource{Component: "anakosmos"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}, metav1.CreateOptions{})
+	if eventErr != nil {
+		log.Printf("Failed to record event for %s %s: %v", kind.resource, obj.GetName(), eventErr)
+	}
+	return false
+}
+
+// Run starts one list/watch loop per CRD kind and blocks until ctx is done
+func (c *Controller) Run(ctx context.Context) {
+	for _, kind := range crdKinds {
+		go c.reconcileLoop(ctx, kind)
+	}
+	<-ctx.Done()
+}
+
+func (c *Controller) reconcileLoop(ctx context.Context, kind crdKind) {
+	gvr := schema.GroupVersionResource{Group: crdGroup, Version: crdVersion, Resource: kind.resource}
+	resource := c.client.Resource(gvr).Namespace(c.namespace)
+	known := make(map[string]bool)
+
+	for {
+		list, err := resource.List(ctx, metav1.ListOptions{})
+		if err != nil {
+			log.Printf("Failed to list %s: %v. Retrying in 30s...", kind.resource, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(30 * time.Second):
+				continue
+			}
+		}
+
+		// Full resync: apply everything listed and drop what disappeared
+		seen := make(map[string]bool)
+		for i := range list.Items {
+			obj := &list.Items[i]
+			// Invalid objects still count as seen so a previously valid
+			// version isn't dropped
+			c.apply(ctx, kind, obj)
+			seen[obj.GetName()] = true
+		}
+		for name := range known {
+			if !seen[name] {
+				kind.remove(c.runtime, name)
+				c.runtime.ClearInvalid(kind.resource, name)
+			}
+		}
+		known = seen
+
+		watcher, err := resource.Watch(ctx, metav1.ListOptions{ResourceVersion: list.GetResourceVersion()})
+		if err == nil {
+			c.handleWatchStream(ctx, watcher, kind, known)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(1 * time.Second):
+			// Relist
+		}
+	}
+}
+
+func (c *Controller) handleWatchStream(ctx context.Context, watcher watch.Interface, kind crdKind, known map[string]bool) {
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return
+			}
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				// watch.Error carries a Status object, fall back to relist
+				return
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				c.apply(ctx, kind, obj)
+				known[obj.GetName()] = true
+			case watch.Deleted:
+				kind.remove(c.runtime, obj.GetName())
+				c.runtime.ClearInvalid(kind.resource, obj.GetName())
+				delete(known, obj.GetName())
+			}
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package settings
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/anakosmos/backend/src/i18n"
+)
+
+// HandleConfig returns the configuration reconciled from the anakosmos CRDs.
+// Token secret references are returned as-is; secret values never leave the backend.
+func HandleConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		i18n.Error(w, r, http.StatusMethodNotAllowed, "error.methodRequired", "GET")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Current().Snapshot())
+}
@@ -0,0 +1,82 @@
+package settings
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+)
+
+// healthCostLimit bounds the work of one health rule evaluation, so a rule
+// looping over a huge list can't stall the graph
+const healthCostLimit = 100000
+
+// healthEnv is the CEL environment health rules compile in: the object is
+// the only variable
+var healthEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(cel.Variable("object", cel.DynType))
+})
+
+// RuleHealth is what a HealthRule said about an object
+type RuleHealth struct {
+	Rule    string
+	Health  string
+	Status  string
+	Message string
+}
+
+// compileHealthRule checks the expression of a rule and keeps its program
+func compileHealthRule(r *HealthRule) error {
+	env, err := healthEnv()
+	if err != nil {
+		return err
+	}
+	ast, issues := env.Compile(r.Expression)
+	if issues.Err() != nil {
+		return issues.Err()
+	}
+	switch ast.OutputType().Kind() {
+	case types.StringKind, types.MapKind, types.DynKind:
+	default:
+		return fmt.Errorf("expression must return a string or a map, not %s", ast.OutputType())
+	}
+	program, err := env.Program(ast, cel.CostLimit(healthCostLimit))
+	if err != nil {
+		return err
+	}
+	r.program = program
+	return nil
+}
+
+// Evaluate runs the rule against an object. ok is false when the rule keeps
+// the built-in health: it returned "", an unknown health, or failed, e.g. on
+// a field the object lacks.
+func (r HealthRule) Evaluate(obj map[string]interface{}) (RuleHealth, bool) {
+	if r.program == nil {
+		return RuleHealth{}, false
+	}
+	out, _, err := r.program.Eval(map[string]interface{}{"object": obj})
+	if err != nil {
+		return RuleHealth{}, false
+	}
+	result := RuleHealth{Rule: r.Name}
+	switch v := out.Value().(type) {
+	case string:
+		result.Health = v
+	default:
+		fields, err := out.ConvertToNative(reflect.TypeOf(map[string]string{}))
+		if err != nil {
+			return RuleHealth{}, false
+		}
+		m := fields.(map[string]string)
+		result.Health, result.Status, result.Message = m["health"], m["status"], m["message"]
+	}
+	switch result.Health {
+	case "ok", "warning", "error":
+		return result, true
+	default:
+		return RuleHealth{}, false
+	}
+}
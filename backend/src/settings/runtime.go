@@ -0,0 +1,319 @@
+package settings
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+)
+
+// ClusterConnection describes a Kubernetes API endpoint anakosmos can connect to
+type ClusterConnection struct {
+	Name                  string     `json:"name"`
+	DisplayName           string     `json:"displayName,omitempty"`
+	Server                string     `json:"server"`
+	TokenSecretRef        *SecretRef `json:"tokenSecretRef,omitempty"`
+	InsecureSkipTLSVerify bool       `json:"insecureSkipTLSVerify,omitempty"`
+}
+
+// SecretRef points to a key inside a Secret in the anakosmos namespace
+type SecretRef struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// LinkRule adds graph links between resources sharing the value of a label
+type LinkRule struct {
+	Name       string `json:"name"`
+	SourceKind string `json:"sourceKind"`
+	TargetKind string `json:"targetKind"`
+	MatchLabel string `json:"matchLabel"`
+	LinkType   string `json:"linkType"`
+}
+
+// AlertRule raises an alert when matching resources stay in a given health state
+type AlertRule struct {
+	Name       string `json:"name"`
+	Kind       string `json:"kind,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Health     string `json:"health"`
+	ForMinutes int    `json:"forMinutes,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+// SavedView is a named filter over the graph shared by a team
+type SavedView struct {
+	Name          string   `json:"name"`
+	Title         string   `json:"title,omitempty"`
+	Description   string   `json:"description,omitempty"`
+	Namespaces    []string `json:"namespaces,omitempty"`
+	Kinds         []string `json:"kinds,omitempty"`
+	LabelSelector string   `json:"labelSelector,omitempty"`
+}
+
+// DisplayField is an extra value shown on resources of a kind, extracted by
+// JSONPath like a kubectl printer column
+type DisplayField struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// Label names the value in the UI; defaults to Name
+	Label    string `json:"label,omitempty"`
+	JSONPath string `json:"jsonPath"`
+}
+
+// HealthRule sets the status and health of resources of a kind from a CEL
+// expression over the object, like an Argo CD health check. Rules of a kind
+// are tried by name until one gives a health.
+type HealthRule struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// Expression sees the object as `object` and returns a health ("ok",
+	// "warning" or "error"), a map with health, status and message keys, or
+	// "" to keep the built-in health
+	Expression string `json:"expression"`
+	program    cel.Program
+}
+
+// InvalidConfig is an anakosmos CRD object that failed to apply. The last
+// valid version of it, if any, stays in effect.
+type InvalidConfig struct {
+	Resource string `json:"resource"`
+	Name     string `json:"name"`
+	Error    string `json:"error"`
+}
+
+// Snapshot is a point-in-time copy of the runtime configuration
+type Snapshot struct {
+	Clusters      []ClusterConnection `json:"clusters"`
+	LinkRules     []LinkRule          `json:"linkRules"`
+	AlertRules    []AlertRule         `json:"alertRules"`
+	SavedViews    []SavedView         `json:"savedViews"`
+	DisplayFields []DisplayField      `json:"displayFields"`
+	HealthRules   []HealthRule        `json:"healthRules"`
+	Invalid       []InvalidConfig     `json:"invalid"`
+}
+
+// Runtime holds the configuration reconciled from the anakosmos CRDs
+type Runtime struct {
+	mu            sync.RWMutex
+	clusters      map[string]ClusterConnection
+	linkRules     map[string]LinkRule
+	alertRules    map[string]AlertRule
+	savedViews    map[string]SavedView
+	displayFields map[string]DisplayField
+	healthRules   map[string]HealthRule
+	invalid       map[string]InvalidConfig // resource/name
+}
+
+func NewRuntime() *Runtime {
+	return &Runtime{
+		clusters:      make(map[string]ClusterConnection),
+		linkRules:     make(map[string]LinkRule),
+		alertRules:    make(map[string]AlertRule),
+		savedViews:    make(map[string]SavedView),
+		displayFields: make(map[string]DisplayField),
+		healthRules:   make(map[string]HealthRule),
+		invalid:       make(map[string]InvalidConfig),
+	}
+}
+
+var current = NewRuntime()
+
+// Current returns the process-wide runtime configuration
+func Current() *Runtime {
+	return current
+}
+
+func (rt *Runtime) SetCluster(c ClusterConnection) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.clusters[c.Name] = c
+}
+
+func (rt *Runtime) DeleteCluster(name string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	delete(rt.clusters, name)
+}
+
+func (rt *Runtime) SetLinkRule(r LinkRule) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.linkRules[r.Name] = r
+}
+
+func (rt *Runtime) DeleteLinkRule(name string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	delete(rt.linkRules, name)
+}
+
+func (rt *Runtime) SetAlertRule(r AlertRule) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.alertRules[r.Name] = r
+}
+
+func (rt *Runtime) DeleteAlertRule(name string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	delete(rt.alertRules, name)
+}
+
+func (rt *Runtime) SetSavedView(v SavedView) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.savedViews[v.Name] = v
+}
+
+func (rt *Runtime) DeleteSavedView(name string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	delete(rt.savedViews, name)
+}
+
+func (rt *Runtime) SetDisplayField(f DisplayField) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.displayFields[f.Name] = f
+}
+
+func (rt *Runtime) DeleteDisplayField(name string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	delete(rt.displayFields, name)
+}
+
+func (rt *Runtime) SetHealthRule(r HealthRule) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.healthRules[r.Name] = r
+}
+
+func (rt *Runtime) DeleteHealthRule(name string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	delete(rt.healthRules, name)
+}
+
+// SetInvalid records that an object failed to apply
+func (rt *Runtime) SetInvalid(c InvalidConfig) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.invalid[c.Resource+"/"+c.Name] = c
+}
+
+// ClearInvalid forgets the failure of an object that applied or was deleted
+func (rt *Runtime) ClearInvalid(resource, name string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	delete(rt.invalid, resource+"/"+name)
+}
+
+// Cluster returns a registered cluster connection by name
+func (rt *Runtime) Cluster(name string) (ClusterConnection, bool) {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+	c, ok := rt.clusters[name]
+	return c, ok
+}
+
+// LinkRules returns the configured link rules sorted by name
+func (rt *Runtime) LinkRules() []LinkRule {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+	rules := make([]LinkRule, 0, len(rt.linkRules))
+	for _, r := range rt.linkRules {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules
+}
+
+// AlertRules returns the configured alert rules sorted by name
+func (rt *Runtime) AlertRules() []AlertRule {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+	rules := make([]AlertRule, 0, len(rt.alertRules))
+	for _, r := range rt.alertRules {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules
+}
+
+// DisplayFields returns the configured display fields of a kind sorted by name
+func (rt *Runtime) DisplayFields(kind string) []DisplayField {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+	var fields []DisplayField
+	for _, f := range rt.displayFields {
+		if f.Kind == kind {
+			fields = append(fields, f)
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+// HealthRules returns the configured health rules of a kind sorted by name
+func (rt *Runtime) HealthRules(kind string) []HealthRule {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+	var rules []HealthRule
+	for _, r := range rt.healthRules {
+		if r.Kind == kind {
+			rules = append(rules, r)
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules
+}
+
+// Snapshot returns a sorted copy of the whole configuration
+func (rt *Runtime) Snapshot() Snapshot {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+
+	snap := Snapshot{
+		Clusters:      make([]ClusterConnection, 0, len(rt.clusters)),
+		LinkRules:     make([]LinkRule, 0, len(rt.linkRules)),
+		AlertRules:    make([]AlertRule, 0, len(rt.alertRules)),
+		SavedViews:    make([]SavedView, 0, len(rt.savedViews)),
+		DisplayFields: make([]DisplayField, 0, len(rt.displayFields)),
+		HealthRules:   make([]HealthRule, 0, len(rt.healthRules)),
+		Invalid:       make([]InvalidConfig, 0, len(rt.invalid)),
+	}
+	for _, c := range rt.clusters {
+		snap.Clusters = append(snap.Clusters, c)
+	}
+	for _, r := range rt.linkRules {
+		snap.LinkRules = append(snap.LinkRules, r)
+	}
+	for _, r := range rt.alertRules {
+		snap.AlertRules = append(snap.AlertRules, r)
+	}
+	for _, v := range rt.savedViews {
+		snap.SavedViews = append(snap.SavedViews, v)
+	}
+	for _, f := range rt.displayFields {
+		snap.DisplayFields = append(snap.DisplayFields, f)
+	}
+	for _, r := range rt.healthRules {
+		snap.HealthRules = append(snap.HealthRules, r)
+	}
+	sort.Slice(snap.Clusters, func(i, j int) bool { return snap.Clusters[i].Name < snap.Clusters[j].Name })
+	sort.Slice(snap.LinkRules, func(i, j int) bool { return snap.LinkRules[i].Name < snap.LinkRules[j].Name })
+	sort.Slice(snap.AlertRules, func(i, j int) bool { return snap.AlertRules[i].Name < snap.AlertRules[j].Name })
+	sort.Slice(snap.SavedViews, func(i, j int) bool { return snap.SavedViews[i].Name < snap.SavedViews[j].Name })
+	sort.Slice(snap.DisplayFields, func(i, j int) bool { return snap.DisplayFields[i].Name < snap.DisplayFields[j].Name })
+	for _, c := range rt.invalid {
+		snap.Invalid = append(snap.Invalid, c)
+	}
+	sort.Slice(snap.HealthRules, func(i, j int) bool { return snap.HealthRules[i].Name < snap.HealthRules[j].Name })
+	sort.Slice(snap.Invalid, func(i, j int) bool {
+		a, b := snap.Invalid[i], snap.Invalid[j]
+		return a.Resource+"/"+a.Name < b.Resource+"/"+b.Name
+	})
+	return snap
+}
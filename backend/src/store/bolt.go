@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltStore persists collections as buckets in a local bbolt database file
+type BoltStore struct {
+	db *bolt.DB
+}
+
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Get(ctx context.Context, collection, key string) ([]byte, error) {
+	var value []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(collection))
+		if bucket == nil {
+			return ErrNotFound
+		}
+		v := bucket.Get([]byte(key))
+		if v == nil {
+			return ErrNotFound
+		}
+		// Values are only valid for the life of the transaction
+		value = append([]byte(nil), v...)
+		return nil
+	})
+	return value, err
+}
+
+func (s *BoltStore) Put(ctx context.Context, collection, key string, value []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(collection))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(key), value)
+	})
+}
+
+func (s *BoltStore) Delete(ctx context.Context, collection, key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(collection))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete([]byte(key))
+	})
+}
+
+func (s *BoltStore) List(ctx context.Context, collection string) ([]Entry, error) {
+	entries := []Entry{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(collection))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			entries = append(entries, Entry{Key: string(k), Value: append([]byte(nil), v...)})
+			return nil
+		})
+	})
+	return entries, err
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
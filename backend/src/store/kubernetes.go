@@ -0,0 +1,132 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"sort"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// storeRecordGVR is the StoreRecord CRD shipped with the Helm chart
+var storeRecordGVR = schema.GroupVersionResource{
+	Group:    "anakosmos.io",
+	Version:  "v1alpha1",
+	Resource: "storerecords",
+}
+
+const collectionLabel = "anakosmos.io/collection"
+
+// KubernetesStore persists entries as StoreRecord custom resources so that
+// in-cluster installs survive restarts and can be shared between replicas.
+type KubernetesStore struct {
+	client    dynamic.ResourceInterface
+	namespace string
+}
+
+func NewKubernetesStore(config *rest.Config, namespace string) (*KubernetesStore, error) {
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &KubernetesStore{
+		client:    dynamicClient.Resource(storeRecordGVR).Namespace(namespace),
+		namespace: namespace,
+	}, nil
+}
+
+// recordName maps an arbitrary collection/key pair to a valid object name
+func recordName(collection, key string) string {
+	sum := sha256.Sum256([]byte(collection + "/" + key))
+	return "rec-" + hex.EncodeToString(sum[:])[:40]
+}
+
+func (s *KubernetesStore) Get(ctx context.Context, collection, key string) ([]byte, error) {
+	obj, err := s.client.Get(ctx, recordName(collection, key), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return recordValue(obj)
+}
+
+func (s *KubernetesStore) Put(ctx context.Context, collection, key string, value []byte) error {
+	name := recordName(collection, key)
+	spec := map[string]interface{}{
+		"collection": collection,
+		"key":        key,
+		"value":      base64.StdEncoding.EncodeToString(value),
+	}
+
+	existing, err := s.client.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": storeRecordGVR.GroupVersion().String(),
+			"kind":       "StoreRecord",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": s.namespace,
+				"labels": map[string]interface{}{
+					collectionLabel: collection,
+				},
+			},
+			"spec": spec,
+		}}
+		_, err = s.client.Create(ctx, obj, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	existing.Object["spec"] = spec
+	_, err = s.client.Update(ctx, existing, metav1.UpdateOptions{})
+	return err
+}
+
+func (s *KubernetesStore) Delete(ctx context.Context, collection, key string) error {
+	err := s.client.Delete(ctx, recordName(collection, key), metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *KubernetesStore) List(ctx context.Context, collection string) ([]Entry, error) {
+	list, err := s.client.List(ctx, metav1.ListOptions{
+		LabelSelector: collectionLabel + "=" + collection,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(list.Items))
+	for i := range list.Items {
+		key, _, _ := unstructured.NestedString(list.Items[i].Object, "spec", "key")
+		value, err := recordValue(&list.Items[i])
+		if err != nil {
+			continue
+		}
+		entries = append(entries, Entry{Key: key, Value: value})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+func (s *KubernetesStore) Close() error {
+	return nil
+}
+
+func recordValue(obj *unstructured.Unstructured) ([]byte, error) {
+	encoded, _, _ := unstructured.NestedString(obj.Object, "spec", "value")
+	return base64.StdEncoding.DecodeString(encoded)
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// MemoryStore keeps everything in process memory. Data is lost on restart,
+// which is what we want for a stateless local run.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	data map[string]map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		data: make(map[string]map[string][]byte),
+	}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, collection, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.data[collection][key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (s *MemoryStore) Put(ctx context.Context, collection, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data[collection] == nil {
+		s.data[collection] = make(map[string][]byte)
+	}
+	s.data[collection][key] = append([]byte(nil), value...)
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, collection, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data[collection], key)
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, collection string) ([]Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]Entry, 0, len(s.data[collection]))
+	for key, value := range s.data[collection] {
+		entries = append(entries, Entry{Key: key, Value: append([]byte(nil), value...)})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"k8s.io/client-go/rest"
+)
+
+// ErrNotFound is returned when a key does not exist in a collection
+var ErrNotFound = errors.New("not found")
+
+// Entry is a single key/value pair stored in a collection
+type Entry struct {
+	Key   string
+	Value []byte
+}
+
+// Store is the persistence abstraction used by server-side features
+// (cluster registry, audit log, history, preferences, share links, ...).
+// Values are opaque bytes grouped into named collections.
+type Store interface {
+	Get(ctx context.Context, collection, key string) ([]byte, error)
+	Put(ctx context.Context, collection, key string, value []byte) error
+	Delete(ctx context.Context, collection, key string) error
+	List(ctx context.Context, collection string) ([]Entry, error)
+	Close() error
+}
+
+// Options selects and configures a storage backend
+type Options struct {
+	// Type is one of "memory", "bolt" or "kubernetes"
+	Type string
+	// Path is the database file used by the bolt backend
+	Path string
+	// Namespace holds the records of the kubernetes backend
+	Namespace string
+	// Config is the cluster used by the kubernetes backend
+	Config *rest.Config
+}
+
+// New creates the storage backend described by opts
+func New(opts Options) (Store, error) {
+	switch opts.Type {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "bolt":
+		if opts.Path == "" {
+			return nil, fmt.Errorf("bolt storage requires a file path")
+		}
+		return NewBoltStore(opts.Path)
+	case "kubernetes":
+		if opts.Config == nil {
+			return nil, fmt.Errorf("kubernetes storage requires a cluster config")
+		}
+		if opts.Namespace == "" {
+			return nil, fmt.Errorf("kubernetes storage requires a namespace")
+		}
+		return NewKubernetesStore(opts.Config, opts.Namespace)
+	default:
+		return nil, fmt.Errorf("unknown storage type: %s", opts.Type)
+	}
+}
+
+// GetJSON reads a key and decodes it into out
+func GetJSON(ctx context.Context, s Store, collection, key string, out interface{}) error {
+	data, err := s.Get(ctx, collection, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// PutJSON encodes value as JSON and stores it under key
+func PutJSON(ctx context.Context, s Store, collection, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, collection, key, data)
+}
@@ -1,8 +1,39 @@
 /* eslint-disable @typescript-eslint/no-explicit-any */
-import type { ClusterResource, ClusterLink, HelmReleaseInfo, ArgoAppInfo, HelmRelease, HelmHistoryEntry, ClusterInitResponse, LightResource } from './types';
+import type { ClusterResource, ClusterLink, HelmReleaseInfo, ArgoAppInfo, HelmRelease, HelmHistoryEntry, ClusterInitResponse, ClusterInitDelta, LightResource, DeleteImpact, SearchResponse, NamespaceSummary, ApiResourceInfo, WatchTransition, RolloutStatus, ActivityItem, ManagedFieldsSummary, ImageInspection, TrafficLayer, HostResolution, MessageCatalog, MaintenanceWindow, MaintenanceWindowRequest, Drill, DrillRequest, ResourceNote, ResourceNoteRequest, AccessGrant, AccessGrantRequest, ApplyResponse, HelmSyncResponse, ClusterCapabilities, ClusterBaseline, BaselineReport } from './types';
 import type { ArgoApplication } from './k8s-types';
 import yaml from 'js-yaml';
 
+// WebSocket subprotocols understood by the backend; keep in sync with
+// backend/src/k8s/protocols.go
+export const WS_PROTOCOLS = {
+  watch: 'anakosmos.watch.v1',
+  resource: 'anakosmos.resource.v1',
+  exec: 'anakosmos.exec.v1',
+  rollout: 'anakosmos.rollout.v1',
+  activity: 'anakosmos.activity.v1',
+} as const;
+
+// Namespaced CRD kinds of the graph (lowercased) -> [group/version, resource]
+const CRD_ENDPOINTS: Record<string, [string, string]> = {
+  applicationset: ['argoproj.io/v1alpha1', 'applicationsets'],
+  appproject: ['argoproj.io/v1alpha1', 'appprojects'],
+  kustomization: ['kustomize.toolkit.fluxcd.io/v1', 'kustomizations'],
+  fluxhelmrelease: ['helm.toolkit.fluxcd.io/v2', 'helmreleases'],
+  gitrepository: ['source.toolkit.fluxcd.io/v1', 'gitrepositories'],
+  helmrepository: ['source.toolkit.fluxcd.io/v1', 'helmrepositories'],
+  cluster: ['cluster.x-k8s.io/v1beta1', 'clusters'],
+  machinedeployment: ['cluster.x-k8s.io/v1beta1', 'machinedeployments'],
+  machine: ['cluster.x-k8s.io/v1beta1', 'machines'],
+  virtualservice: ['networking.istio.io/v1', 'virtualservices'],
+  istiogateway: ['networking.istio.io/v1', 'gateways'],
+  destinationrule: ['networking.istio.io/v1', 'destinationrules'],
+  servicemonitor: ['monitoring.coreos.com/v1', 'servicemonitors'],
+  podmonitor: ['monitoring.coreos.com/v1', 'podmonitors'],
+  prometheusrule: ['monitoring.coreos.com/v1', 'prometheusrules'],
+  scaledobject: ['keda.sh/v1alpha1', 'scaledobjects'],
+  verticalpodautoscaler: ['autoscaling.k8s.io/v1', 'verticalpodautoscalers'],
+};
+
 export class ApiError extends Error {
   public status: number;
   public details: any; // Contains the raw JSON body if available
@@ -26,6 +57,12 @@ export class KubeClient {
   public mode: 'proxy' | 'custom' = 'proxy';
   public baseUrl: string;
   public token?: string;
+  // Last init graph, so a reconnect can ask only for what changed since
+  private initSnapshot?: { hash: string; resources: Record<string, LightResource>; links: ClusterLink[] };
+  // Slice of the cluster loaded by init, pushed down to the server's List calls
+  private initFilter: { namespaces?: string[]; labelSelector?: string; kinds?: string[]; includeSystem?: boolean } = {};
+  // Ask init to list the cluster instead of the server's informer caches once
+  private initRefresh = false;
 
   constructor(mode: 'proxy' | 'custom' = 'proxy', baseUrl: string = '/api', token?: string) {
     this.mode = mode;
@@ -33,6 +70,25 @@ export class KubeClient {
     this.token = token;
   }
 
+  /**
+   * Make the next load bypass the server's informer caches
+   */
+  forceRefresh() {
+    this.initRefresh = true;
+    this.initSnapshot = undefined;
+  }
+
+  /**
+   * Only load some namespaces and/or resources matching a label selector.
+   * Cluster-scoped kinds are left out when namespaces are given; kinds
+   * restricts the load to those kinds. System namespaces (kube-system, ...)
+   * are hidden unless includeSystem is set.
+   */
+  setInitFilter(filter: { namespaces?: string[]; labelSelector?: string; kinds?: string[]; includeSystem?: boolean }) {
+    this.initFilter = filter;
+    this.initSnapshot = undefined;
+  }
+
   async checkConnection(): Promise<boolean> {
     try {
       let url: string;
@@ -97,6 +153,35 @@ export class KubeClient {
       }
     }
 
+    // Check the target/token first so a bad URL or token gets a clear error
+    if (this.mode === 'custom') {
+      const check = await fetch(`/api/clusters/validate?${params.toString()}`);
+      if (!check.ok) {
+        const result = await check.json().catch(() => null);
+        throw new Error(result?.error || `Cluster validation failed (Status: ${check.status})`);
+      }
+    }
+
+    if (this.initFilter.namespaces && this.initFilter.namespaces.length > 0) {
+      params.set('namespaces', this.initFilter.namespaces.join(','));
+    }
+    if (this.initFilter.labelSelector) {
+      params.set('labelSelector', this.initFilter.labelSelector);
+    }
+    if (this.initFilter.kinds && this.initFilter.kinds.length > 0) {
+      params.set('kinds', this.initFilter.kinds.join(','));
+    }
+    if (this.initFilter.includeSystem) {
+      params.set('includeSystem', 'true');
+    }
+    params.set('compact', 'true');
+    if (this.initRefresh) {
+      params.set('refresh', 'true');
+      this.initRefresh = false;
+    }
+    if (this.initSnapshot) {
+      params.set('since', this.initSnapshot.hash);
+    }
     const url = `/api/cluster/init?${params.toString()}`;
     
     if (onProgress) onProgress(30, 'Fetching resources...');
@@ -110,20 +195,59 @@ export class KubeClient {
 
     if (onProgress) onProgress(70, 'Processing data...');
 
-    const data: ClusterInitResponse = await res.json();
+    const data: ClusterInitResponse | ClusterInitDelta = await res.json();
+    const snapshot = this.applyInitResponse(data);
 
     if (onProgress) onProgress(90, 'Building resource map...');
 
     // Transform LightResource[] to Record<string, ClusterResource>
     const resources: Record<string, ClusterResource> = {};
     
-    for (const light of data.resources) {
+    for (const light of Object.values(snapshot.resources)) {
       resources[light.id] = this.lightToClusterResource(light);
     }
 
     if (onProgress) onProgress(100, 'Done');
 
-    return { resources, links: data.links };
+    return { resources, links: snapshot.links };
+  }
+
+  /**
+   * Merge a full or differential init response into the remembered snapshot
+   */
+  private applyInitResponse(data: ClusterInitResponse | ClusterInitDelta) {
+    let lights: Record<string, LightResource>;
+    let links: ClusterLink[];
+
+    if ('since' in data && this.initSnapshot?.hash === data.since) {
+      lights = { ...this.initSnapshot.resources };
+      for (const light of [...data.added, ...data.changed]) {
+        lights[light.id] = light;
+      }
+      for (const id of data.removed) {
+        delete lights[id];
+      }
+      const linkKey = (l: ClusterLink) => `${l.source}>${l.target}>${l.type}`;
+      const removed = new Set(data.removedLinks.map(linkKey));
+      links = [...this.initSnapshot.links.filter(l => !removed.has(linkKey(l))), ...data.addedLinks];
+    } else {
+      const full = data as ClusterInitResponse;
+      lights = {};
+      for (const light of full.resources) {
+        lights[light.id] = light;
+      }
+      links = full.compactLinks
+        ? full.compactLinks.map(([source, target, type, state]) => ({
+            source: full.resources[source].id,
+            target: full.resources[target].id,
+            type,
+            ...(state ? { state } : {}),
+          }))
+        : full.links;
+    }
+
+    this.initSnapshot = data.snapshotHash ? { hash: data.snapshotHash, resources: lights, links } : undefined;
+    return { resources: lights, links };
   }
 
   /**
@@ -141,8 +265,58 @@ export class KubeClient {
       labels: light.labels || {},
       ownerRefs: light.ownerRefs || [],
       creationTimestamp: light.creationTimestamp,
+      deletionTimestamp: light.deletionTimestamp,
+      ageSeconds: light.ageSeconds,
+      lastTransitionTime: light.lastTransitionTime,
+      staleSince: light.staleSince,
+      healthRule: light.healthRule,
+      healthMessage: light.healthMessage,
+      orphaned: light.orphaned,
       nodeName: light.nodeName,
+      restarts: light.restarts,
+      podSecurity: light.podSecurity,
+      serviceAccount: light.serviceAccount,
+      serviceAccountToken: light.serviceAccountToken,
+      imagePullSecrets: light.imagePullSecrets,
+      securityFlags: light.securityFlags,
       helmRelease: light.helmRelease,
+      canary: light.canary,
+      deployment: light.deployment,
+      service: light.service,
+      ingress: light.ingress,
+      externalHosts: light.externalHosts,
+      resourceDefaults: light.resourceDefaults,
+      note: light.note,
+      nodeCoverage: light.nodeCoverage,
+      networkPolicy: light.networkPolicy,
+      job: light.job,
+      rbac: light.rbac,
+      termination: light.termination,
+      gateway: light.gateway,
+      certificate: light.certificate,
+      argocd: light.argocd,
+      flux: light.flux,
+      capi: light.capi,
+      quota: light.quota,
+      disruption: light.disruption,
+      istio: light.istio,
+      keda: light.keda,
+      vpa: light.vpa,
+      monitoring: light.monitoring,
+      csiDriver: light.csiDriver,
+      attachment: light.attachment,
+      containers: light.containers,
+      cpuUsage: light.cpuUsage,
+      memoryUsage: light.memoryUsage,
+      cpuRequests: light.cpuRequests,
+      cpuLimits: light.cpuLimits,
+      memoryRequests: light.memoryRequests,
+      memoryLimits: light.memoryLimits,
+      cpuAllocatable: light.cpuAllocatable,
+      memoryAllocatable: light.memoryAllocatable,
+      warningEvents: light.warningEvents,
+      drill: light.drill,
+      extraFields: light.extraFields,
       // raw is intentionally NOT set - loaded on-demand via getResource()
     };
   }
@@ -280,6 +454,9 @@ export class KubeClient {
             endpoint = namespace 
                 ? `apis/argoproj.io/v1alpha1/namespaces/${namespace}/applications/${name}`
                 : `apis/argoproj.io/v1alpha1/applications/${name}`;
+        } else if (CRD_ENDPOINTS[k]) {
+            const [api, resource] = CRD_ENDPOINTS[k];
+            endpoint = `apis/${api}/namespaces/${namespace}/${resource}`;
         } else if (['pod', 'node', 'service', 'persistentvolumeclaim', 'configmap', 'secret', 'event'].includes(k)) {
             endpoint = namespace ? `api/v1/namespaces/${namespace}/${k}s` : `api/v1/${k}s`;
         } else if (['deployment', 'statefulset', 'daemonset', 'replicaset'].includes(k)) {
@@ -407,11 +584,12 @@ export class KubeClient {
         if (cleanBase) params.append('target', cleanBase);
         if (this.token) params.append('token', this.token);
     }
+    if (this.initFilter.includeSystem) params.append('includeSystem', 'true');
     
     const url = `${protocol}//${host}/api/sock/watch?${params.toString()}`;
     
     // Connect to WebSocket
-    let ws: WebSocket | null = new WebSocket(url);
+    let ws: WebSocket | null = new WebSocket(url, WS_PROTOCOLS.watch);
     
     ws.onmessage = (msg) => {
         try {
@@ -442,13 +620,14 @@ export class KubeClient {
 
   /**
    * Start watching a single resource with full object data
-   * Used for detailed views that need complete, live updates
+   * Used for detailed views that need complete, live updates. The first
+   * message may be a REPLAY event carrying recent transitions of the resource.
    */
   startSingleResourceWatch(
     kind: string, 
     namespace: string, 
     name: string, 
-    onEvent: (event: { type: string; resource: any }) => void
+    onEvent: (event: { type: string; resource: any; transitions?: WatchTransition[] }) => void
   ): () => void {
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
     const host = window.location.host;
@@ -468,7 +647,7 @@ export class KubeClient {
     
     console.log(`Starting single resource watch: ${kind}/${namespace}/${name}`);
     
-    let ws: WebSocket | null = new WebSocket(url);
+    let ws: WebSocket | null = new WebSocket(url, WS_PROTOCOLS.resource);
     
     ws.onmessage = (msg) => {
         try {
@@ -502,6 +681,92 @@ export class KubeClient {
     };
   }
   
+  /**
+   * Follow the rollout of a Deployment, StatefulSet or DaemonSet. The socket
+   * closes itself after the final complete/failed/timeout status.
+   */
+  startRolloutWatch(
+    kind: string,
+    namespace: string,
+    name: string,
+    onStatus: (status: RolloutStatus) => void
+  ): () => void {
+    const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+    const host = window.location.host;
+    const params = new URLSearchParams({ kind, namespace, name });
+
+    if (this.mode === 'custom' || this.mode === 'proxy') {
+        const cleanBase = this.baseUrl.replace(/\/+$/, '');
+        if (cleanBase) params.append('target', cleanBase);
+        if (this.token) params.append('token', this.token);
+    }
+
+    let ws: WebSocket | null = new WebSocket(`${protocol}//${host}/api/sock/rollout?${params.toString()}`, WS_PROTOCOLS.rollout);
+
+    ws.onmessage = (msg) => {
+        try {
+            onStatus(JSON.parse(msg.data));
+        } catch (e) {
+            console.error('Failed to parse rollout status', e);
+        }
+    };
+
+    ws.onclose = () => {
+        ws = null;
+    };
+
+    return () => {
+        if (ws) {
+            ws.close();
+            ws = null;
+        }
+    };
+  }
+
+  /**
+   * Subscribe to the activity feed of the current cluster, reconnecting after
+   * a few seconds when the socket drops
+   */
+  startActivityFeed(onItem: (item: ActivityItem) => void): () => void {
+    const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+    const host = window.location.host;
+    const params = new URLSearchParams();
+
+    if (this.mode === 'custom' || this.mode === 'proxy') {
+        const cleanBase = this.baseUrl.replace(/\/+$/, '');
+        if (cleanBase) params.append('target', cleanBase);
+        if (this.token) params.append('token', this.token);
+    }
+
+    let ws: WebSocket | null = null;
+    let stopped = false;
+    let retry: ReturnType<typeof setTimeout> | null = null;
+    const connect = () => {
+        ws = new WebSocket(`${protocol}//${host}/api/sock/activity?${params.toString()}`, WS_PROTOCOLS.activity);
+        ws.onmessage = (msg) => {
+            try {
+                onItem(JSON.parse(msg.data));
+            } catch (e) {
+                console.error('Failed to parse activity item', e);
+            }
+        };
+        ws.onclose = () => {
+            ws = null;
+            if (!stopped) retry = setTimeout(connect, 5000);
+        };
+    };
+    connect();
+
+    return () => {
+        stopped = true;
+        if (retry) clearTimeout(retry);
+        if (ws) {
+            ws.close();
+            ws = null;
+        }
+    };
+  }
+
   /**
    * Check if metrics-server is available
    */
@@ -1163,6 +1428,32 @@ export class KubeClient {
     }
   }
 
+  /**
+   * Report (dryRun) or repair the drift of a Helm release's resources from
+   * its stored manifest, without a new revision
+   */
+  async syncHelmRelease(namespace: string, releaseName: string, dryRun: boolean): Promise<HelmSyncResponse> {
+    const cleanBase = this.baseUrl.replace(/\/+$/, '');
+    const params = new URLSearchParams({
+      namespace,
+      name: releaseName
+    });
+
+    if (this.mode === 'custom') {
+      params.set('target', cleanBase);
+      if (this.token && this.token.trim().length > 0) {
+        params.set('token', this.token.trim());
+      }
+    }
+
+    const res = await fetch(`/api/helm/sync?${params.toString()}`, { method: dryRun ? 'GET' : 'POST' });
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Helm sync failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
   // ==========================================
   // Resource Creation Methods
   // ==========================================
@@ -1194,6 +1485,37 @@ export class KubeClient {
     return await res.json();
   }
 
+  // Applies a manifests folder dropped on the UI: YAML files and zip/tar
+  // archives, reported per file
+  async applyManifestBundle(files: File[], defaultNamespace: string): Promise<ApplyResponse> {
+    const params = new URLSearchParams();
+    if (defaultNamespace) params.set('defaultNamespace', defaultNamespace);
+
+    if (this.mode === 'custom') {
+      const cleanBase = this.baseUrl.replace(/\/+$/, '');
+      params.set('target', cleanBase);
+      if (this.token && this.token.trim().length > 0) {
+        params.set('token', this.token.trim());
+      }
+    }
+
+    const form = new FormData();
+    // webkitRelativePath keeps the folder structure of directory uploads
+    files.forEach(file => form.append('files', file, file.webkitRelativePath || file.name));
+
+    const res = await fetch(`/api/resources/apply-yaml?${params.toString()}`, {
+      method: 'POST',
+      body: form
+    });
+
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Apply failed: ${res.status}`, res.status, errText);
+    }
+
+    return await res.json();
+  }
+
   async installHelmFromRepo(params: {
     namespace: string;
     releaseName: string;
@@ -1369,6 +1691,416 @@ export class KubeClient {
     };
   }
 
+  async getDeleteImpact(resourceId: string): Promise<DeleteImpact> {
+    const params = new URLSearchParams({ id: resourceId });
+    if (this.mode === 'custom') {
+      const cleanBase = this.baseUrl.replace(/\/+$/, '');
+      params.set('target', cleanBase);
+      if (this.token && this.token.trim().length > 0) {
+        params.set('token', this.token.trim());
+      }
+    }
+
+    const res = await fetch(`/api/resources/impact?${params.toString()}`);
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Impact analysis failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
+  /**
+   * Per namespace resource counts, health rollup and quota usage, without
+   * the graph itself
+   */
+  async getNamespaceSummaries(): Promise<NamespaceSummary[]> {
+    const params = new URLSearchParams();
+    if (this.mode === 'custom') {
+      const cleanBase = this.baseUrl.replace(/\/+$/, '');
+      params.set('target', cleanBase);
+      if (this.token && this.token.trim().length > 0) {
+        params.set('token', this.token.trim());
+      }
+    }
+
+    const res = await fetch(`/api/cluster/namespaces?${params.toString()}`);
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Namespace summary failed: ${res.status}`, res.status, errText);
+    }
+    const body = await res.json();
+    return body.namespaces;
+  }
+
+  /**
+   * Search the cached cluster graph by name, label, annotation, kind and
+   * namespace, best matches first, a page at a time
+   */
+  async searchResources(q: string, options: { kind?: string[]; namespace?: string; limit?: number; offset?: number } = {}): Promise<SearchResponse> {
+    const params = new URLSearchParams({ q, limit: String(options.limit ?? 50), offset: String(options.offset ?? 0) });
+    options.kind?.forEach(k => params.append('kind', k));
+    if (options.namespace) {
+      params.set('namespace', options.namespace);
+    }
+    if (this.initFilter.includeSystem) {
+      params.set('includeSystem', 'true');
+    }
+    if (this.mode === 'custom') {
+      const cleanBase = this.baseUrl.replace(/\/+$/, '');
+      params.set('target', cleanBase);
+      if (this.token && this.token.trim().length > 0) {
+        params.set('token', this.token.trim());
+      }
+    }
+
+    const res = await fetch(`/api/cluster/search?${params.toString()}`);
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Search failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
+  /**
+   * Search the kinds served by the cluster (for autocomplete), best matches first
+   */
+  async searchKinds(search: string, limit = 20): Promise<ApiResourceInfo[]> {
+    const params = new URLSearchParams({ search, limit: String(limit) });
+    if (this.mode === 'custom') {
+      const cleanBase = this.baseUrl.replace(/\/+$/, '');
+      params.set('target', cleanBase);
+      if (this.token && this.token.trim().length > 0) {
+        params.set('token', this.token.trim());
+      }
+    }
+
+    const res = await fetch(`/api/meta/resources?${params.toString()}`);
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Kind search failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
+  async getFieldManagers(uid: string): Promise<ManagedFieldsSummary> {
+    const params = new URLSearchParams({ uid });
+    if (this.mode === 'custom') {
+      const cleanBase = this.baseUrl.replace(/\/+$/, '');
+      params.set('target', cleanBase);
+      if (this.token && this.token.trim().length > 0) {
+        params.set('token', this.token.trim());
+      }
+    }
+
+    const res = await fetch(`/api/resources/managers?${params.toString()}`);
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Field managers request failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
+  /**
+   * Inspect an image in its registry. Passing the pod lets the backend use
+   * its pull secrets and default the image to the container's.
+   */
+  async inspectImage(opts: { image?: string; namespace?: string; pod?: string; container?: string; platform?: string }): Promise<ImageInspection> {
+    const params = new URLSearchParams();
+    for (const [key, value] of Object.entries(opts)) {
+      if (value) params.set(key, value);
+    }
+    if (this.mode === 'custom') {
+      const cleanBase = this.baseUrl.replace(/\/+$/, '');
+      params.set('target', cleanBase);
+      if (this.token && this.token.trim().length > 0) {
+        params.set('token', this.token.trim());
+      }
+    }
+
+    const res = await fetch(`/api/images/inspect?${params.toString()}`);
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Image inspection failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
+  /**
+   * Observed traffic between workloads from mesh telemetry. Resolves to null
+   * when the layer isn't enabled or the cluster isn't the local one.
+   */
+  async getObservedTraffic(): Promise<TrafficLayer | null> {
+    if (this.mode === 'custom') return null;
+
+    const res = await fetch('/api/traffic');
+    if (res.status === 404) return null;
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Traffic request failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
+  /**
+   * Localized backend messages; the browser's Accept-Language picks the
+   * language unless one is given
+   */
+  async getMessageCatalog(lang?: string): Promise<MessageCatalog> {
+    const params = new URLSearchParams();
+    if (lang) params.set('lang', lang);
+
+    const res = await fetch(`/api/i18n?${params.toString()}`);
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Message catalog request failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
+  /**
+   * Maintenance windows, optionally only those in progress
+   */
+  async getMaintenanceWindows(activeOnly = false): Promise<MaintenanceWindow[]> {
+    const res = await fetch(`/api/maintenance${activeOnly ? '?active=true' : ''}`);
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Maintenance request failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
+  /**
+   * Declare a maintenance window (admin only)
+   */
+  async createMaintenanceWindow(request: MaintenanceWindowRequest): Promise<MaintenanceWindow> {
+    const res = await fetch('/api/maintenance', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(request)
+    });
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Creating maintenance window failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
+  /**
+   * End or cancel a maintenance window (admin only)
+   */
+  async deleteMaintenanceWindow(id: string): Promise<void> {
+    const res = await fetch(`/api/maintenance/${encodeURIComponent(id)}`, { method: 'DELETE' });
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Deleting maintenance window failed: ${res.status}`, res.status, errText);
+    }
+  }
+
+  /**
+   * Incident-response drills in progress (admin only)
+   */
+  async getDrills(): Promise<Drill[]> {
+    const res = await fetch('/api/drills');
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Drills request failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
+  /**
+   * Start a drill: a resource reported unhealthy, or a cluster unreachable,
+   * for a while (admin only)
+   */
+  async startDrill(request: DrillRequest): Promise<Drill> {
+    const res = await fetch('/api/drills', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(request)
+    });
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Starting drill failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
+  /**
+   * End a drill early (admin only)
+   */
+  async endDrill(id: string): Promise<void> {
+    const res = await fetch(`/api/drills/${encodeURIComponent(id)}`, { method: 'DELETE' });
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Ending drill failed: ${res.status}`, res.status, errText);
+    }
+  }
+
+  /**
+   * Optional APIs and features of a cluster ("local" or a registered
+   * ClusterConnection), to enable only what works there
+   */
+  async getClusterCapabilities(clusterId = 'local', refresh = false): Promise<ClusterCapabilities> {
+    const res = await fetch(`/api/clusters/${encodeURIComponent(clusterId)}/capabilities${refresh ? '?refresh=true' : ''}`);
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Capabilities request failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
+  /**
+   * Golden baseline of a cluster ("local" or a registered ClusterConnection);
+   * null when none was marked
+   */
+  async getBaseline(clusterId = 'local'): Promise<ClusterBaseline | null> {
+    const res = await fetch(`/api/cluster/baseline?cluster=${encodeURIComponent(clusterId)}`);
+    if (res.status === 404) return null;
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Baseline request failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
+  /**
+   * Mark the current state of a cluster as its baseline (admin only)
+   */
+  async markBaseline(clusterId = 'local'): Promise<ClusterBaseline> {
+    const res = await fetch(`/api/cluster/baseline?cluster=${encodeURIComponent(clusterId)}`, { method: 'POST' });
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Marking baseline failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
+  /**
+   * Remove the baseline of a cluster (admin only)
+   */
+  async deleteBaseline(clusterId = 'local'): Promise<void> {
+    const res = await fetch(`/api/cluster/baseline?cluster=${encodeURIComponent(clusterId)}`, { method: 'DELETE' });
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Deleting baseline failed: ${res.status}`, res.status, errText);
+    }
+  }
+
+  /**
+   * Latest deviations of a cluster from its baseline; refresh compares again
+   */
+  async getBaselineDiff(clusterId = 'local', refresh = false): Promise<BaselineReport> {
+    const params = new URLSearchParams({ cluster: clusterId });
+    if (refresh) params.set('refresh', 'true');
+    const res = await fetch(`/api/cluster/baseline-diff?${params}`);
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Baseline diff request failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
+  /**
+   * Break-glass access grants: all of them for admins, otherwise the caller's own
+   */
+  async getAccessGrants(activeOnly = false): Promise<AccessGrant[]> {
+    const res = await fetch(`/api/access-grants${activeOnly ? '?active=true' : ''}`);
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Access grants request failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
+  /**
+   * Grant a user temporary exec/log access to a namespace (admin only)
+   */
+  async createAccessGrant(request: AccessGrantRequest): Promise<AccessGrant> {
+    const res = await fetch('/api/access-grants', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(request)
+    });
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Creating access grant failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
+  /**
+   * Revoke an access grant early (admin only)
+   */
+  async revokeAccessGrant(id: string): Promise<void> {
+    const res = await fetch(`/api/access-grants/${encodeURIComponent(id)}`, { method: 'DELETE' });
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Revoking access grant failed: ${res.status}`, res.status, errText);
+    }
+  }
+
+  /**
+   * Notes and pins on resources of the current cluster
+   */
+  async getResourceNotes(): Promise<ResourceNote[]> {
+    const res = await fetch('/api/resources/notes');
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Resource notes request failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
+  /**
+   * Set the note or pin of a resource; an empty, unpinned note removes it
+   */
+  async setResourceNote(request: ResourceNoteRequest): Promise<ResourceNote | null> {
+    const res = await fetch('/api/resources/notes', {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(request)
+    });
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Saving resource note failed: ${res.status}`, res.status, errText);
+    }
+    const body = await res.json();
+    return body.status === 'deleted' ? null : body;
+  }
+
+  /**
+   * Remove the note of a resource
+   */
+  async deleteResourceNote(resourceId: string): Promise<void> {
+    const res = await fetch(`/api/resources/notes?id=${encodeURIComponent(resourceId)}`, { method: 'DELETE' });
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Deleting resource note failed: ${res.status}`, res.status, errText);
+    }
+  }
+
+  /**
+   * Resolve external endpoint hostnames from the backend's point of view
+   */
+  async resolveHosts(hosts: string[]): Promise<HostResolution[]> {
+    const params = new URLSearchParams();
+    hosts.forEach(host => params.append('host', host));
+    if (this.mode === 'custom') {
+      const cleanBase = this.baseUrl.replace(/\/+$/, '');
+      params.set('target', cleanBase);
+      if (this.token && this.token.trim().length > 0) {
+        params.set('token', this.token.trim());
+      }
+    }
+
+    const res = await fetch(`/api/external/resolve?${params.toString()}`);
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new ApiError(`Host resolution failed: ${res.status}`, res.status, errText);
+    }
+    return await res.json();
+  }
+
   async deleteResource(namespace: string, kind: string, name: string): Promise<void> {
     try {
         const cleanBase = this.baseUrl.replace(/\/+$/, '');
@@ -4,12 +4,26 @@ export interface ClusterResource {
   kind: string;
   namespace: string;
   status: string;
-  health?: 'ok' | 'warning' | 'error';
+  health?: 'ok' | 'warning' | 'error' | 'flapping';
   labels: Record<string, string>;
   ownerRefs: string[]; // IDs of owners
   creationTimestamp: string;
+  deletionTimestamp?: string; // set while the resource is Terminating
+  ageSeconds?: number; // age when received from the server
+  lastTransitionTime?: string; // latest status condition transition
+  staleSince?: string; // unhealthy since (unset while healthy)
+  healthRule?: string; // configured HealthRule that set status and health
+  healthMessage?: string; // what the HealthRule said about them
+  orphaned?: boolean; // owner gone, or a ReplicaSet scaled to zero with no pods
   // Pod-specific
   nodeName?: string; // For Pods: which node they're scheduled on
+  restarts?: number; // For Pods: total container restarts
+  podSecurity?: PodSecurityInfo; // For Namespaces (declared levels) and workloads (evaluated level)
+  // ServiceAccount usage (Pods and workloads)
+  serviceAccount?: string;
+  serviceAccountToken?: boolean;
+  imagePullSecrets?: string[];
+  securityFlags?: string[]; // e.g. 'default-serviceaccount-token'
   // Dynamic metrics or other props
   cpu?: string;
   memory?: string;
@@ -21,6 +35,121 @@ export interface ClusterResource {
   
   // ArgoCD management info  
   argoApp?: ArgoAppInfo;
+
+  // Progressive delivery state (Argo Rollouts and Flagger Canaries)
+  canary?: CanaryInfo;
+
+  // Deployments: replica counts, rollout strategy and revision
+  deployment?: DeploymentInfo;
+
+  // Services: type, ports and addresses (a LoadBalancer still without one
+  // after a few minutes is a warning)
+  service?: ServiceInfo;
+
+  // Ingresses: class, hosts, TLS (their Secrets come as 'config' links) and
+  // load balancer addresses
+  ingress?: IngressInfo;
+
+  // Hostnames outside the cluster (ExternalName Services, Ingress/HTTPRoute hosts)
+  externalHosts?: string[];
+
+  // Workloads whose containers leave requests/limits unset
+  resourceDefaults?: ResourceDefaults;
+
+  // User note or pin stored for the resource
+  note?: ResourceNote;
+
+  // DaemonSets: eligible nodes without a ready pod
+  nodeCoverage?: NodeCoverage;
+
+  // NetworkPolicies: what they restrict (applied pods come as 'policy' links)
+  networkPolicy?: NetworkPolicyInfo;
+
+  // Jobs: completion, parallelism and backoff progress
+  job?: JobProgress;
+
+  // Roles: rule lines; bindings: granted role and subjects
+  rbac?: RBACInfo;
+
+  // Gateway API Gateways (listeners, addresses; attached HTTPRoutes come as
+  // 'network' links) and GatewayClasses (controller)
+  gateway?: GatewayInfo;
+
+  // Namespaces being deleted (namespaced resources come with a 'containment'
+  // link to their Namespace)
+  termination?: NamespaceTermination;
+
+  // cert-manager Certificates (secret, issuer, validity; both come as
+  // 'config' links) and Issuers/ClusterIssuers (issuer type)
+  certificate?: CertificateInfo;
+
+  // ArgoCD Applications (project, source; their AppProject comes as a
+  // 'policy' link, the ApplicationSet generating them as an 'owner' link),
+  // ApplicationSets (generators) and AppProjects (allowed repos/destinations)
+  argocd?: ArgoCDInfo;
+
+  // Flux sources (url, fetched revision), Kustomizations and HelmReleases
+  // (kind FluxHelmRelease; their source comes as a 'config' link)
+  flux?: FluxInfo;
+
+  // Cluster API Clusters, MachineDeployments and Machines (Machines come
+  // with an 'owner' link from the Node they back)
+  capi?: CAPIInfo;
+
+  // ResourceQuotas (hard vs used, pressure) and LimitRanges (limit lines);
+  // both come with a 'policy' link to their Namespace
+  quota?: QuotaInfo;
+
+  // PodDisruptionBudgets: budget and healthy pods (covered pods, Deployments
+  // and StatefulSets come as 'policy' links)
+  disruption?: DisruptionInfo;
+
+  // Istio VirtualServices (the Services they route to and the Gateways they
+  // are bound to come as 'network' links), Gateways (kind IstioGateway) and
+  // DestinationRules (a 'policy' link to their Service)
+  istio?: IstioInfo;
+
+  // KEDA ScaledObjects (the workload they scale comes as an 'owner' link)
+  keda?: KEDAInfo;
+
+  // VerticalPodAutoscalers (the workload they size comes as an 'owner'
+  // link) and their recommended requests
+  vpa?: VPAInfo;
+
+  // prometheus-operator ServiceMonitors and PodMonitors (the Services and
+  // Pods they scrape come as 'network' links) and PrometheusRules
+  monitoring?: MonitoringInfo;
+
+  // CSIDrivers (StorageClasses they provision come as 'storage' links) and
+  // VolumeAttachments ('storage' links to their Node and PersistentVolume)
+  csiDriver?: CSIDriverInfo;
+  attachment?: VolumeAttachmentInfo;
+
+  // Pods: ready/total containers, per-container restarts and images, and the
+  // dominant waiting reason (e.g. CrashLoopBackOff)
+  containers?: ContainerSummary;
+
+  // Pods and Nodes: CPU (millicores) and memory (bytes) used, from
+  // metrics-server when installed; requests/limits of a Pod or summed over
+  // the pods of a Node; allocatable of a Node
+  cpuUsage?: number;
+  memoryUsage?: number;
+  cpuRequests?: number;
+  cpuLimits?: number;
+  memoryRequests?: number;
+  memoryLimits?: number;
+  cpuAllocatable?: number;
+  memoryAllocatable?: number;
+
+  // Latest Warning event reasons (at most 3) seen in the last hour
+  warningEvents?: WarningEvent[];
+
+  // Set while an incident-response drill overrides the health
+  drill?: boolean;
+
+  // Values extracted by JSONPath: CRD printer columns and DisplayField
+  // resources configured for the kind, by column name or label
+  extraFields?: Record<string, string>;
 }
 
 /**
@@ -35,6 +164,50 @@ export interface HelmReleaseInfo {
   revision?: number;
 }
 
+/**
+ * Progressive delivery state of an Argo Rollout or a Flagger Canary
+ */
+export interface CanaryInfo {
+  provider: 'argo-rollouts' | 'flagger';
+  strategy: 'canary' | 'blueGreen';
+  phase?: string;
+  canaryWeight: number; // percent of traffic on the new version
+  stableWeight: number;
+  step?: string; // current/total steps (Argo) or analysis iteration (Flagger)
+  stableService?: string;
+  canaryService?: string;
+  stableWorkload?: string;
+  canaryWorkload?: string;
+  workloadRef?: { kind: string; name: string };
+}
+
+/**
+ * Requests/limits a workload template leaves unset and the namespace
+ * LimitRange defaults that will apply to its pods
+ */
+export interface ResourceDefaults {
+  containers: string[];
+  unset: string[]; // e.g. 'requests.cpu', 'limits.memory'
+  limitRange?: string; // unset when the namespace has none
+  defaults?: Record<string, string>;
+  qosClass: 'BestEffort' | 'Burstable' | 'Guaranteed';
+}
+
+/**
+ * PodSecurity admission data: namespaces carry the declared levels, workloads
+ * the strictest level they satisfy and the checks failing stricter levels
+ */
+export type PodSecurityLevel = 'privileged' | 'baseline' | 'restricted';
+
+export interface PodSecurityInfo {
+  enforce?: PodSecurityLevel;
+  audit?: PodSecurityLevel;
+  warn?: PodSecurityLevel;
+  level?: PodSecurityLevel;
+  baseline?: string[];
+  restricted?: string[];
+}
+
 /**
  * Helm release history entry (from helm history command)
  */
@@ -167,7 +340,9 @@ export interface HelmRelease {
 export interface ClusterLink {
   source: string; // ID
   target: string; // ID
-  type: 'owner' | 'network' | 'config' | 'storage';
+  type: 'owner' | 'network' | 'config' | 'storage' | 'policy' | 'rbac' | 'containment';
+  // Service -> Pod links from EndpointSlices: whether the endpoint is ready
+  state?: 'ready' | 'notReady';
 }
 
 /**
@@ -180,25 +355,760 @@ export interface LightResource {
   namespace: string;
   kind: string;
   status: string;
-  health?: 'ok' | 'warning' | 'error';
+  health?: 'ok' | 'warning' | 'error' | 'flapping';
   labels: Record<string, string>;
   ownerRefs: string[];
   creationTimestamp: string;
+  deletionTimestamp?: string;
+  ageSeconds?: number;
+  lastTransitionTime?: string;
+  staleSince?: string;
+  healthRule?: string;
+  healthMessage?: string;
+  orphaned?: boolean;
   // Extra fields for link calculation (not needed in UI state)
   nodeName?: string;
+  restarts?: number;
+  podSecurity?: PodSecurityInfo;
+  serviceAccount?: string;
+  serviceAccountToken?: boolean;
+  imagePullSecrets?: string[];
+  securityFlags?: string[];
   selector?: Record<string, string>;
+  selectorExpression?: string; // whole workload selector when it uses matchExpressions
   scaleTargetRef?: { kind: string; name: string };
   storageClassName?: string;
+  claimRef?: string;      // PVs: namespace/name of the bound PVC
+  volumeNodes?: string[]; // PVs: nodes a local volume is pinned to
   ingressBackends?: { serviceName: string }[];
   volumes?: { type: string; name: string }[];
   envRefs?: { type: string; name: string }[];
   helmRelease?: HelmReleaseInfo;
+  canary?: CanaryInfo;
+  deployment?: DeploymentInfo;
+  service?: ServiceInfo;
+  ingress?: IngressInfo;
+  externalHosts?: string[];
+  resourceDefaults?: ResourceDefaults;
+  note?: ResourceNote;
+  nodeCoverage?: NodeCoverage;
+  networkPolicy?: NetworkPolicyInfo;
+  job?: JobProgress;
+  rbac?: RBACInfo;
+  termination?: NamespaceTermination;
+  gateway?: GatewayInfo;
+  certificate?: CertificateInfo;
+  argocd?: ArgoCDInfo;
+  flux?: FluxInfo;
+  capi?: CAPIInfo;
+  quota?: QuotaInfo;
+  disruption?: DisruptionInfo;
+  istio?: IstioInfo;
+  keda?: KEDAInfo;
+  vpa?: VPAInfo;
+  monitoring?: MonitoringInfo;
+  csiDriver?: CSIDriverInfo;
+  attachment?: VolumeAttachmentInfo;
+  containers?: ContainerSummary;
+  cpuUsage?: number;
+  memoryUsage?: number;
+  cpuRequests?: number;
+  cpuLimits?: number;
+  memoryRequests?: number;
+  memoryLimits?: number;
+  cpuAllocatable?: number;
+  memoryAllocatable?: number;
+  warningEvents?: WarningEvent[];
+  drill?: boolean;
+  extraFields?: Record<string, string>;
+}
+
+/**
+ * DNS resolution of an external endpoint hostname (/api/external/resolve)
+ */
+export interface HostResolution {
+  host: string;
+  addresses: string[];
+  cname?: string;
+  error?: string;
+  wildcard?: boolean; // *.example.com hosts aren't resolved
+  checkedAt: string;
+}
+
+/**
+ * Workload-to-workload traffic observed by a service mesh (/api/traffic)
+ */
+export interface TrafficLink {
+  source: string; // ID
+  target: string; // ID
+  type: 'traffic';
+  mesh: 'istio' | 'linkerd';
+  requestRate: number; // requests per second
+  errorRate: number; // failed fraction, 0-1
+}
+
+export interface TrafficLayer {
+  meshes: string[];
+  window: string;
+  refreshSeconds: number;
+  updatedAt?: string;
+  error?: string;
+  links: TrafficLink[];
+}
+
+/**
+ * Backend message catalog (/api/i18n). Ids are status.<status>,
+ * health.<health>, flag.<securityFlag>, qos.<class> and error.<name>; error
+ * responses carry their id in the X-Message-Id header.
+ */
+/**
+ * A declared maintenance window from /api/maintenance. While active, alerts
+ * for the clusters and namespaces it covers are silenced.
+ */
+export interface MaintenanceWindow {
+  id: string;
+  title: string;
+  reason?: string;
+  clusters?: string[];   // target URLs or "local"; empty = every cluster
+  namespaces?: string[]; // empty = whole clusters
+  start: string;
+  end: string;
+  createdBy: string;
+  createdAt: string;
+}
+
+export interface MaintenanceWindowRequest {
+  title: string;
+  reason?: string;
+  clusters?: string[];
+  namespaces?: string[];
+  start?: string;    // defaults to now
+  end?: string;
+  duration?: string; // e.g. "2h", when end isn't given
+}
+
+/**
+ * A synthetic failure for incident-response drills: one resource reported as
+ * error or warning, or a cluster reported unreachable, without touching it
+ */
+export interface Drill {
+  id: string;
+  cluster: string; // "local" or a registered ClusterConnection
+  kind?: string;
+  namespace?: string;
+  name?: string;
+  health?: 'error' | 'warning';
+  unreachable?: boolean;
+  reason?: string;
+  start: string;
+  end: string;
+  createdBy: string;
+}
+
+export interface DrillRequest {
+  cluster?: string;
+  kind?: string;
+  namespace?: string;
+  name?: string;
+  health?: 'error' | 'warning'; // defaults to error
+  unreachable?: boolean;
+  reason?: string;
+  duration: string; // e.g. "15m", at most 24h
+}
+
+/**
+ * Nodes a DaemonSet should run on (node selector, required affinity and
+ * tolerations vs taints) compared with where its pods are ready.
+ */
+export interface NodeCoverage {
+  eligible: number;
+  covered: number;
+  uncovered: { node: string; reason: string }[];
+  excluded?: Record<string, number>; // reason -> node count
+}
+
+export interface RBACInfo {
+  rules?: string[];    // e.g. "get,list: pods, services"
+  roleRef?: string;    // bindings: Kind/Name granted
+  subjects?: string[]; // bindings: Kind/namespace/name
+}
+
+export interface GatewayInfo {
+  className?: string;   // Gateways: their GatewayClass
+  controller?: string;  // GatewayClasses: controllerName
+  listeners?: string[]; // "name protocol/port hostname"
+  addresses?: string[];
+}
+
+export interface CertificateInfo {
+  secretName?: string;  // Certificates: Secret holding the key pair
+  issuerKind?: string;  // Issuer, ClusterIssuer or group/Kind of external issuers
+  issuerName?: string;
+  dnsNames?: string[];
+  notAfter?: string;
+  renewalTime?: string;
+  issuerType?: string;  // Issuers: acme, ca, selfSigned, vault, venafi
+}
+
+export interface ArgoCDInfo {
+  project?: string;       // Applications: their AppProject
+  repoURL?: string;       // Applications: what they sync
+  path?: string;
+  chart?: string;
+  revision?: string;      // last synced revision
+  generators?: string[];  // ApplicationSets: e.g. git, list, matrix
+  sourceRepos?: number;   // AppProjects: allowed repos and destinations
+  destinations?: number;
+  message?: string;       // ApplicationSets: error condition message
+}
+
+export interface FluxInfo {
+  sourceKind?: string;  // Kustomizations/HelmReleases: their source
+  sourceName?: string;  // namespace/name
+  path?: string;        // Kustomizations
+  chart?: string;       // HelmReleases
+  url?: string;         // sources
+  revision?: string;    // last applied, or a source's fetched artifact
+  suspended?: boolean;
+  message?: string;     // Ready condition message
+}
+
+export interface CAPIInfo {
+  clusterName?: string;  // MachineDeployments/Machines: their CAPI Cluster
+  phase?: string;        // e.g. Provisioning, Running, Deleting
+  nodeName?: string;     // Machines: the Node they back
+  providerID?: string;
+  version?: string;      // Kubernetes version
+  replicas?: string;     // MachineDeployments: "ready/desired"
+  failureMessage?: string;
+}
+
+export interface QuotaInfo {
+  hard?: Record<string, string>;  // ResourceQuotas: limit per resource
+  used?: Record<string, string>;
+  pressure?: number;              // highest used/hard, in percent
+  limits?: string[];              // LimitRanges: "Type resource key=value ..."
+}
+
+export interface WarningEvent {
+  reason: string;  // e.g. FailedScheduling
+  message: string; // most recent
+  count: number;
+  lastSeen: string;
+}
+
+export interface DisruptionInfo {
+  minAvailable?: string;   // count or percentage
+  maxUnavailable?: string;
+  currentHealthy: number;
+  desiredHealthy: number;
+  expectedPods: number;
+  disruptionsAllowed: number; // 0 blocks evictions, e.g. node drains
+}
+
+export interface IstioInfo {
+  hosts?: string[];
+  gateways?: string[];      // VirtualServices: namespace/name, or "mesh"
+  destinations?: string[];  // Services (namespace/name) routed to or configured
+  subsets?: string[];       // DestinationRules
+  message?: string;         // first istiod validation message code, e.g. IST0101
+}
+
+export interface KEDAInfo {
+  triggers?: string[];    // trigger types, e.g. prometheus, kafka, cron
+  minReplicas: number;
+  maxReplicas?: number;
+  message?: string;       // Ready condition message when not ready
+}
+
+export interface VPAInfo {
+  updateMode: string;     // Off, Initial, Recreate, InPlaceOrRecreate, Auto
+  recommendations?: { container: string; cpu?: string; memory?: string }[];
+  message?: string;       // why there is no recommendation, or what is wrong
+}
+
+export interface MonitoringInfo {
+  selector?: string;      // monitors: targets, in label query syntax
+  namespaces?: string[];  // where targets are looked for (default: own)
+  anyNamespace?: boolean;
+  endpoints?: string[];   // scraped ports
+  groups?: number;        // PrometheusRules
+  rules?: number;
+}
+
+export interface CSIDriverInfo {
+  attachRequired: boolean; // volumes get a VolumeAttachment per node
+  podInfoOnMount?: boolean;
+  storageCapacity?: boolean;
+  fsGroupPolicy?: string;
+  lifecycleModes?: string[]; // Persistent, Ephemeral
+}
+
+export interface VolumeAttachmentInfo {
+  attacher: string;
+  persistentVolume?: string;
+  attached: boolean;
+  error?: string; // attach error, or detach error while being deleted
+}
+
+export interface DeploymentInfo {
+  desired: number;
+  ready: number;
+  updated: number;
+  available: number;
+  strategy: string;        // RollingUpdate or Recreate
+  maxSurge?: string;       // count or percentage
+  maxUnavailable?: string;
+  revision?: string;       // deployment.kubernetes.io/revision
+  paused?: boolean;
+}
+
+export interface ServiceInfo {
+  type: string;            // ClusterIP, NodePort, LoadBalancer, ExternalName
+  clusterIP?: string;
+  ports?: ServicePort[];
+  externalIPs?: string[];
+  loadBalancer?: string[]; // IPs and hostnames from status.loadBalancer
+}
+
+export interface ServicePort {
+  name?: string;
+  protocol: string;
+  port: number;
+  targetPort?: string;
+  nodePort?: number;
+}
+
+export interface IngressInfo {
+  className?: string;
+  hosts?: string[];
+  tls?: { secretName?: string; hosts?: string[] }[];
+  loadBalancer?: string[]; // IPs and hostnames from status.loadBalancer
+}
+
+export interface ContainerSummary {
+  count: number;
+  ready: number;
+  waitingReason?: string; // e.g. CrashLoopBackOff, Init:ImagePullBackOff
+  containers: ContainerInfo[];
+}
+
+export interface ContainerInfo {
+  name: string;
+  image: string;
+  ready: boolean;
+  restarts: number;
+  state?: 'running' | 'waiting' | 'terminated';
+  reason?: string;
+}
+
+export interface NamespaceTermination {
+  since?: string;
+  blockers?: string[]; // messages of the deletion conditions holding it up
+}
+
+export interface JobProgress {
+  completions?: number; // unset: any single successful pod completes the Job
+  parallelism: number;
+  succeeded: number;
+  failed: number;
+  active: number;
+  backoffLimit: number;
+  nearBackoffLimit?: boolean; // one more failure marks the Job Failed
+  startTime?: string;
+  completionTime?: string;
+  durationSeconds?: number; // until now for unfinished Jobs
+}
+
+export interface NetworkPolicyInfo {
+  policyTypes: string[]; // Ingress, Egress
+  ingressRules: number;
+  egressRules: number;
+  denyAllIngress?: boolean;
+  denyAllEgress?: boolean;
+  peers?: string[]; // e.g. "namespace team=a, pod app=web" or a CIDR
+}
+
+/**
+ * A note or pin on a graph resource, stored by the backend rather than on
+ * the object itself. Notes past their `until` are no longer returned.
+ */
+export interface ResourceNote {
+  cluster: string;
+  resourceId: string;
+  kind: string;
+  namespace?: string;
+  name: string;
+  text?: string;
+  pinned?: boolean;
+  until?: string;
+  author: string;
+  updatedAt: string;
+}
+
+export interface ResourceNoteRequest {
+  resourceId: string;
+  kind: string;
+  namespace?: string;
+  name: string;
+  text?: string;    // an empty, unpinned note deletes it
+  pinned?: boolean;
+  until?: string;
+}
+
+/**
+ * Time-bounded exec/log access to a namespace for one user (break-glass).
+ * Revoked and expired grants are kept with their audit trail of uses.
+ */
+export interface AccessGrant {
+  id: string;
+  user: string;
+  cluster: string;
+  namespace: string;
+  verbs: ('exec' | 'logs')[];
+  reason: string;
+  grantedBy: string;
+  createdAt: string;
+  expiresAt: string;
+  revokedBy?: string;
+  revokedAt?: string;
+  uses?: { time: string; verb: string; pod?: string; command?: string[] }[];
+}
+
+export interface AccessGrantRequest {
+  user: string;
+  namespace: string;
+  cluster?: string;            // defaults to the current cluster
+  verbs?: ('exec' | 'logs')[]; // defaults to both
+  hours: number;               // at most 72
+  reason: string;
+}
+
+export interface MessageCatalog {
+  lang: string;
+  languages: string[];
+  messages: Record<string, string>;
 }
 
 /**
  * Response from /api/cluster/init endpoint
  */
+/**
+ * Set on /api/cluster/init when anakosmos runs with namespace-scoped RBAC
+ */
+export interface ClusterAccess {
+  mode: 'namespaced';
+  namespaces: string[];
+  unavailable: string[]; // kinds that can't be listed, e.g. Node
+}
+
 export interface ClusterInitResponse {
   resources: LightResource[];
   links: ClusterLink[];
+  snapshotHash?: string;
+  access?: ClusterAccess;
+  generatedAt?: string; // when the graph was read; precomputed graphs lag a few seconds
+  resourceVersion?: string; // pass to /api/cluster/delta?since= after a reconnect
+  compactLinks?: CompactLink[]; // with ?compact=true, replacing links
+}
+
+/**
+ * Link of a compact init response: resources referenced by their position in
+ * resources, as [source, target, type] or [source, target, type, state]
+ */
+export type CompactLink = [number, number, ClusterLink['type'], ClusterLink['state']?];
+
+/**
+ * One line of /api/cluster/init?stream=true (newline-delimited JSON):
+ * resources first, then links; the last line has done set, or error
+ */
+export interface ClusterInitChunk {
+  resources?: LightResource[];
+  links?: ClusterLink[];
+  done?: boolean;
+  snapshotHash?: string;
+  access?: ClusterAccess;
+  generatedAt?: string;
+  resourceVersion?: string;
+  error?: string;
+}
+
+/**
+ * Response from /api/cluster/delta?since=<resourceVersion>: the last change of
+ * each built-in resource since then. 410 means since is too old: reload init.
+ */
+export interface ClusterDelta {
+  since: string;
+  resourceVersion: string;
+  events: { type: 'ADDED' | 'MODIFIED' | 'DELETED'; resource: LightResource }[];
+}
+
+/**
+ * Response from /api/cluster/init?since=<snapshotHash> when the server still
+ * knows that snapshot
+ */
+export interface ClusterInitDelta {
+  since: string;
+  snapshotHash: string;
+  added: LightResource[];
+  changed: LightResource[];
+  removed: string[];
+  addedLinks: ClusterLink[];
+  removedLinks: ClusterLink[];
+  generatedAt?: string;
+}
+
+/**
+ * Response from /api/resources/impact (dry-run blast radius of a delete)
+ */
+export interface ImpactResource {
+  id: string;
+  kind: string;
+  namespace?: string;
+  name: string;
+}
+
+export interface SearchResult extends ImpactResource {
+  status: string;
+  health?: string;
+  match: 'name' | 'kind' | 'namespace' | 'label' | 'annotation';
+}
+
+export interface SearchResponse {
+  query: string;
+  generatedAt: string;
+  total: number; // every match; results is the page at offset
+  offset: number;
+  limit: number;
+  results: SearchResult[];
+}
+
+export interface NamespaceQuota {
+  name: string;
+  hard?: Record<string, string>;
+  used?: Record<string, string>;
+  pressure: number; // highest used/hard, in percent
+}
+
+export interface NamespaceSummary {
+  name: string;
+  status?: string; // the Namespace's own, e.g. Active or Terminating
+  health: string;  // worst among its resources
+  counts: { total: number; ok: number; warning: number; error: number; unknown: number };
+  kinds: Record<string, number>;
+  quotas: NamespaceQuota[];
+  quotaPressure: number;
+}
+
+export interface DeleteImpact {
+  target: ImpactResource;
+  cascade: boolean;
+  descendants: (ImpactResource & { depth: number })[];
+  referencedBy: (ImpactResource & { linkType: string; target: string })[];
+  helmRelease?: HelmReleaseInfo;
+}
+
+/**
+ * Recent transition replayed by the single-resource socket (REPLAY event)
+ */
+export interface WatchTransition {
+  type: 'ADDED' | 'MODIFIED' | 'DELETED';
+  time: string;
+  resource: LightResource;
+}
+
+/**
+ * Rollout progress streamed by /api/sock/rollout until the phase leaves 'progressing'
+ */
+export interface RolloutStatus {
+  phase: 'progressing' | 'complete' | 'failed' | 'timeout';
+  kind: string;
+  namespace: string;
+  name: string;
+  message: string;
+  revision?: string;
+  desired: number;
+  updated: number;
+  ready: number;
+  available: number;
+  oldReplicas: number;
+  newReplicaSet?: string;
+  elapsed: string;
+}
+
+/**
+ * A happening pushed by /api/sock/activity: an operation performed through
+ * anakosmos (self when the current user did it), an alert rule firing or
+ * resolving, or a change in cluster connectivity
+ */
+export interface ActivityItem {
+  id: number;
+  time: string;
+  type: 'operation' | 'alert' | 'cluster' | 'maintenance' | 'drill';
+  severity: 'info' | 'success' | 'warning' | 'error';
+  cluster: string;
+  actor?: string;
+  verb?: string;
+  kind?: string;
+  namespace?: string;
+  name?: string;
+  message: string;
+  self?: boolean;
+}
+
+/**
+ * A kind served by the cluster, from /api/meta/resources
+ */
+export interface ApiResourceInfo {
+  kind: string;
+  group: string;
+  resource: string;
+  versions: string[];
+  preferredVersion: string;
+  apiVersion: string;
+  namespaced: boolean;
+  shortNames?: string[];
+  verbs: string[];
+}
+
+/**
+ * One managedFields entry of a resource, from /api/resources/managers
+ */
+export interface FieldManagerSummary {
+  manager: string;
+  operation: 'Apply' | 'Update';
+  subresource?: string;
+  apiVersion: string;
+  time?: string;
+  fields: string[];
+  fieldCount: number;
+}
+
+export interface ManagedFieldsSummary {
+  resource: ImpactResource;
+  managers: FieldManagerSummary[];
+  /** Fields owned by several managers, the usual cause of reverted changes */
+  contested: { field: string; managers: string[] }[];
+}
+
+/**
+ * Registry metadata of an image, from /api/images/inspect
+ */
+export interface ImageInspection {
+  image: string;
+  registry: string;
+  repository: string;
+  reference: string;
+  digest: string;
+  indexDigest?: string;
+  platforms?: string[];
+  platform: string;
+  created?: string;
+  author?: string;
+  labels?: Record<string, string>;
+  entrypoint?: string[];
+  cmd?: string[];
+  user?: string;
+  layers: number;
+  size: number;
+  attestations: { type: string; digest: string; source: 'buildkit' | 'referrers' | 'cosign' }[];
+  sbom?: {
+    format: 'spdx' | 'cyclonedx';
+    digest: string;
+    packageCount: number;
+    packages: { name: string; version?: string; purl?: string }[];
+  };
+  warnings?: string[];
+}
+
+/**
+ * Result of one object of an apply (/api/resources/apply-yaml). Objects of a
+ * multi-file upload carry the file and 1-based document they came from.
+ */
+export interface ApplyResult {
+  file?: string;
+  document?: number;
+  kind: string;
+  name: string;
+  namespace?: string;
+  status: 'applied' | 'error';
+  error?: string;
+}
+
+export interface ApplyResponse {
+  applied: number;
+  results: ApplyResult[];
+}
+
+/**
+ * Drift of one object of a Helm release from its stored manifest
+ * (/api/helm/sync)
+ */
+export interface HelmSyncResult {
+  kind: string;
+  name: string;
+  namespace?: string;
+  status: 'inSync' | 'drifted' | 'missing' | 'restored' | 'error';
+  drifted?: string[]; // declared field paths whose live value differs
+  error?: string;
+}
+
+export interface HelmSyncResponse {
+  dryRun: boolean;
+  results: HelmSyncResult[];
+}
+
+/**
+ * Optional APIs and features a cluster offers
+ * (/api/clusters/{id}/capabilities)
+ */
+export interface ClusterCapabilities {
+  cluster: string;
+  version?: string;
+  metrics: boolean; // metrics.k8s.io (metrics-server)
+  argoCD: boolean;
+  argoRollouts: boolean;
+  flagger: boolean;
+  flux: boolean;
+  istio: boolean;
+  gatewayAPI: boolean;
+  openShift: boolean; // route.openshift.io Routes
+  endpointSlices: boolean;
+  certManager: boolean;
+  clusterAPI: boolean;
+  podSecurity: boolean; // Pod Security admission (1.23+)
+  probedAt: string;
+}
+
+// Golden baseline of a cluster (GET/POST /api/cluster/baseline)
+export interface BaselineEntry {
+  kind: string;
+  namespace: string;
+  name: string;
+  images?: string[]; // workload pod template images
+}
+
+export interface ClusterBaseline {
+  cluster: string; // registered ClusterConnection or "local"
+  createdBy: string;
+  createdAt: string;
+  entries: BaselineEntry[];
+}
+
+export interface BaselineDeviation {
+  type: 'added' | 'removed' | 'imagesChanged';
+  kind: string;
+  namespace: string;
+  name: string;
+  baseline?: string[]; // images in the baseline
+  current?: string[]; // images now
+  since: string; // first check that found it
+}
+
+// GET /api/cluster/baseline-diff
+export interface BaselineReport {
+  cluster: string;
+  baselineAt: string;
+  checkedAt: string;
+  deviations: BaselineDeviation[];
 }
@@ -19,6 +19,10 @@ import {
   Shield,
   GitBranch,
   Package,
+  User,
+  KeyRound,
+  Link,
+  BadgeCheck,
 } from 'lucide-react';
 
 // Geometry types available in sharedResources.ts - ALL UNIQUE
@@ -71,6 +75,16 @@ export interface KindConfig {
 export const KIND_CONFIG: KindConfig[] = [
   // Other / Infrastructure
   { kind: 'Node', label: 'Nodes', icon: Server, color: '#1e293b', geometry: 'node', category: 'other' },
+  { kind: 'Cluster', label: 'CAPI Clusters', icon: Server, color: '#475569', geometry: 'slab', category: 'other' },
+  { kind: 'MachineDeployment', label: 'Machine Deployments', icon: Layers, color: '#64748b', geometry: 'deploy', category: 'other' },
+  { kind: 'Machine', label: 'Machines', icon: HardDrive, color: '#94a3b8', geometry: 'smallBox', category: 'other' },
+  { kind: 'Namespace', label: 'Namespaces', icon: Shield, color: '#64748b', geometry: 'tetra', category: 'other' },
+  { kind: 'ResourceQuota', label: 'Resource Quotas', icon: Activity, color: '#f59e0b', geometry: 'slab', category: 'other' },
+  { kind: 'PodDisruptionBudget', label: 'Disruption Budgets', icon: Shield, color: '#ea580c', geometry: 'hexPrism', category: 'other' },
+  { kind: 'LimitRange', label: 'Limit Ranges', icon: Settings, color: '#d97706', geometry: 'smallBox', category: 'other' },
+  { kind: 'ServiceMonitor', label: 'Service Monitors', icon: Activity, color: '#e6522c', geometry: 'hpa', category: 'other' },
+  { kind: 'PodMonitor', label: 'Pod Monitors', icon: Activity, color: '#f97316', geometry: 'hpa', category: 'other' },
+  { kind: 'PrometheusRule', label: 'Prometheus Rules', icon: FileJson, color: '#b45309', geometry: 'smallBox', category: 'other' },
   
   // Workloads
   { kind: 'Pod', label: 'Pods', icon: Box, color: '#60a5fa', geometry: 'pod', category: 'workload' },
@@ -81,33 +95,57 @@ export const KIND_CONFIG: KindConfig[] = [
   { kind: 'Job', label: 'Jobs', icon: Play, color: '#06b6d4', geometry: 'job', category: 'workload' },
   { kind: 'CronJob', label: 'CronJobs', icon: Clock, color: '#0891b2', geometry: 'cronJob', category: 'workload' },
   { kind: 'HorizontalPodAutoscaler', label: 'HPAs', icon: Activity, color: '#14b8a6', geometry: 'hpa', category: 'workload' },
+  { kind: 'VerticalPodAutoscaler', label: 'VPAs', icon: Activity, color: '#2dd4bf', geometry: 'hpa', category: 'workload' },
+  { kind: 'ScaledObject', label: 'KEDA ScaledObjects', icon: Activity, color: '#0d9488', geometry: 'hpa', category: 'workload' },
+  { kind: 'Rollout', label: 'Argo Rollouts', icon: Layers, color: '#f59e0b', geometry: 'deploy', category: 'workload' },
+  { kind: 'Canary', label: 'Flagger Canaries', icon: ArrowRightLeft, color: '#d97706', geometry: 'deploy', category: 'workload' },
   
   // Network
   { kind: 'Service', label: 'Services', icon: Share2, color: '#34d399', geometry: 'service', category: 'network' },
   { kind: 'Ingress', label: 'Ingresses', icon: Globe, color: '#e879f9', geometry: 'oct', category: 'network' },
   { kind: 'Route', label: 'Routes', icon: ArrowRightLeft, color: '#f472b6', geometry: 'diamond', category: 'network' },
+  { kind: 'HTTPRoute', label: 'HTTPRoutes', icon: ArrowRightLeft, color: '#c084fc', geometry: 'diamond', category: 'network' },
+  { kind: 'Gateway', label: 'Gateways', icon: Globe, color: '#a855f7', geometry: 'oct', category: 'network' },
+  { kind: 'GatewayClass', label: 'Gateway Classes', icon: Settings, color: '#7c3aed', geometry: 'slab', category: 'network' },
+  { kind: 'VirtualService', label: 'Virtual Services', icon: ArrowRightLeft, color: '#466bb0', geometry: 'diamond', category: 'network' },
+  { kind: 'IstioGateway', label: 'Istio Gateways', icon: Globe, color: '#2d4f8e', geometry: 'oct', category: 'network' },
+  { kind: 'DestinationRule', label: 'Destination Rules', icon: Settings, color: '#6b8fd4', geometry: 'hexPrism', category: 'network' },
+  { kind: 'ExternalEndpoint', label: 'External Endpoints', icon: Globe, color: '#94a3b8', geometry: 'tetra', category: 'network' },
   { kind: 'NetworkAttachmentDefinition', label: 'Net Attach Defs', icon: Network, color: '#22d3ee', geometry: 'torusKnot', category: 'network' },
+  { kind: 'NetworkPolicy', label: 'Network Policies', icon: Shield, color: '#f43f5e', geometry: 'hexPrism', category: 'network' },
   { kind: 'NodeNetworkConfigurationPolicy', label: 'Node Net Configs', icon: Settings, color: '#94a3b8', geometry: 'hexPrism', category: 'network' },
   
   // Config
   { kind: 'ConfigMap', label: 'ConfigMaps', icon: FileJson, color: '#6f007c', geometry: 'smallBox', category: 'config' },
   { kind: 'Secret', label: 'Secrets', icon: Lock, color: '#b8a300', geometry: 'pyramid', category: 'config' },
+  { kind: 'Certificate', label: 'Certificates', icon: BadgeCheck, color: '#65a30d', geometry: 'pyramid', category: 'config' },
+  { kind: 'Issuer', label: 'Issuers', icon: BadgeCheck, color: '#4d7c0f', geometry: 'smallBox', category: 'config' },
+  { kind: 'ClusterIssuer', label: 'Cluster Issuers', icon: BadgeCheck, color: '#3f6212', geometry: 'slab', category: 'config' },
   
   // Storage
   { kind: 'PersistentVolumeClaim', label: 'PVCs', icon: Disc, color: '#f97316', geometry: 'puck', category: 'storage' },
   { kind: 'PersistentVolume', label: 'PVs', icon: HardDrive, color: '#ea580c', geometry: 'barrel', category: 'storage' },
   { kind: 'StorageClass', label: 'StorageClasses', icon: Settings, color: '#c2410c', geometry: 'slab', category: 'storage' },
+  { kind: 'CSIDriver', label: 'CSI Drivers', icon: HardDrive, color: '#9a3412', geometry: 'hexPrism', category: 'storage' },
+  { kind: 'VolumeAttachment', label: 'Volume Attachments', icon: Link, color: '#fb923c', geometry: 'diamond', category: 'storage' },
   
-  // RBAC (to be filled)
-  // { kind: 'ServiceAccount', label: 'Service Accounts', icon: User, color: '#...', geometry: '...', category: 'rbac' },
-  // { kind: 'Role', label: 'Roles', icon: Shield, color: '#...', geometry: '...', category: 'rbac' },
-  // { kind: 'ClusterRole', label: 'ClusterRoles', icon: Shield, color: '#...', geometry: '...', category: 'rbac' },
-  // { kind: 'RoleBinding', label: 'RoleBindings', icon: Link, color: '#...', geometry: '...', category: 'rbac' },
-  // { kind: 'ClusterRoleBinding', label: 'ClusterRoleBindings', icon: Link, color: '#...', geometry: '...', category: 'rbac' },
+  // RBAC (Pod -> ServiceAccount -> RoleBinding -> Role)
+  { kind: 'ServiceAccount', label: 'Service Accounts', icon: User, color: '#14b8a6', geometry: 'tetra', category: 'rbac' },
+  { kind: 'Role', label: 'Roles', icon: KeyRound, color: '#eab308', geometry: 'smallBox', category: 'rbac' },
+  { kind: 'ClusterRole', label: 'ClusterRoles', icon: KeyRound, color: '#ca8a04', geometry: 'slab', category: 'rbac' },
+  { kind: 'RoleBinding', label: 'RoleBindings', icon: Link, color: '#2dd4bf', geometry: 'diamond', category: 'rbac' },
+  { kind: 'ClusterRoleBinding', label: 'ClusterRoleBindings', icon: Link, color: '#0d9488', geometry: 'diamond', category: 'rbac' },
   
   // GitOps
   { kind: 'Application', label: 'Argo Applications', icon: GitBranch, color: '#ef6c00', geometry: 'argoApp', category: 'gitops' },
+  { kind: 'ApplicationSet', label: 'Argo ApplicationSets', icon: Layers, color: '#f59e0b', geometry: 'argoApp', category: 'gitops' },
+  { kind: 'AppProject', label: 'Argo Projects', icon: Shield, color: '#c2410c', geometry: 'slab', category: 'gitops' },
+  { kind: 'ApplicationGroup', label: 'Application Groups', icon: Layers, color: '#38bdf8', geometry: 'argoApp', category: 'gitops' },
   { kind: 'HelmRelease', label: 'Helm Releases', icon: Package, color: '#0ea5e9', geometry: 'helmRelease', category: 'gitops' },
+  { kind: 'Kustomization', label: 'Flux Kustomizations', icon: GitBranch, color: '#5468ff', geometry: 'argoApp', category: 'gitops' },
+  { kind: 'FluxHelmRelease', label: 'Flux HelmReleases', icon: Package, color: '#326ce5', geometry: 'helmRelease', category: 'gitops' },
+  { kind: 'GitRepository', label: 'Git Repositories', icon: GitBranch, color: '#818cf8', geometry: 'smallBox', category: 'gitops' },
+  { kind: 'HelmRepository', label: 'Helm Repositories', icon: Package, color: '#60a5fa', geometry: 'smallBox', category: 'gitops' },
 ];
 
 export const ALL_KINDS = KIND_CONFIG.map(k => k.kind);
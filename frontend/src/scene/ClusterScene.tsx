@@ -333,7 +333,7 @@ const InstancedNodes: React.FC<{
       const isFocused = focusedKind === res.kind;
       const isDimmed = selectedId !== null && !connectedIds.has(res.id) && !isSelected;
       
-      const isUnhealthy = res.health === 'warning' || res.health === 'error';
+      const isUnhealthy = res.health === 'warning' || res.health === 'error' || res.health === 'flapping';
       const isLegacyUnhealthy = !['Running', 'Ready', 'Active', 'Available', 'Bound', 'Succeeded', 'Complete', 'Suspended'].includes(res.status);
       const finalIsUnhealthy = res.health ? isUnhealthy : isLegacyUnhealthy;
       
@@ -35,13 +35,16 @@ export const LinkLayer: React.FC<LinkLayerProps> = ({ positionsRef }) => {
   const visibleLinks = useMemo(() => {
     const result: VisibleLink[] = [];
 
-    const getColor = (type: string, dimmed: boolean): [number, number, number] => {
+    const getColor = (link: ClusterLink, dimmed: boolean): [number, number, number] => {
         if (dimmed) return [0.15, 0.15, 0.15];
-        switch (type) {
+        // Endpoints that don't receive traffic yet
+        if (link.state === 'notReady') return [0.96, 0.62, 0.04];
+        switch (link.type) {
             case 'owner': return [0.58, 0.64, 0.72];
             case 'network': return [0.23, 0.51, 0.96];
             case 'config': return [0.85, 0.45, 1.0];
             case 'storage': return [1.0, 0.6, 0.1];
+            case 'containment': return [0.39, 0.45, 0.55];
             default: return [0.5, 0.5, 0.5];
         }
     };
@@ -69,7 +72,7 @@ export const LinkLayer: React.FC<LinkLayerProps> = ({ positionsRef }) => {
         : true;
 
       const dimmed = selectedResourceId !== null && !isConnected;
-      const color = getColor(link.type, dimmed);
+      const color = getColor(link, dimmed);
 
       result.push({ link, color });
     });
@@ -5,7 +5,7 @@ import { useFrame } from '@react-three/fiber';
 interface LinkObjectProps {
   start: [number, number, number];
   end: [number, number, number];
-  type: 'owner' | 'network' | 'config' | 'storage';
+  type: 'owner' | 'network' | 'config' | 'storage' | 'policy' | 'rbac' | 'containment';
   dimmed?: boolean;
 }
 
@@ -50,6 +50,9 @@ export const LinkObject: React.FC<LinkObjectProps> = ({ start, end, type, dimmed
   const color = type === 'owner' ? '#94a3b8' // Slate 400 (Lighter than 600)
     : type === 'network' ? '#3b82f6' 
     : type === 'config' ? '#a855f7' // Purple 500
+    : type === 'policy' ? '#f43f5e' // Rose 500 (NetworkPolicy)
+    : type === 'rbac' ? '#14b8a6' // Teal 500 (ServiceAccount/RBAC)
+    : type === 'containment' ? '#64748b' // Slate 500 (Namespace)
     : '#d97706'; // Storage (Orange)
     
   const opacity = dimmed ? 0.05 : (type === 'owner' ? 0.3 : 0.4);
@@ -53,7 +53,8 @@ export const useSettingsStore = create<SettingsStore>()(
       filterNamespaces: [],
       hideSystemNamespaces: false,
       hiddenResourceKinds: [],
-      hiddenLinkTypes: [],
+      // Namespace containment links are for grouping, not drawn by default
+      hiddenLinkTypes: ['containment'],
       focusedResourceKind: null,
       statusFilters: {},
       searchQuery: '',
@@ -20,6 +20,9 @@ import {
   Network,
   Database,
   GitFork,
+  Shield,
+  KeyRound,
+  FolderTree,
   Map as MapIcon
 } from 'lucide-react';
 import clsx from 'clsx';
@@ -452,6 +455,42 @@ export const ResourceLegend: React.FC = () => {
                     <Database size={11} />
                     <span>Storage</span>
                   </button>
+
+                  <button 
+                    onClick={() => toggleHiddenLinkType('policy')}
+                    className={clsx(
+                        "flex items-center gap-2 text-[11px] transition-colors w-full text-left py-0.5",
+                        hiddenLinkTypes.includes('policy') ? "text-slate-600 line-through decoration-slate-600" : "text-slate-400 hover:text-slate-200"
+                    )}
+                  >
+                    <span className={clsx("w-3 h-0.5 rounded-full transition-colors", hiddenLinkTypes.includes('policy') ? "bg-slate-700" : "bg-rose-500")}></span>
+                    <Shield size={11} />
+                    <span>Policy</span>
+                  </button>
+
+                  <button 
+                    onClick={() => toggleHiddenLinkType('rbac')}
+                    className={clsx(
+                        "flex items-center gap-2 text-[11px] transition-colors w-full text-left py-0.5",
+                        hiddenLinkTypes.includes('rbac') ? "text-slate-600 line-through decoration-slate-600" : "text-slate-400 hover:text-slate-200"
+                    )}
+                  >
+                    <span className={clsx("w-3 h-0.5 rounded-full transition-colors", hiddenLinkTypes.includes('rbac') ? "bg-slate-700" : "bg-teal-500")}></span>
+                    <KeyRound size={11} />
+                    <span>RBAC</span>
+                  </button>
+
+                  <button 
+                    onClick={() => toggleHiddenLinkType('containment')}
+                    className={clsx(
+                        "flex items-center gap-2 text-[11px] transition-colors w-full text-left py-0.5",
+                        hiddenLinkTypes.includes('containment') ? "text-slate-600 line-through decoration-slate-600" : "text-slate-400 hover:text-slate-200"
+                    )}
+                  >
+                    <span className={clsx("w-3 h-0.5 rounded-full transition-colors", hiddenLinkTypes.includes('containment') ? "bg-slate-700" : "bg-slate-500")}></span>
+                    <FolderTree size={11} />
+                    <span>Namespace</span>
+                  </button>
                </div>
             </div>
 
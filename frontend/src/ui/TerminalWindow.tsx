@@ -2,6 +2,7 @@ import React, { useEffect, useRef, useState } from 'react';
 import { useTerminalStore } from '../store/useTerminalStore';
 import type { TerminalSession } from '../store/useTerminalStore';
 import { useClusterStore } from '../store/useClusterStore';
+import { WS_PROTOCOLS } from '../api/kubeClient';
 import { X, Terminal as TerminalIcon, FileText, Maximize2, Minimize2, AlertTriangle } from 'lucide-react';
 import { clsx } from 'clsx';
 import { Terminal } from '@xterm/xterm';
@@ -193,20 +194,54 @@ const SessionContent: React.FC<{ session: TerminalSession, isActive: boolean, is
 
         const url = `${protocol}//${host}/api/sock/exec?${params.toString()}`;
         
-        const ws = new WebSocket(url);
+        const ws = new WebSocket(url, WS_PROTOCOLS.exec);
         wsRef.current = ws;
 
+        const sendResize = () => {
+            if (ws.readyState === WebSocket.OPEN) {
+                ws.send(JSON.stringify({ type: 'resize', cols: term.cols, rows: term.rows }));
+            }
+        };
+        term.onResize(sendResize);
+
         ws.onopen = () => {
             term.writeln("\r\n\x1b[32m✔ Connected\x1b[0m\r\n");
+            sendResize();
             term.focus(); 
         };
 
+        let exited = false;
+
+        // Framed protocol: stdout/stderr data and a final exit control message
         ws.onmessage = (ev) => {
-            term.write(ev.data);
+            let msg: { type: string; data?: string; code?: number; reason?: string };
+            try {
+                msg = JSON.parse(ev.data);
+            } catch {
+                term.write(ev.data);
+                return;
+            }
+            if (msg.type === 'stdout') {
+                term.write(msg.data || '');
+            } else if (msg.type === 'stderr') {
+                term.write(`\x1b[31m${msg.data || ''}\x1b[0m`);
+            } else if (msg.type === 'exit') {
+                exited = true;
+                if (msg.reason) {
+                    term.writeln(`\r\n\x1b[31m✖ ${msg.reason}\x1b[0m`);
+                } else {
+                    const color = msg.code === 0 ? '32' : '33';
+                    term.writeln(`\r\n\x1b[${color}m● Process exited with code ${msg.code}\x1b[0m`);
+                }
+            }
         };
 
-        ws.onclose = () => {
-            term.writeln("\r\n\x1b[31m✖ Connection closed\x1b[0m");
+        // The server closes with the termination reason; only show it when the
+        // exit message didn't already explain why the session ended
+        ws.onclose = (ev) => {
+            if (exited) return;
+            const reason = ev.reason ? `: ${ev.reason}` : '';
+            term.writeln(`\r\n\x1b[31m✖ Connection closed${reason}\x1b[0m`);
         };
 
         ws.onerror = () => {
@@ -215,7 +250,7 @@ const SessionContent: React.FC<{ session: TerminalSession, isActive: boolean, is
 
         term.onData(data => {
             if (ws.readyState === WebSocket.OPEN) {
-                ws.send(data);
+                ws.send(JSON.stringify({ type: 'stdin', data }));
             }
         });
 
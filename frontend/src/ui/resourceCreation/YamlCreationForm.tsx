@@ -1,5 +1,5 @@
 import React, { useMemo, useState } from 'react';
-import { FileText, Play, FileUp, Trash2 } from 'lucide-react';
+import { FileText, Play, FileUp, FolderUp, Trash2 } from 'lucide-react';
 import { clsx } from 'clsx';
 import Editor from '@monaco-editor/react';
 import type { KubeClient } from '../../api/kubeClient';
@@ -78,12 +78,47 @@ export const YamlCreationForm: React.FC<YamlCreationFormProps> = ({
     event.target.value = '';
   };
 
+  // Applies uploaded or dropped files as they are: several YAML files or a
+  // zip/tar of a manifests folder, with failures reported per file
+  const applyBundle = async (files: File[]) => {
+    if (!client || files.length === 0) return;
+    setIsSubmitting(true);
+    setStatusMessage(null);
+    try {
+      const res = await client.applyManifestBundle(files, namespace);
+      const failed = res.results.filter(r => r.status === 'error');
+      const fileCount = new Set(res.results.map(r => r.file)).size;
+      setStatusMessage(`Applied ${res.applied} of ${res.results.length} resource${res.results.length !== 1 ? 's' : ''} from ${fileCount} file${fileCount !== 1 ? 's' : ''}`);
+      if (failed.length > 0) {
+        setError(failed.slice(0, 5).map(r =>
+          `${r.file ?? ''}${r.document ? `#${r.document}` : ''}: ${r.kind ? `${r.kind}/${r.name}: ` : ''}${r.error}`
+        ).join('\n') + (failed.length > 5 ? `\n…and ${failed.length - 5} more` : ''));
+      }
+    } catch (err: unknown) {
+      const message = err instanceof Error ? err.message : 'Failed to apply manifests';
+      setError(message);
+    } finally {
+      setIsSubmitting(false);
+    }
+  };
+
+  const handleBundleUpload = (event: React.ChangeEvent<HTMLInputElement>) => {
+    const files = Array.from(event.target.files ?? []);
+    event.target.value = '';
+    applyBundle(files);
+  };
+
+  const handleDrop = (event: React.DragEvent<HTMLDivElement>) => {
+    event.preventDefault();
+    applyBundle(Array.from(event.dataTransfer.files));
+  };
+
   const handleClear = () => {
     setYamlContent('');
   };
 
   return (
-    <div className="h-full flex flex-col gap-4">
+    <div className="h-full flex flex-col gap-4" onDragOver={(e) => e.preventDefault()} onDrop={handleDrop}>
       {/* Header Section */}
       <div className="bg-slate-900/60 rounded-xl border border-slate-800 p-4">
         <div className="flex items-start justify-between gap-4">
@@ -94,7 +129,7 @@ export const YamlCreationForm: React.FC<YamlCreationFormProps> = ({
             <div>
               <h3 className="text-base font-semibold text-slate-100">Apply YAML</h3>
               <p className="text-xs text-slate-500 mt-0.5">
-                Paste or upload Kubernetes manifests. Supports multiple documents separated by <code className="bg-slate-800 px-1 rounded">---</code>
+                Paste or upload Kubernetes manifests. Supports multiple documents separated by <code className="bg-slate-800 px-1 rounded">---</code>, or drop several files or a zip/tar of a manifests folder to apply them directly
               </p>
             </div>
           </div>
@@ -132,6 +167,18 @@ export const YamlCreationForm: React.FC<YamlCreationFormProps> = ({
                 onChange={handleFileUpload}
               />
             </label>
+            <label className="flex items-center gap-2 px-3 py-2 bg-slate-800 hover:bg-slate-700 text-slate-300 text-xs font-medium rounded-lg transition-colors cursor-pointer border border-slate-700" title="Apply several files or a zip/tar archive">
+              <FolderUp size={14} />
+              Apply Files
+              <input
+                type="file"
+                accept=".yaml,.yml,.json,.zip,.tar,.tgz,.tar.gz"
+                multiple
+                className="hidden"
+                disabled={isSubmitting}
+                onChange={handleBundleUpload}
+              />
+            </label>
             <button
               onClick={handleClear}
               className="flex items-center gap-2 px-3 py-2 bg-slate-800 hover:bg-slate-700 text-slate-400 hover:text-slate-200 text-xs font-medium rounded-lg transition-colors border border-slate-700"
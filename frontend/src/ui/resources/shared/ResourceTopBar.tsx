@@ -1,7 +1,9 @@
-import React, { useState } from 'react';
+import React, { useState, useEffect } from 'react';
 import { Trash2, RefreshCw, AlertTriangle } from 'lucide-react';
 import { clsx } from 'clsx';
 import { EventsIndicator } from './EventsCard';
+import { useClusterStore } from '../../../store/useClusterStore';
+import type { DeleteImpact } from '../../../api/types';
 
 interface ResourceTopBarProps {
   // Resource info
@@ -36,6 +38,21 @@ export const ResourceTopBar: React.FC<ResourceTopBarProps> = ({
   const [showDeleteConfirm, setShowDeleteConfirm] = useState(false);
   const [isDeleting, setIsDeleting] = useState(false);
   const [deleteError, setDeleteError] = useState<string | null>(null);
+  const [impact, setImpact] = useState<DeleteImpact | null>(null);
+  const [impactLoading, setImpactLoading] = useState(false);
+  const client = useClusterStore(state => state.client);
+
+  // Load the blast radius when the confirmation opens
+  useEffect(() => {
+    if (!showDeleteConfirm || !client || !resourceId) return;
+    let cancelled = false;
+    setImpactLoading(true);
+    client.getDeleteImpact(resourceId)
+      .then(result => { if (!cancelled) setImpact(result); })
+      .catch(() => { if (!cancelled) setImpact(null); })
+      .finally(() => { if (!cancelled) setImpactLoading(false); });
+    return () => { cancelled = true; };
+  }, [showDeleteConfirm, client, resourceId]);
 
   const handleDelete = async () => {
     if (!onDelete) return;
@@ -116,6 +133,46 @@ export const ResourceTopBar: React.FC<ResourceTopBarProps> = ({
               <p className="text-sm text-slate-500 mb-4">
                 This action cannot be undone. The {resourceKind.toLowerCase()} and all its associated resources will be permanently removed from the cluster.
               </p>
+
+              {impactLoading && (
+                <div className="mb-4 flex items-center gap-2 text-xs text-slate-500">
+                  <RefreshCw size={12} className="animate-spin" />
+                  Computing impact...
+                </div>
+              )}
+              {impact && !impactLoading && (
+                <div className="mb-4 space-y-3 text-xs max-h-60 overflow-y-auto">
+                  {impact.helmRelease && (
+                    <div className="p-2 bg-amber-900/20 border border-amber-800/50 rounded text-amber-300">
+                      Managed by Helm release <span className="font-mono">{impact.helmRelease.releaseNamespace}/{impact.helmRelease.releaseName}</span>; it may be recreated on the next upgrade.
+                    </div>
+                  )}
+                  {impact.descendants.length > 0 && (
+                    <div>
+                      <div className="text-slate-400 font-medium mb-1">Also deleted ({impact.descendants.length})</div>
+                      <ul className="space-y-0.5">
+                        {impact.descendants.map(d => (
+                          <li key={d.id} className="font-mono text-slate-300" style={{ paddingLeft: (d.depth - 1) * 12 }}>
+                            {d.kind}/{d.name}
+                          </li>
+                        ))}
+                      </ul>
+                    </div>
+                  )}
+                  {impact.referencedBy.length > 0 && (
+                    <div>
+                      <div className="text-slate-400 font-medium mb-1">Still referencing it ({impact.referencedBy.length})</div>
+                      <ul className="space-y-0.5">
+                        {impact.referencedBy.map(r => (
+                          <li key={`${r.id}-${r.target}`} className="font-mono text-slate-300">
+                            {r.kind}/{r.name} <span className="text-slate-500">({r.linkType})</span>
+                          </li>
+                        ))}
+                      </ul>
+                    </div>
+                  )}
+                </div>
+              )}
               
               {deleteError && (
                 <div className="mb-4 p-3 bg-red-900/30 border border-red-700/50 rounded-lg text-sm text-red-300">
@@ -128,6 +185,7 @@ export const ResourceTopBar: React.FC<ResourceTopBarProps> = ({
                   onClick={() => {
                     setShowDeleteConfirm(false);
                     setDeleteError(null);
+                    setImpact(null);
                   }}
                   disabled={isDeleting}
                   className="px-4 py-2 text-sm font-medium text-slate-300 bg-slate-800 hover:bg-slate-700 rounded-lg transition-colors disabled:opacity-50"
@@ -14,6 +14,7 @@ import { EventsList } from './components/EventsList';
 import { YamlEditor } from './components/YamlEditor';
 import { RelationsList } from './components/RelationsList';
 import { EmptyState } from './components/EmptyState';
+import { RolloutProgress, ROLLOUT_KINDS } from './components/RolloutProgress';
 import { ResourceOverview } from './overview/ResourceOverview';
 import { useSidebarResource } from './hooks/useSidebarResource';
 
@@ -32,10 +33,16 @@ export const Sidebar: React.FC = () => {
   const [feedback, setFeedback] = useState<{ type: 'success' | 'error'; message: string } | null>(null);
   const [errorModal, setErrorModal] = useState<{ open: boolean; error: string }>({ open: false, error: '' });
   const [actionLoading, setActionLoading] = useState(false);
+  // Set after an edit to a workload; keys the rollout progress socket
+  const [rolloutKey, setRolloutKey] = useState<number | null>(null);
 
   const resource = selectedResourceId ? resources[selectedResourceId] : null;
   const { rawResource, isLoading: isRawLoading } = useSidebarResource(resource);
 
+  useEffect(() => {
+    setRolloutKey(null);
+  }, [resource?.id]);
+
   useEffect(() => {
     setFeedback(null);
     setErrorModal({ open: false, error: '' });
@@ -92,6 +99,7 @@ export const Sidebar: React.FC = () => {
     try {
       await client.applyYaml(resource.namespace, resource.kind, resource.name, yamlContent);
       setFeedback({ type: 'success', message: 'Resource updated' });
+      if (ROLLOUT_KINDS.includes(resource.kind)) setRolloutKey(Date.now());
       setTimeout(() => setFeedback(null), 3000);
     } catch (e: any) {
       let shortError = 'Update failed';
@@ -134,6 +142,7 @@ export const Sidebar: React.FC = () => {
       if (cleaned?.metadata?.managedFields) delete cleaned.metadata.managedFields;
       await client.applyYaml(resource.namespace, resource.kind, resource.name, yaml.dump(cleaned));
       setFeedback({ type: 'success', message: successMessage });
+      if (ROLLOUT_KINDS.includes(resource.kind)) setRolloutKey(Date.now());
       setTimeout(() => setFeedback(null), 2500);
     } catch (e: any) {
       console.error(e);
@@ -183,6 +192,17 @@ export const Sidebar: React.FC = () => {
         <SidebarHeader resource={resource} onClose={() => setSelectedResourceId(null)} />
         <SidebarTabs active={activeTab} onChange={setActiveTab} />
 
+        {rolloutKey !== null && client && (
+          <RolloutProgress
+            key={rolloutKey}
+            client={client}
+            kind={resource.kind}
+            namespace={resource.namespace || 'default'}
+            name={resource.name}
+            onDismiss={() => setRolloutKey(null)}
+          />
+        )}
+
         <div className={clsx(
           'flex-1 overflow-y-auto scrollbar-thin scrollbar-thumb-slate-700 scrollbar-track-transparent',
           activeTab === 'yaml' ? 'p-0 flex flex-col' : 'p-6'
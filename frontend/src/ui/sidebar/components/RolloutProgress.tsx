@@ -0,0 +1,67 @@
+import React, { useEffect, useState } from 'react';
+import { clsx } from 'clsx';
+import { Check, AlertCircle, Loader2, X } from 'lucide-react';
+import type { KubeClient } from '../../../api/kubeClient';
+import type { RolloutStatus } from '../../../api/types';
+
+export const ROLLOUT_KINDS = ['Deployment', 'StatefulSet', 'DaemonSet'];
+
+interface RolloutProgressProps {
+  client: KubeClient;
+  kind: string;
+  namespace: string;
+  name: string;
+  onDismiss: () => void;
+}
+
+/**
+ * kubectl-rollout-status style progress bar, shown after an edit until the
+ * rollout completes, fails or times out.
+ */
+export const RolloutProgress: React.FC<RolloutProgressProps> = ({ client, kind, namespace, name, onDismiss }) => {
+  const [status, setStatus] = useState<RolloutStatus | null>(null);
+
+  useEffect(() => {
+    setStatus(null);
+    return client.startRolloutWatch(kind, namespace, name, setStatus);
+  }, [client, kind, namespace, name]);
+
+  const desired = Math.max(status?.desired ?? 0, 1);
+  const updatedPct = Math.min(100, ((status?.updated ?? 0) / desired) * 100);
+  const availablePct = Math.min(100, ((status?.available ?? 0) / desired) * 100);
+  const phase = status?.phase ?? 'progressing';
+
+  return (
+    <div className="px-4 py-3 border-b border-slate-700/50 bg-slate-800/40 space-y-2">
+      <div className="flex items-center justify-between gap-2 text-xs">
+        <div className={clsx(
+          'flex items-center gap-2 font-medium min-w-0',
+          phase === 'complete' && 'text-emerald-400',
+          phase === 'progressing' && 'text-blue-300',
+          (phase === 'failed' || phase === 'timeout') && 'text-red-400'
+        )}>
+          {phase === 'progressing' && <Loader2 size={14} className="animate-spin shrink-0" />}
+          {phase === 'complete' && <Check size={14} className="shrink-0" />}
+          {(phase === 'failed' || phase === 'timeout') && <AlertCircle size={14} className="shrink-0" />}
+          <span className="truncate" title={status?.message}>{status?.message ?? 'Waiting for rollout status...'}</span>
+        </div>
+        <button onClick={onDismiss} className="text-slate-500 hover:text-white shrink-0" title="Dismiss">
+          <X size={14} />
+        </button>
+      </div>
+      <div className="relative h-1.5 rounded bg-slate-700 overflow-hidden">
+        <div className="absolute inset-y-0 left-0 bg-blue-500/40 transition-all" style={{ width: `${updatedPct}%` }} />
+        <div className={clsx(
+          'absolute inset-y-0 left-0 transition-all',
+          phase === 'failed' || phase === 'timeout' ? 'bg-red-500' : 'bg-emerald-500'
+        )} style={{ width: `${availablePct}%` }} />
+      </div>
+      {status && (
+        <div className="flex justify-between text-[11px] text-slate-500">
+          <span>{status.updated}/{status.desired} updated · {status.available} available{status.oldReplicas > 0 ? ` · ${status.oldReplicas} old` : ''}</span>
+          <span>{status.elapsed}</span>
+        </div>
+      )}
+    </div>
+  );
+};
@@ -2,7 +2,7 @@ import React from 'react';
 import { clsx } from 'clsx';
 import { Activity, AlertCircle } from 'lucide-react';
 
-type Health = 'ok' | 'warning' | 'error';
+type Health = 'ok' | 'warning' | 'error' | 'flapping';
 
 interface StatusPillProps {
   status: string;
@@ -14,6 +14,7 @@ const healthStyles: Record<Health, string> = {
   ok: 'bg-emerald-500/20 text-emerald-400 border-emerald-500/30',
   warning: 'bg-amber-500/20 text-amber-400 border-amber-500/30',
   error: 'bg-red-500/20 text-red-400 border-red-500/30',
+  flapping: 'bg-orange-500/20 text-orange-400 border-orange-500/30',
 };
 
 export const StatusPill: React.FC<StatusPillProps> = ({ status, health, compact }) => {
@@ -22,6 +22,8 @@ import { HelmReleaseOverview } from './HelmReleaseOverview';
 import { NetworkAttachmentDefinitionOverview } from './NetworkAttachmentDefinitionOverview';
 import { NodeNetworkConfigurationPolicyOverview } from './NodeNetworkConfigurationPolicyOverview';
 import { GenericOverview } from './GenericOverview';
+import { InfoGrid } from '../components/InfoGrid';
+import { SidebarSection } from '../components/Section';
 
 export interface OverviewContext {
   resource: ClusterResource;
@@ -33,6 +35,22 @@ export interface OverviewContext {
 }
 
 export const ResourceOverview: React.FC<OverviewContext> = (props) => {
+  const extraFields = Object.entries(props.resource.extraFields || {});
+  if (extraFields.length === 0) {
+    return <KindOverview {...props} />;
+  }
+  // CRD printer columns and configured DisplayFields, under any kind's view
+  return (
+    <div className="space-y-6">
+      <KindOverview {...props} />
+      <SidebarSection title="Fields">
+        <InfoGrid items={extraFields.map(([label, value]) => ({ label, value, mono: true }))} />
+      </SidebarSection>
+    </div>
+  );
+};
+
+const KindOverview: React.FC<OverviewContext> = (props) => {
   switch (props.resource.kind) {
     case 'Deployment':
       return <DeploymentOverview {...props} />;